@@ -0,0 +1,60 @@
+package authtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   auth.AuthConfig
+		token string
+	}{
+		{name: "unsigned", cfg: auth.AuthConfig{AllowUnsignedTokens: true}, token: TokenFor("user7", jwt.MapClaims{"scope": "favourites:read"})},
+		{name: "signed", cfg: auth.AuthConfig{Secret: "secret"}, token: SignedTokenFor("user7", "secret", jwt.MapClaims{"scope": "favourites:read"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user string
+			var scopes []string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, scopes = auth.UserIDFromContext(r.Context()), auth.ScopesFromContext(r.Context())
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			auth.JWTMiddleware(tt.cfg)(handler).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+			}
+			if user != "user7" || !slices.Equal(scopes, []string{"favourites:read"}) {
+				t.Errorf("authenticated as %q with scopes %v, want user7 with favourites:read", user, scopes)
+			}
+		})
+	}
+}
+
+func TestRequestWithUser(t *testing.T) {
+	original := httptest.NewRequest("GET", "/", nil)
+	req := RequestWithUser(original, "user7")
+
+	if got := auth.UserIDFromContext(req.Context()); got != "user7" {
+		t.Errorf("context user = %q, want user7", got)
+	}
+	rr := httptest.NewRecorder()
+	auth.JWTMiddleware(auth.AuthConfig{AllowUnsignedTokens: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("status = %d through the middleware, want 200", rr.Code)
+	}
+	if original.Header.Get("Authorization") != "" || auth.UserIDFromContext(original.Context()) != "" {
+		t.Error("expected the original request to be left unchanged")
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/backup"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+// parseGlobalFlags removes the flags the service accepts before or after a
+// subcommand from args: --skip-schema-check (or -skip-schema-check), which
+// starts the service without verifying the database schema.
+func parseGlobalFlags(args []string) (rest []string, skipSchemaCheck bool) {
+	rest = slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+		return arg == "--skip-schema-check" || arg == "-skip-schema-check"
+	})
+	return rest, len(rest) < len(args)
+}
+
+// runCommand runs a one-off maintenance subcommand against the connected
+// database instead of starting the HTTP services, through a repository
+// configured by opts. partitions is the configured number of favourites
+// partitions, applied by migrate up.
+func runCommand(ctx context.Context, logger *slog.Logger, db *sql.DB, opts database.Options, partitions int, name string, args []string) error {
+	repo := database.NewRepository(db, opts)
+	switch name {
+	case "migrate":
+		return runMigrate(ctx, logger, db, partitions, args)
+	case "backup":
+		return runBackup(ctx, logger, repo, args)
+	case "restore":
+		return runRestore(ctx, logger, repo, args)
+	case "migrate-asset-types":
+		return runMigrateAssetTypes(ctx, logger, repo, args)
+	default:
+		return fmt.Errorf("unknown command %q (expected backup, restore, migrate or migrate-asset-types)", name)
+	}
+}
+
+func runBackup(ctx context.Context, logger *slog.Logger, repo database.BackupStore, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", "-", "file to write the backup to (- for stdout)")
+	fs.StringVar(out, "o", "-", "shorthand for -out")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("creating backup file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	counts, err := backup.Write(ctx, repo, w, time.Now(), progressLogger(logger, "backup progress"))
+	if err != nil {
+		return err
+	}
+	if f, ok := w.(*os.File); ok && f != os.Stdout {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("syncing backup file: %w", err)
+		}
+	}
+
+	logger.Info("backup complete",
+		slog.String("output", *out),
+		slog.Int("assets", counts.Assets),
+		slog.Int("favourites", counts.Favourites),
+		slog.Int("versions", counts.Versions),
+		slog.Int("audit_entries", counts.AuditEntries),
+		slog.Int("preferences", counts.Preferences),
+	)
+	return nil
+}
+
+func runRestore(ctx context.Context, logger *slog.Logger, repo database.BackupStore, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("in", "-", "file to read the backup from (- for stdin)")
+	fs.StringVar(in, "i", "-", "shorthand for -in")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return fmt.Errorf("opening backup file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	counts, err := backup.Restore(ctx, repo, r, progressLogger(logger, "restore progress"))
+	if err != nil {
+		return err
+	}
+
+	logger.Info("restore complete",
+		slog.String("input", *in),
+		slog.Int("assets", counts.Assets),
+		slog.Int("favourites", counts.Favourites),
+		slog.Int("versions", counts.Versions),
+		slog.Int("audit_entries", counts.AuditEntries),
+		slog.Int("preferences", counts.Preferences),
+	)
+	if counts.Skipped > 0 {
+		logger.Warn("restore skipped records the repository does not keep", slog.Int("skipped", counts.Skipped))
+	}
+	return nil
+}
+
+// progressLogger logs the counts of a backup or restore under msg as it
+// progresses.
+func progressLogger(logger *slog.Logger, msg string) func(backup.Counts) {
+	return func(counts backup.Counts) {
+		logger.Info(msg,
+			slog.Int("assets", counts.Assets),
+			slog.Int("favourites", counts.Favourites),
+			slog.Int("versions", counts.Versions),
+			slog.Int("audit_entries", counts.AuditEntries),
+			slog.Int("preferences", counts.Preferences),
+		)
+	}
+}
+
+// runStoreCommand runs a maintenance subcommand against a repository
+// without PostgreSQL, where only backup and restore are available.
+func runStoreCommand(ctx context.Context, logger *slog.Logger, repo database.BackupStore, name string, args []string) error {
+	switch name {
+	case "backup":
+		return runBackup(ctx, logger, repo, args)
+	case "restore":
+		return runRestore(ctx, logger, repo, args)
+	case "migrate", "migrate-asset-types":
+		return fmt.Errorf("the %s command needs PostgreSQL", name)
+	default:
+		return fmt.Errorf("unknown command %q (expected backup or restore)", name)
+	}
+}
+
+// runMemoryCommand runs a maintenance subcommand without a database, against
+// the favourites in the snapshot at snapshotPath. A restore saves the
+// snapshot again, so it must run while the service using the snapshot is
+// stopped.
+func runMemoryCommand(ctx context.Context, logger *slog.Logger, snapshotPath, name string, args []string) error {
+	memory := database.NewMemoryRepository()
+	if name == "backup" || name == "restore" {
+		if snapshotPath == "" {
+			return fmt.Errorf("the %s command needs memory_snapshot_path when favourites are kept in memory", name)
+		}
+		if _, err := memory.LoadSnapshot(snapshotPath); err != nil {
+			return err
+		}
+	}
+	if err := runStoreCommand(ctx, logger, memory, name, args); err != nil {
+		return err
+	}
+	if name == "restore" {
+		return memory.SaveSnapshot(snapshotPath)
+	}
+	return nil
+}
+
+// runMigrateAssetTypes rewrites the stored favourites and catalog assets of
+// every configured alias to the type it names.
+func runMigrateAssetTypes(ctx context.Context, logger *slog.Logger, repo *database.Repository, args []string) error {
+	fs := flag.NewFlagSet("migrate-asset-types", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	aliases := assets.CurrentAliases()
+	if len(aliases) == 0 {
+		logger.Info("no asset type aliases configured; nothing to migrate")
+		return nil
+	}
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		favourites, catalog, err := repo.RenameAssetType(ctx, alias, aliases[alias])
+		if err != nil {
+			return fmt.Errorf("migrating asset type %q to %q: %w", alias, aliases[alias], err)
+		}
+		logger.Info("asset type migrated",
+			slog.String("from", string(alias)),
+			slog.String("to", string(aliases[alias])),
+			slog.Int64("favourites", favourites),
+			slog.Int64("catalog_assets", catalog),
+		)
+	}
+	return nil
+}
+
+// migrateAtStartup applies pending schema migrations or, with skip set, only
+// checks that none are pending. With partitions above zero the favourites
+// table is then partitioned, unless it is already.
+func migrateAtStartup(ctx context.Context, logger *slog.Logger, db *sql.DB, skip bool, partitions int) error {
+	if skip {
+		pending, err := database.PendingMigrations(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d schema migrations pending; run the migrate command first", len(pending))
+		}
+		return nil
+	}
+	applied, err := database.Migrate(ctx, db)
+	for _, m := range applied {
+		logger.Info("schema migration applied", slog.Int("version", m.Version), slog.String("migration", m.Name))
+	}
+	if err != nil || partitions == 0 {
+		return err
+	}
+	partitioned, err := database.PartitionFavourites(ctx, db, partitions)
+	if partitioned {
+		logger.Info("favourites table partitioned", slog.Int("count", partitions))
+	}
+	return err
+}
+
+// runMigrate applies pending schema migrations (up, the default), reverts the
+// latest ones (down) or lists the pending ones (status).
+func runMigrate(ctx context.Context, logger *slog.Logger, db *sql.DB, partitions int, args []string) error {
+	action := ""
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	steps := fs.Int("n", 1, "number of migrations to revert (down)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "", "up":
+		return migrateAtStartup(ctx, logger, db, false, partitions)
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("-n must be at least 1, got %d", *steps)
+		}
+		reverted, err := database.MigrateDown(ctx, db, *steps)
+		for _, m := range reverted {
+			logger.Info("schema migration reverted", slog.Int("version", m.Version), slog.String("migration", m.Name))
+		}
+		return err
+	case "status":
+		pending, err := database.PendingMigrations(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			logger.Info("schema migration pending", slog.Int("version", m.Version), slog.String("migration", m.Name))
+		}
+		logger.Info("schema migration status", slog.Int("count", len(pending)))
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q (expected up, down or status)", action)
+	}
+}
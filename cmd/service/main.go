@@ -2,23 +2,50 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // timezone preferences must resolve in minimal images without zoneinfo
 
 	"github.com/giannis84/platform-go-challenge/internal"
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/buildinfo"
+	"github.com/giannis84/platform-go-challenge/internal/cache"
 	"github.com/giannis84/platform-go-challenge/internal/config"
 	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/errortracking"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/health"
+	"github.com/giannis84/platform-go-challenge/internal/jobs"
 	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
 	"github.com/giannis84/platform-go-challenge/internal/routes"
+	"github.com/giannis84/platform-go-challenge/internal/secrets"
+	"github.com/giannis84/platform-go-challenge/internal/stream"
 )
 
 func main() {
-	// Initialize shared dependencies
+	// Initialize shared dependencies. Subcommands log to stderr so a backup
+	// can be written to stdout.
 	logger := logging.NewLogger()
+	args, skipSchemaCheck := parseGlobalFlags(os.Args[1:])
+	if len(args) > 0 {
+		logger = logging.NewLoggerTo(os.Stderr)
+	}
+	build := buildinfo.Get()
+	logger.Info("starting service",
+		slog.String("version", build.Version),
+		slog.String("commit", build.Commit),
+		slog.String("build_date", build.Date),
+		slog.String("go_version", build.GoVersion),
+	)
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -26,42 +53,423 @@ func main() {
 		logger.Error("failed to load configuration", slog.String(logging.ErrorKey, err.Error()))
 		os.Exit(1)
 	}
+	logging.SetRedaction(cfg.LogRedaction())
+	logging.SetLevel(cfg.MinLogLevel())
+	closeSecurityLog, err := logging.OpenSecurityLog(cfg.SecurityLog)
+	if err != nil {
+		logger.Error("failed to open security log", slog.String(logging.ErrorKey, err.Error()))
+		os.Exit(1)
+	}
+	defer closeSecurityLog()
 	logger.Info("configuration loaded",
 		slog.String("api_addr", cfg.APIAddr()),
 		slog.String("health_addr", cfg.HealthAddr()),
 	)
 
-	// Connect to PostgreSQL and initialise schema
-	db, err := database.Connect(cfg.PostgresConnString())
+	// Audience enumerations were validated by Load
+	audienceEnums, _ := cfg.AudienceEnumConfig()
+	assets.SetAudienceEnums(audienceEnums)
+
+	// Text field lengths were validated by Load
+	textLimits, _ := cfg.TextLimitConfig()
+	assets.SetTextLimits(textLimits)
+
+	assets.SetAliases(cfg.AssetTypeAliasConfig())
+
+	if cfg.EnableGenericAssets {
+		if err := assets.Enable(models.AssetTypeGeneric); err != nil {
+			logger.Error("failed to enable generic assets", slog.String(logging.ErrorKey, err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	// Column encryption keys were validated by Load
+	columnCipher, _ := cfg.ColumnCipher()
+	repoOptions := database.Options{
+		Cipher:             columnCipher,
+		AssetStorage:       database.AssetStorage(cfg.AssetStorage),
+		SlowQueryThreshold: cfg.SlowQueryThreshold,
+	}
+	if columnCipher != nil {
+		logger.Info("column encryption enabled", slog.String("key_id", cfg.ColumnEncryptionKeyID))
+	}
+
+	// Asset rules were validated by Load
+	assetRules, _ := cfg.AssetRules()
+	assets.SetRules(assetRules)
+	if len(assetRules) > 0 {
+		logger.Info("asset validation rules loaded", slog.Int("types", len(assetRules)))
+	}
+
+	// Secrets fetched from a provider are refetched every secrets_refresh, so
+	// a rotated JWT secret or database password is picked up without a restart
+	var jwtSecret, dbPassword *secrets.Value
+	connString := cfg.PostgresConnString
+	if cfg.Secrets != nil {
+		if cfg.JWTSecretRef != "" {
+			jwtSecret = secrets.NewValue(cfg.Secrets, cfg.JWTSecretRef, cfg.JWTSecret)
+		}
+		if cfg.DBPasswordRef != "" {
+			dbPassword = secrets.NewValue(cfg.Secrets, cfg.DBPasswordRef, cfg.DBPassword)
+			connString = func() string { return cfg.PostgresConnStringWithPassword(dbPassword.Get()) }
+		}
+		logger.Info("secrets provider enabled", slog.String("provider", cfg.SecretsProvider))
+	}
+
+	// The memory driver keeps favourites in this process, without a
+	// database, and the mysql driver stores them in MySQL; db is only
+	// connected to PostgreSQL
+	var db *sql.DB
+	var mysqlRepo *database.MySQLRepository
+	switch database.Driver(cfg.DBDriver) {
+	case database.DriverMemory:
+		// Maintenance subcommands (service backup|restore) run on the snapshot and exit
+		if len(args) > 0 {
+			if err := runMemoryCommand(context.Background(), logger, cfg.MemorySnapshotPath, args[0], args[1:]); err != nil {
+				logger.Error("command failed", slog.String("command", args[0]), slog.String(logging.ErrorKey, err.Error()))
+				os.Exit(1)
+			}
+			return
+		}
+	case database.DriverMySQL:
+		mysqlDB, err := database.OpenMySQL(cfg.MySQLDSN)
+		if err != nil {
+			logger.Error("failed to initialise database", slog.String(logging.ErrorKey, err.Error()))
+			os.Exit(1)
+		}
+		defer mysqlDB.Close()
+		mysqlRepo = database.NewMySQLRepository(mysqlDB)
+		if err := mysqlRepo.EnsureSchema(context.Background()); err != nil {
+			logger.Error("failed to initialise database", slog.String(logging.ErrorKey, err.Error()))
+			mysqlDB.Close()
+			os.Exit(1)
+		}
+		logger.Info("database ready")
+
+		// Maintenance subcommands (service backup|restore) run and exit
+		if len(args) > 0 {
+			if err := runStoreCommand(context.Background(), logger, mysqlRepo, args[0], args[1:]); err != nil {
+				logger.Error("command failed", slog.String("command", args[0]), slog.String(logging.ErrorKey, err.Error()))
+				mysqlDB.Close()
+				os.Exit(1)
+			}
+			return
+		}
+	default:
+		// Connect to PostgreSQL
+		db, err = database.ConnectFunc(connString, database.NewBreaker(cfg.DBBreakerThreshold, cfg.DBBreakerCooldown))
+		if err != nil {
+			logger.Error("failed to initialise database", slog.String(logging.ErrorKey, err.Error()))
+			os.Exit(1)
+		}
+		defer db.Close()
+		if dbPassword != nil {
+			// New connections use the rotated password; idle ones are replaced now
+			dbPassword.OnChange(func(string) { database.RecycleConnections(db) })
+		}
+
+		// Bring the schema up to date, unless the migrate command is run or left
+		// to run it, and check it has what the service uses
+		if len(args) == 0 || args[0] != "migrate" {
+			if err := migrateAtStartup(context.Background(), logger, db, cfg.SkipMigrations, cfg.FavouritesPartitions); err != nil {
+				logger.Error("failed to initialise database", slog.String(logging.ErrorKey, err.Error()))
+				db.Close()
+				os.Exit(1)
+			}
+			if !skipSchemaCheck && !cfg.SkipSchemaCheck {
+				if err := database.VerifySchema(context.Background(), db); err != nil {
+					logger.Error("incompatible database schema", slog.String(logging.ErrorKey, err.Error()))
+					db.Close()
+					os.Exit(1)
+				}
+			}
+		}
+		logger.Info("database ready")
+
+		// Maintenance subcommands (service backup|restore|migrate) run and exit
+		if len(args) > 0 {
+			if err := runCommand(context.Background(), logger, db, repoOptions, cfg.FavouritesPartitions, args[0], args[1:]); err != nil {
+				logger.Error("command failed", slog.String("command", args[0]), slog.String(logging.ErrorKey, err.Error()))
+				db.Close()
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	// In-process bus for favourite change notifications
+	bus := events.NewBus()
+
+	// Background workers run until main returns
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Optional read replicas for list queries, health-checked in the
+	// background; reads fall back to the primary while none is healthy
+	var replicas *database.Replicas
+	if len(cfg.DBReplicaHosts) > 0 && db != nil {
+		replicaDBs := make([]*sql.DB, len(cfg.DBReplicaHosts))
+		for i, host := range cfg.DBReplicaHosts {
+			replicaDBs[i] = database.OpenFunc(func() string {
+				password := cfg.DBPassword
+				if dbPassword != nil {
+					password = dbPassword.Get()
+				}
+				return cfg.ReplicaConnStringWithPassword(host, password)
+			}, nil)
+			if dbPassword != nil {
+				dbPassword.OnChange(func(string) { database.RecycleConnections(replicaDBs[i]) })
+			}
+		}
+		replicas = database.NewReplicas(replicaDBs...)
+		defer replicas.Close()
+		replicas.Check(bgCtx, logger)
+		repoOptions.Replicas = replicas
+		go replicas.Run(bgCtx, cfg.ReplicaCheckInterval, logger)
+		logger.Info("read replicas enabled", slog.Int("count", len(replicaDBs)), slog.Int("healthy", replicas.Healthy()))
+	}
+
+	// The routes, queued writes and exports all store favourites through
+	// the same repository. Without PostgreSQL they are kept in MySQL or in
+	// memory, loaded from the last snapshot, and the routes needing a Store
+	// answer 503.
+	var (
+		repository     *database.Repository
+		memory         *database.MemoryRepository
+		favouritesRepo database.FavouritesRepository
+		store          database.Store
+	)
+	if db != nil {
+		repository = database.NewRepository(db, repoOptions)
+		if cfg.DBPreparedStatements {
+			repository = database.NewPreparedRepository(db, repoOptions)
+			defer repository.Close()
+		}
+		favouritesRepo, store = repository, repository
+	} else if mysqlRepo != nil {
+		favouritesRepo = mysqlRepo
+		logger.Info("favourites stored in MySQL")
+	} else {
+		memory = database.NewMemoryRepository()
+		if cfg.MemorySnapshotPath != "" {
+			n, err := memory.LoadSnapshot(cfg.MemorySnapshotPath)
+			if err != nil {
+				logger.Error("failed to load favourites snapshot", slog.String(logging.ErrorKey, err.Error()))
+				os.Exit(1)
+			}
+			saveSnapshot := func() error { return memory.SaveSnapshot(cfg.MemorySnapshotPath) }
+			go jobs.RunSnapshots(bgCtx, cfg.MemorySnapshotInterval, saveSnapshot, logger)
+			logger.Info("favourites snapshot loaded", slog.String("path", cfg.MemorySnapshotPath), slog.Int("favourites", n))
+		}
+		favouritesRepo = memory
+		logger.Info("favourites kept in memory")
+	}
+	favourites := handlers.NewFavourites(favouritesRepo, store, cfg.EventOutbox)
+	if store != nil {
+		bus.Subscribe(favourites.AuditRecorder(logger))
+	}
+
+	// Optional list cache, invalidated locally from the bus and across
+	// instances via Postgres LISTEN/NOTIFY. With the event outbox on, the
+	// bus only hears of a change once the relay runs, so the repository
+	// invalidates it as each transaction commits as well.
+	var listCache *cache.ListCache
+	var invalidator *cache.Invalidator
+	if cfg.ListCacheSize > 0 && db != nil {
+		listCache = cache.NewListCache(cfg.ListCacheSize)
+		invalidator = cache.NewInvalidator(listCache, db, logger)
+		bus.Subscribe(invalidator.Handle)
+		if cfg.EventOutbox {
+			repository.OnCommit(invalidator.Handle)
+		}
+		if err := invalidator.Listen(bgCtx, connString()); err != nil {
+			logger.Error("failed to start cache invalidation listener", slog.String(logging.ErrorKey, err.Error()))
+			os.Exit(1)
+		}
+		logger.Info("list cache enabled", slog.Int("size", cfg.ListCacheSize))
+	}
+
+	// Optional store-and-forward queue for writes made while the database is unreachable
+	var writeQueue *queue.WriteQueue
+	if cfg.WriteQueuePath != "" {
+		writeQueue, err = queue.Open(cfg.WriteQueuePath, cfg.WriteQueueCapacity)
+		if err != nil {
+			logger.Error("failed to open write queue", slog.String(logging.ErrorKey, err.Error()))
+			os.Exit(1)
+		}
+		replay := favourites.ReplayQueuedFavourite(cfg.QuotaConfig(), bus, logger)
+		go writeQueue.Run(bgCtx, cfg.WriteQueueFlushInterval, replay, logger)
+		logger.Info("write queue enabled",
+			slog.String("path", cfg.WriteQueuePath),
+			slog.Int("capacity", cfg.WriteQueueCapacity),
+			slog.Int("depth", writeQueue.Stats().Depth),
+		)
+	}
+
+	// Asynchronous export jobs
+	exporter, err := jobs.NewExporter(cfg.ExportDir, cfg.ExportQueueCapacity, cfg.ExportTTL, favourites.ExportFavourites)
 	if err != nil {
-		logger.Error("failed to initialise database", slog.String(logging.ErrorKey, err.Error()))
+		logger.Error("failed to set up exports", slog.String(logging.ErrorKey, err.Error()))
 		os.Exit(1)
 	}
-	defer db.Close()
-	logger.Info("database ready")
+	go exporter.Run(bgCtx, cfg.ExportWorkers, logger)
+
+	// Deleted favourites are purged once past their retention
+	if repository != nil {
+		go jobs.RunPurge(bgCtx, "purgeDeletedFavourites", cfg.SoftDeletePurgeInterval, cfg.SoftDeleteRetention, repository.PurgeDeletedFavourites, logger)
+	}
+
+	// Optional event outbox: changes record their events in their own
+	// transaction and the relay publishes them to the bus once committed.
+	// Delivered events are kept for a day.
+	if cfg.EventOutbox {
+		relay := func(ctx context.Context, limit int) (int, error) {
+			return repository.RelayOutboxEvents(ctx, limit, func(e events.Event) { bus.Publish(ctx, e) })
+		}
+		go jobs.RunRelay(bgCtx, cfg.OutboxRelayInterval, repository.OutboxAppended(), relay, logger)
+		go jobs.RunPurge(bgCtx, "purgeDeliveredEvents", time.Hour, 24*time.Hour, repository.PurgeDeliveredEvents, logger)
+		logger.Info("event outbox enabled")
+	}
+
+	// WebSocket streams of favourite change events, closed on shutdown
+	streams := stream.NewHub(bus)
+
+	// Optional TLS, with client certificates configured per listener
+	apiTLS, err := cfg.APITLSConfig().ServerTLS()
+	if err != nil {
+		logger.Error("failed to set up API TLS", slog.String(logging.ErrorKey, err.Error()))
+		os.Exit(1)
+	}
+	healthTLS, err := cfg.HealthTLSConfig().ServerTLS()
+	if err != nil {
+		logger.Error("failed to set up health check TLS", slog.String(logging.ErrorKey, err.Error()))
+		os.Exit(1)
+	}
+
+	// Readiness reports the database and the optional subsystems, which
+	// register their checks once set up; only the critical ones fail it
+	healthChecks := health.NewRegistry(cfg.ReadinessCritical)
+	if db != nil {
+		healthChecks.Register(config.ReadinessDatabase, database.HealthCheck(db))
+	}
+	if mysqlRepo != nil {
+		healthChecks.Register(config.ReadinessDatabase, mysqlRepo.HealthCheck)
+	}
+	if replicas != nil {
+		healthChecks.Register(config.ReadinessReplicas, replicas.HealthCheck)
+	}
+	if invalidator != nil {
+		healthChecks.Register(config.ReadinessCache, invalidator.HealthCheck)
+	}
+	if writeQueue != nil {
+		healthChecks.Register(config.ReadinessWriteQueue, writeQueue.HealthCheck)
+	}
+
+	// Panics recovered by either service are reported to the webhook and
+	// the error tracker, if any; the tracker also receives the 5xx responses
+	// of the API
+	var panicAlerts []logging.PanicAlert
+	if cfg.PanicWebhookURL != "" {
+		panicAlerts = append(panicAlerts, logging.WebhookPanicAlert(cfg.PanicWebhookURL, &http.Client{Timeout: 10 * time.Second}))
+	}
+	var reportError routes.ErrorReporter
+	if cfg.ErrorTrackingDSN != "" {
+		dsn, _ := errortracking.ParseDSN(cfg.ErrorTrackingDSN) // validated by config.Load
+		tracker := errortracking.NewReporter(dsn, cfg.ErrorTrackingEnvironment, &http.Client{Timeout: 10 * time.Second})
+		go tracker.Run(bgCtx, logger)
+		panicAlerts = append(panicAlerts, tracker.PanicAlert())
+		reportError = tracker.ReportResponse
+		logger.Info("error tracking enabled", slog.String("environment", cfg.ErrorTrackingEnvironment))
+	}
+	panicAlert := logging.PanicAlerts(panicAlerts...)
 
 	// Create health check and favourites http services
 	healthService := &internal.Service{
-		Addr:         cfg.HealthAddr(),
-		Logger:       logger,
-		DB:           db,
-		Routes:       routes.RegisterHealthRoutes(cfg.RateLimitConfig()),
-		ReadTimeout:  cfg.ReadTimeout,
-		WriteTimeout: cfg.WriteTimeout,
-		IdleTimeout:  cfg.IdleTimeout,
+		Addr:                cfg.HealthAddr(),
+		Logger:              logger,
+		Routes:              routes.RegisterHealthRoutes(cfg.RateLimitConfig(), healthChecks, cfg.PprofConfig()),
+		ReadTimeout:         cfg.ReadTimeout,
+		WriteTimeout:        cfg.WriteTimeout,
+		IdleTimeout:         cfg.IdleTimeout,
+		TLS:                 healthTLS,
+		ClientCertPrincipal: cfg.ClientCertPrincipal,
+		PanicAlert:          panicAlert,
 	}
 	healthService.Init()
 
+	// A JWT secret from a secrets provider is verified as it rotates
+	authConfig := cfg.AuthConfig()
+	var rotating []*secrets.Value
+	if jwtSecret != nil {
+		authConfig.SecretFunc = jwtSecret.Get
+		rotating = append(rotating, jwtSecret)
+	}
+	if dbPassword != nil {
+		rotating = append(rotating, dbPassword)
+	}
+	if len(rotating) > 0 {
+		go secrets.Watch(bgCtx, logger, cfg.SecretsRefresh, rotating...)
+	}
+	// RS256 tokens are verified with the identity provider's published keys
+	if cfg.JWKSURL != "" {
+		authConfig.JWKS = auth.NewJWKS(cfg.JWKSURL, cfg.JWKSRefresh)
+		go authConfig.JWKS.Run(bgCtx, logger)
+		healthChecks.Register(config.ReadinessJWKS, authConfig.JWKS.HealthCheck)
+		logger.Info("JWKS verification enabled", slog.String("url", cfg.JWKSURL))
+	}
+	// Clients that keep failing authentication are slowed down, then banned
+	authConfig.Throttle = cfg.FailureThrottle()
+	// Service clients may authenticate with API keys issued by admins
+	if repository != nil {
+		authConfig.APIKeys = repository.GetAPIKeyUser
+	}
+	// Revoked token IDs, shared between instances through Redis when configured
+	if cfg.RedisURL != "" {
+		authConfig.Denylist, err = auth.NewRedisDenylist(cfg.RedisURL)
+		if err != nil {
+			logger.Error("failed to set up token revocation", slog.String(logging.ErrorKey, err.Error()))
+			os.Exit(1)
+		}
+	} else {
+		authConfig.Denylist = auth.NewMemoryDenylist()
+	}
+
+	apiRoutes := routes.RegisterFavouritesRoutes(routes.Deps{
+		Favourites:        favouritesRepo,
+		Store:             store,
+		EventOutbox:       cfg.EventOutbox,
+		Auth:              authConfig,
+		RateLimit:         cfg.RateLimitConfig(),
+		Publisher:         bus,
+		Quotas:            cfg.QuotaConfig(),
+		MaxAssetDataBytes: cfg.MaxAssetDataBytes,
+		MaxBodyBytes:      cfg.MaxBodyBytes,
+		LenientJSON:       cfg.LenientJSON,
+		RevocationTTL:     cfg.RevocationTTL,
+		ListCache:         listCache,
+		WriteQueue:        writeQueue,
+		AdminUI:           cfg.AdminUI,
+		Streams:           streams,
+		Exports:           exporter,
+		CORS:              cfg.CORSConfig(),
+		V1Sunset:          cfg.APIV1Sunset,
+		MultiTenant:       cfg.MultiTenant,
+		LogBodies:         cfg.BodyLogConfig(),
+		ReportError:       reportError,
+	})
 	apiService := &internal.Service{
-		Addr:         cfg.APIAddr(),
-		Logger:       logger,
-		DB:           db,
-		Routes:       routes.RegisterFavouritesRoutes(cfg.AuthConfig(), cfg.RateLimitConfig()),
-		ReadTimeout:  cfg.ReadTimeout,
-		WriteTimeout: cfg.WriteTimeout,
-		IdleTimeout:  cfg.IdleTimeout,
+		Addr:                cfg.APIAddr(),
+		Logger:              logger,
+		Routes:              apiRoutes,
+		ReadTimeout:         cfg.ReadTimeout,
+		WriteTimeout:        cfg.WriteTimeout,
+		IdleTimeout:         cfg.IdleTimeout,
+		TLS:                 apiTLS,
+		ClientCertPrincipal: cfg.ClientCertPrincipal,
+		PanicAlert:          panicAlert,
 	}
 	apiService.Init()
+	apiService.HTTPServer.RegisterOnShutdown(streams.Shutdown)
 
 	// Start http service threads
 	go func() {
@@ -93,5 +501,10 @@ func main() {
 	if err := healthService.HTTPServer.Shutdown(ctx); err != nil {
 		logger.Error("health service shutdown error", slog.String(logging.ErrorKey, err.Error()))
 	}
+	if memory != nil && cfg.MemorySnapshotPath != "" {
+		if err := memory.SaveSnapshot(cfg.MemorySnapshotPath); err != nil {
+			logger.Error("failed to save favourites snapshot", slog.String(logging.ErrorKey, err.Error()))
+		}
+	}
 	logger.Info("exiting...")
-}
\ No newline at end of file
+}
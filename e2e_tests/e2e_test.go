@@ -21,7 +21,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/giannis84/platform-go-challenge/authtest"
 )
 
 const (
@@ -61,19 +61,10 @@ func jwtSecret() string {
 
 // tokenForUser returns a Bearer token string for the given user.
 func tokenForUser(userID string) string {
-	claims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Hour).Unix(),
+	if secret := jwtSecret(); secret != "" {
+		return authtest.SignedTokenFor(userID, secret)
 	}
-	secret := jwtSecret()
-	if secret == "" {
-		token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
-		s, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
-		return s
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	s, _ := token.SignedString([]byte(secret))
-	return s
+	return authtest.TokenFor(userID)
 }
 
 // ---------- TestMain: wait for services before running ----------
@@ -195,7 +186,7 @@ func audiencePayload(id string) map[string]any {
 		"asset_data": map[string]any{
 			"id":                       id,
 			"gender":                   []string{"Male"},
-			"birth_country":            []string{"US", "UK"},
+			"birth_country":            []string{"US", "GB"},
 			"age_groups":               []string{"25-34"},
 			"social_media_hours_daily": "3-5",
 			"purchases_last_month":     5,
@@ -203,6 +194,22 @@ func audiencePayload(id string) map[string]any {
 	}
 }
 
+func dashboardPayload(id string) map[string]any {
+	return map[string]any{
+		"asset_type":  "dashboard",
+		"description": "Quarterly review",
+		"asset_data": map[string]any{
+			"id":    id,
+			"title": "Q1 review",
+			"widgets": []map[string]any{
+				{"asset_type": "chart", "asset_id": "e2e-chart-1", "position": map[string]int{"x": 0, "y": 0, "width": 8, "height": 4}},
+				{"asset_type": "insight", "asset_id": "e2e-insight-1", "position": map[string]int{"x": 8, "y": 0, "width": 4, "height": 4}},
+			},
+			"layout": map[string]any{"columns": 12},
+		},
+	}
+}
+
 // ---------- Tests ----------
 
 func TestAddFavourite(t *testing.T) {
@@ -234,6 +241,13 @@ func TestAddFavourite(t *testing.T) {
 			payload:    audiencePayload("e2e-audience-1"),
 			wantStatus: http.StatusCreated,
 		},
+		{
+			name:       "dashboard",
+			userID:     "e2e-add-1",
+			assetID:    "e2e-dashboard-1",
+			payload:    dashboardPayload("e2e-dashboard-1"),
+			wantStatus: http.StatusCreated,
+		},
 		{
 			name:    "invalid asset type",
 			userID:  "e2e-add-2",
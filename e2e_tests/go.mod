@@ -2,4 +2,11 @@ module github.com/giannis84/platform-go-challenge/e2e_tests
 
 go 1.25.6
 
-require github.com/golang-jwt/jwt/v5 v5.3.1
+require github.com/giannis84/platform-go-challenge v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/go-chi/chi/v5 v5.2.5 // indirect
+	github.com/golang-jwt/jwt/v5 v5.3.1 // indirect
+)
+
+replace github.com/giannis84/platform-go-challenge => ../
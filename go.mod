@@ -10,10 +10,15 @@ require github.com/lib/pq v1.11.1
 
 require github.com/golang-jwt/jwt/v5 v5.3.1
 
-require github.com/DATA-DOG/go-sqlmock v1.5.2
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/coder/websocket v1.8.14
+	github.com/go-chi/httprate v0.15.0
+	github.com/go-sql-driver/mysql v1.9.3
+)
 
 require (
-	github.com/go-chi/httprate v0.15.0 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/klauspost/cpuid/v2 v2.2.10 // indirect
 	github.com/zeebo/xxh3 v1.0.2 // indirect
 	golang.org/x/sys v0.30.0 // indirect
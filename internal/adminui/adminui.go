@@ -0,0 +1,33 @@
+// Package adminui embeds the static admin web UI used by support to inspect
+// users' favourites without direct database access.
+//
+// The UI itself holds no data: it asks for an admin JWT and calls the
+// /api/v1/admin endpoints, which enforce the admin scope.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Handler serves the UI's static assets from the root of its mount point.
+// Callers mounting it under a prefix must strip the prefix first.
+func Handler() http.Handler {
+	root, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The embedded directory is fixed at build time.
+		panic(err)
+	}
+	files := http.FileServer(http.FS(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-store")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,45 @@
+package adminui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_ServesEmbeddedAssets(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+	}{
+		{"/", "text/html"},
+		{"/app.js", "javascript"},
+		{"/style.css", "text/css"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			Handler().ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+				t.Errorf("expected Content-Type containing %q, got %q", tt.contentType, ct)
+			}
+			if rr.Header().Get("Content-Security-Policy") == "" {
+				t.Error("expected a Content-Security-Policy header")
+			}
+		})
+	}
+}
+
+func TestHandler_UnknownAsset(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/missing.js", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,113 @@
+// Favourites admin UI. Every call goes through the /api/v1/admin endpoints
+// with the admin JWT kept in sessionStorage for the lifetime of the tab.
+(function () {
+  "use strict";
+
+  const api = "/api/v1/admin";
+  const $ = (id) => document.getElementById(id);
+  let selectedUser = null;
+  let selectedFavourites = [];
+
+  function setStatus(message, isError) {
+    $("status").textContent = message;
+    $("status").className = isError ? "error" : "";
+  }
+
+  async function request(method, path, body) {
+    const token = sessionStorage.getItem("token");
+    if (!token) {
+      throw new Error("Enter an admin token first");
+    }
+    const options = {
+      method: method,
+      headers: {
+        "Authorization": "Bearer " + token,
+        "Accept": "application/json",
+      },
+    };
+    if (body !== undefined) {
+      options.headers["Content-Type"] = "application/json";
+      options.body = JSON.stringify(body);
+    }
+    const resp = await fetch(api + path, options);
+    const data = await resp.json().catch(() => ({}));
+    if (!resp.ok) {
+      throw new Error(data.error || resp.status + " " + resp.statusText);
+    }
+    return data;
+  }
+
+  async function searchUsers(query) {
+    const users = await request("GET", "/users?q=" + encodeURIComponent(query));
+    const list = $("user-list");
+    list.replaceChildren();
+    for (const user of users) {
+      const button = document.createElement("button");
+      button.type = "button";
+      button.textContent = user.user_id + " (" + user.favourites + ")";
+      button.addEventListener("click", () => run(() => showFavourites(user.user_id)));
+      const item = document.createElement("li");
+      item.appendChild(button);
+      list.appendChild(item);
+    }
+    setStatus(users.length + " user(s) found");
+  }
+
+  async function showFavourites(userID) {
+    selectedFavourites = await request("GET", "/users/" + encodeURIComponent(userID) + "/favourites");
+    selectedUser = userID;
+    $("selected-user").textContent = userID;
+    const rows = $("favourite-rows");
+    rows.replaceChildren();
+    for (const fav of selectedFavourites) {
+      const row = document.createElement("tr");
+      for (const value of [fav.id, fav.asset_type, fav.description, fav.created_at, fav.updated_at]) {
+        const cell = document.createElement("td");
+        cell.textContent = value;
+        row.appendChild(cell);
+      }
+      rows.appendChild(row);
+    }
+    $("favourites").hidden = false;
+    setStatus(selectedFavourites.length + " favourite(s) for " + userID);
+  }
+
+  function exportFavourites() {
+    const blob = new Blob([JSON.stringify(selectedFavourites, null, 2)], { type: "application/json" });
+    const link = document.createElement("a");
+    link.href = URL.createObjectURL(blob);
+    link.download = "favourites-" + selectedUser + ".json";
+    link.click();
+    URL.revokeObjectURL(link.href);
+  }
+
+  async function mergeFavourites(sourceUserID) {
+    if (!confirm("Copy every favourite of " + sourceUserID + " into " + selectedUser + "?")) {
+      return;
+    }
+    const result = await request("POST", "/users/" + encodeURIComponent(selectedUser) + "/merge",
+      { source_user_id: sourceUserID });
+    await showFavourites(selectedUser);
+    setStatus(result.merged + " favourite(s) merged from " + sourceUserID);
+  }
+
+  function run(action) {
+    action().catch((err) => setStatus(err.message, true));
+  }
+
+  $("token-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    sessionStorage.setItem("token", $("token").value.trim());
+    $("token").value = "";
+    setStatus("Token set");
+  });
+  $("search-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    run(() => searchUsers($("query").value.trim()));
+  });
+  $("export").addEventListener("click", exportFavourites);
+  $("merge-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    run(() => mergeFavourites($("merge-source").value.trim()));
+  });
+})();
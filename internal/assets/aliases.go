@@ -0,0 +1,40 @@
+package assets
+
+import (
+	"maps"
+	"sync/atomic"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// Aliases maps former asset type names to the registered types they were
+// renamed to, so clients still sending an old name keep working.
+type Aliases map[models.AssetType]models.AssetType
+
+var aliases atomic.Pointer[Aliases]
+
+// SetAliases replaces the asset type aliases. It is meant to be called once at
+// startup, before requests are served.
+func SetAliases(a Aliases) {
+	a = maps.Clone(a)
+	aliases.Store(&a)
+}
+
+// CurrentAliases returns a copy of the aliases in effect.
+func CurrentAliases() Aliases {
+	if a := aliases.Load(); a != nil {
+		return maps.Clone(*a)
+	}
+	return Aliases{}
+}
+
+// Resolve returns the type name refers to: the target of the alias called
+// name, or name itself.
+func Resolve(name models.AssetType) models.AssetType {
+	if a := aliases.Load(); a != nil {
+		if target, ok := (*a)[name]; ok {
+			return target
+		}
+	}
+	return name
+}
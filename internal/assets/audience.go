@@ -0,0 +1,140 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// AudienceEnums holds the allowed values of the enumerated audience fields.
+// Markets define segments differently, so they can be configured.
+type AudienceEnums struct {
+	Gender           []string
+	AgeGroups        []string
+	SocialMediaHours []string
+}
+
+// DefaultAudienceEnums returns the values allowed when nothing is configured.
+func DefaultAudienceEnums() AudienceEnums {
+	return AudienceEnums{
+		Gender:           []string{"Male", "Female"},
+		AgeGroups:        []string{"18-24", "25-34", "35-44", "45-54", "55+"},
+		SocialMediaHours: []string{"0-1", "1-3", "3-5", "5+"},
+	}
+}
+
+var audienceEnums atomic.Pointer[AudienceEnums]
+
+// SetAudienceEnums replaces the allowed audience values. Empty lists keep
+// their defaults. It is meant to be called once at startup, before requests
+// are served.
+func SetAudienceEnums(e AudienceEnums) {
+	defaults := DefaultAudienceEnums()
+	if len(e.Gender) == 0 {
+		e.Gender = defaults.Gender
+	}
+	if len(e.AgeGroups) == 0 {
+		e.AgeGroups = defaults.AgeGroups
+	}
+	if len(e.SocialMediaHours) == 0 {
+		e.SocialMediaHours = defaults.SocialMediaHours
+	}
+	audienceEnums.Store(&e)
+}
+
+// CurrentAudienceEnums returns the allowed audience values in effect.
+func CurrentAudienceEnums() AudienceEnums {
+	return *audienceEnums.Load()
+}
+
+func init() {
+	SetAudienceEnums(AudienceEnums{})
+	Register(Type{
+		Name:      models.AssetTypeAudience,
+		New:       func() models.Asset { return &models.Audience{} },
+		Validate:  func(a models.Asset, v Validator) error { return validateAudience(a.(*models.Audience), v.Audience) },
+		Normalize: func(a models.Asset) { normalizeAudience(a.(*models.Audience)) },
+		Schema:    audienceSchema,
+	})
+}
+
+// audienceSchema documents the audience payload with the configured enums.
+func audienceSchema() json.RawMessage {
+	e := CurrentAudienceEnums()
+	enum := func(values []string) string {
+		b, _ := json.Marshal(values)
+		return string(b)
+	}
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"description": "An audience segment asset.",
+		"properties": {
+			"id": {"type": "string"},
+			"gender": {"type": "array", "items": {"type": "string", "enum": %s}},
+			"birth_country": {"type": "array", "items": {"type": "string", "description": "ISO 3166-1 alpha-2 or alpha-3 code in any case; returned as upper-case alpha-2"}},
+			"age_groups": {"type": "array", "items": {"type": "string", "enum": %s}},
+			"social_media_hours_daily": {"type": "string", "enum": %s},
+			"purchases_last_month": {"type": "integer", "description": "Must be non-negative"},
+			"thumbnail_url": {"type": "string", "format": "uri", "maxLength": 2048, "description": "Absolute http(s) URL of a preview image"}
+		},
+		"required": ["id"]
+	}`, enum(e.Gender), enum(e.AgeGroups), enum(e.SocialMediaHours)))
+}
+
+// validateAudience validates an Audience asset. Only ID is required;
+// other fields are optional but validated when provided.
+func validateAudience(a *models.Audience, enums AudienceEnums) error {
+	checks := []func() string{
+		func() string { return RequireNonEmpty("id", a.ID) },
+		func() string { return CheckMaxLength("id", a.ID, MaxStringLength) },
+		func() string { return CheckNonNegative("purchases_last_month", a.PurchasesLastMonth) },
+		func() string { return checkThumbnailURL(a.ThumbnailURL) },
+	}
+
+	for i, g := range a.Gender {
+		checks = append(checks, func() string {
+			return CheckInList(fmt.Sprintf("gender[%d]", i), g, enums.Gender)
+		})
+	}
+
+	for i, c := range a.BirthCountry {
+		checks = append(checks, func() string {
+			return checkCountryCode(fmt.Sprintf("birth_country[%d]", i), c)
+		})
+	}
+
+	for i, ag := range a.AgeGroups {
+		checks = append(checks, func() string {
+			return CheckInList(fmt.Sprintf("age_groups[%d]", i), ag, enums.AgeGroups)
+		})
+	}
+
+	if a.SocialMediaHoursDaily != "" {
+		checks = append(checks, func() string {
+			return CheckInList("social_media_hours_daily", a.SocialMediaHoursDaily, enums.SocialMediaHours)
+		})
+	}
+
+	return Validate(checks...)
+}
+
+// normalizeAudience replaces known country codes with their alpha-2 form.
+func normalizeAudience(a *models.Audience) {
+	for i, c := range a.BirthCountry {
+		if canonical, ok := CanonicalCountryCode(c); ok {
+			a.BirthCountry[i] = canonical
+		}
+	}
+}
+
+func checkCountryCode(field, value string) string {
+	if msg := RequireNonEmpty(field, value); msg != "" {
+		return msg
+	}
+	if _, ok := CanonicalCountryCode(value); !ok {
+		return fmt.Sprintf("%s has invalid value %q (expected an ISO 3166-1 alpha-2 or alpha-3 code)", field, value)
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// Charts saved before chart_type existed have none, so it stays optional;
+// the frontend picks its default rendering for them.
+var (
+	validChartTypes   = []string{"bar", "line", "pie", "scatter"}
+	validAxisFormats  = []string{"number", "percent", "currency", "date", "time"}
+	maxAxisUnitLength = 32
+	maxChartSeries    = 20
+	maxSeriesPoints   = 1000
+)
+
+func init() {
+	Register(Type{
+		Name:     models.AssetTypeChart,
+		New:      func() models.Asset { return &models.Chart{} },
+		Validate: func(a models.Asset, v Validator) error { return validateChart(a.(*models.Chart), v.Text) },
+		Schema:   chartSchema,
+	})
+}
+
+// chartSchema documents the chart payload with the configured title length.
+func chartSchema() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"description": "A chart asset.",
+		"properties": {
+			"id": {"type": "string"},
+			"title": {"type": "string", "maxLength": %d},
+			"chart_type": {"type": "string", "enum": ["bar", "line", "pie", "scatter"], "description": "How the chart is rendered"},
+			"x_axis_title": {"type": "string"},
+			"y_axis_title": {"type": "string"},
+			"x_axis_unit": {"type": "string", "maxLength": 32, "description": "Unit of the x axis values, e.g. USD or %%"},
+			"y_axis_unit": {"type": "string", "maxLength": 32, "description": "Unit of the y axis values, e.g. USD or %%"},
+			"x_axis_format": {"type": "string", "enum": ["number", "percent", "currency", "date", "time"], "description": "Formatting hint for x axis values"},
+			"y_axis_format": {"type": "string", "enum": ["number", "percent", "currency", "date", "time"], "description": "Formatting hint for y axis values"},
+			"data": {"type": "object", "additionalProperties": {}, "description": "Arbitrary chart data points. Mutually exclusive with series"},
+			"series": {
+				"type": "array",
+				"description": "Structured chart data (at most 20 series of 1 to 1000 points). Mutually exclusive with data",
+				"items": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string", "maxLength": 255},
+						"points": {
+							"type": "array",
+							"items": {
+								"type": "object",
+								"properties": {
+									"x": {"description": "Category (string) or number"},
+									"y": {"type": "number"}
+								},
+								"required": ["x", "y"]
+							}
+						}
+					},
+					"required": ["name", "points"]
+				}
+			},
+			"thumbnail_url": {"type": "string", "format": "uri", "maxLength": 2048, "description": "Absolute http(s) URL of a preview image"}
+		},
+		"required": ["id", "title", "x_axis_title", "y_axis_title"]
+	}`, CurrentTextLimits().Title))
+}
+
+// validateChart validates required fields and length constraints for a Chart asset.
+func validateChart(c *models.Chart, limits TextLimits) error {
+	return Validate(
+		func() string { return RequireNonEmpty("id", c.ID) },
+		func() string { return CheckMaxLength("id", c.ID, MaxStringLength) },
+		func() string { return RequireNonEmpty("title", c.Title) },
+		func() string { return CheckMaxLength("title", c.Title, limits.Title) },
+		func() string { return RequireNonEmpty("x_axis_title", c.XAxisTitle) },
+		func() string { return CheckMaxLength("x_axis_title", c.XAxisTitle, MaxStringLength) },
+		func() string { return RequireNonEmpty("y_axis_title", c.YAxisTitle) },
+		func() string { return CheckMaxLength("y_axis_title", c.YAxisTitle, MaxStringLength) },
+		func() string { return checkOptionalInList("chart_type", c.ChartType, validChartTypes) },
+		func() string { return CheckMaxLength("x_axis_unit", c.XAxisUnit, maxAxisUnitLength) },
+		func() string { return CheckMaxLength("y_axis_unit", c.YAxisUnit, maxAxisUnitLength) },
+		func() string { return checkOptionalInList("x_axis_format", c.XAxisFormat, validAxisFormats) },
+		func() string { return checkOptionalInList("y_axis_format", c.YAxisFormat, validAxisFormats) },
+		func() string { return checkSeries(c) },
+		func() string { return checkThumbnailURL(c.ThumbnailURL) },
+	)
+}
+
+// checkSeries validates the structured series of a chart, reporting the first
+// problem found.
+func checkSeries(c *models.Chart) string {
+	if len(c.Series) == 0 {
+		return ""
+	}
+	if len(c.Data) > 0 {
+		return "data and series are mutually exclusive"
+	}
+	if len(c.Series) > maxChartSeries {
+		return fmt.Sprintf("series exceeds maximum of %d entries", maxChartSeries)
+	}
+	for i, s := range c.Series {
+		field := fmt.Sprintf("series[%d]", i)
+		if msg := RequireNonEmpty(field+".name", s.Name); msg != "" {
+			return msg
+		}
+		if msg := CheckMaxLength(field+".name", s.Name, MaxStringLength); msg != "" {
+			return msg
+		}
+		if len(s.Points) == 0 {
+			return field + ".points must not be empty"
+		}
+		if len(s.Points) > maxSeriesPoints {
+			return fmt.Sprintf("%s.points exceeds maximum of %d entries", field, maxSeriesPoints)
+		}
+		for j, p := range s.Points {
+			point := fmt.Sprintf("%s.points[%d]", field, j)
+			switch x := p.X.(type) {
+			case float64:
+			case string:
+				if msg := CheckMaxLength(point+".x", x, MaxStringLength); msg != "" {
+					return msg
+				}
+			case nil:
+				return point + ".x is required"
+			default:
+				return point + ".x must be a number or a string"
+			}
+			if p.Y == nil {
+				return point + ".y is required"
+			}
+		}
+	}
+	return ""
+}
+
+// checkOptionalInList is CheckInList for fields that may be left empty.
+func checkOptionalInList(field, value string, allowed []string) string {
+	if value == "" {
+		return ""
+	}
+	return CheckInList(field, value, allowed)
+}
@@ -0,0 +1,37 @@
+package assets
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// iso3166 is the ISO 3166-1 country table: alpha-2 code, alpha-3 code, name.
+//
+//go:embed iso3166.csv
+var iso3166 string
+
+// countryCodes maps every upper-case alpha-2 and alpha-3 code to its alpha-2
+// code, the canonical form.
+var countryCodes = loadCountryCodes(iso3166)
+
+func loadCountryCodes(table string) map[string]string {
+	records, err := csv.NewReader(strings.NewReader(table)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("assets: parsing ISO 3166 table: %v", err))
+	}
+	codes := make(map[string]string, 2*len(records))
+	for _, r := range records[1:] {
+		codes[r[0]] = r[0]
+		codes[r[1]] = r[0]
+	}
+	return codes
+}
+
+// CanonicalCountryCode returns the ISO 3166-1 alpha-2 code for an alpha-2 or
+// alpha-3 code in any case.
+func CanonicalCountryCode(code string) (string, bool) {
+	canonical, ok := countryCodes[strings.ToUpper(strings.TrimSpace(code))]
+	return canonical, ok
+}
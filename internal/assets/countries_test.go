@@ -0,0 +1,34 @@
+package assets
+
+import "testing"
+
+func TestCanonicalCountryCode(t *testing.T) {
+	tests := []struct {
+		code   string
+		want   string
+		wantOK bool
+	}{
+		{code: "GR", want: "GR", wantOK: true},
+		{code: "gr", want: "GR", wantOK: true},
+		{code: "GRC", want: "GR", wantOK: true},
+		{code: " deu ", want: "DE", wantOK: true},
+		{code: "UK"},
+		{code: "Greece"},
+		{code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, ok := CanonicalCountryCode(tt.code)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("CanonicalCountryCode(%q) = %q, %v; want %q, %v", tt.code, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCountryTableIsComplete(t *testing.T) {
+	if got := len(countryCodes); got != 2*249 {
+		t.Errorf("expected 249 countries with two codes each, got %d codes", got)
+	}
+}
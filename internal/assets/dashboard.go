@@ -0,0 +1,120 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+const (
+	maxDashboardWidgets = 50
+	maxDashboardColumns = 24
+)
+
+// Dashboards show single assets; they cannot contain other dashboards.
+var validWidgetAssetTypes = []string{string(models.AssetTypeChart), string(models.AssetTypeInsight), string(models.AssetTypeAudience)}
+
+func init() {
+	Register(Type{
+		Name:     models.AssetTypeDashboard,
+		New:      func() models.Asset { return &models.Dashboard{} },
+		Validate: func(a models.Asset, v Validator) error { return validateDashboard(a.(*models.Dashboard), v.Text) },
+		Schema:   dashboardSchema,
+	})
+}
+
+// dashboardSchema documents the dashboard payload with the configured title length.
+func dashboardSchema() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"description": "A dashboard asset: a grid of widgets, each referencing a chart, insight or audience.",
+		"properties": {
+			"id": {"type": "string"},
+			"title": {"type": "string", "maxLength": %d},
+			"widgets": {
+				"type": "array",
+				"description": "At most 50; each asset may appear once",
+				"items": {
+					"type": "object",
+					"properties": {
+						"asset_type": {"type": "string", "enum": ["chart", "insight", "audience"]},
+						"asset_id": {"type": "string"},
+						"position": {
+							"type": "object",
+							"description": "Cell range in grid units; must not be negative, and x + width must fit within layout.columns when set",
+							"properties": {
+								"x": {"type": "integer"},
+								"y": {"type": "integer"},
+								"width": {"type": "integer"},
+								"height": {"type": "integer"}
+							}
+						}
+					},
+					"required": ["asset_type", "asset_id"]
+				}
+			},
+			"layout": {
+				"type": "object",
+				"properties": {
+					"columns": {"type": "integer", "description": "Grid width, 1-24 (0 or omitted: unspecified)"}
+				}
+			}
+		},
+		"required": ["id", "title"]
+	}`, CurrentTextLimits().Title))
+}
+
+// validateDashboard validates a Dashboard asset. Widgets must reference a
+// chart, insight or audience at most once each and, when layout.columns is
+// set, fit within the grid's width.
+func validateDashboard(d *models.Dashboard, limits TextLimits) error {
+	checks := []func() string{
+		func() string { return RequireNonEmpty("id", d.ID) },
+		func() string { return CheckMaxLength("id", d.ID, MaxStringLength) },
+		func() string { return RequireNonEmpty("title", d.Title) },
+		func() string { return CheckMaxLength("title", d.Title, limits.Title) },
+		func() string {
+			if len(d.Widgets) > maxDashboardWidgets {
+				return fmt.Sprintf("widgets exceeds maximum of %d", maxDashboardWidgets)
+			}
+			return ""
+		},
+		func() string {
+			if d.Layout.Columns < 0 || d.Layout.Columns > maxDashboardColumns {
+				return fmt.Sprintf("layout.columns must be between 0 and %d", maxDashboardColumns)
+			}
+			return ""
+		},
+	}
+
+	seen := make(map[models.DashboardWidget]bool, len(d.Widgets))
+	for i, w := range d.Widgets {
+		field := fmt.Sprintf("widgets[%d]", i)
+		ref := models.DashboardWidget{AssetType: w.AssetType, AssetID: w.AssetID}
+		checks = append(checks,
+			func() string { return CheckInList(field+".asset_type", string(w.AssetType), validWidgetAssetTypes) },
+			func() string { return RequireNonEmpty(field+".asset_id", w.AssetID) },
+			func() string { return CheckMaxLength(field+".asset_id", w.AssetID, MaxStringLength) },
+			func() string {
+				if seen[ref] {
+					return fmt.Sprintf("%s duplicates %s %q", field, w.AssetType, w.AssetID)
+				}
+				seen[ref] = true
+				return ""
+			},
+			func() string { return CheckNonNegative(field+".position.x", w.Position.X) },
+			func() string { return CheckNonNegative(field+".position.y", w.Position.Y) },
+			func() string { return CheckNonNegative(field+".position.width", w.Position.Width) },
+			func() string { return CheckNonNegative(field+".position.height", w.Position.Height) },
+			func() string {
+				if d.Layout.Columns > 0 && w.Position.X+w.Position.Width > d.Layout.Columns {
+					return fmt.Sprintf("%s.position exceeds the %d layout columns", field, d.Layout.Columns)
+				}
+				return ""
+			},
+		)
+	}
+
+	return Validate(checks...)
+}
@@ -0,0 +1,53 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// The generic type lets teams favourite asset kinds the service does not model
+// yet. It is opt-in (see config enable_generic_assets); the size of its payload
+// is bounded by the asset data limit like every other type.
+func init() {
+	Register(Type{
+		Name:     models.AssetTypeGeneric,
+		New:      func() models.Asset { return &models.Generic{} },
+		Validate: func(a models.Asset, v Validator) error { return validateGeneric(a.(*models.Generic), v.Text) },
+		Schema:   genericSchema,
+		OptIn:    true,
+	})
+}
+
+// genericSchema documents the generic payload with the configured title length.
+func genericSchema() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"description": "An asset of a kind the service does not model, stored as given. Only available when enabled in the configuration.",
+		"properties": {
+			"id": {"type": "string"},
+			"title": {"type": "string", "maxLength": %d},
+			"payload": {"description": "Arbitrary JSON value, not null"}
+		},
+		"required": ["id", "title", "payload"]
+	}`, CurrentTextLimits().Title))
+}
+
+// validateGeneric validates the id and title of a Generic asset and that it
+// carries a payload.
+func validateGeneric(g *models.Generic, limits TextLimits) error {
+	return Validate(
+		func() string { return RequireNonEmpty("id", g.ID) },
+		func() string { return CheckMaxLength("id", g.ID, MaxStringLength) },
+		func() string { return RequireNonEmpty("title", g.Title) },
+		func() string { return CheckMaxLength("title", g.Title, limits.Title) },
+		func() string {
+			if len(g.Payload) == 0 || bytes.Equal(g.Payload, []byte("null")) {
+				return "payload is required"
+			}
+			return ""
+		},
+	)
+}
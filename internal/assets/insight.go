@@ -0,0 +1,86 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+const (
+	maxSourceURLLength = 2048
+	maxInsightTags     = 20
+	maxTagLength       = 50
+)
+
+func init() {
+	Register(Type{
+		Name:     models.AssetTypeInsight,
+		New:      func() models.Asset { return &models.Insight{} },
+		Validate: func(a models.Asset, v Validator) error { return validateInsight(a.(*models.Insight), v.Text) },
+		Schema:   insightSchema,
+	})
+}
+
+// insightSchema documents the insight payload with the configured text length.
+func insightSchema() json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"type": "object",
+		"description": "An insight asset.",
+		"properties": {
+			"id": {"type": "string"},
+			"text": {"type": "string", "maxLength": %d},
+			"source_url": {"type": "string", "format": "uri", "maxLength": 2048, "description": "Absolute http(s) URL of the data the insight is based on"},
+			"tags": {"type": "array", "maxItems": 20, "items": {"type": "string", "maxLength": 50}, "description": "Distinct, non-empty tags"},
+			"confidence": {"type": "number", "minimum": 0, "maximum": 1, "description": "How confident the author is in the insight, from 0 to 1"},
+			"thumbnail_url": {"type": "string", "format": "uri", "maxLength": 2048, "description": "Absolute http(s) URL of a preview image"}
+		},
+		"required": ["id", "text"]
+	}`, CurrentTextLimits().Text))
+}
+
+// validateInsight validates required fields and length constraints for an Insight asset.
+func validateInsight(i *models.Insight, limits TextLimits) error {
+	return Validate(
+		func() string { return RequireNonEmpty("id", i.ID) },
+		func() string { return CheckMaxLength("id", i.ID, MaxStringLength) },
+		func() string { return RequireNonEmpty("text", i.Text) },
+		func() string { return CheckMaxLength("text", i.Text, limits.Text) },
+		func() string { return CheckSourceURL(i.SourceURL) },
+		func() string { return checkThumbnailURL(i.ThumbnailURL) },
+		func() string { return checkTags(i.Tags) },
+		func() string { return checkConfidence(i.Confidence) },
+	)
+}
+
+// CheckSourceURL accepts an empty value or an absolute http(s) URL.
+func CheckSourceURL(v string) string {
+	return CheckHTTPURL("source_url", v, maxSourceURLLength)
+}
+
+func checkTags(tags []string) string {
+	if len(tags) > maxInsightTags {
+		return fmt.Sprintf("tags exceeds maximum of %d entries", maxInsightTags)
+	}
+	for i, tag := range tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		if msg := RequireNonEmpty(field, tag); msg != "" {
+			return msg
+		}
+		if msg := CheckMaxLength(field, tag, maxTagLength); msg != "" {
+			return msg
+		}
+		if slices.Contains(tags[:i], tag) {
+			return fmt.Sprintf("%s duplicates tag %q", field, tag)
+		}
+	}
+	return ""
+}
+
+func checkConfidence(c *float64) string {
+	if c != nil && (*c < 0 || *c > 1) {
+		return "confidence must be between 0 and 1"
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package assets
+
+import "sync/atomic"
+
+// TextLimits holds the maximum lengths of the free-text fields that can be
+// configured. Other fields keep MaxStringLength.
+type TextLimits struct {
+	Description int // Description of a favourite
+	Title       int // Title of a chart, dashboard or generic asset
+	Text        int // Text of an insight
+}
+
+// DefaultTextLimits returns the limits in effect when nothing is configured.
+func DefaultTextLimits() TextLimits {
+	return TextLimits{
+		Description: MaxStringLength,
+		Title:       MaxStringLength,
+		Text:        MaxStringLength,
+	}
+}
+
+var textLimits atomic.Pointer[TextLimits]
+
+// SetTextLimits replaces the maximum text lengths. Zero limits keep their
+// defaults. It is meant to be called once at startup, before requests are
+// served.
+func SetTextLimits(l TextLimits) {
+	defaults := DefaultTextLimits()
+	if l.Description == 0 {
+		l.Description = defaults.Description
+	}
+	if l.Title == 0 {
+		l.Title = defaults.Title
+	}
+	if l.Text == 0 {
+		l.Text = defaults.Text
+	}
+	textLimits.Store(&l)
+}
+
+// CurrentTextLimits returns the maximum text lengths in effect. It falls back
+// to the defaults until SetTextLimits is called, as type registration already
+// needs them to render the schemas.
+func CurrentTextLimits() TextLimits {
+	if l := textLimits.Load(); l != nil {
+		return *l
+	}
+	return DefaultTextLimits()
+}
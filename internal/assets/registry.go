@@ -0,0 +1,229 @@
+// Package assets is the registry of asset types users can favourite. Each
+// type registers, from its own file, how its payload is decoded, validated and
+// documented, so adding a type touches a single file.
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ErrUnknownType is returned for asset types that are not registered.
+var ErrUnknownType = errors.New("unknown asset type")
+
+// Type describes a registered asset type.
+type Type struct {
+	Name models.AssetType
+	// New returns an empty payload to decode JSON into.
+	New func() models.Asset
+	// Validate checks a decoded payload against the limits of v, returning a
+	// *ValidationError.
+	Validate func(a models.Asset, v Validator) error
+	// Normalize, when set, rewrites a decoded payload into its canonical form
+	// (e.g. code case). It must leave values it does not recognise untouched
+	// for Validate to report.
+	Normalize func(models.Asset)
+	// Schema returns the OpenAPI schema of the payload, embedded in the
+	// generated spec. It is a function so schemas can follow configuration.
+	Schema func() json.RawMessage
+	// OptIn types are off until Enable is called. They always decode, so
+	// favourites stored while a type was enabled stay readable, but they are
+	// left out of Types and Names and Enabled reports false for them.
+	OptIn bool
+}
+
+var (
+	mu         sync.RWMutex
+	registry   = make(map[models.AssetType]Type)
+	enabled    = make(map[models.AssetType]bool)
+	validators = make(map[models.AssetType][]func(models.Asset) error)
+)
+
+// Register adds an asset type. It is meant to be called from init and panics
+// on incomplete or duplicate registrations.
+func Register(t Type) {
+	if t.Name == "" || t.New == nil || t.Validate == nil || t.Schema == nil || !json.Valid(t.Schema()) {
+		panic(fmt.Sprintf("assets: incomplete registration of %q", t.Name))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[t.Name]; ok {
+		panic(fmt.Sprintf("assets: %q registered twice", t.Name))
+	}
+	registry[t.Name] = t
+}
+
+// StaticSchema returns a Type.Schema for a schema that never changes.
+func StaticSchema(schema string) func() json.RawMessage {
+	return func() json.RawMessage { return json.RawMessage(schema) }
+}
+
+// Lookup returns the registered type called name.
+func Lookup(name models.AssetType) (Type, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := registry[name]
+	if !ok {
+		return Type{}, fmt.Errorf("%w: %q", ErrUnknownType, name)
+	}
+	return t, nil
+}
+
+// AddValidator adds a check to the type called name, run by ValidateAsset
+// after the built-in validator and rule. It lets an application enforce its
+// own rules, such as company-specific ID formats, without changing the type's
+// file. Like Type.Validate, v reports failures as a *ValidationError; any
+// other error is returned as is. It is meant to be called at startup.
+func AddValidator(name models.AssetType, v func(models.Asset) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, name)
+	}
+	validators[name] = append(validators[name], v)
+	return nil
+}
+
+// Enable turns on the opt-in type called name.
+func Enable(name models.AssetType) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownType, name)
+	}
+	enabled[name] = true
+	return nil
+}
+
+// Enabled reports whether new payloads of the type called name are accepted:
+// it is registered and, if opt-in, enabled.
+func Enabled(name models.AssetType) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := registry[name]
+	return ok && (!t.OptIn || enabled[name])
+}
+
+// Types returns every enabled type, sorted by name.
+func Types() []Type {
+	mu.RLock()
+	defer mu.RUnlock()
+	types := make([]Type, 0, len(registry))
+	for _, t := range registry {
+		if t.OptIn && !enabled[t.Name] {
+			continue
+		}
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(a, b Type) int {
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	return types
+}
+
+// Names returns the names of every enabled type, sorted.
+func Names() []models.AssetType {
+	types := Types()
+	names := make([]models.AssetType, len(types))
+	for i, t := range types {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Decode unmarshals data into the payload of the named type and normalises it.
+// It does not validate the payload.
+func Decode(name models.AssetType, data []byte) (models.Asset, error) {
+	t, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	asset := t.New()
+	if err := json.Unmarshal(data, asset); err != nil {
+		return nil, fmt.Errorf("invalid %s data: %w", name, err)
+	}
+	if t.Normalize != nil {
+		t.Normalize(asset)
+	}
+	return asset, nil
+}
+
+// Validator holds the limits and rules payloads are validated against. It
+// is the models.Validator passed to Asset.Validate.
+type Validator struct {
+	Text     TextLimits
+	Audience AudienceEnums
+	// Rules holds the rule of each type that has one (see LoadRules).
+	Rules map[models.AssetType]*Rule
+}
+
+// CurrentValidator returns the Validator of the configured limits and rules,
+// those of SetTextLimits, SetAudienceEnums and SetRules.
+func CurrentValidator() Validator {
+	v := Validator{Text: CurrentTextLimits(), Audience: CurrentAudienceEnums()}
+	if r := rules.Load(); r != nil {
+		v.Rules = *r
+	}
+	return v
+}
+
+// ValidateAsset validates asset with CurrentValidator.
+func ValidateAsset(asset models.Asset) error {
+	return CurrentValidator().ValidateAsset(asset)
+}
+
+// ValidateAsset validates asset with the validator of its type, the rule of
+// its type in v.Rules, if any, and the validators added with AddValidator, in
+// that order. The messages of all of them are reported in one
+// *ValidationError.
+func (v Validator) ValidateAsset(asset models.Asset) error {
+	t, err := Lookup(asset.GetType())
+	if err != nil {
+		return err
+	}
+
+	var msgs []string
+	collect := func(err error) error {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			msgs = append(msgs, validationErr.Errors...)
+			return nil
+		}
+		return err
+	}
+
+	if err := collect(t.Validate(asset, v)); err != nil {
+		return err
+	}
+	if rule := v.Rules[t.Name]; rule != nil {
+		ruleMsgs, err := rule.check(asset)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, ruleMsgs...)
+	}
+	mu.RLock()
+	extra := validators[t.Name]
+	mu.RUnlock()
+	for _, check := range extra {
+		if err := collect(check(asset)); err != nil {
+			return err
+		}
+	}
+
+	if len(msgs) > 0 {
+		return &ValidationError{Errors: msgs}
+	}
+	return nil
+}
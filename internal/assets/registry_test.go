@@ -0,0 +1,166 @@
+package assets
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestNames(t *testing.T) {
+	want := []models.AssetType{models.AssetTypeAudience, models.AssetTypeChart, models.AssetTypeDashboard, models.AssetTypeInsight}
+	if got := Names(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	asset, err := Decode(models.AssetTypeInsight, []byte(`{"id":"i1","text":"insight"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if insight, ok := asset.(*models.Insight); !ok || insight.ID != "i1" {
+		t.Errorf("unexpected asset %#v", asset)
+	}
+
+	_, err = Decode("widget", []byte(`{}`))
+	if !errors.Is(err, ErrUnknownType) || !strings.Contains(err.Error(), `"widget"`) {
+		t.Errorf("expected ErrUnknownType naming the type, got %v", err)
+	}
+
+	_, err = Decode(models.AssetTypeChart, []byte(`{"id": 1}`))
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid chart data") {
+		t.Errorf("expected an invalid chart data error, got %v", err)
+	}
+}
+
+func TestDecode_Normalizes(t *testing.T) {
+	asset, err := Decode(models.AssetTypeAudience, []byte(`{"id":"a1","birth_country":["grc","us","Narnia"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := asset.(*models.Audience).BirthCountry
+	if !slices.Equal(got, []string{"GR", "US", "Narnia"}) {
+		t.Errorf("expected known codes as alpha-2 and unknown ones untouched, got %v", got)
+	}
+}
+
+func TestValidateAsset(t *testing.T) {
+	err := ValidateAsset(&models.Insight{ID: "i1"})
+	assertValidation(t, err, true, "text is required")
+
+	if err := ValidateAsset(&models.Chart{ID: "c1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAssetValidate(t *testing.T) {
+	v := CurrentValidator()
+	var asset models.Asset = &models.Insight{ID: "i1"}
+	assertValidation(t, asset.Validate(v), true, "text is required")
+
+	favourite := &models.FavouriteAsset{Data: &models.Chart{ID: "c1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y"}}
+	if err := favourite.Validate(v); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Limits and rules apply as given, whatever is configured
+	v.Text.Text = 3
+	assertValidation(t, (&models.Insight{ID: "i1", Text: "four"}).Validate(v), true, "text exceeds maximum length of 3")
+}
+
+func TestOptInTypes(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(enabled, models.AssetTypeGeneric)
+		mu.Unlock()
+	})
+
+	if Enabled(models.AssetTypeGeneric) || slices.Contains(Names(), models.AssetTypeGeneric) {
+		t.Fatal("expected generic to be off by default")
+	}
+	asset, err := Decode(models.AssetTypeGeneric, []byte(`{"id":"g1","title":"Funnel","payload":{"steps":3}}`))
+	if err != nil {
+		t.Fatalf("expected disabled types to decode, got %v", err)
+	}
+	if g, ok := asset.(*models.Generic); !ok || string(g.Payload) != `{"steps":3}` {
+		t.Errorf("unexpected asset %#v", asset)
+	}
+
+	if err := Enable(models.AssetTypeGeneric); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Enabled(models.AssetTypeGeneric) || !slices.Contains(Names(), models.AssetTypeGeneric) {
+		t.Error("expected generic to be listed once enabled")
+	}
+	if err := Enable("widget"); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestRegister_RejectsDuplicatesAndIncompleteTypes(t *testing.T) {
+	expectPanic := func(name string, typ Type) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected Register to panic", name)
+			}
+		}()
+		Register(typ)
+	}
+
+	chart, _ := Lookup(models.AssetTypeChart)
+	expectPanic("duplicate", chart)
+	expectPanic("missing validator", Type{Name: "widget", New: chart.New, Schema: chart.Schema})
+	expectPanic("invalid schema", Type{Name: "widget", New: chart.New, Validate: chart.Validate, Schema: StaticSchema("{")})
+}
+
+func TestResolve(t *testing.T) {
+	SetAliases(Aliases{"segment": models.AssetTypeAudience})
+	t.Cleanup(func() { SetAliases(nil) })
+
+	if got := Resolve("segment"); got != models.AssetTypeAudience {
+		t.Errorf("expected the alias to resolve to audience, got %q", got)
+	}
+	if got := Resolve(models.AssetTypeChart); got != models.AssetTypeChart {
+		t.Errorf("expected a registered type to resolve to itself, got %q", got)
+	}
+	if got := Resolve("widget"); got != "widget" {
+		t.Errorf("expected an unknown name to be kept, got %q", got)
+	}
+}
+
+func TestAddValidator(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		delete(validators, models.AssetTypeInsight)
+		mu.Unlock()
+	})
+
+	err := AddValidator(models.AssetTypeInsight, func(a models.Asset) error {
+		return Validate(func() string {
+			if !strings.HasPrefix(a.GetID(), "INS-") {
+				return "id must start with INS-"
+			}
+			return ""
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := ValidateAsset(&models.Insight{ID: "INS-1", Text: "t"}); err != nil {
+		t.Errorf("expected a valid insight, got %v", err)
+	}
+	var validationErr *ValidationError
+	err = ValidateAsset(&models.Insight{ID: "i1"})
+	if !errors.As(err, &validationErr) || !slices.Equal(validationErr.Errors, []string{"text is required", "id must start with INS-"}) {
+		t.Errorf("expected built-in then added messages, got %v", err)
+	}
+
+	if err := AddValidator("widget", func(models.Asset) error { return nil }); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType, got %v", err)
+	}
+}
@@ -0,0 +1,420 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// A Rule is a JSON Schema document that tightens the validation of one asset
+// type without a code change. Rules are loaded at startup from
+// <dir>/<type>.json and evaluated against the decoded payload in addition to
+// the type's Go validator.
+//
+// Only this subset of JSON Schema is supported; documents using any other
+// keyword are rejected when loaded, so a rule is never silently ignored:
+//
+//	type, enum, const
+//	properties, required, additionalProperties, minProperties, maxProperties
+//	items, minItems, maxItems, uniqueItems
+//	minLength, maxLength, pattern
+//	minimum, maximum, exclusiveMinimum, exclusiveMaximum
+//
+// The annotations $schema, $id, $comment, title, description, default and
+// examples are accepted and ignored.
+type Rule struct {
+	types    []string
+	enum     []any
+	hasConst bool
+	constVal any
+
+	properties    map[string]*Rule
+	required      []string
+	additional    *Rule
+	noAdditional  bool
+	minProperties *int
+	maxProperties *int
+
+	items       *Rule
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+}
+
+var ruleAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true,
+	"title": true, "description": true, "default": true, "examples": true,
+}
+
+var ruleTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+var rules atomic.Pointer[map[models.AssetType]*Rule]
+
+// SetRules replaces the rules of CurrentValidator. A nil or empty map leaves
+// validation to the Go validators alone.
+func SetRules(r map[models.AssetType]*Rule) {
+	rules.Store(&r)
+}
+
+// LoadRules reads the rule of each <type>.json file in dir. Files named after
+// unregistered types and documents that are not valid rules are errors; other
+// files are ignored.
+func LoadRules(dir string) (map[models.AssetType]*Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading asset rules: %w", err)
+	}
+
+	loaded := make(map[models.AssetType]*Rule)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		name := models.AssetType(strings.TrimSuffix(entry.Name(), ".json"))
+		if _, err := Lookup(name); err != nil {
+			return nil, fmt.Errorf("asset rules %s: %w", entry.Name(), err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading asset rules: %w", err)
+		}
+		rule, err := parseRule(data)
+		if err != nil {
+			return nil, fmt.Errorf("asset rules %s: %w", entry.Name(), err)
+		}
+		loaded[name] = rule
+	}
+	return loaded, nil
+}
+
+// parseRule compiles a JSON Schema document into a Rule.
+func parseRule(data []byte) (*Rule, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return compileRule(doc, "#")
+}
+
+func compileRule(doc any, at string) (*Rule, error) {
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object", at)
+	}
+
+	r := &Rule{}
+	for _, kw := range sortedKeys(obj) {
+		v := obj[kw]
+		loc := at + "/" + kw
+		var err error
+		switch kw {
+		case "type":
+			r.types, err = compileTypes(v, loc)
+		case "enum":
+			values, ok := v.([]any)
+			if !ok || len(values) == 0 {
+				err = fmt.Errorf("%s must be a non-empty array", loc)
+			}
+			r.enum = values
+		case "const":
+			r.hasConst, r.constVal = true, v
+		case "properties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				err = fmt.Errorf("%s must be an object", loc)
+				break
+			}
+			r.properties = make(map[string]*Rule, len(props))
+			for _, name := range sortedKeys(props) {
+				if r.properties[name], err = compileRule(props[name], loc+"/"+name); err != nil {
+					break
+				}
+			}
+		case "required":
+			r.required, err = compileStrings(v, loc)
+		case "additionalProperties":
+			if allowed, ok := v.(bool); ok {
+				r.noAdditional = !allowed
+			} else {
+				r.additional, err = compileRule(v, loc)
+			}
+		case "items":
+			r.items, err = compileRule(v, loc)
+		case "minProperties":
+			r.minProperties, err = compileCount(v, loc)
+		case "maxProperties":
+			r.maxProperties, err = compileCount(v, loc)
+		case "minItems":
+			r.minItems, err = compileCount(v, loc)
+		case "maxItems":
+			r.maxItems, err = compileCount(v, loc)
+		case "uniqueItems":
+			var ok bool
+			if r.uniqueItems, ok = v.(bool); !ok {
+				err = fmt.Errorf("%s must be a boolean", loc)
+			}
+		case "minLength":
+			r.minLength, err = compileCount(v, loc)
+		case "maxLength":
+			r.maxLength, err = compileCount(v, loc)
+		case "pattern":
+			s, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("%s must be a string", loc)
+				break
+			}
+			if r.pattern, err = regexp.Compile(s); err != nil {
+				err = fmt.Errorf("%s: %w", loc, err)
+			}
+		case "minimum":
+			r.minimum, err = compileNumber(v, loc)
+		case "maximum":
+			r.maximum, err = compileNumber(v, loc)
+		case "exclusiveMinimum":
+			r.exclusiveMinimum, err = compileNumber(v, loc)
+		case "exclusiveMaximum":
+			r.exclusiveMaximum, err = compileNumber(v, loc)
+		default:
+			if !ruleAnnotations[kw] {
+				err = fmt.Errorf("%s: unsupported keyword", loc)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func compileTypes(v any, loc string) ([]string, error) {
+	var types []string
+	if s, ok := v.(string); ok {
+		types = []string{s}
+	} else {
+		var err error
+		if types, err = compileStrings(v, loc); err != nil || len(types) == 0 {
+			return nil, fmt.Errorf("%s must be a type name or a non-empty array of them", loc)
+		}
+	}
+	for _, t := range types {
+		if !ruleTypes[t] {
+			return nil, fmt.Errorf("%s: unknown type %q", loc, t)
+		}
+	}
+	return types, nil
+}
+
+func compileStrings(v any, loc string) ([]string, error) {
+	values, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", loc)
+	}
+	out := make([]string, len(values))
+	for i, value := range values {
+		if out[i], ok = value.(string); !ok {
+			return nil, fmt.Errorf("%s must be an array of strings", loc)
+		}
+	}
+	return out, nil
+}
+
+func compileCount(v any, loc string) (*int, error) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s must be a non-negative integer", loc)
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func compileNumber(v any, loc string) (*float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a number", loc)
+	}
+	return &f, nil
+}
+
+// check returns the validation messages of asset against the rule.
+func (r *Rule) check(asset models.Asset) ([]string, error) {
+	data, err := json.Marshal(asset)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %s data: %w", asset.GetType(), err)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s data: %w", asset.GetType(), err)
+	}
+	var errs []string
+	r.evaluate("", doc, &errs)
+	return errs, nil
+}
+
+// evaluate appends a message to errs for every keyword v at path violates.
+// Keywords of one kind of value (e.g. maxLength) only apply to that kind.
+func (r *Rule) evaluate(path string, v any, errs *[]string) {
+	field := path
+	if field == "" {
+		field = "asset_data"
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, field+" "+fmt.Sprintf(format, args...))
+	}
+
+	if len(r.types) > 0 && !slices.ContainsFunc(r.types, func(t string) bool { return hasType(v, t) }) {
+		fail("must be of type %s", strings.Join(r.types, " or "))
+		return
+	}
+	if r.enum != nil && !slices.ContainsFunc(r.enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		fail("has invalid value %s (allowed: %s)", jsonText(v), jsonList(r.enum))
+	}
+	if r.hasConst && !reflect.DeepEqual(r.constVal, v) {
+		fail("must be %s", jsonText(r.constVal))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range r.required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, joinPath(path, name)+" is required")
+			}
+		}
+		if r.minProperties != nil && len(v) < *r.minProperties {
+			fail("must have at least %d properties", *r.minProperties)
+		}
+		if r.maxProperties != nil && len(v) > *r.maxProperties {
+			fail("exceeds maximum of %d properties", *r.maxProperties)
+		}
+		for _, name := range sortedKeys(v) {
+			child := joinPath(path, name)
+			switch sub, declared := r.properties[name]; {
+			case declared:
+				sub.evaluate(child, v[name], errs)
+			case r.noAdditional:
+				*errs = append(*errs, child+" is not allowed")
+			case r.additional != nil:
+				r.additional.evaluate(child, v[name], errs)
+			}
+		}
+	case []any:
+		if r.minItems != nil && len(v) < *r.minItems {
+			fail("must have at least %d entries", *r.minItems)
+		}
+		if r.maxItems != nil && len(v) > *r.maxItems {
+			fail("exceeds maximum of %d entries", *r.maxItems)
+		}
+		if r.uniqueItems && hasDuplicates(v) {
+			fail("must not contain duplicate entries")
+		}
+		if r.items != nil {
+			for i, item := range v {
+				r.items.evaluate(fmt.Sprintf("%s[%d]", field, i), item, errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if r.minLength != nil && n < *r.minLength {
+			fail("must be at least %d characters", *r.minLength)
+		}
+		if r.maxLength != nil && n > *r.maxLength {
+			fail("exceeds maximum length of %d", *r.maxLength)
+		}
+		if r.pattern != nil && !r.pattern.MatchString(v) {
+			fail("must match pattern %q", r.pattern.String())
+		}
+	case float64:
+		if r.minimum != nil && v < *r.minimum {
+			fail("must be at least %v", *r.minimum)
+		}
+		if r.maximum != nil && v > *r.maximum {
+			fail("must be at most %v", *r.maximum)
+		}
+		if r.exclusiveMinimum != nil && v <= *r.exclusiveMinimum {
+			fail("must be greater than %v", *r.exclusiveMinimum)
+		}
+		if r.exclusiveMaximum != nil && v >= *r.exclusiveMaximum {
+			fail("must be less than %v", *r.exclusiveMaximum)
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		return t == "object"
+	case []any:
+		return t == "array"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || t == "integer" && v == math.Trunc(v)
+	case bool:
+		return t == "boolean"
+	case nil:
+		return t == "null"
+	}
+	return false
+}
+
+func hasDuplicates(values []any) bool {
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			if reflect.DeepEqual(values[i], values[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonText(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func jsonList(values []any) string {
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = jsonText(v)
+	}
+	return strings.Join(texts, ", ")
+}
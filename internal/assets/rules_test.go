@@ -0,0 +1,163 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func mustParseRule(t *testing.T, doc string) *Rule {
+	t.Helper()
+	rule, err := parseRule([]byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rule
+}
+
+func TestParseRule_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{name: "not JSON", doc: `{`, wantErr: "invalid JSON"},
+		{name: "not an object", doc: `[]`, wantErr: "#: schema must be an object"},
+		{name: "unsupported keyword", doc: `{"properties": {"title": {"format": "email"}}}`, wantErr: "#/properties/title/format: unsupported keyword"},
+		{name: "unknown type", doc: `{"type": "list"}`, wantErr: `#/type: unknown type "list"`},
+		{name: "negative count", doc: `{"maxItems": -1}`, wantErr: "#/maxItems must be a non-negative integer"},
+		{name: "bad pattern", doc: `{"pattern": "("}`, wantErr: "#/pattern"},
+		{name: "empty enum", doc: `{"enum": []}`, wantErr: "#/enum must be a non-empty array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRule([]byte(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRule_Check(t *testing.T) {
+	rule := mustParseRule(t, `{
+		"$comment": "tighter chart rules",
+		"required": ["chart_type"],
+		"properties": {
+			"title": {"type": "string", "minLength": 3, "pattern": "^[A-Z]"},
+			"chart_type": {"enum": ["bar", "line"]},
+			"series": {
+				"maxItems": 1,
+				"items": {"properties": {"points": {"maxItems": 2}}}
+			}
+		}
+	}`)
+
+	y := 1.0
+	chart := &models.Chart{
+		ID:    "c1",
+		Title: "q1",
+		Series: []models.Series{
+			{Name: "a", Points: []models.Point{{X: 1, Y: &y}, {X: 2, Y: &y}, {X: 3, Y: &y}}},
+			{Name: "b"},
+		},
+	}
+	got, err := rule.check(chart)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"chart_type is required",
+		"series exceeds maximum of 1 entries",
+		"series[0].points exceeds maximum of 2 entries",
+		"title must be at least 3 characters",
+		`title must match pattern "^[A-Z]"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	chart = &models.Chart{ID: "c1", Title: "Revenue", ChartType: "pie"}
+	got, _ = rule.check(chart)
+	if want := []string{`chart_type has invalid value "pie" (allowed: "bar", "line")`}; !slices.Equal(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRule_CheckTypesAndNumbers(t *testing.T) {
+	rule := mustParseRule(t, `{
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "string"},
+			"text": {"type": "string"},
+			"confidence": {"type": "number", "minimum": 0.5, "exclusiveMaximum": 1},
+			"tags": {"type": "array", "uniqueItems": true, "items": {"type": "integer"}}
+		}
+	}`)
+
+	confidence := 1.0
+	got, err := rule.check(&models.Insight{ID: "i1", Text: "t", Confidence: &confidence, Tags: []string{"a", "a"}, SourceURL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"confidence must be less than 1",
+		"source_url is not allowed",
+		"tags must not contain duplicate entries",
+		"tags[0] must be of type integer",
+		"tags[1] must be of type integer",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestValidateAsset_WithRules(t *testing.T) {
+	SetRules(map[models.AssetType]*Rule{
+		models.AssetTypeInsight: mustParseRule(t, `{"properties": {"text": {"maxLength": 5}}}`),
+	})
+	t.Cleanup(func() { SetRules(nil) })
+
+	// Messages of the Go validator come first, followed by those of the rule.
+	err := ValidateAsset(&models.Insight{ID: "i1", Text: "a long insight", Tags: []string{""}})
+	assertValidation(t, err, true, "tags[0] is required; text exceeds maximum length of 5")
+
+	err = ValidateAsset(&models.Insight{ID: "i1", Text: "a long insight"})
+	assertValidation(t, err, true, "text exceeds maximum length of 5")
+
+	if err := ValidateAsset(&models.Insight{ID: "i1", Text: "short"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateAsset(&models.Chart{ID: "c1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y"}); err != nil {
+		t.Errorf("types without a rule should be unaffected, got: %v", err)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("chart.json", `{"properties": {"series": {"maxItems": 10}}}`)
+	write("README.md", "ignored")
+
+	rules, err := LoadRules(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[models.AssetTypeChart] == nil {
+		t.Errorf("expected a chart rule only, got %v", rules)
+	}
+
+	write("widget.json", `{}`)
+	if _, err := LoadRules(dir); err == nil || !strings.Contains(err.Error(), "asset rules widget.json: unknown asset type") {
+		t.Errorf("expected unknown asset type error, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package assets
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxStringLength caps the length of free-text asset fields.
+const MaxStringLength = 255
+
+// maxThumbnailURLLength caps thumbnail_url, which list responses carry for every favourite.
+const maxThumbnailURLLength = 2048
+
+// ValidationError holds a list of field-level validation errors.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %s", strings.Join(e.Errors, "; "))
+}
+
+// Validate runs every check and returns a *ValidationError listing the
+// messages of those that failed, or nil when all passed.
+func Validate(checks ...func() string) error {
+	var errs []string
+	for _, check := range checks {
+		if msg := check(); msg != "" {
+			errs = append(errs, msg)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func RequireNonEmpty(field, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Sprintf("%s is required", field)
+	}
+	return ""
+}
+
+func CheckMaxLength(field, value string, max int) string {
+	if len(value) > max {
+		return fmt.Sprintf("%s exceeds maximum length of %d", field, max)
+	}
+	return ""
+}
+
+func CheckInList(field, value string, allowed []string) string {
+	for _, v := range allowed {
+		if value == v {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s has invalid value %q (allowed: %s)", field, value, strings.Join(allowed, ", "))
+}
+
+// CheckHTTPURL accepts an empty value or an absolute http(s) URL of at most max bytes.
+func CheckHTTPURL(field, value string, max int) string {
+	if value == "" {
+		return ""
+	}
+	if msg := CheckMaxLength(field, value, max); msg != "" {
+		return msg
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Sprintf("%s must be an absolute http or https URL", field)
+	}
+	return ""
+}
+
+// checkThumbnailURL validates the optional preview image of an asset.
+func checkThumbnailURL(value string) string {
+	return CheckHTTPURL("thumbnail_url", value, maxThumbnailURLLength)
+}
+
+func CheckNonNegative(field string, value int) string {
+	if value < 0 {
+		return fmt.Sprintf("%s must not be negative", field)
+	}
+	return ""
+}
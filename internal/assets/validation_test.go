@@ -0,0 +1,226 @@
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func assertValidation(t *testing.T, err error, wantErr bool, errSubstr string) {
+	t.Helper()
+	if !wantErr {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) {
+		t.Fatalf("expected *ValidationError, got %T (%v)", err, err)
+	}
+	if !strings.Contains(err.Error(), errSubstr) {
+		t.Errorf("expected error containing %q, got %q", errSubstr, err.Error())
+	}
+}
+
+func TestValidateChart(t *testing.T) {
+	tests := []struct {
+		name      string
+		chart     models.Chart
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "valid chart", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD"}},
+		{name: "missing id", chart: models.Chart{Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: "id is required"},
+		{name: "missing title", chart: models.Chart{ID: "c1", XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: "title is required"},
+		{name: "missing x_axis_title", chart: models.Chart{ID: "c1", Title: "Revenue", YAxisTitle: "USD"}, wantErr: true, errSubstr: "x_axis_title is required"},
+		{name: "missing y_axis_title", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month"}, wantErr: true, errSubstr: "y_axis_title is required"},
+		{name: "title too long", chart: models.Chart{ID: "c1", Title: strings.Repeat("a", 256), XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: "title exceeds maximum length"},
+		{name: "all required fields missing", chart: models.Chart{}, wantErr: true, errSubstr: "id is required"},
+		{name: "valid rendering hints", chart: models.Chart{ID: "c1", Title: "Revenue", ChartType: "line", XAxisTitle: "Month", YAxisTitle: "Revenue", XAxisFormat: "date", YAxisUnit: "USD", YAxisFormat: "currency"}},
+		{name: "invalid chart_type", chart: models.Chart{ID: "c1", Title: "Revenue", ChartType: "donut", XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: `chart_type has invalid value "donut"`},
+		{name: "invalid y_axis_format", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", YAxisFormat: "money"}, wantErr: true, errSubstr: `y_axis_format has invalid value "money"`},
+		{name: "valid thumbnail", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", ThumbnailURL: "https://cdn.example.com/c1.png"}},
+		{name: "relative thumbnail", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", ThumbnailURL: "c1.png"}, wantErr: true, errSubstr: "thumbnail_url must be an absolute http or https URL"},
+		{name: "valid series", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: []models.Series{{Name: "2026", Points: []models.Point{{X: "Jan", Y: ptr(1.5)}, {X: 2.0, Y: ptr(0)}}}}}},
+		{name: "series with data", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Data: map[string]any{"Jan": 1}, Series: []models.Series{{Name: "2026", Points: []models.Point{{X: "Jan", Y: ptr(1)}}}}}, wantErr: true, errSubstr: "data and series are mutually exclusive"},
+		{name: "series without name", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: []models.Series{{Points: []models.Point{{X: "Jan", Y: ptr(1)}}}}}, wantErr: true, errSubstr: "series[0].name is required"},
+		{name: "series without points", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: []models.Series{{Name: "2026"}}}, wantErr: true, errSubstr: "series[0].points must not be empty"},
+		{name: "too many points", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: []models.Series{{Name: "2026", Points: make([]models.Point, 1001)}}}, wantErr: true, errSubstr: "series[0].points exceeds maximum of 1000 entries"},
+		{name: "too many series", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: make([]models.Series, 21)}, wantErr: true, errSubstr: "series exceeds maximum of 20 entries"},
+		{name: "point without y", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: []models.Series{{Name: "2026", Points: []models.Point{{X: "Jan", Y: ptr(1)}, {X: "Feb"}}}}}, wantErr: true, errSubstr: "series[0].points[1].y is required"},
+		{name: "point with non-scalar x", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", Series: []models.Series{{Name: "2026", Points: []models.Point{{X: true, Y: ptr(1)}}}}}, wantErr: true, errSubstr: "series[0].points[0].x must be a number or a string"},
+		{name: "x_axis_unit too long", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD", XAxisUnit: strings.Repeat("u", 33)}, wantErr: true, errSubstr: "x_axis_unit exceeds maximum length of 32"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, validateChart(&tt.chart, DefaultTextLimits()), tt.wantErr, tt.errSubstr)
+		})
+	}
+}
+
+func TestValidateInsight(t *testing.T) {
+	tests := []struct {
+		name      string
+		insight   models.Insight
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "valid insight", insight: models.Insight{ID: "i1", Text: "40% of millennials spend 3h on social media"}},
+		{name: "missing id", insight: models.Insight{Text: "some text"}, wantErr: true, errSubstr: "id is required"},
+		{name: "missing text", insight: models.Insight{ID: "i1"}, wantErr: true, errSubstr: "text is required"},
+		{name: "text too long", insight: models.Insight{ID: "i1", Text: strings.Repeat("x", 256)}, wantErr: true, errSubstr: "text exceeds maximum length"},
+		{name: "valid metadata", insight: models.Insight{ID: "i1", Text: "t", SourceURL: "https://example.com/report?id=1", Tags: []string{"social", "gen-z"}, Confidence: ptr(0.8)}},
+		{name: "confidence bounds are inclusive", insight: models.Insight{ID: "i1", Text: "t", Confidence: ptr(1)}},
+		{name: "relative source_url", insight: models.Insight{ID: "i1", Text: "t", SourceURL: "/reports/1"}, wantErr: true, errSubstr: "source_url must be an absolute http or https URL"},
+		{name: "non-http source_url", insight: models.Insight{ID: "i1", Text: "t", SourceURL: "javascript:alert(1)"}, wantErr: true, errSubstr: "source_url must be an absolute http or https URL"},
+		{name: "data thumbnail", insight: models.Insight{ID: "i1", Text: "t", ThumbnailURL: "data:image/png;base64,AAAA"}, wantErr: true, errSubstr: "thumbnail_url must be an absolute http or https URL"},
+		{name: "thumbnail too long", insight: models.Insight{ID: "i1", Text: "t", ThumbnailURL: "https://example.com/" + strings.Repeat("x", 2048)}, wantErr: true, errSubstr: "thumbnail_url exceeds maximum length of 2048"},
+		{name: "empty tag", insight: models.Insight{ID: "i1", Text: "t", Tags: []string{"social", " "}}, wantErr: true, errSubstr: "tags[1] is required"},
+		{name: "duplicate tag", insight: models.Insight{ID: "i1", Text: "t", Tags: []string{"social", "social"}}, wantErr: true, errSubstr: `tags[1] duplicates tag "social"`},
+		{name: "too many tags", insight: models.Insight{ID: "i1", Text: "t", Tags: make([]string, 21)}, wantErr: true, errSubstr: "tags exceeds maximum of 20 entries"},
+		{name: "confidence above 1", insight: models.Insight{ID: "i1", Text: "t", Confidence: ptr(1.5)}, wantErr: true, errSubstr: "confidence must be between 0 and 1"},
+		{name: "negative confidence", insight: models.Insight{ID: "i1", Text: "t", Confidence: ptr(-0.1)}, wantErr: true, errSubstr: "confidence must be between 0 and 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, validateInsight(&tt.insight, DefaultTextLimits()), tt.wantErr, tt.errSubstr)
+		})
+	}
+}
+
+func TestValidateAudience(t *testing.T) {
+	tests := []struct {
+		name      string
+		audience  models.Audience
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "valid audience with all fields", audience: models.Audience{ID: "a1", Gender: []string{"Male"}, BirthCountry: []string{"GR"}, AgeGroups: []string{"25-34"}, SocialMediaHoursDaily: "3-5", PurchasesLastMonth: 5}},
+		{name: "valid audience with only required fields", audience: models.Audience{ID: "a2"}},
+		{name: "valid audience with partial optional fields", audience: models.Audience{ID: "a3", AgeGroups: []string{"18-24", "25-34"}}},
+		{name: "missing id", audience: models.Audience{Gender: []string{"Male"}}, wantErr: true, errSubstr: "id is required"},
+		{name: "valid thumbnail", audience: models.Audience{ID: "a1", ThumbnailURL: "http://cdn.example.com/a1.jpg"}},
+		{name: "thumbnail without host", audience: models.Audience{ID: "a1", ThumbnailURL: "https:///a1.jpg"}, wantErr: true, errSubstr: "thumbnail_url must be an absolute http or https URL"},
+		{name: "invalid gender value", audience: models.Audience{ID: "a1", Gender: []string{"Other"}}, wantErr: true, errSubstr: "gender[0] has invalid value"},
+		{name: "invalid age group value", audience: models.Audience{ID: "a1", AgeGroups: []string{"10-17"}}, wantErr: true, errSubstr: "age_groups[0] has invalid value"},
+		{name: "invalid social media hours", audience: models.Audience{ID: "a1", SocialMediaHoursDaily: "10+"}, wantErr: true, errSubstr: "social_media_hours_daily has invalid value"},
+		{name: "negative purchases", audience: models.Audience{ID: "a1", PurchasesLastMonth: -1}, wantErr: true, errSubstr: "purchases_last_month must not be negative"},
+		{name: "empty birth country entry", audience: models.Audience{ID: "a1", BirthCountry: []string{""}}, wantErr: true, errSubstr: "birth_country[0] is required"},
+		{name: "alpha-3 birth country", audience: models.Audience{ID: "a1", BirthCountry: []string{"GRC", "usa"}}},
+		{name: "country name instead of code", audience: models.Audience{ID: "a1", BirthCountry: []string{"GR", "Greece"}}, wantErr: true, errSubstr: `birth_country[1] has invalid value "Greece"`},
+		{name: "reserved non-ISO code", audience: models.Audience{ID: "a1", BirthCountry: []string{"UK"}}, wantErr: true, errSubstr: `birth_country[0] has invalid value "UK"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, validateAudience(&tt.audience, DefaultAudienceEnums()), tt.wantErr, tt.errSubstr)
+		})
+	}
+}
+
+func TestConfiguredTextLimits(t *testing.T) {
+	SetTextLimits(TextLimits{Title: 10, Text: 500})
+	t.Cleanup(func() { SetTextLimits(TextLimits{}) })
+
+	if got := CurrentTextLimits(); got.Description != MaxStringLength {
+		t.Errorf("expected an unset limit to keep its default, got %+v", got)
+	}
+	assertValidation(t, validateChart(&models.Chart{ID: "c1", Title: "Quarterly revenue", XAxisTitle: "X", YAxisTitle: "Y"}, CurrentTextLimits()), true, "title exceeds maximum length of 10")
+	assertValidation(t, validateDashboard(&models.Dashboard{ID: "d1", Title: "Quarterly revenue"}, CurrentTextLimits()), true, "title exceeds maximum length of 10")
+	assertValidation(t, validateInsight(&models.Insight{ID: "i1", Text: strings.Repeat("x", 400)}, CurrentTextLimits()), false, "")
+	assertValidation(t, validateInsight(&models.Insight{ID: "i1", Text: strings.Repeat("x", 501)}, CurrentTextLimits()), true, "text exceeds maximum length of 500")
+
+	if !strings.Contains(string(chartSchema()), `"title": {"type": "string", "maxLength": 10}`) {
+		t.Errorf("expected the chart schema to document the title limit, got %s", chartSchema())
+	}
+	if !strings.Contains(string(insightSchema()), `"text": {"type": "string", "maxLength": 500}`) {
+		t.Errorf("expected the insight schema to document the text limit, got %s", insightSchema())
+	}
+}
+
+func TestValidateAudience_ConfiguredEnums(t *testing.T) {
+	SetAudienceEnums(AudienceEnums{Gender: []string{"Male", "Female", "Non-binary"}})
+	t.Cleanup(func() { SetAudienceEnums(AudienceEnums{}) })
+
+	if err := validateAudience(&models.Audience{ID: "a1", Gender: []string{"Non-binary"}, AgeGroups: []string{"55+"}}, CurrentAudienceEnums()); err != nil {
+		t.Errorf("expected configured gender and default age group to be valid, got %v", err)
+	}
+	if !strings.Contains(string(audienceSchema()), `"Non-binary"`) {
+		t.Errorf("expected the schema to list the configured gender, got %s", audienceSchema())
+	}
+}
+
+func TestValidateDashboard(t *testing.T) {
+	widget := func(assetType models.AssetType, id string, x, width int) models.DashboardWidget {
+		return models.DashboardWidget{AssetType: assetType, AssetID: id, Position: models.WidgetPosition{X: x, Width: width, Height: 2}}
+	}
+	tests := []struct {
+		name      string
+		dashboard models.Dashboard
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "valid dashboard", dashboard: models.Dashboard{ID: "d1", Title: "Q1", Layout: models.DashboardLayout{Columns: 12},
+			Widgets: []models.DashboardWidget{widget(models.AssetTypeChart, "c1", 0, 6), widget(models.AssetTypeInsight, "i1", 6, 6)}}},
+		{name: "valid empty dashboard", dashboard: models.Dashboard{ID: "d1", Title: "Q1"}},
+		{name: "missing title", dashboard: models.Dashboard{ID: "d1"}, wantErr: true, errSubstr: "title is required"},
+		{name: "nested dashboard", dashboard: models.Dashboard{ID: "d1", Title: "Q1",
+			Widgets: []models.DashboardWidget{widget(models.AssetTypeDashboard, "d2", 0, 1)}}, wantErr: true, errSubstr: "widgets[0].asset_type has invalid value"},
+		{name: "missing widget asset id", dashboard: models.Dashboard{ID: "d1", Title: "Q1",
+			Widgets: []models.DashboardWidget{widget(models.AssetTypeChart, "", 0, 1)}}, wantErr: true, errSubstr: "widgets[0].asset_id is required"},
+		{name: "duplicate widget", dashboard: models.Dashboard{ID: "d1", Title: "Q1",
+			Widgets: []models.DashboardWidget{widget(models.AssetTypeChart, "c1", 0, 1), widget(models.AssetTypeChart, "c1", 1, 1)}}, wantErr: true, errSubstr: "widgets[1] duplicates chart"},
+		{name: "negative position", dashboard: models.Dashboard{ID: "d1", Title: "Q1",
+			Widgets: []models.DashboardWidget{widget(models.AssetTypeChart, "c1", -1, 1)}}, wantErr: true, errSubstr: "widgets[0].position.x must not be negative"},
+		{name: "widget wider than the grid", dashboard: models.Dashboard{ID: "d1", Title: "Q1", Layout: models.DashboardLayout{Columns: 12},
+			Widgets: []models.DashboardWidget{widget(models.AssetTypeChart, "c1", 8, 6)}}, wantErr: true, errSubstr: "widgets[0].position exceeds the 12 layout columns"},
+		{name: "too many columns", dashboard: models.Dashboard{ID: "d1", Title: "Q1", Layout: models.DashboardLayout{Columns: 25}}, wantErr: true, errSubstr: "layout.columns must be between 0 and 24"},
+		{name: "too many widgets", dashboard: models.Dashboard{ID: "d1", Title: "Q1", Widgets: make([]models.DashboardWidget, maxDashboardWidgets+1)}, wantErr: true, errSubstr: "widgets exceeds maximum of 50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, validateDashboard(&tt.dashboard, DefaultTextLimits()), tt.wantErr, tt.errSubstr)
+		})
+	}
+}
+
+func TestValidateGeneric(t *testing.T) {
+	tests := []struct {
+		name      string
+		generic   models.Generic
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "valid object payload", generic: models.Generic{ID: "g1", Title: "Funnel", Payload: json.RawMessage(`{"steps":[1,2]}`)}},
+		{name: "valid scalar payload", generic: models.Generic{ID: "g1", Title: "Funnel", Payload: json.RawMessage(`42`)}},
+		{name: "missing title", generic: models.Generic{ID: "g1", Payload: json.RawMessage(`{}`)}, wantErr: true, errSubstr: "title is required"},
+		{name: "missing payload", generic: models.Generic{ID: "g1", Title: "Funnel"}, wantErr: true, errSubstr: "payload is required"},
+		{name: "null payload", generic: models.Generic{ID: "g1", Title: "Funnel", Payload: json.RawMessage(`null`)}, wantErr: true, errSubstr: "payload is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertValidation(t, validateGeneric(&tt.generic, DefaultTextLimits()), tt.wantErr, tt.errSubstr)
+		})
+	}
+}
+
+func TestValidationErrorCollectsAllFieldErrors(t *testing.T) {
+	err := validateChart(&models.Chart{}, DefaultTextLimits())
+	valErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected *ValidationError, got %T", err)
+	}
+	if len(valErr.Errors) != 4 {
+		t.Errorf("expected 4 validation errors, got %d: %v", len(valErr.Errors), valErr.Errors)
+	}
+}
+
+func ptr(f float64) *float64 { return &f }
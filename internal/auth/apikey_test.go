@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTMiddleware_APIKey(t *testing.T) {
+	_, key, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		t.Errorf("expected key %q to start with %q", key, apiKeyPrefix)
+	}
+	lookup := func(ctx context.Context, hash string) (string, string, error) {
+		switch hash {
+		case HashAPIKey(key):
+			return "user7", "", nil
+		case HashAPIKey("broken"):
+			return "", "", errors.New("connection refused")
+		}
+		return "", "", nil
+	}
+	cfg := AuthConfig{Secret: "test-secret", APIKeys: lookup, AdminUsers: []string{"user7"}}
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		bearer     string
+		handler    http.Handler
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "valid key", apiKey: key, handler: dummyHandler, wantStatus: http.StatusOK, wantBody: "user7"},
+		{name: "unknown key", apiKey: "pgc_unknown", handler: dummyHandler, wantStatus: http.StatusUnauthorized},
+		{name: "lookup failure", apiKey: "broken", handler: dummyHandler, wantStatus: http.StatusServiceUnavailable},
+		{name: "bearer token still accepted", bearer: signedToken("user8", "test-secret", time.Now().Add(time.Hour)), handler: dummyHandler, wantStatus: http.StatusOK, wantBody: "user8"},
+		{name: "key grants favourites scopes", apiKey: key, handler: RequireScope(AuthConfig{RequireScopes: true}, ScopeFavouritesWrite)(dummyHandler), wantStatus: http.StatusOK},
+		{name: "key grants no admin role", apiKey: key, handler: RequireRole(RoleAdmin)(dummyHandler), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			rr := httptest.NewRecorder()
+			JWTMiddleware(cfg)(tt.handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantBody != "" && rr.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
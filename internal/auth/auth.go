@@ -2,68 +2,373 @@ package auth
 
 import (
 	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/giannis84/platform-go-challenge/internal/logging"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey contextKey = "userID"
+	scopesKey contextKey = "scopes"
+)
+
+// Scopes granted by a token's "scope" or "permissions" claim.
+const (
+	ScopeFavouritesRead  = "favourites:read"
+	ScopeFavouritesWrite = "favourites:write"
+)
 
 // AuthConfig holds JWT authentication configuration.
 type AuthConfig struct {
-	// Secret is the JWT signing secret. When empty, unsigned tokens may be accepted
-	// if AllowUnsignedTokens is true.
+	// Secret is the JWT signing secret, used for HS256 tokens without a "kid"
+	// header. When no key is configured at all, unsigned tokens may be
+	// accepted if AllowUnsignedTokens is true.
 	Secret string
 
+	// SecretFunc, when set, returns the current Secret instead, for a secret
+	// rotated by a secrets provider while the service runs.
+	SecretFunc func() string
+
+	// Secrets holds further HS256 secrets by key ID. A token whose "kid"
+	// header names one of them is verified with it, so the signing secret can
+	// be rotated by issuing under a new kid while the old one still verifies.
+	Secrets map[string]string
+
+	// RSAKeys holds RS256 public keys by key ID, checked before JWKS.
+	RSAKeys map[string]*rsa.PublicKey
+
+	// JWKS, when set, verifies RS256 tokens with the identity provider's
+	// published keys, alongside HS256 tokens when Secret is also set.
+	JWKS *JWKS
+
+	// Issuers and Audiences, when set, restrict signed tokens to those whose
+	// "iss" claim is one of Issuers and whose "aud" claim names one of
+	// Audiences.
+	Issuers   []string
+	Audiences []string
+
+	// Leeway tolerates clock skew between the token issuer and this service
+	// when checking the "exp", "nbf" and "iat" claims of signed tokens.
+	Leeway time.Duration
+
+	// Denylist, when set, rejects tokens whose "jti" claim it holds.
+	Denylist Denylist
+
+	// APIKeys, when set, authenticates requests carrying an X-API-Key header
+	// instead of a bearer token.
+	APIKeys APIKeyLookup
+
+	// SessionCookie, when set, names a cookie the JWT is read from on requests
+	// without an Authorization header, for browser apps keeping the token in
+	// an httpOnly cookie. Such requests are checked by CSRFMiddleware.
+	SessionCookie string
+
+	// SigningKeys holds the shared secrets of partners signing their requests
+	// (see SignRequest) by key ID. A request with a valid signature acts as
+	// the key ID's user, the way API keys do.
+	SigningKeys map[string]string
+
+	// ServiceTenants names the tenant of the partners signing their requests,
+	// by key ID, and of the clients presenting a certificate, by principal.
+	// API keys carry the tenant they were issued in instead.
+	ServiceTenants map[string]string
+
+	// SignatureWindow is how far a signed request's timestamp may be from
+	// the current time; zero means DefaultSignatureWindow.
+	SignatureWindow time.Duration
+
+	// Methods lists the authentication methods tried, in order, by
+	// JWTMiddleware; the first that recognises a request authenticates it.
+	// Empty means DefaultMethods. Methods left out are disabled.
+	Methods []string
+
+	// Throttle, when set, delays and then bans clients that repeatedly fail
+	// authentication.
+	Throttle *FailureThrottle
+
 	// AllowUnsignedTokens permits unsigned JWT tokens (alg=none) when true.
 	// This should ONLY be enabled for local development and testing.
 	AllowUnsignedTokens bool
+
+	// RequireScopes makes RequireScope reject tokens that do not grant the
+	// scope a route needs. When false, scopes are recorded but not enforced.
+	RequireScopes bool
+
+	// RolesClaim is the claim listing the user's roles, with dots descending
+	// into nested objects (e.g. "realm_access.roles"). Empty means
+	// DefaultRolesClaim.
+	RolesClaim string
+
+	// TenantClaim is the claim naming the user's tenant, surfaced as
+	// Principal.TenantID, with dots descending into nested objects like
+	// RolesClaim. Empty means DefaultTenantClaim.
+	TenantClaim string
+
+	// ServiceClaim, in "claim=value" form (e.g. "gty=client-credentials"),
+	// marks the tokens of service principals: backend jobs acting on behalf
+	// of users named per request (see ActOnBehalf) rather than as one user.
+	// The claim may be a dotted path like RolesClaim. Empty disables them.
+	ServiceClaim string
+
+	// AdminUsers lists the user IDs ("sub" claims) granted RoleAdmin whatever
+	// their token says.
+	AdminUsers []string
+	// SignedURLSecret signs delegated read-only URLs. When empty, signed URLs
+	// can neither be issued nor accepted.
+	SignedURLSecret string
+}
+
+// IsAdmin reports whether userID is listed in AdminUsers. Users whose token
+// grants RoleAdmin are admins too; see HasRole.
+func (c AuthConfig) IsAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	for _, admin := range c.AdminUsers {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
 }
 
 // JWTMiddleware returns HTTP middleware that validates a JWT from the
-// Authorization header and places the "sub" claim into the request context.
+// Authorization header and places the "sub" claim, the scopes and roles the
+// token grants, and the Principal it describes, into the request context.
 //
-// When Secret is empty AND AllowUnsignedTokens is true, unsigned tokens (alg=none)
+// When Secret or Secrets is non-empty, HS256-signed tokens are accepted, and
+// when RSAKeys or JWKS is set, RS256-signed tokens. The token's "kid" header
+// selects the key; verified tokens are counted per key (see signingKeyUses).
+// When no key is set AND AllowUnsignedTokens is true, unsigned tokens (alg=none)
 // are accepted — this is intended for local development and testing only.
-// When Secret is non-empty, only HS256-signed tokens are accepted.
-// When Secret is empty AND AllowUnsignedTokens is false, all requests are rejected.
+// When no key is set AND AllowUnsignedTokens is false, all requests are rejected.
+//
+// When APIKeys is set, a request with an X-API-Key header is authenticated by
+// that key instead. It acts as the key's user with both favourites scopes but
+// no roles, so API keys cannot call admin endpoints. Requests signed with one
+// of SigningKeys (see SignRequest) are authenticated the same way, as the
+// key's ID.
+//
+// Tokens matching ServiceClaim are additionally marked as service principals;
+// see ServiceFromContext and ActOnBehalf.
+//
+// With SessionCookie set, a request without an Authorization header may
+// carry the token in that cookie instead; see CSRFMiddleware.
+//
+// With Throttle set, repeated failures from a client IP or with one
+// credential are answered ever more slowly, and eventually refused with 429.
+//
+// A request made with a verified client certificate (see
+// ClientCertMiddleware) is authenticated the same way as the certificate's
+// principal.
+//
+// These methods are tried in the order of Methods: the first to recognise
+// the request, by the header, cookie or certificate it looks for, decides
+// whether it is authenticated, and is recorded as the Principal's Method. A
+// request no method recognises is rejected with missing_token.
 func JWTMiddleware(cfg AuthConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tokenString, ok := extractBearerToken(r)
-			if !ok {
-				http.Error(w, `{"error":"missing or malformed Authorization header"}`, http.StatusUnauthorized)
+			credential := cfg.credential(r)
+			if wait := cfg.Throttle.credentialBanned(credential); wait > 0 {
+				tooManyFailures(w, wait)
 				return
 			}
-
-			// Reject unsigned tokens unless explicitly allowed
-			if cfg.Secret == "" && !cfg.AllowUnsignedTokens {
-				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
-				return
+			// reject answers 401, after a delay for clients that keep
+			// failing, or 429 once they are banned.
+			reject := func(code, message string) {
+				event := logging.EventAuthFailure
+				if code == "invalid_signature" || code == "signature_expired" {
+					event = logging.EventInvalidSignature
+				}
+				logging.Security(r, event).Str("code", code).Info(message)
+				delay, banned := cfg.Throttle.failed(r, credential, code)
+				if banned > 0 {
+					tooManyFailures(w, banned)
+					return
+				}
+				if !cfg.Throttle.wait(r.Context(), delay) {
+					tooManyFailures(w, delay)
+					return
+				}
+				unauthorized(w, code, message)
 			}
 
-			claims, err := parseToken(tokenString, cfg.Secret)
-			if err != nil {
-				http.Error(w, fmt.Sprintf(`{"error":"%s"}`, err.Error()), http.StatusUnauthorized)
-				return
+			for _, method := range cfg.methods() {
+				switch method {
+				case MethodAPIKey:
+					key := r.Header.Get(APIKeyHeader)
+					if key == "" || cfg.APIKeys == nil {
+						continue
+					}
+					userID, tenantID, err := cfg.APIKeys(r.Context(), HashAPIKey(key))
+					if err != nil {
+						authError(w, http.StatusServiceUnavailable, "temporarily_unavailable", "API key could not be verified")
+						return
+					}
+					if userID == "" {
+						reject("invalid_api_key", "invalid or revoked API key")
+						return
+					}
+					next.ServeHTTP(w, asServiceClient(r, userID, tenantID, MethodAPIKey))
+					return
+
+				case MethodSignature:
+					if r.Header.Get(SignatureHeader) == "" || len(cfg.SigningKeys) == 0 {
+						continue
+					}
+					keyID, err := cfg.verifyRequestSignature(r, time.Now())
+					if err != nil {
+						reject(tokenErrorCode(err), err.Error())
+						return
+					}
+					next.ServeHTTP(w, asServiceClient(r, keyID, cfg.ServiceTenants[keyID], MethodSignature))
+					return
+
+				case MethodClientCert:
+					principal := ClientPrincipalFromContext(r.Context())
+					if principal == "" {
+						continue
+					}
+					next.ServeHTTP(w, asServiceClient(r, principal, cfg.ServiceTenants[principal], MethodClientCert))
+					return
+
+				case MethodJWT:
+					tokenString, ok := extractBearerToken(r)
+					fromSession := false
+					if r.Header.Get("Authorization") == "" {
+						tokenString, fromSession = cfg.sessionToken(r)
+						if !fromSession {
+							continue
+						}
+					} else if !ok {
+						reject("missing_token", "missing or malformed Authorization header")
+						return
+					}
+					cfg.serveToken(w, r, next, tokenString, fromSession, reject)
+					return
+				}
 			}
+			reject("missing_token", "missing or malformed Authorization header")
+		})
+	}
+}
+
+// serveToken authenticates r by the JWT tokenString, read from the session
+// cookie when fromSession is set, and serves it with next, or rejects it.
+func (cfg AuthConfig) serveToken(w http.ResponseWriter, r *http.Request, next http.Handler, tokenString string, fromSession bool, reject func(code, message string)) {
+	// Reject unsigned tokens unless explicitly allowed
+	if !cfg.signsTokens() && !cfg.AllowUnsignedTokens {
+		reject("unauthorized", "unauthorized")
+		return
+	}
+
+	claims, err := parseToken(r.Context(), tokenString, cfg)
+	if err != nil {
+		reject(tokenErrorCode(err), err.Error())
+		return
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		reject("missing_claim", "token missing sub claim")
+		return
+	}
+
+	if jti, _ := claims["jti"].(string); jti != "" && cfg.Denylist != nil {
+		revoked, err := cfg.Denylist.IsRevoked(r.Context(), jti)
+		if err != nil {
+			authError(w, http.StatusServiceUnavailable, "temporarily_unavailable", "token revocation could not be checked")
+			return
+		}
+		if revoked {
+			reject("token_revoked", "token has been revoked")
+			return
+		}
+	}
+
+	principal := cfg.tokenPrincipal(claims, sub)
+	ctx := context.WithValue(r.Context(), userIDKey, sub)
+	ctx = context.WithValue(ctx, scopesKey, tokenScopes(claims))
+	ctx = context.WithValue(ctx, rolesKey, principal.Roles)
+	ctx = context.WithValue(ctx, identityKey, principal)
+	if cfg.isServiceToken(claims) {
+		ctx = context.WithValue(ctx, serviceKey, sub)
+	}
+	if fromSession {
+		ctx = context.WithValue(ctx, sessionKey, true)
+	}
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// asServiceClient authenticates r as userID of tenantID the way API keys and
+// client certificates do: with both favourites scopes and no roles.
+func asServiceClient(r *http.Request, userID, tenantID, method string) *http.Request {
+	ctx := context.WithValue(r.Context(), userIDKey, userID)
+	ctx = context.WithValue(ctx, scopesKey, []string{ScopeFavouritesRead, ScopeFavouritesWrite})
+	ctx = context.WithValue(ctx, identityKey, Principal{UserID: userID, TenantID: tenantID, Method: method})
+	return r.WithContext(ctx)
+}
 
-			sub, err := claims.GetSubject()
-			if err != nil || sub == "" {
-				http.Error(w, `{"error":"token missing sub claim"}`, http.StatusUnauthorized)
+// RequireScope returns HTTP middleware that answers 403 when the token lacks
+// scope and cfg.RequireScopes is set. It must run after JWTMiddleware, which
+// has already rejected invalid tokens with 401.
+func RequireScope(cfg AuthConfig, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.RequireScopes {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r.Context(), scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+				forbidden(w, "insufficient_scope", "token lacks the "+scope+" scope")
 				return
 			}
-
-			ctx := context.WithValue(r.Context(), userIDKey, sub)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// ScopesFromContext returns the scopes stored by JWTMiddleware.
+func ScopesFromContext(ctx context.Context) []string {
+	v, _ := ctx.Value(scopesKey).([]string)
+	return v
+}
+
+// HasScope reports whether the token of the request carrying ctx grants scope.
+func HasScope(ctx context.Context, scope string) bool {
+	return slices.Contains(ScopesFromContext(ctx), scope)
+}
+
+// tokenScopes collects the scopes of the OAuth "scope" claim (space-separated)
+// and the "permissions" claim (an array, as issued by e.g. Auth0).
+func tokenScopes(claims jwt.MapClaims) []string {
+	var scopes []string
+	for _, name := range []string{"scope", "permissions"} {
+		switch v := claims[name].(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(v)...)
+		case []any:
+			for _, s := range v {
+				if s, ok := s.(string); ok {
+					scopes = append(scopes, s)
+				}
+			}
+		}
+	}
+	return scopes
+}
+
 // UserIDFromContext returns the user ID stored by JWTMiddleware.
 // Returns an empty string if no user ID is present.
 func UserIDFromContext(ctx context.Context) string {
@@ -71,6 +376,73 @@ func UserIDFromContext(ctx context.Context) string {
 	return v
 }
 
+// WithUserID returns a copy of ctx carrying userID as the authenticated user,
+// for code authenticating requests by other means, such as tests.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	return context.WithValue(ctx, identityKey, Principal{UserID: userID})
+}
+
+// unauthorized responds with 401 and a JSON body carrying a machine-readable
+// code next to the message, so clients can tell e.g. an expired token, which a
+// refresh fixes, from one issued for another audience, which it does not.
+func unauthorized(w http.ResponseWriter, code, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	authError(w, http.StatusUnauthorized, code, message)
+}
+
+// forbidden responds with 403 and the same JSON body as unauthorized.
+func forbidden(w http.ResponseWriter, code, message string) {
+	authError(w, http.StatusForbidden, code, message)
+}
+
+func authError(w http.ResponseWriter, status int, code, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message, "code": code})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// tokenErrorCode classifies a parseToken error.
+func tokenErrorCode(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token_expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return "token_not_yet_valid"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "invalid_audience"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "invalid_issuer"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "missing_claim"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, ErrSignatureInvalid):
+		return "invalid_signature"
+	case errors.Is(err, ErrSignatureExpired):
+		return "signature_expired"
+	case errors.Is(err, ErrUnknownKey):
+		return "unknown_key"
+	default:
+		return "invalid_token"
+	}
+}
+
+// credential returns the API key, signing key ID or token r presents, for
+// FailureThrottle.
+func (c AuthConfig) credential(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" && c.APIKeys != nil {
+		return key
+	}
+	if r.Header.Get(SignatureHeader) != "" && len(c.SigningKeys) > 0 {
+		return "signature:" + r.Header.Get(SignatureKeyHeader)
+	}
+	if token, ok := extractBearerToken(r); ok {
+		return token
+	}
+	token, _ := c.sessionToken(r)
+	return token
+}
+
 // extractBearerToken pulls the token from "Authorization: Bearer <token>".
 func extractBearerToken(r *http.Request) (string, bool) {
 	auth := r.Header.Get("Authorization")
@@ -84,10 +456,11 @@ func extractBearerToken(r *http.Request) (string, bool) {
 	return parts[1], true
 }
 
-// parseToken validates the JWT string. If secret is empty, only alg=none is
-// accepted (for dev/test). Otherwise HS256 is required.
-func parseToken(tokenString, secret string) (jwt.MapClaims, error) {
-	if secret == "" {
+// parseToken validates the JWT string. If no signing key is configured, only
+// alg=none is accepted (for dev/test). Otherwise the token must be HS256- or
+// RS256-signed with the key its "kid" header selects.
+func parseToken(ctx context.Context, tokenString string, cfg AuthConfig) (jwt.MapClaims, error) {
+	if !cfg.signsTokens() {
 		// Development mode: accept unsigned tokens only.
 		token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
 		if err != nil {
@@ -103,10 +476,20 @@ func parseToken(tokenString, secret string) (jwt.MapClaims, error) {
 		return claims, nil
 	}
 
-	// Production mode: require HS256.
+	// Production mode: require a signature by a configured key.
+	opts := []jwt.ParserOption{jwt.WithValidMethods(cfg.validMethods())}
+	if len(cfg.Audiences) > 0 {
+		opts = append(opts, jwt.WithAudience(cfg.Audiences...))
+	}
+	if cfg.Leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(cfg.Leeway))
+	}
+	if len(cfg.Issuers) == 1 {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuers[0]))
+	}
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-		return []byte(secret), nil
-	}, jwt.WithValidMethods([]string{"HS256"}))
+		return cfg.verificationKey(ctx, t)
+	}, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
@@ -115,5 +498,16 @@ func parseToken(tokenString, secret string) (jwt.MapClaims, error) {
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token claims")
 	}
+	countKeyUse(token)
+	// jwt.WithIssuer takes a single issuer; several are checked here.
+	if len(cfg.Issuers) > 1 {
+		iss, _ := claims.GetIssuer()
+		if iss == "" {
+			return nil, fmt.Errorf("invalid token: %w: iss claim is required", jwt.ErrTokenRequiredClaimMissing)
+		}
+		if !slices.Contains(cfg.Issuers, iss) {
+			return nil, fmt.Errorf("invalid token: %w", jwt.ErrTokenInvalidIssuer)
+		}
+	}
 	return claims, nil
 }
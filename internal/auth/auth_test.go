@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/giannis84/platform-go-challenge/internal/logging"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -196,9 +200,184 @@ func TestJWTMiddleware_SignedMode(t *testing.T) {
 	}
 }
 
+func TestJWTMiddleware_IssuerAndAudience(t *testing.T) {
+	const secret = "test-secret"
+	token := func(claims jwt.MapClaims) string {
+		claims["sub"] = "user7"
+		if _, ok := claims["exp"]; !ok {
+			claims["exp"] = time.Now().Add(time.Hour).Unix()
+		}
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		issuers  []string
+		token    string
+		wantCode string
+	}{
+		{name: "matching claims", issuers: []string{"https://idp"}, token: token(jwt.MapClaims{"iss": "https://idp", "aud": "favourites"})},
+		{name: "audience in list", issuers: []string{"https://idp"}, token: token(jwt.MapClaims{"iss": "https://idp", "aud": []string{"other", "favourites"}})},
+		{name: "second of several issuers", issuers: []string{"https://idp", "https://idp2"}, token: token(jwt.MapClaims{"iss": "https://idp2", "aud": "favourites"})},
+		{name: "wrong audience", issuers: []string{"https://idp"}, token: token(jwt.MapClaims{"iss": "https://idp", "aud": "billing"}), wantCode: "invalid_audience"},
+		{name: "missing audience", issuers: []string{"https://idp"}, token: token(jwt.MapClaims{"iss": "https://idp"}), wantCode: "missing_claim"},
+		{name: "wrong issuer", issuers: []string{"https://idp"}, token: token(jwt.MapClaims{"iss": "https://evil", "aud": "favourites"}), wantCode: "invalid_issuer"},
+		{name: "wrong issuer of several", issuers: []string{"https://idp", "https://idp2"}, token: token(jwt.MapClaims{"iss": "https://evil", "aud": "favourites"}), wantCode: "invalid_issuer"},
+		{name: "expired", issuers: []string{"https://idp"}, token: token(jwt.MapClaims{"iss": "https://idp", "aud": "favourites", "exp": time.Now().Add(-time.Hour).Unix()}), wantCode: "token_expired"},
+		{name: "bad signature", issuers: []string{"https://idp"}, token: signedToken("user7", "wrong", time.Now().Add(time.Hour)), wantCode: "invalid_signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := JWTMiddleware(AuthConfig{Secret: secret, Issuers: tt.issuers, Audiences: []string{"favourites"}})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			mw(dummyHandler).ServeHTTP(rr, req)
+
+			if tt.wantCode == "" {
+				if rr.Code != http.StatusOK {
+					t.Errorf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+				}
+				return
+			}
+			if rr.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want 401", rr.Code)
+			}
+			var body struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding body %q: %v", rr.Body.String(), err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q; body: %s", body.Code, tt.wantCode, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_Leeway(t *testing.T) {
+	const secret = "test-secret"
+	token := func(claims jwt.MapClaims) string {
+		claims["sub"] = "user7"
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return s
+	}
+	justExpired := token(jwt.MapClaims{"exp": time.Now().Add(-5 * time.Second).Unix()})
+	longExpired := token(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})
+	issuedAhead := token(jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix(), "nbf": time.Now().Add(5 * time.Second).Unix()})
+
+	tests := []struct {
+		name       string
+		leeway     time.Duration
+		token      string
+		wantStatus int
+	}{
+		{name: "just expired without leeway", token: justExpired, wantStatus: http.StatusUnauthorized},
+		{name: "just expired within leeway", leeway: 30 * time.Second, token: justExpired, wantStatus: http.StatusOK},
+		{name: "expired beyond leeway", leeway: 30 * time.Second, token: longExpired, wantStatus: http.StatusUnauthorized},
+		{name: "not yet valid without leeway", token: issuedAhead, wantStatus: http.StatusUnauthorized},
+		{name: "not yet valid within leeway", leeway: 30 * time.Second, token: issuedAhead, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := JWTMiddleware(AuthConfig{Secret: secret, Leeway: tt.leeway})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			mw(dummyHandler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestUserIDFromContext_EmptyWhenNoMiddleware(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	if uid := UserIDFromContext(req.Context()); uid != "" {
 		t.Errorf("expected empty user ID, got %q", uid)
 	}
 }
+
+func TestRequireRole(t *testing.T) {
+	token := func(sub string, claims jwt.MapClaims) string {
+		claims["sub"] = sub
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		rolesClaim string
+		token      string
+		wantStatus int
+	}{
+		{name: "admin user allowed", token: token("admin1", jwt.MapClaims{}), wantStatus: http.StatusOK},
+		{name: "regular user forbidden", token: token("user1", jwt.MapClaims{}), wantStatus: http.StatusForbidden},
+		{name: "roles claim", token: token("user1", jwt.MapClaims{"roles": []string{"viewer", "admin"}}), wantStatus: http.StatusOK},
+		{name: "other role", token: token("user1", jwt.MapClaims{"roles": []string{"viewer"}}), wantStatus: http.StatusForbidden},
+		{name: "space-separated roles", token: token("user1", jwt.MapClaims{"roles": "viewer admin"}), wantStatus: http.StatusOK},
+		{name: "nested claim", rolesClaim: "realm_access.roles", token: token("user1", jwt.MapClaims{"realm_access": map[string]any{"roles": []string{"admin"}}}), wantStatus: http.StatusOK},
+		{name: "nested claim ignores top level", rolesClaim: "realm_access.roles", token: token("user1", jwt.MapClaims{"roles": []string{"admin"}}), wantStatus: http.StatusForbidden},
+		{name: "malformed nested claim", rolesClaim: "realm_access.roles", token: token("user1", jwt.MapClaims{"realm_access": "admin"}), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := AuthConfig{AllowUnsignedTokens: true, AdminUsers: []string{"admin1"}, RolesClaim: tt.rolesClaim}
+			handler := JWTMiddleware(cfg)(RequireRole(RoleAdmin)(dummyHandler))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_SecurityEvents(t *testing.T) {
+	var buf bytes.Buffer
+	logging.SetSecurityLog(&buf)
+	t.Cleanup(func() { logging.SetSecurityLog(nil) })
+
+	cfg := AuthConfig{AllowUnsignedTokens: true, AdminUsers: []string{"support1"}, SigningKeys: map[string]string{"partner1": "secret"}}
+	tests := []struct {
+		name      string
+		header    map[string]string
+		wantEvent string
+		wantCode  string
+	}{
+		{name: "missing token", wantEvent: logging.EventAuthFailure, wantCode: "missing_token"},
+		{name: "token without subject", header: map[string]string{"Authorization": "Bearer " + unsignedTokenWithoutSubjectClaim(time.Now().Add(time.Hour))}, wantEvent: logging.EventAuthFailure, wantCode: "missing_claim"},
+		{name: "invalid signature", header: map[string]string{SignatureHeader: "bad", SignatureKeyHeader: "partner1", SignatureTimestampHeader: strconv.FormatInt(time.Now().Unix(), 10)}, wantEvent: logging.EventInvalidSignature, wantCode: "invalid_signature"},
+		{name: "admin impersonation", header: map[string]string{"Authorization": "Bearer " + unsignedToken("support1", time.Now().Add(time.Hour)), OnBehalfOfHeader: "user7"}, wantEvent: logging.EventImpersonation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			JWTMiddleware(cfg)(ActOnBehalf(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))).ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("expected one security event, got %q: %v", buf.String(), err)
+			}
+			if entry["event_code"] != tt.wantEvent || (tt.wantCode != "" && entry["code"] != tt.wantCode) {
+				t.Errorf("unexpected security event: %v", entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Denylist holds the IDs ("jti" claims) of revoked tokens. Entries only need
+// to outlive the tokens they name, so each carries an expiry.
+type Denylist interface {
+	// Revoke denies the token jti until until.
+	Revoke(ctx context.Context, jti string, until time.Time) error
+	// IsRevoked reports whether the token jti is denied.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// MemoryDenylist is a Denylist local to the process. Use RedisDenylist to
+// share revocations between instances.
+type MemoryDenylist struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+// NewMemoryDenylist returns an empty in-memory denylist.
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{entries: make(map[string]time.Time)}
+}
+
+// Revoke denies jti until until, dropping entries that have expired.
+func (d *MemoryDenylist) Revoke(_ context.Context, jti string, until time.Time) error {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, exp := range d.entries {
+		if !exp.After(now) {
+			delete(d.entries, id)
+		}
+	}
+	if until.After(d.entries[jti]) {
+		d.entries[jti] = until
+	}
+	return nil
+}
+
+// IsRevoked reports whether jti is denied and its entry has not expired.
+func (d *MemoryDenylist) IsRevoked(_ context.Context, jti string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	exp, ok := d.entries[jti]
+	return ok && exp.After(time.Now()), nil
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisKeyPrefix = "revoked-jti:"
+	redisTimeout   = 2 * time.Second
+)
+
+// RedisDenylist is a Denylist kept in Redis, so every instance sees the same
+// revocations. Entries expire in Redis along with the tokens they name.
+//
+// It speaks just enough of the Redis protocol for SET and EXISTS over a
+// single connection, which is re-established after any error.
+type RedisDenylist struct {
+	addr     string
+	username string
+	password string
+	db       int
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewRedisDenylist returns a denylist stored in the Redis server at rawURL,
+// of the form redis://[[user]:password@]host[:port][/db]. No connection is
+// made until the denylist is used.
+func NewRedisDenylist(rawURL string) (*RedisDenylist, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "redis" || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid Redis URL %q: want redis://[[user]:password@]host[:port][/db]", rawURL)
+	}
+	d := &RedisDenylist{addr: u.Host}
+	if u.Port() == "" {
+		d.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		d.username = u.User.Username()
+		d.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if d.db, err = strconv.Atoi(db); err != nil || d.db < 0 {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+	return d, nil
+}
+
+// Revoke denies jti until until. Entries already expired are not stored.
+func (d *RedisDenylist) Revoke(ctx context.Context, jti string, until time.Time) error {
+	ttl := time.Until(until).Milliseconds()
+	if ttl <= 0 {
+		return nil
+	}
+	if _, err := d.do(ctx, "SET", redisKeyPrefix+jti, "1", "PX", strconv.FormatInt(ttl, 10)); err != nil {
+		return fmt.Errorf("storing revocation: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether jti is denied.
+func (d *RedisDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	reply, err := d.do(ctx, "EXISTS", redisKeyPrefix+jti)
+	if err != nil {
+		return false, fmt.Errorf("checking revocation: %w", err)
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("checking revocation: unexpected reply %v", reply)
+	}
+	return n > 0, nil
+}
+
+// do sends one command and returns its reply: a string, an int64 or nil.
+func (d *RedisDenylist) do(ctx context.Context, args ...string) (any, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > redisTimeout {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	if d.conn == nil {
+		if err := d.connect(ctx, deadline); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := d.roundTrip(deadline, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// The connection state is unknown after I/O errors.
+		d.conn.Close()
+		d.conn = nil
+	}
+	return reply, err
+}
+
+func (d *RedisDenylist) connect(ctx context.Context, deadline time.Time) error {
+	dialCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(dialCtx, "tcp", d.addr)
+	if err != nil {
+		return fmt.Errorf("connecting to Redis: %w", err)
+	}
+	d.conn, d.rd = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if d.password != "" {
+		if d.username != "" {
+			setup = append(setup, []string{"AUTH", d.username, d.password})
+		} else {
+			setup = append(setup, []string{"AUTH", d.password})
+		}
+	}
+	if d.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(d.db)})
+	}
+	for _, cmd := range setup {
+		if _, err := d.roundTrip(deadline, cmd...); err != nil {
+			conn.Close()
+			d.conn = nil
+			return fmt.Errorf("setting up Redis connection: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *RedisDenylist) roundTrip(deadline time.Time, args ...string) (any, error) {
+	if err := d.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := d.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return d.readReply()
+}
+
+// redisError is an error reply; the connection stays usable after one.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (d *RedisDenylist) readReply() (any, error) {
+	line, err := d.rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(d.rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	}
+	return nil, fmt.Errorf("redis: unsupported reply %q", line)
+}
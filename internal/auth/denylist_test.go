@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	ctx := context.Background()
+	d := NewMemoryDenylist()
+	d.Revoke(ctx, "expired", time.Now().Add(-time.Second))
+	d.Revoke(ctx, "active", time.Now().Add(time.Hour))
+
+	for jti, want := range map[string]bool{"active": true, "expired": false, "unknown": false} {
+		if got, _ := d.IsRevoked(ctx, jti); got != want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", jti, got, want)
+		}
+	}
+
+	// An earlier expiry does not shorten a revocation.
+	d.Revoke(ctx, "active", time.Now().Add(-time.Second))
+	if got, _ := d.IsRevoked(ctx, "active"); !got {
+		t.Error("expected the longer revocation to be kept")
+	}
+	if _, ok := d.entries["expired"]; ok {
+		t.Error("expected expired entries to be dropped")
+	}
+}
+
+// fakeRedis serves SET, EXISTS, AUTH and SELECT from a map, ignoring expiry.
+func fakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	keys := map[string]bool{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				authed := password == ""
+				for {
+					args, err := readCommand(rd)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					switch cmd := strings.ToUpper(args[0]); {
+					case cmd == "AUTH":
+						authed = args[len(args)-1] == password
+						if authed {
+							io.WriteString(conn, "+OK\r\n")
+						} else {
+							io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+						}
+					case !authed:
+						io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+					case cmd == "SELECT":
+						io.WriteString(conn, "+OK\r\n")
+					case cmd == "SET":
+						keys[args[1]] = true
+						io.WriteString(conn, "+OK\r\n")
+					case cmd == "EXISTS":
+						n := 0
+						if keys[args[1]] {
+							n = 1
+						}
+						io.WriteString(conn, ":"+strconv.Itoa(n)+"\r\n")
+					default:
+						io.WriteString(conn, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisDenylist(t *testing.T) {
+	ctx := context.Background()
+	addr := fakeRedis(t, "secret")
+
+	d, err := NewRedisDenylist("redis://:secret@" + addr + "/2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Revoke(ctx, "jti1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for jti, want := range map[string]bool{"jti1": true, "jti2": false} {
+		got, err := d.IsRevoked(ctx, jti)
+		if err != nil || got != want {
+			t.Errorf("IsRevoked(%q) = %v, %v; want %v", jti, got, err, want)
+		}
+	}
+
+	wrong, _ := NewRedisDenylist("redis://:wrong@" + addr)
+	if _, err := wrong.IsRevoked(ctx, "jti1"); err == nil {
+		t.Error("expected an error with the wrong password")
+	}
+
+	for _, bad := range []string{"http://" + addr, "redis://", "redis://" + addr + "/x"} {
+		if _, err := NewRedisDenylist(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestJWTMiddleware_Denylist(t *testing.T) {
+	const secret = "test-secret"
+	denylist := NewMemoryDenylist()
+	denylist.Revoke(context.Background(), "revoked", time.Now().Add(time.Hour))
+	mw := JWTMiddleware(AuthConfig{Secret: secret, Denylist: denylist})
+
+	token := func(jti string) string {
+		claims := jwt.MapClaims{"sub": "user7", "exp": time.Now().Add(time.Hour).Unix()}
+		if jti != "" {
+			claims["jti"] = jti
+		}
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		jti        string
+		wantStatus int
+	}{
+		{name: "active token", jti: "active", wantStatus: http.StatusOK},
+		{name: "token without jti", wantStatus: http.StatusOK},
+		{name: "revoked token", jti: "revoked", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token(tt.jti))
+			rr := httptest.NewRecorder()
+			mw(dummyHandler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && !strings.Contains(rr.Body.String(), "token_revoked") {
+				t.Errorf("expected code token_revoked, got %s", rr.Body.String())
+			}
+		})
+	}
+}
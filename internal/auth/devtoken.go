@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DevTokenTTL is how long tokens minted by IssueDevToken stay valid.
+const DevTokenTTL = time.Hour
+
+// ErrDevTokenUnsupported is returned by IssueDevToken unless cfg accepts
+// unsigned tokens and has no signing key (see AuthConfig.DevTokens).
+var ErrDevTokenUnsupported = errors.New("tokens can only be minted in unsigned mode")
+
+// DevTokens reports whether development tokens may be minted: only when
+// unsigned tokens are allowed and no key verifies signed ones, so a minted
+// token is never one a production deployment would accept.
+func (c AuthConfig) DevTokens() bool {
+	return c.AllowUnsignedTokens && !c.signsTokens()
+}
+
+// IssueDevToken mints an unsigned (alg=none) token for userID, valid for
+// DevTokenTTL from now. It carries the first configured issuer and audience,
+// if any. It is meant for local development only and fails with
+// ErrDevTokenUnsupported unless cfg.DevTokens.
+func IssueDevToken(cfg AuthConfig, userID string, now time.Time) (string, time.Time, error) {
+	if !cfg.DevTokens() {
+		return "", time.Time{}, ErrDevTokenUnsupported
+	}
+	expiresAt := now.Add(DevTokenTTL)
+	claims := jwt.MapClaims{"sub": userID, "iat": now.Unix(), "exp": expiresAt.Unix()}
+	if len(cfg.Issuers) > 0 {
+		claims["iss"] = cfg.Issuers[0]
+	}
+	if len(cfg.Audiences) > 0 {
+		claims["aud"] = cfg.Audiences[0]
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	return token, expiresAt, err
+}
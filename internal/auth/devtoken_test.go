@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIssueDevToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cfg     AuthConfig
+		wantErr error
+	}{
+		{name: "unsigned mode", cfg: AuthConfig{AllowUnsignedTokens: true, Issuers: []string{"https://idp.example.com"}, Audiences: []string{"favourites"}}},
+		{name: "unsigned tokens not allowed", cfg: AuthConfig{}, wantErr: ErrDevTokenUnsupported},
+		{name: "HS256 secret", cfg: AuthConfig{AllowUnsignedTokens: true, Secret: "prod-secret"}, wantErr: ErrDevTokenUnsupported},
+		{name: "RS256 keys", cfg: AuthConfig{AllowUnsignedTokens: true, RSAKeys: map[string]*rsa.PublicKey{"k1": &rsaKey.PublicKey}}, wantErr: ErrDevTokenUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			token, expiresAt, err := IssueDevToken(tt.cfg, "user7", now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !expiresAt.Equal(now.Add(DevTokenTTL)) {
+				t.Errorf("expires at %s, want %s", expiresAt, now.Add(DevTokenTTL))
+			}
+
+			var gotUser string
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			JWTMiddleware(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = UserIDFromContext(r.Context())
+			})).ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK || gotUser != "user7" {
+				t.Errorf("token not accepted: status %d, user %q, body %s", rr.Code, gotUser, rr.Body.String())
+			}
+		})
+	}
+}
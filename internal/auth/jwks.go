@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+const (
+	// DefaultJWKSRefresh is how often a JWKS is refetched when no interval is given.
+	DefaultJWKSRefresh = 15 * time.Minute
+
+	// minJWKSRefetch limits the refetches triggered by tokens with an unknown
+	// key ID, so forged kids cannot flood the identity provider. It is also
+	// the first retry delay after a failed fetch.
+	minJWKSRefetch = 30 * time.Second
+
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// jwksFetches counts JWKS fetches by outcome ("succeeded", "failed") and the
+// refetches requested by tokens with an unknown key ID ("requested"),
+// published by expvar.
+var jwksFetches = expvar.NewMap("jwks_fetches")
+
+// JWKS is a cached set of RSA public keys published as a JSON Web Key Set by
+// an identity provider. Keys are only fetched by Run, in the background:
+// verifying a token never waits for the provider. Run refreshes the set
+// periodically, retries failed fetches while the cached keys keep being used,
+// and refetches the set when a token names a key it does not hold.
+type JWKS struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	// refetch wakes Run for an early refresh; ready is closed once keys have
+	// been fetched.
+	refetch   chan struct{}
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetched   time.Time
+	attempted time.Time
+}
+
+// NewJWKS returns a key set fetched from url and refreshed every refresh
+// (DefaultJWKSRefresh when zero). No request is made until Run is started.
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	return &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+		refetch: make(chan struct{}, 1),
+		ready:   make(chan struct{}),
+	}
+}
+
+// Key returns the key with ID kid. A token without a kid matches the only key
+// of a single-key set. Until Run has first fetched the set, Key waits for it,
+// for at most jwksFetchTimeout. An unknown kid asks Run for a refetch, so a
+// rotated key is accepted shortly after.
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	select {
+	case <-j.ready:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for JWKS: %w", ctx.Err())
+	case <-time.After(jwksFetchTimeout):
+		return nil, errors.New("JWKS not fetched yet")
+	}
+
+	j.mu.RLock()
+	key, ok := j.lookup(kid)
+	recent := time.Since(j.attempted) < minJWKSRefetch
+	j.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !recent {
+		select {
+		case j.refetch <- struct{}{}:
+			jwksFetches.Add("requested", 1)
+		default:
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+// HealthCheck reports how many keys are cached and when they were fetched;
+// it fails until the set is first fetched and once it goes unrefreshed for
+// three refresh intervals, as rotated keys are then not picked up.
+func (j *JWKS) HealthCheck(context.Context) (map[string]any, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.fetched.IsZero() {
+		return nil, errors.New("JWKS not fetched yet")
+	}
+	details := map[string]any{"keys": len(j.keys), "fetched_at": j.fetched.UTC()}
+	if age := time.Since(j.fetched); age > 3*j.refresh {
+		return details, fmt.Errorf("JWKS last fetched %s ago", age.Round(time.Second))
+	}
+	return details, nil
+}
+
+func (j *JWKS) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// Refresh fetches the key set, replacing the cached keys. The cached keys are
+// kept when the fetch fails.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.mu.Lock()
+	j.attempted = time.Now()
+	j.mu.Unlock()
+
+	keys, err := j.fetch(ctx)
+	if err != nil {
+		jwksFetches.Add("failed", 1)
+		return err
+	}
+	jwksFetches.Add("succeeded", 1)
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetched = time.Now()
+	j.mu.Unlock()
+	j.readyOnce.Do(func() { close(j.ready) })
+	return nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building JWKS request: %w", err)
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decoding JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// Encryption keys and other key types cannot verify RS256 tokens.
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("decoding JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS holds no RSA signing keys")
+	}
+	return keys, nil
+}
+
+// Run fetches the key set right away and then refreshes it about every
+// refresh interval until ctx is done, jittered by up to a tenth so that
+// instances started together do not fetch together. A failed fetch is logged
+// and retried after minJWKSRefetch, doubling up to the refresh interval,
+// while the cached keys stay in use. Tokens with an unknown key ID trigger an
+// early refresh, at most once every minJWKSRefetch.
+func (j *JWKS) Run(ctx context.Context, logger *slog.Logger) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-j.refetch:
+		}
+
+		next := j.refresh
+		if err := j.Refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			next = min(minJWKSRefetch<<min(failures-1, 16), j.refresh)
+			logger.Warn("failed to refresh JWKS", slog.String("url", j.url), slog.Int("failures", failures), slog.String(logging.ErrorKey, err.Error()))
+		} else {
+			failures = 0
+		}
+		timer.Reset(jitter(next))
+	}
+}
+
+// jitter returns d shifted randomly by up to a tenth either way.
+func jitter(d time.Duration) time.Duration {
+	spread := d / 10
+	if spread <= 0 {
+		return d
+	}
+	return d - spread + rand.N(2*spread+1)
+}
+
+// jsonWebKey is the subset of an RFC 7517 key used for RSA signatures.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA parameters")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksServer publishes the public halves of keys (kid -> key) and counts the
+// requests it served.
+func jwksServer(t *testing.T, keys map[string]*rsa.PrivateKey, mu *sync.Mutex) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits.Add(1)
+		var set struct {
+			Keys []jsonWebKey `json:"keys"`
+		}
+		for kid, key := range keys {
+			set.Keys = append(set.Keys, jsonWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// runJWKS starts refreshing jwks in the background until the test ends.
+func runJWKS(t *testing.T, jwks *JWKS) *JWKS {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		jwks.Run(ctx, slog.New(slog.DiscardHandler))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return jwks
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return false
+}
+
+func rsaToken(sub, kid string, key *rsa.PrivateKey, exp time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": sub, "exp": exp.Unix()})
+	token.Header["kid"] = kid
+	s, _ := token.SignedString(key)
+	return s
+}
+
+func TestJWTMiddleware_JWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	srv, _ := jwksServer(t, map[string]*rsa.PrivateKey{"k1": key}, new(sync.Mutex))
+
+	const secret = "test-secret"
+	mw := JWTMiddleware(AuthConfig{Secret: secret, JWKS: runJWKS(t, NewJWKS(srv.URL, time.Hour))})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{name: "RS256 token", token: rsaToken("user7", "k1", key, time.Now().Add(time.Hour)), wantStatus: http.StatusOK},
+		{name: "HS256 token alongside JWKS", token: signedToken("user7", secret, time.Now().Add(time.Hour)), wantStatus: http.StatusOK},
+		{name: "unknown key ID", token: rsaToken("user7", "k2", other, time.Now().Add(time.Hour)), wantStatus: http.StatusUnauthorized},
+		{name: "signed by another key", token: rsaToken("user7", "k1", other, time.Now().Add(time.Hour)), wantStatus: http.StatusUnauthorized},
+		{name: "expired RS256 token", token: rsaToken("user7", "k1", key, time.Now().Add(-time.Hour)), wantStatus: http.StatusUnauthorized},
+		{name: "unsigned token", token: unsignedToken("user7", time.Now().Add(time.Hour)), wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			mw(dummyHandler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWKS_Key(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	var mu sync.Mutex
+	keys := map[string]*rsa.PrivateKey{"k1": key}
+	srv, hits := jwksServer(t, keys, &mu)
+	jwks := runJWKS(t, NewJWKS(srv.URL, time.Hour))
+	ctx := context.Background()
+
+	// The first lookup waits for the background prefetch.
+	if _, err := jwks.Key(ctx, "k1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := jwks.Key(ctx, "k1"); err != nil || hits.Load() != 1 {
+		t.Errorf("expected the cached key to be reused, got %d fetches and error %v", hits.Load(), err)
+	}
+
+	// Unknown kids do not refetch right after a fetch.
+	_, err = jwks.Key(ctx, "k2")
+	time.Sleep(50 * time.Millisecond)
+	if !errors.Is(err, ErrUnknownKey) || hits.Load() != 1 {
+		t.Errorf("expected an unknown key error without a refetch, got %d fetches and error %v", hits.Load(), err)
+	}
+
+	// A rotated key is refetched in the background once the refetch interval
+	// has passed, and accepted from then on.
+	mu.Lock()
+	keys["k2"] = key
+	mu.Unlock()
+	jwks.mu.Lock()
+	jwks.attempted = time.Now().Add(-minJWKSRefetch)
+	jwks.mu.Unlock()
+	if _, err := jwks.Key(ctx, "k2"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected an unknown key error before the refetch, got %v", err)
+	}
+	if !waitFor(t, func() bool { return hits.Load() == 2 }) {
+		t.Fatalf("expected a background refetch, got %d fetches", hits.Load())
+	}
+	if !waitFor(t, func() bool { _, err := jwks.Key(ctx, "k2"); return err == nil }) {
+		t.Error("expected the rotated key after the refetch")
+	}
+
+	// Stale keys keep verifying while the provider is down.
+	failures := func() string {
+		if v := jwksFetches.Get("failed"); v != nil {
+			return v.String()
+		}
+		return "0"
+	}
+	failed := failures()
+	srv.Close()
+	if err := jwks.Refresh(ctx); err == nil {
+		t.Fatal("expected the refresh to fail")
+	}
+	if _, err := jwks.Key(ctx, "k1"); err != nil {
+		t.Errorf("expected the cached key while the JWKS is unreachable, got %v", err)
+	}
+	if got := failures(); got == failed {
+		t.Errorf("expected the failed fetch to be counted, got %s", got)
+	}
+}
+
+func TestJWKS_KeyBeforeFetch(t *testing.T) {
+	jwks := NewJWKS("http://jwks.invalid", time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := jwks.Key(ctx, "k1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected to wait for the first fetch, got %v", err)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		if d := jitter(time.Minute); d < 54*time.Second || d > 66*time.Second {
+			t.Fatalf("jitter(1m) = %s, want within 6s of 1m", d)
+		}
+	}
+}
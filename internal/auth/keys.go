@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"expvar"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey is returned for a token whose "kid" header names no
+// configured signing key.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// signingKeyUses counts verified tokens per signing key, keyed by
+// "<alg>:<kid>" ("<alg>:default" for tokens without a kid). It is published
+// by expvar as "jwt_signing_key_uses", so a rotation can be completed once
+// the old key stops being counted.
+var signingKeyUses = expvar.NewMap("jwt_signing_key_uses")
+
+// secret returns the HS256 secret for tokens without a kid.
+func (c AuthConfig) secret() string {
+	if c.SecretFunc != nil {
+		return c.SecretFunc()
+	}
+	return c.Secret
+}
+
+// signsTokens reports whether any key for verifying signed tokens is
+// configured.
+func (c AuthConfig) signsTokens() bool {
+	return c.secret() != "" || len(c.Secrets) > 0 || len(c.RSAKeys) > 0 || c.JWKS != nil
+}
+
+// validMethods lists the signing algorithms the configured keys verify.
+func (c AuthConfig) validMethods() []string {
+	var methods []string
+	if c.secret() != "" || len(c.Secrets) > 0 {
+		methods = append(methods, "HS256")
+	}
+	if len(c.RSAKeys) > 0 || c.JWKS != nil {
+		methods = append(methods, "RS256")
+	}
+	return methods
+}
+
+// verificationKey returns the key verifying t, chosen by its algorithm and
+// "kid" header. HS256 tokens without a kid are verified with Secret; RS256
+// keys configured in RSAKeys take precedence over the JWKS.
+func (c AuthConfig) verificationKey(ctx context.Context, t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if t.Method.Alg() == "RS256" {
+		if key, ok := c.RSAKeys[kid]; ok {
+			return key, nil
+		}
+		if c.JWKS != nil {
+			return c.JWKS.Key(ctx, kid)
+		}
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	if kid == "" {
+		secret := c.secret()
+		if secret == "" {
+			return nil, fmt.Errorf("%w: token has no kid header", ErrUnknownKey)
+		}
+		return []byte(secret), nil
+	}
+	if secret, ok := c.Secrets[kid]; ok {
+		return []byte(secret), nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+// countKeyUse records that t, whose signature has been verified, was signed
+// by the key its header names.
+func countKeyUse(t *jwt.Token) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = "default"
+	}
+	signingKeyUses.Add(t.Method.Alg()+":"+kid, 1)
+}
+
+// LoadRSAPublicKeys reads PEM-encoded RSA public keys from files (kid ->
+// path) for AuthConfig.RSAKeys.
+func LoadRSAPublicKeys(files map[string]string) (map[string]*rsa.PublicKey, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	keys := make(map[string]*rsa.PublicKey, len(files))
+	for kid, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading public key %q: %w", kid, err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key %q: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func keyedToken(sub, kid, secret string, exp time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub, "exp": exp.Unix()})
+	token.Header["kid"] = kid
+	s, _ := token.SignedString([]byte(secret))
+	return s
+}
+
+func keyUses(key string) int64 {
+	if v, ok := signingKeyUses.Get(key).(interface{ Value() int64 }); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestJWTMiddleware_SigningKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	exp := time.Now().Add(time.Hour)
+	cfg := AuthConfig{
+		Secret:  "legacy-secret",
+		Secrets: map[string]string{"2026-01": "old-secret", "2026-07": "new-secret"},
+		RSAKeys: map[string]*rsa.PublicKey{"rsa1": &rsaKey.PublicKey},
+	}
+
+	tests := []struct {
+		name       string
+		cfg        AuthConfig
+		token      string
+		wantStatus int
+		wantCode   string
+		wantKey    string
+	}{
+		{name: "current key", cfg: cfg, token: keyedToken("user7", "2026-07", "new-secret", exp), wantStatus: http.StatusOK, wantKey: "HS256:2026-07"},
+		{name: "previous key", cfg: cfg, token: keyedToken("user7", "2026-01", "old-secret", exp), wantStatus: http.StatusOK, wantKey: "HS256:2026-01"},
+		{name: "no kid uses the default secret", cfg: cfg, token: signedToken("user7", "legacy-secret", exp), wantStatus: http.StatusOK, wantKey: "HS256:default"},
+		{name: "static RSA key", cfg: cfg, token: rsaToken("user7", "rsa1", rsaKey, exp), wantStatus: http.StatusOK, wantKey: "RS256:rsa1"},
+		{name: "kid signed with another key's secret", cfg: cfg, token: keyedToken("user7", "2026-07", "old-secret", exp), wantStatus: http.StatusUnauthorized, wantCode: "invalid_signature"},
+		{name: "retired kid", cfg: cfg, token: keyedToken("user7", "2025-07", "retired-secret", exp), wantStatus: http.StatusUnauthorized, wantCode: "unknown_key"},
+		{name: "unknown RSA kid", cfg: cfg, token: rsaToken("user7", "rsa2", rsaKey, exp), wantStatus: http.StatusUnauthorized, wantCode: "unknown_key"},
+		{name: "no kid without a default secret", cfg: AuthConfig{Secrets: cfg.Secrets}, token: signedToken("user7", "legacy-secret", exp), wantStatus: http.StatusUnauthorized, wantCode: "unknown_key"},
+		{name: "keys only reject unsigned tokens", cfg: AuthConfig{Secrets: cfg.Secrets, AllowUnsignedTokens: true}, token: unsignedToken("user7", exp), wantStatus: http.StatusUnauthorized, wantCode: "invalid_signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := keyUses(tt.wantKey)
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			JWTMiddleware(tt.cfg)(dummyHandler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var body struct {
+					Code string `json:"code"`
+				}
+				json.NewDecoder(rr.Body).Decode(&body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+			}
+			if tt.wantKey != "" {
+				if got := keyUses(tt.wantKey) - before; got != 1 {
+					t.Errorf("uses of %s increased by %d, want 1", tt.wantKey, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRSAPublicKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rsa1.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+
+	keys, err := LoadRSAPublicKeys(map[string]string{"rsa1": path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !keys["rsa1"].Equal(&key.PublicKey) {
+		t.Errorf("loaded key does not match the written one")
+	}
+	if _, err := LoadRSAPublicKeys(map[string]string{"rsa1": garbage}); err == nil {
+		t.Error("expected an error for a file without a PEM key")
+	}
+	if _, err := LoadRSAPublicKeys(map[string]string{"rsa1": filepath.Join(dir, "missing.pem")}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestJWTMiddleware_SecretFunc(t *testing.T) {
+	secret := "first"
+	mw := JWTMiddleware(AuthConfig{SecretFunc: func() string { return secret }})
+	status := func(token string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		mw(dummyHandler).ServeHTTP(rr, req)
+		return rr.Code
+	}
+	exp := time.Now().Add(time.Hour)
+
+	if got := status(signedToken("user7", "first", exp)); got != http.StatusOK {
+		t.Errorf("status = %d before rotation, want 200", got)
+	}
+	secret = "second"
+	if got := status(signedToken("user7", "second", exp)); got != http.StatusOK {
+		t.Errorf("status = %d with the rotated secret, want 200", got)
+	}
+	if got := status(signedToken("user7", "first", exp)); got != http.StatusUnauthorized {
+		t.Errorf("status = %d with the replaced secret, want 401", got)
+	}
+}
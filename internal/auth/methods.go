@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Authentication methods, as listed in AuthConfig.Methods and recorded in
+// Principal.Method.
+const (
+	// MethodAPIKey recognises requests with an X-API-Key header.
+	MethodAPIKey = "api_key"
+	// MethodSignature recognises requests with an X-Signature header.
+	MethodSignature = "signature"
+	// MethodJWT recognises requests with an Authorization header or, with
+	// AuthConfig.SessionCookie set, the session cookie.
+	MethodJWT = "jwt"
+	// MethodClientCert recognises requests made with a verified client
+	// certificate.
+	MethodClientCert = "mtls"
+)
+
+// DefaultMethods is the order methods are tried in when AuthConfig.Methods is
+// empty. Credentials a client sends explicitly come before its certificate.
+var DefaultMethods = []string{MethodAPIKey, MethodSignature, MethodJWT, MethodClientCert}
+
+// methods returns the authentication methods to try, in order.
+func (c AuthConfig) methods() []string {
+	if len(c.Methods) == 0 {
+		return DefaultMethods
+	}
+	return c.Methods
+}
+
+// ValidateMethods checks that methods names known authentication methods,
+// each at most once.
+func ValidateMethods(methods []string) error {
+	for i, method := range methods {
+		if !slices.Contains(DefaultMethods, method) {
+			return fmt.Errorf("unknown authentication method %q (want %v)", method, DefaultMethods)
+		}
+		if slices.Contains(methods[:i], method) {
+			return fmt.Errorf("authentication method %q listed twice", method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestJWTMiddleware_Methods(t *testing.T) {
+	_, key, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lookup := func(ctx context.Context, hash string) (string, string, error) {
+		if hash == HashAPIKey(key) {
+			return "keyuser", "", nil
+		}
+		return "", "", nil
+	}
+	token := signedToken("tokenuser", "test-secret", time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name       string
+		methods    []string
+		apiKey     string
+		bearer     string
+		cert       bool
+		wantStatus int
+		wantUser   string
+		wantMethod string
+		wantCode   string
+	}{
+		{name: "token", bearer: token, wantStatus: http.StatusOK, wantUser: "tokenuser", wantMethod: MethodJWT},
+		{name: "API key before token", apiKey: key, bearer: token, wantStatus: http.StatusOK, wantUser: "keyuser", wantMethod: MethodAPIKey},
+		{name: "token before certificate", bearer: token, cert: true, wantStatus: http.StatusOK, wantUser: "tokenuser", wantMethod: MethodJWT},
+		{name: "certificate", cert: true, wantStatus: http.StatusOK, wantUser: "svc", wantMethod: MethodClientCert},
+		{name: "certificate first", methods: []string{MethodClientCert, MethodJWT}, bearer: token, cert: true, wantStatus: http.StatusOK, wantUser: "svc", wantMethod: MethodClientCert},
+		{name: "first recognising method decides", apiKey: "pgc_unknown", bearer: token, wantStatus: http.StatusUnauthorized, wantCode: "invalid_api_key"},
+		{name: "disabled method skipped", methods: []string{MethodJWT}, apiKey: key, bearer: token, wantStatus: http.StatusOK, wantUser: "tokenuser", wantMethod: MethodJWT},
+		{name: "only disabled method", methods: []string{MethodJWT}, apiKey: key, wantStatus: http.StatusUnauthorized, wantCode: "missing_token"},
+		{name: "no credential", wantStatus: http.StatusUnauthorized, wantCode: "missing_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := AuthConfig{Secret: "test-secret", APIKeys: lookup, Methods: tt.methods}
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.cert {
+				req = req.WithContext(context.WithValue(req.Context(), principalKey, "svc"))
+			}
+			var got Principal
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = PrincipalFromContext(r.Context())
+			})
+			rr := httptest.NewRecorder()
+			JWTMiddleware(cfg)(handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var body struct{ Code string }
+				_ = json.Unmarshal(rr.Body.Bytes(), &body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+				return
+			}
+			if got.UserID != tt.wantUser || got.Method != tt.wantMethod {
+				t.Errorf("principal = %q via %q, want %q via %q", got.UserID, got.Method, tt.wantUser, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_ServiceClientTenants(t *testing.T) {
+	lookup := func(ctx context.Context, hash string) (string, string, error) {
+		if hash == HashAPIKey("pgc_acme") {
+			return "keyuser", "acme", nil
+		}
+		return "", "", nil
+	}
+	cfg := AuthConfig{
+		APIKeys:        lookup,
+		SigningKeys:    map[string]string{"partner1": "shared-secret", "partner2": "other-secret"},
+		ServiceTenants: map[string]string{"partner1": "acme", "svc": "globex"},
+	}
+	sign := func(req *http.Request, key, secret string) {
+		now := time.Now()
+		req.Header.Set(SignatureKeyHeader, key)
+		req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		req.Header.Set(SignatureHeader, SignRequest(secret, req.Method, req.URL.RequestURI(), nil, now))
+	}
+
+	tests := []struct {
+		name       string
+		prepare    func(*http.Request) *http.Request
+		wantMethod string
+		wantTenant string
+	}{
+		{name: "API key", prepare: func(req *http.Request) *http.Request {
+			req.Header.Set(APIKeyHeader, "pgc_acme")
+			return req
+		}, wantMethod: MethodAPIKey, wantTenant: "acme"},
+		{name: "signed request", prepare: func(req *http.Request) *http.Request {
+			sign(req, "partner1", "shared-secret")
+			return req
+		}, wantMethod: MethodSignature, wantTenant: "acme"},
+		{name: "signed request of an unmapped key", prepare: func(req *http.Request) *http.Request {
+			sign(req, "partner2", "other-secret")
+			return req
+		}, wantMethod: MethodSignature},
+		{name: "client certificate", prepare: func(req *http.Request) *http.Request {
+			return req.WithContext(context.WithValue(req.Context(), principalKey, "svc"))
+		}, wantMethod: MethodClientCert, wantTenant: "globex"},
+		{name: "client certificate of an unmapped principal", prepare: func(req *http.Request) *http.Request {
+			return req.WithContext(context.WithValue(req.Context(), principalKey, "other"))
+		}, wantMethod: MethodClientCert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.prepare(httptest.NewRequest("GET", "/api/v1/favourites", nil))
+			var got Principal
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = PrincipalFromContext(r.Context())
+			})
+			rr := httptest.NewRecorder()
+			JWTMiddleware(cfg)(handler).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+			}
+			if got.Method != tt.wantMethod || got.TenantID != tt.wantTenant {
+				t.Errorf("principal via %q in tenant %q, want %q in %q", got.Method, got.TenantID, tt.wantMethod, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestValidateMethods(t *testing.T) {
+	tests := map[string]struct {
+		methods []string
+		wantErr bool
+	}{
+		"default":   {},
+		"reordered": {methods: []string{MethodClientCert, MethodJWT}},
+		"unknown":   {methods: []string{MethodJWT, "oauth"}, wantErr: true},
+		"duplicate": {methods: []string{MethodJWT, MethodAPIKey, MethodJWT}, wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := ValidateMethods(tt.methods); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMethods(%v) = %v, wantErr %v", tt.methods, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ClientAuth selects whether a listener asks TLS clients for certificates.
+type ClientAuth string
+
+const (
+	ClientAuthNone     ClientAuth = "none"     // no client certificates
+	ClientAuthOptional ClientAuth = "optional" // verified when presented
+	ClientAuthRequire  ClientAuth = "require"  // handshakes without one fail
+)
+
+// Certificate fields a client principal can be read from.
+const (
+	PrincipalCN       = "cn"        // subject common name
+	PrincipalSANDNS   = "san_dns"   // first DNS subject alternative name
+	PrincipalSANURI   = "san_uri"   // first URI SAN, e.g. a SPIFFE ID
+	PrincipalSANEmail = "san_email" // first email SAN
+)
+
+const principalKey contextKey = "clientPrincipal"
+
+// TLSConfig describes how a listener serves TLS.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM CA certificates client certificates must
+	// chain to. Required unless ClientAuth is none.
+	ClientCAFile string
+	ClientAuth   ClientAuth
+}
+
+// ServerTLS loads the certificates and returns the listener's tls.Config, or
+// nil when no certificate is configured and the listener serves plain HTTP.
+func (c TLSConfig) ServerTLS() (*tls.Config, error) {
+	if c.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading server certificate: %w", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	switch c.ClientAuth {
+	case "", ClientAuthNone:
+		return cfg, nil
+	case ClientAuthOptional:
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client auth mode %q", c.ClientAuth)
+	}
+	pem, err := os.ReadFile(c.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading client CA file: %w", err)
+	}
+	cfg.ClientCAs = x509.NewCertPool()
+	if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("client CA file holds no PEM certificates")
+	}
+	return cfg, nil
+}
+
+// CertificatePrincipal returns the principal named by the from field of cert
+// (one of the Principal constants), or an empty string when it is absent.
+func CertificatePrincipal(cert *x509.Certificate, from string) string {
+	switch from {
+	case PrincipalCN:
+		return cert.Subject.CommonName
+	case PrincipalSANDNS:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	case PrincipalSANURI:
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	case PrincipalSANEmail:
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	}
+	return ""
+}
+
+// ClientCertMiddleware returns HTTP middleware that places the principal of a
+// verified client certificate, read from the from field, into the request
+// context. Requests without one pass through unchanged.
+func ClientCertMiddleware(from string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			principal := CertificatePrincipal(r.TLS.VerifiedChains[0][0], from)
+			if principal == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := context.WithValue(r.Context(), principalKey, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientPrincipalFromContext returns the principal stored by
+// ClientCertMiddleware, or an empty string.
+func ClientPrincipalFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(principalKey).(string)
+	return v
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert issues a certificate from tmpl, signed by parent (self-signed when
+// nil), and returns it with its key.
+func testCert(t *testing.T, tmpl *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := tmpl, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writePEM writes cert and its key to PEM files in dir.
+func writePEM(t *testing.T, dir, name string, cert tls.Certificate) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestClientCertMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := testCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	client := testCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "reporting-service"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+	caFile, _ := writePEM(t, dir, "ca", ca)
+	certFile, keyFile := writePEM(t, dir, "server", server)
+
+	tests := []struct {
+		name       string
+		mode       ClientAuth
+		clientCert bool
+		wantUser   string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "certificate authenticates", mode: ClientAuthRequire, clientCert: true, wantStatus: http.StatusOK, wantUser: "reporting-service"},
+		{name: "required certificate missing", mode: ClientAuthRequire, wantErr: true},
+		{name: "optional certificate missing", mode: ClientAuthOptional, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverTLS, err := TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: tt.mode}.ServerTLS()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			srv := httptest.NewUnstartedServer(ClientCertMiddleware(PrincipalCN)(JWTMiddleware(AuthConfig{Secret: "s"})(dummyHandler)))
+			srv.TLS = serverTLS
+			srv.StartTLS()
+			defer srv.Close()
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.Leaf)
+			clientTLS := &tls.Config{RootCAs: roots}
+			if tt.clientCert {
+				clientTLS.Certificates = []tls.Certificate{client}
+			}
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+
+			resp, err := httpClient.Get(srv.URL)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("expected the handshake to fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantUser != "" && string(body) != tt.wantUser {
+				t.Errorf("user = %q, want %q", body, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestCertificatePrincipal(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/reporting")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "reporting-service"},
+		DNSNames:       []string{"reporting.internal"},
+		URIs:           []*url.URL{spiffe},
+		EmailAddresses: []string{"reporting@example.org"},
+	}
+
+	tests := map[string]string{
+		PrincipalCN:       "reporting-service",
+		PrincipalSANDNS:   "reporting.internal",
+		PrincipalSANURI:   "spiffe://example.org/reporting",
+		PrincipalSANEmail: "reporting@example.org",
+		"unknown":         "",
+	}
+	for from, want := range tests {
+		if got := CertificatePrincipal(cert, from); got != want {
+			t.Errorf("CertificatePrincipal(%q) = %q, want %q", from, got, want)
+		}
+	}
+	if got := CertificatePrincipal(&x509.Certificate{}, PrincipalSANURI); got != "" {
+		t.Errorf("expected no principal without SANs, got %q", got)
+	}
+}
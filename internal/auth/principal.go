@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultTenantClaim is the claim the tenant is read from when
+// AuthConfig.TenantClaim is empty.
+const DefaultTenantClaim = "tenant_id"
+
+const identityKey contextKey = "principal"
+
+// Principal is the identity that authenticated a request, as JWTMiddleware
+// read it from the token. It stays the caller's when a request acts on behalf
+// of another user (see ActOnBehalf), unlike UserIDFromContext.
+type Principal struct {
+	UserID string
+	// Method is the authentication method that recognised the request, one
+	// of the Method constants.
+	Method string
+	// Email is the token's "email" claim, if any.
+	Email string
+	// Roles are those RolesFromContext returns.
+	Roles []string
+	// TenantID is the claim named by AuthConfig.TenantClaim, if any.
+	TenantID string
+	// Claims are all the token's claims; nil for API keys, signed requests
+	// and client certificates.
+	Claims jwt.MapClaims
+}
+
+// PrincipalFromContext returns the Principal stored by JWTMiddleware, and
+// whether there is one.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(identityKey).(Principal)
+	return p, ok
+}
+
+// ClaimStrings returns the values of the claim at a dot-separated path, read
+// like the roles claim: an array of strings, or one string of space- or
+// comma-separated values.
+func (p Principal) ClaimStrings(path string) []string {
+	return claimStrings(p.Claims, path)
+}
+
+// tokenPrincipal builds the Principal of a verified token for sub.
+func (c AuthConfig) tokenPrincipal(claims jwt.MapClaims, sub string) Principal {
+	email, _ := claims["email"].(string)
+	p := Principal{UserID: sub, Method: MethodJWT, Email: email, Roles: userRoles(claims, sub, c), Claims: claims}
+	tenantClaim := c.TenantClaim
+	if tenantClaim == "" {
+		tenantClaim = DefaultTenantClaim
+	}
+	if tenant := claimStrings(claims, tenantClaim); len(tenant) == 1 {
+		p.TenantID = tenant[0]
+	}
+	return p
+}
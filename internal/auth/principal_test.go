@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWTMiddleware_Principal(t *testing.T) {
+	token := func(claims jwt.MapClaims) string {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		cfg    AuthConfig
+		token  string
+		want   Principal
+		groups []string
+	}{
+		{
+			name:   "all identity claims",
+			cfg:    AuthConfig{AllowUnsignedTokens: true},
+			token:  token(jwt.MapClaims{"sub": "user7", "email": "user7@example.com", "roles": []any{"editor"}, "tenant_id": "acme", "groups": "a b"}),
+			want:   Principal{UserID: "user7", Email: "user7@example.com", Roles: []string{"editor"}, TenantID: "acme"},
+			groups: []string{"a", "b"},
+		},
+		{
+			name:  "nested tenant claim and admin user",
+			cfg:   AuthConfig{AllowUnsignedTokens: true, TenantClaim: "org.id", AdminUsers: []string{"user7"}},
+			token: token(jwt.MapClaims{"sub": "user7", "org": map[string]any{"id": "globex"}}),
+			want:  Principal{UserID: "user7", Roles: []string{RoleAdmin}, TenantID: "globex"},
+		},
+		{
+			name:  "only a subject",
+			cfg:   AuthConfig{AllowUnsignedTokens: true},
+			token: token(jwt.MapClaims{"sub": "user7"}),
+			want:  Principal{UserID: "user7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Principal
+			var ok bool
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, ok = PrincipalFromContext(r.Context())
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			JWTMiddleware(tt.cfg)(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+			if !ok {
+				t.Fatal("expected a principal in the context")
+			}
+			if got.UserID != tt.want.UserID || got.Email != tt.want.Email || got.TenantID != tt.want.TenantID || !slices.Equal(got.Roles, tt.want.Roles) {
+				t.Errorf("principal = %+v, want %+v", got, tt.want)
+			}
+			if got.Claims["sub"] != tt.want.UserID {
+				t.Errorf("expected the raw claims, got %v", got.Claims)
+			}
+			if groups := got.ClaimStrings("groups"); !slices.Equal(groups, tt.groups) {
+				t.Errorf("groups = %v, want %v", groups, tt.groups)
+			}
+		})
+	}
+}
+
+func TestActOnBehalf_KeepsPrincipal(t *testing.T) {
+	cfg := AuthConfig{AllowUnsignedTokens: true, AdminUsers: []string{"support1"}}
+	var principal Principal
+	var user string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFromContext(r.Context())
+		user = UserIDFromContext(r.Context())
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+unsignedToken("support1", time.Now().Add(time.Hour)))
+	req.Header.Set(OnBehalfOfHeader, "user7")
+	JWTMiddleware(cfg)(ActOnBehalf(handler)).ServeHTTP(httptest.NewRecorder(), req)
+
+	if user != "user7" || principal.UserID != "support1" {
+		t.Errorf("user = %q, principal = %q; want user7, support1", user, principal.UserID)
+	}
+}
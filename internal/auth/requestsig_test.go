@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTMiddleware_RequestSignatures(t *testing.T) {
+	cfg := AuthConfig{SigningKeys: map[string]string{"partner1": "shared-secret"}, SignatureWindow: time.Minute}
+	now := time.Now()
+	body := `{"asset_id":"chart1"}`
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		key        string
+		secret     string
+		signedURI  string
+		signedBody string
+		timestamp  time.Time
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "signed GET", method: "GET", target: "/api/v1/favourites?limit=5", key: "partner1", secret: "shared-secret", timestamp: now, wantStatus: http.StatusOK},
+		{name: "signed POST", method: "POST", target: "/api/v1/favourites", body: body, key: "partner1", secret: "shared-secret", timestamp: now, wantStatus: http.StatusOK},
+		{name: "clock slightly ahead", method: "GET", target: "/api/v1/favourites", key: "partner1", secret: "shared-secret", timestamp: now.Add(30 * time.Second), wantStatus: http.StatusOK},
+		{name: "wrong secret", method: "GET", target: "/api/v1/favourites", key: "partner1", secret: "guess", timestamp: now, wantStatus: http.StatusUnauthorized, wantCode: "invalid_signature"},
+		{name: "tampered body", method: "POST", target: "/api/v1/favourites", body: body, signedBody: `{"asset_id":"chart2"}`, key: "partner1", secret: "shared-secret", timestamp: now, wantStatus: http.StatusUnauthorized, wantCode: "invalid_signature"},
+		{name: "tampered query", method: "GET", target: "/api/v1/favourites?limit=500", signedURI: "/api/v1/favourites?limit=5", key: "partner1", secret: "shared-secret", timestamp: now, wantStatus: http.StatusUnauthorized, wantCode: "invalid_signature"},
+		{name: "replayed later", method: "GET", target: "/api/v1/favourites", key: "partner1", secret: "shared-secret", timestamp: now.Add(-2 * time.Minute), wantStatus: http.StatusUnauthorized, wantCode: "signature_expired"},
+		{name: "timestamp too far ahead", method: "GET", target: "/api/v1/favourites", key: "partner1", secret: "shared-secret", timestamp: now.Add(2 * time.Minute), wantStatus: http.StatusUnauthorized, wantCode: "signature_expired"},
+		{name: "unknown key", method: "GET", target: "/api/v1/favourites", key: "partner2", secret: "shared-secret", timestamp: now, wantStatus: http.StatusUnauthorized, wantCode: "unknown_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signedURI, signedBody := tt.target, tt.body
+			if tt.signedURI != "" {
+				signedURI = tt.signedURI
+			}
+			if tt.signedBody != "" {
+				signedBody = tt.signedBody
+			}
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(SignatureKeyHeader, tt.key)
+			req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(tt.timestamp.Unix(), 10))
+			req.Header.Set(SignatureHeader, SignRequest(tt.secret, tt.method, signedURI, []byte(signedBody), tt.timestamp))
+
+			var gotUser, gotBody string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = UserIDFromContext(r.Context())
+				b, _ := io.ReadAll(r.Body)
+				gotBody = string(b)
+			})
+			rr := httptest.NewRecorder()
+			JWTMiddleware(cfg)(handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var resp struct {
+					Code string `json:"code"`
+				}
+				json.NewDecoder(rr.Body).Decode(&resp)
+				if resp.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+				}
+				return
+			}
+			if gotUser != tt.key || gotBody != tt.body {
+				t.Errorf("handler saw user %q and body %q, want %q and %q", gotUser, gotBody, tt.key, tt.body)
+			}
+		})
+	}
+}
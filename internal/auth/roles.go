@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RoleAdmin is the role required by the admin endpoints.
+const RoleAdmin = "admin"
+
+// DefaultRolesClaim is the claim roles are read from when AuthConfig.RolesClaim
+// is empty.
+const DefaultRolesClaim = "roles"
+
+const rolesKey contextKey = "roles"
+
+// RequireRole returns HTTP middleware that answers 403 unless the token grants
+// role. It must run after JWTMiddleware.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasRole(r.Context(), role) {
+				forbidden(w, "insufficient_role", "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RolesFromContext returns the roles stored by JWTMiddleware.
+func RolesFromContext(ctx context.Context) []string {
+	v, _ := ctx.Value(rolesKey).([]string)
+	return v
+}
+
+// HasRole reports whether the user of the request carrying ctx holds role.
+func HasRole(ctx context.Context, role string) bool {
+	return slices.Contains(RolesFromContext(ctx), role)
+}
+
+// userRoles returns the roles listed in the claim at cfg.RolesClaim, plus
+// RoleAdmin for the users in cfg.AdminUsers.
+func userRoles(claims jwt.MapClaims, sub string, cfg AuthConfig) []string {
+	path := cfg.RolesClaim
+	if path == "" {
+		path = DefaultRolesClaim
+	}
+	roles := claimStrings(claims, path)
+	if cfg.IsAdmin(sub) && !slices.Contains(roles, RoleAdmin) {
+		roles = append(roles, RoleAdmin)
+	}
+	return roles
+}
+
+// claimStrings reads the claim at a dot-separated path (e.g.
+// "realm_access.roles"), which may hold an array of strings or a single string
+// of space- or comma-separated values. Missing or malformed claims yield nil.
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	var v any = map[string]any(claims)
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[name]
+	}
+
+	var values []string
+	switch v := v.(type) {
+	case string:
+		values = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	case []any:
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OnBehalfOfParam is the query parameter naming the user a service token
+// acts for.
+const OnBehalfOfParam = "user_id"
+
+// OnBehalfOfHeader names the user an admin impersonates, e.g. for support
+// staff to reproduce what the user sees.
+const OnBehalfOfHeader = "X-On-Behalf-Of"
+
+const (
+	serviceKey contextKey = "service"
+	actorKey   contextKey = "actor"
+)
+
+// isServiceToken reports whether claims mark a service principal, i.e. the
+// claim named by cfg.ServiceClaim ("claim=value") holds the value.
+func (c AuthConfig) isServiceToken(claims jwt.MapClaims) bool {
+	path, value, ok := strings.Cut(c.ServiceClaim, "=")
+	if !ok || path == "" || value == "" {
+		return false
+	}
+	return slices.Contains(claimStrings(claims, path), value)
+}
+
+// ServiceFromContext returns the client ID ("sub" claim) of the service
+// token that authenticated the request, or "" for end users.
+func ServiceFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(serviceKey).(string)
+	return v
+}
+
+// ActorFromContext returns who authenticated a request made on behalf of
+// another user (see ActOnBehalf): the admin or the service. It is "" for
+// requests users make for themselves.
+func ActorFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(actorKey).(string)
+	return v
+}
+
+// ActOnBehalf is HTTP middleware letting a request act for another user, who
+// becomes the request's user while the caller is kept as its actor:
+//   - tokens granting RoleAdmin may name the user in the OnBehalfOfHeader
+//     header, and anyone else sending it gets 403;
+//   - service tokens must name the user in the OnBehalfOfParam query
+//     parameter, getting 400 without it, while an end user naming anyone but
+//     themselves gets 403.
+//
+// It must run after JWTMiddleware.
+func ActOnBehalf(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller := UserIDFromContext(r.Context())
+		if target := r.Header.Get(OnBehalfOfHeader); target != "" {
+			if !HasRole(r.Context(), RoleAdmin) {
+				forbidden(w, "insufficient_role", "only admins may act on behalf of other users")
+				return
+			}
+			principal, _ := PrincipalFromContext(r.Context())
+			logging.Log(r.Context()).Layer("auth").Op("impersonate").User(target).Str("actor", caller).
+				Str("actor_email", principal.Email).Str("tenant", principal.TenantID).
+				Str("method", r.Method).Str("path", r.URL.Path).Info("admin acting on behalf of user")
+			logging.Security(r, logging.EventImpersonation).User(target).Str("actor", caller).
+				Str("actor_email", principal.Email).Str("tenant", principal.TenantID).Info("admin acting on behalf of user")
+			next.ServeHTTP(w, onBehalfOf(r, target, caller))
+			return
+		}
+
+		userID := r.URL.Query().Get(OnBehalfOfParam)
+		if ServiceFromContext(r.Context()) == "" {
+			if userID != "" && userID != caller {
+				forbidden(w, "not_a_service", "only service tokens may act on behalf of other users")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if userID == "" {
+			authError(w, http.StatusBadRequest, "missing_user", "service tokens must name the user with the "+OnBehalfOfParam+" query parameter")
+			return
+		}
+		next.ServeHTTP(w, onBehalfOf(r, userID, caller))
+	})
+}
+
+// onBehalfOf makes userID the user of r, keeping actor as its actor.
+func onBehalfOf(r *http.Request, userID, actor string) *http.Request {
+	if userID == actor {
+		return r
+	}
+	ctx := context.WithValue(r.Context(), userIDKey, userID)
+	ctx = context.WithValue(ctx, actorKey, actor)
+	return r.WithContext(ctx)
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestActOnBehalf(t *testing.T) {
+	token := func(claims jwt.MapClaims) string {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		return s
+	}
+	service := token(jwt.MapClaims{"sub": "batch-job", "gty": "client-credentials"})
+	nested := token(jwt.MapClaims{"sub": "batch-job", "ext": map[string]any{"kinds": []any{"service"}}})
+	user := token(jwt.MapClaims{"sub": "user7", "gty": "password"})
+
+	tests := []struct {
+		name        string
+		claim       string
+		token       string
+		query       string
+		wantStatus  int
+		wantCode    string
+		wantUser    string
+		wantService string
+	}{
+		{name: "service acting for a user", claim: "gty=client-credentials", token: service, query: "?user_id=user9", wantStatus: http.StatusOK, wantUser: "user9", wantService: "batch-job"},
+		{name: "service claim at a nested path", claim: "ext.kinds=service", token: nested, query: "?user_id=user9", wantStatus: http.StatusOK, wantUser: "user9", wantService: "batch-job"},
+		{name: "service without user", claim: "gty=client-credentials", token: service, wantStatus: http.StatusBadRequest, wantCode: "missing_user"},
+		{name: "user", claim: "gty=client-credentials", token: user, wantStatus: http.StatusOK, wantUser: "user7"},
+		{name: "user naming themselves", claim: "gty=client-credentials", token: user, query: "?user_id=user7", wantStatus: http.StatusOK, wantUser: "user7"},
+		{name: "user naming another user", claim: "gty=client-credentials", token: user, query: "?user_id=user9", wantStatus: http.StatusForbidden, wantCode: "not_a_service"},
+		{name: "service principals disabled", token: service, query: "?user_id=user9", wantStatus: http.StatusForbidden, wantCode: "not_a_service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser, gotService string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, gotService = UserIDFromContext(r.Context()), ServiceFromContext(r.Context())
+			})
+			mw := JWTMiddleware(AuthConfig{AllowUnsignedTokens: true, ServiceClaim: tt.claim})
+			req := httptest.NewRequest("GET", "/"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			mw(ActOnBehalf(handler)).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var body struct {
+					Code string `json:"code"`
+				}
+				json.NewDecoder(rr.Body).Decode(&body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+				return
+			}
+			if gotUser != tt.wantUser || gotService != tt.wantService {
+				t.Errorf("user, service = %q, %q; want %q, %q", gotUser, gotService, tt.wantUser, tt.wantService)
+			}
+		})
+	}
+}
+
+func TestActOnBehalf_AdminImpersonation(t *testing.T) {
+	admin := unsignedToken("support1", time.Now().Add(time.Hour))
+	user := unsignedToken("user7", time.Now().Add(time.Hour))
+	cfg := AuthConfig{AllowUnsignedTokens: true, AdminUsers: []string{"support1"}}
+
+	tests := []struct {
+		name       string
+		token      string
+		onBehalfOf string
+		wantStatus int
+		wantUser   string
+		wantActor  string
+	}{
+		{name: "admin impersonating a user", token: admin, onBehalfOf: "user7", wantStatus: http.StatusOK, wantUser: "user7", wantActor: "support1"},
+		{name: "admin naming themselves", token: admin, onBehalfOf: "support1", wantStatus: http.StatusOK, wantUser: "support1"},
+		{name: "admin without the header", token: admin, wantStatus: http.StatusOK, wantUser: "support1"},
+		{name: "user impersonating", token: user, onBehalfOf: "user8", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser, gotActor string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser, gotActor = UserIDFromContext(r.Context()), ActorFromContext(r.Context())
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set(OnBehalfOfHeader, tt.onBehalfOf)
+			rr := httptest.NewRecorder()
+			JWTMiddleware(cfg)(ActOnBehalf(handler)).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if gotUser != tt.wantUser || gotActor != tt.wantActor {
+				t.Errorf("user, actor = %q, %q; want %q, %q", gotUser, gotActor, tt.wantUser, tt.wantActor)
+			}
+		})
+	}
+}
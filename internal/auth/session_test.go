@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCSRFMiddleware_CookieSessions(t *testing.T) {
+	token := unsignedToken("user7", time.Now().Add(time.Hour))
+	cfg := AuthConfig{AllowUnsignedTokens: true, SessionCookie: "session"}
+
+	tests := []struct {
+		name        string
+		cfg         AuthConfig
+		method      string
+		bearer      bool
+		session     bool
+		csrfCookie  string
+		csrfHeader  string
+		wantStatus  int
+		wantCode    string
+		wantIssued  bool
+		wantSession bool
+	}{
+		{name: "safe request is issued a CSRF token", cfg: cfg, method: "GET", session: true, wantStatus: http.StatusOK, wantIssued: true, wantSession: true},
+		{name: "safe request keeps its CSRF token", cfg: cfg, method: "GET", session: true, csrfCookie: "abc", wantStatus: http.StatusOK, wantSession: true},
+		{name: "mutating request with matching token", cfg: cfg, method: "POST", session: true, csrfCookie: "abc", csrfHeader: "abc", wantStatus: http.StatusOK, wantSession: true},
+		{name: "mutating request without header", cfg: cfg, method: "DELETE", session: true, csrfCookie: "abc", wantStatus: http.StatusForbidden, wantCode: "csrf_token_mismatch"},
+		{name: "mutating request with wrong header", cfg: cfg, method: "PUT", session: true, csrfCookie: "abc", csrfHeader: "abd", wantStatus: http.StatusForbidden, wantCode: "csrf_token_mismatch"},
+		{name: "mutating request without CSRF cookie", cfg: cfg, method: "POST", session: true, csrfHeader: "abc", wantStatus: http.StatusForbidden, wantCode: "csrf_token_mismatch"},
+		{name: "bearer token needs no CSRF token", cfg: cfg, method: "POST", bearer: true, session: true, wantStatus: http.StatusOK},
+		{name: "cookie sessions disabled", cfg: AuthConfig{AllowUnsignedTokens: true}, method: "GET", session: true, wantStatus: http.StatusUnauthorized, wantCode: "missing_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSession bool
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotSession = FromSession(r.Context())
+			})
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.bearer {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			if tt.session {
+				req.AddCookie(&http.Cookie{Name: "session", Value: token})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(CSRFHeader, tt.csrfHeader)
+			}
+			rr := httptest.NewRecorder()
+			JWTMiddleware(tt.cfg)(CSRFMiddleware(tt.cfg)(handler)).ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantCode != "" {
+				var body struct {
+					Code string `json:"code"`
+				}
+				json.NewDecoder(rr.Body).Decode(&body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+			}
+			issued := false
+			for _, c := range rr.Result().Cookies() {
+				if c.Name == CSRFCookie {
+					issued = c.Value != "" && !c.HttpOnly && c.SameSite == http.SameSiteStrictMode
+				}
+			}
+			if issued != tt.wantIssued {
+				t.Errorf("CSRF token issued = %v, want %v", issued, tt.wantIssued)
+			}
+			if gotSession != tt.wantSession {
+				t.Errorf("FromSession = %v, want %v", gotSession, tt.wantSession)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+type grantKey struct{}
+
+// Grant is time-boxed, read-only access to a user's favourites delegated
+// through a signed URL, e.g. to a third-party widget.
+type Grant struct {
+	UserID string
+	// TenantID is the tenant of UserID; empty in single-tenant deployments.
+	TenantID string
+	// AssetType restricts the grant to one asset type; empty means all types.
+	AssetType string
+	ExpiresAt time.Time
+}
+
+var (
+	ErrGrantInvalid = errors.New("invalid signature")
+	ErrGrantExpired = errors.New("signed URL expired")
+)
+
+// SignGrant returns the query parameters that carry g, signed with secret.
+func SignGrant(secret string, g Grant) url.Values {
+	exp := strconv.FormatInt(g.ExpiresAt.Unix(), 10)
+	q := url.Values{}
+	q.Set("user", g.UserID)
+	if g.TenantID != "" {
+		q.Set("tenant", g.TenantID)
+	}
+	if g.AssetType != "" {
+		q.Set("type", g.AssetType)
+	}
+	q.Set("exp", exp)
+	q.Set("sig", grantSignature(secret, g.UserID, g.TenantID, g.AssetType, exp))
+	return q
+}
+
+// VerifyGrant checks the signature and expiry of the grant carried by q.
+func VerifyGrant(secret string, q url.Values, now time.Time) (Grant, error) {
+	userID, tenantID, assetType, exp := q.Get("user"), q.Get("tenant"), q.Get("type"), q.Get("exp")
+	if secret == "" || userID == "" || exp == "" {
+		return Grant{}, ErrGrantInvalid
+	}
+	want := grantSignature(secret, userID, tenantID, assetType, exp)
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(want)) {
+		return Grant{}, ErrGrantInvalid
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return Grant{}, ErrGrantInvalid
+	}
+	expiresAt := time.Unix(unix, 0).UTC()
+	if !now.Before(expiresAt) {
+		return Grant{}, ErrGrantExpired
+	}
+	return Grant{UserID: userID, TenantID: tenantID, AssetType: assetType, ExpiresAt: expiresAt}, nil
+}
+
+// grantSignature is the base64url HMAC-SHA256 of the grant fields. The version
+// prefix lets the format change without old URLs verifying under new rules.
+// Grants of a tenant sign it after the v1 fields, so grants without one keep
+// their signatures.
+func grantSignature(secret, userID, tenantID, assetType, exp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fields := "v1\n" + userID + "\n" + assetType + "\n" + exp
+	if tenantID != "" {
+		fields += "\n" + tenantID
+	}
+	mac.Write([]byte(fields))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURLMiddleware returns HTTP middleware that authorises requests by the
+// signed grant in their query string instead of a JWT, and places the Grant
+// into the request context. When secret is empty, every request is rejected.
+func SignedURLMiddleware(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			grant, err := VerifyGrant(secret, r.URL.Query(), time.Now())
+			if err != nil {
+				logging.Security(r, logging.EventInvalidSignature).Str("code", "invalid_signed_url").Info(err.Error())
+				http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), grantKey{}, grant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GrantFromContext returns the grant stored by SignedURLMiddleware.
+func GrantFromContext(ctx context.Context) (Grant, bool) {
+	g, ok := ctx.Value(grantKey{}).(Grant)
+	return g, ok
+}
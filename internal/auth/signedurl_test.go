@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifyGrant(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	grant := Grant{UserID: "user1", TenantID: "acme", AssetType: "chart", ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		secret  string
+		modify  func(q map[string][]string)
+		at      time.Time
+		wantErr error
+	}{
+		{name: "valid", secret: "s3cret", at: now},
+		{name: "expired", secret: "s3cret", at: now.Add(time.Hour), wantErr: ErrGrantExpired},
+		{name: "wrong secret", secret: "other", at: now, wantErr: ErrGrantInvalid},
+		{name: "no secret", secret: "", at: now, wantErr: ErrGrantInvalid},
+		{name: "widened to all types", secret: "s3cret", at: now, wantErr: ErrGrantInvalid,
+			modify: func(q map[string][]string) { delete(q, "type") }},
+		{name: "other user", secret: "s3cret", at: now, wantErr: ErrGrantInvalid,
+			modify: func(q map[string][]string) { q["user"] = []string{"user2"} }},
+		{name: "other tenant", secret: "s3cret", at: now, wantErr: ErrGrantInvalid,
+			modify: func(q map[string][]string) { q["tenant"] = []string{"globex"} }},
+		{name: "tenant dropped", secret: "s3cret", at: now, wantErr: ErrGrantInvalid,
+			modify: func(q map[string][]string) { delete(q, "tenant") }},
+		{name: "extended expiry", secret: "s3cret", at: now, wantErr: ErrGrantInvalid,
+			modify: func(q map[string][]string) { q["exp"] = []string{"9999999999"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := SignGrant("s3cret", grant)
+			if tt.modify != nil {
+				tt.modify(q)
+			}
+
+			got, err := VerifyGrant(tt.secret, q, tt.at)
+			if err != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && got != grant {
+				t.Errorf("expected grant %+v, got %+v", grant, got)
+			}
+		})
+	}
+}
+
+func TestSignedURLMiddleware(t *testing.T) {
+	var got Grant
+	handler := SignedURLMiddleware("s3cret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = GrantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	q := SignGrant("s3cret", Grant{UserID: "user1", ExpiresAt: time.Now().Add(time.Minute)})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/shared?"+q.Encode(), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if got.UserID != "user1" {
+		t.Errorf("expected grant for user1 in context, got %+v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/shared?user=user1&exp=9999999999&sig=forged", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for a forged signature, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// Defaults of the FailureThrottle settings left zero.
+const (
+	defaultFailureWindow   = 15 * time.Minute
+	defaultFailureDelay    = 250 * time.Millisecond
+	defaultFailureMaxDelay = 5 * time.Second
+	defaultFailureBan      = 15 * time.Minute
+	// maxDelayedRequests bounds the 401s being delayed at once; further
+	// failures are refused with 429 at once instead of holding a goroutine.
+	maxDelayedRequests = 64
+)
+
+// authFailures counts rejected credentials by error code, and
+// authThrottled the requests FailureThrottle delayed or refused; both are
+// published by expvar.
+var (
+	authFailures  = expvar.NewMap("auth_failures")
+	authThrottled = expvar.NewMap("auth_throttled")
+)
+
+// FailureThrottle slows down clients that repeatedly fail authentication, to
+// make brute-forcing credentials impractical. Failures are counted per client
+// IP and per credential fingerprint within Window; once either count exceeds
+// Threshold, each further 401 is delayed, starting at BaseDelay and doubling
+// up to MaxDelay, and once it reaches BanAfter the client IP or credential is
+// refused with 429 for BanFor. A banned credential is refused outright, while
+// a banned client IP only has its failures refused: a valid credential is
+// still served, so one client cannot lock out the others sharing its address.
+// Requests without credentials are not counted. The zero value of each
+// setting but Threshold picks a default; a nil *FailureThrottle throttles
+// nothing.
+type FailureThrottle struct {
+	Threshold int
+	Window    time.Duration
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// BanAfter is the failure count that bans the client; 0 never bans.
+	BanAfter int
+	BanFor   time.Duration
+	// ClientIPHeader, when set, names the header ("X-Forwarded-For" or
+	// "X-Real-IP") giving the client IP of requests from TrustedProxies.
+	// Other requests are counted under their connection's address.
+	ClientIPHeader string
+	TrustedProxies []netip.Prefix
+
+	mu        sync.Mutex
+	clients   map[string]*failureRecord
+	lastPrune time.Time
+	delayed   atomic.Int64
+	now       func() time.Time // for tests
+}
+
+type failureRecord struct {
+	count       int
+	since       time.Time // start of the current window
+	bannedUntil time.Time
+}
+
+// throttleKeys returns the keys failures of r are counted under: the client
+// IP, and a fingerprint of the credential when r carries one.
+func (t *FailureThrottle) throttleKeys(r *http.Request, credential string) []string {
+	keys := []string{"ip:" + t.clientIP(r)}
+	if credential != "" {
+		keys = append(keys, credentialKey(credential))
+	}
+	return keys
+}
+
+func credentialKey(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return "credential:" + hex.EncodeToString(sum[:8])
+}
+
+// clientIP returns the IP of the client making r: the connection's address,
+// or, when that is a trusted proxy, the address ClientIPHeader gives. In
+// X-Forwarded-For, the rightmost address not of a trusted proxy is taken, as
+// those left of it are set by the client and may be forged.
+func (t *FailureThrottle) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if t.ClientIPHeader == "" || !t.trustedProxy(ip) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values(t.ClientIPHeader), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		ip = hop
+		if !t.trustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func (t *FailureThrottle) trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	return slices.ContainsFunc(t.TrustedProxies, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// credentialBanned returns how long credential remains banned, or 0.
+func (t *FailureThrottle) credentialBanned(credential string) time.Duration {
+	if t == nil || credential == "" {
+		return 0
+	}
+	now := t.clock()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rec, ok := t.clients[credentialKey(credential)]; ok {
+		return max(rec.bannedUntil.Sub(now), 0)
+	}
+	return 0
+}
+
+// failed records a failed authentication of r and returns how long to delay
+// the 401, or, when the client IP or the credential is banned, how long the
+// ban remains, logging a warning when it starts delaying or banning the
+// client. Requests without a credential (missing_token) are not counted, so
+// they cannot get the clients sharing an address banned.
+func (t *FailureThrottle) failed(r *http.Request, credential, code string) (delay, banned time.Duration) {
+	authFailures.Add(code, 1)
+	if t == nil || code == "missing_token" {
+		return 0, 0
+	}
+	now := t.clock()
+	window := cmp.Or(t.Window, defaultFailureWindow)
+
+	t.mu.Lock()
+	if t.clients == nil {
+		t.clients = make(map[string]*failureRecord)
+	}
+	if now.Sub(t.lastPrune) > window {
+		for key, rec := range t.clients {
+			if now.Sub(rec.since) > window && !rec.bannedUntil.After(now) {
+				delete(t.clients, key)
+			}
+		}
+		t.lastPrune = now
+	}
+	keys := t.throttleKeys(r, credential)
+	count, newlyBanned := 0, false
+	for _, key := range keys {
+		rec, ok := t.clients[key]
+		if !ok || (now.Sub(rec.since) > window && !rec.bannedUntil.After(now)) {
+			rec = &failureRecord{since: now}
+			t.clients[key] = rec
+		}
+		// The failure that bans the client is still answered 401
+		banned = max(banned, rec.bannedUntil.Sub(now))
+		rec.count++
+		if t.BanAfter > 0 && rec.count == t.BanAfter {
+			rec.bannedUntil = now.Add(cmp.Or(t.BanFor, defaultFailureBan))
+			newlyBanned = true
+		}
+		count = max(count, rec.count)
+	}
+	t.mu.Unlock()
+
+	log := logging.Log(r.Context()).Layer("auth").Op("throttle").Str("client", keys[0]).Int("failures", count)
+	if len(keys) > 1 {
+		log = log.Str("credential", keys[1])
+	}
+	if newlyBanned {
+		authThrottled.Add("banned", 1)
+		log.Warn("banning client after repeated authentication failures")
+		security := logging.Security(r, logging.EventAuthBanned).Str("client", keys[0]).Int("failures", count)
+		if len(keys) > 1 {
+			security = security.Str("credential", keys[1])
+		}
+		security.Warn("client banned after repeated authentication failures")
+	}
+	if banned > 0 {
+		return 0, banned
+	}
+	excess := count - t.Threshold
+	if excess <= 0 {
+		return 0, 0
+	}
+	if excess == 1 {
+		log.Warn("delaying client after repeated authentication failures")
+	}
+	authThrottled.Add("delayed", 1)
+	delay, maxDelay := cmp.Or(t.BaseDelay, defaultFailureDelay), cmp.Or(t.MaxDelay, defaultFailureMaxDelay)
+	for i := 1; i < excess && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay), 0
+}
+
+func (t *FailureThrottle) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// tooManyFailures refuses a banned client with 429 and a Retry-After header.
+func tooManyFailures(w http.ResponseWriter, retryAfter time.Duration) {
+	authThrottled.Add("refused", 1)
+	w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	authError(w, http.StatusTooManyRequests, "too_many_failures", "too many failed authentication attempts; try again later")
+}
+
+// wait delays a 401 by d, or until ctx is done. It reports false, without
+// waiting, when maxDelayedRequests are already being delayed, so a flood of
+// failures cannot tie up the server; the caller then refuses the request.
+func (t *FailureThrottle) wait(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	if t.delayed.Add(1) > maxDelayedRequests {
+		t.delayed.Add(-1)
+		return false
+	}
+	defer t.delayed.Add(-1)
+	sleep(ctx, d)
+	return true
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
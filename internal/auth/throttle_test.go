@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func failureCount(code string) int64 {
+	if v, ok := authFailures.Get(code).(interface{ Value() int64 }); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestFailureThrottle_EscalatingDelays(t *testing.T) {
+	now := time.Now()
+	throttle := &FailureThrottle{
+		Threshold: 2,
+		Window:    time.Minute,
+		BaseDelay: 10 * time.Millisecond,
+		MaxDelay:  40 * time.Millisecond,
+		now:       func() time.Time { return now },
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+
+	want := []time.Duration{0, 0, 10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, w := range want {
+		if got, _ := throttle.failed(req, "token", "invalid_signature"); got != w {
+			t.Errorf("failure %d: delay = %v, want %v", i+1, got, w)
+		}
+	}
+
+	// Failures are forgotten once the window has passed.
+	now = now.Add(2 * time.Minute)
+	if got, _ := throttle.failed(req, "token", "invalid_signature"); got != 0 {
+		t.Errorf("delay after the window = %v, want 0", got)
+	}
+
+	// Requests without credentials are not counted.
+	for range 5 {
+		if delay, banned := throttle.failed(httptest.NewRequest("GET", "/", nil), "", "missing_token"); delay != 0 || banned != 0 {
+			t.Fatalf("missing token: delay = %v, banned = %v; want 0, 0", delay, banned)
+		}
+	}
+}
+
+func TestFailureThrottle_ClientIP(t *testing.T) {
+	throttle := &FailureThrottle{
+		ClientIPHeader: "X-Forwarded-For",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "direct client", remote: "192.0.2.1:40000", want: "192.0.2.1"},
+		{name: "untrusted peer's header ignored", remote: "192.0.2.1:40000", forwarded: "198.51.100.7", want: "192.0.2.1"},
+		{name: "through a trusted proxy", remote: "10.0.0.5:40000", forwarded: "198.51.100.7", want: "198.51.100.7"},
+		{name: "forged hops ignored", remote: "10.0.0.5:40000", forwarded: "203.0.113.9, 198.51.100.7, 10.0.0.4", want: "198.51.100.7"},
+		{name: "no header", remote: "10.0.0.5:40000", want: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := throttle.clientIP(req); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware_FailureThrottle(t *testing.T) {
+	now := time.Now()
+	cfg := AuthConfig{
+		Secret:   "secret",
+		Throttle: &FailureThrottle{Threshold: 10, BanAfter: 3, BanFor: time.Minute, now: func() time.Time { return now }},
+	}
+	valid := signedToken("user7", "secret", time.Now().Add(time.Hour))
+	forged := signedToken("user7", "guess", time.Now().Add(time.Hour))
+	send := func(ip, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":40000"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		JWTMiddleware(cfg)(dummyHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	before := failureCount("invalid_signature")
+	for i := 0; i < 3; i++ {
+		if rr := send("192.0.2.1", forged); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, rr.Code)
+		}
+	}
+	if got := failureCount("invalid_signature") - before; got != 3 {
+		t.Errorf("invalid_signature failures increased by %d, want 3", got)
+	}
+
+	// The client's failures are refused, but a valid token from its address
+	// is still served; the forged token is refused from anywhere.
+	rr := send("192.0.2.1", signedToken("user7", "other-guess", time.Now().Add(time.Hour)))
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Errorf("banned client: status = %d, Retry-After = %q; want 429, 60", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if rr := send("192.0.2.1", valid); rr.Code != http.StatusOK {
+		t.Errorf("valid token from a banned client: status = %d, want 200", rr.Code)
+	}
+	if rr := send("198.51.100.7", forged); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("banned token from another client: status = %d, want 429", rr.Code)
+	}
+	if rr := send("198.51.100.7", valid); rr.Code != http.StatusOK {
+		t.Errorf("other client: status = %d, want 200", rr.Code)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	if rr := send("192.0.2.1", forged); rr.Code != http.StatusUnauthorized {
+		t.Errorf("after the ban: status = %d, want 401", rr.Code)
+	}
+
+	// Requests without credentials never get an address banned.
+	for i := 0; i < 5; i++ {
+		if rr := send("203.0.113.5", ""); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("missing token %d: status = %d, want 401", i+1, rr.Code)
+		}
+	}
+}
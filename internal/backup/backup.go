@@ -0,0 +1,274 @@
+// Package backup dumps and restores favourites data in a portable,
+// backend-neutral format: JSON Lines with a header line followed by one
+// line per table row. Timestamps are RFC 3339 and asset data is kept as the
+// JSON document the API accepted, so a backup taken from one store can be
+// restored into another.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+const (
+	// Format identifies a favourites backup stream.
+	Format = "favourites-backup"
+	// Version is the current backup format version.
+	Version = 1
+)
+
+// Table names used in backup records.
+const (
+	TableAssets      = "assets"
+	TableFavourites  = "favourites"
+	TableVersions    = "favourite_versions"
+	TableAudit       = "favourite_audit"
+	TablePreferences = "user_preferences"
+)
+
+// maxLineSize bounds a single backup line; favourites are far smaller.
+const maxLineSize = 16 << 20
+
+// progressInterval is how many records a backup or restore processes between
+// two reports of its progress.
+var progressInterval = 10000
+
+// Header is the first line of every backup.
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Counts reports how many rows of each table a backup or restore processed.
+type Counts struct {
+	Assets       int `json:"assets"`
+	Favourites   int `json:"favourites"`
+	Versions     int `json:"versions"`
+	AuditEntries int `json:"audit_entries"`
+	Preferences  int `json:"preferences"`
+	// Skipped counts the restored records the repository does not keep
+	// (see database.ErrNotRestored).
+	Skipped int `json:"skipped,omitempty"`
+}
+
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// progress passes the counts so far to report, if set, every
+// progressInterval records.
+type progress struct {
+	report  func(Counts)
+	records int
+}
+
+func (p *progress) record(counts Counts) {
+	p.records++
+	if p.report != nil && p.records%progressInterval == 0 {
+		p.report(counts)
+	}
+}
+
+// Write streams every catalog asset, favourite, favourite version, audit entry
+// and user preference repo keeps to w. Versions follow the favourites they belong to, so a
+// restore can insert them in order. report, if not nil, is called with the
+// counts so far as the backup progresses.
+func Write(ctx context.Context, repo database.BackupStore, w io.Writer, now time.Time, report func(Counts)) (Counts, error) {
+	var counts Counts
+	progress := progress{report: report}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(Header{Format: Format, Version: Version, CreatedAt: now.UTC()}); err != nil {
+		return counts, fmt.Errorf("writing header: %w", err)
+	}
+
+	put := func(table string, row any) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("encoding %s row: %w", table, err)
+		}
+		if err := enc.Encode(record{Table: table, Row: data}); err != nil {
+			return fmt.Errorf("writing %s row: %w", table, err)
+		}
+		progress.record(counts)
+		return nil
+	}
+
+	if err := repo.EachAssetRecord(ctx, func(rec *database.AssetRecord) error {
+		counts.Assets++
+		return put(TableAssets, rec)
+	}); err != nil {
+		return counts, err
+	}
+	if err := repo.EachFavouriteRecord(ctx, func(rec *database.FavouriteRecord) error {
+		counts.Favourites++
+		return put(TableFavourites, rec)
+	}); err != nil {
+		return counts, err
+	}
+	if err := repo.EachVersionRecord(ctx, func(rec *database.VersionRecord) error {
+		counts.Versions++
+		return put(TableVersions, rec)
+	}); err != nil {
+		return counts, err
+	}
+	if err := repo.EachAuditRecord(ctx, func(rec *database.AuditRecord) error {
+		counts.AuditEntries++
+		return put(TableAudit, rec)
+	}); err != nil {
+		return counts, err
+	}
+	if err := repo.EachPreferenceRecord(ctx, func(rec *database.PreferenceRecord) error {
+		counts.Preferences++
+		return put(TablePreferences, rec)
+	}); err != nil {
+		return counts, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return counts, fmt.Errorf("flushing backup: %w", err)
+	}
+	return counts, nil
+}
+
+// Restore reads a backup from r and writes it to repo in a single transaction, so a
+// malformed or partially read backup changes nothing. Records repo does not
+// keep are counted as skipped. report, if not nil, is called with the counts
+// so far as the restore progresses.
+func Restore(ctx context.Context, repo database.BackupStore, r io.Reader, report func(Counts)) (Counts, error) {
+	var counts Counts
+	progress := progress{report: report}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return counts, fmt.Errorf("reading header: %w", err)
+		}
+		return counts, errors.New("empty backup")
+	}
+	var header Header
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return counts, fmt.Errorf("decoding header: %w", err)
+	}
+	if header.Format != Format {
+		return counts, fmt.Errorf("unsupported backup format %q", header.Format)
+	}
+	if header.Version != Version {
+		return counts, fmt.Errorf("unsupported backup version %d", header.Version)
+	}
+
+	restorer, err := repo.BeginRestore(ctx)
+	if err != nil {
+		return counts, err
+	}
+
+	line := 1
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := restoreLine(ctx, restorer, scanner.Bytes(), &counts); err != nil {
+			restorer.Rollback()
+			return Counts{}, fmt.Errorf("line %d: %w", line, err)
+		}
+		progress.record(counts)
+	}
+	if err := scanner.Err(); err != nil {
+		restorer.Rollback()
+		return Counts{}, fmt.Errorf("reading backup: %w", err)
+	}
+
+	if err := restorer.Commit(ctx); err != nil {
+		return Counts{}, err
+	}
+	return counts, nil
+}
+
+func restoreLine(ctx context.Context, restorer database.Restorer, line []byte, counts *Counts) error {
+	var rec record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return fmt.Errorf("decoding record: %w", err)
+	}
+
+	switch rec.Table {
+	case TableAssets:
+		var row database.AssetRecord
+		if err := json.Unmarshal(rec.Row, &row); err != nil {
+			return fmt.Errorf("decoding catalog asset: %w", err)
+		}
+		if row.AssetType == "" || row.ID == "" || len(row.Data) == 0 || string(row.Data) == "null" {
+			return errors.New("catalog asset is missing asset_type, id or data")
+		}
+		if err := restorer.PutAsset(ctx, &row); err != nil {
+			return err
+		}
+		counts.Assets++
+	case TableFavourites:
+		var row database.FavouriteRecord
+		if err := json.Unmarshal(rec.Row, &row); err != nil {
+			return fmt.Errorf("decoding favourite: %w", err)
+		}
+		if row.UserID == "" || row.ID == "" {
+			return errors.New("favourite is missing user_id or id")
+		}
+		if err := restorer.PutFavourite(ctx, &row); err != nil {
+			return err
+		}
+		counts.Favourites++
+	case TableVersions:
+		var row database.VersionRecord
+		if err := json.Unmarshal(rec.Row, &row); err != nil {
+			return fmt.Errorf("decoding favourite version: %w", err)
+		}
+		if row.UserID == "" || row.AssetID == "" || row.Version < 1 {
+			return errors.New("favourite version is missing user_id, asset_id or version")
+		}
+		if err := restorer.PutVersion(ctx, &row); err != nil {
+			return skipped(err, counts)
+		}
+		counts.Versions++
+	case TableAudit:
+		var row database.AuditRecord
+		if err := json.Unmarshal(rec.Row, &row); err != nil {
+			return fmt.Errorf("decoding audit entry: %w", err)
+		}
+		if err := restorer.PutAuditEntry(ctx, &row); err != nil {
+			return skipped(err, counts)
+		}
+		counts.AuditEntries++
+	case TablePreferences:
+		var row database.PreferenceRecord
+		if err := json.Unmarshal(rec.Row, &row); err != nil {
+			return fmt.Errorf("decoding user preferences: %w", err)
+		}
+		if err := restorer.PutPreference(ctx, &row); err != nil {
+			return skipped(err, counts)
+		}
+		counts.Preferences++
+	default:
+		return fmt.Errorf("unknown table %q", rec.Table)
+	}
+	return nil
+}
+
+// skipped counts a record refused with database.ErrNotRestored as skipped
+// and returns any other error.
+func skipped(err error, counts *Counts) error {
+	if errors.Is(err, database.ErrNotRestored) {
+		counts.Skipped++
+		return nil
+	}
+	return err
+}
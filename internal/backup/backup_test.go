@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+var (
+	assetCols      = []string{"asset_type", "id", "data", "updated_at"}
+	favouriteCols  = []string{"id", "user_id", "tenant_id", "asset_type", "description", "data", "created_at", "updated_at", "source_system", "source_url", "favourited_from", "description_html"}
+	versionCols    = []string{"user_id", "tenant_id", "asset_id", "version", "data", "replaced_at"}
+	auditCols      = []string{"id", "user_id", "tenant_id", "actor", "action", "asset_id", "diff", "occurred_at"}
+	preferenceCols = []string{"user_id", "tenant_id", "timezone", "updated_at"}
+)
+
+func setupTestDB(t *testing.T) (*database.Repository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return database.NewRepository(db, database.Options{}), mock
+}
+
+func TestWriteThenRestore(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	chart := []byte(`{"id":"c1","title":"T"}`)
+	oldChart := []byte(`{"id":"c1","title":"Old"}`)
+	catalogChart := []byte(`{"id":"c2","title":"Shared"}`)
+
+	repo, mock := setupTestDB(t)
+	mock.ExpectQuery("SELECT .+ FROM assets").
+		WillReturnRows(sqlmock.NewRows(assetCols).AddRow("chart", "c2", catalogChart, now))
+	mock.ExpectQuery("SELECT .+ FROM favourites").
+		WillReturnRows(sqlmock.NewRows(favouriteCols).
+			AddRow("c1", "user1", "acme", "chart", "desc", chart, now, now, nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT .+ FROM favourite_versions").
+		WillReturnRows(sqlmock.NewRows(versionCols).AddRow("user1", "acme", "c1", 1, oldChart, now))
+	mock.ExpectQuery("SELECT .+ FROM favourite_audit").
+		WillReturnRows(sqlmock.NewRows(auditCols).
+			AddRow(int64(7), "user1", "acme", "user1", "add", "c1", nil, now))
+	mock.ExpectQuery("SELECT .+ FROM user_preferences").
+		WillReturnRows(sqlmock.NewRows(preferenceCols).AddRow("user1", "acme", "Europe/Athens", now))
+
+	interval := progressInterval
+	progressInterval = 2
+	t.Cleanup(func() { progressInterval = interval })
+
+	var buf bytes.Buffer
+	var reports []Counts
+	counts, err := Write(context.Background(), repo, &buf, now, func(c Counts) { reports = append(reports, c) })
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Reported after the 2nd and 4th of the 5 records
+	wantReports := []Counts{{Assets: 1, Favourites: 1}, {Assets: 1, Favourites: 1, Versions: 1, AuditEntries: 1}}
+	if !slices.Equal(reports, wantReports) {
+		t.Errorf("expected progress reports %+v, got %+v", wantReports, reports)
+	}
+	if counts != (Counts{Assets: 1, Favourites: 1, Versions: 1, AuditEntries: 1, Preferences: 1}) {
+		t.Errorf("unexpected write counts: %+v", counts)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("expected header and 5 records, got %d lines:\n%s", len(lines), buf.String())
+	}
+	var header Header
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header.Format != Format || header.Version != Version {
+		t.Errorf("unexpected header %q (err %v)", lines[0], err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL favourites.keep_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO assets").
+		WithArgs("chart", "c2", catalogChart, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO favourites").
+		WithArgs("c1", "user1", "chart", "desc", chart, now, now, "", "", "", nil, "acme").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO favourite_versions").
+		WithArgs("user1", "c1", 1, oldChart, now, "acme").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO favourite_audit").
+		WithArgs(int64(7), "user1", "user1", "add", "c1", nil, now, "acme").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO user_preferences").
+		WithArgs("user1", "Europe/Athens", now, "acme").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("SELECT setval").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectCommit()
+
+	counts, err = Restore(context.Background(), repo, &buf, nil)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if counts != (Counts{Assets: 1, Favourites: 1, Versions: 1, AuditEntries: 1, Preferences: 1}) {
+		t.Errorf("unexpected restore counts: %+v", counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestWriteThenRestore_Memory(t *testing.T) {
+	header := `{"format":"favourites-backup","version":1,"created_at":"2024-05-01T12:00:00Z"}`
+	input := strings.Join([]string{
+		header,
+		`{"table":"assets","row":{"asset_type":"chart","id":"c2","data":{"id":"c2","title":"Shared"},"updated_at":"2024-05-01T12:00:00Z"}}`,
+		`{"table":"favourites","row":{"id":"c1","user_id":"user1","tenant_id":"acme","asset_type":"chart","description":"desc","data":{"id":"c1","title":"T"},"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z"}}`,
+		`{"table":"favourites","row":{"id":"c2","user_id":"user1","tenant_id":"acme","asset_type":"chart","description":"","data":null,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z"}}`,
+		`{"table":"favourite_versions","row":{"user_id":"user1","tenant_id":"acme","asset_id":"c1","version":1,"data":{"id":"c1"},"replaced_at":"2024-05-01T12:00:00Z"}}`,
+		`{"table":"favourite_audit","row":{"id":7,"user_id":"user1","tenant_id":"acme","actor":"user1","action":"add","asset_id":"c1","occurred_at":"2024-05-01T12:00:00Z"}}`,
+		`{"table":"user_preferences","row":{"user_id":"user1","tenant_id":"acme","timezone":"Europe/Athens","updated_at":"2024-05-01T12:00:00Z"}}`,
+	}, "\n")
+
+	repo := database.NewMemoryRepository()
+	counts, err := Restore(context.Background(), repo, strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if counts != (Counts{Assets: 1, Favourites: 2, Skipped: 3}) {
+		t.Errorf("unexpected restore counts: %+v", counts)
+	}
+
+	var buf bytes.Buffer
+	counts, err = Write(context.Background(), repo, &buf, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if counts != (Counts{Favourites: 2}) {
+		t.Errorf("unexpected write counts: %+v", counts)
+	}
+	// The favourite restored from the catalog carries its data in the backup
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], `"data":{"id":"c2","title":"Shared"}`) {
+		t.Errorf("unexpected backup of the memory repository:\n%s", buf.String())
+	}
+}
+
+func TestRestore_RejectsInvalidInput(t *testing.T) {
+	header := `{"format":"favourites-backup","version":1,"created_at":"2024-05-01T12:00:00Z"}`
+
+	tests := []struct {
+		name      string
+		input     string
+		beginsTx  bool
+		errSubstr string
+	}{
+		{name: "empty", input: "", errSubstr: "empty backup"},
+		{name: "wrong format", input: `{"format":"other","version":1}`, errSubstr: "unsupported backup format"},
+		{name: "newer version", input: `{"format":"favourites-backup","version":2}`, errSubstr: "unsupported backup version"},
+		{name: "unknown table", input: header + "\n" + `{"table":"users","row":{}}`, beginsTx: true, errSubstr: `line 2: unknown table "users"`},
+		{name: "catalog asset without data", input: header + "\n" + `{"table":"assets","row":{"asset_type":"chart","id":"c1"}}`, beginsTx: true, errSubstr: "missing asset_type, id or data"},
+		{name: "favourite without key", input: header + "\n" + `{"table":"favourites","row":{"id":"c1"}}`, beginsTx: true, errSubstr: "missing user_id"},
+		{name: "version without number", input: header + "\n" + `{"table":"favourite_versions","row":{"user_id":"u1","asset_id":"c1"}}`, beginsTx: true, errSubstr: "missing user_id, asset_id or version"},
+		{name: "malformed record", input: header + "\n{", beginsTx: true, errSubstr: "decoding record"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			if tt.beginsTx {
+				mock.ExpectBegin()
+				mock.ExpectExec("SET LOCAL favourites.keep_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			}
+
+			_, err := Restore(context.Background(), repo, strings.NewReader(tt.input), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tt.errSubstr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package buildinfo describes the running build of the service.
+//
+// Release builds set the version, commit and build date at link time:
+//
+//	go build -ldflags "-X github.com/giannis84/platform-go-challenge/internal/buildinfo.version=v1.4.0 \
+//		-X github.com/giannis84/platform-go-challenge/internal/buildinfo.commit=$(git rev-parse HEAD) \
+//		-X github.com/giannis84/platform-go-challenge/internal/buildinfo.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/service
+//
+// Values not set that way are read from the module and VCS information the
+// Go toolchain embeds in the binary.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Unknown stands for a value neither set at link time nor embedded by the
+// toolchain.
+const Unknown = "unknown"
+
+// Set with -ldflags -X at link time.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// Info identifies a build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"build_date"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Release names the build for error trackers and the like: its version, or
+// its commit for development builds.
+func (i Info) Release() string {
+	if i.Version == Unknown || i.Version == "(devel)" {
+		return i.Commit
+	}
+	return i.Version
+}
+
+// Get returns the build info of the running binary.
+func Get() Info {
+	return get()
+}
+
+var get = sync.OnceValue(func() Info {
+	return resolve(version, commit, date, debug.ReadBuildInfo)
+})
+
+// resolve fills the values not set at link time from the build info read.
+func resolve(version, commit, date string, read func() (*debug.BuildInfo, bool)) Info {
+	info := Info{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if bi, ok := read(); ok {
+		if info.Version == "" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				// Only meaningful for the revision the toolchain recorded
+				info.Modified = commit == "" && s.Value == "true"
+			}
+		}
+	}
+	for _, v := range []*string{&info.Version, &info.Commit, &info.Date} {
+		if *v == "" {
+			*v = Unknown
+		}
+	}
+	return info
+}
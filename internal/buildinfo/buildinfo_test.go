@@ -0,0 +1,63 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	embedded := &debug.BuildInfo{
+		Main: debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	read := func() (*debug.BuildInfo, bool) { return embedded, true }
+	unreadable := func() (*debug.BuildInfo, bool) { return nil, false }
+
+	tests := []struct {
+		name                  string
+		version, commit, date string
+		read                  func() (*debug.BuildInfo, bool)
+		want                  Info
+		wantRelease           string
+	}{
+		{
+			name:    "set at link time",
+			version: "v1.4.0", commit: "def456", date: "2026-10-02T08:00:00Z",
+			read:        read,
+			want:        Info{Version: "v1.4.0", Commit: "def456", Date: "2026-10-02T08:00:00Z"},
+			wantRelease: "v1.4.0",
+		},
+		{
+			name:        "embedded by the toolchain",
+			read:        read,
+			want:        Info{Version: "(devel)", Commit: "abc123", Date: "2026-10-01T12:00:00Z", Modified: true},
+			wantRelease: "abc123",
+		},
+		{
+			name:        "unknown",
+			read:        unreadable,
+			want:        Info{Version: Unknown, Commit: Unknown, Date: Unknown},
+			wantRelease: Unknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolve(tt.version, tt.commit, tt.date, tt.read)
+			if got.GoVersion == "" {
+				t.Error("expected the Go version")
+			}
+			got.GoVersion = ""
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+			if release := got.Release(); release != tt.wantRelease {
+				t.Errorf("expected release %q, got %q", tt.wantRelease, release)
+			}
+		})
+	}
+}
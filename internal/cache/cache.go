@@ -0,0 +1,145 @@
+// Package cache provides an in-memory LRU cache of users' favourites lists,
+// kept consistent across instances by broadcasting invalidations.
+package cache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ListCache is a size-bounded LRU cache of favourites lists keyed by user
+// (see Key). A nil *ListCache is valid and caches nothing.
+type ListCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+
+	// invalidations counts every Invalidate/Purge so Fetch can tell whether a
+	// list loaded from the database may already be stale.
+	invalidations uint64
+}
+
+type entry struct {
+	userID     string
+	favourites []*models.FavouriteAsset
+}
+
+// Key returns the key of userID's list within tenantID. Users of the default
+// tenant "" are keyed by their ID alone; the quoted tenant keeps users of the
+// same ID in different tenants apart.
+func Key(tenantID, userID string) string {
+	if tenantID == "" {
+		return userID
+	}
+	return strconv.Quote(tenantID) + userID
+}
+
+// NewListCache creates a cache holding the lists of at most size users.
+func NewListCache(size int) *ListCache {
+	return &ListCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Fetch returns the cached list for userID, calling load on a miss and caching
+// its result. A list loaded while an invalidation happened is returned but not
+// cached, since it may predate the write that triggered the invalidation.
+// Callers receive their own copies and may modify them freely.
+func (c *ListCache) Fetch(userID string, load func() ([]*models.FavouriteAsset, error)) ([]*models.FavouriteAsset, error) {
+	if c == nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[userID]; ok {
+		c.order.MoveToFront(el)
+		favourites := copyFavourites(el.Value.(*entry).favourites)
+		c.mu.Unlock()
+		return favourites, nil
+	}
+	seen := c.invalidations
+	c.mu.Unlock()
+
+	favourites, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.invalidations == seen {
+		c.store(userID, copyFavourites(favourites))
+	}
+	return favourites, nil
+}
+
+// Invalidate drops the cached list of userID.
+func (c *ListCache) Invalidate(userID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidations++
+	if el, ok := c.entries[userID]; ok {
+		c.order.Remove(el)
+		delete(c.entries, userID)
+	}
+}
+
+// Purge drops every cached list. It is used when invalidations may have been
+// missed, e.g. after the broadcast connection was re-established.
+func (c *ListCache) Purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidations++
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Len returns the number of cached lists.
+func (c *ListCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// store inserts or replaces the list of userID, evicting the least recently
+// used entry when full. c.mu must be held.
+func (c *ListCache) store(userID string, favourites []*models.FavouriteAsset) {
+	if el, ok := c.entries[userID]; ok {
+		el.Value.(*entry).favourites = favourites
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[userID] = c.order.PushFront(&entry{userID: userID, favourites: favourites})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).userID)
+	}
+}
+
+// copyFavourites shallow-copies each favourite so callers can adjust fields
+// such as timestamps without touching the cached values. Asset data is shared
+// and must be treated as read-only.
+func copyFavourites(favourites []*models.FavouriteAsset) []*models.FavouriteAsset {
+	out := make([]*models.FavouriteAsset, len(favourites))
+	for i, f := range favourites {
+		cp := *f
+		out[i] = &cp
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func listOf(ids ...string) []*models.FavouriteAsset {
+	favs := make([]*models.FavouriteAsset, len(ids))
+	for i, id := range ids {
+		favs[i] = &models.FavouriteAsset{ID: id, CreatedAt: time.Unix(0, 0).UTC()}
+	}
+	return favs
+}
+
+// loader returns a load func that counts its calls.
+func loader(calls *int, favs []*models.FavouriteAsset) func() ([]*models.FavouriteAsset, error) {
+	return func() ([]*models.FavouriteAsset, error) {
+		*calls++
+		return favs, nil
+	}
+}
+
+func TestListCache_FetchCachesAndInvalidates(t *testing.T) {
+	c := NewListCache(10)
+	calls := 0
+
+	for range 2 {
+		favs, err := c.Fetch("user1", loader(&calls, listOf("a", "b")))
+		if err != nil || len(favs) != 2 {
+			t.Fatalf("unexpected fetch result: %v, %v", favs, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 load, got %d", calls)
+	}
+
+	c.Invalidate("user1")
+	c.Fetch("user1", loader(&calls, listOf("a")))
+	if calls != 2 {
+		t.Errorf("expected reload after invalidation, got %d loads", calls)
+	}
+}
+
+func TestListCache_ReturnsCopies(t *testing.T) {
+	c := NewListCache(10)
+	calls := 0
+	c.Fetch("user1", loader(&calls, listOf("a")))
+
+	favs, _ := c.Fetch("user1", loader(&calls, nil))
+	favs[0].CreatedAt = favs[0].CreatedAt.In(time.FixedZone("X", 3600))
+	favs[0].Description = "changed"
+
+	again, _ := c.Fetch("user1", loader(&calls, nil))
+	if again[0].Description != "" || again[0].CreatedAt.Location() != time.UTC {
+		t.Errorf("cached entry was modified through a returned copy: %+v", again[0])
+	}
+}
+
+func TestListCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewListCache(2)
+	calls := 0
+	c.Fetch("user1", loader(&calls, listOf("a")))
+	c.Fetch("user2", loader(&calls, listOf("b")))
+	c.Fetch("user1", loader(&calls, nil)) // user1 is now most recent
+	c.Fetch("user3", loader(&calls, listOf("c")))
+
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+	calls = 0
+	c.Fetch("user1", loader(&calls, listOf("a")))
+	if calls != 0 {
+		t.Error("expected user1 to still be cached")
+	}
+	c.Fetch("user2", loader(&calls, listOf("b")))
+	if calls != 1 {
+		t.Error("expected user2 to have been evicted")
+	}
+}
+
+func TestListCache_DoesNotCacheStaleLoad(t *testing.T) {
+	c := NewListCache(10)
+	_, err := c.Fetch("user1", func() ([]*models.FavouriteAsset, error) {
+		// A write lands while the list is being read from the database.
+		c.Invalidate("user1")
+		return listOf("a"), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected list loaded during an invalidation not to be cached")
+	}
+}
+
+func TestListCache_LoadError(t *testing.T) {
+	c := NewListCache(10)
+	wantErr := errors.New("db down")
+	if _, err := c.Fetch("user1", func() ([]*models.FavouriteAsset, error) { return nil, wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("expected %v, got %v", wantErr, err)
+	}
+	if c.Len() != 0 {
+		t.Error("expected failed load not to be cached")
+	}
+}
+
+func TestListCache_Nil(t *testing.T) {
+	var c *ListCache
+	calls := 0
+	c.Fetch("user1", loader(&calls, listOf("a")))
+	c.Fetch("user1", loader(&calls, listOf("a")))
+	c.Invalidate("user1")
+	c.Purge()
+	if calls != 2 || c.Len() != 0 {
+		t.Errorf("expected nil cache to always load, got %d loads", calls)
+	}
+}
+
+func TestKey(t *testing.T) {
+	if got := Key("", "user1"); got != "user1" {
+		t.Errorf("expected the default tenant to key by user ID, got %q", got)
+	}
+	if Key("acme", "user1") == Key("globex", "user1") || Key("acme", "user1") == "user1" {
+		t.Error("expected users of the same ID in different tenants to have different keys")
+	}
+	if Key("a", `"b"c`) == Key(`a"b`, "c") {
+		t.Error("expected the tenant and user ID to stay apart in the key")
+	}
+}
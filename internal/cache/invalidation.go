@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/lib/pq"
+)
+
+// invalidationChannel is the Postgres NOTIFY channel shared by all instances.
+const invalidationChannel = "favourites_cache_invalidation"
+
+const (
+	notifyTimeout = 5 * time.Second
+	pingInterval  = 90 * time.Second
+)
+
+// Invalidator keeps a ListCache consistent across replicas. Local writes are
+// observed on the event bus and broadcast with Postgres NOTIFY; notifications
+// from other instances invalidate the matching local entries.
+type Invalidator struct {
+	cache      *ListCache
+	db         *sql.DB
+	logger     *slog.Logger
+	instanceID string
+	connected  atomic.Bool
+}
+
+// NewInvalidator creates an Invalidator for cache, broadcasting through db.
+func NewInvalidator(cache *ListCache, db *sql.DB, logger *slog.Logger) *Invalidator {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &Invalidator{
+		cache:      cache,
+		db:         db,
+		logger:     logger,
+		instanceID: hex.EncodeToString(id),
+	}
+}
+
+// Handle invalidates the cached list of the event's user, within its tenant,
+// and broadcasts the invalidation to the other instances. It is meant to be subscribed to the event bus; the
+// broadcast runs in the background so publishers are not blocked.
+func (inv *Invalidator) Handle(e events.Event) {
+	key := Key(e.TenantID, e.UserID)
+	inv.cache.Invalidate(key)
+	go inv.broadcast(key)
+}
+
+// Listen subscribes to invalidations from other instances and applies them in
+// the background until ctx is cancelled. dsn must point at the same database
+// the instances broadcast through.
+func (inv *Invalidator) Listen(ctx context.Context, dsn string) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+			inv.connected.Store(true)
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			inv.connected.Store(false)
+		}
+		if err != nil {
+			logging.With(inv.logger).Layer("cache").Op("listen").Err(err).
+				Warn("cache invalidation listener connection problem")
+		}
+	})
+	if err := listener.Listen(invalidationChannel); err != nil {
+		listener.Close()
+		return fmt.Errorf("listening on %s: %w", invalidationChannel, err)
+	}
+
+	go inv.receive(ctx, listener)
+	return nil
+}
+
+// Connected reports whether the listener started by Listen is connected, so
+// invalidations from other instances are being received.
+func (inv *Invalidator) Connected() bool {
+	return inv.connected.Load()
+}
+
+// HealthCheck fails while the listener is disconnected (see Connected), as
+// this instance then serves lists other instances may have changed.
+func (inv *Invalidator) HealthCheck(context.Context) (map[string]any, error) {
+	if !inv.Connected() {
+		return nil, errors.New("cache invalidation listener disconnected")
+	}
+	return nil, nil
+}
+
+// receive applies notifications until ctx is cancelled, then closes listener.
+func (inv *Invalidator) receive(ctx context.Context, listener *pq.Listener) {
+	defer listener.Close()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				// The connection was re-established and notifications may
+				// have been lost in between.
+				inv.cache.Purge()
+				continue
+			}
+			inv.handleNotification(n.Extra)
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
+
+// broadcast notifies the other instances that the list under key changed.
+func (inv *Invalidator) broadcast(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	payload := inv.instanceID + ":" + key
+	if _, err := inv.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", invalidationChannel, payload); err != nil {
+		logging.With(inv.logger).Layer("cache").Op("broadcast").Str("cache_key", key).Err(err).
+			Error("failed to broadcast cache invalidation")
+	}
+}
+
+// handleNotification applies an invalidation received from another instance.
+// Our own broadcasts are ignored since Handle already invalidated locally.
+func (inv *Invalidator) handleNotification(payload string) {
+	instanceID, key, ok := strings.Cut(payload, ":")
+	if !ok || instanceID == inv.instanceID {
+		return
+	}
+	inv.cache.Invalidate(key)
+}
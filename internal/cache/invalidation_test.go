@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func setupInvalidator(t *testing.T) (*Invalidator, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewInvalidator(NewListCache(10), db, slog.New(slog.NewTextHandler(io.Discard, nil))), mock
+}
+
+func warm(c *ListCache, userID string) {
+	c.Fetch(userID, func() ([]*models.FavouriteAsset, error) { return listOf("a"), nil })
+}
+
+func TestInvalidator_Broadcast(t *testing.T) {
+	inv, mock := setupInvalidator(t)
+	mock.ExpectExec("SELECT pg_notify").
+		WithArgs(invalidationChannel, inv.instanceID+":user1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	inv.broadcast("user1")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestInvalidator_HandleNotification(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     func(inv *Invalidator) string
+		wantCleared bool
+	}{
+		{"other instance", func(*Invalidator) string { return "other:user1" }, true},
+		{"own broadcast", func(inv *Invalidator) string { return inv.instanceID + ":user1" }, false},
+		{"other user", func(*Invalidator) string { return "other:user2" }, false},
+		{"malformed", func(*Invalidator) string { return "user1" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, _ := setupInvalidator(t)
+			warm(inv.cache, "user1")
+
+			inv.handleNotification(tt.payload(inv))
+
+			if cleared := inv.cache.Len() == 0; cleared != tt.wantCleared {
+				t.Errorf("expected cleared=%v, got %v", tt.wantCleared, cleared)
+			}
+		})
+	}
+}
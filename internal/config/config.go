@@ -1,22 +1,84 @@
 package config
 
 import (
+	"cmp"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/giannis84/platform-go-challenge/internal/assets"
 	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/errortracking"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/secrets"
 	"gopkg.in/yaml.v3"
 )
 
 const defaultConfigPath = "config.yaml"
 
+const (
+	defaultSecretsDir     = "/run/secrets"
+	defaultSecretsRefresh = 5 * time.Minute
+	secretsFetchTimeout   = 10 * time.Second
+)
+
+// Checks of /health/ready, as named by ReadinessCritical.
+const (
+	ReadinessDatabase   = "database"
+	ReadinessReplicas   = "replicas"
+	ReadinessCache      = "cache"
+	ReadinessWriteQueue = "write_queue"
+	ReadinessJWKS       = "jwks"
+)
+
+// ReadinessChecks lists every check of /health/ready.
+var ReadinessChecks = []string{ReadinessDatabase, ReadinessReplicas, ReadinessCache, ReadinessWriteQueue, ReadinessJWKS}
+
+// serviceRateMultiplier scales the per-user rate limit up for service
+// principals when no budget of their own is configured.
+const serviceRateMultiplier = 10
+
+// maxJWTLeeway caps the tolerated clock skew, so a typo cannot keep expired
+// tokens valid for hours.
+const maxJWTLeeway = 5 * time.Minute
+
+// defaultRevocationTTL outlasts the tokens of most identity providers.
+const defaultRevocationTTL = 24 * time.Hour
+
+// defaultLogBodyMaxBytes fits typical favourite payloads whole.
+const defaultLogBodyMaxBytes = 4096
+
 // Config holds the application configuration.
 type Config struct {
 	APIPort    string `yaml:"api_port"`
 	HealthPort string `yaml:"health_port"`
 
+	// TLS for both listeners (optional — plain HTTP when TLSCertFile is
+	// empty). APIClientAuth and HealthClientAuth ask each listener's clients
+	// for certificates chaining to ClientCAFile; ClientCertPrincipal selects
+	// the certificate field naming the client.
+	TLSCertFile         string          `yaml:"tls_cert_file"`
+	TLSKeyFile          string          `yaml:"tls_key_file"`
+	ClientCAFile        string          `yaml:"client_ca_file"`
+	APIClientAuth       auth.ClientAuth `yaml:"api_client_auth"`
+	HealthClientAuth    auth.ClientAuth `yaml:"health_client_auth"`
+	ClientCertPrincipal string          `yaml:"client_cert_principal"`
+
 	// HTTP server timeouts (optional, defaults apply in server.go)
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
@@ -24,15 +86,143 @@ type Config struct {
 
 	// JWT signing secret (env var only for testing). When empty, only unsigned tokens
 	// (alg=none) are accepted if AllowUnsignedTokens is true.
-	// Normally in production it should be fetched from a secrets provider like Vault, 
-	// and not set via config file or env var.
+	// Normally in production it should be fetched from a secrets provider like Vault
+	// (see SecretsProvider), and not set via config file or env var.
 	JWTSecret string `yaml:"-"`
 
+	// JWTSecrets holds further HS256 secrets by key ID (env var only, like
+	// JWTSecret), so the secret can be rotated: tokens whose "kid" header
+	// names one are verified with it.
+	JWTSecrets map[string]string `yaml:"-"`
+
+	// JWTPublicKeyFiles maps key IDs to PEM files of RSA public keys verifying
+	// RS256 tokens; JWTPublicKeys holds the keys loaded from them.
+	JWTPublicKeyFiles map[string]string         `yaml:"jwt_public_keys"`
+	JWTPublicKeys     map[string]*rsa.PublicKey `yaml:"-"`
+
 	// AllowUnsignedTokens permits unsigned JWT tokens (alg=none) when true.
 	// This should ONLY be enabled for local development and testing.
 	// Requires explicit opt-in via ALLOW_UNSIGNED_TOKENS=true env var.
 	AllowUnsignedTokens bool `yaml:"-"`
 
+	// JWKSURL is the JSON Web Key Set of an identity provider issuing RS256
+	// tokens (env var only, like JWTSecret). JWKSRefresh is how often the keys
+	// are refetched.
+	JWKSURL     string        `yaml:"-"`
+	JWKSRefresh time.Duration `yaml:"-"`
+
+	// JWTIssuers and JWTAudiences, when set, reject signed tokens whose "iss"
+	// is not one of JWTIssuers or whose "aud" names none of JWTAudiences.
+	JWTIssuers   []string `yaml:"jwt_issuers"`
+	JWTAudiences []string `yaml:"jwt_audiences"`
+
+	// AuthMethods lists the authentication methods (api_key, signature, jwt,
+	// mtls) tried on API requests, in order; empty means auth.DefaultMethods.
+	AuthMethods []string `yaml:"auth_methods"`
+
+	// JWTLeeway tolerates clock skew with the token issuer when checking the
+	// expiry and not-before times of signed tokens.
+	JWTLeeway time.Duration `yaml:"jwt_leeway"`
+
+	// RequireScopes makes the favourites routes require the favourites:read or
+	// favourites:write scope in the token's "scope" or "permissions" claim.
+	RequireScopes bool `yaml:"require_scopes"`
+
+	// RedisURL, when set, keeps revoked token IDs in Redis so all instances
+	// share them (env var only, as it may carry a password); otherwise they
+	// are kept in memory. RevocationTTL is how long a revocation lasts when
+	// the token's expiry is not given.
+	RedisURL      string        `yaml:"-"`
+	RevocationTTL time.Duration `yaml:"revocation_ttl"`
+
+	// SignedURLSecret signs the read-only URLs users hand to third-party widgets
+	// (env var only, like JWTSecret). When empty, signed URLs are disabled.
+	SignedURLSecret string `yaml:"-"`
+
+	// RequestSigningKeys holds the shared secrets of partners signing their
+	// requests with HMAC, by key ID (env var only, like JWTSecret).
+	RequestSigningKeys map[string]string `yaml:"-"`
+
+	// RequestSignatureWindow is how far a signed request's timestamp may be
+	// from the current time (0 = auth.DefaultSignatureWindow).
+	RequestSignatureWindow time.Duration `yaml:"request_signature_window"`
+
+	// LogAllowedFields lists log fields written verbatim in addition to
+	// logging.DefaultAllowedFields; the values of other fields, such as
+	// request payloads, are redacted. With LogHashUserIDs, user IDs are logged
+	// as HMACs under LogHashKey (env var only, like JWTSecret).
+	LogAllowedFields []string `yaml:"log_allowed_fields"`
+	LogHashUserIDs   bool     `yaml:"log_hash_user_ids"`
+	LogHashKey       string   `yaml:"-"`
+
+	// LogLevel is the minimum level of the entries logged at startup: debug,
+	// info (default), warn or error. PUT /admin/log-level changes it at runtime.
+	LogLevel string `yaml:"log_level"`
+
+	// LogBodyRoutes are the routes whose request and response bodies are
+	// logged at debug level, redacted like other fields and cut after
+	// LogBodyMaxBytes (default 4096) each.
+	LogBodyRoutes   []RoutePattern `yaml:"log_body_routes"`
+	LogBodyMaxBytes int            `yaml:"log_body_max_bytes"`
+
+	// SecurityLog is where security events (authentication failures, rate
+	// limit hits, impersonation...) are written apart from the application
+	// logs: "stdout", "stderr" or a file path. Empty disables them.
+	SecurityLog string `yaml:"security_log"`
+
+	// PanicWebhookURL, when set, receives a JSON report of every panic
+	// recovered while serving a request (see logging.PanicReport).
+	PanicWebhookURL string `yaml:"panic_webhook_url"`
+
+	// ErrorTrackingDSN, when set, is the Sentry-compatible DSN that 5xx
+	// responses and recovered panics are reported to, tagged with
+	// ErrorTrackingEnvironment (default "production").
+	ErrorTrackingDSN         string `yaml:"error_tracking_dsn"`
+	ErrorTrackingEnvironment string `yaml:"error_tracking_environment"`
+
+	// AdminUsers lists the user IDs allowed to call the admin endpoints, in
+	// addition to users whose token grants the admin role.
+	AdminUsers []string `yaml:"admin_users"`
+
+	// RolesClaim is the token claim listing the user's roles; dots descend
+	// into nested objects (e.g. "realm_access.roles").
+	RolesClaim string `yaml:"roles_claim"`
+
+	// TenantClaim is the token claim naming the user's tenant; dots descend
+	// into nested objects like for RolesClaim.
+	TenantClaim string `yaml:"tenant_claim"`
+
+	// MultiTenant confines each request's favourites to the tenant named by
+	// TenantClaim, refusing principals without one.
+	MultiTenant bool `yaml:"multi_tenant"`
+
+	// ServiceTenants names the tenant of partners signing their requests, by
+	// key ID, and of clients presenting a certificate, by principal. API keys
+	// keep the tenant they were issued in.
+	ServiceTenants map[string]string `yaml:"service_tenants"`
+
+	// ServiceClaim, in "claim=value" form, marks the tokens of service
+	// principals, which act on behalf of the user named per request. Empty
+	// disables service principals.
+	ServiceClaim string `yaml:"service_claim"`
+
+	// SessionCookie names the cookie browser apps may send the JWT in instead
+	// of the Authorization header; mutating requests authenticated by it need
+	// a matching CSRF token. Empty disables cookie sessions.
+	SessionCookie string `yaml:"session_cookie"`
+
+	// AdminUI serves the embedded admin web UI at /admin when true.
+	AdminUI bool `yaml:"admin_ui"`
+
+	// DBDriver selects the storage backend (see database.Driver).
+	DBDriver string `yaml:"db_driver"`
+
+	// With the memory driver, favourites are loaded from MemorySnapshotPath
+	// at startup and saved to it every MemorySnapshotInterval and on
+	// shutdown. Empty keeps them in memory only.
+	MemorySnapshotPath     string        `yaml:"memory_snapshot_path"`
+	MemorySnapshotInterval time.Duration `yaml:"memory_snapshot_interval"`
+
 	// Database configuration (env vars only — secrets must not live in config.yaml)
 	DBHost     string `yaml:"-"`
 	DBPort     string `yaml:"-"`
@@ -40,9 +230,256 @@ type Config struct {
 	DBPassword string `yaml:"-"`
 	DBName     string `yaml:"-"`
 
+	// MySQLDSN is the data source name of the MySQL database the mysql
+	// driver stores favourites in, e.g. "user:pass@tcp(host:3306)/favourites".
+	MySQLDSN string `yaml:"-"`
+
+	// DBReplicaHosts are read replicas ("host" or "host:port", the port
+	// defaulting to DBPort) that list queries are sent to, with the primary's
+	// credentials. Each is pinged every ReplicaCheckInterval; reads go to the
+	// primary while none is healthy.
+	DBReplicaHosts       []string      `yaml:"-"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"`
+
+	// The circuit breaker of the primary stops connecting to it for
+	// DBBreakerCooldown after DBBreakerThreshold consecutive connection
+	// failures, failing requests at once with 503 instead.
+	DBBreakerThreshold int           `yaml:"db_breaker_threshold"`
+	DBBreakerCooldown  time.Duration `yaml:"db_breaker_cooldown"`
+
+	// DBPreparedStatements prepares the hot favourites queries once per
+	// connection instead of on every call. Poolers in transaction mode, such
+	// as PgBouncer, cannot keep prepared statements.
+	DBPreparedStatements bool `yaml:"db_prepared_statements"`
+
+	// SlowQueryThreshold is how long a favourites operation of the database
+	// layer may take before it is logged as slow (default 500ms; negative
+	// disables the warnings).
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+
+	// SecretsProvider fetches the JWT secret and the database password in
+	// place of the JWT_SECRET and POSTGRES_PASSWORD env vars: "env" (the
+	// default), "file", "vault" or "aws". JWTSecretRef and DBPasswordRef name
+	// the secrets in the provider, which are refetched every SecretsRefresh
+	// so that rotations take effect without a restart.
+	SecretsProvider string        `yaml:"secrets_provider"`
+	SecretsRefresh  time.Duration `yaml:"secrets_refresh"`
+	JWTSecretRef    string        `yaml:"jwt_secret_ref"`
+	DBPasswordRef   string        `yaml:"postgres_password_ref"`
+
+	// SecretsDir holds the secret files of the "file" provider.
+	SecretsDir string `yaml:"secrets_dir"`
+
+	// VaultAddr and VaultMount locate the KV engine of the "vault" provider,
+	// and VaultToken authenticates to it (env var only).
+	VaultAddr  string `yaml:"vault_addr"`
+	VaultMount string `yaml:"vault_mount"`
+	VaultToken string `yaml:"-"`
+
+	// AWSRegion is the Secrets Manager region of the "aws" provider, which
+	// signs its requests with the standard AWS credential env vars.
+	AWSRegion string `yaml:"aws_region"`
+
+	// Secrets is the provider built from the settings above; nil for "env".
+	Secrets secrets.Provider `yaml:"-"`
+
+	// FavouriteQuotas caps how many favourites of each asset type a user may keep
+	// (asset type -> limit; missing or 0 = unlimited).
+	FavouriteQuotas map[string]int `yaml:"favourite_quotas"`
+
+	// AudienceEnums overrides or extends the allowed values of the enumerated
+	// audience fields (field name -> values), since markets define segments
+	// differently.
+	AudienceEnums map[string]EnumConfig `yaml:"audience_enums"`
+
+	// MaxTextLengths overrides the maximum length of free-text fields
+	// (description, title, text -> limit; missing = 255).
+	MaxTextLengths map[string]int `yaml:"max_text_lengths"`
+
+	// LenientJSON accepts unknown fields in add and update favourite bodies
+	// instead of rejecting them.
+	LenientJSON bool `yaml:"lenient_json"`
+
+	// AssetTypeAliases maps former asset type names to the registered types
+	// they were renamed to (alias -> type). Aliases are accepted on input, and
+	// the migrate-asset-types command rewrites stored rows to the new name.
+	AssetTypeAliases map[string]string `yaml:"asset_type_aliases"`
+
+	// EnableGenericAssets turns on the opt-in "generic" asset type, which
+	// stores an untyped JSON payload with an id and a title.
+	EnableGenericAssets bool `yaml:"enable_generic_assets"`
+
+	// AssetRulesDir holds optional JSON Schema documents, one <type>.json per
+	// asset type, evaluated in addition to the built-in asset validators.
+	AssetRulesDir string `yaml:"asset_rules_dir"`
+
+	// SkipMigrations stops the service from migrating the schema at startup;
+	// it then refuses to start until the migrate command has been run.
+	SkipMigrations bool `yaml:"skip_migrations"`
+
+	// FavouritesPartitions, when set, hash partitions the favourites table on
+	// user_id into that many partitions after migrating. 0 leaves it whole.
+	FavouritesPartitions int `yaml:"favourites_partitions"`
+
+	// SkipSchemaCheck starts the service without verifying that the database
+	// has the columns, indexes and migrations it uses.
+	SkipSchemaCheck bool `yaml:"skip_schema_check"`
+
+	// ReadinessCritical names the checks of /health/ready whose failure makes
+	// the service not ready; the others only report it as degraded. Empty
+	// means the database only.
+	ReadinessCritical []string `yaml:"readiness_critical"`
+
+	// Pprof serves the net/http/pprof profiles under /debug/pprof/ on the
+	// health port. With PprofUser set, they need HTTP basic authentication
+	// with PprofPassword (env var only, like JWTSecret).
+	Pprof         bool   `yaml:"pprof"`
+	PprofUser     string `yaml:"pprof_user"`
+	PprofPassword string `yaml:"-"`
+
+	// AssetStorage is where new favourites keep their asset data: "embedded"
+	// (a copy per favourite) or "normalized" (one catalog entry per asset).
+	AssetStorage string `yaml:"asset_storage"`
+
+	// ColumnEncryptionKeys enables encryption of favourite descriptions and
+	// asset data at rest. It lists base64-encoded AES keys by key ID, as
+	// "kid=key,kid=key" (env var only, or fetched from the secrets provider
+	// by ColumnEncryptionKeysRef). New values are encrypted with the key
+	// ColumnEncryptionKeyID, which may be omitted when there is one key; the
+	// others keep older values readable while keys are rotated.
+	ColumnEncryptionKeys    string `yaml:"-"`
+	ColumnEncryptionKeysRef string `yaml:"column_encryption_keys_ref"`
+	ColumnEncryptionKeyID   string `yaml:"column_encryption_key_id"`
+
+	// MaxAssetDataBytes caps the size of the asset_data of a new favourite, so
+	// multi-megabyte blobs never reach the JSONB column.
+	MaxAssetDataBytes int `yaml:"max_asset_data_bytes"`
+
+	// MaxBodyBytes caps the size of every API request body, so oversized
+	// uploads are rejected before they are buffered.
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+
+	// ListCacheSize is how many users' favourites lists each instance caches in
+	// memory (0 = caching disabled). Invalidations are broadcast via Postgres.
+	ListCacheSize int `yaml:"list_cache_size"`
+
+	// Store-and-forward write queue. When WriteQueuePath is set, new favourites
+	// that cannot reach the database are persisted there and replayed later.
+	WriteQueuePath          string        `yaml:"write_queue_path"`
+	WriteQueueCapacity      int           `yaml:"write_queue_capacity"`
+	WriteQueueFlushInterval time.Duration `yaml:"write_queue_flush_interval"`
+
+	// Asynchronous exports. Finished export files are written to ExportDir and
+	// kept for ExportTTL; ExportWorkers jobs run at once and at most
+	// ExportQueueCapacity wait.
+	ExportDir           string        `yaml:"export_dir"`
+	ExportWorkers       int           `yaml:"export_workers"`
+	ExportQueueCapacity int           `yaml:"export_queue_capacity"`
+	ExportTTL           time.Duration `yaml:"export_ttl"`
+
+	// Deleted favourites are kept for SoftDeleteRetention before they are
+	// purged, which runs every SoftDeletePurgeInterval.
+	SoftDeleteRetention     time.Duration `yaml:"soft_delete_retention"`
+	SoftDeletePurgeInterval time.Duration `yaml:"soft_delete_purge_interval"`
+
+	// EventOutbox records change events in the database, in the transaction
+	// of their change, and relays them to the event bus once committed,
+	// checking for events left behind every OutboxRelayInterval.
+	EventOutbox         bool          `yaml:"event_outbox"`
+	OutboxRelayInterval time.Duration `yaml:"outbox_relay_interval"`
+
+	// CORS. Cross-origin requests are only allowed when CORSAllowedOrigins is
+	// set; "*" allows any origin but cannot be combined with credentials.
+	CORSAllowedOrigins   []string      `yaml:"cors_allowed_origins"`
+	CORSAllowedMethods   []string      `yaml:"cors_allowed_methods"`
+	CORSAllowedHeaders   []string      `yaml:"cors_allowed_headers"`
+	CORSMaxAge           time.Duration `yaml:"cors_max_age"`
+	CORSAllowCredentials bool          `yaml:"cors_allow_credentials"`
+
+	// APIV1Sunset, when set, is announced in the Sunset header of /api/v1
+	// responses as the date v1 stops being served.
+	APIV1Sunset time.Time `yaml:"api_v1_sunset"`
+
 	// Rate limiting configuration
 	RateLimitRequests int           `yaml:"rate_limit_requests"` // Max requests per window (0 = disabled)
 	RateLimitWindow   time.Duration `yaml:"rate_limit_window"`   // Time window for rate limiting
+
+	// ServiceRateLimitRequests is the budget per window of each service
+	// principal, shared by all the users it acts for (0 = 10x
+	// RateLimitRequests).
+	ServiceRateLimitRequests int `yaml:"service_rate_limit_requests"`
+
+	// TenantRateLimitRequests is the budget per window of each tenant,
+	// shared by all its users, with MultiTenant (0 = no tenant budget).
+	TenantRateLimitRequests int `yaml:"tenant_rate_limit_requests"`
+
+	// RateLimitPolicies give the routes they match budgets of their own
+	// instead of RateLimitRequests; the first matching policy applies.
+	RateLimitPolicies []RateLimitPolicy `yaml:"rate_limit_policies"`
+
+	// AuthFailureThreshold is how many failed authentications per client IP
+	// or credential are tolerated within AuthFailureWindow before further
+	// 401s are delayed, increasingly up to AuthFailureMaxDelay (0 = no
+	// throttling). AuthFailureBanAfter failures ban the client for
+	// AuthFailureBanDuration (0 = never).
+	AuthFailureThreshold   int           `yaml:"auth_failure_threshold"`
+	AuthFailureWindow      time.Duration `yaml:"auth_failure_window"`
+	AuthFailureMaxDelay    time.Duration `yaml:"auth_failure_max_delay"`
+	AuthFailureBanAfter    int           `yaml:"auth_failure_ban_after"`
+	AuthFailureBanDuration time.Duration `yaml:"auth_failure_ban_duration"`
+	// ClientIPHeader names the header ("X-Forwarded-For" or "X-Real-IP")
+	// giving the client IP of requests from TrustedProxies (IPs or CIDRs),
+	// under which their failed authentications are counted.
+	ClientIPHeader string   `yaml:"client_ip_header"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// RateLimitPolicy is a rate limit for the routes matching Method and Path.
+// Each policy keeps one budget per user, shared by all the routes it matches.
+type RateLimitPolicy struct {
+	// Method is the HTTP method of the routes; empty matches any.
+	Method string `yaml:"method"`
+	// Path is a route path as listed in the API docs without the version
+	// prefix (e.g. /favourites/{assetID}); a trailing * matches any path
+	// with that prefix, and empty matches any path.
+	Path     string        `yaml:"path"`
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"` // 0 = rate_limit_window
+	// ServiceRequests is the budget of each service principal (0 = 10x
+	// Requests).
+	ServiceRequests int `yaml:"service_requests"`
+}
+
+// Matches reports whether the route with the given method and path (as
+// declared in the routes table) is subject to p.
+func (p RateLimitPolicy) Matches(method, path string) bool {
+	return RoutePattern{Method: p.Method, Path: p.Path}.Matches(method, path)
+}
+
+// RoutePattern selects routes by Method and Path, with the same meaning as
+// in RateLimitPolicy.
+type RoutePattern struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+}
+
+// Matches reports whether the route with the given method and path (as
+// declared in the routes table) is selected by p.
+func (p RoutePattern) Matches(method, path string) bool {
+	if p.Method != "" && p.Method != method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(p.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return p.Path == "" || p.Path == path
+}
+
+// EnumConfig changes the allowed values of an enumerated field: Values
+// replaces the defaults and Extend appends to them (or to Values).
+type EnumConfig struct {
+	Values []string `yaml:"values"`
+	Extend []string `yaml:"extend"`
 }
 
 // Load reads configuration with the following precedence (highest wins):
@@ -79,19 +516,391 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("health_port is required (set via config file or HEALTH_PORT env var)")
 	}
 
+	// TLS and client certificates (env vars override config file)
+	if v := os.Getenv("TLS_CERT_FILE"); v != "" {
+		cfg.TLSCertFile = v
+	}
+	if v := os.Getenv("TLS_KEY_FILE"); v != "" {
+		cfg.TLSKeyFile = v
+	}
+	if v := os.Getenv("CLIENT_CA_FILE"); v != "" {
+		cfg.ClientCAFile = v
+	}
+	if v := os.Getenv("API_CLIENT_AUTH"); v != "" {
+		cfg.APIClientAuth = auth.ClientAuth(v)
+	}
+	if v := os.Getenv("HEALTH_CLIENT_AUTH"); v != "" {
+		cfg.HealthClientAuth = auth.ClientAuth(v)
+	}
+	if v := os.Getenv("CLIENT_CERT_PRINCIPAL"); v != "" {
+		cfg.ClientCertPrincipal = v
+	}
+	if err := validateTLS(cfg); err != nil {
+		return nil, err
+	}
+
+	// Storage backend (env var overrides config file)
+	if v := os.Getenv("DB_DRIVER"); v != "" {
+		cfg.DBDriver = v
+	}
+	switch database.Driver(cfg.DBDriver) {
+	case "":
+		cfg.DBDriver = string(database.DriverPostgres) // Default driver
+	case database.DriverPostgres, database.DriverMySQL, database.DriverMemory:
+	default:
+		return nil, fmt.Errorf("db_driver must be %q, %q or %q, got %q",
+			database.DriverPostgres, database.DriverMySQL, database.DriverMemory, cfg.DBDriver)
+	}
+	if v := os.Getenv("MEMORY_SNAPSHOT_PATH"); v != "" {
+		cfg.MemorySnapshotPath = v
+	}
+	if v := os.Getenv("MEMORY_SNAPSHOT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.MemorySnapshotInterval = d
+		}
+	}
+	if cfg.MemorySnapshotInterval <= 0 {
+		cfg.MemorySnapshotInterval = 30 * time.Second // Default snapshot interval
+	}
+
 	// Database configuration from environment variables
 	cfg.DBHost = os.Getenv("POSTGRES_HOST")
 	cfg.DBPort = os.Getenv("POSTGRES_PORT")
 	cfg.DBUser = os.Getenv("POSTGRES_USER")
-	cfg.DBPassword = os.Getenv("POSTGRES_PASSWORD")
 	cfg.DBName = os.Getenv("POSTGRES_DB")
+	if v := os.Getenv("POSTGRES_REPLICA_HOSTS"); v != "" {
+		cfg.DBReplicaHosts = splitList(v)
+	}
+	if v := os.Getenv("REPLICA_CHECK_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ReplicaCheckInterval = d
+		}
+	}
+	if len(cfg.DBReplicaHosts) > 0 && cfg.ReplicaCheckInterval <= 0 {
+		cfg.ReplicaCheckInterval = 10 * time.Second // Default replica health check interval
+	}
+	if v := os.Getenv("DB_BREAKER_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.DBBreakerThreshold = n
+		}
+	}
+	if v := os.Getenv("DB_BREAKER_COOLDOWN"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.DBBreakerCooldown = d
+		}
+	}
+	if cfg.DBBreakerThreshold <= 0 {
+		cfg.DBBreakerThreshold = 5 // Default consecutive failures opening the breaker
+	}
+	if cfg.DBBreakerCooldown <= 0 {
+		cfg.DBBreakerCooldown = 10 * time.Second // Default time before a probe
+	}
+	if v := os.Getenv("DB_PREPARED_STATEMENTS"); v != "" {
+		cfg.DBPreparedStatements = v == "true"
+	}
+	if v := os.Getenv("SLOW_QUERY_THRESHOLD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SLOW_QUERY_THRESHOLD: %w", err)
+		}
+		cfg.SlowQueryThreshold = d
+	}
+	if cfg.SlowQueryThreshold == 0 {
+		cfg.SlowQueryThreshold = 500 * time.Millisecond // Default slow query threshold
+	}
+
+	// Secrets, each from its env var or the file named by its _FILE variant.
+	// JWT secret (optional — when empty AND AllowUnsignedTokens is true,
+	// unsigned tokens are accepted). Column encryption keys (optional —
+	// descriptions and asset data are stored as plaintext when empty).
+	for env, field := range map[string]*string{
+		"POSTGRES_PASSWORD":      &cfg.DBPassword,
+		"MYSQL_DSN":              &cfg.MySQLDSN,
+		"JWT_SECRET":             &cfg.JWTSecret,
+		"COLUMN_ENCRYPTION_KEYS": &cfg.ColumnEncryptionKeys,
+		"SIGNED_URL_SECRET":      &cfg.SignedURLSecret,
+		"LOG_HASH_KEY":           &cfg.LogHashKey,
+		"PPROF_PASSWORD":         &cfg.PprofPassword,
+		"REDIS_URL":              &cfg.RedisURL,
+		"VAULT_TOKEN":            &cfg.VaultToken,
+	} {
+		v, err := secretEnv(env)
+		if err != nil {
+			return nil, err
+		}
+		*field = v
+	}
+
+	// Secrets provider (optional — replaces JWT_SECRET, POSTGRES_PASSWORD and
+	// COLUMN_ENCRYPTION_KEYS)
+	if err := loadSecrets(cfg); err != nil {
+		return nil, err
+	}
+
+	// Secrets and public keys by key ID, for rotating signing keys
+	jwtSecrets, err := secretEnv("JWT_SECRETS")
+	if err != nil {
+		return nil, err
+	}
+	if v := jwtSecrets; v != "" {
+		secrets, err := parsePairs("JWT_SECRETS", "kid=secret", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.JWTSecrets = secrets
+	}
+	if v := os.Getenv("JWT_PUBLIC_KEYS"); v != "" {
+		files, err := parsePairs("JWT_PUBLIC_KEYS", "kid=path", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.JWTPublicKeyFiles = files
+	}
+	if cfg.JWTPublicKeys, err = auth.LoadRSAPublicKeys(cfg.JWTPublicKeyFiles); err != nil {
+		return nil, fmt.Errorf("jwt_public_keys: %w", err)
+	}
+
+	// JWKS for RS256 tokens (optional — accepted alongside HS256 when both are set)
+	cfg.JWKSURL = os.Getenv("JWKS_URL")
+	cfg.JWKSRefresh = auth.DefaultJWKSRefresh
+	if v := os.Getenv("JWKS_REFRESH"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("JWKS_REFRESH must be a positive duration, got %q", v)
+		}
+		cfg.JWKSRefresh = d
+	}
+	if cfg.JWKSURL != "" {
+		if u, err := url.Parse(cfg.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("JWKS_URL must be an absolute http(s) URL, got %q", cfg.JWKSURL)
+		}
+	}
+
+	// Expected token issuers and audiences (env var overrides config file, comma-separated)
+	if v := os.Getenv("JWT_ISSUERS"); v != "" {
+		cfg.JWTIssuers = splitList(v)
+	}
+	if v := os.Getenv("JWT_AUDIENCES"); v != "" {
+		cfg.JWTAudiences = splitList(v)
+	}
+
+	// Authentication methods and their order (env var overrides config file, comma-separated)
+	if v := os.Getenv("AUTH_METHODS"); v != "" {
+		cfg.AuthMethods = splitList(v)
+	}
+	if err := auth.ValidateMethods(cfg.AuthMethods); err != nil {
+		return nil, fmt.Errorf("auth_methods: %w", err)
+	}
+
+	// Clock skew tolerated on token times (env var overrides config file)
+	if v := os.Getenv("JWT_LEEWAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_LEEWAY must be a duration, got %q", v)
+		}
+		cfg.JWTLeeway = d
+	}
+	if cfg.JWTLeeway < 0 || cfg.JWTLeeway > maxJWTLeeway {
+		return nil, fmt.Errorf("jwt_leeway must be between 0 and %s, got %s", maxJWTLeeway, cfg.JWTLeeway)
+	}
+
+	// Scope enforcement (env var overrides config file)
+	if v := os.Getenv("REQUIRE_SCOPES"); v != "" {
+		cfg.RequireScopes = v == "true"
+	}
+
+	// Token revocation: shared through Redis (REDIS_URL) when configured, default TTL 24h
+	if v := os.Getenv("REVOCATION_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("REVOCATION_TTL must be a duration, got %q", v)
+		}
+		cfg.RevocationTTL = d
+	}
+	if cfg.RevocationTTL == 0 {
+		cfg.RevocationTTL = defaultRevocationTTL
+	}
+	if cfg.RevocationTTL < 0 {
+		return nil, fmt.Errorf("revocation_ttl must be positive, got %s", cfg.RevocationTTL)
+	}
 
-	// JWT secret (optional — when empty AND AllowUnsignedTokens is true, unsigned tokens are accepted)
-	cfg.JWTSecret = os.Getenv("JWT_SECRET")
+	// Request signing keys of partners (optional — signed requests are
+	// rejected when empty). The signed URL secret (SIGNED_URL_SECRET) is read
+	// with the other secrets.
+	signingKeys, err := secretEnv("REQUEST_SIGNING_KEYS")
+	if err != nil {
+		return nil, err
+	}
+	if v := signingKeys; v != "" {
+		keys, err := parsePairs("REQUEST_SIGNING_KEYS", "key=secret", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RequestSigningKeys = keys
+	}
+	if v := os.Getenv("REQUEST_SIGNATURE_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("REQUEST_SIGNATURE_WINDOW must be a duration, got %q", v)
+		}
+		cfg.RequestSignatureWindow = d
+	}
+	if cfg.RequestSignatureWindow < 0 {
+		return nil, fmt.Errorf("request_signature_window must not be negative, got %s", cfg.RequestSignatureWindow)
+	}
+	if cfg.RequestSignatureWindow == 0 {
+		cfg.RequestSignatureWindow = auth.DefaultSignatureWindow
+	}
 
 	// Allow unsigned tokens (explicit opt-in for dev/test only)
 	cfg.AllowUnsignedTokens = os.Getenv("ALLOW_UNSIGNED_TOKENS") == "true"
 
+	// Log redaction (env vars override config file)
+	if v := os.Getenv("LOG_ALLOWED_FIELDS"); v != "" {
+		cfg.LogAllowedFields = splitList(v)
+	}
+	if v := os.Getenv("LOG_HASH_USER_IDS"); v != "" {
+		cfg.LogHashUserIDs = v == "true"
+	}
+	if v := os.Getenv("SECURITY_LOG"); v != "" {
+		cfg.SecurityLog = v
+	}
+
+	// Log level (env var overrides config file)
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		cfg.LogLevel = v
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+	if _, err := logging.ParseLevel(cfg.LogLevel); err != nil {
+		return nil, fmt.Errorf("log_level: unknown level %q (expected debug, info, warn or error)", cfg.LogLevel)
+	}
+
+	// Panic alerts (env var overrides config file)
+	if v := os.Getenv("PANIC_WEBHOOK_URL"); v != "" {
+		cfg.PanicWebhookURL = v
+	}
+	if cfg.PanicWebhookURL != "" {
+		if u, err := url.Parse(cfg.PanicWebhookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("panic_webhook_url must be an absolute http(s) URL, got %q", cfg.PanicWebhookURL)
+		}
+	}
+
+	// Error tracking (env vars override config file)
+	if v := os.Getenv("ERROR_TRACKING_DSN"); v != "" {
+		cfg.ErrorTrackingDSN = v
+	}
+	if v := os.Getenv("ERROR_TRACKING_ENVIRONMENT"); v != "" {
+		cfg.ErrorTrackingEnvironment = v
+	}
+	if cfg.ErrorTrackingEnvironment == "" {
+		cfg.ErrorTrackingEnvironment = "production"
+	}
+	if cfg.ErrorTrackingDSN != "" {
+		if _, err := errortracking.ParseDSN(cfg.ErrorTrackingDSN); err != nil {
+			return nil, fmt.Errorf("error_tracking_dsn: %w", err)
+		}
+	}
+
+	// Body logging (env vars override config file)
+	if v := os.Getenv("LOG_BODY_ROUTES"); v != "" {
+		patterns, err := parseRoutePatterns("LOG_BODY_ROUTES", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.LogBodyRoutes = patterns
+	}
+	if v := os.Getenv("LOG_BODY_MAX_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOG_BODY_MAX_BYTES: %w", err)
+		}
+		cfg.LogBodyMaxBytes = n
+	}
+	if cfg.LogBodyMaxBytes < 0 {
+		return nil, fmt.Errorf("log_body_max_bytes must not be negative, got %d", cfg.LogBodyMaxBytes)
+	}
+	if cfg.LogBodyMaxBytes == 0 {
+		cfg.LogBodyMaxBytes = defaultLogBodyMaxBytes
+	}
+	for i := range cfg.LogBodyRoutes {
+		p := &cfg.LogBodyRoutes[i]
+		p.Method = strings.ToUpper(p.Method)
+		if p.Method != "" && !slices.Contains(rateLimitMethods, p.Method) {
+			return nil, fmt.Errorf("log_body_routes[%d]: unsupported method %q", i, p.Method)
+		}
+		if p.Path != "" && !strings.HasPrefix(p.Path, "/") {
+			return nil, fmt.Errorf("log_body_routes[%d]: path %q must start with /", i, p.Path)
+		}
+	}
+
+	// Admin users (env var overrides config file, comma-separated)
+	if v := os.Getenv("ADMIN_USERS"); v != "" {
+		cfg.AdminUsers = splitList(v)
+	}
+
+	// Roles claim (env var overrides config file)
+	if v := os.Getenv("ROLES_CLAIM"); v != "" {
+		cfg.RolesClaim = v
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = auth.DefaultRolesClaim
+	}
+	if slices.Contains(strings.Split(cfg.RolesClaim, "."), "") {
+		return nil, fmt.Errorf("roles_claim must not have empty path segments, got %q", cfg.RolesClaim)
+	}
+
+	// Tenant claim (env var overrides config file)
+	if v := os.Getenv("TENANT_CLAIM"); v != "" {
+		cfg.TenantClaim = v
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = auth.DefaultTenantClaim
+	}
+	if slices.Contains(strings.Split(cfg.TenantClaim, "."), "") {
+		return nil, fmt.Errorf("tenant_claim must not have empty path segments, got %q", cfg.TenantClaim)
+	}
+	if v := os.Getenv("MULTI_TENANT"); v != "" {
+		cfg.MultiTenant = v == "true"
+	}
+	if v := os.Getenv("SERVICE_TENANTS"); v != "" {
+		tenants, err := parsePairs("SERVICE_TENANTS", "principal=tenant", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ServiceTenants = tenants
+	}
+
+	// Service principal claim (env var overrides config file)
+	if v := os.Getenv("SERVICE_CLAIM"); v != "" {
+		cfg.ServiceClaim = v
+	}
+	if cfg.ServiceClaim != "" {
+		path, value, ok := strings.Cut(cfg.ServiceClaim, "=")
+		if !ok || value == "" || slices.Contains(strings.Split(path, "."), "") {
+			return nil, fmt.Errorf("service_claim must be of the form claim=value, got %q", cfg.ServiceClaim)
+		}
+	}
+
+	// Cookie sessions (env var overrides config file)
+	if v := os.Getenv("SESSION_COOKIE"); v != "" {
+		cfg.SessionCookie = v
+	}
+	if cfg.SessionCookie != "" {
+		if err := (&http.Cookie{Name: cfg.SessionCookie}).Valid(); err != nil {
+			return nil, fmt.Errorf("session_cookie %q is not a valid cookie name", cfg.SessionCookie)
+		}
+		if cfg.SessionCookie == auth.CSRFCookie {
+			return nil, fmt.Errorf("session_cookie must not be %q, which holds the CSRF token", auth.CSRFCookie)
+		}
+	}
+
+	// Admin UI (env var overrides config file)
+	if v := os.Getenv("ADMIN_UI"); v != "" {
+		cfg.AdminUI = v == "true"
+	}
+
 	// HTTP server timeouts (optional — defaults apply in server.go if zero)
 	if v := os.Getenv("READ_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
@@ -109,20 +918,26 @@ func Load() (*Config, error) {
 		}
 	}
 
-	if cfg.DBHost == "" {
-		return nil, fmt.Errorf("POSTGRES_HOST env var is required")
+	// The memory driver needs no database; MySQL is reached through its DSN
+	if cfg.DBDriver == string(database.DriverMySQL) && cfg.MySQLDSN == "" {
+		return nil, fmt.Errorf("MYSQL_DSN or MYSQL_DSN_FILE env var is required")
 	}
-	if cfg.DBPort == "" {
-		return nil, fmt.Errorf("POSTGRES_PORT env var is required")
-	}
-	if cfg.DBUser == "" {
-		return nil, fmt.Errorf("POSTGRES_USER env var is required")
-	}
-	if cfg.DBPassword == "" {
-		return nil, fmt.Errorf("POSTGRES_PASSWORD env var is required")
-	}
-	if cfg.DBName == "" {
-		return nil, fmt.Errorf("POSTGRES_DB env var is required")
+	if cfg.DBDriver == string(database.DriverPostgres) {
+		if cfg.DBHost == "" {
+			return nil, fmt.Errorf("POSTGRES_HOST env var is required")
+		}
+		if cfg.DBPort == "" {
+			return nil, fmt.Errorf("POSTGRES_PORT env var is required")
+		}
+		if cfg.DBUser == "" {
+			return nil, fmt.Errorf("POSTGRES_USER env var is required")
+		}
+		if cfg.DBPassword == "" {
+			return nil, fmt.Errorf("POSTGRES_PASSWORD or POSTGRES_PASSWORD_FILE env var is required")
+		}
+		if cfg.DBName == "" {
+			return nil, fmt.Errorf("POSTGRES_DB env var is required")
+		}
 	}
 
 	// Rate limiting configuration (env vars override config file)
@@ -136,51 +951,972 @@ func Load() (*Config, error) {
 			cfg.RateLimitWindow = d
 		}
 	}
+	if v := os.Getenv("SERVICE_RATE_LIMIT_REQUESTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ServiceRateLimitRequests = n
+		}
+	}
+	if v := os.Getenv("TENANT_RATE_LIMIT_REQUESTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.TenantRateLimitRequests = n
+		}
+	}
 
-	// Apply rate limiting defaults if partially configured
-	if cfg.RateLimitRequests > 0 && cfg.RateLimitWindow == 0 {
-		cfg.RateLimitWindow = time.Minute // Default window: 1 minute
+	// Rate limit policies (env var overrides config file, e.g. "GET=300,POST /favourites*=30")
+	if v := os.Getenv("RATE_LIMIT_POLICIES"); v != "" {
+		policies, err := parseRateLimitPolicies(v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RateLimitPolicies = policies
 	}
 
-	return cfg, nil
-}
+	// Failed-authentication throttling (env vars override config file)
+	for _, setting := range []struct {
+		env string
+		n   *int
+	}{
+		{"AUTH_FAILURE_THRESHOLD", &cfg.AuthFailureThreshold},
+		{"AUTH_FAILURE_BAN_AFTER", &cfg.AuthFailureBanAfter},
+	} {
+		if v := os.Getenv(setting.env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an integer, got %q", setting.env, v)
+			}
+			*setting.n = n
+		}
+	}
+	for _, setting := range []struct {
+		env string
+		d   *time.Duration
+	}{
+		{"AUTH_FAILURE_WINDOW", &cfg.AuthFailureWindow},
+		{"AUTH_FAILURE_MAX_DELAY", &cfg.AuthFailureMaxDelay},
+		{"AUTH_FAILURE_BAN_DURATION", &cfg.AuthFailureBanDuration},
+	} {
+		if v := os.Getenv(setting.env); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a duration, got %q", setting.env, v)
+			}
+			*setting.d = d
+		}
+	}
+	if cfg.AuthFailureThreshold < 0 || cfg.AuthFailureBanAfter < 0 || cfg.AuthFailureWindow < 0 || cfg.AuthFailureMaxDelay < 0 || cfg.AuthFailureBanDuration < 0 {
+		return nil, fmt.Errorf("auth_failure_* settings must not be negative")
+	}
+	if v := os.Getenv("CLIENT_IP_HEADER"); v != "" {
+		cfg.ClientIPHeader = v
+	}
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		cfg.TrustedProxies = splitList(v)
+	}
+	if _, err := parseTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if cfg.ClientIPHeader != "" && len(cfg.TrustedProxies) == 0 {
+		return nil, fmt.Errorf("client_ip_header requires trusted_proxies, or any client could choose its IP")
+	}
 
-// PostgresConnString returns a PostgreSQL connection string.
-func (c *Config) PostgresConnString() string {
-	return fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName,
-	)
-}
+	// Favourite quotas (env var overrides config file, e.g. "chart=500,audience=50")
+	if v := os.Getenv("FAVOURITE_QUOTAS"); v != "" {
+		quotas, err := parseLimits("FAVOURITE_QUOTAS", "type", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.FavouriteQuotas = quotas
+	}
+	for assetType, limit := range cfg.FavouriteQuotas {
+		if !isKnownAssetType(assetType) {
+			return nil, fmt.Errorf("favourite_quotas: unknown asset type %q", assetType)
+		}
+		if limit < 0 {
+			return nil, fmt.Errorf("favourite_quotas: limit for %q must not be negative", assetType)
+		}
+	}
 
-// APIAddr returns the listen address for the API server.
-func (c *Config) APIAddr() string {
-	return ":" + c.APIPort
-}
+	// Audience enumerations (config file only)
+	if _, err := cfg.AudienceEnumConfig(); err != nil {
+		return nil, err
+	}
+
+	// Text field lengths (env var overrides config file)
+	if v := os.Getenv("MAX_TEXT_LENGTHS"); v != "" {
+		lengths, err := parseLimits("MAX_TEXT_LENGTHS", "field", v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MaxTextLengths = lengths
+	}
+	if _, err := cfg.TextLimitConfig(); err != nil {
+		return nil, err
+	}
+
+	// Lenient JSON decoding (env var overrides config file)
+	if v := os.Getenv("LENIENT_JSON"); v != "" {
+		cfg.LenientJSON = v == "true"
+	}
+
+	// Asset type aliases (env var overrides config file, e.g. "segment=audience")
+	if v := os.Getenv("ASSET_TYPE_ALIASES"); v != "" {
+		aliases := make(map[string]string)
+		for _, item := range splitList(v) {
+			alias, target, ok := strings.Cut(item, "=")
+			if !ok {
+				return nil, fmt.Errorf("ASSET_TYPE_ALIASES: invalid entry %q (expected alias=type)", item)
+			}
+			aliases[strings.TrimSpace(alias)] = strings.TrimSpace(target)
+		}
+		cfg.AssetTypeAliases = aliases
+	}
+	for alias, target := range cfg.AssetTypeAliases {
+		if strings.TrimSpace(alias) == "" {
+			return nil, fmt.Errorf("asset_type_aliases: alias must not be empty")
+		}
+		if isKnownAssetType(alias) {
+			return nil, fmt.Errorf("asset_type_aliases: %q is a registered asset type", alias)
+		}
+		if !isKnownAssetType(target) {
+			return nil, fmt.Errorf("asset_type_aliases: %q maps to unknown asset type %q", alias, target)
+		}
+	}
+
+	// Generic assets (env var overrides config file)
+	if v := os.Getenv("ENABLE_GENERIC_ASSETS"); v != "" {
+		cfg.EnableGenericAssets = v == "true"
+	}
+
+	// Asset validation rules (env var overrides config file)
+	if v := os.Getenv("ASSET_RULES_DIR"); v != "" {
+		cfg.AssetRulesDir = v
+	}
+	if _, err := cfg.AssetRules(); err != nil {
+		return nil, err
+	}
+
+	// Column encryption key ID (env var overrides config file)
+	if v := os.Getenv("COLUMN_ENCRYPTION_KEY_ID"); v != "" {
+		cfg.ColumnEncryptionKeyID = v
+	}
+	if _, err := cfg.ColumnCipher(); err != nil {
+		return nil, err
+	}
+	if cfg.ColumnEncryptionKeys != "" && cfg.DBDriver != string(database.DriverPostgres) {
+		return nil, fmt.Errorf("column encryption needs db_driver %q", database.DriverPostgres)
+	}
+
+	// Schema migrations at startup (env var overrides config file)
+	if v := os.Getenv("SKIP_MIGRATIONS"); v != "" {
+		cfg.SkipMigrations = v == "true"
+	}
+
+	// Schema check at startup (env var overrides config file)
+	if v := os.Getenv("SKIP_SCHEMA_CHECK"); v != "" {
+		cfg.SkipSchemaCheck = v == "true"
+	}
+
+	// Favourites partitions (env var overrides config file)
+	if v := os.Getenv("FAVOURITES_PARTITIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("FAVOURITES_PARTITIONS must be a number, got %q", v)
+		}
+		cfg.FavouritesPartitions = n
+	}
+	if cfg.FavouritesPartitions < 0 || cfg.FavouritesPartitions == 1 {
+		return nil, fmt.Errorf("favourites_partitions must be 0 or at least 2, got %d", cfg.FavouritesPartitions)
+	}
+
+	// Readiness checks failing readiness (env var overrides config file, comma-separated)
+	if v := os.Getenv("READINESS_CRITICAL"); v != "" {
+		cfg.ReadinessCritical = splitList(v)
+	}
+	if len(cfg.ReadinessCritical) == 0 {
+		cfg.ReadinessCritical = []string{ReadinessDatabase}
+	}
+	for _, check := range cfg.ReadinessCritical {
+		if !slices.Contains(ReadinessChecks, check) {
+			return nil, fmt.Errorf("readiness_critical: unknown check %q (expected one of %s)", check, strings.Join(ReadinessChecks, ", "))
+		}
+	}
+
+	// Profiling endpoints (env vars override config file)
+	if v := os.Getenv("PPROF"); v != "" {
+		cfg.Pprof = v == "true"
+	}
+	if v := os.Getenv("PPROF_USER"); v != "" {
+		cfg.PprofUser = v
+	}
+	if cfg.PprofUser != "" && cfg.PprofPassword == "" {
+		return nil, fmt.Errorf("PPROF_PASSWORD is required when pprof_user is set")
+	}
+
+	// Asset storage mode (env var overrides config file)
+	if v := os.Getenv("ASSET_STORAGE"); v != "" {
+		cfg.AssetStorage = v
+	}
+	switch database.AssetStorage(cfg.AssetStorage) {
+	case "":
+		cfg.AssetStorage = string(database.StorageEmbedded) // Default mode
+	case database.StorageEmbedded, database.StorageNormalized:
+	default:
+		return nil, fmt.Errorf("asset_storage must be %q or %q, got %q",
+			database.StorageEmbedded, database.StorageNormalized, cfg.AssetStorage)
+	}
+
+	// Asset data size limit (env var overrides config file)
+	if v := os.Getenv("MAX_ASSET_DATA_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxAssetDataBytes = n
+		}
+	}
+	if cfg.MaxAssetDataBytes < 0 {
+		return nil, fmt.Errorf("max_asset_data_bytes must not be negative")
+	}
+	if cfg.MaxAssetDataBytes == 0 {
+		cfg.MaxAssetDataBytes = 64 << 10 // Default: 64 KiB
+	}
+
+	// Request body size limit (env var overrides config file)
+	if v := os.Getenv("MAX_BODY_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxBodyBytes = n
+		}
+	}
+	if cfg.MaxBodyBytes < 0 {
+		return nil, fmt.Errorf("max_body_bytes must not be negative")
+	}
+	if cfg.MaxBodyBytes == 0 {
+		cfg.MaxBodyBytes = 1 << 20 // Default: 1 MiB
+	}
+	if cfg.MaxBodyBytes < cfg.MaxAssetDataBytes {
+		return nil, fmt.Errorf("max_body_bytes (%d) must be at least max_asset_data_bytes (%d)",
+			cfg.MaxBodyBytes, cfg.MaxAssetDataBytes)
+	}
+
+	// List cache (env var overrides config file)
+	if v := os.Getenv("LIST_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ListCacheSize = n
+		}
+	}
+	if cfg.ListCacheSize < 0 {
+		return nil, fmt.Errorf("list_cache_size must not be negative")
+	}
+
+	// Write queue (env vars override config file)
+	if v := os.Getenv("WRITE_QUEUE_PATH"); v != "" {
+		cfg.WriteQueuePath = v
+	}
+	if v := os.Getenv("WRITE_QUEUE_CAPACITY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.WriteQueueCapacity = n
+		}
+	}
+	if v := os.Getenv("WRITE_QUEUE_FLUSH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.WriteQueueFlushInterval = d
+		}
+	}
+	if cfg.WriteQueuePath != "" {
+		if cfg.WriteQueueCapacity <= 0 {
+			cfg.WriteQueueCapacity = 1000 // Default capacity
+		}
+		if cfg.WriteQueueFlushInterval <= 0 {
+			cfg.WriteQueueFlushInterval = 5 * time.Second // Default flush interval
+		}
+	}
+
+	// Exports (env vars override config file)
+	if v := os.Getenv("EXPORT_DIR"); v != "" {
+		cfg.ExportDir = v
+	}
+	if v := os.Getenv("EXPORT_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ExportWorkers = n
+		}
+	}
+	if v := os.Getenv("EXPORT_QUEUE_CAPACITY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ExportQueueCapacity = n
+		}
+	}
+	if v := os.Getenv("EXPORT_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.ExportTTL = d
+		}
+	}
+	if cfg.ExportDir == "" {
+		cfg.ExportDir = filepath.Join(os.TempDir(), "favourites-exports") // Default directory
+	}
+	if cfg.ExportWorkers <= 0 {
+		cfg.ExportWorkers = 2 // Default workers
+	}
+	if cfg.ExportQueueCapacity <= 0 {
+		cfg.ExportQueueCapacity = 100 // Default capacity
+	}
+	if cfg.ExportTTL <= 0 {
+		cfg.ExportTTL = time.Hour // Default retention
+	}
+
+	// Soft-delete purge (env vars override config file)
+	if v := os.Getenv("SOFT_DELETE_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.SoftDeleteRetention = d
+		}
+	}
+	if v := os.Getenv("SOFT_DELETE_PURGE_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.SoftDeletePurgeInterval = d
+		}
+	}
+	if cfg.SoftDeleteRetention <= 0 {
+		cfg.SoftDeleteRetention = 30 * 24 * time.Hour // Default retention
+	}
+	if cfg.SoftDeletePurgeInterval <= 0 {
+		cfg.SoftDeletePurgeInterval = time.Hour // Default purge interval
+	}
+
+	// Event outbox (env vars override config file)
+	if v := os.Getenv("EVENT_OUTBOX"); v != "" {
+		cfg.EventOutbox = v == "true"
+	}
+	if v := os.Getenv("OUTBOX_RELAY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.OutboxRelayInterval = d
+		}
+	}
+	if cfg.OutboxRelayInterval <= 0 {
+		cfg.OutboxRelayInterval = 5 * time.Second // Default relay interval
+	}
+	if cfg.EventOutbox && cfg.DBDriver != string(database.DriverPostgres) {
+		return nil, fmt.Errorf("event_outbox needs db_driver %q", database.DriverPostgres)
+	}
+
+	// CORS (env vars override config file, lists are comma-separated)
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		cfg.CORSAllowedOrigins = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOWED_METHODS"); v != "" {
+		cfg.CORSAllowedMethods = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOWED_HEADERS"); v != "" {
+		cfg.CORSAllowedHeaders = splitList(v)
+	}
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.CORSMaxAge = d
+		}
+	}
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		cfg.CORSAllowCredentials = v == "true"
+	}
+	if len(cfg.CORSAllowedMethods) == 0 {
+		cfg.CORSAllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"} // Default: every method the API serves
+	}
+	if len(cfg.CORSAllowedHeaders) == 0 {
+		cfg.CORSAllowedHeaders = []string{"Authorization", "Accept", "Content-Type", "Prefer", "X-Timezone", "X-API-Key", "X-On-Behalf-Of", "X-CSRF-Token", logging.RequestIDHeader, logging.CorrelationIDHeader} // Default: every header the API reads
+	}
+	if cfg.CORSMaxAge <= 0 {
+		cfg.CORSMaxAge = 10 * time.Minute // Default preflight cache duration
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		return nil, fmt.Errorf("cors_allowed_origins: \"*\" cannot be combined with cors_allow_credentials")
+	}
+
+	// API versioning (env var overrides config file)
+	if v := os.Getenv("API_V1_SUNSET"); v != "" {
+		sunset, err := parseDate(v)
+		if err != nil {
+			return nil, fmt.Errorf("API_V1_SUNSET: %w", err)
+		}
+		cfg.APIV1Sunset = sunset
+	}
+
+	// Apply rate limiting defaults if partially configured
+	if (cfg.RateLimitRequests > 0 || cfg.TenantRateLimitRequests > 0) && cfg.RateLimitWindow == 0 {
+		cfg.RateLimitWindow = time.Minute // Default window: 1 minute
+	}
+	if cfg.ServiceRateLimitRequests <= 0 {
+		cfg.ServiceRateLimitRequests = cfg.RateLimitRequests * serviceRateMultiplier
+	}
+	for i := range cfg.RateLimitPolicies {
+		p := &cfg.RateLimitPolicies[i]
+		p.Method = strings.ToUpper(p.Method)
+		if p.Method != "" && !slices.Contains(rateLimitMethods, p.Method) {
+			return nil, fmt.Errorf("rate_limit_policies[%d]: unsupported method %q", i, p.Method)
+		}
+		if p.Path != "" && !strings.HasPrefix(p.Path, "/") {
+			return nil, fmt.Errorf("rate_limit_policies[%d]: path %q must start with /", i, p.Path)
+		}
+		if p.Requests <= 0 || p.Window < 0 {
+			return nil, fmt.Errorf("rate_limit_policies[%d]: requests must be positive and window not negative", i)
+		}
+		if p.Window == 0 {
+			p.Window = cmp.Or(cfg.RateLimitWindow, time.Minute)
+		}
+		if p.ServiceRequests <= 0 {
+			p.ServiceRequests = p.Requests * serviceRateMultiplier
+		}
+	}
+
+	return cfg, nil
+}
+
+// LoadFile reads only the YAML config file at path, without environment
+// overrides or required settings. Tools that need part of the configuration
+// (e.g. the spec generator) use it.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// parseDate parses an RFC 3339 timestamp or a plain YYYY-MM-DD date (midnight UTC).
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD or RFC 3339)", v)
+	}
+	return t, nil
+}
+
+// secretEnv returns the value of the env var name or, when name_FILE is set
+// instead, the contents of the file it names without trailing newlines, the
+// way Docker and Kubernetes mount secrets.
+func secretEnv(name string) (string, error) {
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return os.Getenv(name), nil
+	}
+	if os.Getenv(name) != "" {
+		return "", fmt.Errorf("%s and %s_FILE must not both be set", name, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// splitList splits a comma-separated env var value, dropping empty entries.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePairs parses the value of the env var name, of the form
+// "key=value,key=value"; form names the expected entry in error messages.
+func parsePairs(name, form, v string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, item := range splitList(v) {
+		k, value, ok := strings.Cut(item, "=")
+		k, value = strings.TrimSpace(k), strings.TrimSpace(value)
+		if !ok || k == "" || value == "" {
+			return nil, fmt.Errorf("%s: invalid entry (expected %s)", name, form)
+		}
+		pairs[k] = value
+	}
+	return pairs, nil
+}
+
+// parseLimits parses the value of the env var name, of the form
+// "key=limit,key=limit"; key names what the keys are in error messages.
+func parseLimits(name, key, v string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range splitList(v) {
+		k, limit, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid entry %q (expected %s=limit)", name, item, key)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid limit for %q: %w", name, k, err)
+		}
+		limits[strings.TrimSpace(k)] = n
+	}
+	return limits, nil
+}
+
+// rateLimitMethods are the methods the API's routes use.
+var rateLimitMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// parseRateLimitPolicies parses the RATE_LIMIT_POLICIES form: comma-separated
+// "METHOD[ PATH]=requests" entries (or "PATH=requests" for any method), using
+// rate_limit_window.
+func parseRateLimitPolicies(v string) ([]RateLimitPolicy, error) {
+	var policies []RateLimitPolicy
+	for _, item := range splitList(v) {
+		route, limit, ok := strings.Cut(item, "=")
+		fields := strings.Fields(route)
+		if !ok || len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("RATE_LIMIT_POLICIES: invalid entry %q (expected METHOD PATH=requests)", item)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil {
+			return nil, fmt.Errorf("RATE_LIMIT_POLICIES: invalid limit for %q: %w", route, err)
+		}
+		pattern := parseRoutePattern(fields)
+		policies = append(policies, RateLimitPolicy{Method: pattern.Method, Path: pattern.Path, Requests: n})
+	}
+	return policies, nil
+}
+
+// parseRoutePattern parses the "METHOD[ PATH]" or "PATH" form of a route
+// pattern, split into one or two fields.
+func parseRoutePattern(fields []string) RoutePattern {
+	switch {
+	case len(fields) == 2:
+		return RoutePattern{Method: fields[0], Path: fields[1]}
+	case strings.HasPrefix(fields[0], "/"):
+		return RoutePattern{Path: fields[0]}
+	default:
+		return RoutePattern{Method: fields[0]}
+	}
+}
+
+// parseRoutePatterns parses the comma-separated "METHOD[ PATH]" or "PATH"
+// entries of the env var env.
+func parseRoutePatterns(env, v string) ([]RoutePattern, error) {
+	var patterns []RoutePattern
+	for _, item := range splitList(v) {
+		fields := strings.Fields(item)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s: invalid entry %q (expected METHOD PATH)", env, item)
+		}
+		patterns = append(patterns, parseRoutePattern(fields))
+	}
+	return patterns, nil
+}
+
+// isKnownAssetType reports whether t names one of the supported asset types.
+func isKnownAssetType(t string) bool {
+	_, err := assets.Lookup(models.AssetType(t))
+	return err == nil
+}
+
+// PostgresConnString returns a PostgreSQL connection string.
+func (c *Config) PostgresConnString() string {
+	return c.PostgresConnStringWithPassword(c.DBPassword)
+}
+
+// PostgresConnStringWithPassword returns a PostgreSQL connection string with
+// password in place of DBPassword, such as one rotated since Load.
+func (c *Config) PostgresConnStringWithPassword(password string) string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		c.DBHost, c.DBPort, c.DBUser, password, c.DBName,
+	)
+}
+
+// ReplicaConnStringWithPassword returns the connection string of the read
+// replica host, one of DBReplicaHosts, with password.
+func (c *Config) ReplicaConnStringWithPassword(host, password string) string {
+	port := c.DBPort
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+		host, port, c.DBUser, password, c.DBName,
+	)
+}
+
+// loadSecrets builds the configured secrets provider and fetches the JWT
+// secret and database password from it.
+func loadSecrets(cfg *Config) error {
+	for env, field := range map[string]*string{
+		"SECRETS_PROVIDER":           &cfg.SecretsProvider,
+		"JWT_SECRET_REF":             &cfg.JWTSecretRef,
+		"POSTGRES_PASSWORD_REF":      &cfg.DBPasswordRef,
+		"COLUMN_ENCRYPTION_KEYS_REF": &cfg.ColumnEncryptionKeysRef,
+		"SECRETS_DIR":                &cfg.SecretsDir,
+		"VAULT_ADDR":                 &cfg.VaultAddr,
+		"VAULT_MOUNT":                &cfg.VaultMount,
+		"AWS_REGION":                 &cfg.AWSRegion,
+	} {
+		if v := os.Getenv(env); v != "" {
+			*field = v
+		}
+	}
+	if v := os.Getenv("SECRETS_REFRESH"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("SECRETS_REFRESH must be a duration, got %q", v)
+		}
+		cfg.SecretsRefresh = d
+	}
+	if cfg.SecretsRefresh == 0 {
+		cfg.SecretsRefresh = defaultSecretsRefresh
+	}
+	if cfg.SecretsRefresh < 0 {
+		return fmt.Errorf("secrets_refresh must be positive, got %s", cfg.SecretsRefresh)
+	}
+
+	var err error
+	switch cfg.SecretsProvider {
+	case "", "env":
+		return nil
+	case "file":
+		if cfg.SecretsDir == "" {
+			cfg.SecretsDir = defaultSecretsDir
+		}
+		cfg.Secrets = secrets.FileProvider{Dir: cfg.SecretsDir}
+	case "vault":
+		cfg.Secrets, err = secrets.NewVaultProvider(cfg.VaultAddr, cfg.VaultToken, cfg.VaultMount)
+	case "aws":
+		cfg.Secrets, err = secrets.NewAWSProvider(cfg.AWSRegion, secrets.AWSCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		})
+	default:
+		return fmt.Errorf("secrets_provider must be env, file, vault or aws, got %q", cfg.SecretsProvider)
+	}
+	if err != nil {
+		return fmt.Errorf("secrets_provider %s: %w", cfg.SecretsProvider, err)
+	}
+	if cfg.JWTSecretRef == "" && cfg.DBPasswordRef == "" && cfg.ColumnEncryptionKeysRef == "" {
+		return fmt.Errorf("secrets_provider %s needs jwt_secret_ref, postgres_password_ref or column_encryption_keys_ref", cfg.SecretsProvider)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsFetchTimeout)
+	defer cancel()
+	for _, s := range []struct {
+		ref   string
+		value *string
+	}{
+		{cfg.JWTSecretRef, &cfg.JWTSecret},
+		{cfg.DBPasswordRef, &cfg.DBPassword},
+		{cfg.ColumnEncryptionKeysRef, &cfg.ColumnEncryptionKeys},
+	} {
+		if s.ref == "" {
+			continue
+		}
+		value, err := cfg.Secrets.Fetch(ctx, s.ref)
+		if err != nil {
+			return fmt.Errorf("fetching secret %s: %w", s.ref, err)
+		}
+		if value == "" {
+			return fmt.Errorf("secret %s is empty", s.ref)
+		}
+		*s.value = value
+	}
+	return nil
+}
+
+// validateTLS checks the TLS settings and applies their defaults.
+func validateTLS(cfg *Config) error {
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together")
+	}
+	if cfg.ClientCertPrincipal == "" {
+		cfg.ClientCertPrincipal = auth.PrincipalCN
+	}
+	principals := []string{auth.PrincipalCN, auth.PrincipalSANDNS, auth.PrincipalSANURI, auth.PrincipalSANEmail}
+	if !slices.Contains(principals, cfg.ClientCertPrincipal) {
+		return fmt.Errorf("client_cert_principal must be one of %s, got %q", strings.Join(principals, ", "), cfg.ClientCertPrincipal)
+	}
+	for _, l := range []struct {
+		field string
+		mode  *auth.ClientAuth
+	}{{"api_client_auth", &cfg.APIClientAuth}, {"health_client_auth", &cfg.HealthClientAuth}} {
+		if *l.mode == "" {
+			*l.mode = auth.ClientAuthNone
+		}
+		switch *l.mode {
+		case auth.ClientAuthNone, auth.ClientAuthOptional, auth.ClientAuthRequire:
+		default:
+			return fmt.Errorf("%s must be %q, %q or %q, got %q", l.field, auth.ClientAuthNone, auth.ClientAuthOptional, auth.ClientAuthRequire, *l.mode)
+		}
+		if *l.mode != auth.ClientAuthNone && (cfg.TLSCertFile == "" || cfg.ClientCAFile == "") {
+			return fmt.Errorf("%s %q requires tls_cert_file, tls_key_file and client_ca_file", l.field, *l.mode)
+		}
+	}
+	return nil
+}
+
+// APITLSConfig returns the TLS configuration of the API listener.
+func (c *Config) APITLSConfig() auth.TLSConfig {
+	return auth.TLSConfig{CertFile: c.TLSCertFile, KeyFile: c.TLSKeyFile, ClientCAFile: c.ClientCAFile, ClientAuth: c.APIClientAuth}
+}
+
+// HealthTLSConfig returns the TLS configuration of the health check listener.
+func (c *Config) HealthTLSConfig() auth.TLSConfig {
+	return auth.TLSConfig{CertFile: c.TLSCertFile, KeyFile: c.TLSKeyFile, ClientCAFile: c.ClientCAFile, ClientAuth: c.HealthClientAuth}
+}
+
+// APIAddr returns the listen address for the API server.
+func (c *Config) APIAddr() string {
+	return ":" + c.APIPort
+}
 
 // HealthAddr returns the listen address for the health check server.
 func (c *Config) HealthAddr() string {
 	return ":" + c.HealthPort
 }
 
-// AuthConfig returns the JWT authentication configuration.
+// AuthConfig returns the JWT authentication configuration. JWKS is left nil:
+// the key set caches keys and is refreshed in the background, so the caller
+// creates it once from JWKSURL.
 func (c *Config) AuthConfig() auth.AuthConfig {
 	return auth.AuthConfig{
 		Secret:              c.JWTSecret,
+		Secrets:             c.JWTSecrets,
+		RSAKeys:             c.JWTPublicKeys,
 		AllowUnsignedTokens: c.AllowUnsignedTokens,
+		Issuers:             c.JWTIssuers,
+		Audiences:           c.JWTAudiences,
+		Leeway:              c.JWTLeeway,
+		RequireScopes:       c.RequireScopes,
+		RolesClaim:          c.RolesClaim,
+		TenantClaim:         c.TenantClaim,
+		ServiceClaim:        c.ServiceClaim,
+		SessionCookie:       c.SessionCookie,
+		AdminUsers:          c.AdminUsers,
+		SignedURLSecret:     c.SignedURLSecret,
+		SigningKeys:         c.RequestSigningKeys,
+		ServiceTenants:      c.ServiceTenants,
+		SignatureWindow:     c.RequestSignatureWindow,
+		Methods:             c.AuthMethods,
+	}
+}
+
+// FailureThrottle returns the throttle for clients repeatedly failing
+// authentication, or nil when AuthFailureThreshold disables it. Each call
+// returns a new throttle with its own failure counts.
+func (c *Config) FailureThrottle() *auth.FailureThrottle {
+	if c.AuthFailureThreshold <= 0 {
+		return nil
+	}
+	proxies, _ := parseTrustedProxies(c.TrustedProxies) // validated by Load
+	return &auth.FailureThrottle{
+		Threshold:      c.AuthFailureThreshold,
+		Window:         c.AuthFailureWindow,
+		MaxDelay:       c.AuthFailureMaxDelay,
+		BanAfter:       c.AuthFailureBanAfter,
+		BanFor:         c.AuthFailureBanDuration,
+		ClientIPHeader: c.ClientIPHeader,
+		TrustedProxies: proxies,
+	}
+}
+
+// parseTrustedProxies parses trusted_proxies entries, CIDRs or single IPs.
+func parseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_proxies: %q is neither an IP nor a CIDR", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// QuotaConfig returns the per-asset-type favourites quotas.
+func (c *Config) QuotaConfig() handlers.QuotaConfig {
+	perType := make(map[models.AssetType]int, len(c.FavouriteQuotas))
+	for assetType, limit := range c.FavouriteQuotas {
+		perType[models.AssetType(assetType)] = limit
+	}
+	return handlers.QuotaConfig{PerType: perType}
+}
+
+// AssetTypeAliasConfig returns the asset type aliases.
+func (c *Config) AssetTypeAliasConfig() assets.Aliases {
+	aliases := make(assets.Aliases, len(c.AssetTypeAliases))
+	for alias, target := range c.AssetTypeAliases {
+		aliases[models.AssetType(alias)] = models.AssetType(target)
+	}
+	return aliases
+}
+
+// AudienceEnumConfig returns the allowed audience values: the defaults with the
+// configured overrides and extensions applied.
+func (c *Config) AudienceEnumConfig() (assets.AudienceEnums, error) {
+	enums := assets.DefaultAudienceEnums()
+	fields := map[string]*[]string{
+		"gender":                   &enums.Gender,
+		"age_groups":               &enums.AgeGroups,
+		"social_media_hours_daily": &enums.SocialMediaHours,
+	}
+	for field, override := range c.AudienceEnums {
+		values, ok := fields[field]
+		if !ok {
+			return assets.AudienceEnums{}, fmt.Errorf("audience_enums: unknown field %q", field)
+		}
+		if len(override.Values) > 0 {
+			*values = override.Values
+		}
+		*values = append(slices.Clone(*values), override.Extend...)
+		for i, v := range *values {
+			if strings.TrimSpace(v) == "" {
+				return assets.AudienceEnums{}, fmt.Errorf("audience_enums: %s contains an empty value", field)
+			}
+			if slices.Contains((*values)[:i], v) {
+				return assets.AudienceEnums{}, fmt.Errorf("audience_enums: %s lists %q twice", field, v)
+			}
+		}
+	}
+	return enums, nil
+}
+
+// TextLimitConfig returns the maximum text field lengths: the defaults with the
+// configured overrides applied.
+func (c *Config) TextLimitConfig() (assets.TextLimits, error) {
+	limits := assets.DefaultTextLimits()
+	fields := map[string]*int{
+		"description": &limits.Description,
+		"title":       &limits.Title,
+		"text":        &limits.Text,
+	}
+	for field, limit := range c.MaxTextLengths {
+		target, ok := fields[field]
+		if !ok {
+			return assets.TextLimits{}, fmt.Errorf("max_text_lengths: unknown field %q", field)
+		}
+		if limit <= 0 {
+			return assets.TextLimits{}, fmt.Errorf("max_text_lengths: limit for %q must be positive", field)
+		}
+		*target = limit
+	}
+	return limits, nil
+}
+
+// AssetRules loads the JSON Schema rules from AssetRulesDir; it returns no
+// rules when the directory is not set.
+func (c *Config) AssetRules() (map[models.AssetType]*assets.Rule, error) {
+	if c.AssetRulesDir == "" {
+		return nil, nil
+	}
+	rules, err := assets.LoadRules(c.AssetRulesDir)
+	if err != nil {
+		return nil, fmt.Errorf("asset_rules_dir: %w", err)
+	}
+	return rules, nil
+}
+
+// LogRedaction returns the redaction of log entries.
+func (c *Config) LogRedaction() logging.Redaction {
+	return logging.Redaction{
+		AllowedFields: c.LogAllowedFields,
+		HashUserIDs:   c.LogHashUserIDs,
+		HashKey:       c.LogHashKey,
+	}
+}
+
+// MinLogLevel returns LogLevel as parsed, having been validated by Load.
+func (c *Config) MinLogLevel() slog.Level {
+	level, _ := logging.ParseLevel(c.LogLevel)
+	return level
+}
+
+// ColumnCipher builds the cipher of favourite descriptions and asset data
+// from ColumnEncryptionKeys; it returns nil when no keys are set.
+func (c *Config) ColumnCipher() (*database.ColumnCipher, error) {
+	if c.ColumnEncryptionKeys == "" {
+		return nil, nil
+	}
+	pairs, err := parsePairs("column encryption keys", "kid=base64 key", c.ColumnEncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string][]byte, len(pairs))
+	for id, encoded := range pairs {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("column encryption key %s is not valid base64", id)
+		}
+		keys[id] = key
+		if len(pairs) == 1 && c.ColumnEncryptionKeyID == "" {
+			c.ColumnEncryptionKeyID = id
+		}
+	}
+	if c.ColumnEncryptionKeyID == "" {
+		return nil, fmt.Errorf("column_encryption_key_id must name one of the %d column encryption keys", len(keys))
+	}
+	return database.NewColumnCipher(keys, c.ColumnEncryptionKeyID)
+}
+
+// PprofConfig holds the settings of the profiling endpoints.
+type PprofConfig struct {
+	Enabled  bool
+	User     string // Empty serves the profiles without authentication
+	Password string
+}
+
+// PprofConfig returns the profiling endpoints configuration.
+func (c *Config) PprofConfig() PprofConfig {
+	return PprofConfig{Enabled: c.Pprof, User: c.PprofUser, Password: c.PprofPassword}
+}
+
+// BodyLogConfig selects the routes whose bodies are logged at debug level.
+type BodyLogConfig struct {
+	Routes   []RoutePattern // Empty logs no bodies
+	MaxBytes int            // Bytes logged of each body
+}
+
+// BodyLogConfig returns the body logging configuration.
+func (c *Config) BodyLogConfig() BodyLogConfig {
+	return BodyLogConfig{Routes: c.LogBodyRoutes, MaxBytes: c.LogBodyMaxBytes}
+}
+
+// CORSConfig holds the cross-origin resource sharing settings.
+type CORSConfig struct {
+	AllowedOrigins   []string // Empty disables CORS; "*" allows any origin
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	MaxAge           time.Duration // How long browsers may cache a preflight response
+	AllowCredentials bool
+}
+
+// CORSConfig returns the CORS configuration.
+func (c *Config) CORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins:   c.CORSAllowedOrigins,
+		AllowedMethods:   c.CORSAllowedMethods,
+		AllowedHeaders:   c.CORSAllowedHeaders,
+		MaxAge:           c.CORSMaxAge,
+		AllowCredentials: c.CORSAllowCredentials,
 	}
 }
 
 // RateLimitConfig holds rate limiting settings.
 type RateLimitConfig struct {
-	Requests int           // Max requests per window (0 = disabled)
-	Window   time.Duration // Time window for rate limiting
+	Requests        int               // Max requests per window (0 = disabled)
+	ServiceRequests int               // Max requests per window per service principal
+	Window          time.Duration     // Time window for rate limiting
+	Policies        []RateLimitPolicy // Per-route limits replacing Requests where they match
+	TenantRequests  int               // Max requests per window per tenant (0 = disabled)
 }
 
 // RateLimitConfig returns the rate limiting configuration.
 func (c *Config) RateLimitConfig() RateLimitConfig {
 	return RateLimitConfig{
-		Requests: c.RateLimitRequests,
-		Window:   c.RateLimitWindow,
+		Requests:        c.RateLimitRequests,
+		ServiceRequests: c.ServiceRateLimitRequests,
+		Window:          c.RateLimitWindow,
+		Policies:        c.RateLimitPolicies,
+		TenantRequests:  c.TenantRateLimitRequests,
 	}
 }
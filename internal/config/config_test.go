@@ -1,10 +1,26 @@
 package config
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"log/slog"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
 )
 
 // setDBEnv sets all required database environment variables for testing.
@@ -221,3 +237,2422 @@ func writeTempConfig(t *testing.T, content string) string {
 	}
 	return path
 }
+
+func TestLoad_AdminUsers(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+admin_users: ["alice"]
+`)
+
+	tests := []struct {
+		name string
+		env  string
+		want []string
+	}{
+		{name: "from config file", env: "", want: []string{"alice"}},
+		{name: "env overrides file", env: "bob, carol,", want: []string{"bob", "carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ADMIN_USERS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(cfg.AdminUsers, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected AdminUsers=%v, got %v", tt.want, cfg.AdminUsers)
+			}
+			if !cfg.AuthConfig().IsAdmin(tt.want[0]) {
+				t.Errorf("expected %s to be admin in AuthConfig", tt.want[0])
+			}
+		})
+	}
+}
+
+func TestLoad_FavouriteQuotas(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+favourite_quotas:
+  audience: 50
+  insight: 500
+`)
+
+	tests := []struct {
+		name      string
+		env       string
+		want      map[models.AssetType]int
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "from config file", want: map[models.AssetType]int{"audience": 50, "insight": 500}},
+		{name: "env overrides file", env: "chart=10, audience=5", want: map[models.AssetType]int{"chart": 10, "audience": 5}},
+		{name: "malformed env entry", env: "chart", wantErr: true, errSubstr: "expected type=limit"},
+		{name: "non-numeric limit", env: "chart=ten", wantErr: true, errSubstr: "invalid limit"},
+		{name: "unknown asset type", env: "widget=3", wantErr: true, errSubstr: "unknown asset type"},
+		{name: "negative limit", env: "chart=-1", wantErr: true, errSubstr: "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("FAVOURITE_QUOTAS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			quotas := cfg.QuotaConfig()
+			if len(quotas.PerType) != len(tt.want) {
+				t.Fatalf("expected quotas %v, got %v", tt.want, quotas.PerType)
+			}
+			for assetType, limit := range tt.want {
+				if quotas.Limit(assetType) != limit {
+					t.Errorf("expected %s limit %d, got %d", assetType, limit, quotas.Limit(assetType))
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_MaxTextLengths(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+max_text_lengths:
+  description: 1000
+`)
+
+	tests := []struct {
+		name      string
+		env       string
+		want      assets.TextLimits
+		wantErr   bool
+		errSubstr string
+	}{
+		{name: "from config file", want: assets.TextLimits{Description: 1000, Title: 255, Text: 255}},
+		{name: "env overrides file", env: "title=120, text=2000", want: assets.TextLimits{Description: 255, Title: 120, Text: 2000}},
+		{name: "malformed env entry", env: "title", wantErr: true, errSubstr: "expected field=limit"},
+		{name: "unknown field", env: "name=10", wantErr: true, errSubstr: `max_text_lengths: unknown field "name"`},
+		{name: "zero limit", env: "text=0", wantErr: true, errSubstr: "must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("MAX_TEXT_LENGTHS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errSubstr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.errSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := cfg.TextLimitConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected limits %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_JWKS(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name        string
+		url         string
+		refresh     string
+		wantRefresh time.Duration
+		wantErr     bool
+	}{
+		{name: "disabled by default", wantRefresh: 15 * time.Minute},
+		{name: "url and refresh", url: "https://idp.example.com/.well-known/jwks.json", refresh: "5m", wantRefresh: 5 * time.Minute},
+		{name: "relative url", url: "/jwks.json", wantErr: true},
+		{name: "invalid refresh", refresh: "soon", wantErr: true},
+		{name: "non-positive refresh", refresh: "0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("JWKS_URL", tt.url)
+			t.Setenv("JWKS_REFRESH", tt.refresh)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.JWKSURL != tt.url || cfg.JWKSRefresh != tt.wantRefresh {
+				t.Errorf("expected JWKS %q every %s, got %q every %s", tt.url, tt.wantRefresh, cfg.JWKSURL, cfg.JWKSRefresh)
+			}
+		})
+	}
+}
+
+func TestLoad_JWTClaims(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+jwt_issuers: ["https://idp.example.com"]
+jwt_audiences: ["favourites"]
+`)
+
+	tests := []struct {
+		name          string
+		issuers       string
+		audiences     string
+		wantIssuers   []string
+		wantAudiences []string
+	}{
+		{name: "from config file", wantIssuers: []string{"https://idp.example.com"}, wantAudiences: []string{"favourites"}},
+		{name: "env override", issuers: "https://a, https://b", audiences: "api,favourites", wantIssuers: []string{"https://a", "https://b"}, wantAudiences: []string{"api", "favourites"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("JWT_ISSUERS", tt.issuers)
+			t.Setenv("JWT_AUDIENCES", tt.audiences)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			authCfg := cfg.AuthConfig()
+			if !reflect.DeepEqual(authCfg.Issuers, tt.wantIssuers) || !reflect.DeepEqual(authCfg.Audiences, tt.wantAudiences) {
+				t.Errorf("expected issuers %v and audiences %v, got %v and %v", tt.wantIssuers, tt.wantAudiences, authCfg.Issuers, authCfg.Audiences)
+			}
+		})
+	}
+}
+
+func TestLoad_AuthMethods(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+auth_methods: [mtls, jwt]
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "from config file", want: []string{"mtls", "jwt"}},
+		{name: "env override", env: "jwt, api_key", want: []string{"jwt", "api_key"}},
+		{name: "unknown method", env: "jwt,basic", wantErr: true},
+		{name: "duplicate method", env: "jwt,jwt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("AUTH_METHODS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.AuthConfig().Methods; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected methods %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_JWTLeeway(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+jwt_leeway: 30s
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "from config file", want: 30 * time.Second},
+		{name: "env override", env: "10s", want: 10 * time.Second},
+		{name: "disabled by env", env: "0s", want: 0},
+		{name: "invalid duration", env: "soon", wantErr: true},
+		{name: "negative", env: "-1s", wantErr: true},
+		{name: "too large", env: "1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("JWT_LEEWAY", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.AuthConfig().Leeway; got != tt.want {
+				t.Errorf("expected leeway %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_SigningKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "rsa1.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+jwt_public_keys:
+  rsa1: `+keyFile+`
+`)
+
+	tests := []struct {
+		name        string
+		secrets     string
+		publicKeys  string
+		wantSecrets map[string]string
+		wantKeys    []string
+		wantErr     bool
+	}{
+		{name: "public keys from config file", wantKeys: []string{"rsa1"}},
+		{name: "secrets by kid", secrets: "2026-01=old, 2026-07=new", wantSecrets: map[string]string{"2026-01": "old", "2026-07": "new"}, wantKeys: []string{"rsa1"}},
+		{name: "env overrides public keys", publicKeys: "rsa2=" + keyFile, wantKeys: []string{"rsa2"}},
+		{name: "secret without kid", secrets: "=new", wantErr: true},
+		{name: "kid without secret", secrets: "2026-07", wantErr: true},
+		{name: "missing key file", publicKeys: "rsa2=/nonexistent.pem", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("JWT_SECRETS", tt.secrets)
+			t.Setenv("JWT_PUBLIC_KEYS", tt.publicKeys)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			authCfg := cfg.AuthConfig()
+			if len(authCfg.Secrets) != len(tt.wantSecrets) || (len(tt.wantSecrets) > 0 && !reflect.DeepEqual(authCfg.Secrets, tt.wantSecrets)) {
+				t.Errorf("expected secrets %v, got %v", tt.wantSecrets, authCfg.Secrets)
+			}
+			if len(authCfg.RSAKeys) != len(tt.wantKeys) {
+				t.Fatalf("expected keys %v, got %d keys", tt.wantKeys, len(authCfg.RSAKeys))
+			}
+			for _, kid := range tt.wantKeys {
+				if !authCfg.RSAKeys[kid].Equal(&key.PublicKey) {
+					t.Errorf("expected key %q to be loaded from %s", kid, keyFile)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, value := range map[string]string{"db_password": "pg-from-file\n", "jwt_secret": "jwt-from-file", "signing_keys": "partner1=s3cret\r\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0o600); err != nil {
+			t.Fatalf("writing secret: %v", err)
+		}
+	}
+	path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n")
+
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantDBPassword string
+		wantJWTSecret  string
+		wantErr        string
+	}{
+		{name: "env vars", env: map[string]string{"JWT_SECRET": "jwt-from-env"}, wantDBPassword: "testpass", wantJWTSecret: "jwt-from-env"},
+		{
+			name: "files",
+			env: map[string]string{
+				"POSTGRES_PASSWORD": "", "POSTGRES_PASSWORD_FILE": filepath.Join(dir, "db_password"),
+				"JWT_SECRET_FILE":           filepath.Join(dir, "jwt_secret"),
+				"REQUEST_SIGNING_KEYS_FILE": filepath.Join(dir, "signing_keys"),
+			},
+			wantDBPassword: "pg-from-file",
+			wantJWTSecret:  "jwt-from-file",
+		},
+		{name: "both set", env: map[string]string{"POSTGRES_PASSWORD_FILE": filepath.Join(dir, "db_password")}, wantErr: "POSTGRES_PASSWORD and POSTGRES_PASSWORD_FILE"},
+		{name: "missing file", env: map[string]string{"JWT_SECRET_FILE": filepath.Join(dir, "missing")}, wantErr: "JWT_SECRET_FILE"},
+		{name: "empty file", env: map[string]string{"POSTGRES_PASSWORD": "", "POSTGRES_PASSWORD_FILE": filepath.Join(dir, "empty")}, wantErr: "POSTGRES_PASSWORD_FILE"},
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty"), nil, 0o600); err != nil {
+		t.Fatalf("writing secret: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			setDBEnv(t)
+			for _, env := range []string{"JWT_SECRET", "JWT_SECRET_FILE", "POSTGRES_PASSWORD_FILE", "REQUEST_SIGNING_KEYS", "REQUEST_SIGNING_KEYS_FILE"} {
+				t.Setenv(env, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.DBPassword != tt.wantDBPassword || cfg.JWTSecret != tt.wantJWTSecret {
+				t.Errorf("expected DB password %q and JWT secret %q, got %q and %q", tt.wantDBPassword, tt.wantJWTSecret, cfg.DBPassword, cfg.JWTSecret)
+			}
+			if _, ok := tt.env["REQUEST_SIGNING_KEYS_FILE"]; ok && cfg.RequestSigningKeys["partner1"] != "s3cret" {
+				t.Errorf("expected signing keys from file, got %v", cfg.RequestSigningKeys)
+			}
+		})
+	}
+}
+
+func TestLoad_SecretsProvider(t *testing.T) {
+	dir := t.TempDir()
+	for name, value := range map[string]string{"jwt_secret": "jwt-from-file\n", "db_password": "pg-from-file"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0o600); err != nil {
+			t.Fatalf("writing secret: %v", err)
+		}
+	}
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+jwt_secret_ref: jwt_secret
+postgres_password_ref: db_password
+`)
+
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantJWTSecret  string
+		wantDBPassword string
+		wantRefresh    time.Duration
+		wantErr        string
+	}{
+		{name: "env provider by default", wantJWTSecret: "", wantDBPassword: "testpass", wantRefresh: 5 * time.Minute},
+		{name: "file provider", env: map[string]string{"SECRETS_PROVIDER": "file", "SECRETS_DIR": dir, "POSTGRES_PASSWORD": "", "SECRETS_REFRESH": "1m"}, wantJWTSecret: "jwt-from-file", wantDBPassword: "pg-from-file", wantRefresh: time.Minute},
+		{name: "env overrides refs", env: map[string]string{"SECRETS_PROVIDER": "file", "SECRETS_DIR": dir, "JWT_SECRET_REF": "db_password"}, wantJWTSecret: "pg-from-file", wantDBPassword: "pg-from-file", wantRefresh: 5 * time.Minute},
+		{name: "missing secret", env: map[string]string{"SECRETS_PROVIDER": "file", "SECRETS_DIR": dir, "JWT_SECRET_REF": "other"}, wantErr: "fetching secret other"},
+		{name: "unknown provider", env: map[string]string{"SECRETS_PROVIDER": "keychain"}, wantErr: "secrets_provider must be"},
+		{name: "vault without token", env: map[string]string{"SECRETS_PROVIDER": "vault", "VAULT_ADDR": "https://vault:8200"}, wantErr: "vault token is required"},
+		{name: "aws without credentials", env: map[string]string{"SECRETS_PROVIDER": "aws", "AWS_REGION": "eu-west-1"}, wantErr: "aws access key ID"},
+		{name: "negative refresh", env: map[string]string{"SECRETS_REFRESH": "-1m"}, wantErr: "secrets_refresh must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			setDBEnv(t)
+			for _, env := range []string{"SECRETS_PROVIDER", "SECRETS_REFRESH", "SECRETS_DIR", "JWT_SECRET_REF", "POSTGRES_PASSWORD_REF", "VAULT_ADDR", "VAULT_TOKEN", "AWS_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "JWT_SECRET"} {
+				t.Setenv(env, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.JWTSecret != tt.wantJWTSecret || cfg.DBPassword != tt.wantDBPassword {
+				t.Errorf("expected JWT secret %q and DB password %q, got %q and %q", tt.wantJWTSecret, tt.wantDBPassword, cfg.JWTSecret, cfg.DBPassword)
+			}
+			if cfg.SecretsRefresh != tt.wantRefresh {
+				t.Errorf("expected refresh %s, got %s", tt.wantRefresh, cfg.SecretsRefresh)
+			}
+		})
+	}
+}
+
+func TestLoad_ServicePrincipals(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+rate_limit_requests: 100
+service_claim: gty=client-credentials
+`)
+
+	tests := []struct {
+		name         string
+		claim        string
+		serviceLimit string
+		wantClaim    string
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "from config file", wantClaim: "gty=client-credentials", wantRequests: 1000},
+		{name: "env override", claim: "ext.kind=service", serviceLimit: "250", wantClaim: "ext.kind=service", wantRequests: 250},
+		{name: "claim without value", claim: "gty", wantErr: true},
+		{name: "empty value", claim: "gty=", wantErr: true},
+		{name: "empty path segment", claim: "ext..kind=service", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("SERVICE_CLAIM", tt.claim)
+			t.Setenv("SERVICE_RATE_LIMIT_REQUESTS", tt.serviceLimit)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.AuthConfig().ServiceClaim; got != tt.wantClaim {
+				t.Errorf("expected service claim %q, got %q", tt.wantClaim, got)
+			}
+			if got := cfg.RateLimitConfig().ServiceRequests; got != tt.wantRequests {
+				t.Errorf("expected service rate limit %d, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+func TestLoad_RateLimitPolicies(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+rate_limit_requests: 60
+rate_limit_window: 30s
+rate_limit_policies:
+  - method: get
+    requests: 300
+    window: 1m
+  - method: POST
+    path: /favourites*
+    requests: 30
+    service_requests: 90
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    []RateLimitPolicy
+		wantErr bool
+	}{
+		{name: "from config file", want: []RateLimitPolicy{
+			{Method: "GET", Requests: 300, Window: time.Minute, ServiceRequests: 3000},
+			{Method: "POST", Path: "/favourites*", Requests: 30, Window: 30 * time.Second, ServiceRequests: 90},
+		}},
+		{name: "env override", env: "GET=300, delete /favourites/{assetID}=5, /admin/*=10", want: []RateLimitPolicy{
+			{Method: "GET", Requests: 300, Window: 30 * time.Second, ServiceRequests: 3000},
+			{Method: "DELETE", Path: "/favourites/{assetID}", Requests: 5, Window: 30 * time.Second, ServiceRequests: 50},
+			{Path: "/admin/*", Requests: 10, Window: 30 * time.Second, ServiceRequests: 100},
+		}},
+		{name: "missing limit", env: "GET", wantErr: true},
+		{name: "unknown method", env: "FETCH=10", wantErr: true},
+		{name: "relative path", env: "GET favourites=10", wantErr: true},
+		{name: "zero requests", env: "GET=0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("RATE_LIMIT_POLICIES", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.RateLimitConfig().Policies; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected policies %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_FailureThrottle(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+auth_failure_threshold: 5
+auth_failure_ban_after: 20
+auth_failure_ban_duration: 30m
+`)
+
+	tests := []struct {
+		name      string
+		threshold string
+		window    string
+		want      *auth.FailureThrottle
+		wantErr   bool
+	}{
+		{name: "from config file", want: &auth.FailureThrottle{Threshold: 5, BanAfter: 20, BanFor: 30 * time.Minute}},
+		{name: "env override", threshold: "3", window: "5m", want: &auth.FailureThrottle{Threshold: 3, Window: 5 * time.Minute, BanAfter: 20, BanFor: 30 * time.Minute}},
+		{name: "disabled", threshold: "0", want: nil},
+		{name: "invalid threshold", threshold: "many", wantErr: true},
+		{name: "negative window", window: "-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("AUTH_FAILURE_THRESHOLD", tt.threshold)
+			t.Setenv("AUTH_FAILURE_WINDOW", tt.window)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := cfg.FailureThrottle()
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("expected throttle %+v, got %+v", tt.want, got)
+			}
+			if got != nil && (got.Threshold != tt.want.Threshold || got.Window != tt.want.Window || got.BanAfter != tt.want.BanAfter || got.BanFor != tt.want.BanFor) {
+				t.Errorf("expected throttle %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		proxies string
+		want    []netip.Prefix
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "IPs and CIDRs", header: "X-Forwarded-For", proxies: "10.0.0.0/8, 192.0.2.10", want: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.10/32")}},
+		{name: "header without proxies", header: "X-Forwarded-For", wantErr: true},
+		{name: "invalid proxy", header: "X-Real-IP", proxies: "proxy.internal", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\nauth_failure_threshold: 5\n"))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("CLIENT_IP_HEADER", tt.header)
+			t.Setenv("TRUSTED_PROXIES", tt.proxies)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := cfg.FailureThrottle()
+			if got.ClientIPHeader != tt.header || !slices.Equal(got.TrustedProxies, tt.want) {
+				t.Errorf("expected header %q and proxies %v, got %q and %v", tt.header, tt.want, got.ClientIPHeader, got.TrustedProxies)
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicy_Matches(t *testing.T) {
+	tests := []struct {
+		policy RateLimitPolicy
+		method string
+		path   string
+		want   bool
+	}{
+		{policy: RateLimitPolicy{}, method: "GET", path: "/favourites", want: true},
+		{policy: RateLimitPolicy{Method: "GET"}, method: "POST", path: "/favourites", want: false},
+		{policy: RateLimitPolicy{Path: "/favourites"}, method: "GET", path: "/favourites/{assetID}", want: false},
+		{policy: RateLimitPolicy{Path: "/favourites*"}, method: "GET", path: "/favourites/{assetID}", want: true},
+		{policy: RateLimitPolicy{Method: "DELETE", Path: "/favourites/{assetID}"}, method: "DELETE", path: "/favourites/{assetID}", want: true},
+		{policy: RateLimitPolicy{Path: "/admin/*"}, method: "GET", path: "/favourites", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.Matches(tt.method, tt.path); got != tt.want {
+			t.Errorf("%+v.Matches(%s, %s) = %v, want %v", tt.policy, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_RequestSigning(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaml       string
+		keys       string
+		window     string
+		wantKeys   map[string]string
+		wantWindow time.Duration
+		wantErr    bool
+	}{
+		{name: "defaults", wantWindow: 5 * time.Minute},
+		{name: "from env", keys: "partner1=s1, partner2=s2", window: "2m", wantKeys: map[string]string{"partner1": "s1", "partner2": "s2"}, wantWindow: 2 * time.Minute},
+		{name: "window from config file", yaml: "request_signature_window: 30s\n", wantWindow: 30 * time.Second},
+		{name: "key without secret", keys: "partner1", wantErr: true},
+		{name: "negative window", window: "-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml)
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("REQUEST_SIGNING_KEYS", tt.keys)
+			t.Setenv("REQUEST_SIGNATURE_WINDOW", tt.window)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			authCfg := cfg.AuthConfig()
+			if !reflect.DeepEqual(authCfg.SigningKeys, tt.wantKeys) || authCfg.SignatureWindow != tt.wantWindow {
+				t.Errorf("expected keys %v and window %s, got %v and %s", tt.wantKeys, tt.wantWindow, authCfg.SigningKeys, authCfg.SignatureWindow)
+			}
+		})
+	}
+}
+
+func TestLoad_SessionCookie(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+session_cookie: session
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "from config file", want: "session"},
+		{name: "env override", env: "__Host-token", want: "__Host-token"},
+		{name: "invalid name", env: "my session", wantErr: true},
+		{name: "CSRF cookie name", env: "csrf_token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("SESSION_COOKIE", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.AuthConfig().SessionCookie; got != tt.want {
+				t.Errorf("expected session cookie %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_RequireScopes(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		env  string
+		want bool
+	}{
+		{name: "disabled by default", want: false},
+		{name: "from config file", yaml: "require_scopes: true\n", want: true},
+		{name: "env override", yaml: "require_scopes: true\n", env: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml)
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("REQUIRE_SCOPES", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.AuthConfig().RequireScopes != tt.want {
+				t.Errorf("expected RequireScopes %v, got %v", tt.want, cfg.AuthConfig().RequireScopes)
+			}
+		})
+	}
+}
+
+func TestLoad_RolesClaim(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "roles"},
+		{name: "from config file", yaml: "roles_claim: realm_access.roles\n", want: "realm_access.roles"},
+		{name: "env override", yaml: "roles_claim: realm_access.roles\n", env: "groups", want: "groups"},
+		{name: "empty path segment", env: "realm_access..roles", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml)
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ROLES_CLAIM", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.AuthConfig().RolesClaim; got != tt.want {
+				t.Errorf("expected roles claim %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_TenantClaim(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "tenant_id"},
+		{name: "from config file", yaml: "tenant_claim: org.id\n", want: "org.id"},
+		{name: "env override", yaml: "tenant_claim: org.id\n", env: "tid", want: "tid"},
+		{name: "empty path segment", env: "org..id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml)
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("TENANT_CLAIM", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.AuthConfig().TenantClaim; got != tt.want {
+				t.Errorf("expected tenant claim %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_TLS(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantAPI auth.TLSConfig
+		wantErr bool
+	}{
+		{name: "plain HTTP by default", wantAPI: auth.TLSConfig{ClientAuth: auth.ClientAuthNone}},
+		{
+			name:    "client certificates on the API listener",
+			env:     map[string]string{"TLS_CERT_FILE": "server.crt", "TLS_KEY_FILE": "server.key", "CLIENT_CA_FILE": "ca.crt", "API_CLIENT_AUTH": "require"},
+			wantAPI: auth.TLSConfig{CertFile: "server.crt", KeyFile: "server.key", ClientCAFile: "ca.crt", ClientAuth: auth.ClientAuthRequire},
+		},
+		{name: "certificate without key", env: map[string]string{"TLS_CERT_FILE": "server.crt"}, wantErr: true},
+		{name: "client auth without CA", env: map[string]string{"TLS_CERT_FILE": "server.crt", "TLS_KEY_FILE": "server.key", "HEALTH_CLIENT_AUTH": "optional"}, wantErr: true},
+		{name: "unknown client auth mode", env: map[string]string{"API_CLIENT_AUTH": "sometimes"}, wantErr: true},
+		{name: "unknown principal field", env: map[string]string{"CLIENT_CERT_PRINCIPAL": "serial"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			for _, key := range []string{"TLS_CERT_FILE", "TLS_KEY_FILE", "CLIENT_CA_FILE", "API_CLIENT_AUTH", "HEALTH_CLIENT_AUTH", "CLIENT_CERT_PRINCIPAL"} {
+				t.Setenv(key, tt.env[key])
+			}
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.APITLSConfig(); got != tt.wantAPI {
+				t.Errorf("expected API TLS %+v, got %+v", tt.wantAPI, got)
+			}
+			if cfg.HealthTLSConfig().ClientAuth != auth.ClientAuthNone || cfg.ClientCertPrincipal != auth.PrincipalCN {
+				t.Errorf("unexpected defaults: health client auth %q, principal %q", cfg.HealthTLSConfig().ClientAuth, cfg.ClientCertPrincipal)
+			}
+		})
+	}
+}
+
+func TestLoad_Revocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: 24 * time.Hour},
+		{name: "from config file", yaml: "revocation_ttl: 2h\n", want: 2 * time.Hour},
+		{name: "env override", yaml: "revocation_ttl: 2h\n", env: "30m", want: 30 * time.Minute},
+		{name: "invalid", env: "soon", wantErr: true},
+		{name: "negative", env: "-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml)
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("REVOCATION_TTL", tt.env)
+			t.Setenv("REDIS_URL", "redis://cache:6379")
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.RevocationTTL != tt.want || cfg.RedisURL != "redis://cache:6379" {
+				t.Errorf("expected TTL %s and the Redis URL, got %s and %q", tt.want, cfg.RevocationTTL, cfg.RedisURL)
+			}
+		})
+	}
+}
+
+func TestLoad_AdminUI(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+admin_ui: true
+`)
+
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{name: "from config file", want: true},
+		{name: "env overrides file", env: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ADMIN_UI", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.AdminUI != tt.want {
+				t.Errorf("expected admin UI %v, got %v", tt.want, cfg.AdminUI)
+			}
+		})
+	}
+}
+
+func TestLoad_LenientJSON(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+lenient_json: true
+`)
+
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{name: "from config file", want: true},
+		{name: "env overrides file", env: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("LENIENT_JSON", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.LenientJSON != tt.want {
+				t.Errorf("expected lenient JSON %v, got %v", tt.want, cfg.LenientJSON)
+			}
+		})
+	}
+}
+
+func TestLoad_ListCacheSize(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+list_cache_size: 500
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "from config file", want: 500},
+		{name: "env overrides file", env: "20", want: 20},
+		{name: "negative", env: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("LIST_CACHE_SIZE", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.ListCacheSize != tt.want {
+				t.Errorf("expected list cache size %d, got %d", tt.want, cfg.ListCacheSize)
+			}
+		})
+	}
+}
+
+func TestLoad_AudienceEnums(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    assets.AudienceEnums
+		wantErr bool
+	}{
+		{name: "defaults", want: assets.DefaultAudienceEnums()},
+		{
+			name: "override and extend",
+			yaml: `audience_enums:
+  gender:
+    extend: [Non-binary]
+  age_groups:
+    values: ["18-34", "35+"]
+  social_media_hours_daily:
+    values: ["0-2"]
+    extend: ["2+"]
+`,
+			want: assets.AudienceEnums{
+				Gender:           []string{"Male", "Female", "Non-binary"},
+				AgeGroups:        []string{"18-34", "35+"},
+				SocialMediaHours: []string{"0-2", "2+"},
+			},
+		},
+		{name: "unknown field", yaml: "audience_enums:\n  income:\n    values: [high]\n", wantErr: true},
+		{name: "empty value", yaml: "audience_enums:\n  gender:\n    extend: [\"\"]\n", wantErr: true},
+		{name: "duplicate value", yaml: "audience_enums:\n  gender:\n    extend: [Male]\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := cfg.AudienceEnumConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_AssetRulesDir(t *testing.T) {
+	writeRule := func(t *testing.T, name, rule string) string {
+		t.Helper()
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(rule), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	tests := []struct {
+		name      string
+		dir       func(t *testing.T) string
+		wantRules int
+		wantErr   string
+	}{
+		{name: "not set", dir: func(*testing.T) string { return "" }},
+		{name: "valid rule", dir: func(t *testing.T) string {
+			return writeRule(t, "chart.json", `{"properties": {"series": {"maxItems": 5}}}`)
+		}, wantRules: 1},
+		{name: "unknown asset type", dir: func(t *testing.T) string {
+			return writeRule(t, "report.json", `{}`)
+		}, wantErr: "unknown asset type"},
+		{name: "unsupported keyword", dir: func(t *testing.T) string {
+			return writeRule(t, "chart.json", `{"oneOf": []}`)
+		}, wantErr: "#/oneOf: unsupported keyword"},
+		{name: "missing directory", dir: func(t *testing.T) string {
+			return filepath.Join(t.TempDir(), "missing")
+		}, wantErr: "reading asset rules"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ASSET_RULES_DIR", tt.dir(t))
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rules, err := cfg.AssetRules()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rules) != tt.wantRules {
+				t.Errorf("expected %d rules, got %d", tt.wantRules, len(rules))
+			}
+		})
+	}
+}
+
+func TestLoad_MaxAssetDataBytes(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 64 << 10},
+		{name: "from env", env: "1048576", want: 1 << 20},
+		{name: "negative", env: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("MAX_ASSET_DATA_BYTES", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.MaxAssetDataBytes != tt.want {
+				t.Errorf("expected max asset data bytes %d, got %d", tt.want, cfg.MaxAssetDataBytes)
+			}
+		})
+	}
+}
+
+func TestLoad_MaxBodyBytes(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name      string
+		env       string
+		assetData string
+		want      int
+		wantErr   bool
+	}{
+		{name: "default", want: 1 << 20},
+		{name: "from env", env: "131072", want: 128 << 10},
+		{name: "negative", env: "-1", wantErr: true},
+		{name: "below asset data limit", env: "1024", assetData: "2048", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("MAX_BODY_BYTES", tt.env)
+			t.Setenv("MAX_ASSET_DATA_BYTES", tt.assetData)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.MaxBodyBytes != tt.want {
+				t.Errorf("expected max body bytes %d, got %d", tt.want, cfg.MaxBodyBytes)
+			}
+		})
+	}
+}
+
+func TestLoad_AssetTypeAliases(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+asset_type_aliases:
+  segment: audience
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "from config file", want: map[string]string{"segment": "audience"}},
+		{name: "env overrides file", env: "graph=chart, note=insight", want: map[string]string{"graph": "chart", "note": "insight"}},
+		{name: "unknown target", env: "segment=cohort", wantErr: true},
+		{name: "alias is a registered type", env: "chart=insight", wantErr: true},
+		{name: "malformed entry", env: "segment", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ASSET_TYPE_ALIASES", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.AssetTypeAliases, tt.want) {
+				t.Errorf("expected aliases %v, got %v", tt.want, cfg.AssetTypeAliases)
+			}
+		})
+	}
+}
+
+func TestLoad_ServiceTenants(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+service_tenants:
+  partner1: acme
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "from config file", want: map[string]string{"partner1": "acme"}},
+		{name: "env overrides file", env: "partner2=globex, spiffe://example.org/batch=acme", want: map[string]string{"partner2": "globex", "spiffe://example.org/batch": "acme"}},
+		{name: "malformed entry", env: "partner1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("SERVICE_TENANTS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.AuthConfig().ServiceTenants, tt.want) {
+				t.Errorf("expected service tenants %v, got %v", tt.want, cfg.AuthConfig().ServiceTenants)
+			}
+		})
+	}
+}
+
+func TestLoad_SkipMigrations(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+skip_migrations: true
+`)
+
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{name: "from config file", want: true},
+		{name: "env override", env: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("SKIP_MIGRATIONS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.SkipMigrations != tt.want {
+				t.Errorf("expected skip migrations %v, got %v", tt.want, cfg.SkipMigrations)
+			}
+		})
+	}
+}
+
+func TestLoad_SkipSchemaCheck(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+skip_schema_check: true
+`)
+
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{name: "from config file", want: true},
+		{name: "env override", env: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("SKIP_SCHEMA_CHECK", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.SkipSchemaCheck != tt.want {
+				t.Errorf("expected skip schema check %v, got %v", tt.want, cfg.SkipSchemaCheck)
+			}
+		})
+	}
+}
+
+func TestLoad_FavouritesPartitions(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+favourites_partitions: 16
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "from config file", want: 16},
+		{name: "env override", env: "0", want: 0},
+		{name: "single partition rejected", env: "1", wantErr: true},
+		{name: "negative rejected", env: "-4", wantErr: true},
+		{name: "not a number", env: "many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("FAVOURITES_PARTITIONS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.FavouritesPartitions != tt.want {
+				t.Errorf("expected %d partitions, got %d", tt.want, cfg.FavouritesPartitions)
+			}
+		})
+	}
+}
+
+func TestLoad_DBPreparedStatements(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+db_prepared_statements: true
+`)
+
+	tests := []struct {
+		name string
+		env  string
+		want bool
+	}{
+		{name: "from config file", want: true},
+		{name: "env override", env: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("DB_PREPARED_STATEMENTS", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.DBPreparedStatements != tt.want {
+				t.Errorf("expected prepared statements %v, got %v", tt.want, cfg.DBPreparedStatements)
+			}
+		})
+	}
+}
+
+func TestLoad_ReadinessCritical(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "default", want: []string{"database"}},
+		{name: "from config file", file: "readiness_critical: [database, write_queue]", want: []string{"database", "write_queue"}},
+		{name: "env override", file: "readiness_critical: [database]", env: "database, replicas", want: []string{"database", "replicas"}},
+		{name: "unknown check rejected", env: "database,webhooks", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.file+"\n")
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("READINESS_CRITICAL", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.ReadinessCritical, tt.want) {
+				t.Errorf("expected critical checks %v, got %v", tt.want, cfg.ReadinessCritical)
+			}
+		})
+	}
+}
+
+func TestLoad_ReplicaHosts(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name         string
+		hosts        string
+		interval     string
+		wantHosts    []string
+		wantInterval time.Duration
+	}{
+		{name: "no replicas"},
+		{name: "default interval", hosts: "replica-1, replica-2:5433", wantHosts: []string{"replica-1", "replica-2:5433"}, wantInterval: 10 * time.Second},
+		{name: "env interval", hosts: "replica-1", interval: "30s", wantHosts: []string{"replica-1"}, wantInterval: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("POSTGRES_REPLICA_HOSTS", tt.hosts)
+			t.Setenv("REPLICA_CHECK_INTERVAL", tt.interval)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.DBReplicaHosts, tt.wantHosts) || cfg.ReplicaCheckInterval != tt.wantInterval {
+				t.Errorf("expected replicas %v every %v, got %v every %v", tt.wantHosts, tt.wantInterval, cfg.DBReplicaHosts, cfg.ReplicaCheckInterval)
+			}
+		})
+	}
+}
+
+func TestLoad_DBBreaker(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+db_breaker_threshold: 3
+`)
+
+	tests := []struct {
+		name          string
+		threshold     string
+		cooldown      string
+		wantThreshold int
+		wantCooldown  time.Duration
+	}{
+		{name: "from config file with default cooldown", wantThreshold: 3, wantCooldown: 10 * time.Second},
+		{name: "env override", threshold: "8", cooldown: "30s", wantThreshold: 8, wantCooldown: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("DB_BREAKER_THRESHOLD", tt.threshold)
+			t.Setenv("DB_BREAKER_COOLDOWN", tt.cooldown)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.DBBreakerThreshold != tt.wantThreshold || cfg.DBBreakerCooldown != tt.wantCooldown {
+				t.Errorf("expected breaker %d/%v, got %d/%v", tt.wantThreshold, tt.wantCooldown, cfg.DBBreakerThreshold, cfg.DBBreakerCooldown)
+			}
+		})
+	}
+}
+
+func TestReplicaConnStringWithPassword(t *testing.T) {
+	cfg := &Config{DBPort: "5432", DBUser: "app", DBName: "favourites"}
+	tests := map[string]string{
+		"replica-1":      "host=replica-1 port=5432 user=app password=pw dbname=favourites sslmode=disable",
+		"replica-2:5433": "host=replica-2 port=5433 user=app password=pw dbname=favourites sslmode=disable",
+	}
+	for host, want := range tests {
+		if got := cfg.ReplicaConnStringWithPassword(host, "pw"); got != want {
+			t.Errorf("%s: expected %q, got %q", host, want, got)
+		}
+	}
+}
+
+func TestLoad_DBDriver(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		dsn     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "postgres"},
+		{name: "postgres from env", env: "postgres", want: "postgres"},
+		{name: "memory from env", env: "memory", want: "memory"},
+		{name: "mysql from env", env: "mysql", dsn: "app:pw@tcp(mysql:3306)/favourites", want: "mysql"},
+		{name: "mysql without dsn", env: "mysql", wantErr: true},
+		{name: "unknown driver", env: "sqlite", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("DB_DRIVER", tt.env)
+			t.Setenv("MYSQL_DSN", tt.dsn)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.DBDriver != tt.want {
+				t.Errorf("expected driver %q, got %q", tt.want, cfg.DBDriver)
+			}
+		})
+	}
+}
+
+func TestLoad_MemoryDriver(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+db_driver: memory
+memory_snapshot_path: /var/lib/favourites/snapshot.json
+`)
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantInterval time.Duration
+		wantErr      bool
+	}{
+		{name: "no database needed", wantInterval: 30 * time.Second},
+		{name: "interval from env", env: map[string]string{"MEMORY_SNAPSHOT_INTERVAL": "5m"}, wantInterval: 5 * time.Minute},
+		{name: "event outbox refused", env: map[string]string{"EVENT_OUTBOX": "true"}, wantErr: true},
+		{name: "column encryption refused", env: map[string]string{"COLUMN_ENCRYPTION_KEYS": "k1=" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 32))}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			for _, name := range []string{"POSTGRES_HOST", "POSTGRES_PORT", "POSTGRES_USER", "POSTGRES_PASSWORD", "POSTGRES_DB"} {
+				t.Setenv(name, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.DBDriver != "memory" {
+				t.Errorf("expected driver memory, got %q", cfg.DBDriver)
+			}
+			if cfg.MemorySnapshotPath != "/var/lib/favourites/snapshot.json" {
+				t.Errorf("unexpected snapshot path %q", cfg.MemorySnapshotPath)
+			}
+			if cfg.MemorySnapshotInterval != tt.wantInterval {
+				t.Errorf("expected interval %s, got %s", tt.wantInterval, cfg.MemorySnapshotInterval)
+			}
+		})
+	}
+}
+
+func TestLoad_AssetStorage(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: "embedded"},
+		{name: "normalized from env", env: "normalized", want: "normalized"},
+		{name: "unknown mode", env: "sharded", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ASSET_STORAGE", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.AssetStorage != tt.want {
+				t.Errorf("expected asset storage %q, got %q", tt.want, cfg.AssetStorage)
+			}
+		})
+	}
+}
+
+func TestLoad_WriteQueue(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+`)
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantPath     string
+		wantCapacity int
+		wantInterval time.Duration
+	}{
+		{name: "disabled by default"},
+		{
+			name:     "defaults when enabled",
+			env:      map[string]string{"WRITE_QUEUE_PATH": "/data/queue.json"},
+			wantPath: "/data/queue.json", wantCapacity: 1000, wantInterval: 5 * time.Second,
+		},
+		{
+			name: "explicit values",
+			env: map[string]string{
+				"WRITE_QUEUE_PATH":           "/data/queue.json",
+				"WRITE_QUEUE_CAPACITY":       "50",
+				"WRITE_QUEUE_FLUSH_INTERVAL": "30s",
+			},
+			wantPath: "/data/queue.json", wantCapacity: 50, wantInterval: 30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			for _, key := range []string{"WRITE_QUEUE_PATH", "WRITE_QUEUE_CAPACITY", "WRITE_QUEUE_FLUSH_INTERVAL"} {
+				t.Setenv(key, tt.env[key])
+			}
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.WriteQueuePath != tt.wantPath || cfg.WriteQueueCapacity != tt.wantCapacity || cfg.WriteQueueFlushInterval != tt.wantInterval {
+				t.Errorf("unexpected write queue config: path=%q capacity=%d interval=%s",
+					cfg.WriteQueuePath, cfg.WriteQueueCapacity, cfg.WriteQueueFlushInterval)
+			}
+		})
+	}
+}
+
+func TestLoad_Exports(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+export_workers: 4
+export_ttl: 30m
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("API_PORT", "")
+	t.Setenv("HEALTH_PORT", "")
+	t.Setenv("EXPORT_DIR", "/srv/exports")
+	t.Setenv("EXPORT_WORKERS", "")
+	t.Setenv("EXPORT_QUEUE_CAPACITY", "")
+	t.Setenv("EXPORT_TTL", "")
+	setDBEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ExportDir != "/srv/exports" || cfg.ExportWorkers != 4 || cfg.ExportTTL != 30*time.Minute {
+		t.Errorf("unexpected export config: dir=%q workers=%d ttl=%v", cfg.ExportDir, cfg.ExportWorkers, cfg.ExportTTL)
+	}
+	if cfg.ExportQueueCapacity != 100 {
+		t.Errorf("expected default queue capacity 100, got %d", cfg.ExportQueueCapacity)
+	}
+}
+
+func TestLoad_SoftDeletePurge(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+soft_delete_retention: 168h
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("API_PORT", "")
+	t.Setenv("HEALTH_PORT", "")
+	t.Setenv("SOFT_DELETE_RETENTION", "")
+	t.Setenv("SOFT_DELETE_PURGE_INTERVAL", "15m")
+	setDBEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SoftDeleteRetention != 168*time.Hour || cfg.SoftDeletePurgeInterval != 15*time.Minute {
+		t.Errorf("unexpected purge config: retention=%v interval=%v", cfg.SoftDeleteRetention, cfg.SoftDeletePurgeInterval)
+	}
+
+	t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"))
+	t.Setenv("SOFT_DELETE_PURGE_INTERVAL", "")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SoftDeleteRetention != 720*time.Hour || cfg.SoftDeletePurgeInterval != time.Hour {
+		t.Errorf("expected defaults 720h every 1h, got %v every %v", cfg.SoftDeleteRetention, cfg.SoftDeletePurgeInterval)
+	}
+}
+
+func TestLoad_EventOutbox(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+outbox_relay_interval: 2s
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("API_PORT", "")
+	t.Setenv("HEALTH_PORT", "")
+	t.Setenv("EVENT_OUTBOX", "true")
+	t.Setenv("OUTBOX_RELAY_INTERVAL", "")
+	setDBEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.EventOutbox || cfg.OutboxRelayInterval != 2*time.Second {
+		t.Errorf("unexpected outbox config: enabled=%v interval=%v", cfg.EventOutbox, cfg.OutboxRelayInterval)
+	}
+}
+
+func TestLoad_MultiTenant(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+tenant_rate_limit_requests: 500
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("API_PORT", "")
+	t.Setenv("HEALTH_PORT", "")
+	t.Setenv("MULTI_TENANT", "true")
+	t.Setenv("TENANT_RATE_LIMIT_REQUESTS", "")
+	t.Setenv("RATE_LIMIT_REQUESTS", "0")
+	t.Setenv("RATE_LIMIT_WINDOW", "")
+	setDBEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.MultiTenant {
+		t.Error("expected multi-tenancy enabled from env")
+	}
+	rl := cfg.RateLimitConfig()
+	if rl.TenantRequests != 500 || rl.Window != time.Minute {
+		t.Errorf("expected 500 tenant requests per default window, got %d per %v", rl.TenantRequests, rl.Window)
+	}
+}
+
+func TestLoad_APIV1Sunset(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+api_v1_sunset: 2027-04-30
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("API_PORT", "")
+	t.Setenv("HEALTH_PORT", "")
+	t.Setenv("API_V1_SUNSET", "")
+	setDBEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC); !cfg.APIV1Sunset.Equal(want) {
+		t.Errorf("expected sunset %v from config file, got %v", want, cfg.APIV1Sunset)
+	}
+
+	t.Setenv("API_V1_SUNSET", "2027-06-01T12:00:00Z")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2027, time.June, 1, 12, 0, 0, 0, time.UTC); !cfg.APIV1Sunset.Equal(want) {
+		t.Errorf("expected env var to override sunset with %v, got %v", want, cfg.APIV1Sunset)
+	}
+
+	t.Setenv("API_V1_SUNSET", "next spring")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error for an invalid API_V1_SUNSET")
+	}
+}
+
+func TestLoad_CORS(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+cors_allowed_origins:
+  - https://app.example.com
+cors_max_age: 1h
+`)
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("API_PORT", "")
+	t.Setenv("HEALTH_PORT", "")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	t.Setenv("CORS_ALLOWED_METHODS", "GET, POST")
+	t.Setenv("CORS_ALLOWED_HEADERS", "")
+	t.Setenv("CORS_MAX_AGE", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	setDBEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cors := cfg.CORSConfig()
+	if len(cors.AllowedOrigins) != 1 || cors.AllowedOrigins[0] != "https://app.example.com" {
+		t.Errorf("unexpected origins %v", cors.AllowedOrigins)
+	}
+	if len(cors.AllowedMethods) != 2 || cors.AllowedMethods[1] != "POST" {
+		t.Errorf("expected methods from env var, got %v", cors.AllowedMethods)
+	}
+	if len(cors.AllowedHeaders) != 10 || cors.MaxAge != time.Hour || !cors.AllowCredentials {
+		t.Errorf("unexpected CORS config: %+v", cors)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error for a wildcard origin with credentials")
+	}
+}
+
+func TestLoad_ColumnEncryption(t *testing.T) {
+	key1 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("a"), 32))
+	key2 := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("b"), 16))
+
+	tests := []struct {
+		name       string
+		yaml       string
+		keys       string
+		keyID      string
+		wantCipher bool
+		wantKeyID  string
+		wantErr    bool
+	}{
+		{name: "disabled by default"},
+		{name: "single key is current", keys: "k1=" + key1, wantCipher: true, wantKeyID: "k1"},
+		{name: "current key from config file", yaml: "column_encryption_key_id: k2\n", keys: "k1=" + key1 + ",k2=" + key2, wantCipher: true, wantKeyID: "k2"},
+		{name: "env overrides config file", yaml: "column_encryption_key_id: k2\n", keys: "k1=" + key1 + ",k2=" + key2, keyID: "k1", wantCipher: true, wantKeyID: "k1"},
+		{name: "several keys without current", keys: "k1=" + key1 + ",k2=" + key2, wantErr: true},
+		{name: "unknown current key", keys: "k1=" + key1, keyID: "k3", wantErr: true},
+		{name: "invalid base64", keys: "k1=not-base64!", wantErr: true},
+		{name: "invalid key length", keys: "k1=" + base64.StdEncoding.EncodeToString([]byte("short")), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.yaml)
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("COLUMN_ENCRYPTION_KEYS", tt.keys)
+			t.Setenv("COLUMN_ENCRYPTION_KEY_ID", tt.keyID)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			c, err := cfg.ColumnCipher()
+			if err != nil || (c != nil) != tt.wantCipher || cfg.ColumnEncryptionKeyID != tt.wantKeyID {
+				t.Errorf("expected cipher %v with key %q, got %v with key %q (%v)", tt.wantCipher, tt.wantKeyID, c != nil, cfg.ColumnEncryptionKeyID, err)
+			}
+		})
+	}
+}
+
+func TestLoad_LogRedaction(t *testing.T) {
+	path := writeTempConfig(t, `api_port: "9000"
+health_port: "9001"
+log_allowed_fields: [description]
+`)
+
+	tests := []struct {
+		name   string
+		fields string
+		hash   string
+		want   logging.Redaction
+	}{
+		{name: "from config file", want: logging.Redaction{AllowedFields: []string{"description"}}},
+		{name: "env override", fields: "asset_data, description", hash: "true", want: logging.Redaction{AllowedFields: []string{"asset_data", "description"}, HashUserIDs: true, HashKey: "k"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", path)
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("LOG_ALLOWED_FIELDS", tt.fields)
+			t.Setenv("LOG_HASH_USER_IDS", tt.hash)
+			t.Setenv("LOG_HASH_KEY", tt.want.HashKey)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.LogRedaction(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_LogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "default", want: slog.LevelInfo},
+		{name: "from config file", file: "log_level: warn\n", want: slog.LevelWarn},
+		{name: "env override", file: "log_level: warn\n", env: "DEBUG", want: slog.LevelDebug},
+		{name: "unknown level", env: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.file))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("LOG_LEVEL", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error for an unknown level")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.MinLogLevel(); got != tt.want {
+				t.Errorf("expected level %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_LogBodyRoutes(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		env          string
+		want         []RoutePattern
+		wantMaxBytes int
+		wantErr      bool
+	}{
+		{name: "default", wantMaxBytes: defaultLogBodyMaxBytes},
+		{
+			name:         "from config file",
+			file:         "log_body_routes:\n  - method: post\n    path: /favourites\nlog_body_max_bytes: 512\n",
+			want:         []RoutePattern{{Method: "POST", Path: "/favourites"}},
+			wantMaxBytes: 512,
+		},
+		{
+			name:         "env override",
+			file:         "log_body_routes:\n  - path: /favourites\n",
+			env:          "PATCH /favourites/*, /admin/*",
+			want:         []RoutePattern{{Method: "PATCH", Path: "/favourites/*"}, {Path: "/admin/*"}},
+			wantMaxBytes: defaultLogBodyMaxBytes,
+		},
+		{name: "unsupported method", env: "TRACE /favourites", wantErr: true},
+		{name: "relative path", file: "log_body_routes:\n  - path: favourites\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.file))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("LOG_BODY_ROUTES", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := cfg.BodyLogConfig()
+			if !reflect.DeepEqual(got.Routes, tt.want) || got.MaxBytes != tt.wantMaxBytes {
+				t.Errorf("expected routes %v and max bytes %d, got %v and %d", tt.want, tt.wantMaxBytes, got.Routes, got.MaxBytes)
+			}
+		})
+	}
+}
+
+func TestLoad_PanicWebhookURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "https URL", env: "https://alerts.example.com/hook"},
+		{name: "relative URL", env: "/hook", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("PANIC_WEBHOOK_URL", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.PanicWebhookURL != tt.env {
+				t.Errorf("expected webhook %q, got %q", tt.env, cfg.PanicWebhookURL)
+			}
+		})
+	}
+}
+
+func TestLoad_ErrorTracking(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		dsn     string
+		env     string
+		wantEnv string
+		wantErr bool
+	}{
+		{name: "unset", wantEnv: "production"},
+		{name: "from file", file: "error_tracking_dsn: https://key@errors.example.com/42\nerror_tracking_environment: staging\n", wantEnv: "staging"},
+		{name: "env overrides file", file: "error_tracking_environment: staging\n", dsn: "https://key@errors.example.com/42", env: "dev", wantEnv: "dev"},
+		{name: "missing key", dsn: "https://errors.example.com/42", wantErr: true},
+		{name: "missing project", dsn: "https://key@errors.example.com/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.file))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("ERROR_TRACKING_DSN", tt.dsn)
+			t.Setenv("ERROR_TRACKING_ENVIRONMENT", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.ErrorTrackingEnvironment != tt.wantEnv {
+				t.Errorf("expected environment %q, got %q", tt.wantEnv, cfg.ErrorTrackingEnvironment)
+			}
+		})
+	}
+}
+
+func TestLoad_Pprof(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		user     string
+		password string
+		want     PprofConfig
+		wantErr  bool
+	}{
+		{name: "default"},
+		{name: "from config file", file: "pprof: true\npprof_user: ops\n", password: "s3cret", want: PprofConfig{Enabled: true, User: "ops", Password: "s3cret"}},
+		{name: "env override", file: "pprof: true\npprof_user: ops\n", user: "admin", password: "s3cret", want: PprofConfig{Enabled: true, User: "admin", Password: "s3cret"}},
+		{name: "user without password", file: "pprof: true\npprof_user: ops\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.file))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("PPROF", "")
+			t.Setenv("PPROF_USER", tt.user)
+			t.Setenv("PPROF_PASSWORD", tt.password)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.PprofConfig(); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_SlowQueryThreshold(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: 500 * time.Millisecond},
+		{name: "from config file", file: "slow_query_threshold: 200ms\n", want: 200 * time.Millisecond},
+		{name: "env override", file: "slow_query_threshold: 200ms\n", env: "1s", want: time.Second},
+		{name: "disabled", env: "-1ms", want: -time.Millisecond},
+		{name: "invalid", env: "slow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_PATH", writeTempConfig(t, "api_port: \"9000\"\nhealth_port: \"9001\"\n"+tt.file))
+			t.Setenv("API_PORT", "")
+			t.Setenv("HEALTH_PORT", "")
+			t.Setenv("SLOW_QUERY_THRESHOLD", tt.env)
+			setDBEnv(t)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.SlowQueryThreshold != tt.want {
+				t.Errorf("expected threshold %v, got %v", tt.want, cfg.SlowQueryThreshold)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrAPIKeyNotFound is returned when a user has no active API key with the
+// requested ID.
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// APIKey describes an API key bound to a user. The key itself is only known
+// to its holder; the database keeps its hash.
+type APIKey struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKey stores key together with the hash of its secret, in the
+// tenant of ctx.
+func (r *Repository) CreateAPIKey(ctx context.Context, key APIKey, hash string) error {
+	const query = `
+		INSERT INTO api_keys (id, user_id, name, key_hash, created_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	if _, err := r.db.ExecContext(ctx, query, key.ID, key.UserID, key.Name, hash, key.CreatedAt, TenantFromContext(ctx)); err != nil {
+		return fmt.Errorf("inserting API key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys returns the user's API keys, revoked ones included, oldest first.
+func (r *Repository) ListAPIKeys(ctx context.Context, userID string) ([]APIKey, error) {
+	args := []any{userID}
+	query := `
+		SELECT id, user_id, name, created_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1` + tenantScope(ctx, &args) + `
+		ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var (
+			k         APIKey
+			revokedAt sql.NullTime
+		)
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.CreatedAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("scanning API key: %w", err)
+		}
+		if revokedAt.Valid {
+			k.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks the user's key keyID as revoked at revokedAt. It
+// returns ErrAPIKeyNotFound when the user has no such key or it is already
+// revoked.
+func (r *Repository) RevokeAPIKey(ctx context.Context, userID, keyID string, revokedAt time.Time) error {
+	args := []any{userID, keyID, revokedAt}
+	query := `
+		UPDATE api_keys SET revoked_at = $3
+		WHERE user_id = $1 AND id = $2 AND revoked_at IS NULL` + tenantScope(ctx, &args)
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("revoking API key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revoking API key: %w", err)
+	}
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// GetAPIKeyUser returns the user the key with the given hash is bound
+// to and the tenant it was issued in, or empty strings when no active key
+// has that hash. Keys are looked up before any tenant is known, so the
+// query is not scoped.
+func (r *Repository) GetAPIKeyUser(ctx context.Context, hash string) (userID, tenantID string, err error) {
+	const query = `SELECT user_id, tenant_id FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	err = r.db.QueryRowContext(ctx, query, hash).Scan(&userID, &tenantID)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("querying API key: %w", err)
+	}
+	return userID, tenantID, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRevokeAPIKey(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		setupMock func(sqlmock.Sqlmock)
+		wantErr   error
+	}{
+		{
+			name: "revokes active key",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs("user1", "k1", now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "unknown or already revoked key",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs("user1", "k1", now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			tt.setupMock(mock)
+
+			err := repo.RevokeAPIKey(context.Background(), "user1", "k1", now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeyUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMock  func(sqlmock.Sqlmock)
+		wantUser   string
+		wantTenant string
+		wantErr    bool
+	}{
+		{
+			name: "active key",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT user_id, tenant_id FROM api_keys WHERE key_hash = \\$1 AND revoked_at IS NULL").WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "tenant_id"}).AddRow("user1", "acme"))
+			},
+			wantUser:   "user1",
+			wantTenant: "acme",
+		},
+		{
+			name: "unknown or revoked key",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT user_id, tenant_id FROM api_keys").WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "tenant_id"}))
+			},
+		},
+		{
+			name: "query failure",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT user_id, tenant_id FROM api_keys").WillReturnError(fmt.Errorf("connection failed"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			tt.setupMock(mock)
+
+			user, tenant, err := repo.GetAPIKeyUser(context.Background(), "hash")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr=%v, got: %v", tt.wantErr, err)
+			}
+			if user != tt.wantUser || tenant != tt.wantTenant {
+				t.Errorf("expected %q in %q, got %q in %q", tt.wantUser, tt.wantTenant, user, tenant)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	revoked := created.Add(time.Hour)
+	mock.ExpectQuery("SELECT id, user_id, name, created_at, revoked_at FROM api_keys").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "created_at", "revoked_at"}).
+			AddRow("k1", "user1", "reporting", created, revoked).
+			AddRow("k2", "user1", "sync", created, nil))
+
+	keys, err := repo.ListAPIKeys(context.Background(), "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0].RevokedAt == nil || !keys[0].RevokedAt.Equal(revoked) || keys[1].RevokedAt != nil {
+		t.Errorf("unexpected keys: %+v", keys)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// RenameAssetType moves every favourite and catalog asset of type from to
+// type to in one transaction, returning how many of each were changed. It
+// fails, changing nothing, when the catalog already holds an asset of type to
+// with the same id.
+func (r *Repository) RenameAssetType(ctx context.Context, from, to models.AssetType) (favourites, catalog int64, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE favourites SET asset_type = $2 WHERE asset_type = $1`, string(from), string(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("renaming favourites asset type: %w", err)
+	}
+	if favourites, err = result.RowsAffected(); err != nil {
+		return 0, 0, fmt.Errorf("checking rows affected: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `UPDATE assets SET asset_type = $2 WHERE asset_type = $1`, string(from), string(to))
+	if err != nil {
+		return 0, 0, fmt.Errorf("renaming catalog asset type: %w", err)
+	}
+	if catalog, err = result.RowsAffected(); err != nil {
+		return 0, 0, fmt.Errorf("checking rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("committing asset type rename: %w", err)
+	}
+	return favourites, catalog, nil
+}
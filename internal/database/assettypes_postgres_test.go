@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRenameAssetType(t *testing.T) {
+	t.Run("renames favourites and catalog assets", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE favourites SET asset_type").WithArgs("segment", "audience").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("UPDATE assets SET asset_type").WithArgs("segment", "audience").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		favourites, catalog, err := repo.RenameAssetType(context.Background(), "segment", "audience")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if favourites != 3 || catalog != 1 {
+			t.Errorf("expected 3 favourites and 1 catalog asset, got %d and %d", favourites, catalog)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rolls back on catalog conflict", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE favourites SET asset_type").WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("UPDATE assets SET asset_type").WillReturnError(errors.New("duplicate key"))
+		mock.ExpectRollback()
+
+		if _, _, err := repo.RenameAssetType(context.Background(), "segment", "audience"); err == nil {
+			t.Fatal("expected error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
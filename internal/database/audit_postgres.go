@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AuditEntry is a single recorded change to a user's favourites.
+type AuditEntry struct {
+	ID         int64             `json:"id"`
+	UserID     string            `json:"user_id"`
+	Actor      string            `json:"actor"`
+	Action     string            `json:"action"`
+	AssetID    string            `json:"asset_id"`
+	Diff       map[string]string `json:"diff,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+// InsertAuditEntry appends an entry to the audit trail of the tenant of ctx.
+func (r *Repository) InsertAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	var diffJSON []byte
+	if len(entry.Diff) > 0 {
+		diff, err := r.cipher.sealChanges(tenantRow(ctx, entry.UserID, entry.AssetID), entry.Diff)
+		if err != nil {
+			return fmt.Errorf("encrypting audit diff: %w", err)
+		}
+		if diffJSON, err = json.Marshal(diff); err != nil {
+			return fmt.Errorf("marshalling audit diff: %w", err)
+		}
+	}
+
+	const query = `
+		INSERT INTO favourite_audit (user_id, actor, action, asset_id, diff, occurred_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := r.db.ExecContext(ctx, query,
+		entry.UserID, entry.Actor, entry.Action, entry.AssetID, diffJSON, entry.OccurredAt, TenantFromContext(ctx),
+	); err != nil {
+		return fmt.Errorf("inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetAuditEntries returns the user's most recent audit entries, newest first.
+func (r *Repository) GetAuditEntries(ctx context.Context, userID string, limit int) ([]*AuditEntry, error) {
+	args := []any{userID, limit}
+	query := `
+		SELECT id, user_id, actor, action, asset_id, diff, occurred_at, tenant_id
+		FROM favourite_audit
+		WHERE user_id = $1` + tenantScope(ctx, &args) + `
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.readQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		var (
+			entry    AuditEntry
+			diffJSON []byte
+			tenant   string
+		)
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Actor, &entry.Action,
+			&entry.AssetID, &diffJSON, &entry.OccurredAt, &tenant); err != nil {
+			return nil, fmt.Errorf("scanning audit entry: %w", err)
+		}
+		if len(diffJSON) > 0 {
+			if err := json.Unmarshal(diffJSON, &entry.Diff); err != nil {
+				return nil, fmt.Errorf("unmarshalling audit diff: %w", err)
+			}
+			row := favouriteRow{tenant: tenant, user: entry.UserID, id: entry.AssetID}
+			if err := r.cipher.openChanges(row, entry.Diff); err != nil {
+				return nil, fmt.Errorf("decrypting audit entry %d: %w", entry.ID, err)
+			}
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating audit entries: %w", err)
+	}
+	return entries, nil
+}
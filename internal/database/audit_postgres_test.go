@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var auditCols = []string{"id", "user_id", "actor", "action", "asset_id", "diff", "occurred_at", "tenant_id"}
+
+func TestInsertAuditEntry(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		entry     *AuditEntry
+		setupMock func(sqlmock.Sqlmock)
+		wantErr   bool
+	}{
+		{
+			name:  "stores diff as JSON",
+			entry: &AuditEntry{UserID: "user1", Actor: "user1", Action: "update", AssetID: "c1", Diff: map[string]string{"description": "new"}, OccurredAt: now},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("INSERT INTO favourite_audit").
+					WithArgs("user1", "user1", "update", "c1", []byte(`{"description":"new"}`), now, "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:  "stores NULL diff when empty",
+			entry: &AuditEntry{UserID: "user1", Actor: "admin1", Action: "delete", AssetID: "c1", OccurredAt: now},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("INSERT INTO favourite_audit").
+					WithArgs("user1", "admin1", "delete", "c1", []byte(nil), now, "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:  "returns error on exec failure",
+			entry: &AuditEntry{UserID: "user1", Actor: "user1", Action: "add", AssetID: "c1", OccurredAt: now},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("INSERT INTO favourite_audit").WillReturnError(fmt.Errorf("connection failed"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			tt.setupMock(mock)
+
+			err := repo.InsertAuditEntry(context.Background(), tt.entry)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr=%v, got: %v", tt.wantErr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		setupMock func(sqlmock.Sqlmock)
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name: "returns entries with decoded diff",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourite_audit WHERE user_id").WithArgs("user1", 50).
+					WillReturnRows(sqlmock.NewRows(auditCols).
+						AddRow(2, "user1", "admin1", "delete", "c1", nil, now, "").
+						AddRow(1, "user1", "user1", "add", "c1", []byte(`{"description":"d"}`), now, ""))
+			},
+			wantCount: 2,
+		},
+		{
+			name: "returns empty slice when no entries",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourite_audit").WithArgs("user1", 50).
+					WillReturnRows(sqlmock.NewRows(auditCols))
+			},
+			wantCount: 0,
+		},
+		{
+			name: "returns error on query failure",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourite_audit").WillReturnError(fmt.Errorf("connection failed"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			tt.setupMock(mock)
+
+			entries, err := repo.GetAuditEntries(context.Background(), "user1", 50)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr=%v, got: %v", tt.wantErr, err)
+			}
+			if !tt.wantErr {
+				if entries == nil || len(entries) != tt.wantCount {
+					t.Fatalf("expected %d entries, got %v", tt.wantCount, entries)
+				}
+				if tt.wantCount == 2 && (entries[0].Diff != nil || entries[1].Diff["description"] != "d") {
+					t.Errorf("unexpected diffs: %+v, %+v", entries[0], entries[1])
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
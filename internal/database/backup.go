@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ErrNotRestored is returned by a Restorer for records its repository does
+// not keep, such as the versions and audit entries of a MemoryRepository or
+// MySQLRepository.
+// The restore can carry on without them.
+var ErrNotRestored = errors.New("record not kept by this repository")
+
+// BackupStore is a repository a backup can be taken from and restored into.
+// Repository implements it on PostgreSQL, MySQLRepository on MySQL and
+// MemoryRepository in memory, yielding only the records they keep. The Each methods call fn for every
+// record of their table in primary key order; an error from fn stops the
+// iteration and is returned as is.
+type BackupStore interface {
+	EachAssetRecord(ctx context.Context, fn func(*AssetRecord) error) error
+	EachFavouriteRecord(ctx context.Context, fn func(*FavouriteRecord) error) error
+	EachVersionRecord(ctx context.Context, fn func(*VersionRecord) error) error
+	EachAuditRecord(ctx context.Context, fn func(*AuditRecord) error) error
+	EachPreferenceRecord(ctx context.Context, fn func(*PreferenceRecord) error) error
+	// BeginRestore starts a restore, applied only once its Restorer commits.
+	BeginRestore(ctx context.Context) (Restorer, error)
+}
+
+// Restorer writes the records of a backup to a BackupStore. Nothing it was
+// given is visible until Commit; Rollback abandons them. Catalog assets
+// precede the favourites using them and favourites their versions, as in a
+// backup.
+type Restorer interface {
+	PutAsset(ctx context.Context, rec *AssetRecord) error
+	PutFavourite(ctx context.Context, rec *FavouriteRecord) error
+	PutVersion(ctx context.Context, rec *VersionRecord) error
+	PutAuditEntry(ctx context.Context, rec *AuditRecord) error
+	PutPreference(ctx context.Context, rec *PreferenceRecord) error
+	Commit(ctx context.Context) error
+	Rollback() error
+}
+
+// FavouriteRecord is a favourites row in backend-neutral form, as stored in backups.
+// DescriptionHTML is nil for favourites whose description was never rendered.
+type FavouriteRecord struct {
+	ID              string          `json:"id"`
+	UserID          string          `json:"user_id"`
+	TenantID        string          `json:"tenant_id,omitempty"`
+	AssetType       string          `json:"asset_type"`
+	Description     string          `json:"description"`
+	Data            json.RawMessage `json:"data"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	SourceSystem    string          `json:"source_system,omitempty"`
+	SourceURL       string          `json:"source_url,omitempty"`
+	FavouritedFrom  string          `json:"favourited_from,omitempty"`
+	DescriptionHTML *string         `json:"description_html,omitempty"`
+}
+
+// AssetRecord is an assets row in backend-neutral form, as stored in backups.
+type AssetRecord struct {
+	AssetType string          `json:"asset_type"`
+	ID        string          `json:"id"`
+	Data      json.RawMessage `json:"data"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// VersionRecord is a favourite_versions row in backend-neutral form, as stored in backups.
+type VersionRecord struct {
+	UserID     string          `json:"user_id"`
+	TenantID   string          `json:"tenant_id,omitempty"`
+	AssetID    string          `json:"asset_id"`
+	Version    int             `json:"version"`
+	Data       json.RawMessage `json:"data"`
+	ReplacedAt time.Time       `json:"replaced_at"`
+}
+
+// AuditRecord is a favourite_audit row in backend-neutral form, as stored in backups.
+type AuditRecord struct {
+	ID         int64           `json:"id"`
+	UserID     string          `json:"user_id"`
+	TenantID   string          `json:"tenant_id,omitempty"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	AssetID    string          `json:"asset_id"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// PreferenceRecord is a user_preferences row in backend-neutral form, as stored in backups.
+type PreferenceRecord struct {
+	UserID    string    `json:"user_id"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	Timezone  string    `json:"timezone"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// catalogKey is the primary key of a catalog asset, as in the assets table.
+type catalogKey struct {
+	assetType, id string
+}
+
+// restoredCatalog keeps the catalog assets of a restore into a repository
+// without a catalog, whose favourites carry their own asset data.
+type restoredCatalog map[catalogKey]json.RawMessage
+
+// put keeps the data of a restored catalog asset.
+func (c restoredCatalog) put(rec *AssetRecord) {
+	c[catalogKey{rec.AssetType, rec.ID}] = rec.Data
+}
+
+// favourite returns rec with the data of the catalog asset restored before
+// it if it has none, failing unless its data decodes as its asset type.
+func (c restoredCatalog) favourite(rec *FavouriteRecord) (FavouriteRecord, error) {
+	fav := *rec
+	if nullableJSON(fav.Data) == nil {
+		data, ok := c[catalogKey{fav.AssetType, fav.ID}]
+		if !ok {
+			return fav, fmt.Errorf("restoring favourite %s/%s: catalog asset %s/%s not restored", fav.UserID, fav.ID, fav.AssetType, fav.ID)
+		}
+		fav.Data = data
+	}
+	if _, err := assets.Decode(models.AssetType(fav.AssetType), fav.Data); err != nil {
+		return fav, fmt.Errorf("restoring favourite %s/%s: %w", fav.UserID, fav.ID, err)
+	}
+	return fav, nil
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+var _ BackupStore = (*MySQLRepository)(nil)
+
+// EachAssetRecord yields nothing: a MySQLRepository keeps no catalog, its
+// favourites carry their asset data.
+func (m *MySQLRepository) EachAssetRecord(context.Context, func(*AssetRecord) error) error {
+	return nil
+}
+
+// EachFavouriteRecord calls fn for every favourite, in primary key order,
+// without loading the whole table into memory.
+func (m *MySQLRepository) EachFavouriteRecord(ctx context.Context, fn func(*FavouriteRecord) error) error {
+	const query = `
+		SELECT id, user_id, tenant_id, asset_type, description, data, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html
+		FROM favourites
+		ORDER BY tenant_id, user_id, id`
+
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying favourites: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec             FavouriteRecord
+			descriptionHTML sql.NullString
+			data            []byte
+		)
+		if err := rows.Scan(&rec.ID, &rec.UserID, &rec.TenantID, &rec.AssetType, &rec.Description, &data,
+			&rec.CreatedAt, &rec.UpdatedAt, &rec.SourceSystem, &rec.SourceURL, &rec.FavouritedFrom, &descriptionHTML); err != nil {
+			return fmt.Errorf("scanning favourite: %w", err)
+		}
+		rec.Data = data
+		if descriptionHTML.Valid {
+			rec.DescriptionHTML = &descriptionHTML.String
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating favourites: %w", err)
+	}
+	return nil
+}
+
+// EachVersionRecord yields nothing: a MySQLRepository keeps no versions.
+func (m *MySQLRepository) EachVersionRecord(context.Context, func(*VersionRecord) error) error {
+	return nil
+}
+
+// EachAuditRecord yields nothing: a MySQLRepository keeps no audit trail.
+func (m *MySQLRepository) EachAuditRecord(context.Context, func(*AuditRecord) error) error {
+	return nil
+}
+
+// EachPreferenceRecord yields nothing: a MySQLRepository keeps no preferences.
+func (m *MySQLRepository) EachPreferenceRecord(context.Context, func(*PreferenceRecord) error) error {
+	return nil
+}
+
+// BeginRestore starts a restore transaction.
+func (m *MySQLRepository) BeginRestore(ctx context.Context) (Restorer, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning restore: %w", err)
+	}
+	return &mysqlRestorer{tx: tx, catalog: make(restoredCatalog)}, nil
+}
+
+// mysqlRestorer is the Restorer of a MySQLRepository. As with a
+// MemoryRepository, catalog assets are only kept for the restore and
+// versions, audit entries and preferences are refused with ErrNotRestored.
+type mysqlRestorer struct {
+	tx      *sql.Tx
+	catalog restoredCatalog
+}
+
+// PutAsset keeps a catalog asset for the favourites restored after it.
+func (r *mysqlRestorer) PutAsset(_ context.Context, rec *AssetRecord) error {
+	r.catalog.put(rec)
+	return nil
+}
+
+// PutFavourite inserts or replaces a favourite, taking its data from the
+// catalog asset restored before it if it has none.
+func (r *mysqlRestorer) PutFavourite(ctx context.Context, rec *FavouriteRecord) error {
+	fav, err := r.catalog.favourite(rec)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			asset_type = VALUES(asset_type), description = VALUES(description), data = VALUES(data),
+			created_at = VALUES(created_at), updated_at = VALUES(updated_at),
+			source_system = VALUES(source_system), source_url = VALUES(source_url),
+			favourited_from = VALUES(favourited_from), description_html = VALUES(description_html)`
+
+	_, err = r.tx.ExecContext(ctx, query, fav.ID, fav.UserID, fav.AssetType, fav.Description, string(fav.Data),
+		fav.CreatedAt, fav.UpdatedAt, fav.SourceSystem, fav.SourceURL, fav.FavouritedFrom, fav.DescriptionHTML, fav.TenantID)
+	if err != nil {
+		return fmt.Errorf("restoring favourite %s/%s: %w", fav.UserID, fav.ID, err)
+	}
+	return nil
+}
+
+// PutVersion fails with ErrNotRestored.
+func (r *mysqlRestorer) PutVersion(context.Context, *VersionRecord) error {
+	return ErrNotRestored
+}
+
+// PutAuditEntry fails with ErrNotRestored.
+func (r *mysqlRestorer) PutAuditEntry(context.Context, *AuditRecord) error {
+	return ErrNotRestored
+}
+
+// PutPreference fails with ErrNotRestored.
+func (r *mysqlRestorer) PutPreference(context.Context, *PreferenceRecord) error {
+	return ErrNotRestored
+}
+
+// Commit commits the restore transaction.
+func (r *mysqlRestorer) Commit(context.Context) error {
+	if err := r.tx.Commit(); err != nil {
+		return fmt.Errorf("committing restore: %w", err)
+	}
+	return nil
+}
+
+// Rollback abandons the restore transaction.
+func (r *mysqlRestorer) Rollback() error {
+	return r.tx.Rollback()
+}
@@ -0,0 +1,462 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EachAssetRecord calls fn for every catalog asset, in primary key order.
+func (r *Repository) EachAssetRecord(ctx context.Context, fn func(*AssetRecord) error) error {
+	const query = `SELECT asset_type, id, data, updated_at FROM assets ORDER BY asset_type, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying catalog assets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec  AssetRecord
+			data []byte
+		)
+		if err := rows.Scan(&rec.AssetType, &rec.ID, &data, &rec.UpdatedAt); err != nil {
+			return fmt.Errorf("scanning catalog asset: %w", err)
+		}
+		rec.Data = data
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating catalog assets: %w", err)
+	}
+	return nil
+}
+
+// EachFavouriteRecord calls fn for every favourite not deleted, in primary key
+// order, without loading the whole table into memory. Encrypted values are
+// passed decrypted.
+func (r *Repository) EachFavouriteRecord(ctx context.Context, fn func(*FavouriteRecord) error) error {
+	const query = `
+		SELECT id, user_id, tenant_id, asset_type, description, data, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html
+		FROM favourites
+		WHERE deleted_at IS NULL
+		ORDER BY tenant_id, user_id, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying favourites: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec                                     FavouriteRecord
+			description                             sql.NullString
+			sourceSystem, sourceURL, favouritedFrom sql.NullString
+			descriptionHTML                         sql.NullString
+			data                                    []byte
+		)
+		if err := rows.Scan(&rec.ID, &rec.UserID, &rec.TenantID, &rec.AssetType, &description, &data,
+			&rec.CreatedAt, &rec.UpdatedAt, &sourceSystem, &sourceURL, &favouritedFrom, &descriptionHTML); err != nil {
+			return fmt.Errorf("scanning favourite: %w", err)
+		}
+		rec.SourceSystem = sourceSystem.String
+		rec.SourceURL = sourceURL.String
+		rec.FavouritedFrom = favouritedFrom.String
+		if err := r.openFavouriteRecord(&rec, description.String, descriptionHTML, data); err != nil {
+			return err
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating favourites: %w", err)
+	}
+	return nil
+}
+
+// openFavouriteRecord sets the description, rendered description and asset
+// data of rec from their stored values, decrypted: backups hold plaintext,
+// so they can be restored under other keys or into another store.
+func (r *Repository) openFavouriteRecord(rec *FavouriteRecord, description string, descriptionHTML sql.NullString, data []byte) error {
+	row := favouriteRow{rec.TenantID, rec.UserID, rec.ID}
+	var err error
+	if rec.Description, err = r.cipher.openText(row, descriptionColumn, description); err != nil {
+		return fmt.Errorf("decrypting favourite %s/%s: %w", rec.UserID, rec.ID, err)
+	}
+	if descriptionHTML.Valid {
+		html, err := r.cipher.openText(row, descriptionHTMLColumn, descriptionHTML.String)
+		if err != nil {
+			return fmt.Errorf("decrypting favourite %s/%s: %w", rec.UserID, rec.ID, err)
+		}
+		rec.DescriptionHTML = &html
+	}
+	if len(data) > 0 {
+		if rec.Data, err = r.cipher.openData(row, data); err != nil {
+			return fmt.Errorf("decrypting favourite %s/%s: %w", rec.UserID, rec.ID, err)
+		}
+	}
+	return nil
+}
+
+// openAuditDiff returns the diff of an audit entry about the favourite of
+// row with its description decrypted.
+func (r *Repository) openAuditDiff(row favouriteRow, diff []byte) (json.RawMessage, error) {
+	var changes map[string]string
+	if err := json.Unmarshal(diff, &changes); err != nil {
+		return nil, fmt.Errorf("unmarshalling audit diff: %w", err)
+	}
+	if _, ok := changes["description"]; !ok {
+		return diff, nil
+	}
+	if err := r.cipher.openChanges(row, changes); err != nil {
+		return nil, err
+	}
+	return json.Marshal(changes)
+}
+
+// EachVersionRecord calls fn for every favourite_versions row of a favourite
+// not deleted, in primary key order, with its data decrypted.
+func (r *Repository) EachVersionRecord(ctx context.Context, fn func(*VersionRecord) error) error {
+	const query = `
+		SELECT v.user_id, v.tenant_id, v.asset_id, v.version, v.data, v.replaced_at
+		FROM favourite_versions v
+		JOIN favourites f ON f.tenant_id = v.tenant_id AND f.user_id = v.user_id AND f.id = v.asset_id
+		WHERE f.deleted_at IS NULL
+		ORDER BY v.tenant_id, v.user_id, v.asset_id, v.version`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying favourite versions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec  VersionRecord
+			data []byte
+		)
+		if err := rows.Scan(&rec.UserID, &rec.TenantID, &rec.AssetID, &rec.Version, &data, &rec.ReplacedAt); err != nil {
+			return fmt.Errorf("scanning favourite version: %w", err)
+		}
+		if len(data) > 0 {
+			if rec.Data, err = r.cipher.openData(favouriteRow{rec.TenantID, rec.UserID, rec.AssetID}, data); err != nil {
+				return fmt.Errorf("decrypting version %d of favourite %s/%s: %w", rec.Version, rec.UserID, rec.AssetID, err)
+			}
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating favourite versions: %w", err)
+	}
+	return nil
+}
+
+// EachAuditRecord calls fn for every favourite_audit row, in ID order, with
+// the description in its diff decrypted.
+func (r *Repository) EachAuditRecord(ctx context.Context, fn func(*AuditRecord) error) error {
+	const query = `
+		SELECT id, user_id, tenant_id, actor, action, asset_id, diff, occurred_at
+		FROM favourite_audit
+		ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec  AuditRecord
+			diff []byte
+		)
+		if err := rows.Scan(&rec.ID, &rec.UserID, &rec.TenantID, &rec.Actor, &rec.Action, &rec.AssetID,
+			&diff, &rec.OccurredAt); err != nil {
+			return fmt.Errorf("scanning audit entry: %w", err)
+		}
+		if len(diff) > 0 {
+			if rec.Diff, err = r.openAuditDiff(favouriteRow{rec.TenantID, rec.UserID, rec.AssetID}, diff); err != nil {
+				return fmt.Errorf("decrypting audit entry %d: %w", rec.ID, err)
+			}
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating audit entries: %w", err)
+	}
+	return nil
+}
+
+// EachPreferenceRecord calls fn for every user_preferences row, in primary key order.
+func (r *Repository) EachPreferenceRecord(ctx context.Context, fn func(*PreferenceRecord) error) error {
+	const query = `SELECT user_id, tenant_id, timezone, updated_at FROM user_preferences ORDER BY tenant_id, user_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("querying user preferences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rec PreferenceRecord
+		if err := rows.Scan(&rec.UserID, &rec.TenantID, &rec.Timezone, &rec.UpdatedAt); err != nil {
+			return fmt.Errorf("scanning user preferences: %w", err)
+		}
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating user preferences: %w", err)
+	}
+	return nil
+}
+
+// pgRestorer is the Restorer of a Repository. It writes backup records in a
+// single transaction, so a failed restore leaves the database untouched.
+// Backups hold plaintext; the restored values are encrypted with the
+// current key, as writes are.
+// Existing catalog assets, favourites, versions and preferences are
+// overwritten; audit entries already present (by ID) are kept. Favourites
+// are written restoreBatchRows at a time.
+type pgRestorer struct {
+	cipher *ColumnCipher
+	tx     *sql.Tx
+	// favourites are waiting to be written with the next batch
+	favourites []FavouriteRecord
+}
+
+// restoreBatchRows is how many favourites a restore writes per statement,
+// keeping it within the 65535 parameters Postgres accepts.
+const restoreBatchRows = 500
+
+// BeginRestore starts a restore transaction. Favourites keep the updated_at
+// of the backup instead of taking the database's clock.
+func (r *Repository) BeginRestore(ctx context.Context) (Restorer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning restore: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SET LOCAL favourites.keep_updated_at = 'on'`); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("beginning restore: %w", err)
+	}
+	return &pgRestorer{cipher: r.cipher, tx: tx}, nil
+}
+
+// PutAsset inserts or replaces a catalog asset.
+func (r *pgRestorer) PutAsset(ctx context.Context, rec *AssetRecord) error {
+	const query = `
+		INSERT INTO assets (asset_type, id, data, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (asset_type, id) DO UPDATE
+		SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.tx.ExecContext(ctx, query, rec.AssetType, rec.ID, []byte(rec.Data), rec.UpdatedAt); err != nil {
+		return fmt.Errorf("restoring catalog asset %s/%s: %w", rec.AssetType, rec.ID, err)
+	}
+	return nil
+}
+
+// PutFavourite inserts or replaces a favourite. It is written with the next
+// batch, at the latest when a version is restored or the restore commits.
+func (r *pgRestorer) PutFavourite(ctx context.Context, rec *FavouriteRecord) error {
+	// A statement cannot write the same row twice
+	for _, pending := range r.favourites {
+		if pending.TenantID == rec.TenantID && pending.UserID == rec.UserID && pending.ID == rec.ID {
+			if err := r.flushFavourites(ctx); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	sealed, err := r.sealFavourite(*rec)
+	if err != nil {
+		return err
+	}
+	r.favourites = append(r.favourites, sealed)
+	if len(r.favourites) >= restoreBatchRows {
+		return r.flushFavourites(ctx)
+	}
+	return nil
+}
+
+// sealFavourite returns rec with its description, rendered description and
+// asset data encrypted, or escaped where needed, as writes store them.
+func (r *pgRestorer) sealFavourite(rec FavouriteRecord) (FavouriteRecord, error) {
+	row := favouriteRow{rec.TenantID, rec.UserID, rec.ID}
+	var err error
+	if rec.Description, err = r.cipher.sealText(row, descriptionColumn, rec.Description); err != nil {
+		return rec, fmt.Errorf("encrypting favourite %s/%s: %w", rec.UserID, rec.ID, err)
+	}
+	if rec.DescriptionHTML != nil {
+		html, err := r.cipher.sealText(row, descriptionHTMLColumn, *rec.DescriptionHTML)
+		if err != nil {
+			return rec, fmt.Errorf("encrypting favourite %s/%s: %w", rec.UserID, rec.ID, err)
+		}
+		rec.DescriptionHTML = &html
+	}
+	if data, ok := nullableJSON(rec.Data).([]byte); ok {
+		if rec.Data, err = r.cipher.sealData(row, data); err != nil {
+			return rec, fmt.Errorf("encrypting favourite %s/%s: %w", rec.UserID, rec.ID, err)
+		}
+	}
+	return rec, nil
+}
+
+// flushFavourites writes the pending favourites with a single statement.
+func (r *pgRestorer) flushFavourites(ctx context.Context) error {
+	if len(r.favourites) == 0 {
+		return nil
+	}
+	var values strings.Builder
+	args := make([]any, 0, len(r.favourites)*favouriteColumns)
+	for i, rec := range r.favourites {
+		writeFavouriteValues(&values, i)
+		args = append(args, rec.ID, rec.UserID, rec.AssetType, rec.Description,
+			nullableJSON(rec.Data), rec.CreatedAt, rec.UpdatedAt,
+			rec.SourceSystem, rec.SourceURL, rec.FavouritedFrom, rec.DescriptionHTML, rec.TenantID)
+	}
+
+	// Unlike AddFavouritesBatch, live favourites are overwritten too
+	query := insertFavourites + values.String() + `
+		ON CONFLICT (tenant_id, user_id, id) DO UPDATE
+		SET asset_type = EXCLUDED.asset_type, description = EXCLUDED.description,
+		    data = EXCLUDED.data, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+		    source_system = EXCLUDED.source_system, source_url = EXCLUDED.source_url,
+		    favourited_from = EXCLUDED.favourited_from, description_html = EXCLUDED.description_html,
+		    deleted_at = NULL`
+
+	if _, err := r.tx.ExecContext(ctx, query, args...); err != nil {
+		first, last := r.favourites[0], r.favourites[len(r.favourites)-1]
+		return fmt.Errorf("restoring favourites %s/%s to %s/%s: %w", first.UserID, first.ID, last.UserID, last.ID, err)
+	}
+	r.favourites = r.favourites[:0]
+	return nil
+}
+
+// PutVersion inserts or replaces a favourite version. Its favourite must have
+// been restored first.
+func (r *pgRestorer) PutVersion(ctx context.Context, rec *VersionRecord) error {
+	if err := r.flushFavourites(ctx); err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO favourite_versions (user_id, asset_id, version, data, replaced_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, user_id, asset_id, version) DO UPDATE
+		SET data = EXCLUDED.data, replaced_at = EXCLUDED.replaced_at`
+
+	data := nullableJSON(rec.Data)
+	if plaintext, ok := data.([]byte); ok {
+		sealed, err := r.cipher.sealData(favouriteRow{rec.TenantID, rec.UserID, rec.AssetID}, plaintext)
+		if err != nil {
+			return fmt.Errorf("encrypting version %d of favourite %s/%s: %w", rec.Version, rec.UserID, rec.AssetID, err)
+		}
+		data = sealed
+	}
+	if _, err := r.tx.ExecContext(ctx, query, rec.UserID, rec.AssetID, rec.Version,
+		data, rec.ReplacedAt, rec.TenantID); err != nil {
+		return fmt.Errorf("restoring version %d of favourite %s/%s: %w", rec.Version, rec.UserID, rec.AssetID, err)
+	}
+	return nil
+}
+
+// PutAuditEntry inserts an audit entry with its original ID unless that ID exists.
+func (r *pgRestorer) PutAuditEntry(ctx context.Context, rec *AuditRecord) error {
+	const query = `
+		INSERT INTO favourite_audit (id, user_id, actor, action, asset_id, diff, occurred_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING`
+
+	diff, err := r.sealAuditDiff(favouriteRow{rec.TenantID, rec.UserID, rec.AssetID}, rec.Diff)
+	if err != nil {
+		return fmt.Errorf("encrypting audit entry %d: %w", rec.ID, err)
+	}
+	if _, err := r.tx.ExecContext(ctx, query, rec.ID, rec.UserID, rec.Actor, rec.Action,
+		rec.AssetID, nullableJSON(diff), rec.OccurredAt, rec.TenantID); err != nil {
+		return fmt.Errorf("restoring audit entry %d: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// sealAuditDiff returns the diff of an audit entry about the favourite of
+// row with its description encrypted, as InsertAuditEntry stores it.
+func (r *pgRestorer) sealAuditDiff(row favouriteRow, diff json.RawMessage) (json.RawMessage, error) {
+	if r.cipher == nil || nullableJSON(diff) == nil {
+		return diff, nil
+	}
+	var changes map[string]string
+	if err := json.Unmarshal(diff, &changes); err != nil {
+		return nil, fmt.Errorf("unmarshalling audit diff: %w", err)
+	}
+	if _, ok := changes["description"]; !ok {
+		return diff, nil
+	}
+	sealed, err := r.cipher.sealChanges(row, changes)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+// PutPreference inserts or replaces a user's preferences.
+func (r *pgRestorer) PutPreference(ctx context.Context, rec *PreferenceRecord) error {
+	const query = `
+		INSERT INTO user_preferences (user_id, timezone, updated_at, tenant_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE
+		SET timezone = EXCLUDED.timezone, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.tx.ExecContext(ctx, query, rec.UserID, rec.Timezone, rec.UpdatedAt, rec.TenantID); err != nil {
+		return fmt.Errorf("restoring preferences of %s: %w", rec.UserID, err)
+	}
+	return nil
+}
+
+// Commit moves the audit ID sequence past the restored IDs, so new entries do
+// not collide with them, and commits the restore.
+func (r *pgRestorer) Commit(ctx context.Context) error {
+	if err := r.flushFavourites(ctx); err != nil {
+		r.tx.Rollback()
+		return err
+	}
+	const query = `
+		SELECT setval(pg_get_serial_sequence('favourite_audit', 'id'),
+		              COALESCE(MAX(id), 1), MAX(id) IS NOT NULL)
+		FROM favourite_audit`
+
+	if _, err := r.tx.ExecContext(ctx, query); err != nil {
+		r.tx.Rollback()
+		return fmt.Errorf("resetting audit id sequence: %w", err)
+	}
+	if err := r.tx.Commit(); err != nil {
+		return fmt.Errorf("committing restore: %w", err)
+	}
+	return nil
+}
+
+// Rollback abandons the restore.
+func (r *pgRestorer) Rollback() error {
+	return r.tx.Rollback()
+}
+
+// nullableJSON maps an absent JSON value to SQL NULL.
+func nullableJSON(v json.RawMessage) any {
+	if len(v) == 0 || string(v) == "null" {
+		return nil
+	}
+	return []byte(v)
+}
@@ -0,0 +1,308 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// recordCols are the columns EachFavouriteRecord selects.
+var recordCols = []string{"id", "user_id", "tenant_id", "asset_type", "description", "data", "created_at", "updated_at", "source_system", "source_url", "favourited_from", "description_html"}
+
+func TestEachFavouriteRecord(t *testing.T) {
+	now := time.Now()
+
+	t.Run("visits every row and maps NULLs", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE deleted_at IS NULL ORDER BY tenant_id, user_id, id").
+			WillReturnRows(sqlmock.NewRows(recordCols).
+				AddRow("c1", "user1", "", "chart", nil, testChartJSON("c1"), now, now, nil, nil, nil, nil).
+				AddRow("c2", "user2", "acme", "chart", "d", nil, now, now, nil, nil, nil, nil))
+
+		var got []FavouriteRecord
+		err := repo.EachFavouriteRecord(context.Background(), func(rec *FavouriteRecord) error {
+			got = append(got, *rec)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(got))
+		}
+		if got[0].Description != "" || len(got[0].Data) == 0 {
+			t.Errorf("unexpected first record: %+v", got[0])
+		}
+		if got[1].TenantID != "acme" {
+			t.Errorf("expected the tenant of the second record, got %q", got[1].TenantID)
+		}
+		if got[1].Data != nil {
+			t.Errorf("expected NULL data to stay nil, got %s", got[1].Data)
+		}
+	})
+
+	t.Run("stops on callback error", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites").
+			WillReturnRows(sqlmock.NewRows(recordCols).
+				AddRow("c1", "user1", "", "chart", "", testChartJSON("c1"), now, now, nil, nil, nil, nil).
+				AddRow("c2", "user1", "", "chart", "", testChartJSON("c2"), now, now, nil, nil, nil, nil))
+
+		stop := errors.New("stop")
+		calls := 0
+		err := repo.EachFavouriteRecord(context.Background(), func(*FavouriteRecord) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Errorf("expected to stop after first record, got calls=%d err=%v", calls, err)
+		}
+	})
+
+	t.Run("returns error on query failure", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites").WillReturnError(fmt.Errorf("connection failed"))
+
+		if err := repo.EachFavouriteRecord(context.Background(), func(*FavouriteRecord) error { return nil }); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestRestorer(t *testing.T) {
+	now := time.Now()
+
+	t.Run("writes records and resets audit sequence on commit", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL favourites.keep_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO favourite_audit .+ ON CONFLICT \\(id\\) DO NOTHING").
+			WithArgs(int64(3), "user1", "admin1", "update", "c1", []byte(`{"description":"x"}`), now, "").
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		// Favourites are batched until the commit
+		mock.ExpectExec(`INSERT INTO favourites .+ VALUES \(\$1, .+\),\s+\(\$13, .+\$24\)\s+ON CONFLICT \(tenant_id, user_id, id\) DO UPDATE`).
+			WithArgs("c1", "user1", "chart", "", nil, now, now, "", "", "", nil, "",
+				"c2", "user1", "chart", "", nil, now, now, "", "", "", nil, "").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("SELECT setval").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		ctx := context.Background()
+		r, err := repo.BeginRestore(ctx)
+		if err != nil {
+			t.Fatalf("BeginRestore: %v", err)
+		}
+		for _, id := range []string{"c1", "c2"} {
+			if err := r.PutFavourite(ctx, &FavouriteRecord{ID: id, UserID: "user1", AssetType: "chart", Data: []byte("null"), CreatedAt: now, UpdatedAt: now}); err != nil {
+				t.Fatalf("PutFavourite: %v", err)
+			}
+		}
+		if err := r.PutAuditEntry(ctx, &AuditRecord{ID: 3, UserID: "user1", Actor: "admin1", Action: "update", AssetID: "c1", Diff: []byte(`{"description":"x"}`), OccurredAt: now}); err != nil {
+			t.Fatalf("PutAuditEntry: %v", err)
+		}
+		if err := r.Commit(ctx); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("writes favourites before their versions and repeats apart", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL favourites.keep_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO favourites .+ VALUES \(\$1, .+\$12\)\s+ON CONFLICT`).
+			WithArgs("c1", "user1", "chart", "first", nil, now, now, "", "", "", nil, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO favourites .+ VALUES \(\$1, .+\$12\)\s+ON CONFLICT`).
+			WithArgs("c1", "user1", "chart", "second", nil, now, now, "", "", "", nil, "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO favourite_versions").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("SELECT setval").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		ctx := context.Background()
+		r, err := repo.BeginRestore(ctx)
+		if err != nil {
+			t.Fatalf("BeginRestore: %v", err)
+		}
+		for _, description := range []string{"first", "second"} {
+			if err := r.PutFavourite(ctx, &FavouriteRecord{ID: "c1", UserID: "user1", AssetType: "chart", Description: description, CreatedAt: now, UpdatedAt: now}); err != nil {
+				t.Fatalf("PutFavourite: %v", err)
+			}
+		}
+		if err := r.PutVersion(ctx, &VersionRecord{UserID: "user1", AssetID: "c1", Version: 1, Data: []byte(`{}`), ReplacedAt: now}); err != nil {
+			t.Fatalf("PutVersion: %v", err)
+		}
+		if err := r.Commit(ctx); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rolls back when sequence reset fails", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL favourites.keep_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SELECT setval").WillReturnError(fmt.Errorf("permission denied"))
+		mock.ExpectRollback()
+
+		ctx := context.Background()
+		r, err := repo.BeginRestore(ctx)
+		if err != nil {
+			t.Fatalf("BeginRestore: %v", err)
+		}
+		if err := r.Commit(ctx); err == nil {
+			t.Fatal("expected error")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestEachRecord_Decrypts(t *testing.T) {
+	now := time.Now()
+	cipher := testColumnCipher(t, "k1", "k1")
+	repo, mock := setupTestDBWith(t, Options{Cipher: cipher})
+	row := favouriteRow{"acme", "user1", "c1"}
+	description, html, err := cipher.sealDescription(row, "secret", "<p>secret</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := cipher.sealData(row, testChartJSON("c1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := cipher.sealChanges(row, map[string]string{"description": "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, _ := json.Marshal(changes)
+
+	mock.ExpectQuery("SELECT .+ FROM favourites").
+		WillReturnRows(sqlmock.NewRows(recordCols).
+			AddRow("c1", "user1", "acme", "chart", description, data, now, now, nil, nil, nil, html).
+			AddRow("c2", "user1", "acme", "chart", "enc:raw:enc:plain", nil, now, now, nil, nil, nil, nil))
+	mock.ExpectQuery("SELECT .+ FROM favourite_versions").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "tenant_id", "asset_id", "version", "data", "replaced_at"}).
+			AddRow("user1", "acme", "c1", 1, data, now))
+	mock.ExpectQuery("SELECT .+ FROM favourite_audit").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "tenant_id", "actor", "action", "asset_id", "diff", "occurred_at"}).
+			AddRow(int64(1), "user1", "acme", "user1", "add", "c1", diff, now))
+
+	var favourites []FavouriteRecord
+	if err := repo.EachFavouriteRecord(context.Background(), func(rec *FavouriteRecord) error {
+		favourites = append(favourites, *rec)
+		return nil
+	}); err != nil {
+		t.Fatalf("EachFavouriteRecord: %v", err)
+	}
+	if got := favourites[0]; got.Description != "secret" || *got.DescriptionHTML != "<p>secret</p>" || string(got.Data) != string(testChartJSON("c1")) {
+		t.Errorf("expected the first favourite decrypted, got %+v", got)
+	}
+	if got := favourites[1].Description; got != "enc:plain" {
+		t.Errorf("expected the escaped description unescaped, got %q", got)
+	}
+	if err := repo.EachVersionRecord(context.Background(), func(rec *VersionRecord) error {
+		if string(rec.Data) != string(testChartJSON("c1")) {
+			t.Errorf("expected the version data decrypted, got %s", rec.Data)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("EachVersionRecord: %v", err)
+	}
+	if err := repo.EachAuditRecord(context.Background(), func(rec *AuditRecord) error {
+		if string(rec.Diff) != `{"description":"secret"}` {
+			t.Errorf("expected the audit diff decrypted, got %s", rec.Diff)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("EachAuditRecord: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRestorer_Encrypts(t *testing.T) {
+	now := time.Now()
+	cipher := testColumnCipher(t, "k2", "k1", "k2")
+	repo, mock := setupTestDBWith(t, Options{Cipher: cipher})
+	var description, html, data, versionData, diff capturedArg
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL favourites.keep_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO favourites").
+		WithArgs("c1", "user1", "chart", &description, &data, now, now, "", "", "", &html, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO favourite_versions").
+		WithArgs("user1", "c1", 1, &versionData, now, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO favourite_audit").
+		WithArgs(int64(3), "user1", "user1", "update", "c1", &diff, now, "acme").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("SELECT setval").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	r, err := repo.BeginRestore(ctx)
+	if err != nil {
+		t.Fatalf("BeginRestore: %v", err)
+	}
+	rendered := "<p>secret</p>"
+	if err := r.PutFavourite(ctx, &FavouriteRecord{ID: "c1", UserID: "user1", TenantID: "acme", AssetType: "chart",
+		Description: "secret", DescriptionHTML: &rendered, Data: testChartJSON("c1"), CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("PutFavourite: %v", err)
+	}
+	if err := r.PutVersion(ctx, &VersionRecord{UserID: "user1", TenantID: "acme", AssetID: "c1", Version: 1, Data: testChartJSON("c1"), ReplacedAt: now}); err != nil {
+		t.Fatalf("PutVersion: %v", err)
+	}
+	if err := r.PutAuditEntry(ctx, &AuditRecord{ID: 3, UserID: "user1", TenantID: "acme", Actor: "user1", Action: "update", AssetID: "c1",
+		Diff: []byte(`{"description":"secret"}`), OccurredAt: now}); err != nil {
+		t.Fatalf("PutAuditEntry: %v", err)
+	}
+	if err := r.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+
+	// Everything is stored under the current key, bound to the restored favourite
+	row := favouriteRow{"acme", "user1", "c1"}
+	for _, v := range []struct {
+		column, value, want string
+	}{
+		{descriptionColumn, description.value.(string), "secret"},
+		{descriptionHTMLColumn, html.value.(string), rendered},
+	} {
+		if !strings.HasPrefix(v.value, encryptedPrefix+"k2:") {
+			t.Errorf("expected %s encrypted under k2, got %q", v.column, v.value)
+		}
+		if got, err := cipher.openText(row, v.column, v.value); err != nil || got != v.want {
+			t.Errorf("%s: expected %q, got %q, %v", v.column, v.want, got, err)
+		}
+	}
+	for name, arg := range map[string]*capturedArg{"data": &data, "version data": &versionData} {
+		got, err := cipher.openData(row, arg.value.([]byte))
+		if err != nil || string(got) != string(testChartJSON("c1")) || string(got) == string(arg.value.([]byte)) {
+			t.Errorf("expected %s encrypted, got %s (%s, %v)", name, arg.value, got, err)
+		}
+	}
+	var changes map[string]string
+	if err := json.Unmarshal(diff.value.([]byte), &changes); err != nil {
+		t.Fatal(err)
+	}
+	if err := cipher.openChanges(row, changes); err != nil || changes["description"] != "secret" || string(diff.value.([]byte)) == `{"description":"secret"}` {
+		t.Errorf("expected the audit diff encrypted, got %s (%v)", diff.value, err)
+	}
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// batchInsertRows is how many favourites one INSERT of
+// AddFavouritesBatch carries, well below the 65535 parameters Postgres
+// accepts per statement.
+var batchInsertRows = 1000
+
+// AddFavouritesBatch inserts favourites, which may belong to different
+// users, within the transaction, with one multi-row INSERT per
+// batchInsertRows favourites instead of a round trip each. Favourites that
+// already exist, or repeat an earlier one of the batch, are skipped and
+// returned as conflicts. Deleted favourites are replaced as by AddFavourite.
+// Quotas are not checked. All favourites are stored in the tenant of ctx.
+func (t *pgTx) AddFavouritesBatch(ctx context.Context, favourites []*models.FavouriteAsset) (conflicts []*models.FavouriteAsset, err error) {
+	inserted := 0
+	defer t.r.observe(ctx, "add_favourites_batch", time.Now(), &err, func() int { return inserted })
+
+	// Repeats are left out: reviving a deleted favourite twice in one
+	// statement is an error
+	seen := make(map[favouriteKey]bool, len(favourites))
+	unique := make([]*models.FavouriteAsset, 0, len(favourites))
+	for _, fav := range favourites {
+		key := favouriteKey{userID: fav.UserID, assetID: fav.ID}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, fav)
+		}
+	}
+
+	stored := make(map[favouriteKey]bool)
+	for start := 0; start < len(unique); start += batchInsertRows {
+		chunk := unique[start:min(start+batchInsertRows, len(unique))]
+		if err := t.r.insertFavouritesChunk(ctx, t.tx, chunk, stored); err != nil {
+			return nil, err
+		}
+	}
+
+	// Each stored key accounts for its first favourite in the batch
+	for _, fav := range favourites {
+		key := favouriteKey{userID: fav.UserID, assetID: fav.ID}
+		if stored[key] {
+			delete(stored, key)
+			inserted++
+			continue
+		}
+		conflicts = append(conflicts, fav)
+	}
+	return conflicts, nil
+}
+
+// insertFavourites starts a multi-row INSERT of favourites, whose rows
+// writeFavouriteValues adds.
+const insertFavourites = `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES `
+
+// favouriteColumns is the number of columns of insertFavourites.
+const favouriteColumns = 12
+
+// writeFavouriteValues writes the placeholders of the i-th row of
+// insertFavourites: the ID, user ID, asset type, description, data,
+// creation and update times, provenance fields (NULL when empty), rendered
+// description and tenant.
+func writeFavouriteValues(values *strings.Builder, i int) {
+	if i > 0 {
+		values.WriteString(",\n\t\t       ")
+	}
+	n := i * favouriteColumns
+	fmt.Fprintf(values, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, NULLIF($%d, ''), NULLIF($%d, ''), NULLIF($%d, ''), $%d, $%d)",
+		n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12)
+}
+
+// favouriteKey identifies a favourite by its primary key.
+type favouriteKey struct {
+	userID, assetID string
+}
+
+// insertFavouritesChunk inserts favourites with a single statement, adding
+// the keys of those actually inserted to stored and setting their UpdatedAt
+// to the one stored.
+func (r *Repository) insertFavouritesChunk(ctx context.Context, tx *sql.Tx, favourites []*models.FavouriteAsset, stored map[favouriteKey]bool) error {
+	tenant := TenantFromContext(ctx)
+	var values strings.Builder
+	args := make([]any, 0, len(favourites)*favouriteColumns)
+	var catalog []any
+	for i, fav := range favourites {
+		dataJSON, err := json.Marshal(fav.Data)
+		if err != nil {
+			return fmt.Errorf("marshalling asset data of %s: %w", fav.ID, err)
+		}
+		row := favouriteRow{tenant: tenant, user: fav.UserID, id: fav.ID}
+		description, descriptionHTML, err := r.cipher.sealDescription(row, fav.Description, fav.DescriptionHTML)
+		if err != nil {
+			return err
+		}
+		var data any // NULL for favourites referencing the catalog
+		if r.storage == StorageNormalized {
+			// The data goes to the shared catalog, which is not encrypted
+			catalog = append(catalog, string(fav.AssetType), fav.ID, dataJSON, fav.UpdatedAt)
+		} else if data, err = r.cipher.sealData(row, dataJSON); err != nil {
+			return err
+		}
+
+		writeFavouriteValues(&values, i)
+		args = append(args,
+			fav.ID, fav.UserID, string(fav.AssetType),
+			description, data,
+			fav.CreatedAt, fav.UpdatedAt,
+			fav.SourceSystem, fav.SourceURL, fav.FavouritedFrom,
+			descriptionHTML, tenant,
+		)
+	}
+
+	if len(catalog) > 0 {
+		// Existing catalog entries are kept: they are the canonical version
+		placeholders := make([]string, len(catalog)/4)
+		for i := range placeholders {
+			placeholders[i] = fmt.Sprintf("($%d, $%d, $%d, $%d)", i*4+1, i*4+2, i*4+3, i*4+4)
+		}
+		query := `
+		INSERT INTO assets (asset_type, id, data, updated_at)
+		VALUES ` + strings.Join(placeholders, ", ") + `
+		ON CONFLICT (asset_type, id) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, catalog...); err != nil {
+			return fmt.Errorf("inserting catalog assets: %w", err)
+		}
+	}
+
+	query := insertFavourites + values.String() +
+		reviveDeletedFavourite + `
+		RETURNING user_id, id, updated_at`
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("inserting favourites: %w", err)
+	}
+	defer rows.Close()
+
+	byKey := make(map[favouriteKey]*models.FavouriteAsset, len(favourites))
+	for _, fav := range favourites {
+		byKey[favouriteKey{userID: fav.UserID, assetID: fav.ID}] = fav
+	}
+	for rows.Next() {
+		var key favouriteKey
+		var updatedAt time.Time
+		if err := rows.Scan(&key.userID, &key.assetID, &updatedAt); err != nil {
+			return fmt.Errorf("scanning inserted favourite: %w", err)
+		}
+		stored[key] = true
+		if fav := byKey[key]; fav != nil {
+			fav.UpdatedAt = updatedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("inserting favourites: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// storedAt is the updated_at the database returns for inserted favourites.
+var storedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func batchFavourites(userID string, ids ...string) []*models.FavouriteAsset {
+	now := time.Now()
+	favs := make([]*models.FavouriteAsset, len(ids))
+	for i, id := range ids {
+		favs[i] = &models.FavouriteAsset{
+			ID: id, UserID: userID, AssetType: models.AssetTypeChart,
+			Data:      &models.Chart{ID: id, Title: "T", XAxisTitle: "X", YAxisTitle: "Y"},
+			CreatedAt: now, UpdatedAt: now,
+		}
+	}
+	return favs
+}
+
+// addFavouritesBatch runs Tx.AddFavouritesBatch in a transaction of repo.
+func addFavouritesBatch(repo *Repository, favourites []*models.FavouriteAsset) (conflicts []*models.FavouriteAsset, err error) {
+	err = repo.WithTx(context.Background(), func(tx Tx) error {
+		conflicts, err = tx.AddFavouritesBatch(context.Background(), favourites)
+		return err
+	})
+	return conflicts, err
+}
+
+func TestAddFavouritesBatch(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	favs := batchFavourites("user1", "c1", "c2", "c1", "c3")
+
+	mock.ExpectBegin()
+	// c2 exists already and the second c1, which repeats the first, is left out
+	mock.ExpectQuery(`INSERT INTO favourites .+ VALUES \(\$1, .+\),\s+\(\$13, .+\),\s+\(\$25, .+\$36\)\s+ON CONFLICT \(tenant_id, user_id, id\) DO UPDATE .+ WHERE favourites.deleted_at IS NOT NULL\s+RETURNING user_id, id`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "updated_at"}).AddRow("user1", "c1", storedAt).AddRow("user1", "c3", storedAt))
+	mock.ExpectCommit()
+
+	conflicts, err := addFavouritesBatch(repo, favs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 2 || conflicts[0] != favs[1] || conflicts[1] != favs[2] {
+		t.Errorf("expected c2 and the repeated c1 as conflicts, got %+v", conflicts)
+	}
+	if !favs[0].UpdatedAt.Equal(storedAt) || !favs[3].UpdatedAt.Equal(storedAt) {
+		t.Errorf("expected the stored updated_at on inserted favourites, got %v and %v", favs[0].UpdatedAt, favs[3].UpdatedAt)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddFavouritesBatch_Chunks(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	batchInsertRows = 2
+	t.Cleanup(func() { batchInsertRows = 1000 })
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO favourites .+\$24\)\s+ON CONFLICT`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "updated_at"}).AddRow("user1", "c1", storedAt).AddRow("user1", "c2", storedAt))
+	mock.ExpectQuery(`INSERT INTO favourites .+VALUES \(\$1, .+\$12\)\s+ON CONFLICT`).
+		WithArgs("c3", "user1", "chart", "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", "", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "updated_at"}).AddRow("user1", "c3", storedAt))
+	mock.ExpectCommit()
+
+	conflicts, err := addFavouritesBatch(repo, batchFavourites("user1", "c1", "c2", "c3"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", conflicts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddFavouritesBatch_Normalized(t *testing.T) {
+	repo, mock := setupTestDBWith(t, Options{AssetStorage: StorageNormalized})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO assets \(asset_type, id, data, updated_at\)\s+VALUES \(\$1, \$2, \$3, \$4\), \(\$5, \$6, \$7, \$8\)\s+ON CONFLICT \(asset_type, id\) DO NOTHING`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("INSERT INTO favourites").
+		WithArgs("c1", "user1", "chart", "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", "", "", "",
+			"c2", "user1", "chart", "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", "", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "updated_at"}).AddRow("user1", "c1", storedAt).AddRow("user1", "c2", storedAt))
+	mock.ExpectCommit()
+
+	if _, err := addFavouritesBatch(repo, batchFavourites("user1", "c1", "c2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddFavouritesBatch_Error(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO favourites").WillReturnError(errors.New("value too long"))
+	mock.ExpectRollback()
+
+	if _, err := addFavouritesBatch(repo, batchFavourites("user1", "c1")); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
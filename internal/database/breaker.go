@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of connecting to a database whose
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("database circuit breaker open")
+
+// Circuit breaker states, as reported by Breaker.State.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// Breaker stops connection attempts to a database that keeps failing them.
+// After threshold consecutive attempts fail with the database unavailable, it
+// opens: attempts fail at once with ErrCircuitOpen, so during an outage
+// requests are answered immediately instead of each waiting for a connect
+// timeout. Once cooldown has passed it lets a single probe through, closing
+// again if the probe succeeds and reopening for another cooldown if not.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed Breaker opening after threshold consecutive
+// failures, for cooldown at a time.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: max(threshold, 1), cooldown: cooldown, now: time.Now}
+}
+
+// State returns BreakerClosed, BreakerOpen or BreakerHalfOpen, the latter once
+// the cooldown has passed and a probe is due or under way.
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *Breaker) state() string {
+	switch {
+	case b.failures < b.threshold:
+		return BreakerClosed
+	case b.probing || b.now().Sub(b.openedAt) >= b.cooldown:
+		return BreakerHalfOpen
+	default:
+		return BreakerOpen
+	}
+}
+
+// Allow returns ErrCircuitOpen when an attempt must not be made. When half
+// open, only the first caller is allowed, as the probe; its outcome must be
+// passed to Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state() {
+	case BreakerClosed:
+		return nil
+	case BreakerHalfOpen:
+		if !b.probing {
+			b.probing = true
+			return nil
+		}
+	}
+	return ErrCircuitOpen
+}
+
+// Record counts the outcome of an allowed attempt. Only errors meaning the
+// database is unavailable count as failures; an attempt abandoned by its
+// caller counts as neither.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if !IsUnavailable(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
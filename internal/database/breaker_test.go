@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	unavailable := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	attempt := func(err error) {
+		t.Helper()
+		if allowErr := b.Allow(); allowErr != nil {
+			t.Fatalf("expected the attempt to be allowed, got %v", allowErr)
+		}
+		b.Record(err)
+	}
+	expectState := func(want string) {
+		t.Helper()
+		if got := b.State(); got != want {
+			t.Fatalf("expected state %s, got %s", want, got)
+		}
+	}
+
+	// Statement errors and successes do not count towards opening.
+	attempt(unavailable)
+	attempt(errors.New("syntax error"))
+	attempt(unavailable)
+	expectState(BreakerClosed)
+
+	attempt(unavailable)
+	expectState(BreakerOpen)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) || !IsUnavailable(fmt.Errorf("querying: %w", err)) {
+		t.Fatalf("expected ErrCircuitOpen while open, got %v", err)
+	}
+
+	// After the cooldown one probe is let through; a failed probe reopens.
+	now = now.Add(10 * time.Second)
+	expectState(BreakerHalfOpen)
+	attempt(unavailable)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the breaker to reopen after a failed probe, got %v", err)
+	}
+
+	// Only one probe at a time; a successful probe closes the breaker.
+	now = now.Add(10 * time.Second)
+	attempt(nil)
+	expectState(BreakerClosed)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected attempts allowed once closed, got %v", err)
+	}
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(1, time.Second)
+	b.now = func() time.Time { return now }
+	b.Record(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+
+	now = now.Add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected the probe to be allowed, got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a second attempt refused during the probe, got %v", err)
+	}
+
+	// A probe abandoned by its caller leaves the breaker half open.
+	b.Record(context.Canceled)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected another probe after a cancelled one, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrStatementTimeout is returned when a query is cancelled by its class's
+// statement_timeout, as opposed to the caller's context.
+var ErrStatementTimeout = errors.New("query exceeded its time budget")
+
+// QueryClass groups queries by how much database time one request may spend
+// on them, so a single expensive scan cannot hold a connection for long.
+type QueryClass string
+
+const (
+	// QuerySearch covers pattern searches across all users.
+	QuerySearch QueryClass = "search"
+	// QueryReport covers reports that scan favourites across all users.
+	QueryReport QueryClass = "report"
+)
+
+var statementTimeouts = map[QueryClass]time.Duration{
+	QuerySearch: 2 * time.Second,
+	QueryReport: 5 * time.Second,
+}
+
+// StatementTimeout returns the statement_timeout applied to class.
+func StatementTimeout(class QueryClass) time.Duration {
+	return statementTimeouts[class]
+}
+
+// queryWithBudget runs a read-only query in a transaction whose
+// statement_timeout is the class budget and hands the rows to scan. A query
+// cancelled by that timeout fails with ErrStatementTimeout.
+func (r *Repository) queryWithBudget(ctx context.Context, class QueryClass, scan func(*sql.Rows) error, query string, args ...any) error {
+	budget := StatementTimeout(class)
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("beginning %s query: %w", class, err)
+	}
+	defer tx.Rollback()
+
+	// SET does not take parameters; budget is a trusted constant.
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", budget.Milliseconds())); err != nil {
+		return fmt.Errorf("setting statement timeout: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err == nil {
+		err = scan(rows)
+		rows.Close()
+	}
+	if err != nil && isQueryCanceled(err) && ctx.Err() == nil {
+		return fmt.Errorf("%w of %s", ErrStatementTimeout, budget)
+	}
+	return err
+}
+
+// isQueryCanceled checks if a PostgreSQL error is a cancelled statement (57014),
+// raised both by statement_timeout and by context cancellation.
+func isQueryCanceled(err error) bool {
+	var pge *pq.Error
+	if errors.As(err, &pge) {
+		return pge.Code == "57014"
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// expectBudget expects the read-only transaction and statement_timeout that
+// queryWithBudget sets up for class.
+func expectBudget(mock sqlmock.Sqlmock, class QueryClass) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(fmt.Sprintf("SET LOCAL statement_timeout = %d", StatementTimeout(class).Milliseconds()))).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestQueryWithBudget(t *testing.T) {
+	canceled := &pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"}
+
+	t.Run("reports statement timeouts", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		expectBudget(mock, QuerySearch)
+		mock.ExpectQuery("SELECT user_id").WillReturnError(canceled)
+		mock.ExpectRollback()
+
+		_, err := repo.SearchFavouriteUsers(context.Background(), "a", 10)
+		if !errors.Is(err, ErrStatementTimeout) {
+			t.Fatalf("expected ErrStatementTimeout, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("leaves caller cancellation alone", func(t *testing.T) {
+		repo, _ := setupTestDB(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.GetAssetOwners(ctx, []string{"c1"})
+		if err == nil || errors.Is(err, ErrStatementTimeout) {
+			t.Fatalf("expected a plain error for a cancelled context, got %v", err)
+		}
+	})
+
+	t.Run("passes other errors through", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		expectBudget(mock, QueryReport)
+		mock.ExpectQuery("SELECT id").WillReturnError(fmt.Errorf("connection failed"))
+		mock.ExpectRollback()
+
+		_, err := repo.GetAssetOwners(context.Background(), []string{"c1"})
+		if err == nil || errors.Is(err, ErrStatementTimeout) {
+			t.Fatalf("expected a plain error, got %v", err)
+		}
+	})
+}
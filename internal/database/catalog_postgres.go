@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ErrAssetNotFound is returned when an asset is not in the catalog.
+var ErrAssetNotFound = errors.New("asset not found")
+
+// AssetStorage selects where new favourites keep their asset data.
+type AssetStorage string
+
+const (
+	// StorageEmbedded stores a full copy of the asset data in every favourite.
+	StorageEmbedded AssetStorage = "embedded"
+	// StorageNormalized stores each asset once in the assets table; favourites
+	// reference it by (asset_type, id), so catalog updates reach every user.
+	StorageNormalized AssetStorage = "normalized"
+)
+
+// favouriteDataColumn selects a favourite's own data, falling back to its
+// catalog entry for favourites stored in normalized mode (data IS NULL).
+const favouriteDataColumn = `COALESCE(data, (
+		SELECT a.data FROM assets a
+		WHERE a.asset_type = favourites.asset_type AND a.id = favourites.id))`
+
+// insertNormalizedFavouriteQuery adds the asset to the catalog unless it is
+// already there, and inserts a favourite referencing it. An existing catalog
+// entry is kept as is: it is the canonical version of the asset.
+const insertNormalizedFavouriteQuery = `
+		WITH catalog AS (
+			INSERT INTO assets (asset_type, id, data, updated_at)
+			VALUES ($3, $1, $5, $7)
+			ON CONFLICT (asset_type, id) DO NOTHING
+		)
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES ($1, $2, $3, $4, NULL, $6, $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11, $12)` +
+	reviveDeletedFavourite
+
+// UpdateCatalogAsset replaces the catalog entry of asset and returns the
+// favourites that reference it, whose updated_at the database advances.
+// Favourites holding their own copy of the data are not affected.
+func (r *Repository) UpdateCatalogAsset(ctx context.Context, asset models.Asset, updatedAt time.Time) (owners []AssetOwnership, err error) {
+	err = r.WithStoreTx(ctx, func(tx StoreTx) error {
+		owners, err = tx.UpdateCatalogAsset(ctx, asset, updatedAt)
+		return err
+	})
+	return owners, err
+}
+
+// updateCatalogAsset is UpdateCatalogAsset within tx.
+func updateCatalogAsset(ctx context.Context, tx *sql.Tx, asset models.Asset, updatedAt time.Time) ([]AssetOwnership, error) {
+	dataJSON, err := json.Marshal(asset)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling asset data: %w", err)
+	}
+
+	const updateQuery = `
+		UPDATE assets SET data = $3, updated_at = $4
+		WHERE asset_type = $1 AND id = $2`
+
+	result, err := tx.ExecContext(ctx, updateQuery, string(asset.GetType()), asset.GetID(), dataJSON, updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("updating catalog asset: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, ErrAssetNotFound
+	}
+
+	const touchQuery = `
+		UPDATE favourites SET updated_at = $3
+		WHERE asset_type = $1 AND id = $2 AND data IS NULL AND deleted_at IS NULL
+		RETURNING id, user_id, asset_type`
+
+	rows, err := tx.QueryContext(ctx, touchQuery, string(asset.GetType()), asset.GetID(), updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("touching catalog favourites: %w", err)
+	}
+	return scanOwnerships(rows)
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestAddFavourite_Normalized(t *testing.T) {
+	now := time.Now()
+	fav := &models.FavouriteAsset{
+		ID: "c1", UserID: "user1", AssetType: "chart",
+		CreatedAt: now, UpdatedAt: now,
+		Data: &models.Chart{ID: "c1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y"},
+	}
+
+	repo, mock := setupTestDBWith(t, Options{AssetStorage: StorageNormalized})
+	mock.ExpectQuery("WITH catalog AS .+ INSERT INTO assets .+ DO NOTHING .+ INSERT INTO favourites").
+		WillReturnRows(updatedAtRows())
+
+	if err := repo.AddFavourite(context.Background(), fav); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestUpdateCatalogAsset(t *testing.T) {
+	now := time.Now()
+	chart := &models.Chart{ID: "c1", Title: "New", XAxisTitle: "X", YAxisTitle: "Y"}
+
+	t.Run("returns referencing favourites", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE assets SET data").
+			WithArgs("chart", "c1", sqlmock.AnyArg(), now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("UPDATE favourites SET updated_at .+ data IS NULL AND deleted_at IS NULL RETURNING").
+			WithArgs("chart", "c1", now).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "asset_type"}).
+				AddRow("c1", "user1", "chart").
+				AddRow("c1", "user2", "chart"))
+		mock.ExpectCommit()
+
+		owners, err := repo.UpdateCatalogAsset(context.Background(), chart, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(owners) != 2 || owners[0].UserID != "user1" || owners[1].UserID != "user2" {
+			t.Errorf("unexpected owners: %+v", owners)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns ErrAssetNotFound", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE assets SET data").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.UpdateCatalogAsset(context.Background(), chart, now)
+		if !errors.Is(err, ErrAssetNotFound) {
+			t.Errorf("expected ErrAssetNotFound, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks a column value encrypted by a ColumnCipher. The full
+// form is "enc:v1:<key id>:<base64 of nonce and ciphertext>".
+const encryptedPrefix = "enc:v1:"
+
+// Plaintext values starting with reservedPrefix, which encryptedPrefix
+// starts with, are stored behind escapedPrefix so they are not mistaken for
+// encrypted ones.
+const (
+	reservedPrefix = "enc:"
+	escapedPrefix  = "enc:raw:"
+)
+
+// Columns whose values are encrypted. Each value is bound to its column and
+// to the favourite it belongs to (see favouriteRow), so that it cannot be
+// moved to another column, favourite, user or tenant and still decrypt.
+const (
+	descriptionColumn     = "description"
+	descriptionHTMLColumn = "description_html"
+	dataColumn            = "data"
+	changesColumn         = "changes.description"
+)
+
+// favouriteRow identifies the favourite an encrypted value belongs to: the
+// row holding it or, for audit entries and events, the favourite they
+// record a change of.
+type favouriteRow struct {
+	tenant, user, id string
+}
+
+// tenantRow returns the row of the user's favourite id in the tenant of ctx,
+// where writes store it.
+func tenantRow(ctx context.Context, userID, id string) favouriteRow {
+	return favouriteRow{tenant: TenantFromContext(ctx), user: userID, id: id}
+}
+
+// additionalData returns the GCM additional data of a value of column in
+// the row: the column, tenant, user and asset ID, each length-prefixed so
+// that no two rows share it.
+func (r favouriteRow) additionalData(column string) []byte {
+	var ad []byte
+	for _, part := range []string{column, r.tenant, r.user, r.id} {
+		ad = binary.AppendUvarint(ad, uint64(len(part)))
+		ad = append(ad, part...)
+	}
+	return ad
+}
+
+// columnDecryptFailures counts, by column, the values that could not be
+// decrypted, published by expvar, where a missing key shows up.
+var columnDecryptFailures = expvar.NewMap("column_decrypt_failures")
+
+// ErrNoColumnKey is returned when reading a value encrypted with a key that is
+// not configured.
+var ErrNoColumnKey = errors.New("column encryption key not configured")
+
+// ColumnCipher encrypts favourite descriptions and asset data with AES-GCM
+// before they are stored. Each value is prefixed with the ID of the key that
+// encrypted it, so keys can be rotated: new values use the current key while
+// values under older keys stay readable as long as those keys are configured.
+type ColumnCipher struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewColumnCipher returns a ColumnCipher holding keys by ID, which encrypts
+// with the key current. Keys must be 16, 24 or 32 bytes long.
+func NewColumnCipher(keys map[string][]byte, current string) (*ColumnCipher, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current column encryption key %q not among the keys", current)
+	}
+	c := &ColumnCipher{current: current, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid column encryption key ID %q", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("column encryption key %s: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("column encryption key %s: %w", id, err)
+		}
+		c.aeads[id] = aead
+	}
+	return c, nil
+}
+
+// The methods below treat a nil *ColumnCipher as encryption disabled: values
+// are stored as plaintext. Reads handle plaintext values either way, so
+// encryption can be enabled without migrating existing favourites.
+
+// sealText encrypts the value of a text column of row, if encryption is
+// enabled. Empty values are stored as they are, and other plaintext values
+// escaped when they start like an encrypted one.
+func (c *ColumnCipher) sealText(row favouriteRow, column, value string) (string, error) {
+	if c == nil || value == "" {
+		if strings.HasPrefix(value, reservedPrefix) {
+			return escapedPrefix + value, nil
+		}
+		return value, nil
+	}
+	aead := c.aeads[c.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), row.additionalData(column))
+	return encryptedPrefix + c.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openText returns the plaintext of a value stored by sealText. Values
+// without the encrypted prefix were stored as plaintext and are returned
+// unchanged. A value that fails to decrypt, e.g. because its key is not
+// configured or it was stored in another row, is an error, counted in
+// column_decrypt_failures.
+func (c *ColumnCipher) openText(row favouriteRow, column, value string) (string, error) {
+	if rest, ok := strings.CutPrefix(value, escapedPrefix); ok {
+		return rest, nil
+	}
+	plaintext, err := c.decryptText(row, column, value)
+	if err != nil {
+		columnDecryptFailures.Add(column, 1)
+		return "", err
+	}
+	return plaintext, nil
+}
+
+// decryptText decrypts a value encrypted by sealText. Values without the
+// encrypted prefix are returned unchanged.
+func (c *ColumnCipher) decryptText(row favouriteRow, column, value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", fmt.Errorf("decrypting %s: malformed value", column)
+	}
+	var aead cipher.AEAD
+	if c != nil {
+		aead = c.aeads[id]
+	}
+	if aead == nil {
+		return "", fmt.Errorf("decrypting %s: %w: %s", column, ErrNoColumnKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("decrypting %s: malformed value", column)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, row.additionalData(column))
+	if err != nil {
+		return "", fmt.Errorf("decrypting %s: %w", column, err)
+	}
+	return string(plaintext), nil
+}
+
+// sealDescription encrypts a favourite's description and its rendered HTML.
+func (c *ColumnCipher) sealDescription(row favouriteRow, description, descriptionHTML string) (string, string, error) {
+	description, err := c.sealText(row, descriptionColumn, description)
+	if err != nil {
+		return "", "", err
+	}
+	descriptionHTML, err = c.sealText(row, descriptionHTMLColumn, descriptionHTML)
+	if err != nil {
+		return "", "", err
+	}
+	return description, descriptionHTML, nil
+}
+
+// openDescription decrypts a favourite's description and its rendered HTML.
+func (c *ColumnCipher) openDescription(row favouriteRow, description, descriptionHTML string) (string, string, error) {
+	description, err := c.openText(row, descriptionColumn, description)
+	if err != nil {
+		return "", "", err
+	}
+	descriptionHTML, err = c.openText(row, descriptionHTMLColumn, descriptionHTML)
+	if err != nil {
+		return "", "", err
+	}
+	return description, descriptionHTML, nil
+}
+
+// sealData encrypts asset data of row for the JSONB data column, if
+// encryption is enabled. The encrypted value is stored as a JSON string,
+// which keeps the column valid JSONB; plaintext data is always an object.
+func (c *ColumnCipher) sealData(row favouriteRow, data []byte) ([]byte, error) {
+	if c == nil || data == nil {
+		return data, nil
+	}
+	sealed, err := c.sealText(row, dataColumn, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+// openData decrypts asset data stored by sealData. Plaintext data is returned
+// unchanged.
+func (c *ColumnCipher) openData(row favouriteRow, data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != '"' {
+		return data, nil
+	}
+	var sealed string
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", dataColumn, err)
+	}
+	if !strings.HasPrefix(sealed, encryptedPrefix) {
+		return data, nil
+	}
+	plaintext, err := c.decryptText(row, dataColumn, sealed)
+	if err != nil {
+		columnDecryptFailures.Add(dataColumn, 1)
+		return nil, err
+	}
+	return []byte(plaintext), nil
+}
+
+// sealChanges returns the changes of an event or audit entry about the
+// favourite of row with the new description encrypted, if encryption is
+// enabled, so that it is not stored as plaintext next to the favourite's
+// encrypted one. changes is not modified.
+func (c *ColumnCipher) sealChanges(row favouriteRow, changes map[string]string) (map[string]string, error) {
+	description, ok := changes["description"]
+	if !ok || c == nil {
+		return changes, nil
+	}
+	sealed, err := c.sealText(row, changesColumn, description)
+	if err != nil {
+		return nil, err
+	}
+	copied := make(map[string]string, len(changes))
+	for field, value := range changes {
+		copied[field] = value
+	}
+	copied["description"] = sealed
+	return copied, nil
+}
+
+// openChanges decrypts in place the description sealed by sealChanges.
+func (c *ColumnCipher) openChanges(row favouriteRow, changes map[string]string) error {
+	description, ok := changes["description"]
+	if !ok {
+		return nil
+	}
+	plaintext, err := c.openText(row, changesColumn, description)
+	if err != nil {
+		return err
+	}
+	changes["description"] = plaintext
+	return nil
+}
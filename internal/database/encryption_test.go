@@ -0,0 +1,298 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"expvar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// capturedArg matches any argument and keeps its value.
+type capturedArg struct{ value driver.Value }
+
+func (a *capturedArg) Match(v driver.Value) bool {
+	a.value = v
+	return true
+}
+
+func testColumnCipher(t *testing.T, current string, ids ...string) *ColumnCipher {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, id := range ids {
+		keys[id] = bytes.Repeat([]byte(id[:1]), 32)
+	}
+	c, err := NewColumnCipher(keys, current)
+	if err != nil {
+		t.Fatalf("NewColumnCipher: %v", err)
+	}
+	return c
+}
+
+func TestColumnEncryption_RoundTrip(t *testing.T) {
+	now := time.Now()
+	repo, mock := setupTestDBWith(t, Options{Cipher: testColumnCipher(t, "k1", "k1")})
+
+	var description, data, descriptionHTML capturedArg
+	mock.ExpectQuery("INSERT INTO favourites").
+		WithArgs("c1", "user1", "chart", &description, &data, now, now, "", "", "", &descriptionHTML, "").
+		WillReturnRows(updatedAtRows())
+
+	fav := &models.FavouriteAsset{
+		ID: "c1", UserID: "user1", AssetType: models.AssetTypeChart,
+		Description: "quarterly revenue", DescriptionHTML: "<p>quarterly revenue</p>",
+		Data:      &models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "X", YAxisTitle: "Y"},
+		CreatedAt: now, UpdatedAt: now,
+	}
+	if err := repo.AddFavourite(context.Background(), fav); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stored := range []string{description.value.(string), string(data.value.([]byte)), descriptionHTML.value.(string)} {
+		if strings.Contains(stored, "revenue") || strings.Contains(stored, "Revenue") {
+			t.Fatalf("plaintext stored: %s", stored)
+		}
+		if !strings.Contains(stored, encryptedPrefix+"k1:") {
+			t.Fatalf("value not prefixed with its key ID: %s", stored)
+		}
+	}
+
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+		WithArgs("user1", "c1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("c1", "user1", "chart", description.value, data.value, now, now, nil, nil, nil, descriptionHTML.value, ""))
+
+	got, err := repo.GetFavourite(context.Background(), "user1", "c1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Description != fav.Description || got.DescriptionHTML != fav.DescriptionHTML {
+		t.Errorf("descriptions = %q, %q; want %q, %q", got.Description, got.DescriptionHTML, fav.Description, fav.DescriptionHTML)
+	}
+	if chart, ok := got.Data.(*models.Chart); !ok || chart.Title != "Revenue" {
+		t.Errorf("unexpected data: %+v", got.Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// decryptFailures returns the column_decrypt_failures count of column.
+func decryptFailures(column string) int64 {
+	if v, ok := columnDecryptFailures.Get(column).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestColumnEncryption_Rotation(t *testing.T) {
+	row := favouriteRow{tenant: "acme", user: "user1", id: "c1"}
+	sealed, err := testColumnCipher(t, "old", "old").sealText(row, descriptionColumn, "secret note")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cipher  *ColumnCipher
+		row     favouriteRow
+		column  string
+		value   string
+		want    string
+		wantErr bool
+		noKey   bool
+	}{
+		{name: "old key still configured", cipher: testColumnCipher(t, "new", "new", "old"), row: row, column: descriptionColumn, value: sealed, want: "secret note"},
+		{name: "plaintext from before encryption", cipher: testColumnCipher(t, "new", "new"), row: row, column: descriptionColumn, value: "plain note", want: "plain note"},
+		{name: "old key removed", cipher: testColumnCipher(t, "new", "new"), row: row, column: descriptionColumn, value: sealed, wantErr: true, noKey: true},
+		{name: "encryption disabled", row: row, column: descriptionColumn, value: sealed, wantErr: true, noKey: true},
+		{name: "moved to another column", cipher: testColumnCipher(t, "old", "old"), row: row, column: descriptionHTMLColumn, value: sealed, wantErr: true},
+		{name: "moved to another favourite", cipher: testColumnCipher(t, "old", "old"), row: favouriteRow{tenant: "acme", user: "user1", id: "c2"}, column: descriptionColumn, value: sealed, wantErr: true},
+		{name: "moved to another user", cipher: testColumnCipher(t, "old", "old"), row: favouriteRow{tenant: "acme", user: "user2", id: "c1"}, column: descriptionColumn, value: sealed, wantErr: true},
+		{name: "moved to another tenant", cipher: testColumnCipher(t, "old", "old"), row: favouriteRow{user: "user1", id: "c1"}, column: descriptionColumn, value: sealed, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := decryptFailures(tt.column)
+			got, err := tt.cipher.openText(tt.row, tt.column, tt.value)
+			if tt.wantErr {
+				if err == nil || tt.noKey != errors.Is(err, ErrNoColumnKey) {
+					t.Fatalf("openText = %q, %v; want an error", got, err)
+				}
+				if decryptFailures(tt.column) != failures+1 {
+					t.Errorf("failure not counted in column_decrypt_failures")
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("openText = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnEncryption_ReadFailure(t *testing.T) {
+	now := time.Now()
+	repo, mock := setupTestDBWith(t, Options{Cipher: testColumnCipher(t, "k1", "k1")})
+	sealed, err := repo.cipher.sealText(favouriteRow{user: "user2", id: "c1"}, descriptionColumn, "secret note")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A value copied from another user's favourite is not returned
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+		WithArgs("user1", "c1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("c1", "user1", "chart", sealed, testChartJSON("c1"), now, now, nil, nil, nil, nil, ""))
+
+	got, err := repo.GetFavourite(context.Background(), "user1", "c1")
+	if err == nil {
+		t.Fatalf("expected an error, got %+v", got)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error reveals the description: %v", err)
+	}
+}
+
+func TestColumnEncryption_Merge(t *testing.T) {
+	mergedAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	repo, mock := setupTestDBWith(t, Options{Cipher: testColumnCipher(t, "k1", "k1")})
+	source := favouriteRow{tenant: "acme", user: "user1", id: "c1"}
+	description, descriptionHTML, err := repo.cipher.sealDescription(source, "quarterly revenue", "<p>quarterly revenue</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := repo.cipher.sealData(source, testChartJSON("c1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var copiedDescription, copiedData, copiedHTML capturedArg
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, asset_type, description, data, .+ FROM favourites").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "asset_type", "description", "data", "source_system", "source_url", "favourited_from", "description_html", "tenant_id"}).
+			AddRow("c1", "chart", description, data, nil, nil, nil, descriptionHTML, "acme"))
+	mock.ExpectQuery("INSERT INTO favourites .* VALUES .* ON CONFLICT").
+		WithArgs("c1", "user2", "chart", &copiedDescription, &copiedData, mergedAt, nil, nil, nil, &copiedHTML, "acme").
+		WillReturnRows(sqlmock.NewRows(ownerCols).AddRow("c1", "user2", "chart"))
+	mock.ExpectCommit()
+
+	merged, err := repo.MergeUserFavourites(context.Background(), "user1", "user2", mergedAt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged) != 1 || merged[0].UserID != "user2" {
+		t.Errorf("unexpected merged rows: %+v", merged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+
+	// The copy is encrypted for the target user, not copied as it is
+	target := favouriteRow{tenant: "acme", user: "user2", id: "c1"}
+	got, err := repo.cipher.openText(target, descriptionColumn, copiedDescription.value.(string))
+	if err != nil || got != "quarterly revenue" {
+		t.Errorf("copied description = %q, %v", got, err)
+	}
+	if got, err := repo.cipher.openText(target, descriptionHTMLColumn, copiedHTML.value.(string)); err != nil || got != "<p>quarterly revenue</p>" {
+		t.Errorf("copied rendered description = %q, %v", got, err)
+	}
+	if got, err := repo.cipher.openData(target, copiedData.value.([]byte)); err != nil || !bytes.Equal(got, testChartJSON("c1")) {
+		t.Errorf("copied data = %s, %v", got, err)
+	}
+}
+
+func TestColumnEncryption_PrefixedPlaintext(t *testing.T) {
+	row := favouriteRow{user: "user1", id: "c1"}
+	tests := []struct {
+		name   string
+		cipher *ColumnCipher
+		value  string
+	}{
+		{name: "prefixed plaintext", value: "enc:v1:k1:not really encrypted"},
+		{name: "escaped-looking plaintext", value: "enc:raw:note"},
+		{name: "other plaintext", value: "enc is short for encoding"},
+		{name: "prefixed value encrypted", cipher: testColumnCipher(t, "k1", "k1"), value: "enc:v1:k1:not really encrypted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, err := tt.cipher.sealText(row, descriptionColumn, tt.value)
+			if err != nil {
+				t.Fatalf("sealText: %v", err)
+			}
+			if got, err := tt.cipher.openText(row, descriptionColumn, stored); err != nil || got != tt.value {
+				t.Errorf("read back %q as %q, %v (stored %q)", tt.value, got, err, stored)
+			}
+		})
+	}
+
+	// Prefixed values that are not valid ciphertexts are errors
+	c := testColumnCipher(t, "k1", "k1")
+	for _, invalid := range []string{"enc:v1:a note", "enc:v1:k1:bm90IGEgY2lwaGVydGV4dCBhdCBhbGw=", "enc:v1:k9:bm90ZQ=="} {
+		if got, err := c.openText(row, descriptionColumn, invalid); err == nil {
+			t.Errorf("openText(%q) = %q, want an error", invalid, got)
+		}
+	}
+}
+
+func TestColumnEncryption_Changes(t *testing.T) {
+	now := time.Now()
+	repo, mock := setupTestDBWith(t, Options{Cipher: testColumnCipher(t, "k1", "k1")})
+
+	var diff capturedArg
+	mock.ExpectExec("INSERT INTO favourite_audit").
+		WithArgs("user1", "user1", "update", "c1", &diff, now, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	changes := map[string]string{"description": "quarterly revenue"}
+	entry := &AuditEntry{UserID: "user1", Actor: "user1", Action: "update", AssetID: "c1", Diff: changes, OccurredAt: now}
+	if err := repo.InsertAuditEntry(context.Background(), entry); err != nil {
+		t.Fatalf("InsertAuditEntry: %v", err)
+	}
+	stored := diff.value.([]byte)
+	if strings.Contains(string(stored), "revenue") || !strings.Contains(string(stored), encryptedPrefix+"k1:") {
+		t.Fatalf("audit diff not encrypted: %s", stored)
+	}
+	if changes["description"] != "quarterly revenue" {
+		t.Errorf("entry's diff modified: %v", changes)
+	}
+
+	mock.ExpectQuery("SELECT id, user_id, actor, action, asset_id, diff, occurred_at").
+		WithArgs("user1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "actor", "action", "asset_id", "diff", "occurred_at", "tenant_id"}).
+			AddRow(1, "user1", "user1", "update", "c1", stored, now, ""))
+	entries, err := repo.GetAuditEntries(context.Background(), "user1", 10)
+	if err != nil {
+		t.Fatalf("GetAuditEntries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Diff["description"] != "quarterly revenue" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestNewColumnCipher_Invalid(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	tests := map[string]struct {
+		keys    map[string][]byte
+		current string
+	}{
+		"unknown current key": {keys: map[string][]byte{"k1": key}, current: "k2"},
+		"short key":           {keys: map[string][]byte{"k1": key[:10]}, current: "k1"},
+		"colon in key ID":     {keys: map[string][]byte{"k:1": key}, current: "k:1"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewColumnCipher(tt.keys, tt.current); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
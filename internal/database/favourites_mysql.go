@@ -0,0 +1,691 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/health"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/richtext"
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry is the MySQL error number of a duplicate key.
+const mysqlDuplicateEntry = 1062
+
+// mysqlSchema creates the favourites table of a MySQL database. Asset data
+// is kept in a JSON column, as the API accepted it.
+const mysqlSchema = `
+	CREATE TABLE IF NOT EXISTS favourites (
+		tenant_id        VARCHAR(255)  NOT NULL DEFAULT '',
+		user_id          VARCHAR(255)  NOT NULL,
+		id               VARCHAR(255)  NOT NULL,
+		asset_type       VARCHAR(64)   NOT NULL,
+		description      TEXT          NOT NULL,
+		description_html TEXT          NULL,
+		data             JSON          NOT NULL,
+		created_at       DATETIME(6)   NOT NULL,
+		updated_at       DATETIME(6)   NOT NULL,
+		source_system    VARCHAR(255)  NOT NULL DEFAULT '',
+		source_url       VARCHAR(2048) NOT NULL DEFAULT '',
+		favourited_from  VARCHAR(255)  NOT NULL DEFAULT '',
+		PRIMARY KEY (tenant_id, user_id, id),
+		KEY favourites_user_created_idx (user_id, created_at)
+	) DEFAULT CHARSET = utf8mb4`
+
+// mysqlFavouriteColumns are the columns scanMySQLFavourite reads.
+const mysqlFavouriteColumns = `id, user_id, asset_type, description, data, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html`
+
+// OpenMySQL opens the MySQL database of dsn (see mysql.ParseDSN) and checks
+// that it answers. Timestamps are read as time.Time in UTC, and updates
+// report the rows they matched rather than those they changed, as Postgres
+// does.
+func OpenMySQL(dsn string) (*sql.DB, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing MySQL DSN: %w", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.ClientFoundRows = true
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configuring MySQL connection: %w", err)
+	}
+	db := sql.OpenDB(connector)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("pinging MySQL: %w", err)
+	}
+	return db, nil
+}
+
+// MySQLRepository is a FavouritesRepository storing favourites in MySQL, for
+// teams that only operate MySQL. Favourites belong to the tenant of the
+// context they were added in and, as with Repository, reads without a tenant
+// see every tenant. Deletes remove favourites at once, and updated_at keeps
+// the time the service sent. Quotas are enforced by locking the user's
+// favourites of the type, where Repository takes an advisory lock.
+type MySQLRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLRepository returns the MySQLRepository of favourites stored in db.
+func NewMySQLRepository(db *sql.DB) *MySQLRepository {
+	return &MySQLRepository{db: db}
+}
+
+// EnsureSchema creates the favourites table unless it exists.
+func (m *MySQLRepository) EnsureSchema(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, mysqlSchema); err != nil {
+		return fmt.Errorf("creating favourites table: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck checks that the database answers, reporting its connection pool.
+func (m *MySQLRepository) HealthCheck(ctx context.Context) (map[string]any, error) {
+	err := m.db.PingContext(ctx)
+	stats := m.db.Stats()
+	details := map[string]any{
+		"pool": map[string]int{"open": stats.OpenConnections, "in_use": stats.InUse, "idle": stats.Idle},
+	}
+	return details, err
+}
+
+var _ health.Check = (*MySQLRepository)(nil).HealthCheck
+
+// mysqlTenantScope is tenantScope with MySQL placeholders.
+func mysqlTenantScope(ctx context.Context, args *[]any) string {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return ""
+	}
+	*args = append(*args, tenant)
+	return " AND tenant_id = ?"
+}
+
+// isDuplicateKey reports whether err is MySQL's duplicate key error.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+// GetUserFavourites returns the user's favourites, newest first.
+func (m *MySQLRepository) GetUserFavourites(ctx context.Context, userID string) ([]*models.FavouriteAsset, error) {
+	return m.collect(ctx, userID, models.Provenance{})
+}
+
+// GetUserFavouritesByProvenance returns the user's favourites whose
+// provenance matches every non-empty field of provenance exactly, newest
+// first.
+func (m *MySQLRepository) GetUserFavouritesByProvenance(ctx context.Context, userID string, provenance models.Provenance) ([]*models.FavouriteAsset, error) {
+	return m.collect(ctx, userID, provenance)
+}
+
+// ForEachUserFavourite calls fn for every favourite of the user, newest
+// first, as its row is read. An error from fn stops the iteration and is
+// returned as is.
+func (m *MySQLRepository) ForEachUserFavourite(ctx context.Context, userID string, fn func(*models.FavouriteAsset) error) error {
+	return m.eachUserFavourite(ctx, userID, models.Provenance{}, fn)
+}
+
+// collect returns the user's favourites matching provenance, newest first.
+func (m *MySQLRepository) collect(ctx context.Context, userID string, provenance models.Provenance) ([]*models.FavouriteAsset, error) {
+	favourites := []*models.FavouriteAsset{}
+	err := m.eachUserFavourite(ctx, userID, provenance, func(fav *models.FavouriteAsset) error {
+		favourites = append(favourites, fav)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return favourites, nil
+}
+
+// eachUserFavourite reads the user's favourites matching provenance newest
+// first and calls fn for each of them.
+func (m *MySQLRepository) eachUserFavourite(ctx context.Context, userID string, provenance models.Provenance, fn func(*models.FavouriteAsset) error) error {
+	args := []any{userID}
+	var scope strings.Builder
+	for _, field := range []struct{ column, value string }{
+		{"source_system", provenance.SourceSystem},
+		{"source_url", provenance.SourceURL},
+		{"favourited_from", provenance.FavouritedFrom},
+	} {
+		if field.value != "" {
+			args = append(args, field.value)
+			scope.WriteString(" AND " + field.column + " = ?")
+		}
+	}
+	query := `
+		SELECT ` + mysqlFavouriteColumns + `
+		FROM favourites
+		WHERE user_id = ?` + scope.String() + mysqlTenantScope(ctx, &args) + `
+		ORDER BY created_at DESC, id DESC`
+	return m.queryFavourites(ctx, m.db, query, args, fn)
+}
+
+// GetRecentUserFavourites returns the user's favourites created or updated
+// at or after since, most recently changed first.
+func (m *MySQLRepository) GetRecentUserFavourites(ctx context.Context, userID string, since time.Time) ([]*models.FavouriteAsset, error) {
+	args := []any{userID, since}
+	query := `
+		SELECT ` + mysqlFavouriteColumns + `
+		FROM favourites
+		WHERE user_id = ? AND updated_at >= ?` + mysqlTenantScope(ctx, &args) + `
+		ORDER BY updated_at DESC, id`
+
+	favourites := []*models.FavouriteAsset{}
+	err := m.queryFavourites(ctx, m.db, query, args, func(fav *models.FavouriteAsset) error {
+		favourites = append(favourites, fav)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return favourites, nil
+}
+
+// queryFavourites runs a favourites query on db and calls fn for each row.
+func (m *MySQLRepository) queryFavourites(ctx context.Context, db conn, query string, args []any, fn func(*models.FavouriteAsset) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("querying user favourites: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		fav, err := scanMySQLFavourite(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(fav); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating user favourites: %w", err)
+	}
+	return nil
+}
+
+// GetFavourite returns a single favourite of the user.
+func (m *MySQLRepository) GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error) {
+	return m.getFavourite(ctx, m.db, userID, assetID, "")
+}
+
+// getFavourite returns the user's favourite with the asset ID using db,
+// with suffix appended to the query.
+func (m *MySQLRepository) getFavourite(ctx context.Context, db conn, userID, assetID, suffix string) (*models.FavouriteAsset, error) {
+	args := []any{userID, assetID}
+	query := `
+		SELECT ` + mysqlFavouriteColumns + `
+		FROM favourites
+		WHERE user_id = ? AND id = ?` + mysqlTenantScope(ctx, &args) + `
+		LIMIT 1` + suffix
+
+	fav, err := scanMySQLFavourite(db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return fav, err
+}
+
+// FavouriteExists reports whether the user has a favourite with the asset ID.
+func (m *MySQLRepository) FavouriteExists(ctx context.Context, userID, assetID string) (bool, error) {
+	args := []any{userID, assetID}
+	query := `SELECT 1 FROM favourites WHERE user_id = ? AND id = ?` + mysqlTenantScope(ctx, &args) + ` LIMIT 1`
+
+	var one int
+	err := m.db.QueryRowContext(ctx, query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking favourite: %w", err)
+	}
+	return true, nil
+}
+
+// CountUserFavouritesByType returns how many favourites the user has per asset type.
+func (m *MySQLRepository) CountUserFavouritesByType(ctx context.Context, userID string) (map[models.AssetType]int, error) {
+	args := []any{userID}
+	query := `
+		SELECT asset_type, COUNT(*)
+		FROM favourites
+		WHERE user_id = ?` + mysqlTenantScope(ctx, &args) + `
+		GROUP BY asset_type`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("counting user favourites: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[models.AssetType]int)
+	for rows.Next() {
+		var assetType models.AssetType
+		var count int
+		if err := rows.Scan(&assetType, &count); err != nil {
+			return nil, fmt.Errorf("scanning favourite count row: %w", err)
+		}
+		counts[assetType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating favourite counts: %w", err)
+	}
+	return counts, nil
+}
+
+// GetUserFavouriteStats aggregates the user's favourites per asset type,
+// ordered by type. Types the user has no favourites of are omitted.
+func (m *MySQLRepository) GetUserFavouriteStats(ctx context.Context, userID string, since time.Time) ([]FavouriteTypeStats, error) {
+	args := []any{since, userID}
+	query := `
+		SELECT asset_type, COUNT(*), MIN(created_at), MAX(created_at),
+		       COALESCE(SUM(created_at >= ?), 0)
+		FROM favourites
+		WHERE user_id = ?` + mysqlTenantScope(ctx, &args) + `
+		GROUP BY asset_type
+		ORDER BY asset_type`
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating user favourites: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []FavouriteTypeStats
+	for rows.Next() {
+		var st FavouriteTypeStats
+		if err := rows.Scan(&st.AssetType, &st.Count, &st.FirstAddedAt, &st.LastAddedAt, &st.AddedSince); err != nil {
+			return nil, fmt.Errorf("scanning favourite stats row: %w", err)
+		}
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating favourite stats: %w", err)
+	}
+	return stats, nil
+}
+
+// AddFavourite stores a new favourite. A favourite with the same asset ID
+// fails with ErrAlreadyExists.
+func (m *MySQLRepository) AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return m.insertFavourite(ctx, m.db, favourite)
+}
+
+// AddFavouriteWithinQuota stores the favourite only if the user has fewer
+// than limit favourites of the same asset type.
+func (m *MySQLRepository) AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error {
+	return m.WithTx(ctx, func(tx Tx) error {
+		return tx.AddFavouriteWithinQuota(ctx, favourite, limit)
+	})
+}
+
+// insertFavourite inserts favourite in the tenant of ctx using db.
+func (m *MySQLRepository) insertFavourite(ctx context.Context, db conn, favourite *models.FavouriteAsset) error {
+	data, err := json.Marshal(favourite.Data)
+	if err != nil {
+		return fmt.Errorf("marshalling asset data: %w", err)
+	}
+	const query = `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// JSON columns reject binary strings, so data is sent as text
+	_, err = db.ExecContext(ctx, query, favourite.ID, favourite.UserID, string(favourite.AssetType),
+		favourite.Description, string(data), favourite.CreatedAt, favourite.UpdatedAt,
+		favourite.SourceSystem, favourite.SourceURL, favourite.FavouritedFrom, favourite.DescriptionHTML,
+		TenantFromContext(ctx))
+	if isDuplicateKey(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("inserting favourite: %w", err)
+	}
+	return nil
+}
+
+// addFavouriteWithinQuota inserts favourite using tx unless the user has
+// limit favourites of its asset type. Counting them locks the range of the
+// user's favourites, so concurrent adds wait for the transaction.
+func (m *MySQLRepository) addFavouriteWithinQuota(ctx context.Context, tx conn, favourite *models.FavouriteAsset, limit int) error {
+	args := []any{favourite.UserID, string(favourite.AssetType)}
+	query := `
+		SELECT COUNT(*)
+		FROM favourites
+		WHERE user_id = ? AND asset_type = ?` + mysqlTenantScope(ctx, &args) + `
+		FOR UPDATE`
+
+	var count int
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return fmt.Errorf("counting user favourites: %w", err)
+	}
+	if count >= limit {
+		return ErrQuotaExceeded
+	}
+	return m.insertFavourite(ctx, tx, favourite)
+}
+
+// updateFavourite stores the description and asset data of favourite using db.
+func (m *MySQLRepository) updateFavourite(ctx context.Context, db conn, favourite *models.FavouriteAsset) error {
+	data, err := json.Marshal(favourite.Data)
+	if err != nil {
+		return fmt.Errorf("marshalling asset data: %w", err)
+	}
+	args := []any{favourite.Description, string(data), favourite.UpdatedAt, favourite.DescriptionHTML, favourite.UserID, favourite.ID}
+	query := `
+		UPDATE favourites
+		SET description = ?, data = ?, updated_at = ?, description_html = ?
+		WHERE user_id = ? AND id = ?` + mysqlTenantScope(ctx, &args)
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("updating favourite: %w", err)
+	}
+	return requireRow(result)
+}
+
+// requireRow returns ErrNotFound unless result affected a row.
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateDescriptions applies all description updates for the user in a single
+// transaction. The returned slice reports, per update, whether a favourite matched.
+func (m *MySQLRepository) UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) (result []bool, err error) {
+	err = m.WithTx(ctx, func(tx Tx) error {
+		result, err = tx.UpdateDescriptions(ctx, userID, updates, updatedAt)
+		return err
+	})
+	return result, err
+}
+
+// updateDescriptions applies the description updates using db.
+func (m *MySQLRepository) updateDescriptions(ctx context.Context, db conn, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error) {
+	const query = `
+		UPDATE favourites
+		SET description = ?, updated_at = ?, description_html = ?
+		WHERE user_id = ? AND id = ?`
+
+	matched := make([]bool, len(updates))
+	for i, u := range updates {
+		args := []any{u.Description, updatedAt, u.DescriptionHTML, userID, u.AssetID}
+		result, err := db.ExecContext(ctx, query+mysqlTenantScope(ctx, &args), args...)
+		if err != nil {
+			return nil, fmt.Errorf("updating favourite %s: %w", u.AssetID, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("checking rows affected: %w", err)
+		}
+		matched[i] = rowsAffected > 0
+	}
+	return matched, nil
+}
+
+// DeleteFavourite removes a single favourite.
+func (m *MySQLRepository) DeleteFavourite(ctx context.Context, userID, assetID string) error {
+	return m.deleteFavourite(ctx, m.db, userID, assetID)
+}
+
+// deleteFavourite removes the user's favourite with the asset ID using db.
+func (m *MySQLRepository) deleteFavourite(ctx context.Context, db conn, userID, assetID string) error {
+	args := []any{userID, assetID}
+	query := `DELETE FROM favourites WHERE user_id = ? AND id = ?` + mysqlTenantScope(ctx, &args)
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("deleting favourite: %w", err)
+	}
+	return requireRow(result)
+}
+
+// DeleteAllUserFavourites removes every favourite of the user and returns
+// their IDs.
+func (m *MySQLRepository) DeleteAllUserFavourites(ctx context.Context, userID string) (deleted []string, err error) {
+	err = m.WithTx(ctx, func(tx Tx) error {
+		deleted, err = tx.DeleteAllUserFavourites(ctx, userID)
+		return err
+	})
+	return deleted, err
+}
+
+// deleteAllUserFavourites removes the user's favourites using tx, which
+// keeps their IDs locked between reading and deleting them.
+func (m *MySQLRepository) deleteAllUserFavourites(ctx context.Context, tx conn, userID string) ([]string, error) {
+	args := []any{userID}
+	scope := mysqlTenantScope(ctx, &args)
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM favourites WHERE user_id = ?`+scope+` ORDER BY id FOR UPDATE`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying user favourites: %w", err)
+	}
+	defer rows.Close()
+
+	deleted := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning favourite id: %w", err)
+		}
+		deleted = append(deleted, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating user favourites: %w", err)
+	}
+	if len(deleted) == 0 {
+		return deleted, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM favourites WHERE user_id = ?`+scope, args...); err != nil {
+		return nil, fmt.Errorf("deleting user favourites: %w", err)
+	}
+	return slices.Compact(deleted), nil
+}
+
+// addFavouritesBatch inserts favourites using tx, batchInsertRows per
+// statement, and returns those that already existed or repeated an earlier
+// one. The existing ones are read first with a locking read, which keeps
+// others from adding them until the transaction ends.
+func (m *MySQLRepository) addFavouritesBatch(ctx context.Context, tx conn, favourites []*models.FavouriteAsset) ([]*models.FavouriteAsset, error) {
+	var conflicts, fresh []*models.FavouriteAsset
+	seen := make(map[[2]string]bool, len(favourites))
+	for _, fav := range favourites {
+		key := [2]string{fav.UserID, fav.ID}
+		if seen[key] {
+			conflicts = append(conflicts, fav)
+			continue
+		}
+		seen[key] = true
+		fresh = append(fresh, fav)
+	}
+
+	for chunk := range slices.Chunk(fresh, batchInsertRows) {
+		existing, err := m.existingFavourites(ctx, tx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		var values strings.Builder
+		var args []any
+		for _, fav := range chunk {
+			if existing[[2]string{fav.UserID, fav.ID}] {
+				conflicts = append(conflicts, fav)
+				continue
+			}
+			data, err := json.Marshal(fav.Data)
+			if err != nil {
+				return nil, fmt.Errorf("marshalling asset data of %s: %w", fav.ID, err)
+			}
+			if values.Len() > 0 {
+				values.WriteString(", ")
+			}
+			values.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+			args = append(args, fav.ID, fav.UserID, string(fav.AssetType), fav.Description, string(data),
+				fav.CreatedAt, fav.UpdatedAt, fav.SourceSystem, fav.SourceURL, fav.FavouritedFrom,
+				fav.DescriptionHTML, TenantFromContext(ctx))
+		}
+		if len(args) == 0 {
+			continue
+		}
+		query := `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES ` + values.String()
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return nil, fmt.Errorf("inserting favourites: %w", err)
+		}
+	}
+	return conflicts, nil
+}
+
+// existingFavourites returns the user and asset IDs of those of favourites
+// stored already in the tenant of ctx, locking them, or the gaps they
+// would go in, until the transaction ends.
+func (m *MySQLRepository) existingFavourites(ctx context.Context, tx conn, favourites []*models.FavouriteAsset) (map[[2]string]bool, error) {
+	var keys strings.Builder
+	args := []any{TenantFromContext(ctx)}
+	for i, fav := range favourites {
+		if i > 0 {
+			keys.WriteString(", ")
+		}
+		keys.WriteString("(?, ?)")
+		args = append(args, fav.UserID, fav.ID)
+	}
+	query := `SELECT user_id, id FROM favourites WHERE tenant_id = ? AND (user_id, id) IN (` + keys.String() + `) FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying existing favourites: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[[2]string]bool)
+	for rows.Next() {
+		var key [2]string
+		if err := rows.Scan(&key[0], &key[1]); err != nil {
+			return nil, fmt.Errorf("scanning existing favourite: %w", err)
+		}
+		existing[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating existing favourites: %w", err)
+	}
+	return existing, nil
+}
+
+// WithTx runs fn in a transaction, committed when fn returns nil and rolled
+// back otherwise. fn's error is returned as is.
+func (m *MySQLRepository) WithTx(ctx context.Context, fn func(tx Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(mysqlTx{m, tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+// scanMySQLFavourite scans a row of mysqlFavouriteColumns, as scanFavourite
+// does a Postgres one.
+func scanMySQLFavourite(row rowScanner) (*models.FavouriteAsset, error) {
+	var (
+		fav             models.FavouriteAsset
+		data            []byte
+		descriptionHTML sql.NullString
+	)
+	err := row.Scan(&fav.ID, &fav.UserID, &fav.AssetType, &fav.Description, &data, &fav.CreatedAt, &fav.UpdatedAt,
+		&fav.SourceSystem, &fav.SourceURL, &fav.FavouritedFrom, &descriptionHTML)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scanning favourite: %w", err)
+	}
+	if fav.Data, err = assets.Decode(fav.AssetType, data); err != nil {
+		return nil, err
+	}
+	if descriptionHTML.Valid {
+		fav.DescriptionHTML = descriptionHTML.String
+	} else {
+		fav.DescriptionHTML = richtext.Render(fav.Description)
+	}
+	return &fav, nil
+}
+
+// mysqlTx is the Tx of a MySQLRepository.
+type mysqlTx struct {
+	m  *MySQLRepository
+	tx *sql.Tx
+}
+
+// GetFavourite is MySQLRepository.GetFavourite, with the favourite locked
+// until the transaction ends.
+func (t mysqlTx) GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error) {
+	return t.m.getFavourite(ctx, t.tx, userID, assetID, " FOR UPDATE")
+}
+
+// AddFavourite is MySQLRepository.AddFavourite within the transaction.
+func (t mysqlTx) AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return t.m.insertFavourite(ctx, t.tx, favourite)
+}
+
+// UpdateFavourite stores the description and asset data of a favourite.
+func (t mysqlTx) UpdateFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return t.m.updateFavourite(ctx, t.tx, favourite)
+}
+
+// AddFavouriteWithinQuota is MySQLRepository.AddFavouriteWithinQuota within the transaction.
+func (t mysqlTx) AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error {
+	return t.m.addFavouriteWithinQuota(ctx, t.tx, favourite, limit)
+}
+
+// DeleteFavourite is MySQLRepository.DeleteFavourite within the transaction.
+func (t mysqlTx) DeleteFavourite(ctx context.Context, userID, assetID string) error {
+	return t.m.deleteFavourite(ctx, t.tx, userID, assetID)
+}
+
+// DeleteAllUserFavourites is MySQLRepository.DeleteAllUserFavourites within the transaction.
+func (t mysqlTx) DeleteAllUserFavourites(ctx context.Context, userID string) ([]string, error) {
+	return t.m.deleteAllUserFavourites(ctx, t.tx, userID)
+}
+
+// UpdateDescriptions is MySQLRepository.UpdateDescriptions within the transaction.
+func (t mysqlTx) UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error) {
+	return t.m.updateDescriptions(ctx, t.tx, userID, updates, updatedAt)
+}
+
+// AddFavouritesBatch inserts the favourites within the transaction,
+// returning those that already existed.
+func (t mysqlTx) AddFavouritesBatch(ctx context.Context, favourites []*models.FavouriteAsset) ([]*models.FavouriteAsset, error) {
+	return t.m.addFavouritesBatch(ctx, t.tx, favourites)
+}
+
+// AppendEvent fails: the event outbox needs PostgreSQL.
+func (t mysqlTx) AppendEvent(context.Context, events.Event) error {
+	return errNoOutbox
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/go-sql-driver/mysql"
+)
+
+var testMySQLCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at", "source_system", "source_url", "favourited_from", "description_html"}
+
+func setupMySQLTestDB(t *testing.T) (*MySQLRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewMySQLRepository(db), mock
+}
+
+func testMySQLChart(id string) *models.FavouriteAsset {
+	now := time.Now()
+	return &models.FavouriteAsset{ID: id, UserID: "user1", AssetType: models.AssetTypeChart, Description: "d",
+		CreatedAt: now, UpdatedAt: now, Data: &models.Chart{ID: id, Title: "T", XAxisTitle: "X", YAxisTitle: "Y"}}
+}
+
+func TestMySQLRepository_GetUserFavourites(t *testing.T) {
+	repo, mock := setupMySQLTestDB(t)
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id = \\? AND tenant_id = \\? ORDER BY created_at DESC").
+		WithArgs("user1", "acme").
+		WillReturnRows(sqlmock.NewRows(testMySQLCols).
+			AddRow("c1", "user1", "chart", "**new**", testChartJSON("c1"), now, now, "", "", "", nil))
+
+	favs, err := repo.GetUserFavourites(WithTenant(context.Background(), "acme"), "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(favs) != 1 || favs[0].ID != "c1" {
+		t.Fatalf("unexpected favourites: %+v", favs)
+	}
+	if _, ok := favs[0].Data.(*models.Chart); !ok {
+		t.Errorf("expected *models.Chart, got %T", favs[0].Data)
+	}
+	if favs[0].DescriptionHTML == "" {
+		t.Error("expected the description to be rendered")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestMySQLRepository_AddFavourite(t *testing.T) {
+	t.Run("stores the data as JSON text", func(t *testing.T) {
+		repo, mock := setupMySQLTestDB(t)
+		fav := testMySQLChart("c1")
+		mock.ExpectExec("INSERT INTO favourites").
+			WithArgs("c1", "user1", "chart", "d", string(testChartJSON("c1")), fav.CreatedAt, fav.UpdatedAt, "", "", "", "", "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.AddFavourite(context.Background(), fav); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("maps a duplicate key to ErrAlreadyExists", func(t *testing.T) {
+		repo, mock := setupMySQLTestDB(t)
+		mock.ExpectExec("INSERT INTO favourites").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+		if err := repo.AddFavourite(context.Background(), testMySQLChart("c1")); !errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("expected ErrAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("wraps other errors", func(t *testing.T) {
+		repo, mock := setupMySQLTestDB(t)
+		mock.ExpectExec("INSERT INTO favourites").
+			WillReturnError(&mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"})
+
+		err := repo.AddFavourite(context.Background(), testMySQLChart("c1"))
+		if err == nil || errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("expected a wrapped error, got %v", err)
+		}
+	})
+}
+
+func TestMySQLRepository_AddFavouriteWithinQuota(t *testing.T) {
+	repo, mock := setupMySQLTestDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM favourites WHERE user_id = \\? AND asset_type = \\? FOR UPDATE").
+		WithArgs("user1", "chart").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
+
+	if err := repo.AddFavouriteWithinQuota(context.Background(), testMySQLChart("c1"), 2); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestMySQLRepository_DeleteFavourite(t *testing.T) {
+	repo, mock := setupMySQLTestDB(t)
+	mock.ExpectExec("DELETE FROM favourites WHERE user_id = \\? AND id = \\?").
+		WithArgs("user1", "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repo.DeleteFavourite(context.Background(), "user1", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMySQLRepository_AddFavouritesBatch(t *testing.T) {
+	repo, mock := setupMySQLTestDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, id FROM favourites WHERE tenant_id = \\? AND \\(user_id, id\\) IN \\(\\(\\?, \\?\\), \\(\\?, \\?\\)\\) FOR UPDATE").
+		WithArgs("", "user1", "c1", "user1", "c2").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id"}).AddRow("user1", "c1"))
+	mock.ExpectExec("INSERT INTO favourites .+ VALUES \\(\\?(, \\?){11}\\)$").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	batch := []*models.FavouriteAsset{testMySQLChart("c1"), testMySQLChart("c2"), testMySQLChart("c2")}
+	var conflicts []*models.FavouriteAsset
+	err := repo.WithTx(context.Background(), func(tx Tx) (err error) {
+		conflicts, err = tx.AddFavouritesBatch(context.Background(), batch)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 2 || conflicts[0] != batch[2] || conflicts[1] != batch[0] {
+		t.Errorf("expected the repeated c2 and the existing c1 to conflict, got %+v", conflicts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestMySQLRepository_AppendEvent(t *testing.T) {
+	repo, mock := setupMySQLTestDB(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := repo.WithTx(context.Background(), func(tx Tx) error {
+		return tx.AppendEvent(context.Background(), events.Event{})
+	})
+	if !errors.Is(err, errNoOutbox) {
+		t.Errorf("expected errNoOutbox, got %v", err)
+	}
+}
+
+func TestMySQLRepository_Restore(t *testing.T) {
+	repo, mock := setupMySQLTestDB(t)
+	now := time.Now().UTC()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO favourites .+ ON DUPLICATE KEY UPDATE").
+		WithArgs("c1", "user1", "chart", "d", string(testChartJSON("c1")), now, now, "", "", "", nil, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	restorer, err := repo.BeginRestore(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	if err := restorer.PutAsset(ctx, &AssetRecord{AssetType: "chart", ID: "c1", Data: testChartJSON("c1")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec := &FavouriteRecord{ID: "c1", UserID: "user1", TenantID: "acme", AssetType: "chart", Description: "d", CreatedAt: now, UpdatedAt: now}
+	if err := restorer.PutFavourite(ctx, rec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := restorer.PutVersion(ctx, &VersionRecord{}); !errors.Is(err, ErrNotRestored) {
+		t.Errorf("expected ErrNotRestored, got %v", err)
+	}
+	if err := restorer.Commit(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
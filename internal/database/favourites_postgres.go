@@ -3,100 +3,421 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/giannis84/platform-go-challenge/internal/assets"
 	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/richtext"
 	"github.com/lib/pq"
 )
 
 var (
 	ErrNotFound      = errors.New("favourite not found")
 	ErrAlreadyExists = errors.New("favourite already exists")
+	ErrQuotaExceeded = errors.New("favourites quota exceeded")
 )
 
-// DB is the package-level database connection.
-var DB *sql.DB
+// AssetOwnership links an asset to a user who has it favourited.
+type AssetOwnership struct {
+	AssetID   string
+	UserID    string
+	AssetType models.AssetType
+}
 
-func GetUserFavouritesFromDB(userID string) ([]*models.FavouriteAsset, error) {
-	const query = `
-		SELECT id, user_id, asset_type, description, data, created_at, updated_at
+// GetUserFavourites returns the user's favourites, newest first.
+func (r *Repository) GetUserFavourites(ctx context.Context, userID string) (result []*models.FavouriteAsset, err error) {
+	defer r.observe(ctx, "get_user_favourites", time.Now(), &err, func() int { return len(result) })
+	favourites := []*models.FavouriteAsset{}
+	err = r.eachUserFavourite(ctx, userID, models.Provenance{}, func(fav *models.FavouriteAsset) error {
+		favourites = append(favourites, fav)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return favourites, nil
+}
+
+// GetUserFavouritesByProvenance returns the user's favourites whose
+// provenance matches every non-empty field of provenance exactly, newest
+// first.
+func (r *Repository) GetUserFavouritesByProvenance(ctx context.Context, userID string, provenance models.Provenance) (result []*models.FavouriteAsset, err error) {
+	defer r.observe(ctx, "get_user_favourites_by_provenance", time.Now(), &err, func() int { return len(result) })
+	favourites := []*models.FavouriteAsset{}
+	err = r.eachUserFavourite(ctx, userID, provenance, func(fav *models.FavouriteAsset) error {
+		favourites = append(favourites, fav)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return favourites, nil
+}
+
+// ForEachUserFavourite calls fn for every favourite of the user, newest
+// first, as its row is read, so callers can stream a user's favourites
+// without holding them all in memory. An error from fn stops the iteration
+// and is returned as is.
+func (r *Repository) ForEachUserFavourite(ctx context.Context, userID string, fn func(*models.FavouriteAsset) error) (err error) {
+	count := 0
+	defer r.observe(ctx, "for_each_user_favourite", time.Now(), &err, func() int { return count })
+	return r.eachUserFavourite(ctx, userID, models.Provenance{}, func(fav *models.FavouriteAsset) error {
+		count++
+		return fn(fav)
+	})
+}
+
+// eachUserFavourite reads the user's favourites matching provenance newest
+// first and calls fn for each of them.
+func (r *Repository) eachUserFavourite(ctx context.Context, userID string, provenance models.Provenance, fn func(*models.FavouriteAsset) error) error {
+	args := []any{userID}
+	query := `
+		SELECT id, user_id, asset_type, description, ` + favouriteDataColumn + `, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html, tenant_id
 		FROM favourites
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL` + provenanceScope(provenance, &args) + tenantScope(ctx, &args) + `
 		ORDER BY created_at DESC`
 
-	rows, err := DB.Query(query, userID)
+	rows, err := r.readPreparedQuery(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("querying user favourites: %w", err)
+		return fmt.Errorf("querying user favourites: %w", err)
 	}
 	defer rows.Close()
 
-	var favourites []*models.FavouriteAsset
 	for rows.Next() {
-		fav, err := scanFavourite(rows)
+		fav, err := r.scanFavourite(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(fav); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating user favourites: %w", err)
+	}
+	return nil
+}
+
+// PageCursor marks where a page of a user's favourites ended: the creation
+// time and ID of its last favourite.
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// String encodes the cursor as an opaque token for clients.
+func (c PageCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID))
+}
+
+// ParsePageCursor decodes a token made by PageCursor.String.
+func ParsePageCursor(token string) (PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return PageCursor{}, errors.New("malformed cursor")
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if !ok || err != nil || id == "" {
+		return PageCursor{}, errors.New("malformed cursor")
+	}
+	return PageCursor{CreatedAt: time.UnixMicro(usec).UTC(), ID: id}, nil
+}
+
+// GetUserFavouritesPage returns up to limit of the user's favourites matching
+// provenance (see GetUserFavouritesByProvenance), newest first (ties broken
+// by descending ID), starting after cursor, or from the newest when cursor is
+// nil. The returned cursor marks the page's end and
+// is nil after the last page. Pages are read by keyset on (created_at, id),
+// using favourites_user_created_idx, so their cost does not grow with how
+// many pages come before.
+func (r *Repository) GetUserFavouritesPage(ctx context.Context, userID string, provenance models.Provenance, cursor *PageCursor, limit int) (result []*models.FavouriteAsset, next *PageCursor, err error) {
+	defer r.observe(ctx, "get_user_favourites_page", time.Now(), &err, func() int { return len(result) })
+	if limit < 1 {
+		return nil, nil, fmt.Errorf("page limit must be positive, got %d", limit)
+	}
+	query := `
+		SELECT id, user_id, asset_type, description, ` + favouriteDataColumn + `, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html, tenant_id
+		FROM favourites
+		WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []any{userID, limit + 1}
+	if cursor != nil {
+		query += ` AND (created_at, id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += provenanceScope(provenance, &args) + tenantScope(ctx, &args) + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.readPreparedQuery(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying user favourites page: %w", err)
+	}
+	defer rows.Close()
+
+	favourites := []*models.FavouriteAsset{}
+	for rows.Next() {
+		fav, err := r.scanFavourite(rows)
+		if err != nil {
+			return nil, nil, err
 		}
 		favourites = append(favourites, fav)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterating user favourites: %w", err)
+		return nil, nil, fmt.Errorf("iterating user favourites page: %w", err)
+	}
+
+	// One row beyond the limit tells whether another page follows
+	if len(favourites) <= limit {
+		return favourites, nil, nil
 	}
+	favourites = favourites[:limit]
+	last := favourites[limit-1]
+	return favourites, &PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+}
+
+// provenanceScope returns the conditions restricting a favourites query to
+// those matching every non-empty field of provenance, adding their values to
+// args. Empty fields are stored as NULL, so they never match a value.
+func provenanceScope(provenance models.Provenance, args *[]any) string {
+	var where strings.Builder
+	for _, f := range []struct{ column, value string }{
+		{"source_system", provenance.SourceSystem},
+		{"source_url", provenance.SourceURL},
+		{"favourited_from", provenance.FavouritedFrom},
+	} {
+		if f.value == "" {
+			continue
+		}
+		*args = append(*args, f.value)
+		where.WriteString(" AND " + f.column + " = $" + strconv.Itoa(len(*args)))
+	}
+	return where.String()
+}
+
+// GetRecentUserFavourites returns the user's favourites created or updated
+// at or after since, most recently changed first. The database sets updated_at
+// on insert and on every update, so it covers both creations and updates.
+func (r *Repository) GetRecentUserFavourites(ctx context.Context, userID string, since time.Time) (result []*models.FavouriteAsset, err error) {
+	defer r.observe(ctx, "get_recent_user_favourites", time.Now(), &err, func() int { return len(result) })
+	args := []any{userID, since}
+	query := `
+		SELECT id, user_id, asset_type, description, ` + favouriteDataColumn + `, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html, tenant_id
+		FROM favourites
+		WHERE user_id = $1 AND updated_at >= $2 AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		ORDER BY updated_at DESC, id`
+
+	rows, err := r.readQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying recent user favourites: %w", err)
+	}
+	defer rows.Close()
 
-	if favourites == nil {
-		favourites = []*models.FavouriteAsset{}
+	favourites := []*models.FavouriteAsset{}
+	for rows.Next() {
+		fav, err := r.scanFavourite(rows)
+		if err != nil {
+			return nil, err
+		}
+		favourites = append(favourites, fav)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating recent user favourites: %w", err)
 	}
 	return favourites, nil
 }
 
-func GetFavouriteFromDB(userID, assetID string) (*models.FavouriteAsset, error) {
+// GetFavourite returns a single favourite of the user.
+func (r *Repository) GetFavourite(ctx context.Context, userID, assetID string) (result *models.FavouriteAsset, err error) {
+	defer r.observe(ctx, "get_favourite", time.Now(), &err, func() int { return 1 })
+	return r.getFavourite(ctx, r.db, userID, assetID, "")
+}
+
+// getFavourite reads a single favourite using db. suffix is appended to the
+// query, e.g. to lock the row.
+func (r *Repository) getFavourite(ctx context.Context, db querier, userID, assetID, suffix string) (*models.FavouriteAsset, error) {
 	const query = `
-		SELECT id, user_id, asset_type, description, data, created_at, updated_at
+		SELECT id, user_id, asset_type, description, ` + favouriteDataColumn + `, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html, tenant_id
 		FROM favourites
-		WHERE user_id = $1 AND id = $2`
+		WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL`
 
-	row := DB.QueryRow(query, userID, assetID)
+	args := []any{userID, assetID}
+	row := db.QueryRowContext(ctx, query+tenantScope(ctx, &args)+suffix, args...)
 
-	var fav models.FavouriteAsset
-	var rawData []byte
-
-	err := row.Scan(
-		&fav.ID, &fav.UserID, &fav.AssetType,
-		&fav.Description, &rawData,
-		&fav.CreatedAt, &fav.UpdatedAt,
-	)
-	if err == sql.ErrNoRows {
+	fav, err := r.scanFavourite(row)
+	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
 	}
+	return fav, err
+}
+
+// FavouriteExists reports whether the user has a favourite with the asset
+// ID, without reading its data.
+func (r *Repository) FavouriteExists(ctx context.Context, userID, assetID string) (exists bool, err error) {
+	defer r.observe(ctx, "favourite_exists", time.Now(), &err, nil)
+	args := []any{userID, assetID}
+	query := `SELECT 1 FROM favourites WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL` + tenantScope(ctx, &args)
+
+	var one int
+	err = r.conn().QueryRowContext(ctx, query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("scanning favourite: %w", err)
+		return false, fmt.Errorf("checking favourite: %w", err)
 	}
+	return true, nil
+}
+
+// AddFavourite stores a new favourite and sets its UpdatedAt to the one
+// stored. A live favourite with the same asset ID fails with ErrAlreadyExists.
+func (r *Repository) AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) (err error) {
+	defer r.observe(ctx, "add_favourite", time.Now(), &err, nil)
+	return r.insertFavourite(ctx, r.conn(), favourite)
+}
+
+// AddFavouriteWithinQuota inserts the favourite only if the user has fewer
+// than limit favourites of the same asset type. The count and insert run in
+// one transaction holding a per-user advisory lock, so concurrent adds cannot
+// overshoot.
+func (r *Repository) AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) (err error) {
+	defer r.observe(ctx, "add_favourite_within_quota", time.Now(), &err, nil)
+	return r.WithTx(ctx, func(tx Tx) error {
+		return tx.AddFavouriteWithinQuota(ctx, favourite, limit)
+	})
+}
+
+// CountUserFavouritesByType returns how many favourites the user has per asset type.
+func (r *Repository) CountUserFavouritesByType(ctx context.Context, userID string) (result map[models.AssetType]int, err error) {
+	defer r.observe(ctx, "count_user_favourites_by_type", time.Now(), &err, func() int { return len(result) })
+	args := []any{userID}
+	query := `
+		SELECT asset_type, COUNT(*)
+		FROM favourites
+		WHERE user_id = $1 AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		GROUP BY asset_type`
 
-	asset, err := unmarshalAssetData(fav.AssetType, rawData)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("counting user favourites: %w", err)
 	}
-	fav.Data = asset
+	defer rows.Close()
 
-	return &fav, nil
+	counts := make(map[models.AssetType]int)
+	for rows.Next() {
+		var assetType models.AssetType
+		var count int
+		if err := rows.Scan(&assetType, &count); err != nil {
+			return nil, fmt.Errorf("scanning favourite count row: %w", err)
+		}
+		counts[assetType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating favourite counts: %w", err)
+	}
+	return counts, nil
 }
 
-func AddFavouriteInDB(ctx context.Context, favourite *models.FavouriteAsset) error {
+// FavouriteTypeStats aggregates a user's favourites of a single asset type.
+type FavouriteTypeStats struct {
+	AssetType    models.AssetType
+	Count        int
+	FirstAddedAt time.Time
+	LastAddedAt  time.Time
+	AddedSince   int // favourites created at or after the requested cut-off
+}
+
+// GetUserFavouriteStats aggregates the user's favourites per asset type in
+// a single query. Types the user has no favourites of are omitted.
+func (r *Repository) GetUserFavouriteStats(ctx context.Context, userID string, since time.Time) (result []FavouriteTypeStats, err error) {
+	defer r.observe(ctx, "get_user_favourite_stats", time.Now(), &err, func() int { return len(result) })
+	args := []any{userID, since}
+	query := `
+		SELECT asset_type, COUNT(*), MIN(created_at), MAX(created_at),
+		       COUNT(*) FILTER (WHERE created_at >= $2)
+		FROM favourites
+		WHERE user_id = $1 AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		GROUP BY asset_type`
+
+	rows, err := r.readQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating user favourites: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []FavouriteTypeStats
+	for rows.Next() {
+		var st FavouriteTypeStats
+		if err := rows.Scan(&st.AssetType, &st.Count, &st.FirstAddedAt, &st.LastAddedAt, &st.AddedSince); err != nil {
+			return nil, fmt.Errorf("scanning favourite stats row: %w", err)
+		}
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating favourite stats: %w", err)
+	}
+	return stats, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	execer
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertFavourite inserts a single favourite row using db and sets the
+// favourite's UpdatedAt to the one stored.
+func (r *Repository) insertFavourite(ctx context.Context, db querier, favourite *models.FavouriteAsset) error {
 	dataJSON, err := json.Marshal(favourite.Data)
 	if err != nil {
 		return fmt.Errorf("marshalling asset data: %w", err)
 	}
+	row := tenantRow(ctx, favourite.UserID, favourite.ID)
+	description, descriptionHTML, err := r.cipher.sealDescription(row, favourite.Description, favourite.DescriptionHTML)
+	if err != nil {
+		return err
+	}
 
-	const query = `
-		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11, $12)` +
+		reviveDeletedFavourite
+	if r.storage == StorageNormalized {
+		// The data goes to the shared catalog, which is not encrypted
+		query = insertNormalizedFavouriteQuery
+	} else if dataJSON, err = r.cipher.sealData(row, dataJSON); err != nil {
+		return err
+	}
 
-	_, err = DB.ExecContext(ctx, query,
+	var updatedAt time.Time
+	err = db.QueryRowContext(ctx, query+`
+		RETURNING updated_at`,
 		favourite.ID, favourite.UserID, string(favourite.AssetType),
-		favourite.Description, dataJSON,
+		description, dataJSON,
 		favourite.CreatedAt, favourite.UpdatedAt,
-	)
+		favourite.SourceSystem, favourite.SourceURL, favourite.FavouritedFrom,
+		descriptionHTML, TenantFromContext(ctx),
+	).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		// A live favourite with the same key is left untouched
+		return ErrAlreadyExists
+	}
 	if err != nil {
 		// Check for unique-violation (PG error code 23505)
 		if isUniqueViolation(err) {
@@ -104,27 +425,131 @@ func AddFavouriteInDB(ctx context.Context, favourite *models.FavouriteAsset) err
 		}
 		return fmt.Errorf("inserting favourite: %w", err)
 	}
+	favourite.UpdatedAt = updatedAt
 	return nil
 }
 
-func UpdateFavouriteInDB(favourite *models.FavouriteAsset) error {
+// reviveDeletedFavourite completes an INSERT of favourites so that it
+// replaces a soft-deleted favourite with the same key, as if it had been
+// purged; only the versions of the old favourite are kept. A live favourite
+// is not updated, so the INSERT affects no row for it.
+const reviveDeletedFavourite = `
+		ON CONFLICT (tenant_id, user_id, id) DO UPDATE
+		SET asset_type = EXCLUDED.asset_type, description = EXCLUDED.description,
+		    data = EXCLUDED.data, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at,
+		    source_system = EXCLUDED.source_system, source_url = EXCLUDED.source_url,
+		    favourited_from = EXCLUDED.favourited_from, description_html = EXCLUDED.description_html,
+		    deleted_at = NULL
+		WHERE favourites.deleted_at IS NOT NULL`
+
+// UpdateFavourite stores the description and asset data of a favourite and
+// sets its UpdatedAt to the one stored.
+func (r *Repository) UpdateFavourite(ctx context.Context, favourite *models.FavouriteAsset) (err error) {
+	defer r.observe(ctx, "update_favourite", time.Now(), &err, nil)
+	return r.updateFavourite(ctx, r.conn(), favourite)
+}
+
+// updateFavourite stores the description and asset data of a favourite using
+// db and sets the favourite's UpdatedAt to the one stored.
+func (r *Repository) updateFavourite(ctx context.Context, db querier, favourite *models.FavouriteAsset) error {
 	dataJSON, err := json.Marshal(favourite.Data)
 	if err != nil {
 		return fmt.Errorf("marshalling asset data: %w", err)
 	}
+	row := tenantRow(ctx, favourite.UserID, favourite.ID)
+	if dataJSON, err = r.cipher.sealData(row, dataJSON); err != nil {
+		return err
+	}
+	description, descriptionHTML, err := r.cipher.sealDescription(row, favourite.Description, favourite.DescriptionHTML)
+	if err != nil {
+		return err
+	}
 
-	const query = `
+	args := []any{
+		description, dataJSON, favourite.UpdatedAt,
+		favourite.UserID, favourite.ID, descriptionHTML,
+	}
+	query := `
 		UPDATE favourites
-		SET description = $1, data = $2, updated_at = $3
-		WHERE user_id = $4 AND id = $5`
+		SET description = $1, data = CASE WHEN data IS NOT NULL THEN $2::jsonb END, updated_at = $3,
+		    description_html = $6
+		WHERE user_id = $4 AND id = $5 AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		RETURNING updated_at`
 
-	result, err := DB.Exec(query,
-		favourite.Description, dataJSON, favourite.UpdatedAt,
-		favourite.UserID, favourite.ID,
-	)
+	var updatedAt time.Time
+	err = db.QueryRowContext(ctx, query, args...).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
 	if err != nil {
 		return fmt.Errorf("updating favourite: %w", err)
 	}
+	favourite.UpdatedAt = updatedAt
+	return nil
+}
+
+// DescriptionUpdate is a single entry of a batch description update.
+type DescriptionUpdate struct {
+	AssetID         string
+	Description     string
+	DescriptionHTML string
+}
+
+// UpdateDescriptions applies all description updates for the user in a single
+// transaction. The returned slice reports, per update, whether a favourite matched.
+// Any database error rolls back the whole batch.
+func (r *Repository) UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) (result []bool, err error) {
+	defer r.observe(ctx, "update_descriptions", time.Now(), &err, nil)
+	err = r.WithTx(ctx, func(tx Tx) error {
+		result, err = tx.UpdateDescriptions(ctx, userID, updates, updatedAt)
+		return err
+	})
+	return result, err
+}
+
+// updateDescriptions applies the description updates using db.
+func (r *Repository) updateDescriptions(ctx context.Context, db execer, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error) {
+	const query = `
+		UPDATE favourites
+		SET description = $1, updated_at = $2, description_html = $5
+		WHERE user_id = $3 AND id = $4 AND deleted_at IS NULL`
+
+	matched := make([]bool, len(updates))
+	for i, u := range updates {
+		description, descriptionHTML, err := r.cipher.sealDescription(tenantRow(ctx, userID, u.AssetID), u.Description, u.DescriptionHTML)
+		if err != nil {
+			return nil, err
+		}
+		args := []any{description, updatedAt, userID, u.AssetID, descriptionHTML}
+		result, err := db.ExecContext(ctx, query+tenantScope(ctx, &args), args...)
+		if err != nil {
+			return nil, fmt.Errorf("updating favourite %s: %w", u.AssetID, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("checking rows affected: %w", err)
+		}
+		matched[i] = rowsAffected > 0
+	}
+	return matched, nil
+}
+
+// DeleteFavourite soft-deletes a single favourite (see deleteFavourite).
+func (r *Repository) DeleteFavourite(ctx context.Context, userID, assetID string) (err error) {
+	defer r.observe(ctx, "delete_favourite", time.Now(), &err, nil)
+	return deleteFavourite(ctx, r.conn(), userID, assetID)
+}
+
+// deleteFavourite soft-deletes a single favourite using db. It stays in the
+// table, hidden from every query, until PurgeDeletedFavourites removes it.
+func deleteFavourite(ctx context.Context, db execer, userID, assetID string) error {
+	const query = `UPDATE favourites SET deleted_at = NOW() WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL`
+
+	args := []any{userID, assetID}
+	result, err := db.ExecContext(ctx, query+tenantScope(ctx, &args), args...)
+	if err != nil {
+		return fmt.Errorf("deleting favourite: %w", err)
+	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
@@ -136,39 +561,322 @@ func UpdateFavouriteInDB(favourite *models.FavouriteAsset) error {
 	return nil
 }
 
-func DeleteFavouriteFromDB(userID, assetID string) error {
-	const query = `DELETE FROM favourites WHERE user_id = $1 AND id = $2`
+// DeleteAllUserFavourites soft-deletes every favourite of the user in a
+// single statement and returns the IDs of the deleted favourites.
+func (r *Repository) DeleteAllUserFavourites(ctx context.Context, userID string) (result []string, err error) {
+	defer r.observe(ctx, "delete_all_user_favourites", time.Now(), &err, func() int { return len(result) })
+	return deleteAllUserFavourites(ctx, r.conn(), userID)
+}
 
-	result, err := DB.Exec(query, userID, assetID)
+// deleteAllUserFavourites soft-deletes every favourite of the user using db.
+func deleteAllUserFavourites(ctx context.Context, db conn, userID string) ([]string, error) {
+	args := []any{userID}
+	query := `UPDATE favourites SET deleted_at = NOW() WHERE user_id = $1 AND deleted_at IS NULL` +
+		tenantScope(ctx, &args) + ` RETURNING id`
+
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("deleting favourite: %w", err)
+		return nil, fmt.Errorf("deleting user favourites: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	deleted := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning deleted favourite: %w", err)
+		}
+		deleted = append(deleted, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating deleted favourites: %w", err)
+	}
+	return deleted, nil
+}
+
+// GetAssetOwners returns every (asset, user) pair for the given asset IDs.
+// The query scans all users' favourites, so it runs under the QueryReport time budget.
+func (r *Repository) GetAssetOwners(ctx context.Context, assetIDs []string) (result []AssetOwnership, err error) {
+	defer r.observe(ctx, "get_asset_owners", time.Now(), &err, func() int { return len(result) })
+	args := []any{pq.Array(assetIDs)}
+	query := `
+		SELECT id, user_id, asset_type
+		FROM favourites
+		WHERE id = ANY($1) AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		ORDER BY id, user_id`
+
+	var owners []AssetOwnership
+	err = r.queryWithBudget(ctx, QueryReport, func(rows *sql.Rows) (err error) {
+		owners, err = scanOwnerships(rows)
+		return err
+	}, query, args...)
 	if err != nil {
-		return fmt.Errorf("checking rows affected: %w", err)
+		return nil, fmt.Errorf("querying asset owners: %w", err)
 	}
-	if rowsAffected == 0 {
-		return ErrNotFound
+	return owners, nil
+}
+
+// DeleteAssets soft-deletes the given asset IDs from every user's
+// favourites in a single statement and returns the (asset, user) pairs that
+// were removed.
+func (r *Repository) DeleteAssets(ctx context.Context, assetIDs []string) (result []AssetOwnership, err error) {
+	defer r.observe(ctx, "delete_assets", time.Now(), &err, func() int { return len(result) })
+	return deleteAssets(ctx, r.db, assetIDs)
+}
+
+// deleteAssets soft-deletes the asset IDs from every user's favourites using db.
+func deleteAssets(ctx context.Context, db conn, assetIDs []string) ([]AssetOwnership, error) {
+	args := []any{pq.Array(assetIDs)}
+	query := `
+		UPDATE favourites SET deleted_at = NOW()
+		WHERE id = ANY($1) AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		RETURNING id, user_id, asset_type`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("deleting assets: %w", err)
 	}
-	return nil
+	return scanOwnerships(rows)
+}
+
+// PurgeDeletedFavourites permanently removes the favourites soft-deleted
+// before the cut-off, with their versions, and returns how many were removed.
+func (r *Repository) PurgeDeletedFavourites(ctx context.Context, before time.Time) (result int64, err error) {
+	defer r.observe(ctx, "purge_deleted_favourites", time.Now(), &err, func() int { return int(result) })
+	const query = `DELETE FROM favourites WHERE deleted_at < $1`
+
+	res, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("purging deleted favourites: %w", err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("checking rows affected: %w", err)
+	}
+	return purged, nil
+}
+
+// UserSummary is a user who has favourites, with how many they have.
+type UserSummary struct {
+	UserID     string `json:"user_id"`
+	Favourites int    `json:"favourites"`
+}
+
+// SearchFavouriteUsers returns up to limit users with favourites whose ID
+// starts with prefix, ordered by user ID. LIKE wildcards in prefix match literally.
+// The query runs under the QuerySearch time budget.
+func (r *Repository) SearchFavouriteUsers(ctx context.Context, prefix string, limit int) (result []UserSummary, err error) {
+	defer r.observe(ctx, "search_favourite_users", time.Now(), &err, func() int { return len(result) })
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+	args := []any{pattern, limit}
+	query := `
+		SELECT user_id, COUNT(*)
+		FROM favourites
+		WHERE user_id LIKE $1 ESCAPE '\' AND deleted_at IS NULL` + tenantScope(ctx, &args) + `
+		GROUP BY user_id
+		ORDER BY user_id
+		LIMIT $2`
+
+	users := []UserSummary{}
+	err = r.queryWithBudget(ctx, QuerySearch, func(rows *sql.Rows) error {
+		for rows.Next() {
+			var u UserSummary
+			if err := rows.Scan(&u.UserID, &u.Favourites); err != nil {
+				return fmt.Errorf("scanning user summary: %w", err)
+			}
+			users = append(users, u)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterating user summaries: %w", err)
+		}
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("searching favourite users: %w", err)
+	}
+	return users, nil
+}
+
+// MergeUserFavourites copies every favourite of sourceUserID that
+// targetUserID does not already have, in a single statement, and returns the
+// copied (asset, target user) pairs. The source favourites are left in place;
+// favourites the target user had deleted are replaced by the copies. Copies
+// stay in the tenant of their source.
+func (r *Repository) MergeUserFavourites(ctx context.Context, sourceUserID, targetUserID string, mergedAt time.Time) (result []AssetOwnership, err error) {
+	defer r.observe(ctx, "merge_user_favourites", time.Now(), &err, func() int { return len(result) })
+	if r.cipher != nil {
+		// The copies are encrypted one by one, which must not be seen half done
+		err = r.WithStoreTx(ctx, func(tx StoreTx) error {
+			result, err = tx.MergeUserFavourites(ctx, sourceUserID, targetUserID, mergedAt)
+			return err
+		})
+		return result, err
+	}
+	return r.mergeUserFavourites(ctx, r.db, sourceUserID, targetUserID, mergedAt)
+}
+
+// mergeUserFavourites copies the source user's favourites to the target user
+// using db. Encrypted values are bound to their favourite, so with encryption
+// enabled they are decrypted and encrypted again for each copy.
+func (r *Repository) mergeUserFavourites(ctx context.Context, db conn, sourceUserID, targetUserID string, mergedAt time.Time) ([]AssetOwnership, error) {
+	if r.cipher != nil {
+		return r.mergeEncryptedFavourites(ctx, db, sourceUserID, targetUserID, mergedAt)
+	}
+	args := []any{sourceUserID, targetUserID, mergedAt}
+	query := `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		SELECT id, $2, asset_type, description, data, $3, $3,
+		       source_system, source_url, favourited_from, description_html, tenant_id
+		FROM favourites
+		WHERE user_id = $1 AND deleted_at IS NULL` + tenantScope(ctx, &args) +
+		reviveDeletedFavourite + `
+		RETURNING id, user_id, asset_type`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("merging user favourites: %w", err)
+	}
+	return scanOwnerships(rows)
+}
+
+// mergeEncryptedFavourites is mergeUserFavourites with encryption enabled:
+// it reads the source favourites and inserts each copy, encrypted for the
+// target user.
+func (r *Repository) mergeEncryptedFavourites(ctx context.Context, db conn, sourceUserID, targetUserID string, mergedAt time.Time) ([]AssetOwnership, error) {
+	type sourceFavourite struct {
+		id, assetType, description, tenant            string
+		data                                          []byte
+		sourceSystem, sourceURL, favouritedFrom, html sql.NullString
+	}
+	args := []any{sourceUserID}
+	query := `
+		SELECT id, asset_type, description, data, source_system, source_url, favourited_from,
+		       description_html, tenant_id
+		FROM favourites
+		WHERE user_id = $1 AND deleted_at IS NULL` + tenantScope(ctx, &args)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("merging user favourites: %w", err)
+	}
+	// The copies are inserted once the rows are read: a connection runs one
+	// query at a time.
+	var sources []sourceFavourite
+	for rows.Next() {
+		var f sourceFavourite
+		if err := rows.Scan(&f.id, &f.assetType, &f.description, &f.data,
+			&f.sourceSystem, &f.sourceURL, &f.favouritedFrom, &f.html, &f.tenant); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning favourite row: %w", err)
+		}
+		sources = append(sources, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating user favourites: %w", err)
+	}
+
+	const insertQuery = `
+		INSERT INTO favourites (id, user_id, asset_type, description, data, created_at, updated_at,
+		                        source_system, source_url, favourited_from, description_html, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8, $9, $10, $11)` +
+		reviveDeletedFavourite + `
+		RETURNING id, user_id, asset_type`
+
+	var merged []AssetOwnership
+	for _, f := range sources {
+		source := favouriteRow{tenant: f.tenant, user: sourceUserID, id: f.id}
+		target := favouriteRow{tenant: f.tenant, user: targetUserID, id: f.id}
+		description, descriptionHTML, err := r.cipher.openDescription(source, f.description, f.html.String)
+		if err != nil {
+			return nil, fmt.Errorf("favourite %s: %w", f.id, err)
+		}
+		if description, descriptionHTML, err = r.cipher.sealDescription(target, description, descriptionHTML); err != nil {
+			return nil, err
+		}
+		html := sql.NullString{String: descriptionHTML, Valid: f.html.Valid}
+		data := f.data
+		if data != nil {
+			if data, err = r.cipher.openData(source, data); err != nil {
+				return nil, fmt.Errorf("favourite %s: %w", f.id, err)
+			}
+			if data, err = r.cipher.sealData(target, data); err != nil {
+				return nil, err
+			}
+		}
+
+		var o AssetOwnership
+		err = db.QueryRowContext(ctx, insertQuery,
+			f.id, targetUserID, f.assetType, description, data, mergedAt,
+			f.sourceSystem, f.sourceURL, f.favouritedFrom, html, f.tenant,
+		).Scan(&o.AssetID, &o.UserID, &o.AssetType)
+		if err == sql.ErrNoRows {
+			// The target user already has it
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("merging favourite %s: %w", f.id, err)
+		}
+		merged = append(merged, o)
+	}
+	return merged, nil
+}
+
+// scanOwnerships reads (id, user_id, asset_type) rows and closes them.
+func scanOwnerships(rows *sql.Rows) ([]AssetOwnership, error) {
+	defer rows.Close()
+
+	var owners []AssetOwnership
+	for rows.Next() {
+		var o AssetOwnership
+		if err := rows.Scan(&o.AssetID, &o.UserID, &o.AssetType); err != nil {
+			return nil, fmt.Errorf("scanning asset owner row: %w", err)
+		}
+		owners = append(owners, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating asset owners: %w", err)
+	}
+	return owners, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
 // scanFavourite scans a single row from the favourites table into a FavouriteAsset.
-func scanFavourite(rows *sql.Rows) (*models.FavouriteAsset, error) {
+// Errors wrap sql.ErrNoRows when scanning a *sql.Row that matched nothing.
+func (r *Repository) scanFavourite(row rowScanner) (*models.FavouriteAsset, error) {
 	var fav models.FavouriteAsset
 	var rawData []byte
+	var sourceSystem, sourceURL, favouritedFrom, descriptionHTML sql.NullString
+	var tenant string
 
-	err := rows.Scan(
+	err := row.Scan(
 		&fav.ID, &fav.UserID, &fav.AssetType,
 		&fav.Description, &rawData,
 		&fav.CreatedAt, &fav.UpdatedAt,
+		&sourceSystem, &sourceURL, &favouritedFrom, &descriptionHTML, &tenant,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scanning favourite row: %w", err)
 	}
+	fav.SourceSystem = sourceSystem.String
+	fav.SourceURL = sourceURL.String
+	fav.FavouritedFrom = favouritedFrom.String
+	stored := favouriteRow{tenant: tenant, user: fav.UserID, id: fav.ID}
+	fav.Description, fav.DescriptionHTML, err = r.cipher.openDescription(stored, fav.Description, descriptionHTML.String)
+	if err != nil {
+		return nil, fmt.Errorf("favourite %s: %w", fav.ID, err)
+	}
+	// Favourites stored before descriptions were rendered have no HTML yet.
+	if !descriptionHTML.Valid {
+		fav.DescriptionHTML = richtext.Render(fav.Description)
+	}
 
-	asset, err := unmarshalAssetData(fav.AssetType, rawData)
+	asset, err := r.unmarshalAssetData(stored, fav.AssetType, rawData)
 	if err != nil {
 		return nil, err
 	}
@@ -177,35 +885,17 @@ func scanFavourite(rows *sql.Rows) (*models.FavouriteAsset, error) {
 	return &fav, nil
 }
 
-// unmarshalAssetData deserialises JSONB data into the correct Asset implementation
-// based on the asset_type column.
-func unmarshalAssetData(assetType models.AssetType, data []byte) (models.Asset, error) {
+// unmarshalAssetData decrypts JSONB data if it is encrypted and deserialises
+// it into the Asset implementation registered for the asset_type column.
+func (r *Repository) unmarshalAssetData(row favouriteRow, assetType models.AssetType, data []byte) (models.Asset, error) {
 	if data == nil {
 		return nil, nil
 	}
-
-	switch assetType {
-	case models.AssetTypeChart:
-		var chart models.Chart
-		if err := json.Unmarshal(data, &chart); err != nil {
-			return nil, fmt.Errorf("unmarshalling chart data: %w", err)
-		}
-		return &chart, nil
-	case models.AssetTypeInsight:
-		var insight models.Insight
-		if err := json.Unmarshal(data, &insight); err != nil {
-			return nil, fmt.Errorf("unmarshalling insight data: %w", err)
-		}
-		return &insight, nil
-	case models.AssetTypeAudience:
-		var audience models.Audience
-		if err := json.Unmarshal(data, &audience); err != nil {
-			return nil, fmt.Errorf("unmarshalling audience data: %w", err)
-		}
-		return &audience, nil
-	default:
-		return nil, fmt.Errorf("unknown asset type: %s", assetType)
+	data, err := r.cipher.openData(row, data)
+	if err != nil {
+		return nil, err
 	}
+	return assets.Decode(assetType, data)
 }
 
 // isUniqueViolation checks if a PostgreSQL error is a unique constraint violation (23505).
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -12,17 +13,27 @@ import (
 	"github.com/lib/pq"
 )
 
-var testCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at"}
+var testCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at", "source_system", "source_url", "favourited_from", "description_html", "tenant_id"}
 
-func setupTestDB(t *testing.T) sqlmock.Sqlmock {
+// updatedAtRows is the updated_at the database returns for a written favourite.
+func updatedAtRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now())
+}
+
+func setupTestDB(t *testing.T) (*Repository, sqlmock.Sqlmock) {
+	t.Helper()
+	return setupTestDBWith(t, Options{})
+}
+
+// setupTestDBWith is setupTestDB with a Repository configured by opts.
+func setupTestDBWith(t *testing.T, opts Options) (*Repository, sqlmock.Sqlmock) {
 	t.Helper()
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to create sqlmock: %v", err)
 	}
 	t.Cleanup(func() { db.Close() })
-	DB = db
-	return mock
+	return NewRepository(db, opts), mock
 }
 
 func testChartJSON(id string) []byte {
@@ -30,19 +41,19 @@ func testChartJSON(id string) []byte {
 	return data
 }
 
-// --- GetUserFavouritesFromDB ---
+// --- GetUserFavourites ---
 
-func TestGetUserFavouritesFromDB(t *testing.T) {
+func TestGetUserFavourites(t *testing.T) {
 	now := time.Now()
 
 	t.Run("returns favourites", func(t *testing.T) {
-		mock := setupTestDB(t)
+		repo, mock := setupTestDB(t)
 		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 			WithArgs("user1").
 			WillReturnRows(sqlmock.NewRows(testCols).
-				AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now))
+				AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now, nil, nil, nil, nil, ""))
 
-		favs, err := GetUserFavouritesFromDB("user1")
+		favs, err := repo.GetUserFavourites(context.Background(), "user1")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -57,13 +68,34 @@ func TestGetUserFavouritesFromDB(t *testing.T) {
 		}
 	})
 
+	t.Run("decodes dashboards", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		data := []byte(`{"id":"d1","title":"Q1","widgets":[{"asset_type":"chart","asset_id":"c1","position":{"x":0,"y":0,"width":6,"height":4}}],"layout":{"columns":12}}`)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(testCols).
+				AddRow("d1", "user1", "dashboard", "desc", data, now, now, nil, nil, nil, nil, ""))
+
+		favs, err := repo.GetUserFavourites(context.Background(), "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		dashboard, ok := favs[0].Data.(*models.Dashboard)
+		if !ok {
+			t.Fatalf("expected *models.Dashboard, got %T", favs[0].Data)
+		}
+		if len(dashboard.Widgets) != 1 || dashboard.Widgets[0].Position.Width != 6 || dashboard.Layout.Columns != 12 {
+			t.Errorf("unexpected dashboard: %+v", dashboard)
+		}
+	})
+
 	t.Run("returns empty for unknown user", func(t *testing.T) {
-		mock := setupTestDB(t)
+		repo, mock := setupTestDB(t)
 		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 			WithArgs("unknown").
 			WillReturnRows(sqlmock.NewRows(testCols))
 
-		favs, err := GetUserFavouritesFromDB("unknown")
+		favs, err := repo.GetUserFavourites(context.Background(), "unknown")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -76,33 +108,252 @@ func TestGetUserFavouritesFromDB(t *testing.T) {
 	})
 
 	t.Run("returns error on query failure", func(t *testing.T) {
-		mock := setupTestDB(t)
+		repo, mock := setupTestDB(t)
 		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 			WillReturnError(fmt.Errorf("connection failed"))
 
-		_, err := GetUserFavouritesFromDB("user1")
+		_, err := repo.GetUserFavourites(context.Background(), "user1")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("cancels the query with its context", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+			WithArgs("user1").
+			WillDelayFor(time.Minute).
+			WillReturnRows(sqlmock.NewRows(testCols))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := repo.GetUserFavourites(ctx, "user1")
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("expected the query to stop with its context, took %s", elapsed)
+		}
+	})
+}
+
+// --- ForEachUserFavourite ---
+
+func TestForEachUserFavourite(t *testing.T) {
+	now := time.Now()
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(testCols).
+			AddRow("c1", "user1", "chart", "first", testChartJSON("c1"), now, now, nil, nil, nil, nil, "").
+			AddRow("c2", "user1", "chart", "second", testChartJSON("c2"), now, now, nil, nil, nil, nil, "")
+	}
+
+	t.Run("calls fn for every favourite in order", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id = \\$1 AND deleted_at IS NULL\\s+ORDER BY created_at DESC").
+			WithArgs("user1").
+			WillReturnRows(rows())
+
+		var ids []string
+		err := repo.ForEachUserFavourite(context.Background(), "user1", func(fav *models.FavouriteAsset) error {
+			ids = append(ids, fav.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(ids) != 2 || ids[0] != "c1" || ids[1] != "c2" {
+			t.Errorf("expected c1 then c2, got %v", ids)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("stops at the first error of fn", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+			WithArgs("user1").
+			WillReturnRows(rows())
+
+		stop := fmt.Errorf("stop")
+		calls := 0
+		err := repo.ForEachUserFavourite(context.Background(), "user1", func(*models.FavouriteAsset) error {
+			calls++
+			return stop
+		})
+		if err != stop {
+			t.Errorf("expected the callback's error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
+
+// --- GetUserFavouritesByProvenance ---
+
+func TestGetUserFavouritesByProvenance(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		provenance models.Provenance
+		wantQuery  string
+		wantArgs   []driver.Value
+	}{
+		{
+			name:       "by source_system",
+			provenance: models.Provenance{SourceSystem: "crm"},
+			wantQuery:  `WHERE user_id = \$1 AND deleted_at IS NULL AND source_system = \$2\s+ORDER BY`,
+			wantArgs:   []driver.Value{"user1", "crm"},
+		},
+		{
+			name:       "by every field",
+			provenance: models.Provenance{SourceSystem: "crm", SourceURL: "https://crm.example.com/r/7", FavouritedFrom: "search"},
+			wantQuery:  `AND source_system = \$2 AND source_url = \$3 AND favourited_from = \$4\s+ORDER BY`,
+			wantArgs:   []driver.Value{"user1", "crm", "https://crm.example.com/r/7", "search"},
+		},
+		{
+			name:      "empty filter lists every favourite",
+			wantQuery: `WHERE user_id = \$1 AND deleted_at IS NULL\s+ORDER BY`,
+			wantArgs:  []driver.Value{"user1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			mock.ExpectQuery(tt.wantQuery).
+				WithArgs(tt.wantArgs...).
+				WillReturnRows(sqlmock.NewRows(testCols).
+					AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now, "crm", nil, "search", nil, ""))
+
+			favs, err := repo.GetUserFavouritesByProvenance(context.Background(), "user1", tt.provenance)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(favs) != 1 || favs[0].SourceSystem != "crm" {
+				t.Errorf("unexpected favourites: %+v", favs)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+// --- GetUserFavouritesPage ---
+
+func TestGetUserFavouritesPage(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	row := func(rows *sqlmock.Rows, id string, createdAt time.Time) *sqlmock.Rows {
+		return rows.AddRow(id, "user1", "chart", "desc", testChartJSON(id), createdAt, createdAt, nil, nil, nil, nil, "")
+	}
+
+	t.Run("first page with more to come", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		rows := sqlmock.NewRows(testCols)
+		row(rows, "c3", now)
+		row(rows, "c2", now.Add(-time.Minute))
+		row(rows, "c1", now.Add(-2*time.Minute))
+		mock.ExpectQuery(`SELECT .+ FROM favourites\s+WHERE user_id = \$1 AND deleted_at IS NULL\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$2`).
+			WithArgs("user1", 3).
+			WillReturnRows(rows)
+
+		favs, next, err := repo.GetUserFavouritesPage(context.Background(), "user1", models.Provenance{}, nil, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(favs) != 2 || favs[0].ID != "c3" || favs[1].ID != "c2" {
+			t.Fatalf("unexpected page: %+v", favs)
+		}
+		if next == nil || next.ID != "c2" || !next.CreatedAt.Equal(now.Add(-time.Minute)) {
+			t.Errorf("expected a cursor after c2, got %+v", next)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("last page after a cursor", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		cursor := PageCursor{CreatedAt: now.Add(-time.Minute), ID: "c2"}
+		mock.ExpectQuery(`SELECT .+ FROM favourites\s+WHERE user_id = \$1 AND deleted_at IS NULL AND \(created_at, id\) < \(\$3, \$4\)`).
+			WithArgs("user1", 3, cursor.CreatedAt, "c2").
+			WillReturnRows(row(sqlmock.NewRows(testCols), "c1", now.Add(-2*time.Minute)))
+
+		favs, next, err := repo.GetUserFavouritesPage(context.Background(), "user1", models.Provenance{}, &cursor, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(favs) != 1 || favs[0].ID != "c1" || next != nil {
+			t.Errorf("expected the last page with c1, got %+v and cursor %+v", favs, next)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("filtered by provenance after a cursor", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		cursor := PageCursor{CreatedAt: now.Add(-time.Minute), ID: "c2"}
+		mock.ExpectQuery(`WHERE user_id = \$1 AND deleted_at IS NULL AND \(created_at, id\) < \(\$3, \$4\) AND source_system = \$5 AND favourited_from = \$6\s+ORDER BY`).
+			WithArgs("user1", 3, cursor.CreatedAt, "c2", "crm", "search").
+			WillReturnRows(row(sqlmock.NewRows(testCols), "c1", now.Add(-2*time.Minute)))
+
+		provenance := models.Provenance{SourceSystem: "crm", FavouritedFrom: "search"}
+		favs, _, err := repo.GetUserFavouritesPage(context.Background(), "user1", provenance, &cursor, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(favs) != 1 || favs[0].ID != "c1" {
+			t.Errorf("unexpected page: %+v", favs)
+		}
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
+
+	t.Run("rejects non-positive limits", func(t *testing.T) {
+		repo, _ := setupTestDB(t)
+		if _, _, err := repo.GetUserFavouritesPage(context.Background(), "user1", models.Provenance{}, nil, 0); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestPageCursor_RoundTrip(t *testing.T) {
+	cursor := PageCursor{CreatedAt: time.Date(2026, 10, 17, 9, 30, 0, 123456000, time.UTC), ID: "chart:1"}
+	got, err := ParsePageCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != cursor {
+		t.Errorf("expected %+v, got %+v", cursor, got)
+	}
+	for _, token := range []string{"", "!!", "bm9jb2xvbg", "eDpjMQ"} {
+		if _, err := ParsePageCursor(token); err == nil {
+			t.Errorf("expected %q to be rejected", token)
+		}
+	}
 }
 
-// --- GetFavouriteFromDB ---
+// --- GetFavourite ---
 
-func TestGetFavouriteFromDB(t *testing.T) {
+func TestGetFavourite(t *testing.T) {
 	now := time.Now()
 
 	t.Run("returns favourite", func(t *testing.T) {
-		mock := setupTestDB(t)
+		repo, mock := setupTestDB(t)
 		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 			WithArgs("user1", "c1").
 			WillReturnRows(sqlmock.NewRows(testCols).
-				AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now))
+				AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now, nil, nil, nil, nil, ""))
 
-		fav, err := GetFavouriteFromDB("user1", "c1")
+		fav, err := repo.GetFavourite(context.Background(), "user1", "c1")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -114,13 +365,33 @@ func TestGetFavouriteFromDB(t *testing.T) {
 		}
 	})
 
+	t.Run("maps provenance", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+			WithArgs("user1", "c1").
+			WillReturnRows(sqlmock.NewRows(testCols).
+				AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now, "crm", nil, "search", nil, ""))
+
+		fav, err := repo.GetFavourite(context.Background(), "user1", "c1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := models.Provenance{SourceSystem: "crm", FavouritedFrom: "search"}
+		if fav.Provenance != want {
+			t.Errorf("expected provenance %+v, got %+v", want, fav.Provenance)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
 	t.Run("returns ErrNotFound", func(t *testing.T) {
-		mock := setupTestDB(t)
+		repo, mock := setupTestDB(t)
 		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 			WithArgs("user1", "missing").
 			WillReturnRows(sqlmock.NewRows(testCols))
 
-		_, err := GetFavouriteFromDB("user1", "missing")
+		_, err := repo.GetFavourite(context.Background(), "user1", "missing")
 		if err != ErrNotFound {
 			t.Errorf("expected ErrNotFound, got: %v", err)
 		}
@@ -130,9 +401,49 @@ func TestGetFavouriteFromDB(t *testing.T) {
 	})
 }
 
-// --- AddFavouriteInDB ---
+// --- FavouriteExists ---
+
+func TestFavouriteExists(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		err     error
+		want    bool
+		wantErr bool
+	}{
+		{name: "existing favourite", rows: sqlmock.NewRows([]string{"?column?"}).AddRow(1), want: true},
+		{name: "missing favourite", rows: sqlmock.NewRows([]string{"?column?"})},
+		{name: "database error", err: fmt.Errorf("connection refused"), wantErr: true},
+	}
 
-func TestAddFavouriteInDB(t *testing.T) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			expect := mock.ExpectQuery(`SELECT 1 FROM favourites WHERE user_id = \$1 AND id = \$2 AND deleted_at IS NULL`).
+				WithArgs("user1", "c1")
+			if tt.err != nil {
+				expect.WillReturnError(tt.err)
+			} else {
+				expect.WillReturnRows(tt.rows)
+			}
+
+			exists, err := repo.FavouriteExists(context.Background(), "user1", "c1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exists != tt.want {
+				t.Errorf("expected exists %v, got %v", tt.want, exists)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+// --- AddFavourite ---
+
+func TestAddFavourite(t *testing.T) {
 	now := time.Now()
 	fav := &models.FavouriteAsset{
 		ID: "c1", UserID: "user1", AssetType: "chart",
@@ -141,11 +452,11 @@ func TestAddFavouriteInDB(t *testing.T) {
 	}
 
 	t.Run("inserts successfully", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("INSERT INTO favourites").
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("INSERT INTO favourites").
+			WillReturnRows(updatedAtRows())
 
-		err := AddFavouriteInDB(context.Background(), fav)
+		err := repo.AddFavourite(context.Background(), fav)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -155,11 +466,25 @@ func TestAddFavouriteInDB(t *testing.T) {
 	})
 
 	t.Run("returns ErrAlreadyExists on unique violation", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("INSERT INTO favourites").
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("INSERT INTO favourites").
 			WillReturnError(&pq.Error{Code: "23505"})
 
-		err := AddFavouriteInDB(context.Background(), fav)
+		err := repo.AddFavourite(context.Background(), fav)
+		if err != ErrAlreadyExists {
+			t.Errorf("expected ErrAlreadyExists, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("replaces a deleted favourite but not a live one", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery(`INSERT INTO favourites .+ ON CONFLICT \(tenant_id, user_id, id\) DO UPDATE .+ deleted_at = NULL\s+WHERE favourites.deleted_at IS NOT NULL`).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+
+		err := repo.AddFavourite(context.Background(), fav)
 		if err != ErrAlreadyExists {
 			t.Errorf("expected ErrAlreadyExists, got: %v", err)
 		}
@@ -169,11 +494,11 @@ func TestAddFavouriteInDB(t *testing.T) {
 	})
 
 	t.Run("returns error on insert failure", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("INSERT INTO favourites").
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("INSERT INTO favourites").
 			WillReturnError(fmt.Errorf("connection failed"))
 
-		err := AddFavouriteInDB(context.Background(), fav)
+		err := repo.AddFavourite(context.Background(), fav)
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
@@ -183,9 +508,129 @@ func TestAddFavouriteInDB(t *testing.T) {
 	})
 }
 
-// --- UpdateFavouriteInDB ---
+// --- AddFavouriteWithinQuota ---
 
-func TestUpdateFavouriteInDB(t *testing.T) {
+func TestAddFavouriteWithinQuota(t *testing.T) {
+	now := time.Now()
+	fav := &models.FavouriteAsset{
+		ID: "a1", UserID: "user1", AssetType: "audience",
+		CreatedAt: now, UpdatedAt: now,
+		Data: &models.Audience{ID: "a1"},
+	}
+
+	t.Run("inserts when under quota", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs("user1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").WithArgs("user1", "audience").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("INSERT INTO favourites").WillReturnRows(updatedAtRows())
+		mock.ExpectCommit()
+
+		if err := repo.AddFavouriteWithinQuota(context.Background(), fav, 2); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns ErrQuotaExceeded at the limit", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectRollback()
+
+		err := repo.AddFavouriteWithinQuota(context.Background(), fav, 2)
+		if err != ErrQuotaExceeded {
+			t.Errorf("expected ErrQuotaExceeded, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns ErrAlreadyExists on unique violation", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("INSERT INTO favourites").WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		err := repo.AddFavouriteWithinQuota(context.Background(), fav, 2)
+		if err != ErrAlreadyExists {
+			t.Errorf("expected ErrAlreadyExists, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+// --- CountUserFavouritesByType ---
+
+func TestCountUserFavouritesByType(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	mock.ExpectQuery("SELECT asset_type, COUNT").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"asset_type", "count"}).
+			AddRow("chart", 3).
+			AddRow("audience", 1))
+
+	counts, err := repo.CountUserFavouritesByType(context.Background(), "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counts[models.AssetTypeChart] != 3 || counts[models.AssetTypeAudience] != 1 || counts[models.AssetTypeInsight] != 0 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// --- UpdateFavourite ---
+
+var statsCols = []string{"asset_type", "count", "min", "max", "recent"}
+
+func TestGetUserFavouriteStats(t *testing.T) {
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	since := last.AddDate(0, 0, -30)
+
+	t.Run("returns per-type aggregates", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT asset_type, COUNT.+MIN\\(created_at\\), MAX\\(created_at\\).+FILTER").
+			WithArgs("user1", since).
+			WillReturnRows(sqlmock.NewRows(statsCols).
+				AddRow("chart", 3, first, last, 2).
+				AddRow("insight", 1, first, first, 0))
+
+		stats, err := repo.GetUserFavouriteStats(context.Background(), "user1", since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(stats) != 2 || stats[0].Count != 3 || stats[0].AddedSince != 2 || !stats[0].LastAddedAt.Equal(last) {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns error on query failure", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT asset_type").WillReturnError(fmt.Errorf("connection failed"))
+
+		if _, err := repo.GetUserFavouriteStats(context.Background(), "user1", since); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestUpdateFavourite(t *testing.T) {
 	now := time.Now()
 	fav := &models.FavouriteAsset{
 		ID: "c1", UserID: "user1", AssetType: "chart",
@@ -194,25 +639,29 @@ func TestUpdateFavouriteInDB(t *testing.T) {
 	}
 
 	t.Run("updates successfully", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("UPDATE favourites").
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		repo, mock := setupTestDB(t)
+		stored := now.Add(time.Second)
+		mock.ExpectQuery(`UPDATE favourites .+ RETURNING updated_at`).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(stored))
 
-		err := UpdateFavouriteInDB(fav)
+		err := repo.UpdateFavourite(context.Background(), fav)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
+		if !fav.UpdatedAt.Equal(stored) {
+			t.Errorf("expected the stored updated_at %v, got %v", stored, fav.UpdatedAt)
+		}
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
 
 	t.Run("returns ErrNotFound when no rows affected", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("UPDATE favourites").
-			WillReturnResult(sqlmock.NewResult(0, 0))
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("UPDATE favourites").
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
 
-		err := UpdateFavouriteInDB(fav)
+		err := repo.UpdateFavourite(context.Background(), fav)
 		if err != ErrNotFound {
 			t.Errorf("expected ErrNotFound, got: %v", err)
 		}
@@ -222,15 +671,64 @@ func TestUpdateFavouriteInDB(t *testing.T) {
 	})
 }
 
-// --- DeleteFavouriteFromDB ---
+// --- UpdateDescriptions ---
+
+func TestUpdateDescriptions(t *testing.T) {
+	updates := []DescriptionUpdate{
+		{AssetID: "c1", Description: "first"},
+		{AssetID: "missing", Description: "second"},
+	}
+
+	t.Run("commits and reports matches", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE favourites").
+			WithArgs("first", sqlmock.AnyArg(), "user1", "c1", "").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE favourites").
+			WithArgs("second", sqlmock.AnyArg(), "user1", "missing", "").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		matched, err := repo.UpdateDescriptions(context.Background(), "user1", updates, time.Now())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(matched) != 2 || !matched[0] || matched[1] {
+			t.Errorf("unexpected matches: %v", matched)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE favourites").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE favourites").
+			WillReturnError(fmt.Errorf("connection failed"))
+		mock.ExpectRollback()
+
+		if _, err := repo.UpdateDescriptions(context.Background(), "user1", updates, time.Now()); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
 
-func TestDeleteFavouriteFromDB(t *testing.T) {
+// --- DeleteFavourite ---
+
+func TestDeleteFavourite(t *testing.T) {
 	t.Run("deletes successfully", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("DELETE FROM favourites").
+		repo, mock := setupTestDB(t)
+		mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		err := DeleteFavouriteFromDB("user1", "c1")
+		err := repo.DeleteFavourite(context.Background(), "user1", "c1")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -240,11 +738,11 @@ func TestDeleteFavouriteFromDB(t *testing.T) {
 	})
 
 	t.Run("returns ErrNotFound when no rows affected", func(t *testing.T) {
-		mock := setupTestDB(t)
-		mock.ExpectExec("DELETE FROM favourites").
+		repo, mock := setupTestDB(t)
+		mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
-		err := DeleteFavouriteFromDB("user1", "missing")
+		err := repo.DeleteFavourite(context.Background(), "user1", "missing")
 		if err != ErrNotFound {
 			t.Errorf("expected ErrNotFound, got: %v", err)
 		}
@@ -253,3 +751,242 @@ func TestDeleteFavouriteFromDB(t *testing.T) {
 		}
 	})
 }
+
+// --- DeleteAllUserFavourites ---
+
+func TestDeleteAllUserFavourites(t *testing.T) {
+	t.Run("returns deleted IDs", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("UPDATE favourites SET deleted_at = NOW\\(\\) WHERE user_id .+ RETURNING id").
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("c1").AddRow("i1").AddRow("a1"))
+
+		deleted, err := repo.DeleteAllUserFavourites(context.Background(), "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(deleted) != 3 || deleted[0] != "c1" {
+			t.Errorf("expected 3 deleted IDs, got %v", deleted)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("zero rows is not an error", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("UPDATE favourites SET deleted_at = NOW\\(\\) WHERE user_id").
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		deleted, err := repo.DeleteAllUserFavourites(context.Background(), "user1")
+		if err != nil || deleted == nil || len(deleted) != 0 {
+			t.Errorf("expected empty result and no error, got %v, %v", deleted, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+// --- GetAssetOwners / DeleteAssets ---
+
+var ownerCols = []string{"id", "user_id", "asset_type"}
+
+func TestGetAssetOwners(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	expectBudget(mock, QueryReport)
+	mock.ExpectQuery("SELECT id, user_id, asset_type FROM favourites WHERE id = ANY").
+		WillReturnRows(sqlmock.NewRows(ownerCols).
+			AddRow("c1", "user1", "chart").
+			AddRow("c1", "user2", "chart"))
+	mock.ExpectRollback()
+
+	owners, err := repo.GetAssetOwners(context.Background(), []string{"c1", "c2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(owners) != 2 {
+		t.Fatalf("expected 2 owners, got %d", len(owners))
+	}
+	if owners[1].UserID != "user2" || owners[1].AssetType != models.AssetTypeChart {
+		t.Errorf("unexpected owner: %+v", owners[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteAssets(t *testing.T) {
+	t.Run("returns removed rows", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("UPDATE favourites SET deleted_at = NOW\\(\\) WHERE id = ANY").
+			WillReturnRows(sqlmock.NewRows(ownerCols).AddRow("i1", "user1", "insight"))
+
+		removed, err := repo.DeleteAssets(context.Background(), []string{"i1"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(removed) != 1 || removed[0].AssetID != "i1" {
+			t.Errorf("unexpected removed rows: %+v", removed)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns error on failure", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("UPDATE favourites SET deleted_at = NOW\\(\\) WHERE id = ANY").
+			WillReturnError(fmt.Errorf("connection failed"))
+
+		if _, err := repo.DeleteAssets(context.Background(), []string{"i1"}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+// --- PurgeDeletedFavourites ---
+
+func TestPurgeDeletedFavourites(t *testing.T) {
+	before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns purged count", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectExec("DELETE FROM favourites WHERE deleted_at < \\$1").WithArgs(before).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		purged, err := repo.PurgeDeletedFavourites(context.Background(), before)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if purged != 3 {
+			t.Errorf("expected 3 purged, got %d", purged)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns error on failure", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectExec("DELETE FROM favourites").WillReturnError(fmt.Errorf("connection failed"))
+
+		if _, err := repo.PurgeDeletedFavourites(context.Background(), before); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+// --- SearchFavouriteUsers / MergeUserFavourites ---
+
+func TestSearchFavouriteUsers(t *testing.T) {
+	t.Run("escapes wildcards in prefix", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		expectBudget(mock, QuerySearch)
+		mock.ExpectQuery("SELECT user_id, COUNT\\(\\*\\) FROM favourites WHERE user_id LIKE").
+			WithArgs(`a\_b\%%`, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "count"}).AddRow("a_b%1", 3))
+		mock.ExpectRollback()
+
+		users, err := repo.SearchFavouriteUsers(context.Background(), "a_b%", 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(users) != 1 || users[0].UserID != "a_b%1" || users[0].Favourites != 3 {
+			t.Errorf("unexpected users: %+v", users)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns empty slice when nothing matches", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		expectBudget(mock, QuerySearch)
+		mock.ExpectQuery("SELECT user_id").WillReturnRows(sqlmock.NewRows([]string{"user_id", "count"}))
+
+		users, err := repo.SearchFavouriteUsers(context.Background(), "zz", 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if users == nil || len(users) != 0 {
+			t.Errorf("expected empty non-nil slice, got %#v", users)
+		}
+	})
+}
+
+func TestMergeUserFavourites(t *testing.T) {
+	mergedAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("returns copied rows", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("INSERT INTO favourites .* SELECT .* ON CONFLICT \\(tenant_id, user_id, id\\) DO UPDATE .* WHERE favourites.deleted_at IS NOT NULL").
+			WithArgs("user1", "user2", mergedAt).
+			WillReturnRows(sqlmock.NewRows(ownerCols).AddRow("c1", "user2", "chart"))
+
+		merged, err := repo.MergeUserFavourites(context.Background(), "user1", "user2", mergedAt)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(merged) != 1 || merged[0].AssetID != "c1" || merged[0].UserID != "user2" {
+			t.Errorf("unexpected merged rows: %+v", merged)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns error on failure", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("INSERT INTO favourites").WillReturnError(fmt.Errorf("connection failed"))
+
+		if _, err := repo.MergeUserFavourites(context.Background(), "user1", "user2", mergedAt); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+// --- GetRecentUserFavourites ---
+
+func TestGetRecentUserFavourites(t *testing.T) {
+	since := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	now := since.Add(48 * time.Hour)
+
+	t.Run("returns favourites changed since", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id = \\$1 AND updated_at >= \\$2 AND deleted_at IS NULL ORDER BY updated_at DESC").
+			WithArgs("user1", since).
+			WillReturnRows(sqlmock.NewRows(testCols).AddRow("c1", "user1", "chart", "d", testChartJSON("c1"), now, now, nil, nil, nil, nil, ""))
+
+		favourites, err := repo.GetRecentUserFavourites(context.Background(), "user1", since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(favourites) != 1 || favourites[0].ID != "c1" {
+			t.Errorf("unexpected favourites: %+v", favourites)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("returns empty slice when nothing changed", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectQuery("SELECT (.+) FROM favourites").WillReturnRows(sqlmock.NewRows(testCols))
+
+		favourites, err := repo.GetRecentUserFavourites(context.Background(), "user1", since)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if favourites == nil || len(favourites) != 0 {
+			t.Errorf("expected empty non-nil slice, got %#v", favourites)
+		}
+	})
+}
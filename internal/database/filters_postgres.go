@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ErrDataFiltersUnavailable is returned when filtering on asset data while
+// column encryption is enabled, since encrypted data cannot be matched.
+var ErrDataFiltersUnavailable = errors.New("asset data filters are unavailable while column encryption is enabled")
+
+// DataFilter selects favourites by a top-level field of their asset data.
+// Without Contains, the field must equal Value or, for arrays, hold it; such
+// filters are served by the GIN index on the data column. With Contains, the
+// field's text must contain Value, ignoring case.
+type DataFilter struct {
+	Field    string
+	Value    string
+	Contains bool
+}
+
+// condition returns the SQL condition of f on the JSONB expression column,
+// appending its arguments to args.
+func (f DataFilter) condition(column string, args *[]any) (string, error) {
+	arg := func(v any) string {
+		*args = append(*args, v)
+		return "$" + strconv.Itoa(len(*args))
+	}
+	if f.Contains {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Value) + "%"
+		return fmt.Sprintf(`%s->>%s ILIKE %s ESCAPE '\'`, column, arg(f.Field), arg(pattern)), nil
+	}
+
+	candidates := []any{f.Value, []string{f.Value}}
+	if n, err := strconv.ParseFloat(f.Value, 64); err == nil {
+		candidates = append(candidates, n)
+	}
+	conditions := make([]string, len(candidates))
+	for i, value := range candidates {
+		doc, err := json.Marshal(map[string]any{f.Field: value})
+		if err != nil {
+			return "", fmt.Errorf("encoding filter on %s: %w", f.Field, err)
+		}
+		conditions[i] = fmt.Sprintf("%s @> %s::jsonb", column, arg(string(doc)))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", nil
+}
+
+// GetUserFavouritesMatching returns the user's favourites whose asset
+// data matches every filter and whose provenance matches every non-empty
+// field of provenance, newest first. Favourites referencing the catalog are
+// matched on their catalog entry.
+func (r *Repository) GetUserFavouritesMatching(ctx context.Context, userID string, filters []DataFilter, provenance models.Provenance) (result []*models.FavouriteAsset, err error) {
+	defer r.observe(ctx, "get_user_favourites_matching", time.Now(), &err, func() int { return len(result) })
+	if r.cipher != nil {
+		return nil, ErrDataFiltersUnavailable
+	}
+
+	args := []any{userID}
+	var where strings.Builder
+	for _, f := range filters {
+		own, err := f.condition("favourites.data", &args)
+		if err != nil {
+			return nil, err
+		}
+		catalog, err := f.condition("a.data", &args)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&where, `
+		  AND (%s OR (favourites.data IS NULL AND EXISTS (
+		        SELECT 1 FROM assets a
+		        WHERE a.asset_type = favourites.asset_type AND a.id = favourites.id AND %s)))`, own, catalog)
+	}
+
+	query := `
+		SELECT id, user_id, asset_type, description, ` + favouriteDataColumn + `, created_at, updated_at,
+		       source_system, source_url, favourited_from, description_html, tenant_id
+		FROM favourites
+		WHERE user_id = $1` + where.String() + `
+		  AND deleted_at IS NULL` + provenanceScope(provenance, &args) + tenantScope(ctx, &args) + `
+		ORDER BY created_at DESC`
+
+	rows, err := r.readQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying matching user favourites: %w", err)
+	}
+	defer rows.Close()
+
+	var favourites []*models.FavouriteAsset
+	for rows.Next() {
+		fav, err := r.scanFavourite(rows)
+		if err != nil {
+			return nil, err
+		}
+		favourites = append(favourites, fav)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating matching user favourites: %w", err)
+	}
+	return favourites, nil
+}
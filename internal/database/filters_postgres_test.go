@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestGetUserFavouritesMatching(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	now := time.Now()
+
+	filters := []DataFilter{
+		{Field: "age_groups", Value: "25-34"},
+		{Field: "title", Value: "50%", Contains: true},
+		{Field: "purchases_last_month", Value: "3"},
+	}
+	mock.ExpectQuery(`WHERE user_id = \$1\s+AND \(\(favourites.data @> \$2::jsonb OR favourites.data @> \$3::jsonb\) OR \(favourites.data IS NULL AND EXISTS \(.+a.data @> \$4::jsonb OR a.data @> \$5::jsonb\)\)\)\)`+
+		`\s+AND \(favourites.data->>\$6 ILIKE \$7 ESCAPE '\\' OR .+a.data->>\$8 ILIKE \$9 ESCAPE '\\'\)\)\)`+
+		`\s+AND \(\(favourites.data @> \$10::jsonb OR favourites.data @> \$11::jsonb OR favourites.data @> \$12::jsonb\)`).
+		WithArgs("user1",
+			`{"age_groups":"25-34"}`, `{"age_groups":["25-34"]}`, `{"age_groups":"25-34"}`, `{"age_groups":["25-34"]}`,
+			"title", `%50\%%`, "title", `%50\%%`,
+			`{"purchases_last_month":"3"}`, `{"purchases_last_month":["3"]}`, `{"purchases_last_month":3}`,
+			`{"purchases_last_month":"3"}`, `{"purchases_last_month":["3"]}`, `{"purchases_last_month":3}`).
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("a1", "user1", "audience", "desc", []byte(`{"id":"a1","gender":[],"birth_country":[],"age_groups":["25-34"],"social_media_hours_daily":"1-2","purchases_last_month":3}`), now, now, nil, nil, nil, nil, ""))
+
+	favs, err := repo.GetUserFavouritesMatching(context.Background(), "user1", filters, models.Provenance{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(favs) != 1 || favs[0].ID != "a1" {
+		t.Errorf("unexpected favourites: %+v", favs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetUserFavouritesMatching_Provenance(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	mock.ExpectQuery(`AND deleted_at IS NULL AND source_system = \$6\s+ORDER BY`).
+		WithArgs("user1", "title", "%x%", "title", "%x%", "crm").
+		WillReturnRows(sqlmock.NewRows(testCols))
+
+	provenance := models.Provenance{SourceSystem: "crm"}
+	if _, err := repo.GetUserFavouritesMatching(context.Background(), "user1", []DataFilter{{Field: "title", Value: "x", Contains: true}}, provenance); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetUserFavouritesMatching_Encrypted(t *testing.T) {
+	repo, _ := setupTestDBWith(t, Options{Cipher: testColumnCipher(t, "k1", "k1")})
+
+	_, err := repo.GetUserFavouritesMatching(context.Background(), "user1", []DataFilter{{Field: "title", Value: "x"}}, models.Provenance{})
+	if !errors.Is(err, ErrDataFiltersUnavailable) {
+		t.Errorf("expected ErrDataFiltersUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/giannis84/platform-go-challenge/internal/health"
+)
+
+// HealthCheck checks that db answers, reporting its connection pool and,
+// once reached, the version of its schema.
+func HealthCheck(db *sql.DB) health.Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		err := db.PingContext(ctx)
+		stats := db.Stats()
+		details := map[string]any{
+			"pool": map[string]int{"open": stats.OpenConnections, "in_use": stats.InUse, "idle": stats.Idle},
+		}
+		if err != nil {
+			return details, err
+		}
+		version, err := SchemaVersion(ctx, db)
+		if err != nil {
+			return details, err
+		}
+		details["migration_version"] = version
+		return details, nil
+	}
+}
+
+// HealthCheck reports how many replicas are in use; it fails while any is
+// not, as list reads then fall on fewer replicas or the primary.
+func (r *Replicas) HealthCheck(context.Context) (map[string]any, error) {
+	healthy, total := r.Healthy(), r.Len()
+	details := map[string]any{"healthy": healthy, "total": total}
+	if healthy < total {
+		return details, fmt.Errorf("%d of %d replicas unhealthy", total-healthy, total)
+	}
+	return details, nil
+}
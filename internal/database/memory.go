@@ -0,0 +1,649 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/richtext"
+)
+
+// memorySnapshotFormat and memorySnapshotVersion identify the files written
+// by MemoryRepository.SaveSnapshot.
+const (
+	memorySnapshotFormat  = "favourites-memory-snapshot"
+	memorySnapshotVersion = 1
+)
+
+// errNoOutbox is returned by the transactions of a MemoryRepository or
+// MySQLRepository when asked to record an event, as only PostgreSQL keeps an
+// event outbox.
+var errNoOutbox = errors.New("the event outbox needs PostgreSQL")
+
+// memoryKey is the primary key of a favourite, as in the favourites table.
+type memoryKey struct {
+	tenantID, userID, id string
+}
+
+// MemoryRepository is a FavouritesRepository keeping favourites in memory,
+// for demos and tests that run without a database. SaveSnapshot and
+// LoadSnapshot carry them across restarts. Favourites belong to the tenant of
+// the context they were added in and, as with Repository, reads without a
+// tenant see every tenant. Deletes remove favourites at once. As a
+// BackupStore it yields and restores only favourites.
+type MemoryRepository struct {
+	mu         sync.Mutex
+	favourites map[memoryKey]FavouriteRecord
+	saving     sync.Mutex // serialises SaveSnapshot
+}
+
+// NewMemoryRepository returns an empty MemoryRepository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{favourites: make(map[memoryKey]FavouriteRecord)}
+}
+
+// memorySnapshot is the content of a snapshot file.
+type memorySnapshot struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	SavedAt    time.Time         `json:"saved_at"`
+	Favourites []FavouriteRecord `json:"favourites"`
+}
+
+// SaveSnapshot writes every favourite to the JSON file at path, replacing it
+// atomically, so a crash while saving leaves the previous snapshot intact.
+func (m *MemoryRepository) SaveSnapshot(path string) error {
+	m.saving.Lock()
+	defer m.saving.Unlock()
+
+	snapshot := memorySnapshot{
+		Format:     memorySnapshotFormat,
+		Version:    memorySnapshotVersion,
+		SavedAt:    time.Now().UTC(),
+		Favourites: m.records(),
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := json.NewEncoder(tmp).Encode(snapshot); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot replaces the favourites with those saved at path by
+// SaveSnapshot and returns how many were loaded. A missing file loads none,
+// so the first start needs no snapshot.
+func (m *MemoryRepository) LoadSnapshot(path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("opening snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var snapshot memorySnapshot
+	if err := json.NewDecoder(f).Decode(&snapshot); err != nil {
+		return 0, fmt.Errorf("reading snapshot: %w", err)
+	}
+	if snapshot.Format != memorySnapshotFormat || snapshot.Version != memorySnapshotVersion {
+		return 0, fmt.Errorf("reading snapshot: unsupported format %q version %d", snapshot.Format, snapshot.Version)
+	}
+	favourites := make(map[memoryKey]FavouriteRecord, len(snapshot.Favourites))
+	for _, rec := range snapshot.Favourites {
+		favourites[memoryKey{rec.TenantID, rec.UserID, rec.ID}] = rec
+	}
+
+	m.mu.Lock()
+	m.favourites = favourites
+	m.mu.Unlock()
+	return len(favourites), nil
+}
+
+// GetUserFavourites returns the user's favourites, newest first.
+func (m *MemoryRepository) GetUserFavourites(ctx context.Context, userID string) ([]*models.FavouriteAsset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.userFavourites(ctx, userID, func(FavouriteRecord) bool { return true }, newestFirst)
+}
+
+// GetUserFavouritesByProvenance returns the user's favourites whose
+// provenance matches every non-empty field of provenance exactly, newest
+// first.
+func (m *MemoryRepository) GetUserFavouritesByProvenance(ctx context.Context, userID string, provenance models.Provenance) ([]*models.FavouriteAsset, error) {
+	matches := func(want, got string) bool { return want == "" || want == got }
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.userFavourites(ctx, userID, func(rec FavouriteRecord) bool {
+		return matches(provenance.SourceSystem, rec.SourceSystem) &&
+			matches(provenance.SourceURL, rec.SourceURL) &&
+			matches(provenance.FavouritedFrom, rec.FavouritedFrom)
+	}, newestFirst)
+}
+
+// ForEachUserFavourite calls fn for every favourite of the user, newest
+// first. An error from fn stops the iteration and is returned as is.
+func (m *MemoryRepository) ForEachUserFavourite(ctx context.Context, userID string, fn func(*models.FavouriteAsset) error) error {
+	favourites, err := m.GetUserFavourites(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, fav := range favourites {
+		if err := fn(fav); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRecentUserFavourites returns the user's favourites created or updated
+// at or after since, most recently changed first.
+func (m *MemoryRepository) GetRecentUserFavourites(ctx context.Context, userID string, since time.Time) ([]*models.FavouriteAsset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.userFavourites(ctx, userID, func(rec FavouriteRecord) bool { return !rec.UpdatedAt.Before(since) },
+		func(a, b FavouriteRecord) int {
+			return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(a.ID, b.ID))
+		})
+}
+
+// GetFavourite returns a single favourite of the user.
+func (m *MemoryRepository) GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getFavourite(ctx, userID, assetID)
+}
+
+// FavouriteExists reports whether the user has a favourite with the asset ID.
+func (m *MemoryRepository) FavouriteExists(ctx context.Context, userID, assetID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.find(ctx, userID, assetID)
+	return ok, nil
+}
+
+// CountUserFavouritesByType returns how many favourites the user has per asset type.
+func (m *MemoryRepository) CountUserFavouritesByType(ctx context.Context, userID string) (map[models.AssetType]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[models.AssetType]int)
+	for key, rec := range m.favourites {
+		if m.inScope(ctx, key, userID) {
+			counts[models.AssetType(rec.AssetType)]++
+		}
+	}
+	return counts, nil
+}
+
+// GetUserFavouriteStats aggregates the user's favourites per asset type,
+// ordered by type. Types the user has no favourites of are omitted.
+func (m *MemoryRepository) GetUserFavouriteStats(ctx context.Context, userID string, since time.Time) ([]FavouriteTypeStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byType := make(map[models.AssetType]*FavouriteTypeStats)
+	for key, rec := range m.favourites {
+		if !m.inScope(ctx, key, userID) {
+			continue
+		}
+		st := byType[models.AssetType(rec.AssetType)]
+		if st == nil {
+			st = &FavouriteTypeStats{AssetType: models.AssetType(rec.AssetType), FirstAddedAt: rec.CreatedAt, LastAddedAt: rec.CreatedAt}
+			byType[st.AssetType] = st
+		}
+		st.Count++
+		if rec.CreatedAt.Before(st.FirstAddedAt) {
+			st.FirstAddedAt = rec.CreatedAt
+		}
+		if rec.CreatedAt.After(st.LastAddedAt) {
+			st.LastAddedAt = rec.CreatedAt
+		}
+		if !rec.CreatedAt.Before(since) {
+			st.AddedSince++
+		}
+	}
+
+	var stats []FavouriteTypeStats
+	for _, assetType := range slices.Sorted(maps.Keys(byType)) {
+		stats = append(stats, *byType[assetType])
+	}
+	return stats, nil
+}
+
+// AddFavourite stores a new favourite. A favourite with the same asset ID
+// fails with ErrAlreadyExists.
+func (m *MemoryRepository) AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addFavourite(ctx, favourite)
+}
+
+// AddFavouriteWithinQuota stores the favourite only if the user has fewer
+// than limit favourites of the same asset type.
+func (m *MemoryRepository) AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addFavouriteWithinQuota(ctx, favourite, limit)
+}
+
+// UpdateDescriptions applies all description updates for the user at once.
+// The returned slice reports, per update, whether a favourite matched.
+func (m *MemoryRepository) UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateDescriptions(ctx, userID, updates, updatedAt), nil
+}
+
+// DeleteFavourite removes a single favourite.
+func (m *MemoryRepository) DeleteFavourite(ctx context.Context, userID, assetID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteFavourite(ctx, userID, assetID)
+}
+
+// DeleteAllUserFavourites removes every favourite of the user and returns
+// their IDs.
+func (m *MemoryRepository) DeleteAllUserFavourites(ctx context.Context, userID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteAllUserFavourites(ctx, userID), nil
+}
+
+// WithTx runs fn with the repository locked, keeping its changes when fn
+// returns nil and undoing them otherwise. fn's error is returned as is. fn
+// must only use tx, as other calls on the repository would wait for it.
+func (m *MemoryRepository) WithTx(ctx context.Context, fn func(tx Tx) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := maps.Clone(m.favourites)
+	if err := fn(memoryTx{m}); err != nil {
+		m.favourites = saved
+		return err
+	}
+	return nil
+}
+
+// newestFirst orders favourites as lists are: newest first, ties broken by
+// descending ID.
+func newestFirst(a, b FavouriteRecord) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+}
+
+// inScope reports whether the favourite under key is one of userID's in the
+// tenant of ctx. The caller holds m.mu, as for the methods below.
+func (m *MemoryRepository) inScope(ctx context.Context, key memoryKey, userID string) bool {
+	tenant := TenantFromContext(ctx)
+	return key.userID == userID && (tenant == "" || key.tenantID == tenant)
+}
+
+// find returns the key of the user's favourite with the asset ID.
+func (m *MemoryRepository) find(ctx context.Context, userID, assetID string) (memoryKey, bool) {
+	key := memoryKey{TenantFromContext(ctx), userID, assetID}
+	if _, ok := m.favourites[key]; ok || key.tenantID != "" {
+		return key, ok
+	}
+	for key := range m.favourites {
+		if key.userID == userID && key.id == assetID {
+			return key, true
+		}
+	}
+	return memoryKey{}, false
+}
+
+// userFavourites returns the user's favourites that keep accepts, ordered by order.
+func (m *MemoryRepository) userFavourites(ctx context.Context, userID string, keep func(FavouriteRecord) bool, order func(a, b FavouriteRecord) int) ([]*models.FavouriteAsset, error) {
+	var records []FavouriteRecord
+	for key, rec := range m.favourites {
+		if m.inScope(ctx, key, userID) && keep(rec) {
+			records = append(records, rec)
+		}
+	}
+	slices.SortFunc(records, order)
+
+	favourites := make([]*models.FavouriteAsset, 0, len(records))
+	for _, rec := range records {
+		fav, err := memoryFavourite(rec)
+		if err != nil {
+			return nil, err
+		}
+		favourites = append(favourites, fav)
+	}
+	return favourites, nil
+}
+
+// getFavourite returns the user's favourite with the asset ID.
+func (m *MemoryRepository) getFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error) {
+	key, ok := m.find(ctx, userID, assetID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return memoryFavourite(m.favourites[key])
+}
+
+// addFavourite stores favourite in the tenant of ctx.
+func (m *MemoryRepository) addFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	key := memoryKey{TenantFromContext(ctx), favourite.UserID, favourite.ID}
+	if _, ok := m.favourites[key]; ok {
+		return ErrAlreadyExists
+	}
+	data, err := json.Marshal(favourite.Data)
+	if err != nil {
+		return fmt.Errorf("marshalling asset data: %w", err)
+	}
+	descriptionHTML := favourite.DescriptionHTML
+	m.favourites[key] = FavouriteRecord{
+		ID:              favourite.ID,
+		UserID:          favourite.UserID,
+		TenantID:        key.tenantID,
+		AssetType:       string(favourite.AssetType),
+		Description:     favourite.Description,
+		Data:            data,
+		CreatedAt:       favourite.CreatedAt,
+		UpdatedAt:       favourite.UpdatedAt,
+		SourceSystem:    favourite.SourceSystem,
+		SourceURL:       favourite.SourceURL,
+		FavouritedFrom:  favourite.FavouritedFrom,
+		DescriptionHTML: &descriptionHTML,
+	}
+	return nil
+}
+
+// addFavouriteWithinQuota stores favourite unless the user has limit
+// favourites of its asset type.
+func (m *MemoryRepository) addFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error {
+	count := 0
+	for key, rec := range m.favourites {
+		if m.inScope(ctx, key, favourite.UserID) && rec.AssetType == string(favourite.AssetType) {
+			count++
+		}
+	}
+	if count >= limit {
+		return ErrQuotaExceeded
+	}
+	return m.addFavourite(ctx, favourite)
+}
+
+// updateFavourite stores the description and asset data of favourite.
+func (m *MemoryRepository) updateFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	key, ok := m.find(ctx, favourite.UserID, favourite.ID)
+	if !ok {
+		return ErrNotFound
+	}
+	data, err := json.Marshal(favourite.Data)
+	if err != nil {
+		return fmt.Errorf("marshalling asset data: %w", err)
+	}
+	rec := m.favourites[key]
+	descriptionHTML := favourite.DescriptionHTML
+	rec.Description, rec.DescriptionHTML = favourite.Description, &descriptionHTML
+	rec.Data, rec.UpdatedAt = data, favourite.UpdatedAt
+	m.favourites[key] = rec
+	return nil
+}
+
+// updateDescriptions applies the description updates.
+func (m *MemoryRepository) updateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) []bool {
+	matched := make([]bool, len(updates))
+	for i, u := range updates {
+		key, ok := m.find(ctx, userID, u.AssetID)
+		if !ok {
+			continue
+		}
+		rec := m.favourites[key]
+		descriptionHTML := u.DescriptionHTML
+		rec.Description, rec.DescriptionHTML, rec.UpdatedAt = u.Description, &descriptionHTML, updatedAt
+		m.favourites[key] = rec
+		matched[i] = true
+	}
+	return matched
+}
+
+// deleteFavourite removes the user's favourite with the asset ID.
+func (m *MemoryRepository) deleteFavourite(ctx context.Context, userID, assetID string) error {
+	key, ok := m.find(ctx, userID, assetID)
+	if !ok {
+		return ErrNotFound
+	}
+	delete(m.favourites, key)
+	return nil
+}
+
+// deleteAllUserFavourites removes the user's favourites and returns their IDs.
+func (m *MemoryRepository) deleteAllUserFavourites(ctx context.Context, userID string) []string {
+	deleted := []string{}
+	for key := range m.favourites {
+		if m.inScope(ctx, key, userID) {
+			delete(m.favourites, key)
+			deleted = append(deleted, key.id)
+		}
+	}
+	slices.Sort(deleted)
+	return deleted
+}
+
+// memoryFavourite decodes a stored favourite, as scanFavourite does a row.
+func memoryFavourite(rec FavouriteRecord) (*models.FavouriteAsset, error) {
+	asset, err := assets.Decode(models.AssetType(rec.AssetType), rec.Data)
+	if err != nil {
+		return nil, err
+	}
+	fav := &models.FavouriteAsset{
+		ID:          rec.ID,
+		UserID:      rec.UserID,
+		AssetType:   models.AssetType(rec.AssetType),
+		Description: rec.Description,
+		CreatedAt:   rec.CreatedAt,
+		UpdatedAt:   rec.UpdatedAt,
+		Data:        asset,
+		Provenance: models.Provenance{
+			SourceSystem:   rec.SourceSystem,
+			SourceURL:      rec.SourceURL,
+			FavouritedFrom: rec.FavouritedFrom,
+		},
+	}
+	if rec.DescriptionHTML != nil {
+		fav.DescriptionHTML = *rec.DescriptionHTML
+	} else {
+		fav.DescriptionHTML = richtext.Render(rec.Description)
+	}
+	return fav, nil
+}
+
+// memoryTx is the Tx of a MemoryRepository, used while WithTx holds its lock.
+type memoryTx struct {
+	m *MemoryRepository
+}
+
+// GetFavourite is MemoryRepository.GetFavourite within the transaction.
+func (t memoryTx) GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error) {
+	return t.m.getFavourite(ctx, userID, assetID)
+}
+
+// AddFavourite is MemoryRepository.AddFavourite within the transaction.
+func (t memoryTx) AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return t.m.addFavourite(ctx, favourite)
+}
+
+// UpdateFavourite stores the description and asset data of a favourite.
+func (t memoryTx) UpdateFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return t.m.updateFavourite(ctx, favourite)
+}
+
+// AddFavouriteWithinQuota is MemoryRepository.AddFavouriteWithinQuota within the transaction.
+func (t memoryTx) AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error {
+	return t.m.addFavouriteWithinQuota(ctx, favourite, limit)
+}
+
+// DeleteFavourite is MemoryRepository.DeleteFavourite within the transaction.
+func (t memoryTx) DeleteFavourite(ctx context.Context, userID, assetID string) error {
+	return t.m.deleteFavourite(ctx, userID, assetID)
+}
+
+// DeleteAllUserFavourites is MemoryRepository.DeleteAllUserFavourites within the transaction.
+func (t memoryTx) DeleteAllUserFavourites(ctx context.Context, userID string) ([]string, error) {
+	return t.m.deleteAllUserFavourites(ctx, userID), nil
+}
+
+// UpdateDescriptions is MemoryRepository.UpdateDescriptions within the transaction.
+func (t memoryTx) UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error) {
+	return t.m.updateDescriptions(ctx, userID, updates, updatedAt), nil
+}
+
+// AddFavouritesBatch adds the favourites one by one within the transaction,
+// returning those that already existed.
+func (t memoryTx) AddFavouritesBatch(ctx context.Context, favourites []*models.FavouriteAsset) ([]*models.FavouriteAsset, error) {
+	var conflicts []*models.FavouriteAsset
+	for _, fav := range favourites {
+		err := t.m.addFavourite(ctx, fav)
+		if errors.Is(err, ErrAlreadyExists) {
+			conflicts = append(conflicts, fav)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conflicts, nil
+}
+
+// AppendEvent fails: the event outbox needs PostgreSQL.
+func (t memoryTx) AppendEvent(context.Context, events.Event) error {
+	return errNoOutbox
+}
+
+// records returns every favourite, ordered by primary key as
+// in snapshots and backups.
+func (m *MemoryRepository) records() []FavouriteRecord {
+	m.mu.Lock()
+	records := slices.Collect(maps.Values(m.favourites))
+	m.mu.Unlock()
+	slices.SortFunc(records, func(a, b FavouriteRecord) int {
+		return cmp.Or(cmp.Compare(a.TenantID, b.TenantID), cmp.Compare(a.UserID, b.UserID), cmp.Compare(a.ID, b.ID))
+	})
+	return records
+}
+
+// EachAssetRecord yields nothing: a MemoryRepository keeps no catalog, its
+// favourites carry their asset data.
+func (m *MemoryRepository) EachAssetRecord(context.Context, func(*AssetRecord) error) error {
+	return nil
+}
+
+// EachFavouriteRecord calls fn for every favourite, in primary key order.
+func (m *MemoryRepository) EachFavouriteRecord(_ context.Context, fn func(*FavouriteRecord) error) error {
+	for _, rec := range m.records() {
+		if err := fn(&rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EachVersionRecord yields nothing: a MemoryRepository keeps no versions.
+func (m *MemoryRepository) EachVersionRecord(context.Context, func(*VersionRecord) error) error {
+	return nil
+}
+
+// EachAuditRecord yields nothing: a MemoryRepository keeps no audit trail.
+func (m *MemoryRepository) EachAuditRecord(context.Context, func(*AuditRecord) error) error {
+	return nil
+}
+
+// EachPreferenceRecord yields nothing: a MemoryRepository keeps no preferences.
+func (m *MemoryRepository) EachPreferenceRecord(context.Context, func(*PreferenceRecord) error) error {
+	return nil
+}
+
+// BeginRestore starts a restore into the repository. Favourites are staged
+// until the restore commits, which then adds them, replacing those with the
+// same key, at once.
+func (m *MemoryRepository) BeginRestore(context.Context) (Restorer, error) {
+	return &memoryRestorer{
+		m:          m,
+		catalog:    make(restoredCatalog),
+		favourites: make(map[memoryKey]FavouriteRecord),
+	}, nil
+}
+
+// memoryRestorer is the Restorer of a MemoryRepository. Catalog assets are
+// only kept for the restore, to fill in the data of favourites backed up
+// from normalized storage; versions, audit entries and preferences are
+// refused with ErrNotRestored.
+type memoryRestorer struct {
+	m          *MemoryRepository
+	catalog    restoredCatalog
+	favourites map[memoryKey]FavouriteRecord
+}
+
+// PutAsset keeps a catalog asset for the favourites restored after it.
+func (r *memoryRestorer) PutAsset(_ context.Context, rec *AssetRecord) error {
+	r.catalog.put(rec)
+	return nil
+}
+
+// PutFavourite stages a favourite, taking its data from the catalog asset
+// restored before it if it has none.
+func (r *memoryRestorer) PutFavourite(_ context.Context, rec *FavouriteRecord) error {
+	fav, err := r.catalog.favourite(rec)
+	if err != nil {
+		return err
+	}
+	r.favourites[memoryKey{fav.TenantID, fav.UserID, fav.ID}] = fav
+	return nil
+}
+
+// PutVersion fails with ErrNotRestored.
+func (r *memoryRestorer) PutVersion(context.Context, *VersionRecord) error {
+	return ErrNotRestored
+}
+
+// PutAuditEntry fails with ErrNotRestored.
+func (r *memoryRestorer) PutAuditEntry(context.Context, *AuditRecord) error {
+	return ErrNotRestored
+}
+
+// PutPreference fails with ErrNotRestored.
+func (r *memoryRestorer) PutPreference(context.Context, *PreferenceRecord) error {
+	return ErrNotRestored
+}
+
+// Commit adds the staged favourites to the repository.
+func (r *memoryRestorer) Commit(context.Context) error {
+	r.m.mu.Lock()
+	maps.Copy(r.m.favourites, r.favourites)
+	r.m.mu.Unlock()
+	r.favourites = nil
+	return nil
+}
+
+// Rollback drops the staged favourites.
+func (r *memoryRestorer) Rollback() error {
+	r.favourites = nil
+	return nil
+}
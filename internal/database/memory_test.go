@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// memoryChart returns a chart favourite of user1 created at created.
+func memoryChart(id string, created time.Time) *models.FavouriteAsset {
+	return &models.FavouriteAsset{
+		ID: id, UserID: "user1", AssetType: models.AssetTypeChart,
+		Description: "desc", DescriptionHTML: "<p>desc</p>", CreatedAt: created, UpdatedAt: created,
+		Data: &models.Chart{ID: id, Title: "T", XAxisTitle: "X", YAxisTitle: "Y"},
+	}
+}
+
+// favouriteIDs returns the IDs of favourites, in order.
+func favouriteIDs(favourites []*models.FavouriteAsset) []string {
+	ids := make([]string, len(favourites))
+	for i, fav := range favourites {
+		ids[i] = fav.ID
+	}
+	return ids
+}
+
+func TestMemoryRepository_Queries(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewMemoryRepository()
+	for i, id := range []string{"c1", "c2", "c3"} {
+		if err := repo.AddFavourite(ctx, memoryChart(id, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := repo.UpdateDescriptions(ctx, "user1", []DescriptionUpdate{{AssetID: "c1", Description: "new"}}, start.Add(5*time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	favourites, err := repo.GetUserFavourites(ctx, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := favouriteIDs(favourites), []string{"c3", "c2", "c1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected newest first %v, got %v", want, got)
+	}
+
+	recent, err := repo.GetRecentUserFavourites(ctx, "user1", start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := favouriteIDs(recent), []string{"c1", "c3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected recently changed %v, got %v", want, got)
+	}
+
+	stats, err := repo.GetUserFavouriteStats(ctx, "user1", start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []FavouriteTypeStats{{
+		AssetType: models.AssetTypeChart, Count: 3,
+		FirstAddedAt: start, LastAddedAt: start.Add(2 * time.Hour), AddedSince: 2,
+	}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("expected stats %+v, got %+v", want, stats)
+	}
+
+	if _, err := repo.GetFavourite(ctx, "user2", "c1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got: %v", err)
+	}
+}
+
+func TestMemoryRepository_WithTx(t *testing.T) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		repo := NewMemoryRepository()
+		if err := repo.AddFavourite(ctx, memoryChart("c1", time.Now())); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err := repo.WithTx(ctx, func(tx Tx) error {
+			if err := tx.DeleteFavourite(ctx, "user1", "c1"); err != nil {
+				return err
+			}
+			if err := tx.AddFavourite(ctx, memoryChart("c2", time.Now())); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("expected the abort error, got: %v", err)
+		}
+		favourites, _ := repo.GetUserFavourites(ctx, "user1")
+		if got := favouriteIDs(favourites); !reflect.DeepEqual(got, []string{"c1"}) {
+			t.Errorf("expected the transaction undone, got %v", got)
+		}
+	})
+
+	t.Run("refuses outbox events", func(t *testing.T) {
+		repo := NewMemoryRepository()
+		err := repo.WithTx(ctx, func(tx Tx) error {
+			return tx.AppendEvent(ctx, events.Event{Type: events.FavouriteAdded})
+		})
+		if !errors.Is(err, errNoOutbox) {
+			t.Errorf("expected errNoOutbox, got: %v", err)
+		}
+	})
+}
+
+func TestMemoryRepository_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "favourites.json")
+
+	t.Run("missing file loads nothing", func(t *testing.T) {
+		n, err := NewMemoryRepository().LoadSnapshot(path)
+		if err != nil || n != 0 {
+			t.Errorf("expected no favourites and no error, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		saved := NewMemoryRepository()
+		fav := memoryChart("c1", created)
+		fav.Provenance = models.Provenance{SourceSystem: "crm"}
+		if err := saved.AddFavourite(WithTenant(ctx, "acme"), fav); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := saved.SaveSnapshot(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		loaded := NewMemoryRepository()
+		n, err := loaded.LoadSnapshot(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 1 {
+			t.Fatalf("expected 1 favourite loaded, got %d", n)
+		}
+		got, err := loaded.GetFavourite(WithTenant(ctx, "acme"), "user1", "c1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, fav) {
+			t.Errorf("expected %+v, got %+v", fav, got)
+		}
+		if _, err := loaded.GetFavourite(WithTenant(ctx, "globex"), "user1", "c1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected the tenant kept, got: %v", err)
+		}
+	})
+
+	t.Run("rejects another format", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "backup.json")
+		if err := os.WriteFile(other, []byte(`{"format":"favourites-backup","version":1}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewMemoryRepository().LoadSnapshot(other); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestMemoryRepository_Restore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := NewMemoryRepository()
+	if err := repo.AddFavourite(ctx, memoryChart("c1", now)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := repo.BeginRestore(ctx)
+	if err != nil {
+		t.Fatalf("BeginRestore: %v", err)
+	}
+	if err := r.PutAsset(ctx, &AssetRecord{AssetType: "chart", ID: "c2", Data: []byte(`{"id":"c2","title":"Shared"}`), UpdatedAt: now}); err != nil {
+		t.Fatalf("PutAsset: %v", err)
+	}
+	// c2 takes its data from the catalog asset, as favourites of normalized storage
+	for _, rec := range []FavouriteRecord{
+		{ID: "c1", UserID: "user1", AssetType: "chart", Description: "restored", Data: []byte(`{"id":"c1","title":"R"}`), CreatedAt: now, UpdatedAt: now},
+		{ID: "c2", UserID: "user1", AssetType: "chart", CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := r.PutFavourite(ctx, &rec); err != nil {
+			t.Fatalf("PutFavourite %s: %v", rec.ID, err)
+		}
+	}
+	if err := r.PutVersion(ctx, &VersionRecord{UserID: "user1", AssetID: "c1", Version: 1}); !errors.Is(err, ErrNotRestored) {
+		t.Errorf("expected ErrNotRestored for a version, got %v", err)
+	}
+	if err := r.PutFavourite(ctx, &FavouriteRecord{ID: "c3", UserID: "user1", AssetType: "chart"}); err == nil {
+		t.Error("expected an error for a favourite without data or catalog asset")
+	}
+
+	// Nothing is visible before the restore commits
+	if fav, err := repo.GetFavourite(ctx, "user1", "c1"); err != nil || fav.Description != "desc" {
+		t.Errorf("expected c1 untouched before commit, got %+v, %v", fav, err)
+	}
+	if err := r.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	var restored []string
+	if err := repo.EachFavouriteRecord(ctx, func(rec *FavouriteRecord) error {
+		restored = append(restored, rec.ID+":"+rec.Description)
+		return nil
+	}); err != nil {
+		t.Fatalf("EachFavouriteRecord: %v", err)
+	}
+	if want := []string{"c1:restored", "c2:"}; !reflect.DeepEqual(restored, want) {
+		t.Errorf("expected favourites %v, got %v", want, restored)
+	}
+	fav, err := repo.GetFavourite(ctx, "user1", "c2")
+	if err != nil || fav.Data.(*models.Chart).Title != "Shared" {
+		t.Errorf("expected c2 with the catalog asset's data, got %+v, %v", fav, err)
+	}
+}
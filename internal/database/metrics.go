@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// latencyBucketsMs are the upper bounds, in milliseconds, of the latency
+// histogram of each operation; slower calls count in "le_inf" only.
+var latencyBucketsMs = []int{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// dbQueries counts the calls of each favourites operation, published by
+// expvar: calls, errors, rows returned, total time in microseconds and a
+// cumulative latency histogram, so slow requests can be told apart from slow
+// queries.
+var dbQueries = expvar.NewMap("db_queries")
+
+// dbQueriesMu serialises the creation of an operation's metrics.
+var dbQueriesMu sync.Mutex
+
+// operationMetrics returns the metrics of op, creating them on first use.
+func operationMetrics(op string) *expvar.Map {
+	if m, ok := dbQueries.Get(op).(*expvar.Map); ok {
+		return m
+	}
+	dbQueriesMu.Lock()
+	defer dbQueriesMu.Unlock()
+	if m, ok := dbQueries.Get(op).(*expvar.Map); ok {
+		return m
+	}
+	m := new(expvar.Map).Init()
+	latency := new(expvar.Map).Init()
+	for _, bound := range latencyBucketsMs {
+		latency.Add("le_"+strconv.Itoa(bound)+"ms", 0)
+	}
+	latency.Add("le_inf", 0)
+	m.Set("latency", latency)
+	for _, key := range []string{"calls", "errors", "rows", "total_us"} {
+		m.Add(key, 0)
+	}
+	dbQueries.Set(op, m)
+	return m
+}
+
+// observe records a call of op started at start that failed with *err, if
+// set, or returned rows() rows; rows may be nil for operations returning
+// none. It is meant to be deferred, with err the caller's named result.
+// Not-found and conflict outcomes are answers rather than failures and are
+// not counted as errors. With debug logging enabled, the call is also logged
+// as a span of the request's trace (see logging.RequestLogger), named after
+// op, so the slow query of a given request can be found by its request or
+// trace ID. Calls slower than the slow query threshold are logged as
+// warnings, with the ID of the request's user, whatever the log level.
+func (r *Repository) observe(ctx context.Context, op string, start time.Time, err *error, rows func() int) {
+	elapsed := time.Since(start)
+	m := operationMetrics(op)
+	m.Add("calls", 1)
+	m.Add("total_us", elapsed.Microseconds())
+	switch {
+	case *err != nil && !errors.Is(*err, ErrNotFound) && !errors.Is(*err, ErrAlreadyExists) && !errors.Is(*err, ErrQuotaExceeded):
+		m.Add("errors", 1)
+	case *err == nil && rows != nil:
+		m.Add("rows", int64(rows()))
+	}
+
+	latency := m.Get("latency").(*expvar.Map)
+	for _, bound := range latencyBucketsMs {
+		if elapsed <= time.Duration(bound)*time.Millisecond {
+			latency.Add("le_"+strconv.Itoa(bound)+"ms", 1)
+		}
+	}
+	latency.Add("le_inf", 1)
+
+	if r.slowQuery > 0 && elapsed > r.slowQuery {
+		slow := logging.Log(ctx).Layer("database").Op(op).
+			Any("duration_ms", float64(elapsed.Microseconds())/1000).
+			Any("threshold_ms", float64(r.slowQuery.Microseconds())/1000).Err(*err)
+		if userID := auth.UserIDFromContext(ctx); userID != "" {
+			slow.User(userID) // background jobs run for no user
+		}
+		slow.Warn("slow database query")
+	}
+
+	if logger := logging.FromContext(ctx); logger.Enabled(ctx, slog.LevelDebug) {
+		span := logging.With(logger).Layer("database").Op(op).Str("span_id", logging.NewSpanID()).
+			Any("duration_ms", float64(elapsed.Microseconds())/1000).Err(*err)
+		if *err == nil && rows != nil {
+			span.Int("rows", rows())
+		}
+		span.Debug("database query")
+	}
+}
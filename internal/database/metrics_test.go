@@ -0,0 +1,117 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// metric returns the counter key of op's metrics.
+func metric(op, key string) int64 {
+	return operationMetrics(op).Get(key).(*expvar.Int).Value()
+}
+
+func TestObserve(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	now := time.Now()
+	calls, errs, rows := metric("get_user_favourites", "calls"), metric("get_user_favourites", "errors"), metric("get_user_favourites", "rows")
+
+	mock.ExpectQuery("SELECT .+ FROM favourites").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now, nil, nil, nil, nil, "").
+			AddRow("c2", "user1", "chart", "desc", testChartJSON("c2"), now, now, nil, nil, nil, nil, ""))
+	if _, err := repo.GetUserFavourites(context.Background(), "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mock.ExpectQuery("SELECT .+ FROM favourites").WillReturnError(errors.New("connection reset"))
+	if _, err := repo.GetUserFavourites(context.Background(), "user1"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if got := metric("get_user_favourites", "calls") - calls; got != 2 {
+		t.Errorf("expected 2 calls, got %d", got)
+	}
+	if got := metric("get_user_favourites", "errors") - errs; got != 1 {
+		t.Errorf("expected 1 error, got %d", got)
+	}
+	if got := metric("get_user_favourites", "rows") - rows; got != 2 {
+		t.Errorf("expected 2 rows, got %d", got)
+	}
+	latency := operationMetrics("get_user_favourites").Get("latency").(*expvar.Map)
+	if got := latency.Get("le_inf").(*expvar.Int).Value(); got < 2 {
+		t.Errorf("expected both calls in the latency histogram, got %d", got)
+	}
+}
+
+func TestObserve_NotFoundIsNotAnError(t *testing.T) {
+	var err error = ErrNotFound
+	errs := metric("test_lookup", "errors")
+	NewRepository(nil, Options{}).observe(context.Background(), "test_lookup", time.Now(), &err, nil)
+	if got := metric("test_lookup", "errors") - errs; got != 0 {
+		t.Errorf("expected not found not counted as an error, got %d", got)
+	}
+}
+
+func TestObserve_LogsSpanAtDebugLevel(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})).
+		With(slog.String("request_id", "req-1"))
+	ctx := logging.NewContextWithLogger(context.Background(), logger)
+
+	mock.ExpectQuery("SELECT .+ FROM favourites").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), time.Now(), time.Now(), nil, nil, nil, nil, ""))
+	if _, err := repo.GetUserFavourites(ctx, "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`"msg":"database query"`, `"operation":"get_user_favourites"`, `"rows":1`, `"request_id":"req-1"`, `"span_id":"`, `"duration_ms":`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected span log to contain %s, got: %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	info := logging.NewContextWithLogger(context.Background(), slog.New(slog.NewJSONHandler(buf, nil)))
+	mock.ExpectQuery("SELECT .+ FROM favourites").WithArgs("user1").WillReturnRows(sqlmock.NewRows(testCols))
+	if _, err := repo.GetUserFavourites(info, "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no span log above debug level, got: %s", buf.String())
+	}
+}
+
+func TestObserve_WarnsOfSlowQueries(t *testing.T) {
+	repo := NewRepository(nil, Options{SlowQueryThreshold: 10 * time.Millisecond})
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	ctx := auth.WithUserID(logging.NewContextWithLogger(context.Background(), logger), "user1")
+
+	var err error
+	repo.observe(ctx, "test_fast", time.Now(), &err, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no warning for a fast query, got %s", buf.String())
+	}
+	repo.observe(ctx, "test_slow", time.Now().Add(-50*time.Millisecond), &err, nil)
+
+	out := buf.String()
+	for _, want := range []string{`"level":"WARN"`, `"msg":"slow database query"`, `"operation":"test_slow"`, `"user_id":"user1"`, `"duration_ms":`, `"threshold_ms":10`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the warning, got %s", want, out)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// migrationFiles holds the schema migrations, named
+// "<version>_<name>.up.sql" and "<version>_<name>.down.sql". Versions are
+// applied in increasing order; a change to the schema is a new pair of
+// files, never an edit of an applied one.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLockID is the Postgres advisory lock held while migrating, so
+// instances starting together do not apply the same migration twice.
+const migrationLockID = 4350001
+
+const versionTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER     PRIMARY KEY,
+		name       TEXT        NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`
+
+// Migration is a versioned change to the schema, with the statements applying
+// and reverting it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the embedded migrations in order of version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Migrate applies the migrations not yet recorded in the schema_migrations
+// table of db, in one transaction, and returns them. Nothing is applied when
+// one of them fails.
+func Migrate(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	err = inMigrationTx(ctx, db, func(tx *sql.Tx, versions []int) error {
+		for _, m := range migrations {
+			if slices.Contains(versions, m.Version) {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+				return fmt.Errorf("applying migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name); err != nil {
+				return fmt.Errorf("recording migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the steps most recently applied migrations of db, in
+// one transaction, and returns them.
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var reverted []Migration
+	err = inMigrationTx(ctx, db, func(tx *sql.Tx, versions []int) error {
+		for i := len(versions) - 1; i >= 0 && len(reverted) < steps; i-- {
+			idx := slices.IndexFunc(migrations, func(m Migration) bool { return m.Version == versions[i] })
+			if idx < 0 {
+				return fmt.Errorf("migration %d is applied but unknown to this build", versions[i])
+			}
+			m := migrations[idx]
+			if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+				return fmt.Errorf("reverting migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+				return fmt.Errorf("recording revert of migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// PendingMigrations returns the migrations not yet applied to db.
+func PendingMigrations(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	err = inMigrationTx(ctx, db, func(tx *sql.Tx, versions []int) error {
+		for _, m := range migrations {
+			if !slices.Contains(versions, m.Version) {
+				pending = append(pending, m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// inMigrationTx runs fn in a transaction holding the migration lock, with the
+// versions applied so far in increasing order.
+func inMigrationTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx, versions []int) error) error {
+	if _, err := db.ExecContext(ctx, versionTable); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("locking migrations: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return fmt.Errorf("reading schema_migrations: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	rows.Close()
+
+	if err := fn(tx, versions); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing migration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: expected version %d, versions must be consecutive", m.Version, m.Name, i+1)
+		}
+	}
+	if len(migrations) == 0 || migrations[0].Name != "initial" {
+		t.Fatalf("expected the initial migration first, got %+v", migrations)
+	}
+}
+
+// expectMigrationTx expects the start of a migration transaction on a
+// database where versions have been applied.
+func expectMigrationTx(mock sqlmock.Sqlmock, versions ...int) {
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, v := range versions {
+		rows.AddRow(v)
+	}
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(rows)
+}
+
+// migrationStatements match the start of each migration's up script.
+var migrationStatements = map[int]string{
+	1: "CREATE TABLE IF NOT EXISTS favourites",
+	2: "CREATE INDEX IF NOT EXISTS favourites_user_created_idx",
+	3: "CREATE INDEX IF NOT EXISTS favourites_data_gin_idx",
+	4: "CREATE INDEX IF NOT EXISTS favourites_asset_idx",
+	5: "ALTER TABLE favourites ADD COLUMN IF NOT EXISTS deleted_at",
+	6: "CREATE TABLE IF NOT EXISTS event_outbox",
+	7: "ALTER TABLE favourites ADD COLUMN IF NOT EXISTS tenant_id",
+	8: "CREATE OR REPLACE FUNCTION favourites_set_updated_at",
+	9: "ALTER TABLE favourite_audit ADD COLUMN IF NOT EXISTS tenant_id",
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		applied     []int
+		failApply   bool
+		wantApplied int
+		wantErr     bool
+	}{
+		{name: "fresh database", wantApplied: 9},
+		{name: "partly migrated", applied: []int{1}, wantApplied: 8},
+		{name: "up to date", applied: []int{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{name: "failing migration", failApply: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			expectMigrationTx(mock, tt.applied...)
+			switch {
+			case tt.failApply:
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS favourites").WillReturnError(errors.New("syntax error"))
+				mock.ExpectRollback()
+			default:
+				migrations, err := Migrations()
+				if err != nil {
+					t.Fatalf("Migrations: %v", err)
+				}
+				for _, m := range migrations[len(tt.applied):] {
+					mock.ExpectExec(migrationStatements[m.Version]).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(m.Version, m.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				mock.ExpectCommit()
+			}
+
+			applied, err := Migrate(context.Background(), db)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(applied) != tt.wantApplied {
+				t.Errorf("expected %d migrations applied, got %+v", tt.wantApplied, applied)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrationTx(mock, 1, 2)
+	mock.ExpectExec("DROP INDEX IF EXISTS favourites_user_created_idx").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations WHERE version").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reverted, err := MigrateDown(context.Background(), db, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Errorf("expected migration 2 reverted, got %+v", reverted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrationTx(mock, 1)
+	mock.ExpectCommit()
+
+	pending, err := PendingMigrations(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pending) != 8 || pending[0].Version != 2 {
+		t.Errorf("expected migrations 2 to 9 pending, got %+v", pending)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
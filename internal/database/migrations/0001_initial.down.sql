@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS user_preferences;
+DROP TABLE IF EXISTS favourite_versions;
+DROP TABLE IF EXISTS favourite_audit;
+DROP TABLE IF EXISTS assets;
+DROP TABLE IF EXISTS favourites;
@@ -0,0 +1,65 @@
+-- The schema as it stood before migrations were versioned. Statements are
+-- idempotent, so databases created back then adopt this version as they are.
+CREATE TABLE IF NOT EXISTS favourites (
+	id         TEXT        NOT NULL,
+	user_id    TEXT        NOT NULL,
+	asset_type TEXT        NOT NULL,
+	description TEXT,
+	data       JSONB,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (user_id, id)
+);
+-- Provenance columns were added after the table; existing deployments gain them here.
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS source_system   TEXT;
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS source_url      TEXT;
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS favourited_from TEXT;
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS description_html TEXT;
+
+-- Canonical asset payloads of favourites stored in normalized mode. Such
+-- favourites have data = NULL and reference their row by (asset_type, id).
+CREATE TABLE IF NOT EXISTS assets (
+	asset_type TEXT        NOT NULL,
+	id         TEXT        NOT NULL,
+	data       JSONB       NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (asset_type, id)
+);
+
+CREATE TABLE IF NOT EXISTS favourite_audit (
+	id          BIGSERIAL   PRIMARY KEY,
+	user_id     TEXT        NOT NULL,
+	actor       TEXT        NOT NULL,
+	action      TEXT        NOT NULL,
+	asset_id    TEXT        NOT NULL,
+	diff        JSONB,
+	occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS favourite_audit_user_idx ON favourite_audit (user_id, occurred_at DESC);
+
+CREATE TABLE IF NOT EXISTS favourite_versions (
+	user_id     TEXT        NOT NULL,
+	asset_id    TEXT        NOT NULL,
+	version     INTEGER     NOT NULL,
+	data        JSONB,
+	replaced_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (user_id, asset_id, version),
+	FOREIGN KEY (user_id, asset_id) REFERENCES favourites (user_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+	user_id    TEXT        PRIMARY KEY,
+	timezone   TEXT        NOT NULL DEFAULT 'UTC',
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- API keys of service clients. Only a SHA-256 hash of each key is kept.
+CREATE TABLE IF NOT EXISTS api_keys (
+	id         TEXT        PRIMARY KEY,
+	user_id    TEXT        NOT NULL,
+	name       TEXT        NOT NULL DEFAULT '',
+	key_hash   TEXT        NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS api_keys_user_idx ON api_keys (user_id);
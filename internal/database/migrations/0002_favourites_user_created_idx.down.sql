@@ -0,0 +1 @@
+DROP INDEX IF EXISTS favourites_user_created_idx;
@@ -0,0 +1,2 @@
+-- Supports keyset pagination of a user's favourites, newest first.
+CREATE INDEX IF NOT EXISTS favourites_user_created_idx ON favourites (user_id, created_at DESC, id DESC);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS favourites_data_gin_idx;
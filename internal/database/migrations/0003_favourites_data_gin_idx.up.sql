@@ -0,0 +1,3 @@
+-- Serves the @> containment filters of Repository.GetUserFavouritesMatching.
+-- jsonb_path_ops indexes are smaller than the default jsonb_ops and cover @>.
+CREATE INDEX IF NOT EXISTS favourites_data_gin_idx ON favourites USING GIN (data jsonb_path_ops);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS favourites_asset_idx;
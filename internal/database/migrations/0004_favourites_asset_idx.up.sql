@@ -0,0 +1,3 @@
+-- Serves the queries across users by asset: catalog updates reaching every
+-- favourite of an asset, and asset type renames.
+CREATE INDEX IF NOT EXISTS favourites_asset_idx ON favourites (asset_type, id);
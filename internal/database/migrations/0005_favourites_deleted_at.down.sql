@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS favourites_deleted_idx;
+ALTER TABLE favourites DROP COLUMN IF EXISTS deleted_at;
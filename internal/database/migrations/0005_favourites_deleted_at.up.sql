@@ -0,0 +1,4 @@
+-- Deleted favourites are kept as tombstones until purged after a retention
+-- period. The partial index serves the purge without growing with live rows.
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS favourites_deleted_idx ON favourites (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Change events recorded in the transaction of their change, relayed to the
+-- event bus once committed. Delivered rows are kept for a while, then removed.
+CREATE TABLE IF NOT EXISTS event_outbox (
+	id           BIGSERIAL   PRIMARY KEY,
+	event        JSONB       NOT NULL,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	delivered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS event_outbox_pending_idx ON event_outbox (id) WHERE delivered_at IS NULL;
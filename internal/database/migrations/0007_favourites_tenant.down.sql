@@ -0,0 +1 @@
+ALTER TABLE favourites DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,4 @@
+-- Favourites belong to the tenant of the user who added them. Rows written
+-- before multi-tenancy, or by single-tenant deployments, belong to the
+-- default tenant ''.
+ALTER TABLE favourites ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
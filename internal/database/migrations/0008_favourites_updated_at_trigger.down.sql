@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS favourites_set_updated_at ON favourites;
+DROP FUNCTION IF EXISTS favourites_set_updated_at();
@@ -0,0 +1,17 @@
+-- updated_at is the database's clock, not the application's: every insert
+-- and update of a favourite sets it to the transaction time. A restore keeps
+-- the timestamps of the backup by setting favourites.keep_updated_at for its
+-- transaction.
+CREATE OR REPLACE FUNCTION favourites_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF current_setting('favourites.keep_updated_at', true) IS DISTINCT FROM 'on' THEN
+        NEW.updated_at := now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS favourites_set_updated_at ON favourites;
+CREATE TRIGGER favourites_set_updated_at
+    BEFORE INSERT OR UPDATE ON favourites
+    FOR EACH ROW EXECUTE FUNCTION favourites_set_updated_at();
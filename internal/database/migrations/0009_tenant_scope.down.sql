@@ -0,0 +1,17 @@
+-- Fails while two tenants have favourites or preferences under the same user ID.
+DROP INDEX IF EXISTS api_keys_user_idx;
+CREATE INDEX api_keys_user_idx ON api_keys (user_id);
+DROP INDEX IF EXISTS favourite_audit_user_idx;
+CREATE INDEX favourite_audit_user_idx ON favourite_audit (user_id, occurred_at DESC);
+
+ALTER TABLE user_preferences DROP CONSTRAINT user_preferences_pkey, ADD PRIMARY KEY (user_id);
+ALTER TABLE favourite_versions DROP CONSTRAINT IF EXISTS favourite_versions_favourite_fkey;
+ALTER TABLE favourite_versions DROP CONSTRAINT favourite_versions_pkey, ADD PRIMARY KEY (user_id, asset_id, version);
+ALTER TABLE favourites DROP CONSTRAINT favourites_pkey, ADD PRIMARY KEY (user_id, id);
+ALTER TABLE favourite_versions ADD CONSTRAINT favourite_versions_user_id_asset_id_fkey
+	FOREIGN KEY (user_id, asset_id) REFERENCES favourites (user_id, id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE favourite_versions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE favourite_audit DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,25 @@
+-- The audit trail, versions, preferences and API keys belong to a tenant like
+-- the favourites, and a favourite is identified within its tenant, so users of
+-- different tenants may share an ID. Existing rows belong to the default
+-- tenant '', except versions, which follow their favourite, also when its
+-- tenant is assigned later.
+ALTER TABLE favourite_audit ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE favourite_versions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+
+UPDATE favourite_versions v SET tenant_id = f.tenant_id
+FROM favourites f
+WHERE f.user_id = v.user_id AND f.id = v.asset_id AND v.tenant_id <> f.tenant_id;
+
+ALTER TABLE favourite_versions DROP CONSTRAINT IF EXISTS favourite_versions_user_id_asset_id_fkey;
+ALTER TABLE favourites DROP CONSTRAINT favourites_pkey, ADD PRIMARY KEY (tenant_id, user_id, id);
+ALTER TABLE favourite_versions DROP CONSTRAINT favourite_versions_pkey, ADD PRIMARY KEY (tenant_id, user_id, asset_id, version);
+ALTER TABLE favourite_versions ADD CONSTRAINT favourite_versions_favourite_fkey
+	FOREIGN KEY (tenant_id, user_id, asset_id) REFERENCES favourites (tenant_id, user_id, id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE user_preferences DROP CONSTRAINT user_preferences_pkey, ADD PRIMARY KEY (tenant_id, user_id);
+
+DROP INDEX IF EXISTS favourite_audit_user_idx;
+CREATE INDEX favourite_audit_user_idx ON favourite_audit (tenant_id, user_id, occurred_at DESC);
+DROP INDEX IF EXISTS api_keys_user_idx;
+CREATE INDEX api_keys_user_idx ON api_keys (tenant_id, user_id);
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/lib/pq"
+)
+
+// OutboxAppended returns a channel that receives after a transaction of r
+// that recorded events commits, so the relay can deliver them without
+// waiting for its next tick. Signals are coalesced.
+func (r *Repository) OutboxAppended() <-chan struct{} {
+	return r.appended
+}
+
+// AppendEvent records e in the outbox within the transaction, so it is
+// relayed if, and only if, the transaction commits. Its Actor and OccurredAt
+// are filled in from ctx now, as the bus would on publishing. A changed
+// description is stored encrypted, like the favourite's.
+func (t *pgTx) AppendEvent(ctx context.Context, e events.Event) error {
+	e = events.Resolve(ctx, e)
+	stored := e
+	changes, err := t.r.cipher.sealChanges(favouriteRow{tenant: e.TenantID, user: e.UserID, id: e.AssetID}, e.Changes)
+	if err != nil {
+		return fmt.Errorf("encrypting event changes: %w", err)
+	}
+	stored.Changes = changes
+	payload, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("marshalling event: %w", err)
+	}
+	if _, err := t.tx.ExecContext(ctx, `INSERT INTO event_outbox (event) VALUES ($1)`, payload); err != nil {
+		return fmt.Errorf("recording event: %w", err)
+	}
+	t.appended = append(t.appended, e)
+	return nil
+}
+
+// RelayOutboxEvents passes up to limit undelivered outbox events, oldest
+// first, to publish and marks them delivered, returning how many it relayed.
+// The rows stay locked while they are published, so concurrent relays, e.g.
+// of other instances, skip them. Events are delivered at least once: should
+// marking them fail after publishing, they are published again later.
+func (r *Repository) RelayOutboxEvents(ctx context.Context, limit int, publish func(events.Event)) (result int, err error) {
+	defer r.observe(ctx, "relay_outbox_events", time.Now(), &err, func() int { return result })
+	const selectQuery = `
+		SELECT id, event
+		FROM event_outbox
+		WHERE delivered_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, selectQuery, limit)
+	if err != nil {
+		return 0, fmt.Errorf("querying outbox events: %w", err)
+	}
+	var (
+		ids     []int64
+		pending []events.Event
+	)
+	for rows.Next() {
+		var (
+			id      int64
+			payload []byte
+			e       events.Event
+		)
+		if err := rows.Scan(&id, &payload); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning outbox event: %w", err)
+		}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("decoding outbox event %d: %w", id, err)
+		}
+		row := favouriteRow{tenant: e.TenantID, user: e.UserID, id: e.AssetID}
+		if err := r.cipher.openChanges(row, e.Changes); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("decrypting outbox event %d: %w", id, err)
+		}
+		ids = append(ids, id)
+		pending = append(pending, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterating outbox events: %w", err)
+	}
+
+	for _, e := range pending {
+		publish(e)
+	}
+	if len(ids) > 0 {
+		const markQuery = `UPDATE event_outbox SET delivered_at = NOW() WHERE id = ANY($1)`
+		if _, err := tx.ExecContext(ctx, markQuery, pq.Array(ids)); err != nil {
+			return 0, fmt.Errorf("marking outbox events delivered: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing outbox relay: %w", err)
+	}
+	return len(pending), nil
+}
+
+// PurgeDeliveredEvents removes the outbox events delivered before the
+// cut-off and returns how many were removed.
+func (r *Repository) PurgeDeliveredEvents(ctx context.Context, before time.Time) (result int64, err error) {
+	defer r.observe(ctx, "purge_delivered_events", time.Now(), &err, func() int { return int(result) })
+	res, err := r.db.ExecContext(ctx, `DELETE FROM event_outbox WHERE delivered_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("purging delivered events: %w", err)
+	}
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("checking rows affected: %w", err)
+	}
+	return purged, nil
+}
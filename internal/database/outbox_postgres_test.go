@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+func TestTx_AppendEvent(t *testing.T) {
+	t.Run("signals the relay once committed", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		var committed []events.Event
+		repo.OnCommit(func(e events.Event) { committed = append(committed, e) })
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO event_outbox \(event\) VALUES \(\$1\)`).
+			WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.WithTx(context.Background(), func(tx Tx) error {
+			if err := tx.DeleteFavourite(context.Background(), "user1", "c1"); err != nil {
+				return err
+			}
+			return tx.AppendEvent(context.Background(), events.Event{Type: events.FavouriteRemoved, UserID: "user1", AssetID: "c1"})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		select {
+		case <-repo.OutboxAppended():
+		default:
+			t.Error("expected the relay to be signalled")
+		}
+		if len(committed) != 1 || committed[0].AssetID != "c1" {
+			t.Errorf("expected the event once committed, got %+v", committed)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("changed description stored encrypted", func(t *testing.T) {
+		repo, mock := setupTestDBWith(t, Options{Cipher: testColumnCipher(t, "k1", "k1")})
+		var committed []events.Event
+		repo.OnCommit(func(e events.Event) { committed = append(committed, e) })
+		var payload capturedArg
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO event_outbox").WithArgs(&payload).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.WithTx(context.Background(), func(tx Tx) error {
+			return tx.AppendEvent(context.Background(), events.Event{Type: events.FavouriteUpdated, UserID: "user1", AssetID: "c1",
+				Changes: map[string]string{"description": "quarterly revenue"}})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		<-repo.OutboxAppended()
+		stored := payload.value.([]byte)
+		if strings.Contains(string(stored), "revenue") || !strings.Contains(string(stored), encryptedPrefix+"k1:") {
+			t.Fatalf("description stored as plaintext: %s", stored)
+		}
+		if len(committed) != 1 || committed[0].Changes["description"] != "quarterly revenue" {
+			t.Errorf("expected the plaintext event once committed, got %+v", committed)
+		}
+
+		// The relay publishes the plaintext
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, event").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event"}).AddRow(int64(1), stored))
+		mock.ExpectExec("UPDATE event_outbox").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		var published []events.Event
+		if _, err := repo.RelayOutboxEvents(context.Background(), 10, func(e events.Event) { published = append(published, e) }); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(published) != 1 || published[0].Changes["description"] != "quarterly revenue" {
+			t.Errorf("expected the description decrypted, got %+v", published)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rolled back change is not signalled", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		repo.OnCommit(func(e events.Event) { t.Errorf("unexpected event after a rollback: %+v", e) })
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO event_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+
+		errAbort := errors.New("abort")
+		err := repo.WithTx(context.Background(), func(tx Tx) error {
+			if err := tx.AppendEvent(context.Background(), events.Event{Type: events.FavouriteAdded, UserID: "user1"}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("expected abort, got %v", err)
+		}
+		select {
+		case <-repo.OutboxAppended():
+			t.Error("expected no signal after a rollback")
+		default:
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRelayOutboxEvents(t *testing.T) {
+	outboxCols := []string{"id", "event"}
+
+	t.Run("publishes pending events and marks them delivered", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, event\s+FROM event_outbox\s+WHERE delivered_at IS NULL\s+ORDER BY id\s+LIMIT \$1\s+FOR UPDATE SKIP LOCKED`).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows(outboxCols).
+				AddRow(int64(7), []byte(`{"type":"favourite.added","user_id":"user1","actor":"admin","asset_id":"c1","occurred_at":"2026-10-01T10:00:00Z"}`)).
+				AddRow(int64(8), []byte(`{"type":"favourite.removed","user_id":"user1","actor":"user1","asset_id":"c2","occurred_at":"2026-10-01T10:00:01Z"}`)))
+		mock.ExpectExec(`UPDATE event_outbox SET delivered_at = NOW\(\) WHERE id = ANY\(\$1\)`).
+			WithArgs("{7,8}").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		var published []events.Event
+		n, err := repo.RelayOutboxEvents(context.Background(), 10, func(e events.Event) { published = append(published, e) })
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 2 || len(published) != 2 {
+			t.Fatalf("expected 2 events relayed, got %d: %+v", n, published)
+		}
+		want := events.Event{Type: events.FavouriteAdded, UserID: "user1", Actor: "admin", AssetID: "c1",
+			OccurredAt: time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)}
+		if got := published[0]; got.Type != want.Type || got.Actor != want.Actor || got.AssetID != want.AssetID || !got.OccurredAt.Equal(want.OccurredAt) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("failed marking rolls back", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, event").
+			WillReturnRows(sqlmock.NewRows(outboxCols).AddRow(int64(7), []byte(`{"type":"favourite.added"}`)))
+		mock.ExpectExec("UPDATE event_outbox").WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		if _, err := repo.RelayOutboxEvents(context.Background(), 10, func(events.Event) {}); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestPurgeDeliveredEvents(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	before := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`DELETE FROM event_outbox WHERE delivered_at < \$1`).WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	purged, err := repo.PurgeDeliveredEvents(context.Background(), before)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purged != 4 {
+		t.Errorf("expected 4 purged, got %d", purged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// versionsForeignKey is the name of the foreign key from favourite_versions
+// to favourites, as migration 0009 recreated it with the tenant.
+const versionsForeignKey = "favourite_versions_favourite_fkey"
+
+// PartitionFavourites turns the favourites table of db into one hash
+// partitioned on user_id into the given number of partitions, named
+// favourites_p0 onwards, and reports whether it did. A table that is
+// partitioned already is left as it is, whatever its number of partitions.
+//
+// The rows are copied in one transaction holding the migration lock, and the
+// table's indexes, triggers, primary key and the foreign key of
+// favourite_versions are recreated under their names, so queries and later
+// migrations are unaffected.
+// Hashing on user_id keeps the (tenant_id, user_id, id) primary key, which
+// must include the partition key, and sends each user's queries to a single
+// partition.
+func PartitionFavourites(ctx context.Context, db *sql.DB, partitions int) (partitioned bool, err error) {
+	if partitions < 2 {
+		return false, fmt.Errorf("favourites need at least 2 partitions, got %d", partitions)
+	}
+	err = inMigrationTx(ctx, db, func(tx *sql.Tx, _ []int) error {
+		var kind string
+		if err := tx.QueryRowContext(ctx, `SELECT relkind FROM pg_class WHERE oid = 'favourites'::regclass`).Scan(&kind); err != nil {
+			return fmt.Errorf("inspecting favourites: %w", err)
+		}
+		if kind == "p" {
+			return nil
+		}
+
+		// Index and trigger definitions name the table, which is back under
+		// its name when they are replayed
+		indexes, err := favouritesDefinitions(ctx, tx, favouritesIndexesQuery)
+		if err != nil {
+			return err
+		}
+		triggers, err := favouritesDefinitions(ctx, tx, favouritesTriggersQuery)
+		if err != nil {
+			return err
+		}
+		statements := []string{
+			`ALTER TABLE favourites RENAME TO favourites_unpartitioned`,
+			`CREATE TABLE favourites (LIKE favourites_unpartitioned INCLUDING DEFAULTS) PARTITION BY HASH (user_id)`,
+		}
+		for i := range partitions {
+			statements = append(statements, fmt.Sprintf(
+				`CREATE TABLE favourites_p%d PARTITION OF favourites FOR VALUES WITH (MODULUS %d, REMAINDER %d)`, i, partitions, i))
+		}
+		statements = append(statements,
+			`INSERT INTO favourites SELECT * FROM favourites_unpartitioned`,
+			`ALTER TABLE favourite_versions DROP CONSTRAINT IF EXISTS `+versionsForeignKey,
+			`DROP TABLE favourites_unpartitioned`,
+			`ALTER TABLE favourites ADD PRIMARY KEY (tenant_id, user_id, id)`,
+		)
+		statements = append(statements, indexes...)
+		statements = append(statements, triggers...)
+		statements = append(statements, `ALTER TABLE favourite_versions ADD CONSTRAINT `+versionsForeignKey+`
+			FOREIGN KEY (tenant_id, user_id, asset_id) REFERENCES favourites (tenant_id, user_id, id) ON DELETE CASCADE ON UPDATE CASCADE`)
+
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("partitioning favourites: %w", err)
+			}
+		}
+		partitioned = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return partitioned, nil
+}
+
+// Queries listing the statements creating the indexes of the favourites table
+// other than its primary key, and its triggers.
+const (
+	favouritesIndexesQuery = `
+		SELECT indexdef FROM pg_indexes
+		WHERE schemaname = current_schema() AND tablename = 'favourites' AND indexname <> 'favourites_pkey'
+		ORDER BY indexname`
+	favouritesTriggersQuery = `
+		SELECT pg_get_triggerdef(oid) FROM pg_trigger
+		WHERE tgrelid = 'favourites'::regclass AND NOT tgisinternal
+		ORDER BY tgname`
+)
+
+// favouritesDefinitions returns the statements listed by query.
+func favouritesDefinitions(ctx context.Context, tx *sql.Tx, query string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing favourites definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var definitions []string
+	for rows.Next() {
+		var definition string
+		if err := rows.Scan(&definition); err != nil {
+			return nil, fmt.Errorf("scanning favourites definition: %w", err)
+		}
+		definitions = append(definitions, definition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating favourites definitions: %w", err)
+	}
+	return definitions, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPartitionFavourites(t *testing.T) {
+	t.Run("converts a plain table", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create sqlmock: %v", err)
+		}
+		defer db.Close()
+
+		const assetIndex = "CREATE INDEX favourites_asset_idx ON public.favourites USING btree (asset_type, id)"
+		expectMigrationTx(mock, 1)
+		mock.ExpectQuery("SELECT relkind FROM pg_class").WillReturnRows(sqlmock.NewRows([]string{"relkind"}).AddRow("r"))
+		const trigger = "CREATE TRIGGER favourites_set_updated_at BEFORE INSERT OR UPDATE ON public.favourites FOR EACH ROW EXECUTE FUNCTION favourites_set_updated_at()"
+		mock.ExpectQuery("SELECT indexdef FROM pg_indexes").WillReturnRows(sqlmock.NewRows([]string{"indexdef"}).AddRow(assetIndex))
+		mock.ExpectQuery("SELECT pg_get_triggerdef").WillReturnRows(sqlmock.NewRows([]string{"pg_get_triggerdef"}).AddRow(trigger))
+		mock.ExpectExec("ALTER TABLE favourites RENAME TO favourites_unpartitioned").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE favourites \(LIKE favourites_unpartitioned INCLUDING DEFAULTS\) PARTITION BY HASH \(user_id\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE favourites_p0 PARTITION OF favourites FOR VALUES WITH \(MODULUS 2, REMAINDER 0\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE TABLE favourites_p1 PARTITION OF favourites FOR VALUES WITH \(MODULUS 2, REMAINDER 1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO favourites SELECT \\* FROM favourites_unpartitioned").WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectExec("ALTER TABLE favourite_versions DROP CONSTRAINT IF EXISTS favourite_versions_favourite_fkey").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DROP TABLE favourites_unpartitioned").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`ALTER TABLE favourites ADD PRIMARY KEY \(tenant_id, user_id, id\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE INDEX favourites_asset_idx ON public.favourites").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TRIGGER favourites_set_updated_at").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE favourite_versions ADD CONSTRAINT favourite_versions_favourite_fkey").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		partitioned, err := PartitionFavourites(context.Background(), db, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !partitioned {
+			t.Error("expected the table to be partitioned")
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("leaves a partitioned table", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("failed to create sqlmock: %v", err)
+		}
+		defer db.Close()
+
+		expectMigrationTx(mock, 1)
+		mock.ExpectQuery("SELECT relkind FROM pg_class").WillReturnRows(sqlmock.NewRows([]string{"relkind"}).AddRow("p"))
+		mock.ExpectCommit()
+
+		partitioned, err := PartitionFavourites(context.Background(), db, 16)
+		if err != nil || partitioned {
+			t.Errorf("expected nothing to do, got partitioned=%v err=%v", partitioned, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("needs two partitions", func(t *testing.T) {
+		if _, err := PartitionFavourites(context.Background(), nil, 1); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -3,38 +3,31 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
-const schema = `
-	CREATE TABLE IF NOT EXISTS favourites (
-		id         TEXT        NOT NULL,
-		user_id    TEXT        NOT NULL,
-		asset_type TEXT        NOT NULL,
-		description TEXT,
-		data       JSONB,
-		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-		PRIMARY KEY (user_id, id)
-	);
-`
-
-// Connect opens a PostgreSQL connection pool, verifies connectivity,
-// initialises the schema, and returns the ready-to-use *sql.DB.
+// maxIdleConns is the number of idle connections the pool keeps open.
+const maxIdleConns = 10
+
+// Connect opens a PostgreSQL connection pool, verifies connectivity, and
+// returns the *sql.DB. The schema is set up separately, by Migrate.
 func Connect(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("opening database: %w", err)
-	}
+	return ConnectFunc(func() string { return dsn }, nil)
+}
 
-	// Connection pool defaults, normally these values could be made configurable in production.
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(10)
-	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetConnMaxIdleTime(1 * time.Minute)
+// ConnectFunc is Connect with a connection string that may change, such as
+// one holding a rotated password: every new connection calls dsn. After a
+// change, RecycleConnections moves the pool over to it. A non-nil breaker
+// guards every new connection.
+func ConnectFunc(dsn func() string, breaker *Breaker) (*sql.DB, error) {
+	db := OpenFunc(dsn, breaker)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -44,15 +37,75 @@ func Connect(dsn string) (*sql.DB, error) {
 		return nil, fmt.Errorf("pinging database: %w", err)
 	}
 
-	if _, err := db.ExecContext(ctx, schema); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("initializing schema: %w", err)
+	return db, nil
+}
+
+// OpenFunc is ConnectFunc without the connectivity check, for databases that
+// may be down at startup, such as read replicas.
+func OpenFunc(dsn func() string, breaker *Breaker) *sql.DB {
+	db := sql.OpenDB(dsnConnector{dsn: dsn, breaker: breaker})
+
+	// Connection pool defaults, normally these values could be made configurable in production.
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetConnMaxIdleTime(1 * time.Minute)
+	return db
+}
+
+// dsnConnector opens connections with the connection string current at the
+// time of each connection, unless its breaker is open.
+type dsnConnector struct {
+	dsn     func() string
+	breaker *Breaker
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	connector, err := pq.NewConnector(c.dsn())
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	if c.breaker == nil {
+		return connector.Connect(ctx)
+	}
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
 	}
+	conn, err := connector.Connect(ctx)
+	c.breaker.Record(err)
+	return conn, err
+}
 
-	return db, nil
+func (c dsnConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// RecycleConnections closes db's idle connections, so they are reopened with
+// the current connection string. Connections in use are replaced once they
+// reach their maximum lifetime.
+func RecycleConnections(db *sql.DB) {
+	db.SetMaxIdleConns(0)
+	db.SetMaxIdleConns(maxIdleConns)
 }
 
-// PingDB checks database connectivity. Intended for health check endpoints.
-func PingDB(ctx context.Context) error {
-	return DB.PingContext(ctx)
+// IsUnavailable reports whether err means the database could not be reached,
+// as opposed to the statement itself failing. Such writes are safe to retry.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pge *pq.Error
+	if errors.As(err, &pge) {
+		// Class 08 is connection exceptions; 57P01-57P03 cover server
+		// shutdown and startup.
+		return pge.Code.Class() == "08" || pge.Code == "57P01" || pge.Code == "57P02" || pge.Code == "57P03"
+	}
+	return false
 }
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"bad connection", fmt.Errorf("inserting favourite: %w", driver.ErrBadConn), true},
+		{"network error", fmt.Errorf("beginning transaction: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"connection exception", &pq.Error{Code: "08006"}, true},
+		{"server shutting down", &pq.Error{Code: "57P01"}, true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"not found", ErrNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnavailable(tt.err); got != tt.want {
+				t.Errorf("IsUnavailable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDSNConnector_UsesCurrentDSN(t *testing.T) {
+	var dsns []string
+	password := "old"
+	c := dsnConnector{dsn: func() string {
+		dsn := "host=127.0.0.1 port=1 user=app password=" + password + " dbname=app sslmode=disable connect_timeout=1"
+		dsns = append(dsns, dsn)
+		return dsn
+	}}
+
+	// Nothing listens on port 1, so both attempts fail, each with the
+	// connection string current at the time.
+	if _, err := c.Connect(context.Background()); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	password = "new"
+	if _, err := c.Connect(context.Background()); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if len(dsns) != 2 || !strings.Contains(dsns[1], "password=new") {
+		t.Errorf("expected the second connection to use the rotated password, got %q", dsns)
+	}
+}
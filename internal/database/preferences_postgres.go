@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetUserTimezone returns the user's stored timezone preference, or an
+// empty string when the user has not set one.
+func (r *Repository) GetUserTimezone(ctx context.Context, userID string) (string, error) {
+	args := []any{userID}
+	query := `SELECT timezone FROM user_preferences WHERE user_id = $1` + tenantScope(ctx, &args)
+
+	var timezone string
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&timezone)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("querying user timezone: %w", err)
+	}
+	return timezone, nil
+}
+
+// SetUserTimezone stores the user's timezone preference, creating the
+// preferences row in the tenant of ctx if needed.
+func (r *Repository) SetUserTimezone(ctx context.Context, userID, timezone string) error {
+	const query = `
+		INSERT INTO user_preferences (user_id, timezone, updated_at, tenant_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE
+		SET timezone = EXCLUDED.timezone, updated_at = EXCLUDED.updated_at`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, timezone, time.Now(), TenantFromContext(ctx)); err != nil {
+		return fmt.Errorf("storing user timezone: %w", err)
+	}
+	return nil
+}
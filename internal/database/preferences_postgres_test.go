@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetUserTimezone(t *testing.T) {
+	tests := []struct {
+		name      string
+		setupMock func(sqlmock.Sqlmock)
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "returns stored timezone",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT timezone FROM user_preferences").WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow("Europe/Athens"))
+			},
+			want: "Europe/Athens",
+		},
+		{
+			name: "returns empty when unset",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT timezone FROM user_preferences").WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows([]string{"timezone"}))
+			},
+			want: "",
+		},
+		{
+			name: "returns error on query failure",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT timezone FROM user_preferences").
+					WillReturnError(fmt.Errorf("connection failed"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			tt.setupMock(mock)
+
+			got, err := repo.GetUserTimezone(context.Background(), "user1")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("wantErr=%v, got: %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetUserTimezone(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	mock.ExpectExec("INSERT INTO user_preferences .+ ON CONFLICT").
+		WithArgs("user1", "America/New_York", sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.SetUserTimezone(context.Background(), "user1", "America/New_York"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// replicaPingTimeout bounds each health check of a replica.
+const replicaPingTimeout = 2 * time.Second
+
+// Replicas is a pool of read replicas that list and report queries are sent
+// to, leaving the primary with the writes and the reads that must see
+// them. Replicas are used in turn while healthy; one that fails a health
+// check or cannot be reached by a query is skipped until a later health check
+// succeeds, and reads go to the primary when none is healthy.
+type Replicas struct {
+	pool []*replica
+	next atomic.Uint64
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// NewReplicas returns a pool of the replicas dbs, all assumed healthy.
+func NewReplicas(dbs ...*sql.DB) *Replicas {
+	r := &Replicas{pool: make([]*replica, len(dbs))}
+	for i, db := range dbs {
+		r.pool[i] = &replica{db: db}
+		r.pool[i].healthy.Store(true)
+	}
+	return r
+}
+
+// Healthy returns the number of replicas currently in use.
+func (r *Replicas) Healthy() int {
+	n := 0
+	for _, rep := range r.pool {
+		if rep.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// Len returns the number of replicas in the pool, healthy or not.
+func (r *Replicas) Len() int {
+	return len(r.pool)
+}
+
+// Check pings every replica, marking each healthy or not.
+func (r *Replicas) Check(ctx context.Context, logger *slog.Logger) {
+	for i, rep := range r.pool {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := rep.db.PingContext(pingCtx)
+		cancel()
+		was := rep.healthy.Swap(err == nil)
+		switch {
+		case err != nil && was:
+			logging.With(logger).Layer("database").Op("replica_check").Int("replica", i).Err(err).
+				Warn("read replica unhealthy; reading from the primary")
+		case err == nil && !was:
+			logging.With(logger).Layer("database").Op("replica_check").Int("replica", i).
+				Info("read replica healthy again")
+		}
+	}
+}
+
+// Run checks the replicas every interval until ctx is cancelled.
+func (r *Replicas) Run(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Check(ctx, logger)
+		}
+	}
+}
+
+// Close closes the connection pools of the replicas.
+func (r *Replicas) Close() error {
+	var first error
+	for _, rep := range r.pool {
+		if err := rep.db.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// pick returns the next healthy replica, or nil when there is none.
+func (r *Replicas) pick() *replica {
+	if r == nil || len(r.pool) == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := range uint64(len(r.pool)) {
+		rep := r.pool[(start+i)%uint64(len(r.pool))]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+type primaryReadsKey struct{}
+
+// ReadFromPrimary returns a copy of ctx whose reads skip the replicas, for
+// results that are cached: a replica that has not caught up with a write
+// would otherwise have its stale result cached until the next invalidation.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// readQuery runs a query that may see slightly stale data on a healthy
+// replica, unless ctx comes from ReadFromPrimary. A replica that cannot be
+// reached is marked unhealthy and the query retried on the primary.
+func (r *Repository) readQuery(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.readQueryOn(ctx, r.db, query, args...)
+}
+
+// readPreparedQuery is readQuery for a query whose SQL takes a few forms
+// only: on the primary it runs prepared when r is (see
+// NewPreparedRepository). Replicas run it unprepared.
+func (r *Repository) readPreparedQuery(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.readQueryOn(ctx, r.conn(), query, args...)
+}
+
+// readQueryOn is readQuery with primary running the query on the primary.
+func (r *Repository) readQueryOn(ctx context.Context, primary conn, query string, args ...any) (*sql.Rows, error) {
+	if fromPrimary, _ := ctx.Value(primaryReadsKey{}).(bool); fromPrimary {
+		return primary.QueryContext(ctx, query, args...)
+	}
+	if rep := r.replicas.pick(); rep != nil {
+		rows, err := rep.db.QueryContext(ctx, query, args...)
+		if !IsUnavailable(err) {
+			return rows, err
+		}
+		rep.healthy.Store(false)
+	}
+	return primary.QueryContext(ctx, query, args...)
+}
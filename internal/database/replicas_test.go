@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func setupTestReplica(t *testing.T) (*Replicas, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewReplicas(db), mock
+}
+
+func TestReadQuery_Replica(t *testing.T) {
+	replicas, replica := setupTestReplica(t)
+	repo, primary := setupTestDBWith(t, Options{Replicas: replicas})
+	now := time.Now()
+	replica.ExpectQuery("SELECT .+ FROM favourites").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("c1", "user1", "chart", "desc", testChartJSON("c1"), now, now, nil, nil, nil, nil, ""))
+
+	favs, err := repo.GetUserFavourites(context.Background(), "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(favs) != 1 {
+		t.Errorf("expected 1 favourite, got %d", len(favs))
+	}
+	if err := replica.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet replica expectations: %v", err)
+	}
+	if err := primary.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected primary queries: %v", err)
+	}
+}
+
+func TestReadQuery_FromPrimary(t *testing.T) {
+	replicas, replica := setupTestReplica(t)
+	repo, primary := setupTestDBWith(t, Options{Replicas: replicas})
+	primary.ExpectQuery("SELECT .+ FROM favourites").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols))
+
+	if _, err := repo.GetUserFavourites(ReadFromPrimary(context.Background()), "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := primary.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet primary expectations: %v", err)
+	}
+	if err := replica.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected replica queries: %v", err)
+	}
+}
+
+func TestReadQuery_Failover(t *testing.T) {
+	replicas, replica := setupTestReplica(t)
+	repo, primary := setupTestDBWith(t, Options{Replicas: replicas})
+	replica.ExpectQuery("SELECT .+ FROM favourites").WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")})
+	primary.ExpectQuery("SELECT .+ FROM favourites").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols))
+
+	if _, err := repo.GetUserFavourites(context.Background(), "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replicas.Healthy() != 0 {
+		t.Error("expected the unreachable replica to be marked unhealthy")
+	}
+
+	// Until a health check succeeds, reads stay on the primary
+	primary.ExpectQuery("SELECT .+ FROM favourites").WillReturnRows(sqlmock.NewRows(testCols))
+	if _, err := repo.GetUserFavourites(context.Background(), "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := primary.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet primary expectations: %v", err)
+	}
+}
+
+func TestReadQuery_StatementErrorNotRetried(t *testing.T) {
+	replicas, replica := setupTestReplica(t)
+	repo, primary := setupTestDBWith(t, Options{Replicas: replicas})
+	replica.ExpectQuery("SELECT .+ FROM favourites").WillReturnError(errors.New("syntax error"))
+
+	if _, err := repo.GetUserFavourites(context.Background(), "user1"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if replicas.Healthy() != 1 {
+		t.Error("expected the replica to stay healthy")
+	}
+	if err := primary.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected primary queries: %v", err)
+	}
+}
+
+func TestReplicas_Check(t *testing.T) {
+	replicas, replica := setupTestReplica(t)
+	logger := slog.New(slog.DiscardHandler)
+
+	replica.ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicas.Check(context.Background(), logger)
+	if replicas.Healthy() != 0 || replicas.pick() != nil {
+		t.Fatal("expected the replica to be unhealthy after a failed ping")
+	}
+
+	replica.ExpectPing()
+	replicas.Check(context.Background(), logger)
+	if replicas.Healthy() != 1 || replicas.pick() == nil {
+		t.Fatal("expected the replica to be healthy again after a successful ping")
+	}
+	if err := replica.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
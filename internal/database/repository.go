@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// Driver selects the backend storing the favourites.
+type Driver string
+
+const (
+	// DriverPostgres stores favourites in PostgreSQL through Repository,
+	// the only driver serving every feature.
+	DriverPostgres Driver = "postgres"
+	// DriverMySQL stores favourites in MySQL through a MySQLRepository.
+	// As with DriverMemory, only the favourites API is served: the features
+	// relying on jsonb operators, advisory locks and LISTEN/NOTIFY are off.
+	DriverMySQL Driver = "mysql"
+	// DriverMemory keeps favourites in a MemoryRepository, optionally
+	// snapshotted to a JSON file. Only the favourites API is served; the
+	// features needing PostgreSQL (audit, API keys, export jobs, the event
+	// outbox and the shared list cache) are off.
+	DriverMemory Driver = "memory"
+)
+
+// FavouritesRepository stores users' favourites. Repository implements it
+// on PostgreSQL and MemoryRepository in memory; the favourites handlers are
+// given one, so they can be run against either.
+type FavouritesRepository interface {
+	GetUserFavourites(ctx context.Context, userID string) ([]*models.FavouriteAsset, error)
+	GetUserFavouritesByProvenance(ctx context.Context, userID string, provenance models.Provenance) ([]*models.FavouriteAsset, error)
+	ForEachUserFavourite(ctx context.Context, userID string, fn func(*models.FavouriteAsset) error) error
+	GetRecentUserFavourites(ctx context.Context, userID string, since time.Time) ([]*models.FavouriteAsset, error)
+	GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error)
+	FavouriteExists(ctx context.Context, userID, assetID string) (bool, error)
+	CountUserFavouritesByType(ctx context.Context, userID string) (map[models.AssetType]int, error)
+	GetUserFavouriteStats(ctx context.Context, userID string, since time.Time) ([]FavouriteTypeStats, error)
+	AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error
+	AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error
+	UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error)
+	DeleteFavourite(ctx context.Context, userID, assetID string) error
+	DeleteAllUserFavourites(ctx context.Context, userID string) ([]string, error)
+	// WithTx runs fn in a transaction, committed when fn returns nil and
+	// rolled back otherwise. fn's error is returned as is.
+	WithTx(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// Store holds what the service keeps beside the favourites themselves:
+// asset versions, the audit trail, preferences, API keys, the catalog and
+// the cross-user admin queries. Repository implements it on PostgreSQL;
+// routes that need it answer 503 when the service runs without one.
+type Store interface {
+	GetUserFavouritesMatching(ctx context.Context, userID string, filters []DataFilter, provenance models.Provenance) ([]*models.FavouriteAsset, error)
+
+	ReplaceAssetData(ctx context.Context, userID, assetID string, asset models.Asset, replacedAt time.Time) (int, error)
+	RevertAssetData(ctx context.Context, userID, assetID string, version int, revertedAt time.Time) (int, error)
+	GetFavouriteVersions(ctx context.Context, userID, assetID string, assetType models.AssetType) ([]*FavouriteVersion, error)
+
+	InsertAuditEntry(ctx context.Context, entry *AuditEntry) error
+	GetAuditEntries(ctx context.Context, userID string, limit int) ([]*AuditEntry, error)
+
+	GetUserTimezone(ctx context.Context, userID string) (string, error)
+	SetUserTimezone(ctx context.Context, userID, timezone string) error
+
+	CreateAPIKey(ctx context.Context, key APIKey, hash string) error
+	ListAPIKeys(ctx context.Context, userID string) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string, revokedAt time.Time) error
+
+	UpdateCatalogAsset(ctx context.Context, asset models.Asset, updatedAt time.Time) ([]AssetOwnership, error)
+
+	GetAssetOwners(ctx context.Context, assetIDs []string) ([]AssetOwnership, error)
+	DeleteAssets(ctx context.Context, assetIDs []string) ([]AssetOwnership, error)
+	SearchFavouriteUsers(ctx context.Context, prefix string, limit int) ([]UserSummary, error)
+	MergeUserFavourites(ctx context.Context, sourceUserID, targetUserID string, mergedAt time.Time) ([]AssetOwnership, error)
+
+	// WithStoreTx runs fn in a transaction, as FavouritesRepository.WithTx.
+	WithStoreTx(ctx context.Context, fn func(tx StoreTx) error) error
+}
+
+// Options configures a Repository. The zero value reads from the primary
+// only, stores descriptions and asset data as plaintext, embeds the asset
+// data in every favourite and logs no slow queries.
+type Options struct {
+	// Replicas serve the list and report reads when set (see readQuery).
+	Replicas *Replicas
+	// Cipher encrypts descriptions and asset data when set; nil stores them
+	// as plaintext.
+	Cipher *ColumnCipher
+	// AssetStorage is the storage mode of new favourites; empty is
+	// StorageEmbedded. Reads handle both kinds of rows, so the mode can
+	// change without migrating existing favourites.
+	AssetStorage AssetStorage
+	// SlowQueryThreshold is how long a favourites operation may take before
+	// it is logged as slow; zero or negative disables the warnings.
+	SlowQueryThreshold time.Duration
+}
+
+// Repository is the FavouritesRepository and Store backed by db. Its list
+// reads go to the read replicas of its Options, if any.
+type Repository struct {
+	db        *sql.DB
+	stmts     *statements        // nil unless prepared
+	committed func(events.Event) // see OnCommit
+	appended  chan struct{}      // see OutboxAppended
+	replicas  *Replicas
+	cipher    *ColumnCipher
+	storage   AssetStorage
+	slowQuery time.Duration
+}
+
+// NewRepository returns the Repository of favourites stored in db,
+// configured by opts.
+func NewRepository(db *sql.DB, opts Options) *Repository {
+	storage := opts.AssetStorage
+	if storage == "" {
+		storage = StorageEmbedded
+	}
+	return &Repository{
+		db:        db,
+		appended:  make(chan struct{}, 1),
+		replicas:  opts.Replicas,
+		cipher:    opts.Cipher,
+		storage:   storage,
+		slowQuery: opts.SlowQueryThreshold,
+	}
+}
+
+// NewPreparedRepository is NewRepository with the single-statement lookups,
+// writes and list reads of favourites prepared on first use and reused, so
+// the database does not parse and plan them on every call. List reads sent
+// to a read replica run unprepared. Poolers that cannot keep
+// prepared statements, such as PgBouncer in transaction mode, need
+// NewRepository. Close releases the statements.
+func NewPreparedRepository(db *sql.DB, opts Options) *Repository {
+	r := NewRepository(db, opts)
+	r.stmts = &statements{db: db, stmts: make(map[string]*sql.Stmt)}
+	return r
+}
+
+// conn returns what r runs its single statements on.
+func (r *Repository) conn() conn {
+	if r.stmts != nil {
+		return r.stmts
+	}
+	return r.db
+}
+
+// OnCommit has fn called with every event a transaction of r records in the
+// event outbox once the transaction commits, ahead of the relay publishing it
+// to the bus. It serves local state that must follow a change at once, such
+// as the list cache. OnCommit must be called before r is used.
+func (r *Repository) OnCommit(fn func(events.Event)) {
+	r.committed = fn
+}
+
+// Close closes the prepared statements of r, if any; db is left open.
+func (r *Repository) Close() error {
+	if r.stmts == nil {
+		return nil
+	}
+	return r.stmts.Close()
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// schemaColumns lists, per table, the columns the service reads and writes.
+// Migrations adding a column the code relies on add it here too.
+var schemaColumns = map[string][]string{
+	"favourites": {"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at",
+		"source_system", "source_url", "favourited_from", "description_html", "deleted_at", "tenant_id"},
+	"assets":             {"asset_type", "id", "data", "updated_at"},
+	"favourite_audit":    {"id", "user_id", "actor", "action", "asset_id", "diff", "occurred_at", "tenant_id"},
+	"favourite_versions": {"user_id", "asset_id", "version", "data", "replaced_at", "tenant_id"},
+	"user_preferences":   {"user_id", "timezone", "updated_at", "tenant_id"},
+	"api_keys":           {"id", "user_id", "name", "key_hash", "created_at", "revoked_at", "tenant_id"},
+	"event_outbox":       {"id", "event", "created_at", "delivered_at"},
+}
+
+// schemaIndexes lists the indexes the service's queries rely on to stay fast.
+var schemaIndexes = []string{
+	"favourite_audit_user_idx",
+	"api_keys_user_idx",
+	"favourites_user_created_idx",
+	"favourites_data_gin_idx",
+	"favourites_asset_idx",
+	"favourites_deleted_idx",
+	"event_outbox_pending_idx",
+}
+
+// VerifySchema checks that db has been migrated at least to the latest
+// migration of this build and has every column and index the service uses,
+// so a database it cannot work with is reported at startup by name rather
+// than by the first query that fails. Columns and indexes missing are all
+// listed in the error.
+func VerifySchema(ctx context.Context, db *sql.DB) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	version, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("schema is at migration %d but the service needs %d; run the migrate command", version, latest)
+	}
+
+	columns, err := schemaNames(ctx, db, `
+		SELECT table_name || '.' || column_name FROM information_schema.columns
+		WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("listing columns: %w", err)
+	}
+	indexes, err := schemaNames(ctx, db, `SELECT indexname FROM pg_indexes WHERE schemaname = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("listing indexes: %w", err)
+	}
+
+	var missing []string
+	for _, table := range slices.Sorted(maps.Keys(schemaColumns)) {
+		for _, column := range schemaColumns[table] {
+			if !columns[table+"."+column] {
+				missing = append(missing, "column "+table+"."+column)
+			}
+		}
+	}
+	for _, index := range schemaIndexes {
+		if !indexes[index] {
+			missing = append(missing, "index "+index)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("schema is missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the latest migration applied to db,
+// or 0 if none is.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// schemaNames returns the set of names the query lists.
+func schemaNames(ctx context.Context, db *sql.DB, query string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestVerifySchema(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations: %v", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	// complete lists every expected column and index, leaving out skip
+	complete := func(skip string) (columns, indexes *sqlmock.Rows) {
+		columns = sqlmock.NewRows([]string{"column"})
+		for table, names := range schemaColumns {
+			for _, name := range names {
+				if table+"."+name != skip {
+					columns.AddRow(table + "." + name)
+				}
+			}
+		}
+		indexes = sqlmock.NewRows([]string{"indexname"})
+		for _, name := range schemaIndexes {
+			if name != skip {
+				indexes.AddRow(name)
+			}
+		}
+		return columns, indexes
+	}
+
+	tests := []struct {
+		name      string
+		version   int
+		skip      string
+		errSubstr string
+	}{
+		{name: "compatible schema", version: latest},
+		{name: "newer schema", version: latest + 1},
+		{name: "missing migrations", version: latest - 1, errSubstr: "run the migrate command"},
+		{name: "missing column", version: latest, skip: "favourites.tenant_id", errSubstr: "schema is missing column favourites.tenant_id"},
+		{name: "missing index", version: latest, skip: "favourites_asset_idx", errSubstr: "schema is missing index favourites_asset_idx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM schema_migrations").
+				WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(tt.version))
+			if tt.version >= latest {
+				columns, indexes := complete(tt.skip)
+				mock.ExpectQuery("FROM information_schema.columns").WillReturnRows(columns)
+				mock.ExpectQuery("FROM pg_indexes").WillReturnRows(indexes)
+			}
+
+			err = VerifySchema(context.Background(), db)
+			if tt.errSubstr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.errSubstr != "" && (err == nil || !strings.Contains(err.Error(), tt.errSubstr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.errSubstr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"strconv"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx whose favourites reads and writes are
+// confined to tenantID: queries only see the tenant's favourites and new
+// favourites are stored as the tenant's. Without a tenant, favourites are
+// stored in the default tenant "" and queries see every tenant, as suits a
+// single-tenant deployment or jobs spanning all tenants.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, or "" if none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// tenantScope returns the condition confining a favourites query to the
+// tenant of ctx, appending the tenant to args, or "" when ctx has none.
+func tenantScope(ctx context.Context, args *[]any) string {
+	tenant := TenantFromContext(ctx)
+	if tenant == "" {
+		return ""
+	}
+	*args = append(*args, tenant)
+	return " AND tenant_id = $" + strconv.Itoa(len(*args))
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestTenantScope(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	ctx := WithTenant(context.Background(), "acme")
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO favourites .+ tenant_id\)\s+VALUES .+\$12\).+ON CONFLICT \(tenant_id, user_id, id\) .+WHERE favourites.deleted_at IS NOT NULL`).
+		WithArgs("c1", "user1", "chart", "", sqlmock.AnyArg(), now, now, "", "", "", "", "acme").
+		WillReturnRows(updatedAtRows())
+	fav := &models.FavouriteAsset{ID: "c1", UserID: "user1", AssetType: models.AssetTypeChart, CreatedAt: now, UpdatedAt: now,
+		Data: &models.Chart{ID: "c1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y"}}
+	if err := repo.AddFavourite(ctx, fav); err != nil {
+		t.Fatalf("AddFavourite: %v", err)
+	}
+
+	// A favourite of another tenant is out of reach
+	mock.ExpectQuery(`FROM favourites\s+WHERE user_id = \$1 AND id = \$2 AND deleted_at IS NULL AND tenant_id = \$3`).
+		WithArgs("user1", "c2", "acme").
+		WillReturnRows(sqlmock.NewRows(testCols))
+	if _, err := repo.GetFavourite(ctx, "user1", "c2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// The tenant follows the query's own arguments
+	mock.ExpectExec(`UPDATE favourites SET deleted_at = NOW\(\) WHERE user_id = \$1 AND id = \$2 AND deleted_at IS NULL AND tenant_id = \$3`).
+		WithArgs("user1", "c1", "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.DeleteFavourite(ctx, "user1", "c1"); err != nil {
+		t.Errorf("DeleteFavourite: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTenantScope_Store(t *testing.T) {
+	repo, mock := setupTestDB(t)
+	ctx := WithTenant(context.Background(), "acme")
+	now := time.Now()
+
+	mock.ExpectExec(`INSERT INTO favourite_audit .+ tenant_id\)`).
+		WithArgs("user1", "user1", "add", "c1", []byte(nil), now, "acme").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := repo.InsertAuditEntry(ctx, &AuditEntry{UserID: "user1", Actor: "user1", Action: "add", AssetID: "c1", OccurredAt: now}); err != nil {
+		t.Errorf("InsertAuditEntry: %v", err)
+	}
+	mock.ExpectQuery(`FROM favourite_audit\s+WHERE user_id = \$1 AND tenant_id = \$3`).
+		WithArgs("user1", 10, "acme").
+		WillReturnRows(sqlmock.NewRows(auditCols))
+	if _, err := repo.GetAuditEntries(ctx, "user1", 10); err != nil {
+		t.Errorf("GetAuditEntries: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT timezone FROM user_preferences WHERE user_id = \$1 AND tenant_id = \$2`).
+		WithArgs("user1", "acme").
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}))
+	if _, err := repo.GetUserTimezone(ctx, "user1"); err != nil {
+		t.Errorf("GetUserTimezone: %v", err)
+	}
+	mock.ExpectExec(`INSERT INTO user_preferences .+ ON CONFLICT \(tenant_id, user_id\)`).
+		WithArgs("user1", "UTC", sqlmock.AnyArg(), "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.SetUserTimezone(ctx, "user1", "UTC"); err != nil {
+		t.Errorf("SetUserTimezone: %v", err)
+	}
+
+	mock.ExpectExec(`INSERT INTO api_keys .+ tenant_id\)`).
+		WithArgs("k1", "user1", "ci", "hash", now, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.CreateAPIKey(ctx, APIKey{ID: "k1", UserID: "user1", Name: "ci", CreatedAt: now}, "hash"); err != nil {
+		t.Errorf("CreateAPIKey: %v", err)
+	}
+	mock.ExpectQuery(`FROM api_keys\s+WHERE user_id = \$1 AND tenant_id = \$2`).
+		WithArgs("user1", "acme").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "created_at", "revoked_at"}))
+	if _, err := repo.ListAPIKeys(ctx, "user1"); err != nil {
+		t.Errorf("ListAPIKeys: %v", err)
+	}
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = \$3\s+WHERE user_id = \$1 AND id = \$2 AND revoked_at IS NULL AND tenant_id = \$4`).
+		WithArgs("user1", "k1", now, "acme").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.RevokeAPIKey(ctx, "user1", "k1", now); err != nil {
+		t.Errorf("RevokeAPIKey: %v", err)
+	}
+
+	mock.ExpectQuery(`FROM favourite_versions\s+WHERE user_id = \$1 AND asset_id = \$2 AND tenant_id = \$3`).
+		WithArgs("user1", "c1", "acme").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "data", "replaced_at", "tenant_id"}))
+	if _, err := repo.GetFavouriteVersions(ctx, "user1", "c1", models.AssetTypeChart); err != nil {
+		t.Errorf("GetFavouriteVersions: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// Tx reads and writes favourites within a single transaction, started by
+// FavouritesRepository.WithTx. Favourites read through it stay locked until
+// the transaction ends, so a read followed by a write cannot interleave with
+// another writer.
+type Tx interface {
+	// GetFavourite is FavouritesRepository.GetFavourite, with the
+	// favourite locked until the transaction ends.
+	GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error)
+	AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error
+	UpdateFavourite(ctx context.Context, favourite *models.FavouriteAsset) error
+	AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error
+	DeleteFavourite(ctx context.Context, userID, assetID string) error
+	DeleteAllUserFavourites(ctx context.Context, userID string) ([]string, error)
+	UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error)
+	// AddFavouritesBatch adds favourites at once, returning those that
+	// already existed, or repeated an earlier one, without storing them.
+	// Quotas are not checked.
+	AddFavouritesBatch(ctx context.Context, favourites []*models.FavouriteAsset) ([]*models.FavouriteAsset, error)
+	// AppendEvent records e in the event outbox, relayed once the
+	// transaction commits.
+	AppendEvent(ctx context.Context, e events.Event) error
+}
+
+// StoreTx is a Tx that also changes what a Store keeps, started by
+// Store.WithStoreTx.
+type StoreTx interface {
+	Tx
+	ReplaceAssetData(ctx context.Context, userID, assetID string, asset models.Asset, replacedAt time.Time) (int, error)
+	RevertAssetData(ctx context.Context, userID, assetID string, version int, revertedAt time.Time) (int, error)
+	UpdateCatalogAsset(ctx context.Context, asset models.Asset, updatedAt time.Time) ([]AssetOwnership, error)
+	DeleteAssets(ctx context.Context, assetIDs []string) ([]AssetOwnership, error)
+	MergeUserFavourites(ctx context.Context, sourceUserID, targetUserID string, mergedAt time.Time) ([]AssetOwnership, error)
+}
+
+// pgTx is the Tx and StoreTx of a Repository.
+type pgTx struct {
+	r        *Repository
+	tx       *sql.Tx
+	appended []events.Event // recorded in the outbox
+}
+
+// WithTx runs fn in a transaction, committed when fn returns nil and rolled
+// back otherwise. fn's error is returned as is.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx Tx) error) error {
+	return r.inTx(ctx, func(t *pgTx) error { return fn(t) })
+}
+
+// WithStoreTx is WithTx for changes that reach the Store.
+func (r *Repository) WithStoreTx(ctx context.Context, fn func(tx StoreTx) error) error {
+	return r.inTx(ctx, func(t *pgTx) error { return fn(t) })
+}
+
+// inTx runs fn in a transaction as WithTx does. Once it commits, the events
+// fn recorded in the outbox are passed to the OnCommit hook and the relay is
+// woken.
+func (r *Repository) inTx(ctx context.Context, fn func(t *pgTx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	t := &pgTx{r: r, tx: tx}
+	if err := fn(t); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	if len(t.appended) > 0 {
+		if r.committed != nil {
+			for _, e := range t.appended {
+				r.committed(e)
+			}
+		}
+		select {
+		case r.appended <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// GetFavourite locks the favourite's row until the transaction ends.
+func (t *pgTx) GetFavourite(ctx context.Context, userID, assetID string) (*models.FavouriteAsset, error) {
+	return t.r.getFavourite(ctx, t.tx, userID, assetID, " FOR UPDATE")
+}
+
+// AddFavourite is Repository.AddFavourite within the transaction.
+func (t *pgTx) AddFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return t.r.insertFavourite(ctx, t.tx, favourite)
+}
+
+// UpdateFavourite is Repository.UpdateFavourite within the transaction.
+func (t *pgTx) UpdateFavourite(ctx context.Context, favourite *models.FavouriteAsset) error {
+	return t.r.updateFavourite(ctx, t.tx, favourite)
+}
+
+// AddFavouriteWithinQuota is Repository.AddFavouriteWithinQuota within the transaction.
+func (t *pgTx) AddFavouriteWithinQuota(ctx context.Context, favourite *models.FavouriteAsset, limit int) error {
+	if err := t.LockUserFavourites(ctx, favourite.UserID); err != nil {
+		return err
+	}
+	count, err := t.CountFavouritesOfType(ctx, favourite.UserID, favourite.AssetType)
+	if err != nil {
+		return err
+	}
+	if count >= limit {
+		return ErrQuotaExceeded
+	}
+	return t.AddFavourite(ctx, favourite)
+}
+
+// DeleteFavourite is Repository.DeleteFavourite within the transaction.
+func (t *pgTx) DeleteFavourite(ctx context.Context, userID, assetID string) error {
+	return deleteFavourite(ctx, t.tx, userID, assetID)
+}
+
+// DeleteAllUserFavourites is Repository.DeleteAllUserFavourites within the transaction.
+func (t *pgTx) DeleteAllUserFavourites(ctx context.Context, userID string) ([]string, error) {
+	return deleteAllUserFavourites(ctx, t.tx, userID)
+}
+
+// UpdateDescriptions is Repository.UpdateDescriptions within the transaction.
+func (t *pgTx) UpdateDescriptions(ctx context.Context, userID string, updates []DescriptionUpdate, updatedAt time.Time) ([]bool, error) {
+	return t.r.updateDescriptions(ctx, t.tx, userID, updates, updatedAt)
+}
+
+// ReplaceAssetData is Repository.ReplaceAssetData within the transaction.
+func (t *pgTx) ReplaceAssetData(ctx context.Context, userID, assetID string, asset models.Asset, replacedAt time.Time) (int, error) {
+	return t.r.replaceAssetData(ctx, t.tx, userID, assetID, asset, replacedAt)
+}
+
+// RevertAssetData is Repository.RevertAssetData within the transaction.
+func (t *pgTx) RevertAssetData(ctx context.Context, userID, assetID string, version int, revertedAt time.Time) (int, error) {
+	return revertAssetData(ctx, t.tx, userID, assetID, version, revertedAt)
+}
+
+// UpdateCatalogAsset is Repository.UpdateCatalogAsset within the transaction.
+func (t *pgTx) UpdateCatalogAsset(ctx context.Context, asset models.Asset, updatedAt time.Time) ([]AssetOwnership, error) {
+	return updateCatalogAsset(ctx, t.tx, asset, updatedAt)
+}
+
+// DeleteAssets is Repository.DeleteAssets within the transaction.
+func (t *pgTx) DeleteAssets(ctx context.Context, assetIDs []string) ([]AssetOwnership, error) {
+	return deleteAssets(ctx, t.tx, assetIDs)
+}
+
+// MergeUserFavourites is Repository.MergeUserFavourites within the transaction.
+func (t *pgTx) MergeUserFavourites(ctx context.Context, sourceUserID, targetUserID string, mergedAt time.Time) ([]AssetOwnership, error) {
+	return t.r.mergeUserFavourites(ctx, t.tx, sourceUserID, targetUserID, mergedAt)
+}
+
+// LockUserFavourites holds a per-user advisory lock until the transaction
+// ends, serialising transactions that check and then change the set of a
+// user's favourites, such as quota checks.
+func (t *pgTx) LockUserFavourites(ctx context.Context, userID string) error {
+	if _, err := t.tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, userID); err != nil {
+		return fmt.Errorf("locking user favourites: %w", err)
+	}
+	return nil
+}
+
+// CountFavouritesOfType returns how many favourites of assetType the user has.
+func (t *pgTx) CountFavouritesOfType(ctx context.Context, userID string, assetType models.AssetType) (int, error) {
+	const query = `SELECT COUNT(*) FROM favourites WHERE user_id = $1 AND asset_type = $2 AND deleted_at IS NULL`
+	args := []any{userID, string(assetType)}
+	var count int
+	if err := t.tx.QueryRowContext(ctx, query+tenantScope(ctx, &args), args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting user favourites: %w", err)
+	}
+	return count, nil
+}
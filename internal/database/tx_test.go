@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestWithTx(t *testing.T) {
+	now := time.Now()
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name      string
+		setupMock func(sqlmock.Sqlmock)
+		fn        func(ctx context.Context, tx Tx) error
+		wantErr   error
+	}{
+		{
+			name: "commits read and update",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id = \\$1 AND id = \\$2 AND deleted_at IS NULL FOR UPDATE").
+					WithArgs("user1", "c1").
+					WillReturnRows(sqlmock.NewRows(testCols).
+						AddRow("c1", "user1", "chart", "old", testChartJSON("c1"), now, now, nil, nil, nil, nil, ""))
+				m.ExpectQuery("UPDATE favourites").WillReturnRows(updatedAtRows())
+				m.ExpectCommit()
+			},
+			fn: func(ctx context.Context, tx Tx) error {
+				fav, err := tx.GetFavourite(ctx, "user1", "c1")
+				if err != nil {
+					return err
+				}
+				fav.Description = "new"
+				return tx.UpdateFavourite(ctx, fav)
+			},
+		},
+		{
+			name: "rolls back on error",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE favourites SET deleted_at = NOW").WithArgs("user1", "c1").WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectRollback()
+			},
+			fn: func(ctx context.Context, tx Tx) error {
+				if err := tx.DeleteFavourite(ctx, "user1", "c1"); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			wantErr: errAbort,
+		},
+		{
+			name: "not found",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+					WithArgs("user1", "missing").
+					WillReturnRows(sqlmock.NewRows(testCols))
+				m.ExpectRollback()
+			},
+			fn: func(ctx context.Context, tx Tx) error {
+				_, err := tx.GetFavourite(ctx, "user1", "missing")
+				return err
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTestDB(t)
+			tt.setupMock(mock)
+
+			ctx := context.Background()
+			err := repo.WithTx(ctx, func(tx Tx) error { return tt.fn(ctx, tx) })
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
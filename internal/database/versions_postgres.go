@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ErrVersionNotFound is returned when a favourite has no version with the
+// requested number.
+var ErrVersionNotFound = errors.New("favourite version not found")
+
+// FavouriteVersion is asset data a favourite held before it was replaced.
+// Versions are numbered from 1 per favourite; the current data is the version
+// after the newest stored one.
+type FavouriteVersion struct {
+	Version    int          `json:"version"`
+	Data       models.Asset `json:"data"`
+	ReplacedAt time.Time    `json:"replaced_at"`
+}
+
+// ReplaceAssetData stores asset as the favourite's data and keeps the data
+// it replaces as a new version. It returns the number of the new current version.
+func (r *Repository) ReplaceAssetData(ctx context.Context, userID, assetID string, asset models.Asset, replacedAt time.Time) (version int, err error) {
+	err = r.WithStoreTx(ctx, func(tx StoreTx) error {
+		version, err = tx.ReplaceAssetData(ctx, userID, assetID, asset, replacedAt)
+		return err
+	})
+	return version, err
+}
+
+// replaceAssetData is ReplaceAssetData within tx.
+func (r *Repository) replaceAssetData(ctx context.Context, tx *sql.Tx, userID, assetID string, asset models.Asset, replacedAt time.Time) (int, error) {
+	dataJSON, err := json.Marshal(asset)
+	if err != nil {
+		return 0, fmt.Errorf("marshalling asset data: %w", err)
+	}
+	current, tenant, err := lockAssetData(ctx, tx, userID, assetID)
+	if err != nil {
+		return 0, err
+	}
+	row := favouriteRow{tenant: tenant, user: userID, id: assetID}
+	if dataJSON, err = r.cipher.sealData(row, dataJSON); err != nil {
+		return 0, err
+	}
+	return archiveAndSetAssetData(ctx, tx, tenant, userID, assetID, current, dataJSON, replacedAt)
+}
+
+// RevertAssetData makes the data of an earlier version the favourite's
+// data. The data it replaces is kept as a new version, so a revert can itself
+// be reverted. It returns the number of the new current version.
+func (r *Repository) RevertAssetData(ctx context.Context, userID, assetID string, version int, revertedAt time.Time) (newVersion int, err error) {
+	err = r.WithStoreTx(ctx, func(tx StoreTx) error {
+		newVersion, err = tx.RevertAssetData(ctx, userID, assetID, version, revertedAt)
+		return err
+	})
+	return newVersion, err
+}
+
+// revertAssetData is RevertAssetData within tx.
+func revertAssetData(ctx context.Context, tx *sql.Tx, userID, assetID string, version int, revertedAt time.Time) (int, error) {
+	current, tenant, err := lockAssetData(ctx, tx, userID, assetID)
+	if err != nil {
+		return 0, err
+	}
+
+	const query = `
+		SELECT data FROM favourite_versions
+		WHERE tenant_id = $1 AND user_id = $2 AND asset_id = $3 AND version = $4`
+
+	var target []byte
+	err = tx.QueryRowContext(ctx, query, tenant, userID, assetID, version).Scan(&target)
+	if err == sql.ErrNoRows {
+		return 0, ErrVersionNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("querying favourite version: %w", err)
+	}
+
+	return archiveAndSetAssetData(ctx, tx, tenant, userID, assetID, current, target, revertedAt)
+}
+
+// GetFavouriteVersions returns the stored versions of a favourite,
+// newest first. Their data is decoded as assetType.
+func (r *Repository) GetFavouriteVersions(ctx context.Context, userID, assetID string, assetType models.AssetType) ([]*FavouriteVersion, error) {
+	args := []any{userID, assetID}
+	query := `
+		SELECT version, data, replaced_at, tenant_id
+		FROM favourite_versions
+		WHERE user_id = $1 AND asset_id = $2` + tenantScope(ctx, &args) + `
+		ORDER BY version DESC`
+
+	rows, err := r.readQuery(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying favourite versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []*FavouriteVersion{}
+	for rows.Next() {
+		var (
+			v       FavouriteVersion
+			rawData []byte
+			tenant  string
+		)
+		if err := rows.Scan(&v.Version, &rawData, &v.ReplacedAt, &tenant); err != nil {
+			return nil, fmt.Errorf("scanning favourite version: %w", err)
+		}
+		row := favouriteRow{tenant: tenant, user: userID, id: assetID}
+		if v.Data, err = r.unmarshalAssetData(row, assetType, rawData); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating favourite versions: %w", err)
+	}
+	return versions, nil
+}
+
+// lockAssetData returns the favourite's current data and its tenant, locking
+// its row until the transaction ends. For a favourite referencing the asset
+// catalog this is the catalog data; replacing it gives the favourite its own copy.
+func lockAssetData(ctx context.Context, tx *sql.Tx, userID, assetID string) (data []byte, tenant string, err error) {
+	args := []any{userID, assetID}
+	query := `SELECT ` + favouriteDataColumn + `, tenant_id FROM favourites WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL` +
+		tenantScope(ctx, &args) + ` FOR UPDATE`
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&data, &tenant)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("locking favourite: %w", err)
+	}
+	return data, tenant, nil
+}
+
+// archiveAndSetAssetData stores current as the next version of the tenant's
+// favourite and replaces its data with data. It returns the number of the new
+// current version.
+func archiveAndSetAssetData(ctx context.Context, tx *sql.Tx, tenant, userID, assetID string, current, data []byte, at time.Time) (int, error) {
+	const archiveQuery = `
+		INSERT INTO favourite_versions (tenant_id, user_id, asset_id, version, data, replaced_at)
+		SELECT $1, $2, $3, COALESCE(MAX(version), 0) + 1, $4, $5
+		FROM favourite_versions
+		WHERE tenant_id = $1 AND user_id = $2 AND asset_id = $3
+		RETURNING version`
+
+	var archived int
+	if err := tx.QueryRowContext(ctx, archiveQuery, tenant, userID, assetID, current, at).Scan(&archived); err != nil {
+		return 0, fmt.Errorf("archiving favourite version: %w", err)
+	}
+
+	const updateQuery = `UPDATE favourites SET data = $4, updated_at = $5 WHERE tenant_id = $1 AND user_id = $2 AND id = $3`
+	if _, err := tx.ExecContext(ctx, updateQuery, tenant, userID, assetID, data, at); err != nil {
+		return 0, fmt.Errorf("updating asset data: %w", err)
+	}
+	return archived + 1, nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestReplaceAssetData(t *testing.T) {
+	now := time.Now()
+	chart := &models.Chart{ID: "c1", Title: "New", XAxisTitle: "X", YAxisTitle: "Y"}
+
+	t.Run("archives the current data", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "c1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}).AddRow(testChartJSON("c1"), ""))
+		mock.ExpectQuery("INSERT INTO favourite_versions").
+			WithArgs("", "user1", "c1", testChartJSON("c1"), now).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectExec("UPDATE favourites SET data").
+			WithArgs("", "user1", "c1", sqlmock.AnyArg(), now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		version, err := repo.ReplaceAssetData(context.Background(), "user1", "c1", chart, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if version != 3 {
+			t.Errorf("expected current version 3, got %d", version)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "c1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}))
+		mock.ExpectRollback()
+
+		if _, err := repo.ReplaceAssetData(context.Background(), "user1", "c1", chart, now); err != ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRevertAssetData(t *testing.T) {
+	now := time.Now()
+	old := []byte(`{"id":"c1","title":"Old","x_axis_title":"X","y_axis_title":"Y"}`)
+
+	t.Run("restores the version and archives the current data", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "c1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}).AddRow(testChartJSON("c1"), ""))
+		mock.ExpectQuery("SELECT data FROM favourite_versions").WithArgs("", "user1", "c1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(old))
+		mock.ExpectQuery("INSERT INTO favourite_versions").
+			WithArgs("", "user1", "c1", testChartJSON("c1"), now).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectExec("UPDATE favourites SET data").
+			WithArgs("", "user1", "c1", old, now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		version, err := repo.RevertAssetData(context.Background(), "user1", "c1", 1, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if version != 3 {
+			t.Errorf("expected current version 3, got %d", version)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		repo, mock := setupTestDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "c1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}).AddRow(testChartJSON("c1"), ""))
+		mock.ExpectQuery("SELECT data FROM favourite_versions").WithArgs("", "user1", "c1", 9).
+			WillReturnRows(sqlmock.NewRows([]string{"data"}))
+		mock.ExpectRollback()
+
+		if _, err := repo.RevertAssetData(context.Background(), "user1", "c1", 9, now); err != ErrVersionNotFound {
+			t.Errorf("expected ErrVersionNotFound, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetFavouriteVersions(t *testing.T) {
+	now := time.Now()
+	repo, mock := setupTestDB(t)
+	mock.ExpectQuery("SELECT version, data, replaced_at, tenant_id FROM favourite_versions").WithArgs("user1", "c1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "data", "replaced_at", "tenant_id"}).
+			AddRow(2, testChartJSON("c1"), now, "").
+			AddRow(1, testChartJSON("c1"), now.Add(-time.Hour), ""))
+
+	versions, err := repo.GetFavouriteVersions(context.Background(), "user1", "c1", models.AssetTypeChart)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if chart, ok := versions[0].Data.(*models.Chart); !ok || chart.ID != "c1" {
+		t.Errorf("expected decoded chart data, got %#v", versions[0].Data)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
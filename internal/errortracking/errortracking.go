@@ -0,0 +1,274 @@
+// Package errortracking reports server errors and recovered panics to a
+// Sentry-compatible error tracker.
+package errortracking
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/buildinfo"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// queueCapacity bounds the events waiting to be sent; further events
+	// are dropped until the queue drains.
+	queueCapacity = 256
+	// batchSize is the most events sent in one go.
+	batchSize = 20
+	// flushInterval is how long an event may wait for its batch to fill.
+	flushInterval = 5 * time.Second
+	sendTimeout   = 10 * time.Second
+)
+
+// DSN locates a project of the error tracker, as in
+// https://<public key>@<host>/<project ID>.
+type DSN struct {
+	raw       string
+	publicKey string
+	endpoint  string
+}
+
+// ParseDSN parses a Sentry-compatible DSN.
+func ParseDSN(dsn string) (DSN, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return DSN{}, fmt.Errorf("invalid DSN %q: want http(s)://key@host/project", dsn)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return DSN{}, fmt.Errorf("invalid DSN %q: missing public key", dsn)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := path[:max(i, 0)], path[i+1:]
+	if project == "" {
+		return DSN{}, fmt.Errorf("invalid DSN %q: missing project ID", dsn)
+	}
+	return DSN{
+		raw:       dsn,
+		publicKey: u.User.Username(),
+		endpoint:  u.Scheme + "://" + u.Host + prefix + "/api/" + project + "/envelope/",
+	}, nil
+}
+
+// Event is an error reported to the tracker.
+type Event struct {
+	// Message describes the error, e.g. the panic value.
+	Message string
+	// Level is "error" for server errors and "fatal" for panics.
+	Level string
+	// Status is the HTTP status answered, if any.
+	Status    int
+	Method    string
+	Path      string
+	RequestID string
+	// Stack is the stack trace of a panic, innermost frame first.
+	Stack []logging.StackFrame
+	Time  time.Time
+}
+
+// Reporter batches events and sends them to the tracker in the background
+// (see Run). Reporting never blocks: events are dropped while the queue is
+// full.
+type Reporter struct {
+	dsn         DSN
+	release     string
+	environment string
+	serverName  string
+	client      *http.Client
+	events      chan Event
+	dropped     atomic.Int64
+}
+
+// NewReporter returns a Reporter sending to dsn, tagging events with the
+// release of the build (see buildinfo.Info.Release) and with environment.
+func NewReporter(dsn DSN, environment string, client *http.Client) *Reporter {
+	host, _ := os.Hostname()
+	return &Reporter{
+		dsn:         dsn,
+		release:     buildinfo.Get().Release(),
+		environment: environment,
+		serverName:  host,
+		client:      client,
+		events:      make(chan Event, queueCapacity),
+	}
+}
+
+// Report queues e to be sent.
+func (r *Reporter) Report(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	select {
+	case r.events <- e:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// ReportResponse reports that req was answered with a server error.
+func (r *Reporter) ReportResponse(req *http.Request, status int, message string) {
+	r.Report(Event{
+		Message:   message,
+		Level:     "error",
+		Status:    status,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		RequestID: middleware.GetReqID(req.Context()),
+	})
+}
+
+// PanicAlert returns a logging.PanicAlert reporting recovered panics.
+func (r *Reporter) PanicAlert() logging.PanicAlert {
+	return func(_ context.Context, p logging.PanicReport) {
+		r.Report(Event{
+			Message:   p.Panic,
+			Level:     "fatal",
+			Status:    http.StatusInternalServerError,
+			Method:    p.Method,
+			Path:      p.Path,
+			RequestID: p.RequestID,
+			Stack:     p.Stack,
+			Time:      p.Time,
+		})
+	}
+}
+
+// Run sends the queued events until ctx is done, as soon as batchSize of
+// them are waiting or flushInterval after the first one was queued.
+func (r *Reporter) Run(ctx context.Context, logger *slog.Logger) {
+	var batch []Event
+	timer := time.NewTimer(flushInterval)
+	timer.Stop()
+	flush := func() {
+		sent, err := r.send(ctx, batch)
+		if err != nil {
+			logging.With(logger).Layer("errortracking").Op("sendEvents").
+				Int("count", len(batch)-sent).Err(err).Warn("failed to send error events")
+		}
+		if dropped := r.dropped.Swap(0); dropped > 0 {
+			logging.With(logger).Layer("errortracking").Op("sendEvents").
+				Int("count", int(dropped)).Warn("dropped error events, the queue was full")
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case e := <-r.events:
+			if len(batch) == 0 {
+				timer.Reset(flushInterval)
+			}
+			batch = append(batch, e)
+			if len(batch) >= batchSize {
+				timer.Stop()
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// send posts events, one envelope each as the envelope format allows a
+// single event, and returns how many were accepted. It stops at the first
+// failure, as the tracker is then likely unreachable or rate limiting.
+func (r *Reporter) send(ctx context.Context, events []Event) (int, error) {
+	for i, e := range events {
+		if err := r.post(ctx, r.envelope(e)); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+func (r *Reporter) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.dsn.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=favourites/"+r.release+", sentry_key="+r.dsn.publicKey)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.New("error tracker answered " + resp.Status)
+	}
+	return nil
+}
+
+// envelope encodes e as a Sentry envelope: a header, an item header and the
+// event, one JSON document per line.
+func (r *Reporter) envelope(e Event) []byte {
+	id := eventID()
+	event := map[string]any{
+		"event_id":    id,
+		"timestamp":   e.Time.Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       e.Level,
+		"logger":      "favourites",
+		"release":     r.release,
+		"environment": r.environment,
+		"server_name": r.serverName,
+		"message":     map[string]string{"formatted": e.Message},
+		"request":     map[string]string{"method": e.Method, "url": e.Path},
+		"tags": map[string]string{
+			"request_id": e.RequestID,
+			"status":     strconv.Itoa(e.Status),
+		},
+	}
+	if len(e.Stack) > 0 {
+		// Sentry lists frames outermost first
+		frames := make([]map[string]any, len(e.Stack))
+		for i, f := range e.Stack {
+			frames[len(e.Stack)-1-i] = map[string]any{"function": f.Function, "abs_path": f.File, "lineno": f.Line}
+		}
+		event["exception"] = map[string]any{"values": []map[string]any{{
+			"type":       "panic",
+			"value":      e.Message,
+			"stacktrace": map[string]any{"frames": frames},
+		}}}
+	}
+	payload, _ := json.Marshal(event)
+	header, _ := json.Marshal(map[string]string{
+		"event_id": id,
+		"dsn":      r.dsn.raw,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+
+	var buf bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// eventID returns a random 32 hex digit event ID.
+func eventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package errortracking
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn          string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{dsn: "https://key@errors.example.com/42", wantEndpoint: "https://errors.example.com/api/42/envelope/"},
+		{dsn: "http://key@localhost:9000/sentry/7/", wantEndpoint: "http://localhost:9000/sentry/api/7/envelope/"},
+		{dsn: "https://errors.example.com/42", wantErr: true},
+		{dsn: "https://key@errors.example.com", wantErr: true},
+		{dsn: "key@errors.example.com/42", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			dsn, err := ParseDSN(tt.dsn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if dsn.endpoint != tt.wantEndpoint || dsn.publicKey != "key" {
+				t.Errorf("expected endpoint %q with key, got %+v", tt.wantEndpoint, dsn)
+			}
+		})
+	}
+}
+
+func TestReporter_Run(t *testing.T) {
+	type envelope struct {
+		auth  string
+		lines []string
+	}
+	received := make(chan envelope, batchSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env envelope
+		env.auth = r.Header.Get("X-Sentry-Auth")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			env.lines = append(env.lines, scanner.Text())
+		}
+		received <- env
+	}))
+	defer server.Close()
+
+	dsn, err := ParseDSN(strings.Replace(server.URL, "://", "://public@", 1) + "/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter := NewReporter(dsn, "staging", server.Client())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reporter.Run(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// A full batch is sent without waiting for the flush interval
+	reporter.PanicAlert()(ctx, logging.PanicReport{
+		Panic:  "boom",
+		Stack:  []logging.StackFrame{{Function: "inner", File: "a.go", Line: 1}, {Function: "outer", File: "b.go", Line: 2}},
+		Method: http.MethodGet,
+		Path:   "/api/v1/favourites",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/favourites", nil)
+	for range batchSize - 1 {
+		reporter.ReportResponse(req, http.StatusInternalServerError, "db failed")
+	}
+
+	var first envelope
+	for i := range batchSize {
+		select {
+		case env := <-received:
+			if i == 0 {
+				first = env
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d envelopes, got %d", batchSize, i)
+		}
+	}
+
+	if !strings.Contains(first.auth, "sentry_key=public") {
+		t.Errorf("expected the public key in the auth header, got %q", first.auth)
+	}
+	if len(first.lines) != 3 {
+		t.Fatalf("expected a header, an item header and an event, got %q", first.lines)
+	}
+	var event struct {
+		Level       string            `json:"level"`
+		Release     string            `json:"release"`
+		Environment string            `json:"environment"`
+		Tags        map[string]string `json:"tags"`
+		Exception   struct {
+			Values []struct {
+				Value      string `json:"value"`
+				Stacktrace struct {
+					Frames []struct {
+						Function string `json:"function"`
+					} `json:"frames"`
+				} `json:"stacktrace"`
+			} `json:"values"`
+		} `json:"exception"`
+	}
+	if err := json.Unmarshal([]byte(first.lines[2]), &event); err != nil {
+		t.Fatalf("failed to decode event %q: %v", first.lines[2], err)
+	}
+	if event.Level != "fatal" || event.Environment != "staging" || event.Release == "" || event.Tags["status"] != "500" {
+		t.Errorf("unexpected event %s", first.lines[2])
+	}
+	if values := event.Exception.Values; len(values) != 1 || values[0].Value != "boom" ||
+		len(values[0].Stacktrace.Frames) != 2 || values[0].Stacktrace.Frames[0].Function != "outer" {
+		t.Errorf("expected the panic with its frames outermost first, got %s", first.lines[2])
+	}
+}
@@ -0,0 +1,110 @@
+// Package events provides an in-process publish/subscribe bus for
+// favourite change notifications.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Type identifies the kind of change an Event describes.
+type Type string
+
+const (
+	FavouriteAdded   Type = "favourite.added"
+	FavouriteUpdated Type = "favourite.updated"
+	FavouriteRemoved Type = "favourite.removed"
+)
+
+// Event describes a single change to a user's favourites. UserID owns the
+// favourites, within TenantID; Actor is who made the change, which differs
+// for admin actions. Changes holds the new values of the fields the change set.
+type Event struct {
+	Type       Type              `json:"type"`
+	UserID     string            `json:"user_id"`
+	TenantID   string            `json:"tenant_id,omitempty"`
+	Actor      string            `json:"actor,omitempty"`
+	AssetID    string            `json:"asset_id"`
+	AssetType  string            `json:"asset_type,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Changes    map[string]string `json:"changes,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+type contextKey string
+
+const actorKey contextKey = "actor"
+
+// WithActor returns a copy of ctx naming actor as who makes the changes
+// published with it, for requests made on behalf of another user.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Publisher publishes favourite change events.
+type Publisher interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// Subscriber delivers published events to registered callbacks.
+type Subscriber interface {
+	Subscribe(fn func(Event)) (unsubscribe func())
+}
+
+// Bus is an in-process Publisher that fans events out to subscribers.
+// Subscribers are invoked synchronously on the publishing goroutine, so they
+// must return quickly.
+type Bus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]func(Event)
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]func(Event))}
+}
+
+// Resolve returns e with its unset OccurredAt and Actor filled in: the
+// current time, and the actor ctx carries (see WithActor), else the UserID.
+func Resolve(ctx context.Context, e Event) Event {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now().UTC()
+	}
+	if e.Actor == "" {
+		e.Actor, _ = ctx.Value(actorKey).(string)
+	}
+	if e.Actor == "" {
+		e.Actor = e.UserID
+	}
+	return e
+}
+
+// Publish delivers the event to every current subscriber, after filling in
+// its OccurredAt and Actor (see Resolve).
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	e = Resolve(ctx, e)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subs {
+		fn(e)
+	}
+}
+
+// Subscribe registers fn to receive every published event and returns a
+// function that removes the subscription.
+func (b *Bus) Subscribe(fn func(Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = fn
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	}
+}
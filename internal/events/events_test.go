@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus_PublishDeliversToSubscribers(t *testing.T) {
+	bus := NewBus()
+
+	var first, second []Event
+	bus.Subscribe(func(e Event) { first = append(first, e) })
+	bus.Subscribe(func(e Event) { second = append(second, e) })
+
+	bus.Publish(context.Background(), Event{Type: FavouriteRemoved, UserID: "user1", AssetID: "c1"})
+
+	if len(first) != 1 || len(second) != 1 {
+		t.Fatalf("expected each subscriber to receive 1 event, got %d and %d", len(first), len(second))
+	}
+	if first[0].AssetID != "c1" || first[0].Type != FavouriteRemoved {
+		t.Errorf("unexpected event: %+v", first[0])
+	}
+	if first[0].OccurredAt.IsZero() {
+		t.Error("expected OccurredAt to be set")
+	}
+	if first[0].Actor != "user1" {
+		t.Errorf("expected Actor to default to the owner, got %q", first[0].Actor)
+	}
+}
+
+func TestBus_Unsubscribe(t *testing.T) {
+	bus := NewBus()
+
+	var received int
+	unsubscribe := bus.Subscribe(func(e Event) { received++ })
+
+	bus.Publish(context.Background(), Event{Type: FavouriteAdded})
+	unsubscribe()
+	bus.Publish(context.Background(), Event{Type: FavouriteAdded})
+
+	if received != 1 {
+		t.Errorf("expected 1 event before unsubscribing, got %d", received)
+	}
+}
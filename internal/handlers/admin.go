@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+// maxOwnershipAssetIDs caps how many asset IDs a single ownership report may cover.
+const maxOwnershipAssetIDs = 1000
+
+// ReasonAssetDecommissioned is attached to removal events raised by the ownership report.
+const ReasonAssetDecommissioned = "asset_decommissioned"
+
+// ReasonUserMerge is attached to add events raised by merging one user's favourites into another's.
+const ReasonUserMerge = "user_merge"
+
+const (
+	// defaultUserSearchLimit is how many users a search returns when no limit is given.
+	defaultUserSearchLimit = 50
+	// maxUserSearchLimit caps how many users a single search may return.
+	maxUserSearchLimit = 500
+)
+
+// AssetOwnershipRequest is the request payload for the admin ownership report.
+type AssetOwnershipRequest struct {
+	AssetIDs []string `json:"asset_ids"`
+	Remove   bool     `json:"remove"`
+}
+
+// AssetOwners lists the users that have a single asset favourited.
+type AssetOwners struct {
+	AssetID string   `json:"asset_id"`
+	Users   []string `json:"users"`
+}
+
+// AssetOwnershipReport is the response of the admin ownership report.
+type AssetOwnershipReport struct {
+	Assets  []AssetOwners `json:"assets"`
+	Removed int           `json:"removed"`
+}
+
+// ReportAssetOwnership reports which users have the requested assets favourited.
+// When req.Remove is set the favourites are deleted as well, and a removal event
+// naming actor is published for every affected user.
+func (h *Favourites) ReportAssetOwnership(ctx context.Context, actor string, req *AssetOwnershipRequest, publisher events.Publisher) (*AssetOwnershipReport, error) {
+	if err := validateOwnershipAssetIDs(req.AssetIDs); err != nil {
+		return nil, err
+	}
+
+	var (
+		owners []database.AssetOwnership
+		err    error
+	)
+	if req.Remove {
+		err = writeWithEvents(ctx, h.outbox, publisher, h.store.WithStoreTx, func(tx database.StoreTx) ([]events.Event, error) {
+			if owners, err = tx.DeleteAssets(ctx, req.AssetIDs); err != nil {
+				return nil, err
+			}
+			changes := make([]events.Event, len(owners))
+			for i, o := range owners {
+				changes[i] = events.Event{
+					Type:      events.FavouriteRemoved,
+					UserID:    o.UserID,
+					Actor:     actor,
+					AssetID:   o.AssetID,
+					AssetType: string(o.AssetType),
+					Reason:    ReasonAssetDecommissioned,
+				}
+			}
+			return changes, nil
+		})
+	} else {
+		owners, err = h.store.GetAssetOwners(ctx, req.AssetIDs)
+	}
+	if err != nil {
+		return nil, rejectOverBudget(err, "request fewer asset_ids at a time")
+	}
+
+	byAsset := make(map[string][]string, len(req.AssetIDs))
+	for _, o := range owners {
+		byAsset[o.AssetID] = append(byAsset[o.AssetID], o.UserID)
+	}
+
+	report := &AssetOwnershipReport{Assets: make([]AssetOwners, 0, len(req.AssetIDs))}
+	seen := make(map[string]bool, len(req.AssetIDs))
+	for _, id := range req.AssetIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		users := byAsset[id]
+		if users == nil {
+			users = []string{}
+		}
+		report.Assets = append(report.Assets, AssetOwners{AssetID: id, Users: users})
+	}
+
+	if req.Remove {
+		report.Removed = len(owners)
+	}
+	return report, nil
+}
+
+// validateOwnershipAssetIDs checks that at least one, and not too many, non-empty IDs were given.
+func validateOwnershipAssetIDs(assetIDs []string) error {
+	checks := []func() string{
+		func() string {
+			if len(assetIDs) == 0 {
+				return "asset_ids is required"
+			}
+			return ""
+		},
+		func() string {
+			if len(assetIDs) > maxOwnershipAssetIDs {
+				return fmt.Sprintf("asset_ids exceeds maximum of %d entries", maxOwnershipAssetIDs)
+			}
+			return ""
+		},
+	}
+	for i, id := range assetIDs {
+		id := id
+		i := i
+		checks = append(checks, func() string {
+			return requireNonEmpty(fmt.Sprintf("asset_ids[%d]", i), id)
+		})
+	}
+	return validate(checks...)
+}
+
+// SearchUsers returns the users with favourites whose ID starts with query,
+// ordered by user ID. A limit of 0 selects the default. Overlong queries and
+// searches that exceed their time budget fail with a QueryRejectedError.
+func (h *Favourites) SearchUsers(ctx context.Context, query string, limit int) ([]database.UserSummary, error) {
+	if limit == 0 {
+		limit = defaultUserSearchLimit
+	}
+	if limit < 0 || limit > maxUserSearchLimit {
+		return nil, &ValidationError{Errors: []string{fmt.Sprintf("limit must be between 1 and %d", maxUserSearchLimit)}}
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, &QueryRejectedError{Reason: fmt.Sprintf("q exceeds maximum length of %d characters", maxSearchQueryLength)}
+	}
+	users, err := h.store.SearchFavouriteUsers(ctx, query, limit)
+	return users, rejectOverBudget(err, "use a longer q prefix or a lower limit")
+}
+
+// MergeFavouritesRequest is the request payload for merging another user's
+// favourites into a user's.
+type MergeFavouritesRequest struct {
+	SourceUserID string `json:"source_user_id"`
+}
+
+// MergeFavouritesResponse lists the favourites a merge added.
+type MergeFavouritesResponse struct {
+	Merged   int      `json:"merged"`
+	AssetIDs []string `json:"asset_ids"`
+}
+
+// MergeFavourites copies the source user's favourites into targetUserID's,
+// skipping assets the target already has. Quotas are not applied to this admin
+// operation. An add event naming actor is published for every copied favourite.
+func (h *Favourites) MergeFavourites(ctx context.Context, actor, targetUserID string, req *MergeFavouritesRequest, publisher events.Publisher) (*MergeFavouritesResponse, error) {
+	err := validate(
+		func() string { return requireNonEmpty("source_user_id", req.SourceUserID) },
+		func() string {
+			if req.SourceUserID == targetUserID {
+				return "source_user_id must differ from the target user"
+			}
+			return ""
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged []database.AssetOwnership
+	err = writeWithEvents(ctx, h.outbox, publisher, h.store.WithStoreTx, func(tx database.StoreTx) ([]events.Event, error) {
+		var err error
+		if merged, err = tx.MergeUserFavourites(ctx, req.SourceUserID, targetUserID, time.Now().UTC()); err != nil {
+			return nil, err
+		}
+		changes := make([]events.Event, len(merged))
+		for i, m := range merged {
+			changes[i] = events.Event{
+				Type:      events.FavouriteAdded,
+				UserID:    m.UserID,
+				Actor:     actor,
+				AssetID:   m.AssetID,
+				AssetType: string(m.AssetType),
+				Reason:    ReasonUserMerge,
+			}
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &MergeFavouritesResponse{Merged: len(merged), AssetIDs: make([]string, 0, len(merged))}
+	for _, m := range merged {
+		resp.AssetIDs = append(resp.AssetIDs, m.AssetID)
+	}
+	return resp, nil
+}
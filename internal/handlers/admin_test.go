@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/lib/pq"
+)
+
+var ownerCols = []string{"id", "user_id", "asset_type"}
+
+func TestReportAssetOwnership(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         AssetOwnershipRequest
+		setupMock   func(sqlmock.Sqlmock)
+		wantErr     bool
+		errSubstr   string
+		wantUsers   map[string]int
+		wantRemoved int
+		wantEvents  int
+	}{
+		{
+			name: "report only", req: AssetOwnershipRequest{AssetIDs: []string{"c1", "c2"}},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("SET LOCAL statement_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectQuery("SELECT id, user_id, asset_type FROM favourites").WillReturnRows(
+					sqlmock.NewRows(ownerCols).AddRow("c1", "user1", "chart").AddRow("c1", "user2", "chart"))
+				m.ExpectRollback()
+			},
+			wantUsers: map[string]int{"c1": 2, "c2": 0},
+		},
+		{
+			name: "report and remove", req: AssetOwnershipRequest{AssetIDs: []string{"c1"}, Remove: true},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("UPDATE favourites SET deleted_at = NOW").WillReturnRows(
+					sqlmock.NewRows(ownerCols).AddRow("c1", "user1", "chart").AddRow("c1", "user2", "chart"))
+				m.ExpectCommit()
+			},
+			wantUsers: map[string]int{"c1": 2}, wantRemoved: 2, wantEvents: 2,
+		},
+		{name: "no asset ids", req: AssetOwnershipRequest{}, wantErr: true, errSubstr: "asset_ids is required"},
+		{name: "empty asset id", req: AssetOwnershipRequest{AssetIDs: []string{"c1", " "}}, wantErr: true, errSubstr: "asset_ids[1] is required"},
+		{
+			name: "too many asset ids", req: AssetOwnershipRequest{AssetIDs: strings.Split(strings.Repeat("a,", maxOwnershipAssetIDs), ",")},
+			wantErr: true, errSubstr: fmt.Sprintf("exceeds maximum of %d", maxOwnershipAssetIDs),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+			bus := events.NewBus()
+			var received []events.Event
+			bus.Subscribe(func(e events.Event) { received = append(received, e) })
+
+			report, err := h.ReportAssetOwnership(ctx, "admin1", &tt.req, bus)
+			assertError(t, err, tt.wantErr, tt.wantErr, tt.errSubstr)
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(report.Assets) != len(tt.wantUsers) {
+				t.Fatalf("expected %d assets in report, got %d", len(tt.wantUsers), len(report.Assets))
+			}
+			for _, a := range report.Assets {
+				if len(a.Users) != tt.wantUsers[a.AssetID] {
+					t.Errorf("asset %s: expected %d users, got %v", a.AssetID, tt.wantUsers[a.AssetID], a.Users)
+				}
+			}
+			if report.Removed != tt.wantRemoved {
+				t.Errorf("expected %d removed, got %d", tt.wantRemoved, report.Removed)
+			}
+			if len(received) != tt.wantEvents {
+				t.Errorf("expected %d events, got %d", tt.wantEvents, len(received))
+			}
+			for _, e := range received {
+				if e.Type != events.FavouriteRemoved || e.Reason != ReasonAssetDecommissioned || e.Actor != "admin1" {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	t.Run("applies default limit", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT user_id, COUNT").WithArgs("al%", defaultUserSearchLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "count"}).AddRow("alice", 2))
+		mock.ExpectRollback()
+
+		users, err := h.SearchUsers(ctx, "al", 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(users) != 1 || users[0].UserID != "alice" {
+			t.Errorf("unexpected users: %+v", users)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rejects out of range limit", func(t *testing.T) {
+		h, _, ctx := setupFavourites(t)
+		_, err := h.SearchUsers(ctx, "al", maxUserSearchLimit+1)
+		assertError(t, err, true, true, "limit must be between")
+	})
+
+	t.Run("rejects overlong query before searching", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		_, err := h.SearchUsers(ctx, strings.Repeat("a", maxSearchQueryLength+1), 0)
+		var rejected *QueryRejectedError
+		if !errors.As(err, &rejected) || !strings.Contains(err.Error(), "maximum length") {
+			t.Fatalf("expected QueryRejectedError, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rejects searches that exceed their time budget", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT user_id, COUNT").WillReturnError(&pq.Error{Code: "57014"})
+		mock.ExpectRollback()
+
+		_, err := h.SearchUsers(ctx, "a", 0)
+		var rejected *QueryRejectedError
+		if !errors.As(err, &rejected) || !strings.Contains(err.Error(), "longer q prefix") {
+			t.Fatalf("expected QueryRejectedError with a hint, got %v", err)
+		}
+	})
+}
+
+func TestMergeFavourites(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        MergeFavouritesRequest
+		setupMock  func(sqlmock.Sqlmock)
+		wantErr    bool
+		errSubstr  string
+		wantMerged int
+	}{
+		{
+			name: "copies favourites", req: MergeFavouritesRequest{SourceUserID: "user1"},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO favourites").WillReturnRows(
+					sqlmock.NewRows(ownerCols).AddRow("c1", "user2", "chart").AddRow("i1", "user2", "insight"))
+				m.ExpectCommit()
+			},
+			wantMerged: 2,
+		},
+		{name: "missing source", req: MergeFavouritesRequest{}, wantErr: true, errSubstr: "source_user_id is required"},
+		{name: "source is target", req: MergeFavouritesRequest{SourceUserID: "user2"}, wantErr: true, errSubstr: "must differ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+			bus := events.NewBus()
+			var received []events.Event
+			bus.Subscribe(func(e events.Event) { received = append(received, e) })
+
+			resp, err := h.MergeFavourites(ctx, "admin1", "user2", &tt.req, bus)
+			assertError(t, err, tt.wantErr, tt.wantErr, tt.errSubstr)
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if resp.Merged != tt.wantMerged || len(resp.AssetIDs) != tt.wantMerged {
+				t.Errorf("expected %d merged, got %+v", tt.wantMerged, resp)
+			}
+			if len(received) != tt.wantMerged {
+				t.Fatalf("expected %d events, got %d", tt.wantMerged, len(received))
+			}
+			for _, e := range received {
+				if e.Type != events.FavouriteAdded || e.UserID != "user2" || e.Actor != "admin1" || e.Reason != ReasonUserMerge {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			}
+		})
+	}
+}
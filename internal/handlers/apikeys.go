@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+// maxAPIKeyNameLength caps the label of an API key.
+const maxAPIKeyNameLength = 100
+
+// CreateAPIKeyRequest is the request payload for issuing an API key.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// CreatedAPIKey is the response of issuing an API key. Key is only returned
+// here; the service keeps a hash and cannot show it again.
+type CreatedAPIKey struct {
+	database.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey issues a new API key bound to userID.
+func (h *Favourites) CreateAPIKey(ctx context.Context, userID string, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	err := validate(
+		func() string { return requireNonEmpty("name", req.Name) },
+		func() string { return checkMaxLength("name", req.Name, maxAPIKeyNameLength) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, key, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	created := &CreatedAPIKey{
+		APIKey: database.APIKey{ID: id, UserID: userID, Name: req.Name, CreatedAt: time.Now().UTC()},
+		Key:    key,
+	}
+	if err := h.store.CreateAPIKey(ctx, created.APIKey, auth.HashAPIKey(key)); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListAPIKeys returns the API keys issued to userID, revoked ones included.
+func (h *Favourites) ListAPIKeys(ctx context.Context, userID string) ([]database.APIKey, error) {
+	return h.store.ListAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey revokes userID's key keyID, which stops authenticating at once.
+func (h *Favourites) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	return h.store.RevokeAPIKey(ctx, userID, keyID, time.Now().UTC())
+}
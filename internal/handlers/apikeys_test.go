@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		keyName    string
+		setupMock  func(sqlmock.Sqlmock)
+		wantErr    bool
+		wantValErr bool
+		errSubstr  string
+	}{
+		{
+			name:    "stores the hash of a new key",
+			keyName: "reporting",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("INSERT INTO api_keys").
+					WithArgs(sqlmock.AnyArg(), "user1", "reporting", sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{name: "missing name", setupMock: func(sqlmock.Sqlmock) {}, wantErr: true, wantValErr: true, errSubstr: "name"},
+		{name: "name too long", keyName: strings.Repeat("a", maxAPIKeyNameLength+1), setupMock: func(sqlmock.Sqlmock) {}, wantErr: true, wantValErr: true, errSubstr: "maximum length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			tt.setupMock(mock)
+
+			created, err := h.CreateAPIKey(ctx, "user1", &CreateAPIKeyRequest{Name: tt.keyName})
+			assertError(t, err, tt.wantErr, tt.wantValErr, tt.errSubstr)
+			if err == nil && (created.Key == "" || created.ID == "" || created.UserID != "user1") {
+				t.Errorf("unexpected key: %+v", created)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
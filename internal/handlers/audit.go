@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// Actions recorded in the audit trail.
+const (
+	AuditActionAdd    = "add"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+const (
+	// defaultAuditLimit is how many entries are returned when no limit is given.
+	defaultAuditLimit = 100
+	// maxAuditLimit caps how many entries a single audit request may return.
+	maxAuditLimit = 1000
+	// auditWriteTimeout bounds how long recording an entry may hold up a request.
+	auditWriteTimeout = 5 * time.Second
+)
+
+var auditActions = map[events.Type]string{
+	events.FavouriteAdded:   AuditActionAdd,
+	events.FavouriteUpdated: AuditActionUpdate,
+	events.FavouriteRemoved: AuditActionDelete,
+}
+
+// GetAuditTrail returns the most recent changes to the user's favourites,
+// newest first. A limit of 0 selects the default.
+func (h *Favourites) GetAuditTrail(ctx context.Context, userID string, limit int) ([]*database.AuditEntry, error) {
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+	if limit < 0 || limit > maxAuditLimit {
+		return nil, &ValidationError{Errors: []string{fmt.Sprintf("limit must be between 1 and %d", maxAuditLimit)}}
+	}
+	return h.store.GetAuditEntries(ctx, userID, limit)
+}
+
+// AuditRecorder returns an event subscriber that appends every favourite change
+// to the audit trail of the change's tenant. Failures are logged and do not
+// affect the change itself.
+func (h *Favourites) AuditRecorder(logger *slog.Logger) func(events.Event) {
+	return func(e events.Event) {
+		action, ok := auditActions[e.Type]
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(database.WithTenant(context.Background(), e.TenantID), auditWriteTimeout)
+		defer cancel()
+
+		entry := &database.AuditEntry{
+			UserID:     e.UserID,
+			Actor:      e.Actor,
+			Action:     action,
+			AssetID:    e.AssetID,
+			Diff:       e.Changes,
+			OccurredAt: e.OccurredAt,
+		}
+		if err := h.store.InsertAuditEntry(ctx, entry); err != nil {
+			logging.With(logger).Layer("handler").Op("recordAudit").User(e.UserID).Asset(e.AssetID).
+				Str("actor", e.Actor).Str("action", action).Err(err).
+				Error("failed to record audit entry")
+		}
+	}
+}
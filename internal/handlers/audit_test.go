@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+var auditCols = []string{"id", "user_id", "actor", "action", "asset_id", "diff", "occurred_at", "tenant_id"}
+
+func TestGetAuditTrail(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+		wantErr   bool
+	}{
+		{name: "default limit", limit: 0, wantLimit: defaultAuditLimit},
+		{name: "explicit limit", limit: 10, wantLimit: 10},
+		{name: "negative limit", limit: -1, wantErr: true},
+		{name: "limit too large", limit: maxAuditLimit + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if !tt.wantErr {
+				mock.ExpectQuery("SELECT .+ FROM favourite_audit").WithArgs("user1", tt.wantLimit).
+					WillReturnRows(sqlmock.NewRows(auditCols).
+						AddRow(1, "user1", "user1", "add", "c1", nil, time.Now(), ""))
+			}
+
+			entries, err := h.GetAuditTrail(ctx, "user1", tt.limit)
+			assertError(t, err, tt.wantErr, tt.wantErr, "limit must be between")
+			if !tt.wantErr && len(entries) != 1 {
+				t.Errorf("expected 1 entry, got %d", len(entries))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestAuditRecorder(t *testing.T) {
+	h, mock, _ := setupFavourites(t)
+	record := h.AuditRecorder(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Now()
+
+	mock.ExpectExec("INSERT INTO favourite_audit").
+		WithArgs("user1", "admin1", AuditActionDelete, "c1", []byte(nil), now, "acme").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO favourite_audit").
+		WithArgs("user1", "user1", AuditActionUpdate, "c2", []byte(`{"description":"new"}`), now, "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	record(events.Event{Type: events.FavouriteRemoved, UserID: "user1", TenantID: "acme", Actor: "admin1", AssetID: "c1", OccurredAt: now})
+	record(events.Event{Type: events.FavouriteUpdated, UserID: "user1", Actor: "user1", AssetID: "c2",
+		Changes: map[string]string{"description": "new"}, OccurredAt: now})
+	record(events.Event{Type: "unknown", UserID: "user1"})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ReasonCatalogUpdate is attached to update events raised by a catalog asset update.
+const ReasonCatalogUpdate = "catalog_update"
+
+// CatalogUpdateResult reports how many favourites a catalog update reached.
+type CatalogUpdateResult struct {
+	AssetType  models.AssetType `json:"asset_type"`
+	AssetID    string           `json:"asset_id"`
+	Favourites int              `json:"favourites"`
+}
+
+// UpdateCatalogAsset validates data as assetType and stores it as the catalog
+// entry of the asset, so every favourite referencing the entry shows it. An
+// update event naming actor is published for each of those favourites.
+// An alias is resolved to its type; unregistered types fail with
+// assets.ErrUnknownType.
+func (h *Favourites) UpdateCatalogAsset(ctx context.Context, actor string, assetType models.AssetType, assetID string, data json.RawMessage, publisher events.Publisher) (*CatalogUpdateResult, error) {
+	assetType = assets.Resolve(assetType)
+	if _, err := assets.Lookup(assetType); err != nil {
+		return nil, err
+	}
+	asset, err := assets.Decode(assetType, data)
+	if err != nil {
+		return nil, &ValidationError{Errors: []string{err.Error()}}
+	}
+	if err := asset.Validate(assets.CurrentValidator()); err != nil {
+		return nil, err
+	}
+	if asset.GetID() != assetID {
+		return nil, &ValidationError{Errors: []string{fmt.Sprintf("asset_data.id must be the asset ID %q", assetID)}}
+	}
+
+	var owners []database.AssetOwnership
+	err = writeWithEvents(ctx, h.outbox, publisher, h.store.WithStoreTx, func(tx database.StoreTx) ([]events.Event, error) {
+		var err error
+		if owners, err = tx.UpdateCatalogAsset(ctx, asset, time.Now()); err != nil {
+			return nil, err
+		}
+		changes := make([]events.Event, len(owners))
+		for i, o := range owners {
+			changes[i] = events.Event{
+				Type:      events.FavouriteUpdated,
+				UserID:    o.UserID,
+				Actor:     actor,
+				AssetID:   o.AssetID,
+				AssetType: string(o.AssetType),
+				Changes:   map[string]string{"asset_data": "catalog"},
+				Reason:    ReasonCatalogUpdate,
+			}
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CatalogUpdateResult{AssetType: assetType, AssetID: assetID, Favourites: len(owners)}, nil
+}
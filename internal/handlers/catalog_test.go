@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestUpdateCatalogAsset(t *testing.T) {
+	validChart := json.RawMessage(`{"id":"c1","title":"T","x_axis_title":"X","y_axis_title":"Y"}`)
+
+	tests := []struct {
+		name       string
+		assetType  models.AssetType
+		data       json.RawMessage
+		setupMock  func(sqlmock.Sqlmock)
+		wantErr    bool
+		wantValErr bool
+		wantIs     error
+		errSubstr  string
+		wantCount  int
+	}{
+		{
+			name: "updates and notifies owners", assetType: models.AssetTypeChart, data: validChart,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE assets SET data").WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectQuery("UPDATE favourites SET updated_at").WillReturnRows(
+					sqlmock.NewRows(ownerCols).AddRow("c1", "user1", "chart").AddRow("c1", "user2", "chart"))
+				m.ExpectCommit()
+			},
+			wantCount: 2,
+		},
+		{
+			name: "not in catalog", assetType: models.AssetTypeChart, data: validChart,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE assets SET data").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectRollback()
+			},
+			wantErr: true, wantIs: database.ErrAssetNotFound,
+		},
+		{name: "unknown type", assetType: "widget", data: validChart, wantErr: true, wantIs: assets.ErrUnknownType},
+		{
+			name: "id mismatch", assetType: models.AssetTypeChart,
+			data:    json.RawMessage(`{"id":"c2","title":"T","x_axis_title":"X","y_axis_title":"Y"}`),
+			wantErr: true, wantValErr: true, errSubstr: `asset_data.id must be the asset ID "c1"`,
+		},
+		{
+			name: "invalid data", assetType: models.AssetTypeChart, data: json.RawMessage(`{"id":"c1"}`),
+			wantErr: true, wantValErr: true, errSubstr: "title is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+			bus := events.NewBus()
+			var received []events.Event
+			bus.Subscribe(func(e events.Event) { received = append(received, e) })
+
+			result, err := h.UpdateCatalogAsset(ctx, "admin1", tt.assetType, "c1", tt.data, bus)
+			assertError(t, err, tt.wantErr, tt.wantValErr, tt.errSubstr)
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("expected %v, got: %v", tt.wantIs, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if result.Favourites != tt.wantCount {
+				t.Errorf("expected %d favourites, got %d", tt.wantCount, result.Favourites)
+			}
+			if len(received) != tt.wantCount {
+				t.Fatalf("expected %d events, got %d", tt.wantCount, len(received))
+			}
+			for _, e := range received {
+				if e.Type != events.FavouriteUpdated || e.Reason != ReasonCatalogUpdate || e.Actor != "admin1" {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+)
+
+// maxDevTokenUserIDLength caps the user IDs dev tokens are minted for.
+const maxDevTokenUserIDLength = 256
+
+// DevTokenRequest is the request payload for minting a development token.
+type DevTokenRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// DevToken is the response of minting a development token.
+type DevToken struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IssueDevToken mints a short-lived token for req.UserID that cfg accepts
+// (see auth.IssueDevToken).
+func IssueDevToken(cfg auth.AuthConfig, req *DevTokenRequest) (*DevToken, error) {
+	err := validate(
+		func() string { return requireNonEmpty("user_id", req.UserID) },
+		func() string { return checkMaxLength("user_id", req.UserID, maxDevTokenUserIDLength) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := auth.IssueDevToken(cfg, req.UserID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &DevToken{Token: token, TokenType: "Bearer", ExpiresAt: expiresAt.UTC()}, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// ExportFavourites writes all of the user's favourites to w as a JSON array
+// in the shape of the list endpoint, with UTC timestamps. Favourites are
+// written as they are read, so the export does not hold them all in memory.
+// It is the export job body run by jobs.Exporter.
+func (h *Favourites) ExportFavourites(ctx context.Context, userID, tenantID string, w io.Writer) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if tenantID != "" {
+		ctx = database.WithTenant(ctx, tenantID)
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, fmt.Errorf("writing export: %w", err)
+	}
+	count := 0
+	err := h.repo.ForEachUserFavourite(ctx, userID, func(fav *models.FavouriteAsset) error {
+		fav.DescriptionHTML = ""
+		encoded, err := json.Marshal(fav)
+		if err != nil {
+			return fmt.Errorf("encoding export: %w", err)
+		}
+		if count > 0 {
+			encoded = append([]byte(","), encoded...)
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return fmt.Errorf("writing export: %w", err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.WriteString(w, "]\n"); err != nil {
+		return 0, fmt.Errorf("writing export: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExportFavourites(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("writes the favourites as a JSON array", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id").
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(testCols).
+				AddRow("c1", "user1", "chart", "d", chartData("c1"), now, now, nil, nil, nil, nil, "").
+				AddRow("c2", "user1", "chart", "", chartData("c2"), now, now, nil, nil, nil, nil, ""))
+
+		var buf bytes.Buffer
+		count, err := h.ExportFavourites(ctx, "user1", "", &buf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("expected count 2, got %d", count)
+		}
+		var got []map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil || len(got) != 2 {
+			t.Errorf("expected a 2-element JSON array, got %s (err %v)", buf.String(), err)
+		}
+	})
+
+	t.Run("writes an empty array without favourites", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id").
+			WithArgs("user1").
+			WillReturnRows(sqlmock.NewRows(testCols))
+
+		var buf bytes.Buffer
+		count, err := h.ExportFavourites(ctx, "user1", "", &buf)
+		if err != nil || count != 0 {
+			t.Fatalf("expected an empty export, got count %d err %v", count, err)
+		}
+		if buf.String() != "[]\n" {
+			t.Errorf("expected an empty JSON array, got %q", buf.String())
+		}
+	})
+
+	t.Run("returns database errors", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id").
+			WillReturnError(fmt.Errorf("connection failed"))
+
+		if _, err := h.ExportFavourites(ctx, "user1", "", &bytes.Buffer{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+const (
+	// dataFilterPrefix starts the query parameters filtering on asset data.
+	dataFilterPrefix = "data."
+	// maxDataFilters caps the asset data filters of one request.
+	maxDataFilters = 5
+)
+
+// ErrDataFiltersDisabled is returned when filtering on asset data without a
+// store, which runs the filters.
+var ErrDataFiltersDisabled = errors.New("asset data filters are not enabled")
+
+// dataFieldPattern matches the top-level asset data fields that can be
+// filtered on.
+var dataFieldPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ParseDataFilters reads the asset data filters of a list request:
+// data.<field>=<value> matches favourites whose field equals value or, for
+// arrays, holds it, and data.<field>.contains=<text> those whose field
+// contains text, ignoring case. Other parameters are ignored.
+func ParseDataFilters(query url.Values) ([]database.DataFilter, error) {
+	var filters []database.DataFilter
+	var errs []string
+	for key, values := range query {
+		field, ok := strings.CutPrefix(key, dataFilterPrefix)
+		if !ok {
+			continue
+		}
+		field, contains := strings.CutSuffix(field, ".contains")
+		if !dataFieldPattern.MatchString(field) {
+			errs = append(errs, fmt.Sprintf("%s: field must be a lowercase asset data field name", key))
+			continue
+		}
+		for _, value := range values {
+			if value == "" {
+				errs = append(errs, fmt.Sprintf("%s: value must not be empty", key))
+				continue
+			}
+			filters = append(filters, database.DataFilter{Field: field, Value: value, Contains: contains})
+		}
+	}
+	if len(filters) > maxDataFilters {
+		errs = append(errs, fmt.Sprintf("at most %d asset data filters are allowed", maxDataFilters))
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, &ValidationError{Errors: errs}
+	}
+	// Query parameters come in map order; keep queries stable
+	sort.Slice(filters, func(i, j int) bool {
+		a, b := filters[i], filters[j]
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if a.Contains != b.Contains {
+			return !a.Contains
+		}
+		return a.Value < b.Value
+	})
+	return filters, nil
+}
+
+// GetMatchingFavourites returns the user's favourites whose asset data
+// matches every filter and whose provenance matches every non-empty field of
+// provenance, newest first.
+func (h *Favourites) GetMatchingFavourites(ctx context.Context, userID string, filters []database.DataFilter, provenance models.Provenance) ([]*models.FavouriteAsset, error) {
+	if h.store == nil {
+		return nil, ErrDataFiltersDisabled
+	}
+	return h.store.GetUserFavouritesMatching(ctx, userID, filters, provenance)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+func TestParseDataFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []database.DataFilter
+		wantErr string
+	}{
+		{name: "no filters", query: "source_system=crm&render=html"},
+		{
+			name:  "match and contains",
+			query: "data.title.contains=revenue&data.age_groups=25-34&data.age_groups=35-44&source_system=crm",
+			want: []database.DataFilter{
+				{Field: "age_groups", Value: "25-34"},
+				{Field: "age_groups", Value: "35-44"},
+				{Field: "title", Value: "revenue", Contains: true},
+			},
+		},
+		{name: "invalid field", query: "data.Title=x", wantErr: "field must be"},
+		{name: "nested field", query: "data.series.name=x", wantErr: "field must be"},
+		{name: "empty value", query: "data.title=", wantErr: "must not be empty"},
+		{name: "too many", query: "data.a=1&data.b=2&data.c=3&data.d=4&data.e=5&data.f=6", wantErr: "at most 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := ParseDataFilters(query)
+			assertError(t, err, tt.wantErr != "", true, tt.wantErr)
+			if tt.wantErr == "" && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,64 +2,307 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
 	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/richtext"
 )
 
-func GetUserFavourites(userID string) ([]*models.FavouriteAsset, error) {
-	return database.GetUserFavouritesFromDB(userID)
+// Favourites adds, changes and reads users' favourites in a repository, and
+// what is kept beside them in a store.
+type Favourites struct {
+	repo   database.FavouritesRepository
+	store  database.Store
+	outbox bool
 }
 
-func AddFavourite(ctx context.Context, userID string, asset models.Asset, description string) error {
-	if err := validateAsset(asset); err != nil {
+// NewFavourites returns the Favourites handlers storing favourites in repo
+// and the rest in store, which may be nil when the backend has none; the
+// routes that need it are then disabled. With outbox, changes record their
+// events in the event outbox of their transaction instead of publishing them
+// (see writeWithEvent).
+func NewFavourites(repo database.FavouritesRepository, store database.Store, outbox bool) *Favourites {
+	return &Favourites{repo: repo, store: store, outbox: outbox}
+}
+
+// GetUserFavourites returns the user's favourites, newest first.
+func (h *Favourites) GetUserFavourites(ctx context.Context, userID string) ([]*models.FavouriteAsset, error) {
+	return h.repo.GetUserFavourites(ctx, userID)
+}
+
+// OmitRenderedDescriptions clears the rendered descriptions of favourites, which
+// list responses only carry when asked to.
+func OmitRenderedDescriptions(favourites []*models.FavouriteAsset) {
+	for _, f := range favourites {
+		f.DescriptionHTML = ""
+	}
+}
+
+// GetUserFavouritesByProvenance returns the user's favourites whose
+// provenance matches every non-empty field of provenance exactly, newest first.
+func (h *Favourites) GetUserFavouritesByProvenance(ctx context.Context, userID string, provenance models.Provenance) ([]*models.FavouriteAsset, error) {
+	return h.repo.GetUserFavouritesByProvenance(ctx, userID, provenance)
+}
+
+// writeWithEvent makes a change and announces it with e. With the event
+// outbox enabled, write runs in a transaction that also records e, relayed to
+// the bus once committed, so e is published if and only if the change is
+// stored. Otherwise direct makes the change and e is published after it.
+// e belongs to the tenant of ctx.
+func (h *Favourites) writeWithEvent(ctx context.Context, publisher events.Publisher, e events.Event, direct func() error, write func(tx database.Tx) error) error {
+	e.TenantID = database.TenantFromContext(ctx)
+	if h.outbox {
+		return h.repo.WithTx(ctx, func(tx database.Tx) error {
+			if err := write(tx); err != nil {
+				return err
+			}
+			return tx.AppendEvent(ctx, e)
+		})
+	}
+	if err := direct(); err != nil {
 		return err
 	}
+	publisher.Publish(ctx, e)
+	return nil
+}
+
+// writeWithEvents makes a change with write, in a transaction started by
+// withTx, and announces it with the events write returns. With the event
+// outbox enabled they are recorded in the transaction, as by writeWithEvent;
+// otherwise they are published once it commits. The events belong to the
+// tenant of ctx.
+func writeWithEvents[T database.Tx](ctx context.Context, outbox bool, publisher events.Publisher, withTx func(context.Context, func(T) error) error, write func(tx T) ([]events.Event, error)) error {
+	var committed []events.Event
+	err := withTx(ctx, func(tx T) error {
+		changes, err := write(tx)
+		if err != nil {
+			return err
+		}
+		for i := range changes {
+			changes[i].TenantID = database.TenantFromContext(ctx)
+		}
+		if !outbox {
+			committed = changes
+			return nil
+		}
+		for _, e := range changes {
+			if err := tx.AppendEvent(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, e := range committed {
+		publisher.Publish(ctx, e)
+	}
+	return nil
+}
+
+// AddFavourite validates and stores a new favourite together with its
+// provenance, and publishes a FavouriteAdded event. The description is
+// sanitized and stored with its rendered HTML. When quotas define a limit for
+// the asset type, the insert is rejected with database.ErrQuotaExceeded once
+// the user has reached it.
+func (h *Favourites) AddFavourite(ctx context.Context, publisher events.Publisher, userID string, asset models.Asset, description string, provenance models.Provenance, quotas QuotaConfig) error {
+	if err := ValidateFavourite(asset, description, provenance); err != nil {
+		return err
+	}
+	event := events.Event{
+		Type:      events.FavouriteAdded,
+		UserID:    userID,
+		AssetID:   asset.GetID(),
+		AssetType: string(asset.GetType()),
+		Changes:   map[string]string{"description": description},
+	}
+	description = richtext.Sanitize(description)
 
 	favourite := &models.FavouriteAsset{
-		ID:          asset.GetID(),
-		UserID:      userID,
-		AssetType:   asset.GetType(),
-		Description: description,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		Data:        asset,
+		ID:              asset.GetID(),
+		UserID:          userID,
+		AssetType:       asset.GetType(),
+		Description:     description,
+		DescriptionHTML: richtext.Render(description),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+		Data:            asset,
+		Provenance:      provenance,
 	}
 
-	return database.AddFavouriteInDB(ctx, favourite)
+	limit := quotas.Limit(favourite.AssetType)
+	if limit == 0 {
+		return h.writeWithEvent(ctx, publisher, event,
+			func() error { return h.repo.AddFavourite(ctx, favourite) },
+			func(tx database.Tx) error { return tx.AddFavourite(ctx, favourite) })
+	}
+
+	err := h.writeWithEvent(ctx, publisher, event,
+		func() error { return h.repo.AddFavouriteWithinQuota(ctx, favourite, limit) },
+		func(tx database.Tx) error { return tx.AddFavouriteWithinQuota(ctx, favourite, limit) })
+	if errors.Is(err, database.ErrQuotaExceeded) {
+		return fmt.Errorf("%w for asset type %s (limit %d)", err, favourite.AssetType, limit)
+	}
+	return err
 }
 
-func UpdateDescription(userID, assetID, description string) error {
+// UpdateDescription sanitizes and validates description, stores it with its
+// rendered HTML and publishes a FavouriteUpdated event. The favourite is read
+// and updated in one transaction, so a concurrent change to it is not
+// overwritten with stale data.
+func (h *Favourites) UpdateDescription(ctx context.Context, publisher events.Publisher, userID, assetID, description string) error {
+	event := events.Event{
+		Type:    events.FavouriteUpdated,
+		UserID:  userID,
+		AssetID: assetID,
+		Changes: map[string]string{"description": description},
+	}
+	description = richtext.Sanitize(description)
 	if err := validateDescription(description); err != nil {
 		return err
 	}
 
-	favourite, err := database.GetFavouriteFromDB(userID, assetID)
-	if err != nil {
-		return err
+	update := func(tx database.Tx) error {
+		favourite, err := tx.GetFavourite(ctx, userID, assetID)
+		if err != nil {
+			return err
+		}
+
+		favourite.Description = description
+		favourite.DescriptionHTML = richtext.Render(description)
+		favourite.UpdatedAt = time.Now()
+
+		return tx.UpdateFavourite(ctx, favourite)
+	}
+	return h.writeWithEvent(ctx, publisher, event,
+		func() error { return h.repo.WithTx(ctx, update) },
+		update)
+}
+
+// maxBatchUpdates caps how many descriptions a single batch update may change.
+const maxBatchUpdates = 100
+
+// Per-item statuses reported by UpdateDescriptions.
+const (
+	BatchStatusUpdated  = "updated"
+	BatchStatusNotFound = "not_found"
+	BatchStatusInvalid  = "invalid"
+)
+
+// BatchUpdateResult reports the outcome of a single batch description update.
+type BatchUpdateResult struct {
+	AssetID string `json:"asset_id"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// UpdateDescriptions validates every item and applies the valid ones in a single
+// transaction, publishing a FavouriteUpdated event for each favourite changed.
+// Invalid and unknown items are reported per item without aborting the rest of
+// the batch.
+func (h *Favourites) UpdateDescriptions(ctx context.Context, publisher events.Publisher, userID string, items []BatchDescriptionUpdate) ([]BatchUpdateResult, error) {
+	if len(items) == 0 {
+		return nil, &ValidationError{Errors: []string{"at least one update is required"}}
+	}
+	if len(items) > maxBatchUpdates {
+		return nil, &ValidationError{Errors: []string{fmt.Sprintf("batch exceeds maximum of %d updates", maxBatchUpdates)}}
+	}
+
+	results := make([]BatchUpdateResult, len(items))
+	var updates []database.DescriptionUpdate
+	var updateIdx []int
+	seen := make(map[string]bool, len(items))
+
+	for i, item := range items {
+		results[i].AssetID = item.AssetID
+		description := richtext.Sanitize(item.Description)
+		err := ValidateAssetID(item.AssetID)
+		if err == nil {
+			err = validateDescription(description)
+		}
+		if err == nil && seen[item.AssetID] {
+			err = &ValidationError{Errors: []string{"duplicate asset_id in batch"}}
+		}
+		if err != nil {
+			results[i].Status = BatchStatusInvalid
+			results[i].Error = err.Error()
+			continue
+		}
+		seen[item.AssetID] = true
+		updates = append(updates, database.DescriptionUpdate{
+			AssetID:         item.AssetID,
+			Description:     description,
+			DescriptionHTML: richtext.Render(description),
+		})
+		updateIdx = append(updateIdx, i)
+	}
+
+	if len(updates) == 0 {
+		return results, nil
 	}
 
-	favourite.Description = description
-	favourite.UpdatedAt = time.Now()
+	err := writeWithEvents(ctx, h.outbox, publisher, h.repo.WithTx, func(tx database.Tx) ([]events.Event, error) {
+		matched, err := tx.UpdateDescriptions(ctx, userID, updates, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		var changes []events.Event
+		for j, i := range updateIdx {
+			if !matched[j] {
+				results[i].Status = BatchStatusNotFound
+				results[i].Error = database.ErrNotFound.Error()
+				continue
+			}
+			results[i].Status = BatchStatusUpdated
+			changes = append(changes, events.Event{
+				Type:    events.FavouriteUpdated,
+				UserID:  userID,
+				AssetID: items[i].AssetID,
+				Changes: map[string]string{"description": items[i].Description},
+			})
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
 
-	return database.UpdateFavouriteInDB(favourite)
+// RemoveFavourite deletes a favourite and publishes a FavouriteRemoved event.
+func (h *Favourites) RemoveFavourite(ctx context.Context, publisher events.Publisher, userID, assetID string) error {
+	return h.writeWithEvent(ctx, publisher, events.Event{Type: events.FavouriteRemoved, UserID: userID, AssetID: assetID},
+		func() error { return h.repo.DeleteFavourite(ctx, userID, assetID) },
+		func(tx database.Tx) error { return tx.DeleteFavourite(ctx, userID, assetID) })
 }
 
-func RemoveFavourite(userID, assetID string) error {
-	return database.DeleteFavouriteFromDB(userID, assetID)
+// FavouriteExists reports whether the user has a favourite with the asset ID.
+func (h *Favourites) FavouriteExists(ctx context.Context, userID, assetID string) (bool, error) {
+	return h.repo.FavouriteExists(ctx, userID, assetID)
 }
 
-// validateAsset chooses the correct validation function based on asset type.
-func validateAsset(asset models.Asset) error {
-	switch a := asset.(type) {
-	case *models.Chart:
-		return validateChart(a)
-	case *models.Insight:
-		return validateInsight(a)
-	case *models.Audience:
-		return validateAudience(a)
-	default:
-		return nil
+// RemoveAllFavourites deletes every favourite of the user, publishing a
+// FavouriteRemoved event for each, and returns the removed asset IDs.
+func (h *Favourites) RemoveAllFavourites(ctx context.Context, publisher events.Publisher, userID string) ([]string, error) {
+	var deleted []string
+	err := writeWithEvents(ctx, h.outbox, publisher, h.repo.WithTx, func(tx database.Tx) ([]events.Event, error) {
+		var err error
+		if deleted, err = tx.DeleteAllUserFavourites(ctx, userID); err != nil {
+			return nil, err
+		}
+		changes := make([]events.Event, len(deleted))
+		for i, assetID := range deleted {
+			changes[i] = events.Event{Type: events.FavouriteRemoved, UserID: userID, AssetID: assetID}
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return deleted, nil
 }
@@ -6,12 +6,16 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/assets"
 	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
 	"github.com/giannis84/platform-go-challenge/internal/logging"
 	"github.com/giannis84/platform-go-challenge/internal/models"
 )
@@ -22,18 +26,32 @@ func testContext() context.Context {
 	return logging.NewContextWithLogger(context.Background(), logger)
 }
 
-var testCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at"}
+var testCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at", "source_system", "source_url", "favourited_from", "description_html", "tenant_id"}
 
-// setupTest creates a sqlmock-backed db and returns the mock + test context.
-func setupTest(t *testing.T) (sqlmock.Sqlmock, context.Context) {
+// updatedAtRows is the updated_at the database returns for a written favourite.
+func updatedAtRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now())
+}
+
+// setupFavourites creates Favourites over a sqlmock-backed db and returns it
+// with the mock and a test context.
+func setupFavourites(t *testing.T) (*Favourites, sqlmock.Sqlmock, context.Context) {
 	t.Helper()
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to create sqlmock: %v", err)
 	}
 	t.Cleanup(func() { db.Close() })
-	database.DB = db
-	return mock, testContext()
+	repo := database.NewRepository(db, database.Options{})
+	return NewFavourites(repo, repo, false), mock, testContext()
+}
+
+// setupMemoryFavourites creates Favourites over an empty in-memory repository,
+// without a Store.
+func setupMemoryFavourites(t *testing.T) (*Favourites, *database.MemoryRepository) {
+	t.Helper()
+	repo := database.NewMemoryRepository()
+	return NewFavourites(repo, nil, false), repo
 }
 
 func chartData(id string) []byte {
@@ -73,7 +91,7 @@ func assertValidation(t *testing.T, err error, wantErr bool, errSubstr string) {
 
 func TestAddFavourite(t *testing.T) {
 	insertOK := func(m sqlmock.Sqlmock) {
-		m.ExpectExec("INSERT INTO favourites").WillReturnResult(sqlmock.NewResult(0, 1))
+		m.ExpectQuery("INSERT INTO favourites").WillReturnRows(updatedAtRows())
 	}
 
 	tests := []struct {
@@ -87,7 +105,7 @@ func TestAddFavourite(t *testing.T) {
 	}{
 		{name: "valid chart", userID: "user1", asset: &models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD"}, setupMock: insertOK},
 		{name: "valid insight", userID: "user1", asset: &models.Insight{ID: "i1", Text: "40% of millennials spend 3h on social media"}, setupMock: insertOK},
-		{name: "valid audience", userID: "user1", asset: &models.Audience{ID: "a1", Gender: []string{"Male"}, BirthCountry: []string{"Greece"}, AgeGroups: []string{"25-34"}, SocialMediaHoursDaily: "3-5", PurchasesLastMonth: 5}, setupMock: insertOK},
+		{name: "valid audience", userID: "user1", asset: &models.Audience{ID: "a1", Gender: []string{"Male"}, BirthCountry: []string{"GR"}, AgeGroups: []string{"25-34"}, SocialMediaHoursDaily: "3-5", PurchasesLastMonth: 5}, setupMock: insertOK},
 		{name: "valid audience minimal", userID: "user1", asset: &models.Audience{ID: "a2"}, setupMock: insertOK},
 		{name: "chart missing title", userID: "user1", asset: &models.Chart{ID: "c1", XAxisTitle: "X", YAxisTitle: "Y"}, wantErr: true, wantValErr: true, errSubstr: "title is required"},
 		{name: "insight missing text", userID: "user1", asset: &models.Insight{ID: "i1"}, wantErr: true, wantValErr: true, errSubstr: "text is required"},
@@ -97,11 +115,11 @@ func TestAddFavourite(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock, ctx := setupTest(t)
+			h, mock, ctx := setupFavourites(t)
 			if tt.setupMock != nil {
 				tt.setupMock(mock)
 			}
-			err := AddFavourite(ctx, tt.userID, tt.asset, "")
+			err := h.AddFavourite(ctx, events.NewBus(), tt.userID, tt.asset, "", models.Provenance{}, QuotaConfig{})
 			assertError(t, err, tt.wantErr, tt.wantValErr, tt.errSubstr)
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("unmet expectations: %v", err)
@@ -110,6 +128,196 @@ func TestAddFavourite(t *testing.T) {
 	}
 }
 
+func TestAddFavourite_DescriptionLimit(t *testing.T) {
+	assets.SetTextLimits(assets.TextLimits{Description: 5})
+	t.Cleanup(func() { assets.SetTextLimits(assets.TextLimits{}) })
+
+	h, mock, ctx := setupFavourites(t)
+	err := h.AddFavourite(ctx, events.NewBus(), "user1", &models.Insight{ID: "i1", Text: "t"}, "too long", models.Provenance{}, QuotaConfig{})
+	assertError(t, err, true, true, "description exceeds maximum length of 5")
+
+	err = h.UpdateDescription(context.Background(), events.NewBus(), "user1", "i1", "too long")
+	assertError(t, err, true, true, "description exceeds maximum length of 5")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddFavourite_SanitizesDescription(t *testing.T) {
+	h, mock, ctx := setupFavourites(t)
+	mock.ExpectQuery("INSERT INTO favourites").
+		WithArgs("i1", "user1", "insight", "**hi** there", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"", "", "", "<p><strong>hi</strong> there</p>", "").
+		WillReturnRows(updatedAtRows())
+
+	err := h.AddFavourite(ctx, events.NewBus(), "user1", &models.Insight{ID: "i1", Text: "t"}, "**hi** <script>alert(1)</script>there", models.Provenance{}, QuotaConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddFavourite_Quota(t *testing.T) {
+	quotas := QuotaConfig{PerType: map[models.AssetType]int{models.AssetTypeAudience: 1}}
+
+	t.Run("rejects add at the limit", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT COUNT").WithArgs("user1", "audience").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err := h.AddFavourite(ctx, events.NewBus(), "user1", &models.Audience{ID: "a1"}, "", models.Provenance{}, quotas)
+		if !errors.Is(err, database.ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "audience (limit 1)") {
+			t.Errorf("expected error to name type and limit, got: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unlimited type skips the quota transaction", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("INSERT INTO favourites").WillReturnRows(updatedAtRows())
+
+		if err := h.AddFavourite(ctx, events.NewBus(), "user1", &models.Insight{ID: "i1", Text: "t"}, "", models.Provenance{}, quotas); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestAddFavourite_Provenance(t *testing.T) {
+	insight := &models.Insight{ID: "i1", Text: "t"}
+
+	t.Run("stores provenance", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("INSERT INTO favourites").
+			WithArgs("i1", "user1", "insight", "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				"crm", "https://crm.example.com/reports/7", "dashboard", "", "").
+			WillReturnRows(updatedAtRows())
+
+		err := h.AddFavourite(ctx, events.NewBus(), "user1", insight, "", models.Provenance{
+			SourceSystem:   "crm",
+			SourceURL:      "https://crm.example.com/reports/7",
+			FavouritedFrom: "dashboard",
+		}, QuotaConfig{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		provenance models.Provenance
+		errSubstr  string
+	}{
+		{name: "relative source_url", provenance: models.Provenance{SourceURL: "/reports/7"}, errSubstr: "source_url must be an absolute http or https URL"},
+		{name: "long source_system", provenance: models.Provenance{SourceSystem: strings.Repeat("s", maxStringLength+1)}, errSubstr: "source_system exceeds maximum length"},
+		{name: "upper-case favourited_from", provenance: models.Provenance{FavouritedFrom: "Dashboard"}, errSubstr: "favourited_from may only contain"},
+		{name: "long favourited_from", provenance: models.Provenance{FavouritedFrom: strings.Repeat("f", maxFavouritedFromLength+1)}, errSubstr: "favourited_from exceeds maximum length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			err := h.AddFavourite(ctx, events.NewBus(), "user1", insight, "", tt.provenance, QuotaConfig{})
+			assertValidation(t, err, true, tt.errSubstr)
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestGetUserFavouritesByProvenance(t *testing.T) {
+	h, _ := setupMemoryFavourites(t)
+	ctx := testContext()
+	for _, add := range []struct {
+		id         string
+		provenance models.Provenance
+	}{
+		{id: "a", provenance: models.Provenance{SourceSystem: "crm", FavouritedFrom: "dashboard"}},
+		{id: "b", provenance: models.Provenance{SourceSystem: "crm", FavouritedFrom: "search"}},
+		{id: "c"},
+	} {
+		if err := h.AddFavourite(ctx, events.NewBus(), "user1", &models.Insight{ID: add.id, Text: "t"}, "", add.provenance, QuotaConfig{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ids := func(favs []*models.FavouriteAsset) string {
+		var out []string
+		for _, f := range favs {
+			out = append(out, f.ID)
+		}
+		slices.Sort(out)
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name   string
+		filter models.Provenance
+		want   string
+	}{
+		{name: "empty filter keeps all", filter: models.Provenance{}, want: "a,b,c"},
+		{name: "by source_system", filter: models.Provenance{SourceSystem: "crm"}, want: "a,b"},
+		{name: "by both fields", filter: models.Provenance{SourceSystem: "crm", FavouritedFrom: "search"}, want: "b"},
+		{name: "no match", filter: models.Provenance{FavouritedFrom: "email"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			favourites, err := h.GetUserFavouritesByProvenance(ctx, "user1", tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := ids(favourites); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetQuotaUsage(t *testing.T) {
+	h, mock, ctx := setupFavourites(t)
+	mock.ExpectQuery("SELECT asset_type, COUNT").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"asset_type", "count"}).
+			AddRow("audience", 3).
+			AddRow("chart", 2))
+
+	quotas := QuotaConfig{PerType: map[models.AssetType]int{models.AssetTypeAudience: 5}}
+	report, err := h.GetQuotaUsage(ctx, "user1", quotas)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Total != 5 {
+		t.Errorf("expected total 5, got %d", report.Total)
+	}
+	if len(report.Types) != 4 {
+		t.Fatalf("expected 4 types, got %d", len(report.Types))
+	}
+	audience := report.Types[0]
+	if audience.AssetType != models.AssetTypeAudience || audience.Used != 3 || *audience.Limit != 5 || *audience.Remaining != 2 {
+		t.Errorf("unexpected audience usage: %+v", audience)
+	}
+	if chart := report.Types[1]; chart.Limit != nil || chart.Remaining != nil || chart.Used != 2 {
+		t.Errorf("expected chart to be unlimited with 2 used, got %+v", chart)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
 func TestUpdateDescription(t *testing.T) {
 	now := time.Now()
 
@@ -126,10 +334,12 @@ func TestUpdateDescription(t *testing.T) {
 		{
 			name: "valid update", userID: "user1", assetID: "c1", description: "Updated description",
 			setupMock: func(m sqlmock.Sqlmock) {
-				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+				m.ExpectBegin()
+				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").
 					WithArgs("user1", "c1").
-					WillReturnRows(sqlmock.NewRows(testCols).AddRow("c1", "user1", "chart", "old", chartData("c1"), now, now))
-				m.ExpectExec("UPDATE favourites").WillReturnResult(sqlmock.NewResult(0, 1))
+					WillReturnRows(sqlmock.NewRows(testCols).AddRow("c1", "user1", "chart", "old", chartData("c1"), now, now, nil, nil, nil, nil, ""))
+				m.ExpectQuery("UPDATE favourites").WillReturnRows(updatedAtRows())
+				m.ExpectCommit()
 			},
 		},
 		{name: "empty description", userID: "user1", assetID: "c1", description: "", wantErr: true, wantValErr: true, errSubstr: "description is required"},
@@ -138,9 +348,11 @@ func TestUpdateDescription(t *testing.T) {
 		{
 			name: "not found", userID: "user1", assetID: "nonexistent", description: "Some description",
 			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
 				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 					WithArgs("user1", "nonexistent").
 					WillReturnRows(sqlmock.NewRows(testCols))
+				m.ExpectRollback()
 			},
 			wantErr: true, errSubstr: "not found",
 		},
@@ -148,11 +360,11 @@ func TestUpdateDescription(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock, _ := setupTest(t)
+			h, mock, _ := setupFavourites(t)
 			if tt.setupMock != nil {
 				tt.setupMock(mock)
 			}
-			err := UpdateDescription(tt.userID, tt.assetID, tt.description)
+			err := h.UpdateDescription(context.Background(), events.NewBus(), tt.userID, tt.assetID, tt.description)
 			assertError(t, err, tt.wantErr, tt.wantValErr, tt.errSubstr)
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("unmet expectations: %v", err)
@@ -161,6 +373,62 @@ func TestUpdateDescription(t *testing.T) {
 	}
 }
 
+func TestUpdateDescriptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		items        []BatchDescriptionUpdate
+		setupMock    func(sqlmock.Sqlmock)
+		wantErr      bool
+		errSubstr    string
+		wantStatuses []string
+	}{
+		{
+			name: "mixed results",
+			items: []BatchDescriptionUpdate{
+				{AssetID: "c1", Description: "new"},
+				{AssetID: "c2", Description: ""},
+				{AssetID: "missing", Description: "new"},
+				{AssetID: "c1", Description: "again"},
+			},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE favourites").WithArgs("new", sqlmock.AnyArg(), "user1", "c1", "<p>new</p>").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec("UPDATE favourites").WithArgs("new", sqlmock.AnyArg(), "user1", "missing", "<p>new</p>").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectCommit()
+			},
+			wantStatuses: []string{BatchStatusUpdated, BatchStatusInvalid, BatchStatusNotFound, BatchStatusInvalid},
+		},
+		{
+			name:         "all invalid skips database",
+			items:        []BatchDescriptionUpdate{{AssetID: " ", Description: "x"}},
+			wantStatuses: []string{BatchStatusInvalid},
+		},
+		{name: "empty batch", items: nil, wantErr: true, errSubstr: "at least one update is required"},
+		{name: "batch too large", items: make([]BatchDescriptionUpdate, maxBatchUpdates+1), wantErr: true, errSubstr: "batch exceeds maximum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+			results, err := h.UpdateDescriptions(ctx, events.NewBus(), "user1", tt.items)
+			assertError(t, err, tt.wantErr, tt.wantErr, tt.errSubstr)
+			for i, want := range tt.wantStatuses {
+				if results[i].Status != want {
+					t.Errorf("item %d: expected status %q, got %q (%s)", i, want, results[i].Status, results[i].Error)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
 func TestRemoveFavourite(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -173,13 +441,13 @@ func TestRemoveFavourite(t *testing.T) {
 		{
 			name: "remove existing", userID: "user1", assetID: "c1",
 			setupMock: func(m sqlmock.Sqlmock) {
-				m.ExpectExec("DELETE FROM favourites").WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec("UPDATE favourites SET deleted_at = NOW").WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 		},
 		{
 			name: "not found", userID: "user1", assetID: "nonexistent",
 			setupMock: func(m sqlmock.Sqlmock) {
-				m.ExpectExec("DELETE FROM favourites").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectExec("UPDATE favourites SET deleted_at = NOW").WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 			wantErr: true, errSubstr: "not found",
 		},
@@ -187,9 +455,9 @@ func TestRemoveFavourite(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock, _ := setupTest(t)
+			h, mock, _ := setupFavourites(t)
 			tt.setupMock(mock)
-			err := RemoveFavourite(tt.userID, tt.assetID)
+			err := h.RemoveFavourite(context.Background(), events.NewBus(), tt.userID, tt.assetID)
 			assertError(t, err, tt.wantErr, false, tt.errSubstr)
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("unmet expectations: %v", err)
@@ -198,43 +466,57 @@ func TestRemoveFavourite(t *testing.T) {
 	}
 }
 
-func TestGetUserFavourites(t *testing.T) {
-	now := time.Now()
-
+func TestRemoveFavourite_Events(t *testing.T) {
 	tests := []struct {
-		name      string
-		userID    string
-		setupMock func(sqlmock.Sqlmock)
-		wantCount int
+		name        string
+		outbox      bool
+		setupMock   func(sqlmock.Sqlmock)
+		wantErr     bool
+		wantPublish int
 	}{
 		{
-			name: "returns favourites", userID: "user1", wantCount: 2,
+			name: "published after the delete",
 			setupMock: func(m sqlmock.Sqlmock) {
-				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("user1").WillReturnRows(
-					sqlmock.NewRows(testCols).
-						AddRow("a", "user1", "chart", "", chartData("a"), now, now).
-						AddRow("b", "user1", "chart", "", chartData("b"), now, now))
+				m.ExpectExec("UPDATE favourites SET deleted_at = NOW").WillReturnResult(sqlmock.NewResult(0, 1))
 			},
+			wantPublish: 1,
 		},
 		{
-			name: "empty for unknown user", userID: "unknown", wantCount: 0,
+			name: "recorded in the delete transaction", outbox: true,
 			setupMock: func(m sqlmock.Sqlmock) {
-				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("unknown").
-					WillReturnRows(sqlmock.NewRows(testCols))
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE favourites SET deleted_at = NOW").WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec("INSERT INTO event_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+				m.ExpectCommit()
 			},
 		},
+		{
+			name: "not recorded when nothing is deleted", outbox: true,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE favourites SET deleted_at = NOW").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock, _ := setupTest(t)
+			h, mock, _ := setupFavourites(t)
+			h.outbox = tt.outbox
 			tt.setupMock(mock)
-			favourites, err := GetUserFavourites(tt.userID)
-			if err != nil {
+
+			bus := events.NewBus()
+			published := 0
+			bus.Subscribe(func(events.Event) { published++ })
+
+			err := h.RemoveFavourite(context.Background(), bus, "user1", "c1")
+			if (err != nil) != tt.wantErr {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if len(favourites) != tt.wantCount {
-				t.Errorf("expected %d favourites, got %d", tt.wantCount, len(favourites))
+			if published != tt.wantPublish {
+				t.Errorf("expected %d events published directly, got %d", tt.wantPublish, published)
 			}
 			if err := mock.ExpectationsWereMet(); err != nil {
 				t.Errorf("unmet expectations: %v", err)
@@ -243,76 +525,235 @@ func TestGetUserFavourites(t *testing.T) {
 	}
 }
 
-// --- Validation tests ---
-
-func TestValidateChart(t *testing.T) {
+func TestRemoveAllFavourites(t *testing.T) {
 	tests := []struct {
-		name      string
-		chart     models.Chart
-		wantErr   bool
-		errSubstr string
+		name        string
+		outbox      bool
+		wantPublish int
 	}{
-		{name: "valid chart", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD"}},
-		{name: "missing id", chart: models.Chart{Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: "id is required"},
-		{name: "missing title", chart: models.Chart{ID: "c1", XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: "title is required"},
-		{name: "missing x_axis_title", chart: models.Chart{ID: "c1", Title: "Revenue", YAxisTitle: "USD"}, wantErr: true, errSubstr: "x_axis_title is required"},
-		{name: "missing y_axis_title", chart: models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month"}, wantErr: true, errSubstr: "y_axis_title is required"},
-		{name: "title too long", chart: models.Chart{ID: "c1", Title: strings.Repeat("a", 256), XAxisTitle: "Month", YAxisTitle: "USD"}, wantErr: true, errSubstr: "title exceeds maximum length"},
-		{name: "all required fields missing", chart: models.Chart{}, wantErr: true, errSubstr: "id is required"},
+		{name: "published after the commit", wantPublish: 2},
+		{name: "recorded in the transaction", outbox: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assertValidation(t, validateChart(&tt.chart), tt.wantErr, tt.errSubstr)
+			h, mock, ctx := setupFavourites(t)
+			h.outbox = tt.outbox
+			mock.ExpectBegin()
+			mock.ExpectQuery("UPDATE favourites SET deleted_at = NOW\\(\\) WHERE user_id").
+				WithArgs("user1").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("c1").AddRow("i1"))
+			if tt.outbox {
+				mock.ExpectExec("INSERT INTO event_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO event_outbox").WillReturnResult(sqlmock.NewResult(2, 1))
+			}
+			mock.ExpectCommit()
+
+			bus := events.NewBus()
+			published := 0
+			bus.Subscribe(func(events.Event) { published++ })
+
+			deleted, err := h.RemoveAllFavourites(ctx, bus, "user1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(deleted) != 2 {
+				t.Errorf("expected 2 deleted, got %v", deleted)
+			}
+			if published != tt.wantPublish {
+				t.Errorf("expected %d events published directly, got %d", tt.wantPublish, published)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
 		})
 	}
 }
 
-func TestValidateInsight(t *testing.T) {
+func TestGetUserFavourites(t *testing.T) {
+	now := time.Now()
+
 	tests := []struct {
 		name      string
-		insight   models.Insight
-		wantErr   bool
-		errSubstr string
+		userID    string
+		setupMock func(sqlmock.Sqlmock)
+		wantCount int
 	}{
-		{name: "valid insight", insight: models.Insight{ID: "i1", Text: "40% of millennials spend 3h on social media"}},
-		{name: "missing id", insight: models.Insight{Text: "some text"}, wantErr: true, errSubstr: "id is required"},
-		{name: "missing text", insight: models.Insight{ID: "i1"}, wantErr: true, errSubstr: "text is required"},
-		{name: "text too long", insight: models.Insight{ID: "i1", Text: strings.Repeat("x", 256)}, wantErr: true, errSubstr: "text exceeds maximum length"},
+		{
+			name: "returns favourites", userID: "user1", wantCount: 2,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("user1").WillReturnRows(
+					sqlmock.NewRows(testCols).
+						AddRow("a", "user1", "chart", "", chartData("a"), now, now, nil, nil, nil, nil, "").
+						AddRow("b", "user1", "chart", "", chartData("b"), now, now, nil, nil, nil, nil, ""))
+			},
+		},
+		{
+			name: "empty for unknown user", userID: "unknown", wantCount: 0,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("unknown").
+					WillReturnRows(sqlmock.NewRows(testCols))
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assertValidation(t, validateInsight(&tt.insight), tt.wantErr, tt.errSubstr)
+			h, mock, _ := setupFavourites(t)
+			tt.setupMock(mock)
+			favourites, err := h.GetUserFavourites(context.Background(), tt.userID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(favourites) != tt.wantCount {
+				t.Errorf("expected %d favourites, got %d", tt.wantCount, len(favourites))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
 		})
 	}
 }
 
-func TestValidateAudience(t *testing.T) {
-	tests := []struct {
-		name      string
-		audience  models.Audience
-		wantErr   bool
-		errSubstr string
-	}{
-		{name: "valid audience with all fields", audience: models.Audience{ID: "a1", Gender: []string{"Male"}, BirthCountry: []string{"Greece"}, AgeGroups: []string{"25-34"}, SocialMediaHoursDaily: "3-5", PurchasesLastMonth: 5}},
-		{name: "valid audience with only required fields", audience: models.Audience{ID: "a2"}},
-		{name: "valid audience with partial optional fields", audience: models.Audience{ID: "a3", AgeGroups: []string{"18-24", "25-34"}}},
-		{name: "missing id", audience: models.Audience{Gender: []string{"Male"}}, wantErr: true, errSubstr: "id is required"},
-		{name: "invalid gender value", audience: models.Audience{ID: "a1", Gender: []string{"Other"}}, wantErr: true, errSubstr: "gender[0] has invalid value"},
-		{name: "invalid age group value", audience: models.Audience{ID: "a1", AgeGroups: []string{"10-17"}}, wantErr: true, errSubstr: "age_groups[0] has invalid value"},
-		{name: "invalid social media hours", audience: models.Audience{ID: "a1", SocialMediaHoursDaily: "10+"}, wantErr: true, errSubstr: "social_media_hours_daily has invalid value"},
-		{name: "negative purchases", audience: models.Audience{ID: "a1", PurchasesLastMonth: -1}, wantErr: true, errSubstr: "purchases_last_month must not be negative"},
-		{name: "empty birth country entry", audience: models.Audience{ID: "a1", BirthCountry: []string{""}}, wantErr: true, errSubstr: "birth_country[0] is required"},
-	}
+func TestFavourites_MemoryRepository(t *testing.T) {
+	ctx := testContext()
+	chart := &models.Chart{ID: "c1", Title: "Revenue", XAxisTitle: "Month", YAxisTitle: "USD"}
+	insight := &models.Insight{ID: "i1", Text: "40% of millennials spend 3h on social media"}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			assertValidation(t, validateAudience(&tt.audience), tt.wantErr, tt.errSubstr)
+	t.Run("adds, updates and lists", func(t *testing.T) {
+		h, _ := setupMemoryFavourites(t)
+		bus := events.NewBus()
+		var published []events.Type
+		bus.Subscribe(func(e events.Event) { published = append(published, e.Type) })
+
+		if err := h.AddFavourite(ctx, bus, "user1", chart, "", models.Provenance{SourceSystem: "crm"}, QuotaConfig{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.AddFavourite(ctx, bus, "user1", insight, "", models.Provenance{}, QuotaConfig{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.UpdateDescription(ctx, bus, "user1", "c1", "**best** chart"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		favourites, err := h.GetUserFavourites(ctx, "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(favourites) != 2 {
+			t.Fatalf("expected 2 favourites, got %d", len(favourites))
+		}
+		var got *models.FavouriteAsset
+		for _, fav := range favourites {
+			if fav.ID == "c1" {
+				got = fav
+			}
+		}
+		if got == nil {
+			t.Fatal("chart favourite not listed")
+		}
+		if got.Description != "**best** chart" || got.DescriptionHTML != "<p><strong>best</strong> chart</p>" {
+			t.Errorf("unexpected description %q / %q", got.Description, got.DescriptionHTML)
+		}
+		if chart, ok := got.Data.(*models.Chart); !ok || chart.Title != "Revenue" {
+			t.Errorf("unexpected asset data %#v", got.Data)
+		}
+		if got.Provenance.SourceSystem != "crm" {
+			t.Errorf("expected provenance to be kept, got %+v", got.Provenance)
+		}
+		want := []events.Type{events.FavouriteAdded, events.FavouriteAdded, events.FavouriteUpdated}
+		if !reflect.DeepEqual(published, want) {
+			t.Errorf("expected events %v, got %v", want, published)
+		}
+	})
+
+	t.Run("rejects a duplicate", func(t *testing.T) {
+		h, _ := setupMemoryFavourites(t)
+		if err := h.AddFavourite(ctx, events.NewBus(), "user1", chart, "", models.Provenance{}, QuotaConfig{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err := h.AddFavourite(ctx, events.NewBus(), "user1", chart, "", models.Provenance{}, QuotaConfig{})
+		if !errors.Is(err, database.ErrAlreadyExists) {
+			t.Errorf("expected ErrAlreadyExists, got: %v", err)
+		}
+	})
+
+	t.Run("enforces the quota", func(t *testing.T) {
+		h, _ := setupMemoryFavourites(t)
+		quotas := QuotaConfig{PerType: map[models.AssetType]int{models.AssetTypeChart: 1}}
+		if err := h.AddFavourite(ctx, events.NewBus(), "user1", chart, "", models.Provenance{}, quotas); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		second := &models.Chart{ID: "c2", Title: "Costs", XAxisTitle: "Month", YAxisTitle: "USD"}
+		err := h.AddFavourite(ctx, events.NewBus(), "user1", second, "", models.Provenance{}, quotas)
+		if !errors.Is(err, database.ErrQuotaExceeded) {
+			t.Fatalf("expected ErrQuotaExceeded, got: %v", err)
+		}
+		report, err := h.GetQuotaUsage(ctx, "user1", quotas)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Total != 1 {
+			t.Errorf("expected 1 favourite counted, got %d", report.Total)
+		}
+	})
+
+	t.Run("batch updates report unknown favourites", func(t *testing.T) {
+		h, _ := setupMemoryFavourites(t)
+		if err := h.AddFavourite(ctx, events.NewBus(), "user1", insight, "", models.Provenance{}, QuotaConfig{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		results, err := h.UpdateDescriptions(ctx, events.NewBus(), "user1", []BatchDescriptionUpdate{
+			{AssetID: "i1", Description: "kept"},
+			{AssetID: "missing", Description: "lost"},
 		})
-	}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if results[0].Status != BatchStatusUpdated || results[1].Status != BatchStatusNotFound {
+			t.Errorf("unexpected results %+v", results)
+		}
+	})
+
+	t.Run("removes favourites", func(t *testing.T) {
+		h, _ := setupMemoryFavourites(t)
+		for _, asset := range []models.Asset{chart, insight} {
+			if err := h.AddFavourite(ctx, events.NewBus(), "user1", asset, "", models.Provenance{}, QuotaConfig{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := h.RemoveFavourite(ctx, events.NewBus(), "user1", "c1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.RemoveFavourite(ctx, events.NewBus(), "user1", "c1"); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("expected ErrNotFound removing twice, got: %v", err)
+		}
+		deleted, err := h.RemoveAllFavourites(ctx, events.NewBus(), "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(deleted, []string{"i1"}) {
+			t.Errorf("expected [i1] removed, got %v", deleted)
+		}
+	})
+
+	t.Run("keeps tenants apart", func(t *testing.T) {
+		h, _ := setupMemoryFavourites(t)
+		acme := database.WithTenant(ctx, "acme")
+		if err := h.AddFavourite(acme, events.NewBus(), "user1", chart, "", models.Provenance{}, QuotaConfig{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exists, _ := h.FavouriteExists(database.WithTenant(ctx, "globex"), "user1", "c1"); exists {
+			t.Error("expected the favourite to be hidden from another tenant")
+		}
+		if exists, _ := h.FavouriteExists(acme, "user1", "c1"); !exists {
+			t.Error("expected the favourite in its tenant")
+		}
+	})
 }
 
+// --- Validation tests ---
+
 func TestValidateDescription(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -352,13 +793,68 @@ func TestValidateAssetID(t *testing.T) {
 	}
 }
 
-func TestValidationErrorCollectsAllFieldErrors(t *testing.T) {
-	err := validateChart(&models.Chart{})
-	valErr, ok := err.(*ValidationError)
-	if !ok {
-		t.Fatalf("expected *ValidationError, got %T", err)
+func TestCheckAddFavouriteRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  AddFavouriteRequest
+		want ValidationReport
+	}{
+		{
+			name: "valid",
+			req:  AddFavouriteRequest{AssetType: "insight", AssetData: json.RawMessage(`{"id":"i1","text":"t"}`)},
+			want: ValidationReport{Valid: true},
+		},
+		{
+			name: "unknown type",
+			req:  AddFavouriteRequest{AssetType: "segment", AssetData: json.RawMessage(`{}`)},
+			want: ValidationReport{Errors: []string{`unknown asset type: "segment"`}},
+		},
+		{
+			name: "every failure is reported",
+			req: AddFavouriteRequest{
+				AssetType:   "insight",
+				AssetData:   json.RawMessage(`{"id":"i1"}`),
+				Description: strings.Repeat("x", 256),
+				Provenance:  models.Provenance{FavouritedFrom: "Search"},
+			},
+			want: ValidationReport{Errors: []string{
+				"text is required",
+				"description exceeds maximum length of 255",
+				"favourited_from may only contain lower-case letters, digits, '-' and '_'",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckAddFavouriteRequest(&tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckAddFavouriteRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAddFavouriteRequest_ResolvesAlias(t *testing.T) {
+	assets.SetAliases(assets.Aliases{"segment": models.AssetTypeAudience})
+	t.Cleanup(func() { assets.SetAliases(nil) })
+
+	asset, err := ParseAddFavouriteRequest(&AddFavouriteRequest{
+		AssetType: "segment",
+		AssetData: json.RawMessage(`{"id":"a1"}`),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(valErr.Errors) != 4 {
-		t.Errorf("expected 4 validation errors, got %d: %v", len(valErr.Errors), valErr.Errors)
+	if asset.GetType() != models.AssetTypeAudience {
+		t.Errorf("expected an audience, got %q", asset.GetType())
+	}
+}
+
+func TestParseAddFavouriteRequest_RejectsDisabledOptInType(t *testing.T) {
+	_, err := ParseAddFavouriteRequest(&AddFavouriteRequest{
+		AssetType: "generic",
+		AssetData: json.RawMessage(`{"id":"g1","title":"Funnel","payload":{}}`),
+	})
+	if !IsInvalidAssetType(err) {
+		t.Errorf("expected an invalid asset type error, got %v", err)
 	}
 }
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/richtext"
+)
+
+// maxImportFavourites caps how many favourites a single import may add.
+const maxImportFavourites = 10000
+
+// Per-item statuses reported by ImportFavourites.
+const (
+	ImportStatusAdded         = "added"
+	ImportStatusExists        = "exists"
+	ImportStatusQuotaExceeded = "quota_exceeded"
+	ImportStatusInvalid       = "invalid"
+)
+
+// ImportResult reports the outcome of a single imported favourite.
+type ImportResult struct {
+	AssetID string `json:"asset_id,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ImportFavourites validates every item and adds the valid ones to the user's
+// favourites in a single transaction, publishing a FavouriteAdded event for
+// each favourite added. Favourites of asset types without a quota are
+// inserted together (see database.Tx.AddFavouritesBatch); those of types
+// with one are added one by one within it. Invalid items, favourites the
+// user already has and those over quota are reported per item without
+// aborting the rest of the import.
+func (h *Favourites) ImportFavourites(ctx context.Context, publisher events.Publisher, userID string, items []AddFavouriteRequest, quotas QuotaConfig) ([]ImportResult, error) {
+	if len(items) == 0 {
+		return nil, &ValidationError{Errors: []string{"at least one favourite is required"}}
+	}
+	if len(items) > maxImportFavourites {
+		return nil, &ValidationError{Errors: []string{fmt.Sprintf("import exceeds maximum of %d favourites", maxImportFavourites)}}
+	}
+
+	results := make([]ImportResult, len(items))
+	favourites := make([]*models.FavouriteAsset, len(items))
+	seen := make(map[string]bool, len(items))
+	now := time.Now()
+
+	for i := range items {
+		item := &items[i]
+		asset, err := ParseAddFavouriteRequest(item)
+		if err == nil {
+			results[i].AssetID = asset.GetID()
+			err = ValidateFavourite(asset, item.Description, item.Provenance)
+		}
+		if err == nil && seen[asset.GetID()] {
+			err = &ValidationError{Errors: []string{"duplicate asset_id in import"}}
+		}
+		if err != nil {
+			results[i].Status = ImportStatusInvalid
+			results[i].Error = err.Error()
+			continue
+		}
+		seen[asset.GetID()] = true
+		description := richtext.Sanitize(item.Description)
+		favourites[i] = &models.FavouriteAsset{
+			ID:              asset.GetID(),
+			UserID:          userID,
+			AssetType:       asset.GetType(),
+			Description:     description,
+			DescriptionHTML: richtext.Render(description),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			Data:            asset,
+			Provenance:      item.Provenance,
+		}
+	}
+
+	err := writeWithEvents(ctx, h.outbox, publisher, h.repo.WithTx, func(tx database.Tx) ([]events.Event, error) {
+		var batch []*models.FavouriteAsset
+		index := make(map[*models.FavouriteAsset]int, len(favourites))
+		for i, fav := range favourites {
+			if fav == nil {
+				continue
+			}
+			index[fav] = i
+			limit := quotas.Limit(fav.AssetType)
+			if limit == 0 {
+				batch = append(batch, fav)
+				continue
+			}
+			err := tx.AddFavouriteWithinQuota(ctx, fav, limit)
+			switch {
+			case err == nil:
+				results[i].Status = ImportStatusAdded
+			case errors.Is(err, database.ErrAlreadyExists):
+				results[i].Status = ImportStatusExists
+			case errors.Is(err, database.ErrQuotaExceeded):
+				results[i].Status = ImportStatusQuotaExceeded
+				results[i].Error = fmt.Sprintf("%v for asset type %s (limit %d)", err, fav.AssetType, limit)
+			default:
+				return nil, err
+			}
+		}
+		if len(batch) > 0 {
+			conflicts, err := tx.AddFavouritesBatch(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
+			for _, fav := range batch {
+				results[index[fav]].Status = ImportStatusAdded
+			}
+			for _, fav := range conflicts {
+				results[index[fav]].Status = ImportStatusExists
+			}
+		}
+
+		var changes []events.Event
+		for i, fav := range favourites {
+			if fav == nil || results[i].Status != ImportStatusAdded {
+				continue
+			}
+			changes = append(changes, events.Event{
+				Type:      events.FavouriteAdded,
+				UserID:    userID,
+				AssetID:   fav.ID,
+				AssetType: string(fav.AssetType),
+				Changes:   map[string]string{"description": items[i].Description},
+			})
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func importItem(assetType AssetType, data, description string) AddFavouriteRequest {
+	return AddFavouriteRequest{AssetType: assetType, AssetData: []byte(data), Description: description}
+}
+
+func TestImportFavourites(t *testing.T) {
+	h, repo := setupMemoryFavourites(t)
+	ctx := context.Background()
+	if err := repo.AddFavourite(ctx, &models.FavouriteAsset{ID: "c0", UserID: "user1", AssetType: models.AssetTypeChart, Data: &models.Chart{ID: "c0"}}); err != nil {
+		t.Fatal(err)
+	}
+	bus := events.NewBus()
+	var added []string
+	bus.Subscribe(func(e events.Event) {
+		if e.Type == events.FavouriteAdded {
+			added = append(added, e.AssetID)
+		}
+	})
+
+	items := []AddFavouriteRequest{
+		importItem("chart", string(chartData("c1")), "first"),
+		importItem("chart", string(chartData("c0")), ""),
+		importItem("insight", `{"id":"i1","text":"t"}`, ""),
+		importItem("insight", `{"id":"i2","text":"t"}`, ""),
+		importItem("chart", string(chartData("c1")), "again"),
+		importItem("chart", `{"id":"c2"`, ""),
+	}
+	quotas := QuotaConfig{PerType: map[models.AssetType]int{models.AssetTypeInsight: 1}}
+	results, err := h.ImportFavourites(ctx, bus, "user1", items, quotas)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{ImportStatusAdded, ImportStatusExists, ImportStatusAdded, ImportStatusQuotaExceeded, ImportStatusInvalid, ImportStatusInvalid}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("item %d: expected status %q, got %q (%s)", i, status, results[i].Status, results[i].Error)
+		}
+	}
+	if results[0].AssetID != "c1" || results[5].AssetID != "" {
+		t.Errorf("unexpected asset IDs: %+v", results)
+	}
+	if strings.Join(added, ",") != "c1,i1" {
+		t.Errorf("expected FavouriteAdded for c1 and i1, got %v", added)
+	}
+	fav, err := repo.GetFavourite(ctx, "user1", "c1")
+	if err != nil || fav.DescriptionHTML != "<p>first</p>" {
+		t.Errorf("c1 not stored with its rendered description: %+v, %v", fav, err)
+	}
+}
+
+func TestImportFavourites_Batch(t *testing.T) {
+	h, mock, ctx := setupFavourites(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO favourites .+ VALUES \(\$1, .+\),\s+\(\$13, .+\$24\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "updated_at"}).AddRow("user1", "c2", time.Now()))
+	mock.ExpectCommit()
+
+	items := []AddFavouriteRequest{
+		importItem("chart", string(chartData("c1")), ""),
+		importItem("chart", string(chartData("c2")), ""),
+	}
+	results, err := h.ImportFavourites(ctx, events.NewBus(), "user1", items, QuotaConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Status != ImportStatusExists || results[1].Status != ImportStatusAdded {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestImportFavourites_Limits(t *testing.T) {
+	h, _ := setupMemoryFavourites(t)
+	for name, items := range map[string][]AddFavouriteRequest{
+		"at least one favourite is required": nil,
+		"import exceeds maximum":             make([]AddFavouriteRequest, maxImportFavourites+1),
+	} {
+		_, err := h.ImportFavourites(context.Background(), events.NewBus(), "user1", items, QuotaConfig{})
+		assertError(t, err, true, true, name)
+	}
+}
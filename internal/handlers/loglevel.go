@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// LogLevelRequest is the request payload for changing the log level.
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// LogLevel is the response of reading or changing the log level.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// CurrentLogLevel returns the minimum level of the entries logged.
+func CurrentLogLevel() *LogLevel {
+	return &LogLevel{Level: logging.Level().String()}
+}
+
+// SetLogLevel changes the minimum level of the entries logged to req.Level,
+// taking effect at once for every logger of the process.
+func SetLogLevel(req *LogLevelRequest) (*LogLevel, error) {
+	err := validate(
+		func() string { return requireNonEmpty("level", req.Level) },
+		func() string {
+			if req.Level == "" {
+				return ""
+			}
+			if _, err := logging.ParseLevel(req.Level); err != nil {
+				return "level must be one of debug, info, warn, error"
+			}
+			return ""
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	l, _ := logging.ParseLevel(req.Level)
+	logging.SetLevel(l)
+	return CurrentLogLevel(), nil
+}
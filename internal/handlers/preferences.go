@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// defaultTimezone is reported when a user has not set a timezone preference.
+const defaultTimezone = "UTC"
+
+// UserPreferences is the request and response payload of the preferences endpoint.
+type UserPreferences struct {
+	Timezone string `json:"timezone"`
+}
+
+// GetPreferences returns the user's preferences, falling back to UTC.
+func (h *Favourites) GetPreferences(ctx context.Context, userID string) (*UserPreferences, error) {
+	timezone, err := h.store.GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	return &UserPreferences{Timezone: timezone}, nil
+}
+
+// UpdatePreferences validates and stores the user's preferences.
+func (h *Favourites) UpdatePreferences(ctx context.Context, userID string, prefs *UserPreferences) error {
+	if err := validateTimezone("timezone", prefs.Timezone); err != nil {
+		return err
+	}
+	return h.store.SetUserTimezone(ctx, userID, prefs.Timezone)
+}
+
+// ResolveLocation picks the timezone used to render timestamps for a request.
+// An explicit override (the X-Timezone header) wins; otherwise the user's stored
+// preference applies, if there is a store. A nil location means timestamps are
+// rendered as stored (UTC).
+func (h *Favourites) ResolveLocation(ctx context.Context, userID, override string) (*time.Location, error) {
+	if override != "" {
+		if err := validateTimezone("X-Timezone", override); err != nil {
+			return nil, err
+		}
+		return time.LoadLocation(override)
+	}
+	if h.store == nil {
+		return nil, nil
+	}
+
+	timezone, err := h.store.GetUserTimezone(ctx, userID)
+	if err != nil || timezone == "" {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading stored timezone %q: %w", timezone, err)
+	}
+	return loc, nil
+}
+
+// LocalizeFavourites converts created_at/updated_at of each favourite to loc.
+// Storage is unaffected; only the rendered offset changes.
+func LocalizeFavourites(favourites []*models.FavouriteAsset, loc *time.Location) {
+	if loc == nil {
+		return
+	}
+	for _, f := range favourites {
+		f.CreatedAt = f.CreatedAt.In(loc)
+		f.UpdatedAt = f.UpdatedAt.In(loc)
+	}
+}
+
+// validateTimezone checks that value names an IANA timezone.
+func validateTimezone(field, value string) error {
+	return validate(
+		func() string { return requireNonEmpty(field, value) },
+		func() string {
+			if value == "" {
+				return ""
+			}
+			if value == "Local" {
+				return fmt.Sprintf("%s must be an IANA timezone name", field)
+			}
+			if _, err := time.LoadLocation(value); err != nil {
+				return fmt.Sprintf("%s has invalid value %q (must be an IANA timezone name)", field, value)
+			}
+			return ""
+		},
+	)
+}
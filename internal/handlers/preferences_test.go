@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func expectTimezone(m sqlmock.Sqlmock, userID, timezone string) {
+	rows := sqlmock.NewRows([]string{"timezone"})
+	if timezone != "" {
+		rows.AddRow(timezone)
+	}
+	m.ExpectQuery("SELECT timezone FROM user_preferences").WithArgs(userID).WillReturnRows(rows)
+}
+
+func TestGetPreferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored string
+		want   string
+	}{
+		{name: "stored timezone", stored: "Europe/Athens", want: "Europe/Athens"},
+		{name: "defaults to UTC", stored: "", want: "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			expectTimezone(mock, "user1", tt.stored)
+
+			prefs, err := h.GetPreferences(ctx, "user1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prefs.Timezone != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, prefs.Timezone)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		timezone  string
+		setupMock func(sqlmock.Sqlmock)
+		wantErr   bool
+		errSubstr string
+	}{
+		{
+			name: "valid timezone", timezone: "Asia/Tokyo",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectExec("INSERT INTO user_preferences").
+					WithArgs("user1", "Asia/Tokyo", sqlmock.AnyArg(), "").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{name: "empty timezone", timezone: "", wantErr: true, errSubstr: "timezone is required"},
+		{name: "unknown timezone", timezone: "Mars/Olympus", wantErr: true, errSubstr: "must be an IANA timezone name"},
+		{name: "Local is rejected", timezone: "Local", wantErr: true, errSubstr: "must be an IANA timezone name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+			err := h.UpdatePreferences(ctx, "user1", &UserPreferences{Timezone: tt.timezone})
+			assertError(t, err, tt.wantErr, tt.wantErr, tt.errSubstr)
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		stored   string
+		queryDB  bool
+		wantLoc  string
+		wantErr  bool
+	}{
+		{name: "header override wins", override: "Asia/Tokyo", wantLoc: "Asia/Tokyo"},
+		{name: "stored preference", stored: "Europe/Athens", queryDB: true, wantLoc: "Europe/Athens"},
+		{name: "no preference", queryDB: true, wantLoc: ""},
+		{name: "invalid override", override: "Nowhere/City", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.queryDB {
+				expectTimezone(mock, "user1", tt.stored)
+			}
+
+			loc, err := h.ResolveLocation(ctx, "user1", tt.override)
+			assertError(t, err, tt.wantErr, tt.wantErr, "")
+			if !tt.wantErr {
+				got := ""
+				if loc != nil {
+					got = loc.String()
+				}
+				if got != tt.wantLoc {
+					t.Errorf("expected location %q, got %q", tt.wantLoc, got)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocalizeFavourites(t *testing.T) {
+	stored := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	favs := []*models.FavouriteAsset{{ID: "c1", CreatedAt: stored, UpdatedAt: stored}}
+	athens, _ := time.LoadLocation("Europe/Athens")
+
+	LocalizeFavourites(favs, athens)
+
+	if !favs[0].CreatedAt.Equal(stored) {
+		t.Errorf("expected the same instant, got %v", favs[0].CreatedAt)
+	}
+	if got := favs[0].CreatedAt.Format(time.RFC3339); got != "2026-03-01T14:00:00+02:00" {
+		t.Errorf("expected Athens offset, got %s", got)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+// maxSearchQueryLength caps the ?q= prefix of a user search. Longer prefixes
+// match nothing useful and only make the LIKE scan more expensive.
+const maxSearchQueryLength = 64
+
+// QueryRejectedError reports a search or report the service refused to run,
+// or cut short, because of its cost. Unlike a ValidationError the request is
+// well-formed; narrowing it may let it through.
+type QueryRejectedError struct {
+	Reason string
+}
+
+func (e *QueryRejectedError) Error() string {
+	return fmt.Sprintf("query rejected: %s", e.Reason)
+}
+
+// rejectOverBudget turns a statement timeout into a QueryRejectedError
+// carrying hint on how to narrow the query; other errors pass through.
+func rejectOverBudget(err error, hint string) error {
+	if errors.Is(err, database.ErrStatementTimeout) {
+		return &QueryRejectedError{Reason: fmt.Sprintf("%s; %s", database.ErrStatementTimeout, hint)}
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// QuotaConfig holds per-asset-type limits on how many favourites a single user
+// may keep. Types without an entry, or with a limit <= 0, are unlimited.
+type QuotaConfig struct {
+	PerType map[models.AssetType]int
+}
+
+// Limit returns the limit for assetType, or 0 when it is unlimited.
+func (q QuotaConfig) Limit(assetType models.AssetType) int {
+	if limit := q.PerType[assetType]; limit > 0 {
+		return limit
+	}
+	return 0
+}
+
+// QuotaUsage reports usage against the quota of a single asset type.
+// Limit and Remaining are null when the type is unlimited.
+type QuotaUsage struct {
+	AssetType models.AssetType `json:"asset_type"`
+	Used      int              `json:"used"`
+	Limit     *int             `json:"limit"`
+	Remaining *int             `json:"remaining"`
+}
+
+// QuotaReport is the per-type quota breakdown for a user.
+type QuotaReport struct {
+	Total int          `json:"total"`
+	Types []QuotaUsage `json:"types"`
+}
+
+// GetQuotaUsage returns the user's favourites count per asset type alongside the configured limits.
+func (h *Favourites) GetQuotaUsage(ctx context.Context, userID string, quotas QuotaConfig) (*QuotaReport, error) {
+	counts, err := h.repo.CountUserFavouritesByType(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	types := map[models.AssetType]bool{}
+	for _, name := range assets.Names() {
+		types[name] = true
+	}
+	for t := range counts {
+		types[t] = true
+	}
+	for t := range quotas.PerType {
+		types[t] = true
+	}
+
+	report := &QuotaReport{Types: make([]QuotaUsage, 0, len(types))}
+	for t := range types {
+		usage := QuotaUsage{AssetType: t, Used: counts[t]}
+		if limit := quotas.Limit(t); limit > 0 {
+			remaining := max(limit-usage.Used, 0)
+			usage.Limit = &limit
+			usage.Remaining = &remaining
+		}
+		report.Total += usage.Used
+		report.Types = append(report.Types, usage)
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		return report.Types[i].AssetType < report.Types[j].AssetType
+	})
+	return report, nil
+}
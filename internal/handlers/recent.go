@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+const (
+	// defaultActivityWindow is the period covered when no window is given.
+	defaultActivityWindow = 7 * 24 * time.Hour
+	// maxActivityWindow caps how far back a recent activity request may look.
+	maxActivityWindow = 90 * 24 * time.Hour
+)
+
+// GetRecentFavourites returns the user's favourites created or updated within
+// window of now, most recently changed first, with timestamps rendered in loc
+// (UTC when nil). window is a day count such as "7d" or a Go duration such as
+// "12h"; an empty window selects the default.
+func (h *Favourites) GetRecentFavourites(ctx context.Context, userID, window string, loc *time.Location) ([]*models.FavouriteAsset, error) {
+	d, err := ParseActivityWindow(window)
+	if err != nil {
+		return nil, err
+	}
+
+	favourites, err := h.repo.GetRecentUserFavourites(ctx, userID, time.Now().Add(-d))
+	if err != nil {
+		return nil, err
+	}
+	LocalizeFavourites(favourites, loc)
+	return favourites, nil
+}
+
+// ParseActivityWindow parses a recent activity window, accepting a whole
+// number of days ("7d") or a Go duration ("36h").
+func ParseActivityWindow(window string) (time.Duration, error) {
+	if window == "" {
+		return defaultActivityWindow, nil
+	}
+
+	invalid := &ValidationError{Errors: []string{
+		fmt.Sprintf("window must be a positive duration such as 7d or 12h, at most %dd", int(maxActivityWindow/(24*time.Hour))),
+	}}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(window, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, invalid
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(window); err != nil {
+			return 0, invalid
+		}
+	}
+	if d <= 0 || d > maxActivityWindow {
+		return 0, invalid
+	}
+	return d, nil
+}
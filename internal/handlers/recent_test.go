@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseActivityWindow(t *testing.T) {
+	tests := []struct {
+		window  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{window: "", want: defaultActivityWindow},
+		{window: "7d", want: 7 * 24 * time.Hour},
+		{window: "36h", want: 36 * time.Hour},
+		{window: "90d", want: maxActivityWindow},
+		{window: "91d", wantErr: true},
+		{window: "0d", wantErr: true},
+		{window: "-2h", wantErr: true},
+		{window: "1.5d", wantErr: true},
+		{window: "week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			got, err := ParseActivityWindow(tt.window)
+			assertError(t, err, tt.wantErr, tt.wantErr, "window must be")
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetRecentFavourites(t *testing.T) {
+	athens, _ := time.LoadLocation("Europe/Athens")
+	now := time.Now().UTC()
+
+	t.Run("queries the window and localizes", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id = \\$1 AND updated_at >= \\$2").
+			WithArgs("user1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(testCols).AddRow("c1", "user1", "chart", "d", chartData("c1"), now, now, nil, nil, nil, nil, ""))
+
+		favourites, err := h.GetRecentFavourites(ctx, "user1", "3d", athens)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(favourites) != 1 || favourites[0].UpdatedAt.Location() != athens {
+			t.Errorf("unexpected favourites: %+v", favourites)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rejects invalid window before querying", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		_, err := h.GetRecentFavourites(ctx, "user1", "forever", nil)
+		assertError(t, err, true, true, "window must be")
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+)
+
+// maxJTILength caps the token IDs accepted for revocation.
+const maxJTILength = 256
+
+// RevokeTokenRequest is the request payload for revoking a token. ExpiresAt
+// is the token's own expiry ("exp"), after which it needs no denying.
+type RevokeTokenRequest struct {
+	JTI       string     `json:"jti"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// TokenRevocation is the response of revoking a token.
+type TokenRevocation struct {
+	JTI          string    `json:"jti"`
+	RevokedUntil time.Time `json:"revoked_until"`
+}
+
+// RevokeToken denies the token req.JTI until req.ExpiresAt or, when the
+// token's expiry is not given, for defaultTTL.
+func RevokeToken(ctx context.Context, denylist auth.Denylist, req *RevokeTokenRequest, defaultTTL time.Duration) (*TokenRevocation, error) {
+	now := time.Now().UTC()
+	err := validate(
+		func() string { return requireNonEmpty("jti", req.JTI) },
+		func() string { return checkMaxLength("jti", req.JTI, maxJTILength) },
+		func() string {
+			if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+				return "expires_at must be in the future"
+			}
+			return ""
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	until := now.Add(defaultTTL)
+	if req.ExpiresAt != nil {
+		until = req.ExpiresAt.UTC()
+	}
+	if err := denylist.Revoke(ctx, req.JTI, until); err != nil {
+		return nil, err
+	}
+	return &TokenRevocation{JTI: req.JTI, RevokedUntil: until}, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+)
+
+func TestRevokeToken(t *testing.T) {
+	expiry := time.Now().Add(30 * time.Minute).UTC().Truncate(time.Second)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		req       RevokeTokenRequest
+		wantUntil func(time.Time) bool
+		wantErr   bool
+		errSubstr string
+	}{
+		{
+			name:      "token expiry given",
+			req:       RevokeTokenRequest{JTI: "jti1", ExpiresAt: &expiry},
+			wantUntil: func(u time.Time) bool { return u.Equal(expiry) },
+		},
+		{
+			name:      "default TTL",
+			req:       RevokeTokenRequest{JTI: "jti1"},
+			wantUntil: func(u time.Time) bool { return time.Until(u) > 59*time.Minute },
+		},
+		{name: "missing jti", req: RevokeTokenRequest{}, wantErr: true, errSubstr: "jti"},
+		{name: "jti too long", req: RevokeTokenRequest{JTI: strings.Repeat("a", maxJTILength+1)}, wantErr: true, errSubstr: "maximum length"},
+		{name: "past expiry", req: RevokeTokenRequest{JTI: "jti1", ExpiresAt: &past}, wantErr: true, errSubstr: "future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denylist := auth.NewMemoryDenylist()
+			got, err := RevokeToken(context.Background(), denylist, &tt.req, time.Hour)
+			assertError(t, err, tt.wantErr, true, tt.errSubstr)
+			if tt.wantErr {
+				return
+			}
+			if !tt.wantUntil(got.RevokedUntil) {
+				t.Errorf("unexpected revoked_until %s", got.RevokedUntil)
+			}
+			if revoked, _ := denylist.IsRevoked(context.Background(), tt.req.JTI); !revoked {
+				t.Error("expected the token to be denied")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+const (
+	// defaultShareTTL is how long a signed URL stays valid when no ttl is given.
+	defaultShareTTL = 15 * time.Minute
+	// maxShareTTL caps how long a signed URL may stay valid.
+	maxShareTTL = 24 * time.Hour
+)
+
+// ShareRequest is the request payload for issuing a signed, read-only URL to
+// the user's favourites. AssetType optionally limits it to one asset type.
+type ShareRequest struct {
+	AssetType string `json:"asset_type"`
+	TTL       string `json:"ttl"`
+}
+
+// ShareLink is a signed URL and when it stops being accepted.
+type ShareLink struct {
+	URL       string    `json:"url"`
+	AssetType string    `json:"asset_type,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewShareGrant validates req and returns the grant it describes for userID.
+func NewShareGrant(userID string, req *ShareRequest, now time.Time) (auth.Grant, error) {
+	ttl := defaultShareTTL
+	var ttlErr string
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 || d > maxShareTTL {
+			ttlErr = fmt.Sprintf("ttl must be a positive duration of at most %s", maxShareTTL)
+		}
+		ttl = d
+	}
+
+	assetType := string(assets.Resolve(models.AssetType(req.AssetType)))
+	err := validate(
+		func() string { return ttlErr },
+		func() string {
+			if assetType == "" {
+				return ""
+			}
+			var names []string
+			for _, name := range assets.Names() {
+				names = append(names, string(name))
+			}
+			return checkInList("asset_type", assetType, names)
+		},
+	)
+	if err != nil {
+		return auth.Grant{}, err
+	}
+
+	return auth.Grant{
+		UserID:    userID,
+		AssetType: assetType,
+		ExpiresAt: now.Add(ttl).UTC().Truncate(time.Second),
+	}, nil
+}
+
+// FilterSharedFavourites returns the favourites the grant covers.
+func FilterSharedFavourites(favourites []*models.FavouriteAsset, grant auth.Grant) []*models.FavouriteAsset {
+	if grant.AssetType == "" {
+		return favourites
+	}
+	shared := []*models.FavouriteAsset{}
+	for _, f := range favourites {
+		if string(f.AssetType) == grant.AssetType {
+			shared = append(shared, f)
+		}
+	}
+	return shared
+}
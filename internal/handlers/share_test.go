@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestNewShareGrant(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		req       ShareRequest
+		wantErr   bool
+		errSubstr string
+		wantExp   time.Time
+	}{
+		{name: "defaults", req: ShareRequest{}, wantExp: now.Add(defaultShareTTL)},
+		{name: "type and ttl", req: ShareRequest{AssetType: "chart", TTL: "2h"}, wantExp: now.Add(2 * time.Hour)},
+		{name: "ttl too long", req: ShareRequest{TTL: "25h"}, wantErr: true, errSubstr: "ttl must be"},
+		{name: "ttl not a duration", req: ShareRequest{TTL: "soon"}, wantErr: true, errSubstr: "ttl must be"},
+		{name: "unknown type", req: ShareRequest{AssetType: "map"}, wantErr: true, errSubstr: "asset_type has invalid value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grant, err := NewShareGrant("user1", &tt.req, now)
+			assertError(t, err, tt.wantErr, tt.wantErr, tt.errSubstr)
+			if tt.wantErr {
+				return
+			}
+			if grant.UserID != "user1" || grant.AssetType != tt.req.AssetType || !grant.ExpiresAt.Equal(tt.wantExp) {
+				t.Errorf("unexpected grant: %+v", grant)
+			}
+		})
+	}
+}
+
+func TestFilterSharedFavourites(t *testing.T) {
+	favourites := []*models.FavouriteAsset{
+		{ID: "c1", AssetType: models.AssetTypeChart},
+		{ID: "i1", AssetType: models.AssetTypeInsight},
+	}
+
+	if got := FilterSharedFavourites(favourites, auth.Grant{UserID: "user1"}); len(got) != 2 {
+		t.Errorf("expected all favourites for an untyped grant, got %d", len(got))
+	}
+	got := FilterSharedFavourites(favourites, auth.Grant{UserID: "user1", AssetType: "insight"})
+	if len(got) != 1 || got[0].ID != "i1" {
+		t.Errorf("expected only the insight, got %+v", got)
+	}
+}
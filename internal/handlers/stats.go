@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// recentWindow is the period covered by the "added recently" counters.
+const recentWindow = 30 * 24 * time.Hour
+
+// TypeStats summarises a user's favourites of a single asset type.
+// The timestamps are null when the user has none of that type.
+type TypeStats struct {
+	AssetType       models.AssetType `json:"asset_type"`
+	Count           int              `json:"count"`
+	FirstAddedAt    *time.Time       `json:"first_added_at"`
+	LastAddedAt     *time.Time       `json:"last_added_at"`
+	AddedLast30Days int              `json:"added_last_30_days"`
+}
+
+// FavouriteStats summarises all of a user's favourites.
+type FavouriteStats struct {
+	Total           int         `json:"total"`
+	FirstAddedAt    *time.Time  `json:"first_added_at"`
+	LastAddedAt     *time.Time  `json:"last_added_at"`
+	AddedLast30Days int         `json:"added_last_30_days"`
+	Types           []TypeStats `json:"types"`
+}
+
+// GetFavouriteStats aggregates the user's favourites per asset type, with
+// timestamps rendered in loc (UTC when nil). Only favourites that still exist
+// are counted, so "added in the last 30 days" excludes ones removed since.
+func (h *Favourites) GetFavouriteStats(ctx context.Context, userID string, loc *time.Location) (*FavouriteStats, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	rows, err := h.repo.GetUserFavouriteStats(ctx, userID, time.Now().Add(-recentWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	byType := map[models.AssetType]TypeStats{}
+	for _, name := range assets.Names() {
+		byType[name] = TypeStats{AssetType: name}
+	}
+	stats := &FavouriteStats{}
+	for _, row := range rows {
+		first, last := row.FirstAddedAt.In(loc), row.LastAddedAt.In(loc)
+		byType[row.AssetType] = TypeStats{
+			AssetType:       row.AssetType,
+			Count:           row.Count,
+			FirstAddedAt:    &first,
+			LastAddedAt:     &last,
+			AddedLast30Days: row.AddedSince,
+		}
+
+		stats.Total += row.Count
+		stats.AddedLast30Days += row.AddedSince
+		if stats.FirstAddedAt == nil || first.Before(*stats.FirstAddedAt) {
+			stats.FirstAddedAt = &first
+		}
+		if stats.LastAddedAt == nil || last.After(*stats.LastAddedAt) {
+			stats.LastAddedAt = &last
+		}
+	}
+
+	stats.Types = make([]TypeStats, 0, len(byType))
+	for _, st := range byType {
+		stats.Types = append(stats.Types, st)
+	}
+	sort.Slice(stats.Types, func(i, j int) bool {
+		return stats.Types[i].AssetType < stats.Types[j].AssetType
+	})
+	return stats, nil
+}
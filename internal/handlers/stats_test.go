@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+var statsCols = []string{"asset_type", "count", "min", "max", "recent"}
+
+func TestGetFavouriteStats(t *testing.T) {
+	first := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	middle := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	last := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	athens, _ := time.LoadLocation("Europe/Athens")
+
+	t.Run("aggregates across types", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT asset_type, COUNT").WithArgs("user1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(statsCols).
+				AddRow("chart", 3, middle, last, 2).
+				AddRow("insight", 1, first, first, 0))
+
+		stats, err := h.GetFavouriteStats(ctx, "user1", athens)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.Total != 4 || stats.AddedLast30Days != 2 {
+			t.Errorf("unexpected totals: %+v", stats)
+		}
+		if !stats.FirstAddedAt.Equal(first) || !stats.LastAddedAt.Equal(last) {
+			t.Errorf("unexpected first/last: %v, %v", stats.FirstAddedAt, stats.LastAddedAt)
+		}
+		if stats.LastAddedAt.Location() != athens {
+			t.Errorf("expected timestamps in %s, got %s", athens, stats.LastAddedAt.Location())
+		}
+		if len(stats.Types) != 4 || stats.Types[0].AssetType != models.AssetTypeAudience {
+			t.Fatalf("expected all four types sorted, got %+v", stats.Types)
+		}
+		if audience := stats.Types[0]; audience.Count != 0 || audience.FirstAddedAt != nil {
+			t.Errorf("expected empty audience stats, got %+v", audience)
+		}
+		if chart := stats.Types[1]; chart.Count != 3 || chart.AddedLast30Days != 2 || !chart.FirstAddedAt.Equal(middle) {
+			t.Errorf("unexpected chart stats: %+v", chart)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("no favourites", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT asset_type, COUNT").WillReturnRows(sqlmock.NewRows(statsCols))
+
+		stats, err := h.GetFavouriteStats(ctx, "user1", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.Total != 0 || stats.FirstAddedAt != nil || stats.LastAddedAt != nil || len(stats.Types) != 4 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		h, mock, ctx := setupFavourites(t)
+		mock.ExpectQuery("SELECT asset_type, COUNT").WillReturnError(fmt.Errorf("connection failed"))
+
+		if _, err := h.GetFavouriteStats(ctx, "user1", nil); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
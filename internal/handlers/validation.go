@@ -2,28 +2,31 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/giannis84/platform-go-challenge/internal/assets"
 	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/richtext"
 )
 
-const maxStringLength = 255
+const maxStringLength = assets.MaxStringLength
 
-// AssetType represents the type of asset being favourited.
-type AssetType string
+// maxFavouritedFromLength bounds favourited_from, which names a UI surface
+// such as "dashboard" or "search".
+const maxFavouritedFromLength = 64
 
-const (
-	AssetTypeChart    AssetType = "chart"
-	AssetTypeInsight  AssetType = "insight"
-	AssetTypeAudience AssetType = "audience"
-)
+// AssetType names the type of asset being favourited; see assets.Names for the
+// registered ones.
+type AssetType string
 
 // AddFavouriteRequest is the request payload for adding a favourite.
 type AddFavouriteRequest struct {
 	AssetType   AssetType       `json:"asset_type"`
 	Description string          `json:"description"`
 	AssetData   json.RawMessage `json:"asset_data"`
+	models.Provenance
 }
 
 // UpdateDescriptionRequest is the request payload for updating a favourite's description.
@@ -31,72 +34,46 @@ type UpdateDescriptionRequest struct {
 	Description string `json:"description"`
 }
 
-// ParseAddFavouriteRequest validates the request and returns the parsed asset.
-// It handles asset type validation and type-specific unmarshaling.
-func ParseAddFavouriteRequest(req *AddFavouriteRequest) (models.Asset, error) {
-	switch req.AssetType {
-	case AssetTypeChart:
-		var chart models.Chart
-		if err := json.Unmarshal(req.AssetData, &chart); err != nil {
-			return nil, fmt.Errorf("invalid chart data: %w", err)
-		}
-		return &chart, nil
-	case AssetTypeInsight:
-		var insight models.Insight
-		if err := json.Unmarshal(req.AssetData, &insight); err != nil {
-			return nil, fmt.Errorf("invalid insight data: %w", err)
-		}
-		return &insight, nil
-	case AssetTypeAudience:
-		var audience models.Audience
-		if err := json.Unmarshal(req.AssetData, &audience); err != nil {
-			return nil, fmt.Errorf("invalid audience data: %w", err)
-		}
-		return &audience, nil
-	default:
-		return nil, fmt.Errorf("invalid asset_type: %q", req.AssetType)
-	}
+// BatchDescriptionUpdate is a single entry of the batch description update payload.
+type BatchDescriptionUpdate struct {
+	AssetID     string `json:"asset_id"`
+	Description string `json:"description"`
 }
 
-// ValidateAssetID validates that an asset ID is not empty.
-func ValidateAssetID(assetID string) error {
-	if strings.TrimSpace(assetID) == "" {
-		return &ValidationError{Errors: []string{"asset_id is required"}}
+// ParseAddFavouriteRequest decodes the asset payload with the decoder
+// registered for req.AssetType, or for the type it is an alias of.
+// Unregistered types, and opt-in types that are not enabled, fail with
+// assets.ErrUnknownType.
+func ParseAddFavouriteRequest(req *AddFavouriteRequest) (models.Asset, error) {
+	name := assets.Resolve(models.AssetType(req.AssetType))
+	if !assets.Enabled(name) {
+		return nil, fmt.Errorf("%w: %q", assets.ErrUnknownType, name)
 	}
-	return nil
+	return assets.Decode(name, req.AssetData)
 }
 
-// IsInvalidAssetType returns true if the error is due to invalid asset type.
-func IsInvalidAssetType(err error) bool {
-	return err != nil && strings.HasPrefix(err.Error(), "invalid asset_type:")
+// ValidationReport is the outcome of validating an add request without
+// storing it.
+type ValidationReport struct {
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
 }
 
-// IsInvalidAssetData returns true if the error is due to invalid asset data.
-func IsInvalidAssetData(err error) bool {
-	return err != nil && strings.HasPrefix(err.Error(), "invalid ")
-}
-
-var (
-	validGenders          = []string{"Male", "Female"}
-	validAgeGroups        = []string{"18-24", "25-34", "35-44", "45-54", "55+"}
-	validSocialMediaHours = []string{"0-1", "1-3", "3-5", "5+"}
-)
-
-// ValidationError holds a list of field-level validation errors.
-type ValidationError struct {
-	Errors []string
-}
-
-func (e *ValidationError) Error() string {
-	return fmt.Sprintf("validation failed: %s", strings.Join(e.Errors, "; "))
-}
-
-// validate collects errors and returns a *ValidationError if any exist.
-func validate(checks ...func() string) error {
+// ValidateFavourite runs the checks AddFavourite applies before writing: the
+// asset, the sanitized description and the provenance. Their failures are
+// reported together in one *ValidationError.
+func ValidateFavourite(asset models.Asset, description string, provenance models.Provenance) error {
 	var errs []string
-	for _, check := range checks {
-		if msg := check(); msg != "" {
-			errs = append(errs, msg)
+	for _, err := range []error{
+		asset.Validate(assets.CurrentValidator()),
+		validate(func() string { return checkDescriptionLength(richtext.Sanitize(description)) }),
+		validateProvenance(provenance),
+	} {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			errs = append(errs, validationErr.Errors...)
+		} else if err != nil {
+			return err
 		}
 	}
 	if len(errs) > 0 {
@@ -105,106 +82,84 @@ func validate(checks ...func() string) error {
 	return nil
 }
 
-func requireNonEmpty(field, value string) string {
-	if strings.TrimSpace(value) == "" {
-		return fmt.Sprintf("%s is required", field)
+// CheckAddFavouriteRequest parses and validates req as AddFavourite would,
+// without touching the database, so duplicates and quotas are not checked.
+func CheckAddFavouriteRequest(req *AddFavouriteRequest) ValidationReport {
+	asset, err := ParseAddFavouriteRequest(req)
+	if err == nil {
+		err = ValidateFavourite(asset, req.Description, req.Provenance)
 	}
-	return ""
-}
-
-func checkMaxLength(field, value string, max int) string {
-	if len(value) > max {
-		return fmt.Sprintf("%s exceeds maximum length of %d", field, max)
+	var validationErr *ValidationError
+	switch {
+	case err == nil:
+		return ValidationReport{Valid: true}
+	case errors.As(err, &validationErr):
+		return ValidationReport{Errors: validationErr.Errors}
+	default:
+		return ValidationReport{Errors: []string{err.Error()}}
 	}
-	return ""
 }
 
-func checkInList(field, value string, allowed []string) string {
-	for _, v := range allowed {
-		if value == v {
-			return ""
-		}
+// ValidateAssetID validates that an asset ID is not empty.
+func ValidateAssetID(assetID string) error {
+	if strings.TrimSpace(assetID) == "" {
+		return &ValidationError{Errors: []string{"asset_id is required"}}
 	}
-	return fmt.Sprintf("%s has invalid value %q (allowed: %s)", field, value, strings.Join(allowed, ", "))
+	return nil
 }
 
-func checkNonNegative(field string, value int) string {
-	if value < 0 {
-		return fmt.Sprintf("%s must not be negative", field)
-	}
-	return ""
+// IsInvalidAssetType returns true if the error is due to invalid asset type.
+func IsInvalidAssetType(err error) bool {
+	return errors.Is(err, assets.ErrUnknownType)
 }
 
-// validateChart validates required fields and length constraints for a Chart asset.
-func validateChart(c *models.Chart) error {
-	return validate(
-		func() string { return requireNonEmpty("id", c.ID) },
-		func() string { return checkMaxLength("id", c.ID, maxStringLength) },
-		func() string { return requireNonEmpty("title", c.Title) },
-		func() string { return checkMaxLength("title", c.Title, maxStringLength) },
-		func() string { return requireNonEmpty("x_axis_title", c.XAxisTitle) },
-		func() string { return checkMaxLength("x_axis_title", c.XAxisTitle, maxStringLength) },
-		func() string { return requireNonEmpty("y_axis_title", c.YAxisTitle) },
-		func() string { return checkMaxLength("y_axis_title", c.YAxisTitle, maxStringLength) },
-	)
+// IsInvalidAssetData returns true if the error is due to invalid asset data.
+func IsInvalidAssetData(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "invalid ")
 }
 
-// validateInsight validates required fields and length constraints for an Insight asset.
-func validateInsight(i *models.Insight) error {
+// ValidationError holds a list of field-level validation errors.
+type ValidationError = assets.ValidationError
+
+// Validation helpers shared with the asset validators.
+var (
+	validate        = assets.Validate
+	requireNonEmpty = assets.RequireNonEmpty
+	checkMaxLength  = assets.CheckMaxLength
+	checkInList     = assets.CheckInList
+	checkSourceURL  = assets.CheckSourceURL
+)
+
+// validateDescription validates the description field on update requests.
+func validateDescription(description string) error {
 	return validate(
-		func() string { return requireNonEmpty("id", i.ID) },
-		func() string { return checkMaxLength("id", i.ID, maxStringLength) },
-		func() string { return requireNonEmpty("text", i.Text) },
-		func() string { return checkMaxLength("text", i.Text, maxStringLength) },
+		func() string { return requireNonEmpty("description", description) },
+		func() string { return checkDescriptionLength(description) },
 	)
 }
 
-// validateAudience validates an Audience asset. Only ID is required;
-// other fields are optional but validated when provided.
-func validateAudience(a *models.Audience) error {
-	checks := []func() string{
-		func() string { return requireNonEmpty("id", a.ID) },
-		func() string { return checkMaxLength("id", a.ID, maxStringLength) },
-		func() string { return checkNonNegative("purchases_last_month", a.PurchasesLastMonth) },
-	}
-
-	for i, g := range a.Gender {
-		g := g
-		i := i
-		checks = append(checks, func() string {
-			return checkInList(fmt.Sprintf("gender[%d]", i), g, validGenders)
-		})
-	}
-
-	for i, c := range a.BirthCountry {
-		c := c
-		i := i
-		checks = append(checks, func() string {
-			return requireNonEmpty(fmt.Sprintf("birth_country[%d]", i), c)
-		})
-	}
-
-	for i, ag := range a.AgeGroups {
-		ag := ag
-		i := i
-		checks = append(checks, func() string {
-			return checkInList(fmt.Sprintf("age_groups[%d]", i), ag, validAgeGroups)
-		})
-	}
-
-	if a.SocialMediaHoursDaily != "" {
-		checks = append(checks, func() string {
-			return checkInList("social_media_hours_daily", a.SocialMediaHoursDaily, validSocialMediaHours)
-		})
-	}
-
-	return validate(checks...)
+// checkDescriptionLength applies the configured description length limit.
+func checkDescriptionLength(description string) string {
+	return checkMaxLength("description", description, assets.CurrentTextLimits().Description)
 }
 
-// validateDescription validates the description field on update requests.
-func validateDescription(description string) error {
+// validateProvenance validates the optional provenance fields of an add request.
+// favourited_from is limited to lower-case letters, digits, '-' and '_' so it
+// groups cleanly in analytics.
+func validateProvenance(p models.Provenance) error {
 	return validate(
-		func() string { return requireNonEmpty("description", description) },
-		func() string { return checkMaxLength("description", description, maxStringLength) },
+		func() string { return checkMaxLength("source_system", p.SourceSystem, maxStringLength) },
+		func() string { return checkSourceURL(p.SourceURL) },
+		func() string { return checkMaxLength("favourited_from", p.FavouritedFrom, maxFavouritedFromLength) },
+		func() string {
+			if strings.ContainsFunc(p.FavouritedFrom, invalidFavouritedFromRune) {
+				return "favourited_from may only contain lower-case letters, digits, '-' and '_'"
+			}
+			return ""
+		},
 	)
 }
+
+func invalidFavouritedFromRune(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+// ReplaceAssetDataRequest is the body of a request replacing a favourite's asset data.
+type ReplaceAssetDataRequest struct {
+	AssetData json.RawMessage `json:"asset_data"`
+}
+
+// VersionResult reports the version a favourite's asset data is now at.
+type VersionResult struct {
+	AssetID        string `json:"asset_id"`
+	CurrentVersion int    `json:"current_version"`
+}
+
+// VersionHistory lists the asset data a favourite held before, newest first.
+type VersionHistory struct {
+	AssetID        string                       `json:"asset_id"`
+	CurrentVersion int                          `json:"current_version"`
+	Versions       []*database.FavouriteVersion `json:"versions"`
+}
+
+// ReplaceAssetData validates data as the favourite's asset type and stores it,
+// keeping the replaced data as a version, and publishes a FavouriteUpdated
+// event. The asset type and ID cannot change.
+func (h *Favourites) ReplaceAssetData(ctx context.Context, publisher events.Publisher, userID, assetID string, data json.RawMessage) (*VersionResult, error) {
+	var version int
+	err := writeWithEvents(ctx, h.outbox, publisher, h.store.WithStoreTx, func(tx database.StoreTx) ([]events.Event, error) {
+		favourite, err := tx.GetFavourite(ctx, userID, assetID)
+		if err != nil {
+			return nil, err
+		}
+
+		asset, err := assets.Decode(favourite.AssetType, data)
+		if err != nil {
+			return nil, &ValidationError{Errors: []string{err.Error()}}
+		}
+		if err := asset.Validate(assets.CurrentValidator()); err != nil {
+			return nil, err
+		}
+		if asset.GetID() != assetID {
+			return nil, &ValidationError{Errors: []string{fmt.Sprintf("asset_data.id must be the favourite's asset ID %q", assetID)}}
+		}
+
+		if version, err = tx.ReplaceAssetData(ctx, userID, assetID, asset, time.Now()); err != nil {
+			return nil, err
+		}
+		return []events.Event{{
+			Type:    events.FavouriteUpdated,
+			UserID:  userID,
+			AssetID: assetID,
+			Changes: map[string]string{"version": strconv.Itoa(version)},
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &VersionResult{AssetID: assetID, CurrentVersion: version}, nil
+}
+
+// GetVersionHistory returns the earlier asset data of a favourite.
+func (h *Favourites) GetVersionHistory(ctx context.Context, userID, assetID string) (*VersionHistory, error) {
+	favourite, err := h.repo.GetFavourite(ctx, userID, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := h.store.GetFavouriteVersions(ctx, userID, assetID, favourite.AssetType)
+	if err != nil {
+		return nil, err
+	}
+
+	current := 1
+	if len(versions) > 0 {
+		current = versions[0].Version + 1
+	}
+	return &VersionHistory{AssetID: assetID, CurrentVersion: current, Versions: versions}, nil
+}
+
+// RevertAssetData restores the asset data of an earlier version and publishes
+// a FavouriteUpdated event. The data it replaces is kept as a version too.
+func (h *Favourites) RevertAssetData(ctx context.Context, publisher events.Publisher, userID, assetID string, version int) (*VersionResult, error) {
+	if version < 1 {
+		return nil, &ValidationError{Errors: []string{"version must be a positive integer"}}
+	}
+
+	var current int
+	err := writeWithEvents(ctx, h.outbox, publisher, h.store.WithStoreTx, func(tx database.StoreTx) ([]events.Event, error) {
+		var err error
+		if current, err = tx.RevertAssetData(ctx, userID, assetID, version, time.Now()); err != nil {
+			return nil, err
+		}
+		return []events.Event{{
+			Type:    events.FavouriteUpdated,
+			UserID:  userID,
+			AssetID: assetID,
+			Changes: map[string]string{
+				"version":     strconv.Itoa(current),
+				"reverted_to": strconv.Itoa(version),
+			},
+		}}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &VersionResult{AssetID: assetID, CurrentVersion: current}, nil
+}
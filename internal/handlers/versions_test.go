@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+func TestReplaceAssetData_Validation(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		data      string
+		errSubstr string
+	}{
+		{name: "wrong shape", data: `{"id": 1}`, errSubstr: "invalid chart data"},
+		{name: "invalid asset", data: `{"id": "c1"}`, errSubstr: "title is required"},
+		{name: "changed id", data: `{"id": "c2", "title": "T", "x_axis_title": "X", "y_axis_title": "Y"}`, errSubstr: `asset_data.id must be the favourite's asset ID "c1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "c1").
+				WillReturnRows(sqlmock.NewRows(testCols).AddRow("c1", "user1", "chart", "desc", chartData("c1"), now, now, nil, nil, nil, nil, ""))
+			mock.ExpectRollback()
+
+			_, err := h.ReplaceAssetData(ctx, events.NewBus(), "user1", "c1", json.RawMessage(tt.data))
+			assertError(t, err, true, true, tt.errSubstr)
+		})
+	}
+}
+
+func TestGetVersionHistory_NeverReplaced(t *testing.T) {
+	now := time.Now()
+	h, mock, ctx := setupFavourites(t)
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("user1", "c1").
+		WillReturnRows(sqlmock.NewRows(testCols).AddRow("c1", "user1", "chart", "desc", chartData("c1"), now, now, nil, nil, nil, nil, ""))
+	mock.ExpectQuery("SELECT version, data, replaced_at, tenant_id FROM favourite_versions").WithArgs("user1", "c1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "data", "replaced_at", "tenant_id"}))
+
+	history, err := h.GetVersionHistory(ctx, "user1", "c1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.CurrentVersion != 1 || len(history.Versions) != 0 {
+		t.Errorf("expected version 1 without history, got %+v", history)
+	}
+}
+
+func TestRevertAssetData_RejectsInvalidVersion(t *testing.T) {
+	h, _, ctx := setupFavourites(t)
+	_, err := h.RevertAssetData(ctx, events.NewBus(), "user1", "c1", 0)
+	assertError(t, err, true, true, "version must be a positive integer")
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
+)
+
+// ReplayQueuedFavourite returns the function used to store favourites that were
+// queued while the database was unavailable. Replays are duplicate-safe: a
+// favourite that already exists (e.g. because the original insert did land) is
+// treated as stored. Entries that fail for any reason other than the database
+// being unreachable are dropped and logged, since retrying cannot help.
+func (h *Favourites) ReplayQueuedFavourite(quotas QuotaConfig, publisher events.Publisher, logger *slog.Logger) queue.ReplayFunc {
+	return func(ctx context.Context, e queue.Entry) error {
+		if e.TenantID != "" {
+			ctx = database.WithTenant(ctx, e.TenantID)
+		}
+		req := &AddFavouriteRequest{
+			AssetType:   AssetType(e.AssetType),
+			Description: e.Description,
+			AssetData:   e.AssetData,
+			Provenance: models.Provenance{
+				SourceSystem:   e.SourceSystem,
+				SourceURL:      e.SourceURL,
+				FavouritedFrom: e.FavouritedFrom,
+			},
+		}
+		asset, err := ParseAddFavouriteRequest(req)
+		if err == nil {
+			err = h.AddFavourite(ctx, publisher, e.UserID, asset, e.Description, req.Provenance, quotas)
+		}
+
+		switch {
+		case err == nil, errors.Is(err, database.ErrAlreadyExists):
+			return nil
+		case database.IsUnavailable(err):
+			return err
+		default:
+			logging.With(logger).Layer("handler").Op("replayQueuedFavourite").User(e.UserID).Asset(e.AssetID).
+				Str("queued_at", e.QueuedAt.String()).Err(err).
+				Error("dropping queued favourite that cannot be stored")
+			return nil
+		}
+	}
+}
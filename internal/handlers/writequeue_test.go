@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
+	"github.com/lib/pq"
+)
+
+var errConnRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func TestReplayQueuedFavourite(t *testing.T) {
+	queued := queue.Entry{
+		UserID:      "user1",
+		AssetID:     "i1",
+		AssetType:   "insight",
+		AssetData:   []byte(`{"id":"i1","text":"some insight"}`),
+		Description: "desc",
+	}
+
+	tests := []struct {
+		name       string
+		entry      queue.Entry
+		setupMock  func(sqlmock.Sqlmock)
+		wantRetry  bool
+		wantEvents int
+	}{
+		{
+			name: "stored", entry: queued,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("INSERT INTO favourites").WillReturnRows(updatedAtRows())
+			},
+			wantEvents: 1,
+		},
+		{
+			name: "duplicate is treated as stored", entry: queued,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("INSERT INTO favourites").WillReturnError(&pq.Error{Code: "23505"})
+			},
+		},
+		{
+			name: "database still unavailable", entry: queued,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("INSERT INTO favourites").WillReturnError(errConnRefused)
+			},
+			wantRetry: true,
+		},
+		{
+			name: "permanent failure is dropped", entry: queued,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("INSERT INTO favourites").WillReturnError(fmt.Errorf("value too long"))
+			},
+		},
+		{
+			name:  "invalid entry is dropped",
+			entry: queue.Entry{UserID: "user1", AssetID: "i1", AssetType: "insight", AssetData: []byte(`{"id":"i1"}`)},
+		},
+		{
+			name:  "unparseable entry is dropped",
+			entry: queue.Entry{UserID: "user1", AssetID: "x", AssetType: "widget", AssetData: []byte(`{}`)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock, ctx := setupFavourites(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+			bus := events.NewBus()
+			var received []events.Event
+			bus.Subscribe(func(e events.Event) { received = append(received, e) })
+
+			replay := h.ReplayQueuedFavourite(QuotaConfig{}, bus, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			err := replay(ctx, tt.entry)
+
+			if tt.wantRetry != (err != nil) {
+				t.Errorf("wantRetry=%v, got err=%v", tt.wantRetry, err)
+			}
+			if tt.wantRetry && !errors.Is(err, errConnRefused) {
+				t.Errorf("expected connection error to be returned, got %v", err)
+			}
+			if len(received) != tt.wantEvents {
+				t.Errorf("expected %d events, got %d", tt.wantEvents, len(received))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package health runs the health checks of the service's components and
+// sums them up in a report.
+package health
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Statuses of a component and of the service as a whole.
+const (
+	StatusOK = "ok"
+	// StatusDegraded means a non-critical component is down; the service
+	// still works, with less redundancy or capacity.
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// checkTimeout bounds each check, so a hanging subsystem cannot hang the
+// report.
+const checkTimeout = 5 * time.Second
+
+// Check reports the health of a component: it is down when the error is
+// non-nil. Details, such as pool or queue figures, are reported either way.
+type Check func(ctx context.Context) (details map[string]any, err error)
+
+// Report is the health of the service and of each of its components.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Component is the result of the check of one component.
+type Component struct {
+	Status    string         `json:"status"`
+	Critical  bool           `json:"critical"`
+	LatencyMS float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Registry holds the checks of the components. Subsystems register their
+// check once they are set up; the components named critical make the
+// service down when they are, the others only degrade it.
+type Registry struct {
+	critical []string
+
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+// NewRegistry returns an empty Registry in which the components named in
+// critical are critical.
+func NewRegistry(critical []string) *Registry {
+	return &Registry{critical: critical, checks: make(map[string]Check)}
+}
+
+// Register adds the check of component name, replacing any earlier one.
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Check runs every registered check concurrently and reports their results.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]Check, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	report := Report{Status: StatusOK, Components: make(map[string]Component, len(checks))}
+	for name, check := range checks {
+		wg.Go(func() {
+			c := r.run(ctx, name, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = c
+		})
+	}
+	wg.Wait()
+
+	for _, c := range report.Components {
+		switch {
+		case c.Status == StatusOK:
+		case c.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, name string, check Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	start := time.Now()
+	details, err := check(ctx)
+	c := Component{
+		Status:    StatusOK,
+		Critical:  slices.Contains(r.critical, name),
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		c.Status = StatusDown
+		c.Error = err.Error()
+	}
+	return c
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	up := func(context.Context) (map[string]any, error) { return map[string]any{"keys": 2}, nil }
+	down := func(context.Context) (map[string]any, error) { return nil, errors.New("unreachable") }
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus string
+	}{
+		{name: "no checks", wantStatus: StatusOK},
+		{name: "all up", checks: map[string]Check{"db": up, "jwks": up}, wantStatus: StatusOK},
+		{name: "non-critical down", checks: map[string]Check{"db": up, "jwks": down}, wantStatus: StatusDegraded},
+		{name: "critical down", checks: map[string]Check{"db": down, "jwks": down}, wantStatus: StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry([]string{"db"})
+			for name, check := range tt.checks {
+				registry.Register(name, check)
+			}
+
+			report := registry.Check(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, report.Status)
+			}
+			if len(report.Components) != len(tt.checks) {
+				t.Fatalf("expected %d components, got %+v", len(tt.checks), report.Components)
+			}
+			if db, ok := report.Components["db"]; ok && !db.Critical {
+				t.Errorf("expected db to be critical: %+v", db)
+			}
+			if jwks, ok := report.Components["jwks"]; ok {
+				if jwks.Critical || (jwks.Status == StatusDown) != (jwks.Error == "unreachable") {
+					t.Errorf("unexpected jwks component: %+v", jwks)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_CheckTimesOut(t *testing.T) {
+	registry := NewRegistry(nil)
+	registry.Register("slow", func(ctx context.Context) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if c := registry.Check(ctx).Components["slow"]; c.Status != StatusDown || c.Error == "" {
+		t.Errorf("expected the slow check to be down, got %+v", c)
+	}
+}
@@ -0,0 +1,281 @@
+// Package jobs runs background work: favourites exports, so exports of any
+// size are not bound by the HTTP write timeout, the purge of expired rows,
+// the relay of outbox events and the snapshots of in-memory favourites.
+// Export jobs live in memory on the instance that accepted them and their
+// files in a local directory.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+var (
+	ErrFull     = errors.New("export queue is full")
+	ErrNotFound = errors.New("export job not found")
+	ErrNotReady = errors.New("export is not ready")
+)
+
+// Status is the lifecycle state of an export job.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job describes an export. Finished jobs, and their files, are kept for the
+// exporter's TTL after completion.
+type Job struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	TenantID    string     `json:"-"`
+	Status      Status     `json:"status"`
+	Count       int        `json:"count"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// active reports whether the job is still waiting or running.
+func (j *Job) active() bool {
+	return j.Status == StatusPending || j.Status == StatusRunning
+}
+
+// ExportFunc writes userID's export, confined to tenantID when set, to w and
+// returns how many favourites it wrote.
+type ExportFunc func(ctx context.Context, userID, tenantID string, w io.Writer) (int, error)
+
+// Exporter queues export jobs and runs them on a pool of workers.
+type Exporter struct {
+	dir    string
+	ttl    time.Duration
+	export ExportFunc
+	queue  chan string
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// exportFileName matches the export files and their temporary files, named
+// after the job ID (see path and write).
+var exportFileName = regexp.MustCompile(`^[0-9a-f]{32}\.json(\.[0-9]+\.tmp)?$`)
+
+// NewExporter creates an Exporter writing files to dir, holding at most
+// capacity pending jobs and keeping finished jobs for ttl. Export files left
+// in dir by a previous run are removed, since their jobs did not survive the
+// restart; other files are left alone, in case dir is shared.
+func NewExporter(dir string, capacity int, ttl time.Duration, export ExportFunc) (*Exporter, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating export directory %s: %w", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing export directory %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && exportFileName.MatchString(entry.Name()) {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return &Exporter{
+		dir:    dir,
+		ttl:    ttl,
+		export: export,
+		queue:  make(chan string, capacity),
+		jobs:   make(map[string]*Job),
+	}, nil
+}
+
+// Submit queues an export of userID's favourites in tenantID, the tenant of
+// the request, if any, since the export runs outside it. A user has at most one
+// active export: while one is pending or running, Submit returns it instead of
+// queueing another. It fails with ErrFull when the queue is at capacity.
+func (e *Exporter) Submit(userID, tenantID string, now time.Time) (Job, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, job := range e.jobs {
+		if job.UserID == userID && job.active() {
+			return *job, nil
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	job := &Job{ID: id, UserID: userID, TenantID: tenantID, Status: StatusPending, CreatedAt: now.UTC()}
+	select {
+	case e.queue <- id:
+	default:
+		return Job{}, ErrFull
+	}
+	e.jobs[id] = job
+	return *job, nil
+}
+
+// Get returns userID's job id. Other users' jobs are reported as ErrNotFound.
+func (e *Exporter) Get(userID, id string) (Job, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	job, ok := e.jobs[id]
+	if !ok || job.UserID != userID {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// Open returns the file of userID's job id, which the caller must close. It
+// fails with ErrNotReady until the job has succeeded.
+func (e *Exporter) Open(userID, id string) (*os.File, Job, error) {
+	job, err := e.Get(userID, id)
+	if err != nil {
+		return nil, Job{}, err
+	}
+	if job.Status != StatusSucceeded {
+		return nil, job, ErrNotReady
+	}
+	f, err := os.Open(e.path(id))
+	if err != nil {
+		return nil, job, fmt.Errorf("opening export %s: %w", id, err)
+	}
+	return f, job, nil
+}
+
+// Run processes queued jobs on workers goroutines and expires finished jobs
+// until ctx is cancelled.
+func (e *Exporter) Run(ctx context.Context, workers int, logger *slog.Logger) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-e.queue:
+					e.process(ctx, id, logger)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(min(e.ttl, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case now := <-ticker.C:
+			if expired := e.expire(now); expired > 0 {
+				logging.With(logger).Layer("jobs").Op("expireExports").Int("expired", expired).
+					Info("removed expired exports")
+			}
+		}
+	}
+}
+
+// process runs job id, writing to a temporary file that is renamed into
+// place only once the export is complete.
+func (e *Exporter) process(ctx context.Context, id string, logger *slog.Logger) {
+	started, ok := e.start(id)
+	if !ok {
+		return
+	}
+	log := logging.With(logger).Layer("jobs").Op("export").User(started.UserID).Str("job_id", id)
+
+	count, err := e.write(ctx, id, started.UserID, started.TenantID)
+	now := time.Now().UTC()
+	expiresAt := now.Add(e.ttl)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	job := e.jobs[id]
+	job.CompletedAt, job.ExpiresAt = &now, &expiresAt
+	if err != nil {
+		job.Status, job.Error = StatusFailed, "export failed"
+		log.Err(err).Error("export failed")
+		return
+	}
+	job.Status, job.Count = StatusSucceeded, count
+	log.Int("count", count).Info("export completed")
+}
+
+func (e *Exporter) start(id string) (Job, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	job, ok := e.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	job.Status = StatusRunning
+	return *job, true
+}
+
+func (e *Exporter) write(ctx context.Context, id, userID, tenantID string) (int, error) {
+	tmp, err := os.CreateTemp(e.dir, id+".json.*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("creating export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	count, err := e.export(ctx, userID, tenantID, tmp)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("writing export file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), e.path(id)); err != nil {
+		return 0, fmt.Errorf("finalising export file: %w", err)
+	}
+	return count, nil
+}
+
+// expire forgets finished jobs whose TTL has passed and removes their files.
+func (e *Exporter) expire(now time.Time) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	expired := 0
+	for id, job := range e.jobs {
+		if job.ExpiresAt != nil && !now.Before(*job.ExpiresAt) {
+			delete(e.jobs, id)
+			os.Remove(e.path(id))
+			expired++
+		}
+	}
+	return expired
+}
+
+func (e *Exporter) path(id string) string {
+	return filepath.Join(e.dir, id+".json")
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func writeExport(content string) ExportFunc {
+	return func(_ context.Context, _, _ string, w io.Writer) (int, error) {
+		_, err := io.WriteString(w, content)
+		return 1, err
+	}
+}
+
+// waitForStatus polls until the job leaves the active states.
+func waitForStatus(t *testing.T, e *Exporter, userID, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := e.Get(userID, id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if !job.active() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s", job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestExporter_RunsJobsAndServesFiles(t *testing.T) {
+	e, err := NewExporter(t.TempDir(), 10, time.Hour, writeExport(`[{"id":"c1"}]`))
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+
+	job, err := e.Submit("user1", "", time.Now())
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if job.Status != StatusPending {
+		t.Errorf("expected pending job, got %s", job.Status)
+	}
+	if _, _, err := e.Open("user1", job.ID); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected ErrNotReady before the job runs, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx, 1, testLogger())
+
+	done := waitForStatus(t, e, "user1", job.ID)
+	if done.Status != StatusSucceeded || done.Count != 1 || done.CompletedAt == nil || done.ExpiresAt == nil {
+		t.Fatalf("unexpected finished job: %+v", done)
+	}
+
+	f, _, err := e.Open("user1", job.ID)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	if string(data) != `[{"id":"c1"}]` {
+		t.Errorf("unexpected export content %q", data)
+	}
+
+	if _, err := e.Get("user2", job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected other users to get ErrNotFound, got %v", err)
+	}
+}
+
+func TestExporter_FailedJob(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewExporter(dir, 10, time.Hour, func(context.Context, string, string, io.Writer) (int, error) {
+		return 0, errors.New("connection failed")
+	})
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	job, _ := e.Submit("user1", "", time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx, 1, testLogger())
+
+	done := waitForStatus(t, e, "user1", job.ID)
+	if done.Status != StatusFailed || done.Error == "" {
+		t.Fatalf("expected failed job with an error, got %+v", done)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no files left behind, got %d", len(entries))
+	}
+}
+
+func TestExporter_PassesTenant(t *testing.T) {
+	tenants := make(chan string, 1)
+	e, err := NewExporter(t.TempDir(), 10, time.Hour, func(_ context.Context, _, tenantID string, w io.Writer) (int, error) {
+		tenants <- tenantID
+		return 0, nil
+	})
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	job, _ := e.Submit("user1", "acme", time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx, 1, testLogger())
+
+	if tenant := <-tenants; tenant != "acme" {
+		t.Errorf("expected the export to run in tenant acme, got %q", tenant)
+	}
+	waitForStatus(t, e, "user1", job.ID)
+}
+
+func TestExporter_Submit(t *testing.T) {
+	e, err := NewExporter(t.TempDir(), 1, time.Hour, writeExport("[]"))
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+
+	first, err := e.Submit("user1", "", time.Now())
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	again, err := e.Submit("user1", "", time.Now())
+	if err != nil || again.ID != first.ID {
+		t.Errorf("expected the active job to be returned, got %+v (err %v)", again, err)
+	}
+	if _, err := e.Submit("user2", "", time.Now()); !errors.Is(err, ErrFull) {
+		t.Errorf("expected ErrFull, got %v", err)
+	}
+}
+
+func TestExporter_ExpiresFinishedJobs(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewExporter(dir, 10, time.Minute, writeExport("[]"))
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	job, _ := e.Submit("user1", "", time.Now())
+	e.process(context.Background(), <-e.queue, testLogger())
+
+	if n := e.expire(time.Now()); n != 0 {
+		t.Errorf("expected nothing to expire yet, got %d", n)
+	}
+	if n := e.expire(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Errorf("expected 1 expired job, got %d", n)
+	}
+	if _, err := e.Get("user1", job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired job to be gone, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected export file to be removed, got %d files", len(entries))
+	}
+}
+
+func TestNewExporter_RemovesOnlyStaleExports(t *testing.T) {
+	dir := t.TempDir()
+	stale := []string{"0123456789abcdef0123456789abcdef.json", "0123456789abcdef0123456789abcdef.json.123456.tmp"}
+	kept := []string{"settings.json", "report.json.bak", "notes.txt"}
+	for _, name := range append(stale, kept...) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := NewExporter(dir, 10, time.Hour, writeExport("[]")); err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+	for _, name := range stale {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("expected stale export %s to be removed, got %v", name, err)
+		}
+	}
+	for _, name := range kept {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be kept, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// PurgeFunc permanently removes the rows that expired before the cut-off,
+// such as favourites deleted then, and returns how many were removed.
+type PurgeFunc func(ctx context.Context, before time.Time) (int64, error)
+
+// RunPurge removes the rows expired more than retention ago, once at start
+// and then every interval, until ctx is cancelled. op names the purge in logs.
+// Each instance runs it; concurrent purges remove each row once.
+func RunPurge(ctx context.Context, op string, interval, retention time.Duration, purge PurgeFunc, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purgeOnce(ctx, op, time.Now(), retention, purge, logger)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeOnce removes the rows expired more than retention before now.
+func purgeOnce(ctx context.Context, op string, now time.Time, retention time.Duration, purge PurgeFunc, logger *slog.Logger) {
+	log := logging.With(logger).Layer("jobs").Op(op)
+	purged, err := purge(ctx, now.Add(-retention))
+	switch {
+	case err != nil && ctx.Err() == nil:
+		log.Err(err).Warn("purge failed")
+	case purged > 0:
+		log.Int("purged", int(purged)).Info("purged expired rows")
+	}
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPurgeOnce(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	var before time.Time
+	purge := func(_ context.Context, cutoff time.Time) (int64, error) {
+		before = cutoff
+		return 2, nil
+	}
+
+	purgeOnce(context.Background(), "purgeDeletedFavourites", now, 30*24*time.Hour, purge, testLogger())
+	if want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC); !before.Equal(want) {
+		t.Errorf("expected cut-off %v, got %v", want, before)
+	}
+}
+
+func TestRunPurge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan struct{}, 10)
+	purge := func(context.Context, time.Time) (int64, error) {
+		calls <- struct{}{}
+		return 0, errors.New("connection refused")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		RunPurge(ctx, "purgeDeletedFavourites", 10*time.Millisecond, time.Hour, purge, testLogger())
+		close(done)
+	}()
+
+	// A failed purge is retried on the next tick
+	for range 2 {
+		select {
+		case <-calls:
+		case <-time.After(2 * time.Second):
+			t.Fatal("purge not run")
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunPurge did not return after cancellation")
+	}
+}
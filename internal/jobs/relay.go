@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// RelayFunc publishes up to limit pending outbox events and returns how many
+// it published.
+type RelayFunc func(ctx context.Context, limit int) (int, error)
+
+// relayBatch is how many outbox events one relay transaction publishes.
+const relayBatch = 100
+
+// RunRelay publishes pending outbox events when wake receives, typically
+// right after a change recorded some, and every interval, which catches
+// events left by other instances or by a failed relay. It returns once ctx
+// is cancelled.
+func RunRelay(ctx context.Context, interval time.Duration, wake <-chan struct{}, relay RelayFunc, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		relayPending(ctx, relay, logger)
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayPending publishes batches of pending events until none is left or a
+// relay fails.
+func relayPending(ctx context.Context, relay RelayFunc, logger *slog.Logger) {
+	for {
+		n, err := relay(ctx, relayBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.With(logger).Layer("jobs").Op("relayOutbox").Err(err).
+					Warn("failed to relay outbox events")
+			}
+			return
+		}
+		if n < relayBatch {
+			return
+		}
+	}
+}
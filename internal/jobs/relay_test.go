@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRelayPending(t *testing.T) {
+	t.Run("relays full batches until drained", func(t *testing.T) {
+		var calls int
+		relay := func(_ context.Context, limit int) (int, error) {
+			calls++
+			if calls < 3 {
+				return limit, nil
+			}
+			return 1, nil
+		}
+		relayPending(context.Background(), relay, testLogger())
+		if calls != 3 {
+			t.Errorf("expected 3 relays, got %d", calls)
+		}
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		var calls int
+		relay := func(context.Context, int) (int, error) {
+			calls++
+			return 0, errors.New("connection refused")
+		}
+		relayPending(context.Background(), relay, testLogger())
+		if calls != 1 {
+			t.Errorf("expected 1 relay, got %d", calls)
+		}
+	})
+}
+
+func TestRunRelay_Wake(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wake := make(chan struct{}, 1)
+	calls := make(chan struct{}, 10)
+	relay := func(context.Context, int) (int, error) {
+		calls <- struct{}{}
+		return 0, nil
+	}
+
+	go RunRelay(ctx, time.Hour, wake, relay, testLogger())
+	for i := range 2 {
+		if i == 1 {
+			wake <- struct{}{}
+		}
+		select {
+		case <-calls:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("relay %d not run", i+1)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// RunSnapshots calls save every interval, saving the in-memory favourites,
+// and returns once ctx is cancelled. A failed save is logged and retried on
+// the next tick; the caller saves once more after shutting down.
+func RunSnapshots(ctx context.Context, interval time.Duration, save func() error, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := save(); err != nil {
+			logging.With(logger).Layer("jobs").Op("saveSnapshot").Err(err).
+				Warn("failed to save the favourites snapshot")
+		}
+	}
+}
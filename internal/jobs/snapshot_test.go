@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	saves := make(chan struct{}, 10)
+	save := func() error {
+		saves <- struct{}{}
+		return errors.New("disk full")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		RunSnapshots(ctx, 10*time.Millisecond, save, testLogger())
+		close(done)
+	}()
+	for i := range 2 {
+		select {
+		case <-saves:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("save %d not run", i+1)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunSnapshots did not return after cancellation")
+	}
+}
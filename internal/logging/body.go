@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// BodyLogger is a middleware that, while debug logging is enabled, logs the
+// request and response bodies of each request, up to maxBytes each, to
+// debug malformed payloads. A body that is a whole JSON document is logged
+// as request_body or response_body with its members redacted as log fields
+// are; any other body is logged as request_body_raw or response_body_raw,
+// which are redacted unless allowed by Redaction.AllowedFields. Bodies are
+// captured as the handler reads and writes them, so nothing is buffered
+// beyond maxBytes.
+func BodyLogger(maxBytes int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if !FromContext(ctx).Enabled(ctx, slog.LevelDebug) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			request := &bodyCapture{max: maxBytes}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, request), r.Body}
+			}
+			response := &bodyCapture{max: maxBytes}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(response)
+
+			next.ServeHTTP(ww, r)
+
+			b := Log(ctx).Layer("http").Str("method", r.Method).Str("path", r.URL.Path).Int("status", ww.Status())
+			request.log(b, "request_body")
+			response.log(b, "response_body")
+			b.Debug("http bodies")
+		})
+	}
+}
+
+// bodyCapture keeps the first max bytes written to it and counts them all.
+type bodyCapture struct {
+	max  int
+	data []byte
+	n    int
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	c.n += len(p)
+	if room := c.max - len(c.data); room > 0 {
+		c.data = append(c.data, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// log adds the captured body to b as field, redacted; see BodyLogger.
+func (c *bodyCapture) log(b *LogBuilder, field string) {
+	b.Int(field+"_bytes", c.n)
+	if c.n == 0 {
+		return
+	}
+	if c.n <= c.max {
+		if v, ok := redactJSON(c.data); ok {
+			b.Any(field, v)
+			return
+		}
+	}
+	b.Str(field+"_raw", string(c.data))
+}
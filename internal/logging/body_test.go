@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    slog.Level
+		body     string
+		maxBytes int
+		want     map[string]any
+	}{
+		{
+			name:     "JSON bodies redacted",
+			level:    slog.LevelDebug,
+			body:     `{"asset_type":"chart","description":"my notes"}`,
+			maxBytes: 1024,
+			want: map[string]any{
+				"request_body":        map[string]any{"asset_type": "chart", "description": Redacted},
+				"response_body":       map[string]any{"asset_id": "c1", "count": float64(2)},
+				"request_body_bytes":  float64(47),
+				"response_body_bytes": float64(29),
+			},
+		},
+		{
+			name:     "truncated body raw and redacted",
+			level:    slog.LevelDebug,
+			body:     `{"asset_type":"chart","description":"my notes"}`,
+			maxBytes: 10,
+			want:     map[string]any{"request_body_raw": Redacted, "response_body_raw": Redacted, "request_body_bytes": float64(47)},
+		},
+		{
+			name:     "not logged above debug",
+			level:    slog.LevelInfo,
+			body:     `{"asset_type":"chart"}`,
+			maxBytes: 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(redactingHandler{next: slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: tt.level})})
+			var read string
+			handler := BodyLogger(tt.maxBytes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				read = string(b)
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"asset_id":"c1","count":2}` + "\n\n"))
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/favourites", strings.NewReader(tt.body))
+			req = req.WithContext(NewContextWithLogger(req.Context(), logger))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if read != tt.body || rec.Code != http.StatusCreated {
+				t.Fatalf("expected the handler to read the whole body and answer 201, got %q and %d", read, rec.Code)
+			}
+			if tt.want == nil {
+				if buf.Len() != 0 {
+					t.Errorf("expected no entry, got %s", buf.String())
+				}
+				return
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to decode entry %q: %v", buf.String(), err)
+			}
+			if entry["status"] != float64(http.StatusCreated) {
+				t.Errorf("expected status 201 to be logged, got %v", entry["status"])
+			}
+			for key, want := range tt.want {
+				got, _ := json.Marshal(entry[key])
+				if wantJSON, _ := json.Marshal(want); !bytes.Equal(got, wantJSON) {
+					t.Errorf("expected %s %s, got %s", key, wantJSON, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// level is the minimum level of the loggers made by NewLoggerTo. It is shared
+// by all of them, so SetLevel takes effect at once without a restart.
+var level = new(slog.LevelVar)
+
+// SetLevel sets the minimum level of the entries logged.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Level returns the minimum level of the entries logged.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// ParseLevel parses a level name: "debug", "info", "warn" or "error",
+// in any case, optionally with an offset such as "debug-4".
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(strings.TrimSpace(s)))
+	return l, err
+}
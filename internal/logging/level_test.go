@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetLevel_TakesEffectOnExistingLoggers(t *testing.T) {
+	t.Cleanup(func() { SetLevel(slog.LevelInfo) })
+	var buf bytes.Buffer
+	logger := NewLoggerTo(&buf)
+
+	logger.Debug("hidden")
+	SetLevel(slog.LevelDebug)
+	logger.Debug("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("expected only the entry after SetLevel, got %s", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, " warn ": slog.LevelWarn, "error": slog.LevelError} {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -17,21 +18,47 @@ const logAttributesNumber = 8 // Preallocate for common attributes. Go will real
 // NewLogger creates a new JSON logger configured for production use.
 // It sets the logger as the default slog logger and returns it.
 func NewLogger() *slog.Logger {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	return NewLoggerTo(os.Stdout)
+}
+
+// NewLoggerTo is NewLogger writing to w, for commands whose stdout carries data.
+// Its entries are redacted as set by SetRedaction and filtered by the level
+// set by SetLevel (info by default).
+func NewLoggerTo(w io.Writer) *slog.Logger {
+	logger := slog.New(redactingHandler{next: slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+	})})
 	slog.SetDefault(logger)
 	return logger
 }
 
 // RequestLogger is a middleware that creates a request-scoped logger with the request ID
 // and stores it in the context for use by all downstream handlers and layers.
+// The request ID is the caller's, from its X-Request-ID or X-Correlation-ID
+// header, when valid, and random otherwise; it is also stored for
+// middleware.GetReqID and echoed in the response headers.
+// The logger also carries the trace ID of the request, taken from its
+// traceparent header when valid and random otherwise, so the entries of a
+// request, its database queries included, can be joined with the caller's trace.
 func RequestLogger(logger *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			requestID := middleware.GetReqID(r.Context())
-			log := logger.With(slog.String("request_id", requestID))
-			ctx := context.WithValue(r.Context(), loggerKey, log)
+			requestID, header := requestIDFromHeaders(r.Header)
+			if requestID == "" {
+				requestID = randomHex(8)
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+			if header == CorrelationIDHeader {
+				w.Header().Set(CorrelationIDHeader, requestID)
+			}
+			traceID := traceIDFromHeader(r.Header.Get(TraceParentHeader))
+			if traceID == "" {
+				traceID = randomHex(16)
+			}
+			log := logger.With(slog.String("request_id", requestID), slog.String("trace_id", traceID))
+			ctx := context.WithValue(r.Context(), middleware.RequestIDKey, requestID)
+			ctx = context.WithValue(ctx, loggerKey, log)
+			ctx = context.WithValue(ctx, traceIDKey, traceID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
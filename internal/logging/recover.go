@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// panicsRecovered counts the panics recovered by Recoverer.
+var panicsRecovered = expvar.NewInt("http_panics")
+
+// PanicReport describes a panic recovered while serving a request.
+type PanicReport struct {
+	Panic     string       `json:"panic"`
+	Stack     []StackFrame `json:"stack"`
+	Method    string       `json:"method"`
+	Path      string       `json:"path"`
+	RequestID string       `json:"request_id"`
+	Time      time.Time    `json:"time"`
+}
+
+// StackFrame is a call of a stack trace, innermost first.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// PanicAlert is told about each panic Recoverer recovers, e.g. to report it
+// to an error tracker. It runs in its own goroutine, so it may block.
+type PanicAlert func(ctx context.Context, report PanicReport)
+
+// Recoverer is a middleware that recovers from panics in the handlers it
+// wraps. It logs the panic with its stack trace as structured fields, counts
+// it in the http_panics expvar, answers 500 with a problem details body and,
+// when alert is non-nil, passes the report to alert. http.ErrAbortHandler is
+// let through, as net/http uses it to abort responses silently.
+func Recoverer(alert PanicAlert) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				ctx := r.Context()
+				report := PanicReport{
+					Panic:     fmt.Sprint(rec),
+					Stack:     panicStack(),
+					Method:    r.Method,
+					Path:      r.URL.Path,
+					RequestID: middleware.GetReqID(ctx),
+					Time:      time.Now().UTC(),
+				}
+				panicsRecovered.Add(1)
+				Log(ctx).Layer("http").Str("method", r.Method).Str("path", r.URL.Path).
+					Str("panic", report.Panic).Any("stack", report.Stack).Error("panic recovered")
+				if alert != nil {
+					go alert(context.WithoutCancel(ctx), report)
+				}
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					writePanicProblem(w, r)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// panicStack returns the stack of the panicking goroutine, from the call that
+// panicked outwards, when called by a deferred function recovering it.
+func panicStack() []StackFrame {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	var stack []StackFrame
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			stack = stack[:0] // drop the frames of the recovery itself
+		} else {
+			stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// writePanicProblem answers r with a 500 problem details body (RFC 9457).
+func writePanicProblem(w http.ResponseWriter, r *http.Request) {
+	body, _ := json.Marshal(map[string]any{
+		"type":     "about:blank",
+		"title":    http.StatusText(http.StatusInternalServerError),
+		"status":   http.StatusInternalServerError,
+		"detail":   "an unexpected error occurred",
+		"instance": r.URL.Path,
+	})
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(body)
+}
+
+// PanicAlerts returns a PanicAlert passing each report to alerts in turn,
+// or nil when there are none.
+func PanicAlerts(alerts ...PanicAlert) PanicAlert {
+	switch len(alerts) {
+	case 0:
+		return nil
+	case 1:
+		return alerts[0]
+	}
+	return func(ctx context.Context, report PanicReport) {
+		for _, alert := range alerts {
+			alert(ctx, report)
+		}
+	}
+}
+
+// WebhookPanicAlert returns a PanicAlert posting each report as JSON to url,
+// e.g. a chat or incident webhook. Failures are logged and not retried.
+func WebhookPanicAlert(url string, client *http.Client) PanicAlert {
+	return func(ctx context.Context, report PanicReport) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		body, _ := json.Marshal(report)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			var resp *http.Response
+			if resp, err = client.Do(req); err == nil {
+				resp.Body.Close()
+				if resp.StatusCode >= http.StatusBadRequest {
+					err = fmt.Errorf("webhook answered %s", resp.Status)
+				}
+			}
+		}
+		if err != nil {
+			Log(ctx).Layer("http").Err(err).Error("failed to send panic alert")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecoverer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	alerts := make(chan PanicReport, 1)
+	before := panicsRecovered.Value()
+
+	handler := RequestLogger(logger)(Recoverer(func(_ context.Context, report PanicReport) {
+		alerts <- report
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/favourites", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected a 500 problem, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var problem map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || problem["status"] != float64(500) || problem["instance"] != "/favourites" {
+		t.Errorf("unexpected problem %s: %v", rec.Body.String(), err)
+	}
+	if got := panicsRecovered.Value() - before; got != 1 {
+		t.Errorf("expected the panic to be counted once, got %d", got)
+	}
+
+	var entry struct {
+		Msg       string       `json:"msg"`
+		Panic     string       `json:"panic"`
+		RequestID string       `json:"request_id"`
+		Stack     []StackFrame `json:"stack"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode entry %q: %v", buf.String(), err)
+	}
+	if entry.Msg != "panic recovered" || entry.Panic != "boom" || entry.RequestID != "req-1" {
+		t.Errorf("unexpected entry %s", buf.String())
+	}
+	if len(entry.Stack) == 0 || !strings.Contains(entry.Stack[0].Function, "TestRecoverer") {
+		t.Errorf("expected the stack to start at the panicking handler, got %+v", entry.Stack)
+	}
+
+	select {
+	case report := <-alerts:
+		if report.Panic != "boom" || report.RequestID != "req-1" || report.Path != "/favourites" {
+			t.Errorf("unexpected alert %+v", report)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the alert hook to be called")
+	}
+}
+
+func TestWebhookPanicAlert(t *testing.T) {
+	received := make(chan PanicReport, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var report PanicReport
+		if err := json.Unmarshal(body, &report); err != nil {
+			t.Errorf("failed to decode report %q: %v", body, err)
+		}
+		received <- report
+	}))
+	defer server.Close()
+
+	WebhookPanicAlert(server.URL, server.Client())(context.Background(), PanicReport{Panic: "boom", RequestID: "req-1"})
+
+	if report := <-received; report.Panic != "boom" || report.RequestID != "req-1" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Redacted replaces the value of fields that are not allowed in logs.
+const Redacted = "[REDACTED]"
+
+// DefaultAllowedFields are the fields logged verbatim: identifiers, counts
+// and other operational details that never carry request payloads. Fields
+// such as asset_data or description are redacted unless added by
+// Redaction.AllowedFields.
+var DefaultAllowedFields = []string{
+	// Request and call site
+	"request_id", "trace_id", "span_id", "duration_ms", "threshold_ms", "event_code", "code", "client_ip", "layer", "operation", ErrorKey, "db_error", "method", "path", "status", "status_code", "command",
+	"panic", "stack",
+	// Identities, hashed when Redaction.HashUserIDs is set
+	"user_id", "actor", "target_user", "source_user", "tenant", "client", "credential", "key_id", "jti",
+	// Assets
+	"asset_id", "asset_type", "action", "version", "reverted_to", "expires_at", "revoked_until", "job_id",
+	"queued_at", "timezone",
+	// Counts and sizes
+	"count", "total", "limit", "window", "size", "capacity", "depth", "queue_depth", "error_count", "failures",
+	"favourites", "versions", "asset_count", "asset_data_bytes", "deleted",
+	"preferences", "audit_entries", "assets", "catalog_assets", "types", "updated", "removed", "merged",
+	"flushed", "expired", "remove", "purged",
+	// Startup and maintenance
+	"api_addr", "health_addr", "port", "tls", "provider", "ref", "url", "from", "to", "input", "output", "migration",
+	"replica", "healthy", "log_level", "previous_log_level", "environment", "release",
+	"commit", "build_date", "go_version",
+	// Bodies logged by BodyLogger, whose members are redacted in turn
+	"request_body", "response_body", "request_body_bytes", "response_body_bytes",
+	"OS signal received",
+}
+
+// userIDFields hold user IDs, which HashUserIDs replaces with a pseudonym.
+var userIDFields = map[string]bool{"user_id": true, "actor": true, "target_user": true, "source_user": true}
+
+// Redaction decides which log fields are written as they are. Fields neither
+// in DefaultAllowedFields nor in AllowedFields have their value replaced by
+// Redacted, so payloads that may hold personal data stay out of the logs.
+// With HashUserIDs, user IDs are replaced by a keyed hash, which still lets
+// the entries of a user be correlated.
+type Redaction struct {
+	AllowedFields []string
+	HashUserIDs   bool
+	HashKey       string
+}
+
+type redactor struct {
+	allowed     map[string]bool
+	hashUserIDs bool
+	hashKey     []byte
+}
+
+// activeRedaction is applied by the loggers of NewLogger; until SetRedaction
+// is called, the default fields are allowed and user IDs kept.
+var activeRedaction atomic.Pointer[redactor]
+
+func init() {
+	SetRedaction(Redaction{})
+}
+
+// SetRedaction sets the redaction applied by loggers made with NewLogger. It
+// is meant to be called once at startup.
+func SetRedaction(r Redaction) {
+	allowed := make(map[string]bool, len(DefaultAllowedFields)+len(r.AllowedFields))
+	for _, field := range DefaultAllowedFields {
+		allowed[field] = true
+	}
+	for _, field := range r.AllowedFields {
+		allowed[field] = true
+	}
+	activeRedaction.Store(&redactor{allowed: allowed, hashUserIDs: r.HashUserIDs, hashKey: []byte(r.HashKey)})
+}
+
+// HashUserID returns the pseudonym logged for userID when user IDs are hashed:
+// the first 16 hex digits of its HMAC-SHA256 under key.
+func HashUserID(key, userID string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(userID))
+	return "usr_" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func (r *redactor) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if !r.allowed[a.Key] {
+		return slog.String(a.Key, Redacted)
+	}
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]any, len(attrs))
+		for i, member := range attrs {
+			redacted[i] = r.attr(member)
+		}
+		return slog.Group(a.Key, redacted...)
+	case r.hashUserIDs && userIDFields[a.Key] && a.Value.String() != "":
+		return slog.String(a.Key, HashUserID(string(r.hashKey), a.Value.String()))
+	}
+	return a
+}
+
+// redactJSON returns the JSON document data with the members of its objects
+// redacted by the active Redaction as log fields of the same names are;
+// arrays are redacted element by element. ok is false when data is not a
+// single valid JSON document.
+func redactJSON(data []byte) (v any, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+	return activeRedaction.Load().json(v), true
+}
+
+func (r *redactor) json(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, member := range v {
+			switch s, isString := member.(string); {
+			case !r.allowed[key]:
+				v[key] = Redacted
+			case r.hashUserIDs && userIDFields[key] && isString && s != "":
+				v[key] = HashUserID(string(r.hashKey), s)
+			default:
+				v[key] = r.json(member)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = r.json(v[i])
+		}
+	}
+	return v
+}
+
+// redactingHandler applies the active Redaction to every record before
+// passing it to the next handler.
+type redactingHandler struct {
+	next slog.Handler
+}
+
+func (h redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	r := activeRedaction.Load()
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(r.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	r := activeRedaction.Load()
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = r.attr(a)
+	}
+	return redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h redactingHandler) WithGroup(name string) slog.Handler {
+	return redactingHandler{next: h.next.WithGroup(name)}
+}
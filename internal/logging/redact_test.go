@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		redaction Redaction
+		want      map[string]any
+	}{
+		{
+			name:      "payload fields redacted by default",
+			redaction: Redaction{},
+			want:      map[string]any{"request_id": "req1", "user_id": "user1", "asset_data": Redacted, "description": Redacted, "count": float64(2)},
+		},
+		{
+			name:      "allowed fields kept",
+			redaction: Redaction{AllowedFields: []string{"description"}},
+			want:      map[string]any{"request_id": "req1", "user_id": "user1", "asset_data": Redacted, "description": "my notes", "count": float64(2)},
+		},
+		{
+			name:      "user IDs hashed",
+			redaction: Redaction{HashUserIDs: true, HashKey: "k"},
+			want:      map[string]any{"request_id": "req1", "user_id": HashUserID("k", "user1"), "asset_data": Redacted, "description": Redacted, "count": float64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRedaction(tt.redaction)
+			t.Cleanup(func() { SetRedaction(Redaction{}) })
+
+			var buf bytes.Buffer
+			logger := slog.New(redactingHandler{next: slog.NewJSONHandler(&buf, nil)}).With(slog.String("request_id", "req1"))
+			Log(NewContextWithLogger(context.Background(), logger)).User("user1").
+				Str("asset_data", `{"email":"a@example.com"}`).Str("description", "my notes").Int("count", 2).
+				Info("received request")
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %v, want %v", key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHashUserID(t *testing.T) {
+	a, b := HashUserID("k", "user1"), HashUserID("k", "user2")
+	if a == b || a != HashUserID("k", "user1") || a == HashUserID("other", "user1") {
+		t.Errorf("hashes not stable per key and user: %s %s", a, b)
+	}
+	if len(a) != len("usr_")+16 {
+		t.Errorf("unexpected hash %q", a)
+	}
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"net/http"
+)
+
+// Headers carrying the caller's ID of a request, adopted as its request ID
+// so the entries of every service it went through can be joined. Responses
+// echo the request ID in RequestIDHeader, and in CorrelationIDHeader when the
+// caller sent that one.
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// maxRequestIDLength caps the request IDs adopted from callers.
+const maxRequestIDLength = 128
+
+// requestIDFromHeaders returns the caller's request ID, from RequestIDHeader
+// or else CorrelationIDHeader, and the header it came from. IDs that are too
+// long or hold characters other than letters, digits and "-_.:/" are ignored,
+// so callers cannot forge log lines or headers with them.
+func requestIDFromHeaders(h http.Header) (id, header string) {
+	for _, header := range []string{RequestIDHeader, CorrelationIDHeader} {
+		if id := h.Get(header); validRequestID(id) {
+			return id, header
+		}
+	}
+	return "", ""
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':' || c == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestLogger_RequestID(t *testing.T) {
+	tests := []struct {
+		name              string
+		headers           map[string]string
+		want              string
+		wantCorrelationID bool
+	}{
+		{name: "generated"},
+		{name: "from X-Request-ID", headers: map[string]string{"X-Request-ID": "abc-123"}, want: "abc-123"},
+		{name: "from X-Correlation-ID", headers: map[string]string{"X-Correlation-ID": "corr.42"}, want: "corr.42", wantCorrelationID: true},
+		{name: "X-Request-ID preferred", headers: map[string]string{"X-Request-ID": "abc-123", "X-Correlation-ID": "corr.42"}, want: "abc-123"},
+		{name: "invalid ignored", headers: map[string]string{"X-Request-ID": "evil\"id"}},
+		{name: "too long ignored", headers: map[string]string{"X-Request-ID": strings.Repeat("a", maxRequestIDLength+1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var fromChi string
+			handler := RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromChi = middleware.GetReqID(r.Context())
+				FromContext(r.Context()).Info("handled")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("expected request ID %q, got %q", tt.want, got)
+			}
+			if tt.want == "" && (got == "" || got == tt.headers["X-Request-ID"]) {
+				t.Errorf("expected a generated request ID, got %q", got)
+			}
+			if fromChi != got || !strings.Contains(buf.String(), `"request_id":"`+got+`"`) {
+				t.Errorf("expected request ID %q in the context and logs, got %q: %s", got, fromChi, buf.String())
+			}
+			if echoed := rec.Header().Get(CorrelationIDHeader); (echoed == got) != tt.wantCorrelationID {
+				t.Errorf("unexpected %s header %q", CorrelationIDHeader, echoed)
+			}
+		})
+	}
+}
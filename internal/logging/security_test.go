@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecurity(t *testing.T) {
+	var buf bytes.Buffer
+	SetSecurityLog(&buf)
+	t.Cleanup(func() { SetSecurityLog(nil) })
+
+	req := httptest.NewRequest("GET", "/api/v1/favourites?limit=5", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	Security(req, EventAuthFailure).Str("code", "token_expired").Str("description", "secret").Info("token is expired")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid security log entry %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg": "token is expired", "event_code": EventAuthFailure, "code": "token_expired",
+		"client_ip": "203.0.113.7", "method": "GET", "path": "/api/v1/favourites", "description": Redacted,
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+
+	SetSecurityLog(nil)
+	buf.Reset()
+	Security(req, EventAuthFailure).Info("discarded")
+	if buf.Len() != 0 {
+		t.Errorf("expected no entry once disabled, got %s", buf.String())
+	}
+}
+
+func TestOpenSecurityLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "security.log")
+	closeLog, err := OpenSecurityLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { SetSecurityLog(nil) })
+
+	Security(httptest.NewRequest("GET", "/", nil), EventRateLimited).Info("rate limit exceeded")
+	if err := closeLog(); err != nil {
+		t.Fatalf("closing: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"event_code":"RATE_LIMITED"`) {
+		t.Errorf("unexpected security log: %s", data)
+	}
+
+	if _, err := OpenSecurityLog(filepath.Join(t.TempDir(), "missing", "security.log")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
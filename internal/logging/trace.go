@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const traceIDKey string = "trace_id"
+
+// TraceParentHeader carries the W3C trace context of a request:
+// "00-<32 hex trace ID>-<16 hex parent span ID>-<2 hex flags>".
+const TraceParentHeader = "traceparent"
+
+// traceIDFromHeader returns the trace ID of a traceparent header value, or ""
+// when it is missing or malformed.
+func traceIDFromHeader(v string) string {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if _, err := hex.DecodeString(traceID); err != nil || traceID == strings.Repeat("0", 32) {
+		return ""
+	}
+	return traceID
+}
+
+// randomHex returns n random bytes, hex-encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewSpanID returns a random span ID in the traceparent format.
+func NewSpanID() string {
+	return randomHex(8)
+}
+
+// TraceID returns the trace ID of the request ctx belongs to, set by
+// RequestLogger, or "" outside of a request.
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey).(string)
+	return traceID
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLogger_TraceID(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name        string
+		traceparent string
+		want        string // empty: a random trace ID
+	}{
+		{name: "from traceparent", traceparent: "00-" + traceID + "-00f067aa0ba902b7-01", want: traceID},
+		{name: "upper case traceparent", traceparent: "00-" + strings.ToUpper(traceID) + "-00f067aa0ba902b7-01", want: traceID},
+		{name: "no traceparent"},
+		{name: "malformed traceparent", traceparent: "00-xyz-00f067aa0ba902b7-01"},
+		{name: "all-zero trace ID", traceparent: "00-" + strings.Repeat("0", 32) + "-00f067aa0ba902b7-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			var got string
+			handler := RequestLogger(slog.New(slog.NewJSONHandler(buf, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = TraceID(r.Context())
+				Log(r.Context()).Info("handled")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.traceparent != "" {
+				req.Header.Set(TraceParentHeader, tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.want != "" && got != tt.want {
+				t.Errorf("expected trace ID %q, got %q", tt.want, got)
+			}
+			if len(got) != 32 || got == strings.Repeat("0", 32) {
+				t.Errorf("expected a 32 hex digit trace ID, got %q", got)
+			}
+			if !strings.Contains(buf.String(), `"trace_id":"`+got+`"`) {
+				t.Errorf("expected log output to carry the trace ID, got: %s", buf.String())
+			}
+		})
+	}
+}
@@ -2,52 +2,113 @@
 
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
 
 type AssetType string
 
 const (
-	AssetTypeChart    AssetType = "chart"
-	AssetTypeInsight  AssetType = "insight"
-	AssetTypeAudience AssetType = "audience"
+	AssetTypeChart     AssetType = "chart"
+	AssetTypeInsight   AssetType = "insight"
+	AssetTypeAudience  AssetType = "audience"
+	AssetTypeDashboard AssetType = "dashboard"
+	AssetTypeGeneric   AssetType = "generic"
 )
 
+// Asset is the payload of a favourite.
 type Asset interface {
 	GetID() string
 	GetType() AssetType
+	// Validate checks the payload against the rules and limits of its type
+	// in v, reporting field errors in one *assets.ValidationError.
+	Validate(v Validator) error
+}
+
+// Validator checks payloads against the rules and limits of their type.
+// The rules, including the configured limits, JSON Schema rules and custom
+// validators, live in internal/assets, whose Validator implements it; this
+// package only declares what Asset.Validate needs.
+type Validator interface {
+	ValidateAsset(a Asset) error
 }
 
 type FavouriteAsset struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	AssetType   AssetType `json:"asset_type"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Data        Asset     `json:"data"`
+	ID              string    `json:"id"`
+	UserID          string    `json:"user_id"`
+	AssetType       AssetType `json:"asset_type"`
+	Description     string    `json:"description"`
+	DescriptionHTML string    `json:"description_html,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Data            Asset     `json:"data"`
+	Provenance
+}
+
+// Provenance records where a favourite was created. All fields are optional
+// and are set once, when the favourite is added.
+type Provenance struct {
+	SourceSystem   string `json:"source_system,omitempty"`
+	SourceURL      string `json:"source_url,omitempty"`
+	FavouritedFrom string `json:"favourited_from,omitempty"`
 }
 
 func (f *FavouriteAsset) GetID() string      { return f.ID }
 func (f *FavouriteAsset) GetType() AssetType { return f.AssetType }
 
+// Validate validates the favourite's asset data with v.
+func (f *FavouriteAsset) Validate(v Validator) error {
+	if f.Data == nil {
+		return errors.New("favourite has no asset data")
+	}
+	return f.Data.Validate(v)
+}
+
 type Chart struct {
-	ID         string         `json:"id"`
-	Title      string         `json:"title"`
-	XAxisTitle string         `json:"x_axis_title"`
-	YAxisTitle string         `json:"y_axis_title"`
-	Data       map[string]any `json:"data"`
+	ID           string         `json:"id"`
+	Title        string         `json:"title"`
+	ChartType    string         `json:"chart_type,omitempty"`
+	XAxisTitle   string         `json:"x_axis_title"`
+	YAxisTitle   string         `json:"y_axis_title"`
+	XAxisUnit    string         `json:"x_axis_unit,omitempty"`
+	YAxisUnit    string         `json:"y_axis_unit,omitempty"`
+	XAxisFormat  string         `json:"x_axis_format,omitempty"`
+	YAxisFormat  string         `json:"y_axis_format,omitempty"`
+	Data         map[string]any `json:"data"`
+	Series       []Series       `json:"series,omitempty"`
+	ThumbnailURL string         `json:"thumbnail_url,omitempty"`
+}
+
+// Series is one named line, bar group or point cloud of a chart.
+type Series struct {
+	Name   string  `json:"name"`
+	Points []Point `json:"points"`
 }
 
-func (c *Chart) GetID() string      { return c.ID }
-func (c *Chart) GetType() AssetType { return AssetTypeChart }
+// Point is a data point of a series. X is a category (string) or a number.
+type Point struct {
+	X any      `json:"x"`
+	Y *float64 `json:"y"`
+}
+
+func (c *Chart) GetID() string              { return c.ID }
+func (c *Chart) GetType() AssetType         { return AssetTypeChart }
+func (c *Chart) Validate(v Validator) error { return v.ValidateAsset(c) }
 
 type Insight struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
+	ID           string   `json:"id"`
+	Text         string   `json:"text"`
+	SourceURL    string   `json:"source_url,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Confidence   *float64 `json:"confidence,omitempty"`
+	ThumbnailURL string   `json:"thumbnail_url,omitempty"`
 }
 
-func (i *Insight) GetID() string      { return i.ID }
-func (i *Insight) GetType() AssetType { return AssetTypeInsight }
+func (i *Insight) GetID() string              { return i.ID }
+func (i *Insight) GetType() AssetType         { return AssetTypeInsight }
+func (i *Insight) Validate(v Validator) error { return v.ValidateAsset(i) }
 
 type Audience struct {
 	ID                    string   `json:"id"`
@@ -56,7 +117,54 @@ type Audience struct {
 	AgeGroups             []string `json:"age_groups"`
 	SocialMediaHoursDaily string   `json:"social_media_hours_daily"`
 	PurchasesLastMonth    int      `json:"purchases_last_month"`
+	ThumbnailURL          string   `json:"thumbnail_url,omitempty"`
 }
 
-func (a *Audience) GetID() string      { return a.ID }
-func (a *Audience) GetType() AssetType { return AssetTypeAudience }
+func (a *Audience) GetID() string              { return a.ID }
+func (a *Audience) GetType() AssetType         { return AssetTypeAudience }
+func (a *Audience) Validate(v Validator) error { return v.ValidateAsset(a) }
+
+// Dashboard is a whole dashboard: a grid of widgets, each showing another
+// asset. Widgets reference their assets rather than embedding them.
+type Dashboard struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Widgets []DashboardWidget `json:"widgets"`
+	Layout  DashboardLayout   `json:"layout"`
+}
+
+func (d *Dashboard) GetID() string              { return d.ID }
+func (d *Dashboard) GetType() AssetType         { return AssetTypeDashboard }
+func (d *Dashboard) Validate(v Validator) error { return v.ValidateAsset(d) }
+
+// Generic is an asset of a kind the service does not model: a title and an
+// opaque JSON payload that is stored as given.
+type Generic struct {
+	ID      string          `json:"id"`
+	Title   string          `json:"title"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (g *Generic) GetID() string              { return g.ID }
+func (g *Generic) GetType() AssetType         { return AssetTypeGeneric }
+func (g *Generic) Validate(v Validator) error { return v.ValidateAsset(g) }
+
+// DashboardWidget places a chart, insight or audience on a dashboard.
+type DashboardWidget struct {
+	AssetType AssetType      `json:"asset_type"`
+	AssetID   string         `json:"asset_id"`
+	Position  WidgetPosition `json:"position"`
+}
+
+// WidgetPosition is a widget's cell range on the dashboard grid, in grid units.
+type WidgetPosition struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// DashboardLayout holds the grid metadata of a dashboard.
+type DashboardLayout struct {
+	Columns int `json:"columns"`
+}
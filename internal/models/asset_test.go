@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// recordingValidator records the assets it validates and fails them with err.
+type recordingValidator struct {
+	validated []Asset
+	err       error
+}
+
+func (v *recordingValidator) ValidateAsset(a Asset) error {
+	v.validated = append(v.validated, a)
+	return v.err
+}
+
+func TestAssetValidate(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	v := &recordingValidator{err: errInvalid}
+	assets := []Asset{&Chart{ID: "c1"}, &Insight{ID: "i1"}, &Audience{ID: "a1"}, &Dashboard{ID: "d1"}, &Generic{ID: "g1"}}
+	for _, a := range assets {
+		if err := a.Validate(v); !errors.Is(err, errInvalid) {
+			t.Errorf("%s: expected the validator's error, got %v", a.GetType(), err)
+		}
+	}
+	if len(v.validated) != len(assets) {
+		t.Fatalf("expected %d assets validated, got %d", len(assets), len(v.validated))
+	}
+	for i, a := range assets {
+		if v.validated[i] != a {
+			t.Errorf("expected %s to be validated itself, got %v", a.GetType(), v.validated[i])
+		}
+	}
+}
+
+func TestFavouriteAssetValidate(t *testing.T) {
+	chart := &Chart{ID: "c1"}
+	v := &recordingValidator{}
+
+	if err := (&FavouriteAsset{ID: "c1", Data: chart}).Validate(v); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(v.validated) != 1 || v.validated[0] != chart {
+		t.Errorf("expected the asset data to be validated, got %v", v.validated)
+	}
+	if err := (&FavouriteAsset{ID: "c1"}).Validate(v); err == nil {
+		t.Error("expected an error without asset data")
+	}
+}
@@ -0,0 +1,231 @@
+// Package queue provides a bounded, disk-persisted write-behind queue that
+// holds new favourites while the database is unavailable and replays them
+// once it is reachable again.
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+var (
+	ErrFull          = errors.New("write queue is full")
+	ErrAlreadyQueued = errors.New("favourite is already queued")
+)
+
+// Entry is a favourite accepted while the database was unavailable.
+type Entry struct {
+	ID             string          `json:"id"`
+	UserID         string          `json:"user_id"`
+	TenantID       string          `json:"tenant_id,omitempty"`
+	AssetID        string          `json:"asset_id"`
+	AssetType      string          `json:"asset_type"`
+	AssetData      json.RawMessage `json:"asset_data"`
+	Description    string          `json:"description"`
+	SourceSystem   string          `json:"source_system,omitempty"`
+	SourceURL      string          `json:"source_url,omitempty"`
+	FavouritedFrom string          `json:"favourited_from,omitempty"`
+	QueuedAt       time.Time       `json:"queued_at"`
+}
+
+// Stats describes the current state of the queue.
+type Stats struct {
+	Depth          int        `json:"depth"`
+	Capacity       int        `json:"capacity"`
+	OldestQueuedAt *time.Time `json:"oldest_queued_at"`
+}
+
+// ReplayFunc stores a queued entry. Returning nil removes the entry from the
+// queue, so it must also return nil for entries that can never succeed (e.g.
+// duplicates); returning an error keeps the entry and stops the flush.
+type ReplayFunc func(ctx context.Context, e Entry) error
+
+// WriteQueue is a FIFO of pending favourites, persisted to a local file so
+// accepted writes survive a restart.
+type WriteQueue struct {
+	mu       sync.Mutex
+	path     string
+	capacity int
+	entries  []Entry
+}
+
+// Open loads the queue persisted at path, creating an empty one if the file
+// does not exist yet.
+func Open(path string, capacity int) (*WriteQueue, error) {
+	q := &WriteQueue{path: path, capacity: capacity}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading write queue %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &q.entries); err != nil {
+			return nil, fmt.Errorf("parsing write queue %s: %w", path, err)
+		}
+	}
+	return q, nil
+}
+
+// Enqueue appends e to the queue and persists it before returning. It fails
+// with ErrFull when the queue is at capacity and ErrAlreadyQueued when the
+// same favourite is already waiting.
+func (q *WriteQueue) Enqueue(e Entry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, queued := range q.entries {
+		if queued.UserID == e.UserID && queued.AssetID == e.AssetID {
+			return ErrAlreadyQueued
+		}
+	}
+	if len(q.entries) >= q.capacity {
+		return ErrFull
+	}
+
+	e.ID = newEntryID()
+	if e.QueuedAt.IsZero() {
+		e.QueuedAt = time.Now().UTC()
+	}
+	entries := append(q.entries[:len(q.entries):len(q.entries)], e)
+	if err := q.persist(entries); err != nil {
+		return err
+	}
+	q.entries = entries
+	return nil
+}
+
+// HealthCheck reports the queue's Stats; it fails while favourites are
+// waiting for the database to take them.
+func (q *WriteQueue) HealthCheck(context.Context) (map[string]any, error) {
+	stats := q.Stats()
+	details := map[string]any{"queue": stats}
+	if stats.Depth > 0 {
+		return details, fmt.Errorf("%d favourites waiting for the database", stats.Depth)
+	}
+	return details, nil
+}
+
+// Stats reports the queue depth and the age of the oldest entry.
+func (q *WriteQueue) Stats() Stats {
+	if q == nil {
+		return Stats{}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := Stats{Depth: len(q.entries), Capacity: q.capacity}
+	if len(q.entries) > 0 {
+		oldest := q.entries[0].QueuedAt
+		stats.OldestQueuedAt = &oldest
+	}
+	return stats
+}
+
+// Flush replays queued entries in order until the queue is empty or replay
+// fails, and returns how many entries were removed.
+func (q *WriteQueue) Flush(ctx context.Context, replay ReplayFunc) (int, error) {
+	flushed := 0
+	for {
+		q.mu.Lock()
+		if len(q.entries) == 0 {
+			q.mu.Unlock()
+			return flushed, nil
+		}
+		head := q.entries[0]
+		q.mu.Unlock()
+
+		// The lock is not held while replaying so new writes can still be
+		// queued; only Flush removes entries, so head stays at the front.
+		if err := replay(ctx, head); err != nil {
+			return flushed, err
+		}
+
+		q.mu.Lock()
+		remaining := q.entries[1:]
+		err := q.persist(remaining)
+		if err == nil {
+			q.entries = remaining
+		}
+		q.mu.Unlock()
+		if err != nil {
+			return flushed, err
+		}
+		flushed++
+	}
+}
+
+// Run flushes the queue every interval until ctx is cancelled.
+func (q *WriteQueue) Run(ctx context.Context, interval time.Duration, replay ReplayFunc, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if q.Stats().Depth == 0 {
+				continue
+			}
+			flushed, err := q.Flush(ctx, replay)
+			depth := q.Stats().Depth
+			if flushed > 0 {
+				logging.With(logger).Layer("queue").Op("flush").Int("flushed", flushed).Int("depth", depth).
+					Info("replayed queued favourites")
+			}
+			if err != nil {
+				logging.With(logger).Layer("queue").Op("flush").Int("depth", depth).Err(err).
+					Warn("write queue flush interrupted; will retry")
+			}
+		}
+	}
+}
+
+// persist atomically replaces the queue file with entries. q.mu must be held.
+func (q *WriteQueue) persist(entries []Entry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("marshalling write queue: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating write queue file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing write queue file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing write queue file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing write queue file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), q.path); err != nil {
+		return fmt.Errorf("replacing write queue file: %w", err)
+	}
+	return nil
+}
+
+func newEntryID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,98 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func openTestQueue(t *testing.T, capacity int) (*WriteQueue, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := Open(path, capacity)
+	if err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	return q, path
+}
+
+func entry(userID, assetID string) Entry {
+	return Entry{UserID: userID, AssetID: assetID, AssetType: "insight", AssetData: []byte(`{"id":"` + assetID + `"}`)}
+}
+
+func TestWriteQueue_EnqueuePersists(t *testing.T) {
+	q, path := openTestQueue(t, 10)
+	if err := q.Enqueue(entry("user1", "i1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := q.Enqueue(entry("user1", "i2")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := Open(path, 10)
+	if err != nil {
+		t.Fatalf("failed to reopen queue: %v", err)
+	}
+	stats := reopened.Stats()
+	if stats.Depth != 2 || stats.Capacity != 10 || stats.OldestQueuedAt == nil {
+		t.Errorf("unexpected stats after reopen: %+v", stats)
+	}
+	if reopened.entries[0].AssetID != "i1" || reopened.entries[0].ID == "" {
+		t.Errorf("expected entries in order with IDs, got %+v", reopened.entries)
+	}
+}
+
+func TestWriteQueue_EnqueueLimits(t *testing.T) {
+	q, _ := openTestQueue(t, 1)
+	if err := q.Enqueue(entry("user1", "i1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := q.Enqueue(entry("user1", "i1")); !errors.Is(err, ErrAlreadyQueued) {
+		t.Errorf("expected ErrAlreadyQueued, got %v", err)
+	}
+	if err := q.Enqueue(entry("user2", "i1")); !errors.Is(err, ErrFull) {
+		t.Errorf("expected ErrFull, got %v", err)
+	}
+}
+
+func TestWriteQueue_Flush(t *testing.T) {
+	q, path := openTestQueue(t, 10)
+	for _, id := range []string{"i1", "i2", "i3"} {
+		q.Enqueue(entry("user1", id))
+	}
+
+	unavailable := errors.New("database unavailable")
+	var replayed []string
+	flushed, err := q.Flush(context.Background(), func(_ context.Context, e Entry) error {
+		if e.AssetID == "i3" {
+			return unavailable
+		}
+		replayed = append(replayed, e.AssetID)
+		return nil
+	})
+
+	if !errors.Is(err, unavailable) {
+		t.Fatalf("expected flush to stop with replay error, got %v", err)
+	}
+	if flushed != 2 || len(replayed) != 2 || replayed[0] != "i1" {
+		t.Errorf("expected i1 and i2 replayed in order, got %d %v", flushed, replayed)
+	}
+
+	reopened, _ := Open(path, 10)
+	if reopened.Stats().Depth != 1 || reopened.entries[0].AssetID != "i3" {
+		t.Errorf("expected only i3 left on disk, got %+v", reopened.entries)
+	}
+
+	flushed, err = q.Flush(context.Background(), func(context.Context, Entry) error { return nil })
+	if err != nil || flushed != 1 || q.Stats().Depth != 0 || q.Stats().OldestQueuedAt != nil {
+		t.Errorf("expected queue drained, got flushed=%d err=%v stats=%+v", flushed, err, q.Stats())
+	}
+}
+
+func TestWriteQueue_NilStats(t *testing.T) {
+	var q *WriteQueue
+	if stats := q.Stats(); stats.Depth != 0 || stats.Capacity != 0 {
+		t.Errorf("expected zero stats for disabled queue, got %+v", stats)
+	}
+}
@@ -0,0 +1,135 @@
+// Package richtext normalizes and renders the Markdown subset accepted in
+// favourite descriptions:
+//
+//   - paragraphs separated by blank lines, with single line breaks kept
+//   - bullet lists whose items start with "- " or "* "
+//   - **bold**, *italic* or _italic_, and `code`
+//   - [text](url) links to http, https and mailto URLs
+//
+// Anything else is kept as plain text. The renderer escapes all input, so its
+// output is safe to embed in a page whatever the source contains.
+package richtext
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Script and style elements are removed with their content; an element
+	// that is never closed swallows the rest of the text.
+	scriptRe  = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+	openRe    = regexp.MustCompile(`(?is)<(script|style)\b.*$`)
+	commentRe = regexp.MustCompile(`(?s)<!--.*?(-->|$)`)
+	tagRe     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	blankRe   = regexp.MustCompile(`\n{3,}`)
+
+	codeRe   = regexp.MustCompile("`([^`]+)`")
+	linkRe   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldRe   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	italicRe = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+)
+
+// Sanitize normalizes a description before it is stored: HTML comments,
+// script and style elements and all other tags are stripped, line endings
+// become "\n", trailing spaces are trimmed and runs of blank lines collapse
+// into one.
+func Sanitize(raw string) string {
+	s := strings.ReplaceAll(raw, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = commentRe.ReplaceAllString(s, "")
+	s = scriptRe.ReplaceAllString(s, "")
+	s = openRe.ReplaceAllString(s, "")
+	s = tagRe.ReplaceAllString(s, "")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	s = blankRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// Render converts a description to HTML. Empty input renders as "".
+func Render(src string) string {
+	var (
+		b     strings.Builder
+		para  []string
+		items []string
+	)
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>") + "</p>")
+			para = nil
+		}
+	}
+	flushList := func() {
+		if len(items) > 0 {
+			b.WriteString("<ul><li>" + strings.Join(items, "</li><li>") + "</li></ul>")
+			items = nil
+		}
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flushPara()
+			flushList()
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			flushPara()
+			items = append(items, inline(strings.TrimSpace(line[2:])))
+		default:
+			flushList()
+			para = append(para, inline(line))
+		}
+	}
+	flushPara()
+	flushList()
+	return b.String()
+}
+
+// inline renders the inline markup of a single line. Code spans are rendered
+// verbatim, and link targets are kept out of emphasis processing.
+func inline(line string) string {
+	return transform(html.EscapeString(line), codeRe,
+		func(m []string) string { return "<code>" + m[1] + "</code>" },
+		func(text string) string { return transform(text, linkRe, link, emphasis) })
+}
+
+// link renders a link, or only its text when the target is not an allowed URL.
+func link(m []string) string {
+	text, target := emphasis(m[1]), m[2]
+	u, err := url.Parse(html.UnescapeString(target))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "mailto") {
+		return text
+	}
+	return `<a href="` + target + `" rel="nofollow noopener">` + text + "</a>"
+}
+
+func emphasis(text string) string {
+	text = boldRe.ReplaceAllString(text, "<strong>$1</strong>")
+	return italicRe.ReplaceAllString(text, "<em>$1$2</em>")
+}
+
+// transform replaces each match of re in s with fn of its submatches, and the
+// text between matches with rest.
+func transform(s string, re *regexp.Regexp, fn func([]string) string, rest func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, idx := range re.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(rest(s[last:idx[0]]))
+		m := make([]string, len(idx)/2)
+		for i := range m {
+			if idx[2*i] >= 0 {
+				m[i] = s[idx[2*i]:idx[2*i+1]]
+			}
+		}
+		b.WriteString(fn(m))
+		last = idx[1]
+	}
+	b.WriteString(rest(s[last:]))
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package richtext
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "plain text", raw: "Revenue by month", want: "Revenue by month"},
+		{name: "script with content", raw: "before<script>alert(1)</script> after", want: "before after"},
+		{name: "unclosed script", raw: "keep <SCRIPT src=x>alert(1)", want: "keep"},
+		{name: "style and comment", raw: "a<style>p{}</style><!-- hidden -->b", want: "ab"},
+		{name: "other tags", raw: `<b onclick="x()">bold</b> and <img src=x onerror=y>`, want: "bold and"},
+		{name: "comparison is not a tag", raw: "1 < 2 > 0", want: "1 < 2 > 0"},
+		{name: "line endings and blank lines", raw: "a  \r\n\r\n\r\n\rb\r", want: "a\n\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.raw); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "empty", src: "", want: ""},
+		{name: "paragraphs and breaks", src: "one\ntwo\n\nthree", want: "<p>one<br>two</p><p>three</p>"},
+		{name: "emphasis", src: "**bold**, *italic* and _also_ but not snake_case_name", want: "<p><strong>bold</strong>, <em>italic</em> and <em>also</em> but not snake_case_name</p>"},
+		{name: "code is verbatim", src: "run `a *b* <c>`", want: "<p>run <code>a *b* &lt;c&gt;</code></p>"},
+		{name: "list", src: "Notes:\n- first\n* **second**\n\nend", want: "<p>Notes:</p><ul><li>first</li><li><strong>second</strong></li></ul><p>end</p>"},
+		{name: "link", src: "[the report](https://example.com/r?a=1&b=_x_)", want: `<p><a href="https://example.com/r?a=1&amp;b=_x_" rel="nofollow noopener">the report</a></p>`},
+		{name: "unsafe link keeps text", src: "[click](javascript:alert(1))", want: "<p>click)</p>"},
+		{name: "html is escaped", src: `<b>"x"</b>`, want: "<p>&lt;b&gt;&#34;x&#34;&lt;/b&gt;</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src); got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
+	"github.com/go-chi/chi/v5"
+)
+
+func assetOwnershipRoute(h *handlers.Favourites, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+
+		var req handlers.AssetOwnershipRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("assetOwnership").User(adminID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("assetOwnership").User(adminID).
+			Int("asset_count", len(req.AssetIDs)).Bool("remove", req.Remove).
+			Info("received asset ownership request")
+
+		report, err := h.ReportAssetOwnership(ctx, adminID, &req, publisher)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			var rejectedErr *handlers.QueryRejectedError
+			if errors.As(err, &rejectedErr) {
+				logging.Log(ctx).Layer("routes").Op("assetOwnership").User(adminID).Err(err).Warn("query rejected")
+				respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("assetOwnership").User(adminID).Err(err).
+				Error("failed to build asset ownership report")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("assetOwnership").User(adminID).
+			Int("asset_count", len(report.Assets)).Int("removed", report.Removed).
+			Int("status_code", http.StatusOK).Info("asset ownership report generated")
+		respondWithJSON(w, http.StatusOK, report)
+	}
+}
+
+// writeQueueStatsRoute reports the depth of the store-and-forward write queue.
+// A disabled queue reports zero depth and capacity.
+func writeQueueStatsRoute(writeQueue *queue.WriteQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, writeQueue.Stats())
+	}
+}
+
+// searchUsersRoute lists the users with favourites whose ID starts with ?q=,
+// honouring an optional ?limit= query parameter.
+func searchUsersRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		query := r.URL.Query().Get("q")
+
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "limit must be an integer")
+				return
+			}
+			limit = n
+		}
+
+		logging.Log(ctx).Layer("routes").Op("searchUsers").User(adminID).
+			Str("query", query).Int("limit", limit).Info("received user search request")
+
+		users, err := h.SearchUsers(ctx, query, limit)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			var rejectedErr *handlers.QueryRejectedError
+			if errors.As(err, &rejectedErr) {
+				logging.Log(ctx).Layer("routes").Op("searchUsers").User(adminID).Err(err).Warn("query rejected")
+				respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("searchUsers").User(adminID).Err(err).
+				Error("failed to search users")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("searchUsers").User(adminID).
+			Int("count", len(users)).Int("status_code", http.StatusOK).
+			Info("user search completed")
+		respondWithJSON(w, http.StatusOK, users)
+	}
+}
+
+// getAdminUserFavouritesRoute returns the favourites of the user named in the path.
+func getAdminUserFavouritesRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		userID := chi.URLParam(r, "userID")
+
+		logging.Log(ctx).Layer("routes").Op("getAdminUserFavourites").User(adminID).
+			Str("target_user", userID).Info("received admin get favourites request")
+
+		render, ok := renderHTML(w, r)
+		if !ok {
+			return
+		}
+		favourites, err := h.GetUserFavourites(ctx, userID)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").Op("getAdminUserFavourites").User(adminID).
+				Str("target_user", userID).Err(err).Error("failed to get user favourites")
+			respondWithServerError(w, err)
+			return
+		}
+		if !render {
+			handlers.OmitRenderedDescriptions(favourites)
+		}
+
+		logging.Log(ctx).Layer("routes").Op("getAdminUserFavourites").User(adminID).
+			Str("target_user", userID).Int("count", len(favourites)).
+			Int("status_code", http.StatusOK).Info("favourites retrieved successfully")
+		respondWithJSON(w, http.StatusOK, favourites)
+	}
+}
+
+// mergeUserFavouritesRoute copies another user's favourites into the user named in the path.
+func mergeUserFavouritesRoute(h *handlers.Favourites, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		userID := chi.URLParam(r, "userID")
+
+		var req handlers.MergeFavouritesRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("mergeUserFavourites").User(adminID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("mergeUserFavourites").User(adminID).
+			Str("target_user", userID).Str("source_user", req.SourceUserID).
+			Info("received merge favourites request")
+
+		resp, err := h.MergeFavourites(ctx, adminID, userID, &req, publisher)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("mergeUserFavourites").User(adminID).Err(err).
+				Error("failed to merge favourites")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("mergeUserFavourites").User(adminID).
+			Str("target_user", userID).Int("merged", resp.Merged).
+			Int("status_code", http.StatusOK).Info("favourites merged successfully")
+		respondWithJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,177 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+)
+
+func postAssetOwnership(t *testing.T, router *chi.Mux, userID string, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/api/v1/admin/assets/ownership", bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, userID)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminRoutes_AssetOwnership(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		body      map[string]any
+		setupMock func(sqlmock.Sqlmock)
+		wantCode  int
+	}{
+		{
+			name: "admin gets report", userID: "admin1",
+			body: map[string]any{"asset_ids": []string{"c1"}},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("SET LOCAL statement_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectQuery("SELECT id, user_id, asset_type FROM favourites").WillReturnRows(
+					sqlmock.NewRows([]string{"id", "user_id", "asset_type"}).AddRow("c1", "user1", "chart"))
+				m.ExpectRollback()
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "admin removes assets", userID: "admin1",
+			body: map[string]any{"asset_ids": []string{"c1"}, "remove": true},
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("UPDATE favourites SET deleted_at = NOW").WillReturnRows(
+					sqlmock.NewRows([]string{"id", "user_id", "asset_type"}).AddRow("c1", "user1", "chart"))
+				m.ExpectCommit()
+			},
+			wantCode: http.StatusOK,
+		},
+		{name: "non-admin forbidden", userID: "user1", body: map[string]any{"asset_ids": []string{"c1"}}, wantCode: http.StatusForbidden},
+		{name: "missing asset ids", userID: "admin1", body: map[string]any{}, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+
+			rr := postAssetOwnership(t, router, tt.userID, tt.body)
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var report struct {
+					Assets []struct {
+						AssetID string   `json:"asset_id"`
+						Users   []string `json:"users"`
+					} `json:"assets"`
+				}
+				json.Unmarshal(rr.Body.Bytes(), &report)
+				if len(report.Assets) != 1 || len(report.Assets[0].Users) != 1 || report.Assets[0].Users[0] != "user1" {
+					t.Errorf("unexpected report: %s", rr.Body.String())
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestAdminRoutes_Users(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		body      string
+		setupMock func(sqlmock.Sqlmock)
+		wantCode  int
+	}{
+		{
+			name: "search users", method: "GET", path: "/api/v1/admin/users?q=us",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("SET LOCAL statement_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectQuery("SELECT user_id, COUNT").WithArgs("us%", 50).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "count"}).AddRow("user1", 2))
+				m.ExpectRollback()
+			},
+			wantCode: http.StatusOK,
+		},
+		{name: "search with invalid limit", method: "GET", path: "/api/v1/admin/users?limit=x", wantCode: http.StatusBadRequest},
+		{name: "search with overlong query", method: "GET", path: "/api/v1/admin/users?q=" + strings.Repeat("u", 65), wantCode: http.StatusUnprocessableEntity},
+		{
+			name: "view user favourites", method: "GET", path: "/api/v1/admin/users/user1/favourites",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id").WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows(testCols))
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "merge favourites", method: "POST", path: "/api/v1/admin/users/user2/merge",
+			body: `{"source_user_id": "user1"}`,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO favourites").WithArgs("user1", "user2", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "asset_type"}).AddRow("c1", "user2", "chart"))
+				m.ExpectCommit()
+			},
+			wantCode: http.StatusOK,
+		},
+		{name: "merge into self", method: "POST", path: "/api/v1/admin/users/user1/merge", body: `{"source_user_id": "user1"}`, wantCode: http.StatusBadRequest},
+		{name: "merge with invalid body", method: "POST", path: "/api/v1/admin/users/user1/merge", body: `{`, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "admin1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestAdminUI(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		router := chi.NewRouter()
+		router.Group(RegisterFavouritesRoutes(Deps{AdminUI: enabled}))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/admin/", nil))
+
+		wantCode := http.StatusNotFound
+		if enabled {
+			wantCode = http.StatusOK
+		}
+		if rr.Code != wantCode {
+			t.Errorf("enabled=%v: expected status %d, got %d", enabled, wantCode, rr.Code)
+		}
+	}
+}
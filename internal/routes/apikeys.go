@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// listAPIKeysRoute lists the API keys issued to the user named in the path.
+func listAPIKeysRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		userID := chi.URLParam(r, "userID")
+
+		keys, err := h.ListAPIKeys(ctx, userID)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").Op("listAPIKeys").User(adminID).
+				Str("target_user", userID).Err(err).Error("failed to list API keys")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("listAPIKeys").User(adminID).
+			Str("target_user", userID).Int("count", len(keys)).
+			Int("status_code", http.StatusOK).Info("API keys listed")
+		respondWithJSON(w, http.StatusOK, keys)
+	}
+}
+
+// createAPIKeyRoute issues an API key bound to the user named in the path.
+func createAPIKeyRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		userID := chi.URLParam(r, "userID")
+
+		var req handlers.CreateAPIKeyRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("createAPIKey").User(adminID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		created, err := h.CreateAPIKey(ctx, userID, &req)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("createAPIKey").User(adminID).
+				Str("target_user", userID).Err(err).Error("failed to create API key")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("createAPIKey").User(adminID).
+			Str("target_user", userID).Str("key_id", created.ID).
+			Int("status_code", http.StatusCreated).Info("API key created")
+		respondWithJSON(w, http.StatusCreated, created)
+	}
+}
+
+// revokeAPIKeyRoute revokes an API key of the user named in the path.
+func revokeAPIKeyRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		userID := chi.URLParam(r, "userID")
+		keyID := chi.URLParam(r, "keyID")
+
+		if err := h.RevokeAPIKey(ctx, userID, keyID); err != nil {
+			if errors.Is(err, database.ErrAPIKeyNotFound) {
+				respondWithError(w, http.StatusNotFound, "API key not found")
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("revokeAPIKey").User(adminID).
+				Str("target_user", userID).Str("key_id", keyID).Err(err).Error("failed to revoke API key")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("revokeAPIKey").User(adminID).
+			Str("target_user", userID).Str("key_id", keyID).
+			Int("status_code", http.StatusOK).Info("API key revoked")
+		respondWithJSON(w, http.StatusOK, map[string]string{"message": "API key revoked successfully"})
+	}
+}
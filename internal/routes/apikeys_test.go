@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestAdminRoutes_APIKeys(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	send := func(method, path, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		addAuthHeader(req, userID)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	mock.ExpectExec("INSERT INTO api_keys").
+		WithArgs(sqlmock.AnyArg(), "user1", "reporting", sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rr := send("POST", "/api/v2/admin/users/user1/api-keys", "admin1", `{"name":"reporting"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID  string `json:"id"`
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil || created.Data.ID == "" || created.Data.Key == "" {
+		t.Errorf("expected the key in the response, got %s (%v)", rr.Body.String(), err)
+	}
+
+	if rr := send("POST", "/api/v2/admin/users/user1/api-keys", "admin1", `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a name, got %d", rr.Code)
+	}
+	if rr := send("POST", "/api/v2/admin/users/user1/api-keys", "user1", `{"name":"mine"}`); rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for non-admin, got %d", rr.Code)
+	}
+
+	mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs("user1", "k9", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if rr := send("DELETE", "/api/v2/admin/users/user1/api-keys/k9", "admin1", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for unknown key, got %d", rr.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRegisterFavouritesRoutes_APIKeyAuth(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth: auth.AuthConfig{
+			APIKeys: func(ctx context.Context, hash string) (string, string, error) {
+				if hash == auth.HashAPIKey("pgc_valid") {
+					return "user1", "", nil
+				}
+				return "", "", nil
+			},
+			AdminUsers: []string{"user1"},
+		},
+		Publisher: events.NewBus(),
+	}))
+
+	send := func(method, path, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set(auth.APIKeyHeader, key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Remove-all without confirm=true is rejected by the handler, after
+	// authentication and before any database access.
+	if code := send("DELETE", "/api/v1/favourites", "pgc_valid"); code != http.StatusBadRequest {
+		t.Errorf("expected a valid key to reach the handler, got %d", code)
+	}
+	if code := send("DELETE", "/api/v1/favourites", "pgc_revoked"); code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for an unknown key, got %d", code)
+	}
+	if code := send("GET", "/api/v1/admin/queue", "pgc_valid"); code != http.StatusForbidden {
+		t.Errorf("expected API keys to be kept out of admin routes, got %d", code)
+	}
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// getUserAuditRoute returns the audit trail of the authenticated user's favourites.
+func getUserAuditRoute(h *handlers.Favourites) http.HandlerFunc {
+	return auditTrailRoute(h, "getUserAudit", func(r *http.Request) string {
+		return auth.UserIDFromContext(r.Context())
+	})
+}
+
+// getAdminUserAuditRoute returns the audit trail of the user named in the path.
+func getAdminUserAuditRoute(h *handlers.Favourites) http.HandlerFunc {
+	return auditTrailRoute(h, "getAdminUserAudit", func(r *http.Request) string {
+		return chi.URLParam(r, "userID")
+	})
+}
+
+// auditTrailRoute serves the audit trail of the user selected by target,
+// honouring an optional ?limit= query parameter.
+func auditTrailRoute(h *handlers.Favourites, op string, target func(r *http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		callerID := auth.UserIDFromContext(ctx)
+		userID := target(r)
+
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "limit must be an integer")
+				return
+			}
+			limit = n
+		}
+
+		logging.Log(ctx).Layer("routes").Op(op).User(callerID).Str("target_user", userID).
+			Int("limit", limit).Info("received audit trail request")
+
+		entries, err := h.GetAuditTrail(ctx, userID, limit)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op(op).User(callerID).Err(err).
+				Error("failed to get audit trail")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op(op).User(callerID).Str("target_user", userID).
+			Int("count", len(entries)).Int("status_code", http.StatusOK).
+			Info("audit trail retrieved successfully")
+		respondWithJSON(w, http.StatusOK, entries)
+	}
+}
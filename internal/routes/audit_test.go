@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+)
+
+var auditCols = []string{"id", "user_id", "actor", "action", "asset_id", "diff", "occurred_at", "tenant_id"}
+
+func TestAuditRoutes(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		caller    string
+		setupMock func(sqlmock.Sqlmock)
+		wantCode  int
+	}{
+		{
+			name: "user reads own trail", path: "/api/v1/favourites/audit", caller: "user1",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourite_audit").WithArgs("user1", 100).
+					WillReturnRows(sqlmock.NewRows(auditCols).
+						AddRow(1, "user1", "admin1", "delete", "c1", nil, time.Now(), ""))
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "user passes limit", path: "/api/v1/favourites/audit?limit=5", caller: "user1",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourite_audit").WithArgs("user1", 5).
+					WillReturnRows(sqlmock.NewRows(auditCols).
+						AddRow(1, "user1", "user1", "add", "c1", []byte(`{"description":"d"}`), time.Now(), ""))
+			},
+			wantCode: http.StatusOK,
+		},
+		{name: "non-numeric limit", path: "/api/v1/favourites/audit?limit=abc", caller: "user1", wantCode: http.StatusBadRequest},
+		{name: "limit out of range", path: "/api/v1/favourites/audit?limit=5000", caller: "user1", wantCode: http.StatusBadRequest},
+		{
+			name: "admin reads another user's trail", path: "/api/v1/admin/users/user1/audit", caller: "admin1",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery("SELECT .+ FROM favourite_audit").WithArgs("user1", 100).
+					WillReturnRows(sqlmock.NewRows(auditCols).
+						AddRow(1, "user1", "user2", "delete", "c1", nil, time.Now(), ""))
+			},
+			wantCode: http.StatusOK,
+		},
+		{name: "non-admin forbidden", path: "/api/v1/admin/users/user2/audit", caller: "user1", wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, tt.caller)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var entries []database.AuditEntry
+				json.Unmarshal(rr.Body.Bytes(), &entries)
+				if len(entries) != 1 || entries[0].UserID != "user1" {
+					t.Errorf("unexpected entries: %s", rr.Body.String())
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// updateCatalogAssetRoute replaces the catalog entry of an asset, updating every
+// favourite that references it.
+func updateCatalogAssetRoute(h *handlers.Favourites, maxAssetDataBytes int, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		assetType := models.AssetType(chi.URLParam(r, "assetType"))
+		assetID := chi.URLParam(r, "assetID")
+
+		if err := handlers.ValidateAssetID(assetID); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req handlers.ReplaceAssetDataRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("updateCatalogAsset").User(adminID).Asset(assetID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+		if !checkAssetDataSize(w, r, req.AssetData, maxAssetDataBytes) {
+			return
+		}
+
+		result, err := h.UpdateCatalogAsset(ctx, adminID, assetType, assetID, req.AssetData, publisher)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			switch {
+			case handlers.IsInvalidAssetType(err), errors.As(err, &validationErr):
+				respondWithError(w, http.StatusBadRequest, err.Error())
+			case errors.Is(err, database.ErrAssetNotFound):
+				respondWithError(w, http.StatusNotFound, "Asset not found in catalog")
+			default:
+				logging.Log(ctx).Layer("routes").Op("updateCatalogAsset").User(adminID).Asset(assetID).Err(err).
+					Error("failed to update catalog asset")
+				respondWithServerError(w, err)
+			}
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("updateCatalogAsset").User(adminID).Asset(assetID).
+			AssetType(string(assetType)).Int("favourites", result.Favourites).
+			Int("status_code", http.StatusOK).Info("catalog asset updated")
+		respondWithJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAdminRoutes_UpdateCatalogAsset(t *testing.T) {
+	chart := map[string]any{"asset_data": map[string]any{"id": "c1", "title": "T", "x_axis_title": "X", "y_axis_title": "Y"}}
+
+	tests := []struct {
+		name      string
+		userID    string
+		path      string
+		body      map[string]any
+		setupMock func(sqlmock.Sqlmock)
+		wantCode  int
+	}{
+		{
+			name: "admin updates entry", userID: "admin1", path: "/api/v2/admin/assets/chart/c1", body: chart,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE assets SET data").WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectQuery("UPDATE favourites SET updated_at").WillReturnRows(
+					sqlmock.NewRows([]string{"id", "user_id", "asset_type"}).AddRow("c1", "user1", "chart"))
+				m.ExpectCommit()
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "entry not in catalog", userID: "admin1", path: "/api/v2/admin/assets/chart/c1", body: chart,
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectExec("UPDATE assets SET data").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectRollback()
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{name: "unknown type", userID: "admin1", path: "/api/v2/admin/assets/widget/c1", body: chart, wantCode: http.StatusBadRequest},
+		{name: "non-admin forbidden", userID: "user1", path: "/api/v2/admin/assets/chart/c1", body: chart, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+
+			data, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest("PUT", tt.path, bytes.NewBuffer(data))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, tt.userID)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var result struct {
+					Data struct {
+						Favourites int `json:"favourites"`
+					} `json:"data"`
+				}
+				json.Unmarshal(rr.Body.Bytes(), &result)
+				if result.Data.Favourites != 1 {
+					t.Errorf("expected 1 favourite, got %s", rr.Body.String())
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// corsExposedHeaders are the response headers browsers let cross-origin
+// callers read, beyond the CORS-safelisted ones.
+var corsExposedHeaders = strings.Join([]string{
+	"Location", "Content-Disposition", preferenceAppliedHeader,
+	logging.RequestIDHeader, logging.CorrelationIDHeader,
+	"Deprecation", "Sunset", "Link",
+	"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}, ", ")
+
+// corsMiddleware implements CORS for the origins in cfg. Preflight requests
+// (OPTIONS with Access-Control-Request-Method) are answered here with 204, so
+// they need no JWT; preflights from other origins, or asking for a method or
+// header that is not allowed, get no CORS headers and are refused by the
+// browser. It returns a pass-through middleware when no origin is allowed.
+func corsMiddleware(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*")
+	allowedOrigin := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		return slices.ContainsFunc(cfg.AllowedOrigins, func(o string) bool { return strings.EqualFold(o, origin) })
+	}
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if preflight {
+				h.Add("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+				if origin != "" && allowedOrigin(origin) &&
+					slices.Contains(cfg.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) &&
+					headersAllowed(cfg.AllowedHeaders, r.Header.Values("Access-Control-Request-Headers")) {
+					setAllowOrigin(h, origin, anyOrigin, cfg.AllowCredentials)
+					h.Set("Access-Control-Allow-Methods", allowedMethods)
+					h.Set("Access-Control-Allow-Headers", allowedHeaders)
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			h.Add("Vary", "Origin")
+			if origin != "" && allowedOrigin(origin) {
+				setAllowOrigin(h, origin, anyOrigin, cfg.AllowCredentials)
+				h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setAllowOrigin allows origin. Credentialed requests need the origin echoed;
+// otherwise a wildcard configuration answers "*".
+func setAllowOrigin(h http.Header, origin string, anyOrigin, credentials bool) {
+	if anyOrigin && !credentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// headersAllowed reports whether every header named in the
+// Access-Control-Request-Headers values is in allowed.
+func headersAllowed(allowed []string, requested []string) bool {
+	for _, value := range requested {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, name) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
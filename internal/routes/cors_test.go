@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/go-chi/chi/v5"
+)
+
+func setupCORSHandler(cors config.CORSConfig) *chi.Mux {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth: auth.AuthConfig{AllowUnsignedTokens: true},
+		CORS: cors,
+	}))
+	return router
+}
+
+func testCORSConfig() config.CORSConfig {
+	return config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Accept", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+func preflight(router http.Handler, path, origin, method, headers string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	router := setupCORSHandler(testCORSConfig())
+
+	tests := []struct {
+		name    string
+		path    string
+		origin  string
+		method  string
+		headers string
+		allowed bool
+	}{
+		{"allowed", "/api/v1/favourites", "https://app.example.com", "POST", "authorization, content-type", true},
+		{"origin is case-insensitive", "/api/v2/favourites/c1", "HTTPS://APP.EXAMPLE.COM", "DELETE", "", true},
+		{"unknown origin", "/api/v1/favourites", "https://evil.example.com", "GET", "", false},
+		{"method not allowed", "/api/v1/favourites", "https://app.example.com", "PATCH", "", false},
+		{"header not allowed", "/api/v1/favourites", "https://app.example.com", "GET", "authorization, x-debug", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := preflight(router, tt.path, tt.origin, tt.method, tt.headers)
+
+			// Preflights never reach authentication or routing.
+			if rr.Code != http.StatusNoContent {
+				t.Fatalf("expected status 204, got %d", rr.Code)
+			}
+			allowOrigin := rr.Header().Get("Access-Control-Allow-Origin")
+			if !tt.allowed {
+				if allowOrigin != "" {
+					t.Errorf("expected no CORS headers, got Access-Control-Allow-Origin %q", allowOrigin)
+				}
+				return
+			}
+			if allowOrigin != tt.origin {
+				t.Errorf("expected the origin to be echoed, got %q", allowOrigin)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, DELETE" {
+				t.Errorf("unexpected Access-Control-Allow-Methods %q", got)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Accept, Content-Type" {
+				t.Errorf("unexpected Access-Control-Allow-Headers %q", got)
+			}
+			if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+				t.Errorf("expected Access-Control-Max-Age 600, got %q", got)
+			}
+			if rr.Header().Get("Access-Control-Allow-Credentials") != "" {
+				t.Error("expected credentials not to be allowed")
+			}
+		})
+	}
+}
+
+func TestCORS_ActualRequest(t *testing.T) {
+	router := setupCORSHandler(testCORSConfig())
+
+	send := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/favourites/quota", nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Origin", origin)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Error responses carry CORS headers too, so browsers can read them.
+	rr := send("https://app.example.com")
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected the origin to be allowed, got %q", got)
+	}
+	if rr.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Error("expected Access-Control-Expose-Headers to be set")
+	}
+	if rr.Header().Get("Vary") != "Origin" {
+		t.Errorf("expected Vary: Origin, got %q", rr.Header().Get("Vary"))
+	}
+
+	rr = send("https://evil.example.com")
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers for an unknown origin")
+	}
+}
+
+func TestCORS_WildcardAndCredentials(t *testing.T) {
+	cfg := testCORSConfig()
+	cfg.AllowedOrigins = []string{"*"}
+	rr := preflight(setupCORSHandler(cfg), "/api/v1/favourites", "https://any.example.com", "GET", "")
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("expected a wildcard origin, got %q", got)
+	}
+
+	cfg = testCORSConfig()
+	cfg.AllowCredentials = true
+	rr = preflight(setupCORSHandler(cfg), "/api/v1/favourites", "https://app.example.com", "GET", "")
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected the origin to be echoed with credentials, got %q", got)
+	}
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("expected credentials to be allowed")
+	}
+}
+
+func TestCORS_DisabledByDefault(t *testing.T) {
+	rr := preflight(setupCORSHandler(config.CORSConfig{}), "/api/v1/favourites", "https://app.example.com", "GET", "")
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected preflight to be unhandled (405), got %d", rr.Code)
+	}
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers when no origin is configured")
+	}
+}
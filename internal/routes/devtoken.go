@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// devTokenPath is where development tokens are minted. It is only mounted
+// when unsigned tokens are allowed, so it never exists in production.
+const devTokenPath = "/dev/token"
+
+// devTokenRoute mints a short-lived token for the user in the request body,
+// so frontend developers can call the API without running tools/tokengen.
+func devTokenRoute(cfg auth.AuthConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req handlers.DevTokenRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("issueDevToken").Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		token, err := handlers.IssueDevToken(cfg, &req)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("issueDevToken").User(req.UserID).Err(err).
+				Error("failed to issue development token")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("issueDevToken").User(req.UserID).
+			Int("status_code", http.StatusCreated).Warn("development token issued")
+		respondWithJSON(w, http.StatusCreated, token)
+	}
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDevTokenRoute(t *testing.T) {
+	newRouter := func(cfg auth.AuthConfig) *chi.Mux {
+		router := chi.NewRouter()
+		router.Group(RegisterFavouritesRoutes(Deps{Auth: cfg, Publisher: events.NewBus()}))
+		return router
+	}
+	send := func(router *chi.Mux, method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send(newRouter(auth.AuthConfig{Secret: "prod-secret"}), "POST", "/api/v2/dev/token", `{"user_id":"user1"}`, ""); rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected no dev token endpoint without unsigned tokens, got %d", rr.Code)
+	}
+	// A signing key left configured must not let anyone mint tokens it accepts
+	if rr := send(newRouter(auth.AuthConfig{AllowUnsignedTokens: true, Secret: "prod-secret"}), "POST", "/api/v2/dev/token", `{"user_id":"admin"}`, ""); rr.Code != http.StatusNotFound && rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected no dev token endpoint with a JWT secret, got %d", rr.Code)
+	}
+
+	router := newRouter(auth.AuthConfig{AllowUnsignedTokens: true})
+	if rr := send(router, "POST", "/api/v2/dev/token", `{}`, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a user ID, got %d", rr.Code)
+	}
+	rr := send(router, "POST", "/api/v2/dev/token", `{"user_id":"user1"}`, "")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data handlers.DevToken `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Data.Token == "" || resp.Data.TokenType != "Bearer" {
+		t.Fatalf("unexpected response %+v (%v)", resp.Data, err)
+	}
+	token := resp.Data
+
+	// Remove-all without confirm=true answers 400 once authenticated.
+	if rr := send(router, "DELETE", "/api/v1/favourites", "", token.Token); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected the minted token to be accepted, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
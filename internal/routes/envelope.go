@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// envelope is the body of successful enveloped responses.
+type envelope struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// Problem is an RFC 9457 problem details body, served as application/problem+json.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+const problemContentType = "application/problem+json"
+
+// envelopeMiddleware shapes the responses of enveloped versions: JSON bodies
+// of successful responses are wrapped as {"data": ...} and errors become
+// problem details. Bodies are buffered, so errors written by the auth, rate
+// limit and header middleware are converted too. HEAD requests, bodiless
+// responses, downloads (Content-Disposition) and hijacked connections pass
+// through.
+func envelopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish(r)
+	})
+}
+
+type envelopeWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	passthrough bool
+	buf         bytes.Buffer
+}
+
+func (e *envelopeWriter) WriteHeader(code int) {
+	if e.wroteHeader {
+		return
+	}
+	e.wroteHeader = true
+	e.status = code
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified ||
+		e.Header().Get("Content-Disposition") != "" {
+		e.passthrough = true
+		e.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (e *envelopeWriter) Write(b []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.passthrough {
+		return e.ResponseWriter.Write(b)
+	}
+	return e.buf.Write(b)
+}
+
+// Hijack hands the connection over untouched, e.g. for WebSocket upgrades.
+func (e *envelopeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := e.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	e.wroteHeader, e.passthrough = true, true
+	return hj.Hijack()
+}
+
+// finish writes the buffered response in its enveloped form.
+func (e *envelopeWriter) finish(r *http.Request) {
+	if !e.wroteHeader || e.passthrough {
+		return
+	}
+	body := bytes.TrimSpace(e.buf.Bytes())
+	h := e.Header()
+
+	var out []byte
+	switch {
+	case e.status >= http.StatusBadRequest:
+		out, _ = json.Marshal(Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(e.status),
+			Status:   e.status,
+			Detail:   errorDetail(body),
+			Instance: r.URL.Path,
+		})
+		h.Set("Content-Type", problemContentType)
+	case len(body) > 0 && json.Valid(body):
+		out, _ = json.Marshal(envelope{Data: body})
+		h.Set("Content-Type", "application/json")
+	default:
+		out = e.buf.Bytes()
+	}
+
+	h.Set("Content-Length", strconv.Itoa(len(out)))
+	e.ResponseWriter.WriteHeader(e.status)
+	e.ResponseWriter.Write(out)
+}
+
+// errorDetail extracts the message of a v1 {"error": "..."} body, falling
+// back to the body itself.
+func errorDetail(body []byte) string {
+	var v1 struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &v1) == nil && v1.Error != "" {
+		return v1.Error
+	}
+	return string(body)
+}
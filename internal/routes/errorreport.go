@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrorReporter is told about each request a route answers with a 5xx
+// through respondWithError, e.g. to report it to an error tracker. It must
+// not block.
+type ErrorReporter func(r *http.Request, status int, message string)
+
+// errorReportingMiddleware hands the routes it wraps a writer through which
+// respondWithError reports their server errors to report. It must be the
+// last middleware of a route, so the handler gets that writer unwrapped.
+func errorReportingMiddleware(report ErrorReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if report == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&errorReportingWriter{ResponseWriter: w, r: r, report: report}, r)
+		})
+	}
+}
+
+type errorReportingWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	report ErrorReporter
+}
+
+// reportError reports a server error answered through w, if w reports them.
+func reportError(w http.ResponseWriter, code int, message string) {
+	if ew, ok := w.(*errorReportingWriter); ok && code >= http.StatusInternalServerError {
+		ew.report(ew.r, code, message)
+	}
+}
+
+func (e *errorReportingWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+func (e *errorReportingWriter) Flush() {
+	if f, ok := e.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hands the connection over, e.g. for WebSocket upgrades.
+func (e *errorReportingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := e.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return hj.Hijack()
+}
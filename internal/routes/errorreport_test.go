@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorReportingMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		wantReport bool
+	}{
+		{name: "server error", code: http.StatusInternalServerError, wantReport: true},
+		{name: "unavailable", code: http.StatusServiceUnavailable, wantReport: true},
+		{name: "client error", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []string
+			report := func(r *http.Request, status int, message string) {
+				reported = append(reported, r.URL.Path+" "+message)
+			}
+			handler := errorReportingMiddleware(report)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respondWithError(w, tt.code, "failed")
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/favourites", nil))
+
+			if rec.Code != tt.code {
+				t.Errorf("expected status %d, got %d", tt.code, rec.Code)
+			}
+			if tt.wantReport != (len(reported) == 1) {
+				t.Errorf("expected report %v, got %q", tt.wantReport, reported)
+			}
+			if tt.wantReport && reported[0] != "/favourites failed" {
+				t.Errorf("unexpected report %q", reported[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/jobs"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// exportJobPath is where an export job's status is served, relative to the
+// version prefix.
+const exportJobPath = "/export-jobs/"
+
+// exportJobResponse is an export job with the links a client follows next.
+type exportJobResponse struct {
+	jobs.Job
+	StatusURL   string `json:"status_url"`
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// newExportJobResponse links job within the API version mounted at prefix.
+func newExportJobResponse(prefix string, job jobs.Job) exportJobResponse {
+	resp := exportJobResponse{Job: job, StatusURL: prefix + exportJobPath + job.ID}
+	if job.Status == jobs.StatusSucceeded {
+		resp.DownloadURL = resp.StatusURL + "/download"
+	}
+	return resp
+}
+
+// createExportJobRoute queues an export of the authenticated user's favourites.
+func createExportJobRoute(exporter *jobs.Exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		if exporter == nil {
+			respondWithError(w, http.StatusServiceUnavailable, "exports are not enabled")
+			return
+		}
+
+		job, err := exporter.Submit(userID, database.TenantFromContext(ctx), time.Now())
+		if err != nil {
+			if errors.Is(err, jobs.ErrFull) {
+				logging.Log(ctx).Layer("routes").Op("createExportJob").User(userID).Warn("export queue is full")
+				respondWithError(w, http.StatusServiceUnavailable, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("createExportJob").User(userID).Err(err).
+				Error("failed to queue export")
+			respondWithServerError(w, err)
+			return
+		}
+
+		resp := newExportJobResponse(apiPrefix(ctx), job)
+		logging.Log(ctx).Layer("routes").Op("createExportJob").User(userID).Str("job_id", job.ID).
+			Int("status_code", http.StatusAccepted).Info("export queued")
+		w.Header().Set("Location", resp.StatusURL)
+		respondWithJSON(w, http.StatusAccepted, resp)
+	}
+}
+
+// getExportJobRoute reports the status of one of the user's export jobs.
+func getExportJobRoute(exporter *jobs.Exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		if exporter == nil {
+			respondWithError(w, http.StatusServiceUnavailable, "exports are not enabled")
+			return
+		}
+
+		job, err := exporter.Get(userID, chi.URLParam(r, "jobID"))
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("getExportJob").User(userID).Str("job_id", job.ID).
+			Str("status", string(job.Status)).Int("status_code", http.StatusOK).Info("export job retrieved")
+		respondWithJSON(w, http.StatusOK, newExportJobResponse(apiPrefix(ctx), job))
+	}
+}
+
+// downloadExportRoute serves the file produced by a succeeded export job.
+func downloadExportRoute(exporter *jobs.Exporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		if exporter == nil {
+			respondWithError(w, http.StatusServiceUnavailable, "exports are not enabled")
+			return
+		}
+
+		f, job, err := exporter.Open(userID, chi.URLParam(r, "jobID"))
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			respondWithError(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, jobs.ErrNotReady):
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("%s (status %s)", err, job.Status))
+			return
+		case err != nil:
+			logging.Log(ctx).Layer("routes").Op("downloadExport").User(userID).Err(err).
+				Error("failed to open export")
+			respondWithServerError(w, err)
+			return
+		}
+		defer f.Close()
+
+		logging.Log(ctx).Layer("routes").Op("downloadExport").User(userID).Str("job_id", job.ID).
+			Int("count", job.Count).Info("export downloaded")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="favourites-%s.json"`, job.ID))
+		http.ServeContent(w, r, "", *job.CompletedAt, f)
+	}
+}
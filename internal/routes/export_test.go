@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/jobs"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+func setupExportHandler(t *testing.T) (*chi.Mux, *jobs.Exporter) {
+	t.Helper()
+	exporter, err := jobs.NewExporter(t.TempDir(), 10, time.Hour, func(_ context.Context, userID, _ string, w io.Writer) (int, error) {
+		_, err := io.WriteString(w, `[{"id":"c1","user_id":"`+userID+`"}]`)
+		return 1, err
+	})
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+
+	router := chi.NewRouter()
+	router.Use(logging.RequestLogger(testLogger()))
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth:    auth.AuthConfig{AllowUnsignedTokens: true},
+		Exports: exporter,
+	}))
+	return router, exporter
+}
+
+func exportRequest(method, path, userID string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	addAuthHeader(req, userID)
+	return req
+}
+
+func TestExportJobs_Lifecycle(t *testing.T) {
+	router, exporter := setupExportHandler(t)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, exportRequest(http.MethodPost, "/api/v1/favourites/export-jobs", "user1"))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created exportJobResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rr.Header().Get("Location") != created.StatusURL || created.StatusURL != "/api/v1/export-jobs/"+created.ID {
+		t.Errorf("unexpected status URL %q (Location %q)", created.StatusURL, rr.Header().Get("Location"))
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, exportRequest(http.MethodGet, created.StatusURL+"/download", "user1"))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 before the job has run, got %d", rr.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exporter.Run(ctx, 1, testLogger())
+
+	var status exportJobResponse
+	for deadline := time.Now().Add(2 * time.Second); status.Status != jobs.StatusSucceeded; {
+		if time.Now().After(deadline) {
+			t.Fatalf("export did not finish, last status %q", status.Status)
+		}
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, exportRequest(http.MethodGet, created.StatusURL, "user1"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		json.Unmarshal(rr.Body.Bytes(), &status)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if status.DownloadURL != created.StatusURL+"/download" {
+		t.Errorf("unexpected download URL %q", status.DownloadURL)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, exportRequest(http.MethodGet, status.DownloadURL, "user1"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("expected an attachment, got %q", rr.Header().Get("Content-Disposition"))
+	}
+	if rr.Body.String() != `[{"id":"c1","user_id":"user1"}]` {
+		t.Errorf("unexpected body %q", rr.Body.String())
+	}
+}
+
+func TestExportJobs_Errors(t *testing.T) {
+	router, exporter := setupExportHandler(t)
+	job, _ := exporter.Submit("user1", "", time.Now())
+
+	tests := []struct {
+		name   string
+		path   string
+		userID string
+		want   int
+	}{
+		{name: "unknown job", path: "/api/v1/export-jobs/nope", userID: "user1", want: http.StatusNotFound},
+		{name: "another user's job", path: "/api/v1/export-jobs/" + job.ID, userID: "user2", want: http.StatusNotFound},
+		{name: "another user's download", path: "/api/v1/export-jobs/" + job.ID + "/download", userID: "user2", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, exportRequest(http.MethodGet, tt.path, tt.userID))
+			if rr.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+
+	t.Run("503 when exports are not enabled", func(t *testing.T) {
+		router, _ := setupTestHandler(t)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, exportRequest(http.MethodPost, "/api/v1/favourites/export-jobs", "user1"))
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", rr.Code)
+		}
+	})
+}
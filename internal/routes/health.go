@@ -1,16 +1,22 @@
 package routes
 
 import (
+	"expvar"
 	"net/http"
 
+	"github.com/giannis84/platform-go-challenge/internal/buildinfo"
 	"github.com/giannis84/platform-go-challenge/internal/config"
-	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/health"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httprate"
 )
 
-// RegisterHealthRoutes creates the health check endpoints.
-func RegisterHealthRoutes(rateCfg config.RateLimitConfig) func(r chi.Router) {
+// RegisterHealthRoutes creates the health check and metrics endpoints.
+// /health/ready reports the health of each component registered in checks
+// as JSON; it answers 503 while a critical component is down. With
+// pprofCfg enabled, the runtime profiles are served under /debug/pprof/.
+func RegisterHealthRoutes(rateCfg config.RateLimitConfig, checks *health.Registry, pprofCfg config.PprofConfig) func(r chi.Router) {
 	return func(r chi.Router) {
 		// Apply IP-based rate limiting if configured
 		if rateCfg.Requests > 0 && rateCfg.Window > 0 {
@@ -19,6 +25,7 @@ func RegisterHealthRoutes(rateCfg config.RateLimitConfig) func(r chi.Router) {
 				rateCfg.Window,
 				httprate.WithKeyFuncs(httprate.KeyByIP),
 				httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+					logging.Security(r, logging.EventRateLimited).Info("rate limit exceeded")
 					w.WriteHeader(http.StatusTooManyRequests)
 					w.Write([]byte("rate limit exceeded"))
 				}),
@@ -31,13 +38,25 @@ func RegisterHealthRoutes(rateCfg config.RateLimitConfig) func(r chi.Router) {
 		})
 
 		r.Get("/health/ready", func(w http.ResponseWriter, r *http.Request) {
-			if err := database.PingDB(r.Context()); err != nil {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				w.Write([]byte("database not ready"))
-				return
+			report := checks.Check(r.Context())
+			code := http.StatusOK
+			if report.Status == health.StatusDown {
+				code = http.StatusServiceUnavailable
 			}
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("Ready"))
+			respondWithJSON(w, code, report)
+		})
+
+		// What is deployed, e.g. to tell instances apart during a rollout
+		r.Get("/version", func(w http.ResponseWriter, r *http.Request) {
+			respondWithJSON(w, http.StatusOK, buildinfo.Get())
 		})
+
+		// Runtime and service counters, such as which signing keys verified
+		// tokens, published by expvar.
+		r.Get("/debug/vars", expvar.Handler().ServeHTTP)
+
+		if pprofCfg.Enabled {
+			registerPprofRoutes(r, pprofCfg)
+		}
 	}
 }
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/buildinfo"
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/health"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHealthRoutes_Ready(t *testing.T) {
+	tests := []struct {
+		name       string
+		pingErr    error
+		queued     bool
+		critical   []string
+		wantCode   int
+		wantStatus string
+	}{
+		{name: "ok", critical: []string{"database"}, wantCode: http.StatusOK, wantStatus: "ok"},
+		{name: "database down", pingErr: errors.New("connection refused"), critical: []string{"database"}, wantCode: http.StatusServiceUnavailable, wantStatus: "down"},
+		{name: "queued writes degrade", queued: true, critical: []string{"database"}, wantCode: http.StatusOK, wantStatus: "degraded"},
+		{name: "queued writes critical", queued: true, critical: []string{"database", "write_queue"}, wantCode: http.StatusServiceUnavailable, wantStatus: "down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			if err != nil {
+				t.Fatalf("failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+			mock.ExpectPing().WillReturnError(tt.pingErr)
+			if tt.pingErr == nil {
+				mock.ExpectQuery("SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM schema_migrations").
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(8))
+			}
+
+			writeQueue, err := queue.Open(filepath.Join(t.TempDir(), "queue.json"), 10)
+			if err != nil {
+				t.Fatalf("failed to open write queue: %v", err)
+			}
+			if tt.queued {
+				if err := writeQueue.Enqueue(queue.Entry{ID: "q1", UserID: "user1", AssetID: "c1"}); err != nil {
+					t.Fatalf("failed to enqueue: %v", err)
+				}
+			}
+
+			checks := health.NewRegistry(tt.critical)
+			checks.Register("database", database.HealthCheck(db))
+			checks.Register("write_queue", writeQueue.HealthCheck)
+			router := chi.NewRouter()
+			router.Group(RegisterHealthRoutes(config.RateLimitConfig{}, checks, config.PprofConfig{}))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			var report health.Report
+			if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+				t.Fatalf("failed to decode report: %v", err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, report.Status)
+			}
+			dbCheck := report.Components["database"]
+			if (dbCheck.Status == health.StatusOK) != (tt.pingErr == nil) || !dbCheck.Critical || dbCheck.Details["pool"] == nil {
+				t.Errorf("unexpected database check: %+v", dbCheck)
+			}
+			if tt.pingErr == nil && dbCheck.Details["migration_version"] != float64(8) {
+				t.Errorf("expected migration version 8, got %v", dbCheck.Details["migration_version"])
+			}
+			if q := report.Components["write_queue"]; (q.Status == health.StatusOK) == tt.queued || q.Details["queue"] == nil {
+				t.Errorf("unexpected write queue check: %+v", q)
+			}
+			if _, ok := report.Components["replicas"]; ok {
+				t.Error("expected no replicas check when none are registered")
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestHealthRoutes_Version(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterHealthRoutes(config.RateLimitConfig{}, health.NewRegistry(nil), config.PprofConfig{}))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var got buildinfo.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode %q: %v", rec.Body.String(), err)
+	}
+	if got != buildinfo.Get() || got.Version == "" || got.Commit == "" || got.Date == "" {
+		t.Errorf("expected the build info %+v, got %s", buildinfo.Get(), rec.Body.String())
+	}
+}
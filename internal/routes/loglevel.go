@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// getLogLevelRoute reports the minimum level of the entries logged.
+func getLogLevelRoute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, handlers.CurrentLogLevel())
+	}
+}
+
+// setLogLevelRoute changes the minimum level of the entries logged, e.g. to
+// debug on a misbehaving instance, until changed again or restarted.
+func setLogLevelRoute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+
+		var req handlers.LogLevelRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("setLogLevel").User(adminID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		previous := handlers.CurrentLogLevel()
+		current, err := handlers.SetLogLevel(&req)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithServerError(w, err)
+			return
+		}
+
+		// Logged at warn so that the change is kept whatever the new level.
+		logging.Log(ctx).Layer("routes").Op("setLogLevel").User(adminID).
+			Str("previous_log_level", previous.Level).Str("log_level", current.Level).
+			Int("status_code", http.StatusOK).Warn("log level changed")
+		respondWithJSON(w, http.StatusOK, current)
+	}
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/authtest"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestAdminRoutes_LogLevel(t *testing.T) {
+	t.Cleanup(func() { logging.SetLevel(slog.LevelInfo) })
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth: auth.AuthConfig{
+			AllowUnsignedTokens: true,
+			AdminUsers:          []string{"admin1"},
+		},
+		Publisher: events.NewBus(),
+	}))
+
+	send := func(method, body, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/admin/log-level", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authtest.TokenFor(userID))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send("PUT", `{"level":"debug"}`, "user1"); rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for non-admin, got %d", rr.Code)
+	}
+	if rr := send("PUT", `{"level":"verbose"}`, "admin1"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown level, got %d", rr.Code)
+	}
+	if rr := send("PUT", `{"level":"debug"}`, "admin1"); rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"level":"DEBUG"`)) {
+		t.Fatalf("expected the level to be set to DEBUG, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if logging.Level() != slog.LevelDebug {
+		t.Errorf("expected the logger level to be DEBUG, got %v", logging.Level())
+	}
+	if rr := send("GET", "", "admin1"); rr.Code != http.StatusOK || !bytes.Contains(rr.Body.Bytes(), []byte(`"level":"DEBUG"`)) {
+		t.Errorf("expected DEBUG to be reported, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// registerPprofRoutes serves the net/http/pprof profiles under /debug/pprof/,
+// behind basic authentication when cfg names a user.
+func registerPprofRoutes(r chi.Router, cfg config.PprofConfig) {
+	r.Group(func(r chi.Router) {
+		if cfg.User != "" {
+			r.Use(basicAuthMiddleware(cfg.User, cfg.Password, "pprof"))
+		}
+		r.HandleFunc("/debug/pprof/*", pprof.Index)
+		r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		r.HandleFunc("/debug/pprof/profile", withoutWriteDeadline(pprof.Profile))
+		r.HandleFunc("/debug/pprof/trace", withoutWriteDeadline(pprof.Trace))
+	})
+}
+
+// withoutWriteDeadline lifts the server's write timeout for handlers that
+// take ?seconds= to answer, such as CPU profiles (30 seconds by default).
+func withoutWriteDeadline(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		next(w, r)
+	}
+}
+
+// basicAuthMiddleware requires HTTP basic authentication as user with
+// password; failures are security events.
+func basicAuthMiddleware(user, password, realm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, p, ok := r.BasicAuth()
+			userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+			passwordOK := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+			if !ok || !userOK || !passwordOK {
+				logging.Security(r, logging.EventAuthFailure).Info("basic authentication failed")
+				w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+				respondWithError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/health"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHealthRoutes_Pprof(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.PprofConfig
+		user     string
+		password string
+		wantCode int
+	}{
+		{name: "disabled", wantCode: http.StatusNotFound},
+		{name: "enabled", cfg: config.PprofConfig{Enabled: true}, wantCode: http.StatusOK},
+		{name: "missing credentials", cfg: config.PprofConfig{Enabled: true, User: "ops", Password: "s3cret"}, wantCode: http.StatusUnauthorized},
+		{name: "wrong password", cfg: config.PprofConfig{Enabled: true, User: "ops", Password: "s3cret"}, user: "ops", password: "guess", wantCode: http.StatusUnauthorized},
+		{name: "authenticated", cfg: config.PprofConfig{Enabled: true, User: "ops", Password: "s3cret"}, user: "ops", password: "s3cret", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.Group(RegisterHealthRoutes(config.RateLimitConfig{}, health.NewRegistry(nil), tt.cfg))
+
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/heap?debug=1", nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rr.Code)
+			}
+			if tt.wantCode == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
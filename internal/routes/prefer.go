@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Preferences are the RFC 7240 preferences a client sent in the Prefer header.
+// Preferences the service does not understand, or with invalid values, are ignored.
+type Preferences struct {
+	// Minimal is set by return=minimal: successful writes answer without a body.
+	Minimal bool
+	// Wait is set by wait=<seconds>: the request deadline is shortened to it.
+	Wait time.Duration
+	// Strict is set by handling=strict: request bodies with unknown fields are rejected.
+	Strict bool
+}
+
+const (
+	preferHeader            = "Prefer"
+	preferenceAppliedHeader = "Preference-Applied"
+)
+
+type preferencesKey struct{}
+
+// ParsePreferences parses every Prefer header value of h.
+func ParsePreferences(h http.Header) Preferences {
+	var p Preferences
+	for _, value := range h.Values(preferHeader) {
+		for _, item := range strings.Split(value, ",") {
+			// Preference parameters (after ';') are not used by any preference we support.
+			item, _, _ = strings.Cut(item, ";")
+			name, val, _ := strings.Cut(item, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			val = strings.Trim(strings.TrimSpace(val), `"`)
+
+			switch name {
+			case "return":
+				switch strings.ToLower(val) {
+				case "minimal":
+					p.Minimal = true
+				case "representation":
+					p.Minimal = false
+				}
+			case "wait":
+				if n, err := strconv.Atoi(val); err == nil && n > 0 {
+					p.Wait = time.Duration(n) * time.Second
+				}
+			case "handling":
+				switch strings.ToLower(val) {
+				case "strict":
+					p.Strict = true
+				case "lenient":
+					p.Strict = false
+				}
+			}
+		}
+	}
+	return p
+}
+
+// preferencesFromContext returns the preferences stored by preferMiddleware.
+func preferencesFromContext(ctx context.Context) Preferences {
+	p, _ := ctx.Value(preferencesKey{}).(Preferences)
+	return p
+}
+
+// preferMiddleware parses the Prefer header into the request context and,
+// for return=minimal on writes, strips the body of successful responses.
+func preferMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Values(preferHeader)) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		prefs := ParsePreferences(r.Header)
+		w.Header().Add("Vary", preferHeader)
+		if prefs.Minimal && r.Method != http.MethodGet {
+			w = &minimalResponseWriter{ResponseWriter: w}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), preferencesKey{}, prefs)))
+	})
+}
+
+// minimalResponseWriter drops the body of 2xx responses for return=minimal.
+type minimalResponseWriter struct {
+	http.ResponseWriter
+	discard bool
+}
+
+func (m *minimalResponseWriter) WriteHeader(code int) {
+	if code >= 200 && code < 300 {
+		m.discard = true
+		h := m.Header()
+		h.Del("Content-Type")
+		h.Add(preferenceAppliedHeader, "return=minimal")
+		if code == http.StatusOK {
+			code = http.StatusNoContent
+		}
+	}
+	m.ResponseWriter.WriteHeader(code)
+}
+
+func (m *minimalResponseWriter) Write(b []byte) (int, error) {
+	if m.discard {
+		return len(b), nil
+	}
+	return m.ResponseWriter.Write(b)
+}
+
+// decodeJSONBody decodes the request body into v. Under handling=strict,
+// fields v does not declare and trailing data are rejected instead of ignored.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v any) error {
+	return decodeBody(w, r, v, false)
+}
+
+// decodeStrictJSONBody decodes the request body into v, rejecting fields v
+// does not declare and trailing data unless lenient is set. A lenient decode
+// still honours handling=strict.
+func decodeStrictJSONBody(w http.ResponseWriter, r *http.Request, v any, lenient bool) error {
+	return decodeBody(w, r, v, !lenient)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any, strict bool) error {
+	dec := json.NewDecoder(r.Body)
+	if preferencesFromContext(r.Context()).Strict {
+		strict = true
+		w.Header().Add(preferenceAppliedHeader, "handling=strict")
+	}
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if strict && dec.More() {
+		return errors.New("unexpected data after JSON body")
+	}
+	return nil
+}
+
+// respondInvalidBody answers a body decodeJSONBody rejected: 413 when it
+// exceeded the body size limit, otherwise 400 naming an unknown field or
+// with a generic message.
+func respondInvalidBody(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body exceeds maximum size of %d bytes", tooLarge.Limit))
+		return
+	}
+	// encoding/json reports unknown fields only through the error text.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		respondWithError(w, http.StatusBadRequest, "unknown field "+field)
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, "Invalid request body")
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParsePreferences(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   Preferences
+	}{
+		{name: "none", want: Preferences{}},
+		{name: "single header", values: []string{"return=minimal, wait=5, handling=strict"}, want: Preferences{Minimal: true, Wait: 5 * time.Second, Strict: true}},
+		{name: "repeated headers", values: []string{"return=minimal", "handling=strict"}, want: Preferences{Minimal: true, Strict: true}},
+		{name: "case, quotes and parameters", values: []string{`Return="minimal"; foo=bar`}, want: Preferences{Minimal: true}},
+		{name: "later value wins", values: []string{"handling=strict, handling=lenient"}, want: Preferences{}},
+		{name: "invalid values ignored", values: []string{"wait=soon, return=everything, handling=loose, respond-async"}, want: Preferences{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for _, v := range tt.values {
+				h.Add("Prefer", v)
+			}
+			if got := ParsePreferences(h); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func putPreferences(router http.Handler, body, prefer string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PUT", "/api/v1/preferences", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPrefer_ReturnMinimal(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	mock.ExpectExec("INSERT INTO user_preferences").
+		WithArgs("user1", "UTC", sqlmock.AnyArg(), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rr := putPreferences(router, `{"timezone": "UTC"}`, "return=minimal")
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %s", rr.Body.String())
+	}
+	if got := rr.Header().Get("Preference-Applied"); got != "return=minimal" {
+		t.Errorf("expected Preference-Applied return=minimal, got %q", got)
+	}
+	if rr.Header().Get("Vary") != "Prefer" {
+		t.Errorf("expected Vary: Prefer, got %q", rr.Header().Get("Vary"))
+	}
+}
+
+func TestPrefer_ReturnMinimalKeepsErrors(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	rr := putPreferences(router, `{"timezone": "Mars/Olympus"}`, "return=minimal")
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rr.Code)
+	}
+	if rr.Body.Len() == 0 {
+		t.Error("expected the error body to be kept")
+	}
+	if rr.Header().Get("Preference-Applied") != "" {
+		t.Errorf("expected no Preference-Applied, got %q", rr.Header().Get("Preference-Applied"))
+	}
+}
+
+func TestPrefer_Handling(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefer   string
+		wantCode int
+	}{
+		{name: "lenient ignores unknown fields", prefer: "handling=lenient", wantCode: http.StatusOK},
+		{name: "strict rejects unknown fields", prefer: "handling=strict", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.wantCode == http.StatusOK {
+				mock.ExpectExec("INSERT INTO user_preferences").WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			rr := putPreferences(router, `{"timezone": "UTC", "theme": "dark"}`, tt.prefer)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrefer_WaitShortensDeadline(t *testing.T) {
+	var remaining time.Duration
+	handler := preferMiddleware(timeoutMiddleware(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		remaining = time.Until(deadline)
+	})))
+
+	for _, tt := range []struct {
+		prefer      string
+		wantMax     time.Duration
+		wantApplied string
+	}{
+		{prefer: "wait=2", wantMax: 2 * time.Second, wantApplied: "wait=2"},
+		{prefer: "wait=600", wantMax: time.Minute, wantApplied: ""},
+	} {
+		req := httptest.NewRequest("GET", "/", nil).WithContext(context.Background())
+		req.Header.Set("Prefer", tt.prefer)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if remaining <= 0 || remaining > tt.wantMax {
+			t.Errorf("%s: expected deadline within %v, got %v", tt.prefer, tt.wantMax, remaining)
+		}
+		if got := rr.Header().Get("Preference-Applied"); got != tt.wantApplied {
+			t.Errorf("%s: expected Preference-Applied %q, got %q", tt.prefer, tt.wantApplied, got)
+		}
+	}
+}
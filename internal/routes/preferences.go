@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// timezoneHeader lets a client override the timezone timestamps are rendered in.
+const timezoneHeader = "X-Timezone"
+
+func getUserPreferencesRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		prefs, err := h.GetPreferences(ctx, userID)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").Op("getUserPreferences").User(userID).Err(err).
+				Error("failed to get user preferences")
+			respondWithServerError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, prefs)
+	}
+}
+
+func updateUserPreferencesRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		var prefs handlers.UserPreferences
+		if err := decodeJSONBody(w, r, &prefs); err != nil {
+			logging.Log(ctx).Layer("routes").Op("updateUserPreferences").User(userID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("updateUserPreferences").User(userID).
+			Str("timezone", prefs.Timezone).Info("received update preferences request")
+
+		if err := h.UpdatePreferences(ctx, userID, &prefs); err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("updateUserPreferences").User(userID).Err(err).
+				Error("failed to update user preferences")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("updateUserPreferences").User(userID).
+			Int("status_code", http.StatusOK).Info("preferences updated successfully")
+		respondWithJSON(w, http.StatusOK, prefs)
+	}
+}
+
+// resolveLocation picks the timezone to render timestamps in for the request,
+// writing an error response and returning false when it cannot.
+func resolveLocation(h *handlers.Favourites, w http.ResponseWriter, r *http.Request, userID string) (*time.Location, bool) {
+	ctx := r.Context()
+
+	loc, err := h.ResolveLocation(ctx, userID, r.Header.Get(timezoneHeader))
+	if err != nil {
+		var validationErr *handlers.ValidationError
+		if errors.As(err, &validationErr) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		logging.Log(ctx).Layer("routes").User(userID).Err(err).
+			Error("failed to resolve timezone")
+		respondWithServerError(w, err)
+		return nil, false
+	}
+	return loc, true
+}
@@ -0,0 +1,128 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+func TestPreferencesRoutes_GetDefault(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	expectTimezone(mock, "user1", "")
+
+	req := httptest.NewRequest("GET", "/api/v1/preferences", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var prefs handlers.UserPreferences
+	json.Unmarshal(rr.Body.Bytes(), &prefs)
+	if prefs.Timezone != "UTC" {
+		t.Errorf("expected default timezone UTC, got %q", prefs.Timezone)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPreferencesRoutes_Update(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		wantCode int
+	}{
+		{"valid timezone", "Europe/Athens", http.StatusOK},
+		{"unknown timezone", "Mars/Olympus", http.StatusBadRequest},
+		{"empty timezone", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.wantCode == http.StatusOK {
+				mock.ExpectExec("INSERT INTO user_preferences").
+					WithArgs("user1", tt.timezone, sqlmock.AnyArg(), "").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			body, _ := json.Marshal(map[string]string{"timezone": tt.timezone})
+			req := httptest.NewRequest("PUT", "/api/v1/preferences", bytes.NewReader(body))
+			req.Header.Set("Accept", "application/json")
+			req.Header.Set("Content-Type", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestFavouritesRoutes_GetUserFavouritesLocalized(t *testing.T) {
+	created := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	insightData, _ := json.Marshal(models.Insight{ID: "insight1", Text: "text"})
+
+	tests := []struct {
+		name       string
+		header     string
+		stored     string
+		wantCode   int
+		wantOffset string
+	}{
+		{"stored preference", "", "Europe/Athens", http.StatusOK, "+02:00"},
+		{"header overrides preference", "America/New_York", "Europe/Athens", http.StatusOK, "-05:00"},
+		{"no preference", "", "", http.StatusOK, "Z"},
+		{"invalid header", "Not/AZone", "", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.header == "" {
+				expectTimezone(mock, "user1", tt.stored)
+			}
+			if tt.wantCode == http.StatusOK {
+				mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+					WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows(testCols).
+						AddRow("insight1", "user1", "insight", "desc", insightData, created, created, nil, nil, nil, nil, ""))
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/favourites", nil)
+			req.Header.Set("Accept", "application/json")
+			if tt.header != "" {
+				req.Header.Set(timezoneHeader, tt.header)
+			}
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantOffset != "" && !strings.Contains(rr.Body.String(), tt.wantOffset+`"`) {
+				t.Errorf("expected timestamps with offset %s, got %s", tt.wantOffset, rr.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
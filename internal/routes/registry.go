@@ -0,0 +1,409 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/adminui"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/cache"
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/jobs"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
+	"github.com/giannis84/platform-go-challenge/internal/stream"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+)
+
+// APIPrefix is where version 1 of the API is mounted. See Versions for the
+// other mounted versions.
+const APIPrefix = "/api/v1"
+
+// Scope is the access level a route requires.
+type Scope int
+
+const (
+	ScopeUser   Scope = iota // any authenticated user
+	ScopeAdmin               // users with auth.RoleAdmin (or listed in AuthConfig.AdminUsers)
+	ScopeSigned              // holders of a signed URL instead of a JWT
+)
+
+// RateClass selects the rate limits applied to a route.
+type RateClass int
+
+const (
+	// RateStandard applies the configured per-user limit shared by all routes.
+	RateStandard RateClass = iota
+	// RateBulk additionally applies a stricter per-user budget
+	// (1/bulkRateDivisor of the standard one) for expensive operations.
+	RateBulk
+)
+
+// bulkRateDivisor scales the standard limit down for RateBulk routes.
+const bulkRateDivisor = 10
+
+// TimeoutClass selects how long a route's request context stays valid.
+type TimeoutClass int
+
+const (
+	TimeoutStandard TimeoutClass = iota // single-row reads and writes
+	TimeoutExtended                     // bulk operations and reports
+	TimeoutStream                       // long-lived connections; no deadline
+)
+
+var timeouts = map[TimeoutClass]time.Duration{
+	TimeoutStandard: 5 * time.Second,
+	TimeoutExtended: 12 * time.Second,
+	TimeoutStream:   0,
+}
+
+// Route declares a single API endpoint. The Table of routes drives both the
+// router setup and the generated OpenAPI spec, so adding an endpoint is a
+// one-row change that picks up consistent middleware.
+type Route struct {
+	Method  string
+	Path    string // relative to the version prefix
+	Name    string // operation ID
+	Summary string
+	Scope   Scope
+	Rate    RateClass
+	Timeout TimeoutClass
+	Handler http.HandlerFunc
+}
+
+// TokenScope is the token scope a ScopeUser route needs when scopes are
+// required: reading for GET and HEAD, writing for every other method.
+func (r Route) TokenScope() string {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return auth.ScopeFavouritesRead
+	}
+	return auth.ScopeFavouritesWrite
+}
+
+// Deps holds the dependencies shared by the API routes.
+type Deps struct {
+	Auth      auth.AuthConfig
+	RateLimit config.RateLimitConfig
+	// Favourites stores the favourites the routes read and change.
+	Favourites database.FavouritesRepository
+	// Store keeps asset versions, audit trails, preferences, API keys and
+	// the catalog; the routes needing it answer 503 when nil.
+	Store database.Store
+	// EventOutbox records the events of changes in the event outbox of their
+	// transaction, relayed to the Publisher once committed, rather than
+	// publishing them directly.
+	EventOutbox bool
+	// Publisher receives an event for every change to a user's favourites.
+	Publisher events.Publisher
+	// Quotas caps how many favourites of each asset type a user may add.
+	Quotas handlers.QuotaConfig
+	// MaxAssetDataBytes caps the asset_data of a new favourite (0 = no limit).
+	MaxAssetDataBytes int
+	// MaxBodyBytes caps every request body (0 = no limit).
+	MaxBodyBytes int
+	// LenientJSON lets the add and update favourite bodies carry fields the
+	// service does not know, which are rejected with 400 otherwise.
+	LenientJSON bool
+	// ListCache serves favourites lists when non-nil; it is up to the caller
+	// to invalidate it from the published events.
+	ListCache *cache.ListCache
+	// WriteQueue, when non-nil, holds new favourites that cannot reach the
+	// database for later replay instead of failing.
+	WriteQueue *queue.WriteQueue
+	// RevocationTTL is how long a revoked token is denied when the request
+	// does not give the token's expiry.
+	RevocationTTL time.Duration
+	// AdminUI serves the embedded admin web UI at /admin when true.
+	AdminUI bool
+	// Streams serves favourite change events over WebSocket; the endpoint
+	// answers 503 when nil.
+	Streams *stream.Hub
+	// Exports runs asynchronous export jobs; the export endpoints answer 503
+	// when nil.
+	Exports *jobs.Exporter
+	// CORS allows browsers on the configured origins to call the API.
+	CORS config.CORSConfig
+	// V1Sunset, when set, is announced as the date /api/v1 stops being served.
+	V1Sunset time.Time
+	// MultiTenant confines user and admin routes to the tenant of the
+	// principal (see tenantMiddleware); signed URLs keep reaching the
+	// favourites of the user who granted them.
+	MultiTenant bool
+	// LogBodies selects the routes whose bodies are logged at debug level
+	// (see logging.BodyLogger).
+	LogBodies config.BodyLogConfig
+	// ReportError, when non-nil, is told about the 5xx responses of the
+	// routes.
+	ReportError ErrorReporter
+}
+
+// Table lists every API route. Handlers are built from d; callers that only
+// need the declarations (e.g. the spec generator) may pass a zero Deps.
+func Table(d Deps) []Route {
+	h := handlers.NewFavourites(d.Favourites, d.Store, d.EventOutbox)
+	return []Route{
+		{http.MethodGet, "/favourites", "getUserFavourites", "List user favourites", ScopeUser, RateStandard, TimeoutStandard, getUserFavouritesRoute(h, d.ListCache)},
+		{http.MethodPost, "/favourites", "addUserFavourite", "Add a favourite", ScopeUser, RateStandard, TimeoutStandard, addUserFavouriteRoute(h, d.Quotas, d.MaxAssetDataBytes, d.LenientJSON, d.Publisher, d.WriteQueue)},
+		{http.MethodPatch, "/favourites", "batchUpdateUserFavourites", "Batch update favourite descriptions", ScopeUser, RateBulk, TimeoutExtended, batchUpdateUserFavouritesRoute(h, d.Publisher)},
+		{http.MethodPost, "/favourites/import", "importUserFavourites", "Import favourites", ScopeUser, RateBulk, TimeoutExtended, importUserFavouritesRoute(h, d.Quotas, d.MaxAssetDataBytes, d.LenientJSON, d.Publisher)},
+		{http.MethodDelete, "/favourites", "removeAllUserFavourites", "Remove all favourites", ScopeUser, RateBulk, TimeoutExtended, removeAllUserFavouritesRoute(h, d.Publisher)},
+		{http.MethodGet, "/favourites/recent", "getRecentUserFavourites", "List recently added or updated favourites", ScopeUser, RateStandard, TimeoutStandard, getRecentUserFavouritesRoute(h)},
+		{http.MethodPost, "/favourites/share", "createShareLink", "Issue a signed read-only URL", ScopeUser, RateStandard, TimeoutStandard, createShareLinkRoute(d.Auth.SignedURLSecret)},
+		{http.MethodGet, sharedFavouritesPath, "getSharedFavourites", "List favourites shared through a signed URL", ScopeSigned, RateStandard, TimeoutStandard, getSharedFavouritesRoute(h, d.ListCache)},
+		{http.MethodPost, "/favourites/export-jobs", "createExportJob", "Start an asynchronous export", ScopeUser, RateBulk, TimeoutStandard, createExportJobRoute(d.Exports)},
+		{http.MethodGet, exportJobPath + "{jobID}", "getExportJob", "Get export job status", ScopeUser, RateStandard, TimeoutStandard, getExportJobRoute(d.Exports)},
+		{http.MethodGet, exportJobPath + "{jobID}/download", "downloadExport", "Download a finished export", ScopeUser, RateStandard, TimeoutExtended, downloadExportRoute(d.Exports)},
+		{http.MethodGet, "/favourites/quota", "getUserQuota", "Get quota usage", ScopeUser, RateStandard, TimeoutStandard, getUserQuotaRoute(h, d.Quotas)},
+		{http.MethodGet, "/favourites/stats", "getUserStats", "Get favourites statistics", ScopeUser, RateStandard, TimeoutStandard, getUserStatsRoute(h)},
+		{http.MethodGet, "/favourites/audit", "getUserAudit", "Get audit trail", ScopeUser, RateStandard, TimeoutExtended, needsStore(d.Store, "audit trails", getUserAuditRoute(h))},
+		{http.MethodHead, "/favourites/{assetID}", "favouriteExists", "Check whether a favourite exists", ScopeUser, RateStandard, TimeoutStandard, favouriteExistsRoute(h)},
+		{http.MethodPatch, "/favourites/{assetID}", "updateUserFavourite", "Update favourite description", ScopeUser, RateStandard, TimeoutStandard, updateUserFavouriteRoute(h, d.LenientJSON, d.Publisher)},
+		{http.MethodPut, "/favourites/{assetID}", "replaceFavouriteAssetData", "Replace favourite asset data", ScopeUser, RateStandard, TimeoutStandard, needsStore(d.Store, "asset versions", replaceFavouriteAssetDataRoute(h, d.MaxAssetDataBytes, d.Publisher))},
+		{http.MethodGet, "/favourites/{assetID}/versions", "getFavouriteVersions", "List earlier asset data versions", ScopeUser, RateStandard, TimeoutStandard, needsStore(d.Store, "asset versions", getFavouriteVersionsRoute(h))},
+		{http.MethodPost, "/favourites/{assetID}/versions/{version}/revert", "revertFavouriteVersion", "Revert asset data to an earlier version", ScopeUser, RateStandard, TimeoutStandard, needsStore(d.Store, "asset versions", revertFavouriteVersionRoute(h, d.Publisher))},
+		{http.MethodDelete, "/favourites/{assetID}", "removeUserFavourite", "Remove a favourite", ScopeUser, RateStandard, TimeoutStandard, removeUserFavouriteRoute(h, d.Publisher)},
+		{http.MethodGet, "/ws", "subscribeEvents", "Stream favourite change events over a WebSocket", ScopeUser, RateStandard, TimeoutStream, subscribeEventsRoute(d.Streams)},
+		{http.MethodGet, "/preferences", "getUserPreferences", "Get user preferences", ScopeUser, RateStandard, TimeoutStandard, needsStore(d.Store, "preferences", getUserPreferencesRoute(h))},
+		{http.MethodPut, "/preferences", "updateUserPreferences", "Update user preferences", ScopeUser, RateStandard, TimeoutStandard, needsStore(d.Store, "preferences", updateUserPreferencesRoute(h))},
+		{http.MethodPost, "/admin/assets/ownership", "assetOwnership", "Report (and optionally remove) asset ownership", ScopeAdmin, RateBulk, TimeoutExtended, needsStore(d.Store, "ownership reports", assetOwnershipRoute(h, d.Publisher))},
+		{http.MethodPut, "/admin/assets/{assetType}/{assetID}", "updateCatalogAsset", "Update an asset in the catalog", ScopeAdmin, RateStandard, TimeoutExtended, needsStore(d.Store, "catalog updates", updateCatalogAssetRoute(h, d.MaxAssetDataBytes, d.Publisher))},
+		{http.MethodGet, "/admin/users", "searchUsers", "Search users with favourites", ScopeAdmin, RateStandard, TimeoutStandard, needsStore(d.Store, "user searches", searchUsersRoute(h))},
+		{http.MethodGet, "/admin/users/{userID}/favourites", "getAdminUserFavourites", "Get a user's favourites", ScopeAdmin, RateStandard, TimeoutStandard, getAdminUserFavouritesRoute(h)},
+		{http.MethodPost, "/admin/users/{userID}/merge", "mergeUserFavourites", "Merge another user's favourites into a user's", ScopeAdmin, RateBulk, TimeoutExtended, needsStore(d.Store, "user merges", mergeUserFavouritesRoute(h, d.Publisher))},
+		{http.MethodGet, "/admin/users/{userID}/audit", "getAdminUserAudit", "Get a user's audit trail", ScopeAdmin, RateStandard, TimeoutExtended, needsStore(d.Store, "audit trails", getAdminUserAuditRoute(h))},
+		{http.MethodGet, "/admin/users/{userID}/api-keys", "listAPIKeys", "List a user's API keys", ScopeAdmin, RateStandard, TimeoutStandard, needsStore(d.Store, "API keys", listAPIKeysRoute(h))},
+		{http.MethodPost, "/admin/users/{userID}/api-keys", "createAPIKey", "Issue an API key bound to a user", ScopeAdmin, RateStandard, TimeoutStandard, needsStore(d.Store, "API keys", createAPIKeyRoute(h))},
+		{http.MethodDelete, "/admin/users/{userID}/api-keys/{keyID}", "revokeAPIKey", "Revoke an API key", ScopeAdmin, RateStandard, TimeoutStandard, needsStore(d.Store, "API keys", revokeAPIKeyRoute(h))},
+		{http.MethodPost, "/admin/token-revocations", "revokeToken", "Revoke a token by its ID", ScopeAdmin, RateStandard, TimeoutStandard, revokeTokenRoute(d.Auth.Denylist, d.RevocationTTL)},
+		{http.MethodGet, "/admin/queue", "getWriteQueueStats", "Get write queue depth", ScopeAdmin, RateStandard, TimeoutStandard, writeQueueStatsRoute(d.WriteQueue)},
+		{http.MethodGet, "/admin/log-level", "getLogLevel", "Get the log level", ScopeAdmin, RateStandard, TimeoutStandard, getLogLevelRoute()},
+		{http.MethodPut, "/admin/log-level", "setLogLevel", "Change the log level at runtime", ScopeAdmin, RateStandard, TimeoutStandard, setLogLevelRoute()},
+	}
+}
+
+// needsStore answers 503 in place of next when the service runs without a
+// Store, which next needs for what it serves.
+func needsStore(store database.Store, what string, next http.HandlerFunc) http.HandlerFunc {
+	if store != nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		respondWithError(w, http.StatusServiceUnavailable, what+" are not enabled")
+	}
+}
+
+// RegisterFavouritesRoutes mounts the routes of the Table under the prefix of
+// each API version that serves them (see Versions); all versions share the
+// same handlers.
+// HTTP concerns are handled here, while business logic is delegated to the handlers package.
+// All routes require a valid JWT or API key (or, for ScopeSigned, a signed URL), JSON
+// Accept/Content-Type headers and the standard rate limit, or that of the
+// first config.RateLimitPolicy matching them, and honour the Prefer header;
+// scope, rate and timeout classes add per-route middleware.
+// With AuthConfig.RequireScopes, user routes also need the favourites:read
+// (GET) or favourites:write (other methods) token scope. Service principals
+// name the user they act for on user routes (see auth.ActOnBehalf) and get
+// their own, larger rate limit budgets; admins may impersonate users there
+// with the X-On-Behalf-Of header. Requests authenticated by the session
+// cookie must pass the CSRF check (see auth.CSRFMiddleware).
+// With Deps.MultiTenant, user and admin routes only reach the favourites of
+// the principal's tenant, within the tenant's rate limit budget if any.
+// With AuthConfig.DevTokens, POST /dev/token mints unsigned development
+// tokens without authentication.
+// CORS runs first, so preflights are answered before authentication and
+// cross-origin callers can read error responses too. Routes selected by
+// Deps.LogBodies log their bodies once the caller is authenticated.
+// Server errors answered by the handlers are passed to Deps.ReportError.
+func RegisterFavouritesRoutes(d Deps) func(r chi.Router) {
+	return func(r chi.Router) {
+		authenticate := map[Scope]func(http.Handler) http.Handler{
+			ScopeUser:   auth.JWTMiddleware(d.Auth),
+			ScopeAdmin:  auth.JWTMiddleware(d.Auth),
+			ScopeSigned: auth.SignedURLMiddleware(d.Auth.SignedURLSecret),
+		}
+
+		// Limiters are shared by every route of a class or policy, across
+		// versions, so a single budget covers all of them.
+		var standardLimiter, bulkLimiter func(http.Handler) http.Handler
+		if rl := d.RateLimit; rl.Requests > 0 && rl.Window > 0 {
+			standardLimiter = rateLimit(rl.Requests, rl.ServiceRequests, rl.Window)
+			bulkLimiter = rateLimit(max(rl.Requests/bulkRateDivisor, 1), max(rl.ServiceRequests/bulkRateDivisor, 1), rl.Window)
+		}
+		policyLimiters := make([]func(http.Handler) http.Handler, len(d.RateLimit.Policies))
+		for i, p := range d.RateLimit.Policies {
+			policyLimiters[i] = rateLimit(p.Requests, p.ServiceRequests, p.Window)
+		}
+		var tenantLimiter func(http.Handler) http.Handler
+		if rl := d.RateLimit; d.MultiTenant && rl.TenantRequests > 0 && rl.Window > 0 {
+			tenantLimiter = perTenantRateLimit(rl.TenantRequests, rl.Window)
+		}
+		limiterFor := func(route Route) func(http.Handler) http.Handler {
+			for i, p := range d.RateLimit.Policies {
+				if p.Matches(route.Method, route.Path) {
+					return policyLimiters[i]
+				}
+			}
+			return standardLimiter
+		}
+
+		logBodies := logging.BodyLogger(d.LogBodies.MaxBytes)
+		logsBodies := func(route Route) bool {
+			return slices.ContainsFunc(d.LogBodies.Routes, func(p config.RoutePattern) bool {
+				return p.Matches(route.Method, route.Path)
+			})
+		}
+
+		cors := corsMiddleware(d.CORS)
+		bodyLimit := maxBodyMiddleware(d.MaxBodyBytes)
+		csrf := auth.CSRFMiddleware(d.Auth)
+		reportErrors := errorReportingMiddleware(d.ReportError)
+		table := Table(d)
+		for _, v := range Versions(d) {
+			r.Route(v.Prefix, func(r chi.Router) {
+				r.Use(cors)
+				if v.Enveloped {
+					r.Use(envelopeMiddleware)
+				}
+				if v.Deprecation != nil {
+					r.Use(deprecationMiddleware(v))
+				}
+				r.Use(versionMiddleware(v))
+
+				for _, route := range table {
+					if !v.Serves(route) {
+						continue
+					}
+					mws := []func(http.Handler) http.Handler{authenticate[route.Scope]}
+					if logsBodies(route) {
+						mws = append(mws, logBodies)
+					}
+					if d.MultiTenant && route.Scope != ScopeSigned {
+						mws = append(mws, tenantMiddleware)
+						if tenantLimiter != nil {
+							mws = append(mws, tenantLimiter)
+						}
+					}
+					if limiter := limiterFor(route); limiter != nil {
+						mws = append(mws, limiter)
+					}
+					mws = append(mws, acceptJSONMiddleware, contentTypeJSONMiddleware, bodyLimit, preferMiddleware)
+					switch route.Scope {
+					case ScopeUser:
+						mws = append(mws, csrf, auth.RequireScope(d.Auth, route.TokenScope()), auth.ActOnBehalf, actorMiddleware)
+					case ScopeAdmin:
+						mws = append(mws, csrf, auth.RequireRole(auth.RoleAdmin))
+					}
+					if route.Rate == RateBulk && bulkLimiter != nil {
+						mws = append(mws, bulkLimiter)
+					}
+					mws = append(mws, timeoutMiddleware(timeouts[route.Timeout]), reportErrors)
+					r.With(mws...).Method(route.Method, route.Path, route.Handler)
+				}
+
+				// Anyone may mint a token here, so it only exists in
+				// development, with unsigned tokens allowed and no signing key.
+				if d.Auth.DevTokens() && !v.Frozen() {
+					r.With(acceptJSONMiddleware, contentTypeJSONMiddleware, bodyLimit, timeoutMiddleware(timeouts[TimeoutStandard])).
+						Post(devTokenPath, devTokenRoute(d.Auth))
+				}
+			})
+		}
+
+		// The UI's static assets are public; its data comes from the admin
+		// routes above, which enforce the admin scope.
+		if d.AdminUI {
+			r.Get("/admin", http.RedirectHandler("/admin/", http.StatusMovedPermanently).ServeHTTP)
+			r.Handle("/admin/*", http.StripPrefix("/admin", adminui.Handler()))
+		}
+	}
+}
+
+// rateLimit limits end users to userRequests per window (see
+// perUserRateLimit) and service principals, which act for many users, to
+// serviceRequests per service.
+func rateLimit(userRequests, serviceRequests int, window time.Duration) func(http.Handler) http.Handler {
+	users := perUserRateLimit(userRequests, window)
+	services := httprate.Limit(
+		serviceRequests,
+		window,
+		httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
+			return "service:" + auth.ServiceFromContext(r.Context()), nil
+		}),
+		httprate.WithLimitHandler(rateLimited),
+	)
+	return func(next http.Handler) http.Handler {
+		userLimited, serviceLimited := users(next), services(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.ServiceFromContext(r.Context()) != "" {
+				serviceLimited.ServeHTTP(w, r)
+				return
+			}
+			userLimited.ServeHTTP(w, r)
+		})
+	}
+}
+
+// perUserRateLimit limits requests per user (keyed by JWT sub claim, or by the
+// granting user for signed URLs) to requests per window.
+func perUserRateLimit(requests int, window time.Duration) func(http.Handler) http.Handler {
+	return httprate.Limit(
+		requests,
+		window,
+		httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
+			if grant, ok := auth.GrantFromContext(r.Context()); ok {
+				// Widgets get their own budget rather than the user's.
+				return "signed:" + grant.UserID, nil
+			}
+			return auth.UserIDFromContext(r.Context()), nil
+		}),
+		httprate.WithLimitHandler(rateLimited),
+	)
+}
+
+// rateLimited refuses a request over its rate limit, recording a security
+// event.
+func rateLimited(w http.ResponseWriter, r *http.Request) {
+	logging.Security(r, logging.EventRateLimited).User(auth.UserIDFromContext(r.Context())).Info("rate limit exceeded")
+	respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+}
+
+// actorMiddleware makes the admin or service acting on behalf of the request's
+// user (see auth.ActOnBehalf) the actor of the events it publishes, so the
+// audit trail records both identities.
+func actorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := auth.ActorFromContext(r.Context()); actor != "" {
+			r = r.WithContext(events.WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timeoutMiddleware bounds the request context, so database calls made on its
+// behalf are cancelled once d has elapsed. A shorter Prefer: wait=<seconds>
+// takes precedence; a longer one cannot extend d. A zero d leaves the context
+// unbounded.
+func timeoutMiddleware(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := d
+			if wait := preferencesFromContext(r.Context()).Wait; wait > 0 && wait < d {
+				timeout = wait
+				w.Header().Add(preferenceAppliedHeader, "wait="+strconv.Itoa(int(wait/time.Second)))
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/authtest"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTable_EveryRouteIsRegistered(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	registered := map[string]bool{}
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+strings.TrimSuffix(route, "/")] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, route := range Table(Deps{}) {
+		if names[route.Name] {
+			t.Errorf("duplicate route name %q", route.Name)
+		}
+		names[route.Name] = true
+
+		for _, v := range Versions(Deps{}) {
+			if registered[route.Method+" "+v.Prefix+route.Path] != v.Serves(route) {
+				t.Errorf("%s %s: registered in %s = %v, want %v", route.Method, route.Path, v.Name, !v.Serves(route), v.Serves(route))
+			}
+		}
+	}
+	for _, v := range Versions(Deps{}) {
+		for _, name := range v.Operations {
+			if !names[name] {
+				t.Errorf("%s serves %q, which is not in the table", v.Name, name)
+			}
+		}
+	}
+}
+
+func TestVersions_V1IsFrozen(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	// Routes added after v2 was introduced are not served by v1
+	req := httptest.NewRequest(http.MethodGet, APIPrefix+"/admin/log-level", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a v2-only route under v1, got %d", rr.Code)
+	}
+}
+
+func TestTable_AdminScopeIsEnforced(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	for _, route := range Table(Deps{}) {
+		if route.Scope != ScopeAdmin {
+			continue
+		}
+		t.Run(route.Name, func(t *testing.T) {
+			path := strings.NewReplacer("{userID}", "user2", "{assetID}", "c1").Replace(route.Path)
+			req := httptest.NewRequest(route.Method, APIV2Prefix+path, bytes.NewBufferString("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusForbidden {
+				t.Errorf("expected status 403 for non-admin, got %d", rr.Code)
+			}
+		})
+	}
+}
+
+func TestRegisterFavouritesRoutes_BulkRateClass(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth:      auth.AuthConfig{AllowUnsignedTokens: true},
+		RateLimit: config.RateLimitConfig{Requests: 10, Window: time.Minute},
+		Publisher: events.NewBus(),
+	}))
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		addAuthHeader(req, "user1")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Without confirm=true remove-all is rejected before reaching the database,
+	// but the request still counts against the bulk budget of 10/10 = 1.
+	if code := send("DELETE", "/api/v1/favourites", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected first bulk request to reach the handler, got %d", code)
+	}
+	if code := send("DELETE", "/api/v1/favourites", ""); code != http.StatusTooManyRequests {
+		t.Errorf("expected second bulk request to be rate limited, got %d", code)
+	}
+
+	// Standard routes keep the full budget.
+	if code := send("PATCH", "/api/v1/favourites/c1", "{"); code != http.StatusBadRequest {
+		t.Errorf("expected standard route to be unaffected by the bulk limit, got %d", code)
+	}
+}
+
+func TestRegisterFavouritesRoutes_WithoutStore(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth:      auth.AuthConfig{AllowUnsignedTokens: true},
+		Publisher: events.NewBus(),
+	}))
+
+	for _, path := range []string{"/api/v1/preferences", "/api/v1/favourites/audit", "/api/v2/favourites/c1/versions"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", "application/json")
+		addAuthHeader(req, "user1")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "not enabled") {
+			t.Errorf("GET %s: expected 503 without a store, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestRegisterFavouritesRoutes_RateLimitPolicies(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth: auth.AuthConfig{AllowUnsignedTokens: true},
+		RateLimit: config.RateLimitConfig{Requests: 1, ServiceRequests: 10, Window: time.Minute, Policies: []config.RateLimitPolicy{
+			{Method: "GET", Requests: 2, ServiceRequests: 20, Window: time.Minute},
+			{Path: "/favourites/*", Requests: 3, ServiceRequests: 30, Window: time.Minute},
+		}},
+		Publisher: events.NewBus(),
+	}))
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		addAuthHeader(req, "user1")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Without an export runner the job lookup answers 503, and a malformed
+	// body 400, before any database access.
+	for i := 0; i < 2; i++ {
+		if code := send("GET", "/api/v1/export-jobs/j1", ""); code != http.StatusServiceUnavailable {
+			t.Fatalf("expected GET %d to pass the read policy, got %d", i+1, code)
+		}
+	}
+	if code := send("GET", "/api/v2/export-jobs/j1", ""); code != http.StatusTooManyRequests {
+		t.Errorf("expected the read budget to be shared across versions, got %d", code)
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := send("PATCH", "/api/v1/favourites/c1", "{"); code != http.StatusBadRequest {
+			t.Fatalf("expected PATCH %d to pass the path policy, got %d", i+1, code)
+		}
+	}
+	if code := send("PATCH", "/api/v1/favourites/c2", "{"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the path policy budget to be exhausted, got %d", code)
+	}
+
+	// Routes no policy matches keep the global budget.
+	if code := send("DELETE", "/api/v1/favourites", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected the first unmatched request to pass, got %d", code)
+	}
+	var security bytes.Buffer
+	logging.SetSecurityLog(&security)
+	t.Cleanup(func() { logging.SetSecurityLog(nil) })
+	if code := send("DELETE", "/api/v1/favourites", ""); code != http.StatusTooManyRequests {
+		t.Errorf("expected the global budget of 1 to be exhausted, got %d", code)
+	}
+	if !strings.Contains(security.String(), `"event_code":"RATE_LIMITED"`) || !strings.Contains(security.String(), `"user_id":"user1"`) {
+		t.Errorf("expected a rate limit security event, got %s", security.String())
+	}
+}
+
+func TestRegisterFavouritesRoutes_TokenScopes(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth:      auth.AuthConfig{AllowUnsignedTokens: true, RequireScopes: true},
+		Publisher: events.NewBus(),
+	}))
+
+	send := func(method, path string, claims jwt.MapClaims) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authtest.TokenFor("user1", claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Without an export runner the job lookup answers 503 once authorized, and
+	// remove-all without confirm=true answers 400, both before any database
+	// access.
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{name: "read with read scope", method: "GET", path: "/api/v1/export-jobs/j1", claims: jwt.MapClaims{"scope": "favourites:read"}, want: http.StatusServiceUnavailable},
+		{name: "read with permissions claim", method: "GET", path: "/api/v1/export-jobs/j1", claims: jwt.MapClaims{"permissions": []string{"favourites:read"}}, want: http.StatusServiceUnavailable},
+		{name: "read with write scope only", method: "GET", path: "/api/v1/export-jobs/j1", claims: jwt.MapClaims{"scope": "favourites:write"}, want: http.StatusForbidden},
+		{name: "read without scopes", method: "GET", path: "/api/v1/export-jobs/j1", claims: jwt.MapClaims{}, want: http.StatusForbidden},
+		{name: "write with write scope", method: "DELETE", path: "/api/v1/favourites", claims: jwt.MapClaims{"scope": "openid favourites:write"}, want: http.StatusBadRequest},
+		{name: "write with read scope only", method: "DELETE", path: "/api/v1/favourites", claims: jwt.MapClaims{"scope": "favourites:read"}, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := send(tt.method, tt.path, tt.claims); code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestRegisterFavouritesRoutes_ServicePrincipals(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth:      auth.AuthConfig{AllowUnsignedTokens: true, ServiceClaim: "gty=client-credentials"},
+		RateLimit: config.RateLimitConfig{Requests: 1, ServiceRequests: 3, Window: time.Minute},
+		Publisher: events.NewBus(),
+	}))
+
+	send := func(sub, query string, claims jwt.MapClaims) int {
+		req := httptest.NewRequest("GET", "/api/v1/export-jobs/j1"+query, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authtest.TokenFor(sub, claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	service := func() jwt.MapClaims { return jwt.MapClaims{"gty": "client-credentials"} }
+
+	// Without an export runner the job lookup answers 503 once a request gets
+	// past authentication, before any database access.
+	for _, user := range []string{"user1", "user2"} {
+		if code := send("batch-job", "?user_id="+user, service()); code != http.StatusServiceUnavailable {
+			t.Errorf("expected the service to act for %s, got %d", user, code)
+		}
+	}
+	if code := send("batch-job", "", service()); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a service request without user_id, got %d", code)
+	}
+	// The service budget of 3 is shared by every user it acts for.
+	if code := send("batch-job", "?user_id=user3", service()); code != http.StatusTooManyRequests {
+		t.Errorf("expected the service budget to be exhausted, got %d", code)
+	}
+
+	// End users keep their own budget, unaffected by the service's.
+	if code := send("user1", "?user_id=user2", jwt.MapClaims{}); code != http.StatusForbidden {
+		t.Errorf("expected 403 for a user naming another user, got %d", code)
+	}
+	if code := send("user2", "", jwt.MapClaims{}); code != http.StatusServiceUnavailable {
+		t.Errorf("expected the user request to pass, got %d", code)
+	}
+	if code := send("user2", "", jwt.MapClaims{}); code != http.StatusTooManyRequests {
+		t.Errorf("expected the user budget of 1 to be exhausted, got %d", code)
+	}
+}
+
+func TestTimeoutMiddleware_SetsDeadline(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	handler := timeoutMiddleware(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	}))
+
+	start := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(context.Background()))
+
+	if !ok {
+		t.Fatal("expected request context to have a deadline")
+	}
+	if d := deadline.Sub(start); d < 59*time.Second || d > time.Minute+time.Second {
+		t.Errorf("expected deadline about a minute away, got %v", d)
+	}
+}
+
+func TestTimeoutMiddleware_ZeroLeavesContextUnbounded(t *testing.T) {
+	var ok bool
+	handler := timeoutMiddleware(timeouts[TimeoutStream])(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok = r.Context().Deadline()
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(context.Background()))
+
+	if ok {
+		t.Error("expected no deadline for streaming routes")
+	}
+}
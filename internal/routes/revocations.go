@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// revokeTokenRoute adds a token ID to the denylist, so JWTMiddleware rejects
+// the token from then on. It answers 503 when revocation is not enabled.
+func revokeTokenRoute(denylist auth.Denylist, defaultTTL time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		adminID := auth.UserIDFromContext(ctx)
+		if denylist == nil {
+			respondWithError(w, http.StatusServiceUnavailable, "token revocation is not enabled")
+			return
+		}
+
+		var req handlers.RevokeTokenRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("revokeToken").User(adminID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		revocation, err := handlers.RevokeToken(ctx, denylist, &req, defaultTTL)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("revokeToken").User(adminID).Str("jti", req.JTI).Err(err).
+				Error("failed to revoke token")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("revokeToken").User(adminID).Str("jti", revocation.JTI).
+			Str("revoked_until", revocation.RevokedUntil.Format(time.RFC3339)).
+			Int("status_code", http.StatusCreated).Info("token revoked")
+		respondWithJSON(w, http.StatusCreated, revocation)
+	}
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/authtest"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAdminRoutes_RevokeToken(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth: auth.AuthConfig{
+			AllowUnsignedTokens: true,
+			AdminUsers:          []string{"admin1"},
+			Denylist:            auth.NewMemoryDenylist(),
+		},
+		Publisher:     events.NewBus(),
+		RevocationTTL: time.Hour,
+	}))
+
+	send := func(method, path, body string, claims jwt.MapClaims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authtest.TokenFor(claims["sub"].(string), claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	admin := func() jwt.MapClaims { return jwt.MapClaims{"sub": "admin1"} }
+
+	if rr := send("POST", "/api/v2/admin/token-revocations", `{"jti":"stolen"}`, jwt.MapClaims{"sub": "user1"}); rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for non-admin, got %d", rr.Code)
+	}
+	if rr := send("POST", "/api/v2/admin/token-revocations", `{}`, admin()); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a jti, got %d", rr.Code)
+	}
+	if rr := send("POST", "/api/v2/admin/token-revocations", `{"jti":"stolen","expires_at":"2000-01-01T00:00:00Z"}`, admin()); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a past expiry, got %d", rr.Code)
+	}
+	if rr := send("POST", "/api/v2/admin/token-revocations", `{"jti":"stolen"}`, admin()); rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Remove-all without confirm=true answers 400 once authenticated.
+	if rr := send("DELETE", "/api/v1/favourites", "", jwt.MapClaims{"sub": "user1", "jti": "stolen"}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected the revoked token to be rejected, got %d", rr.Code)
+	}
+	if rr := send("DELETE", "/api/v1/favourites", "", jwt.MapClaims{"sub": "user1", "jti": "fresh"}); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected other tokens to be accepted, got %d", rr.Code)
+	}
+}
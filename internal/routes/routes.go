@@ -3,50 +3,20 @@ package routes
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/giannis84/platform-go-challenge/internal/auth"
-	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/cache"
 	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
 	"github.com/giannis84/platform-go-challenge/internal/handlers"
 	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/httprate"
 )
 
-// RegisterFavouritesRoutes sets up the favourites API routes.
-// HTTP concerns are handled here, while business logic is delegated to the handlers package.
-func RegisterFavouritesRoutes(authCfg auth.AuthConfig, rateCfg config.RateLimitConfig) func(r chi.Router) {
-	return func(r chi.Router) {
-		r.Route("/api/v1", func(r chi.Router) {
-			r.Use(auth.JWTMiddleware(authCfg))
-
-			// Apply per-user rate limiting (keyed by JWT sub claim) if configured
-			if rateCfg.Requests > 0 && rateCfg.Window > 0 {
-				r.Use(httprate.Limit(
-					rateCfg.Requests,
-					rateCfg.Window,
-					httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
-						return auth.UserIDFromContext(r.Context()), nil
-					}),
-					httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
-						respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
-					}),
-				))
-			}
-
-			r.Route("/favourites", func(r chi.Router) {
-				r.Use(acceptJSONMiddleware)
-				r.Use(contentTypeJSONMiddleware)
-				r.Get("/", getUserFavouritesRoute())
-				r.Post("/", addUserFavouriteRoute())
-				r.Patch("/{assetID}", updateUserFavouriteRoute())
-				r.Delete("/{assetID}", removeUserFavouriteRoute())
-			})
-		})
-	}
-}
-
 // acceptJSONMiddleware checks that the Accept header includes application/json.
 // Returns 406 Not Acceptable if the header is missing or doesn't accept JSON.
 func acceptJSONMiddleware(next http.Handler) http.Handler {
@@ -76,6 +46,26 @@ func contentTypeJSONMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// maxBodyMiddleware caps request bodies at limit bytes (0 = no limit). A
+// declared Content-Length over the limit is rejected with 413 up front; a
+// longer body fails while it is decoded.
+func maxBodyMiddleware(limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > int64(limit) {
+				respondWithError(w, http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("request body exceeds maximum size of %d bytes", limit))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // acceptsJSON checks if the Accept header value includes application/json or */*.
 func acceptsJSON(accept string) bool {
 	return accept == "*/*" ||
@@ -107,7 +97,25 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
-func getUserFavouritesRoute() http.HandlerFunc {
+// BatchUpdateResponse is returned by PATCH /api/v1/favourites.
+type BatchUpdateResponse struct {
+	Updated int                          `json:"updated"`
+	Results []handlers.BatchUpdateResult `json:"results"`
+}
+
+// ImportResponse is returned by POST /api/v2/favourites/import.
+type ImportResponse struct {
+	Added   int                     `json:"added"`
+	Results []handlers.ImportResult `json:"results"`
+}
+
+// RemoveAllResponse is returned by DELETE /api/v1/favourites.
+type RemoveAllResponse struct {
+	Message string `json:"message"`
+	Deleted int64  `json:"deleted"`
+}
+
+func getUserFavouritesRoute(h *handlers.Favourites, listCache *cache.ListCache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		userID := auth.UserIDFromContext(ctx)
@@ -115,13 +123,63 @@ func getUserFavouritesRoute() http.HandlerFunc {
 		logging.Log(ctx).Layer("routes").Op("getUserFavourites").User(userID).
 			Info("received get favourites request")
 
-		favourites, err := handlers.GetUserFavourites(userID)
+		loc, ok := resolveLocation(h, w, r, userID)
+		if !ok {
+			return
+		}
+		render, ok := renderHTML(w, r)
+		if !ok {
+			return
+		}
+		filters, err := handlers.ParseDataFilters(r.URL.Query())
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		query := r.URL.Query()
+		provenance := models.Provenance{
+			SourceSystem:   query.Get("source_system"),
+			SourceURL:      query.Get("source_url"),
+			FavouritedFrom: query.Get("favourited_from"),
+		}
+
+		// Lists filtered on asset data or provenance are selected by the
+		// database and not cached
+		var favourites []*models.FavouriteAsset
+		switch {
+		case len(filters) > 0:
+			favourites, err = h.GetMatchingFavourites(ctx, userID, filters, provenance)
+		case provenance != (models.Provenance{}):
+			favourites, err = h.GetUserFavouritesByProvenance(ctx, userID, provenance)
+		default:
+			// Cached lists are read from the primary, so the list reloaded
+			// after a write's invalidation includes that write
+			favourites, err = listCache.Fetch(cache.Key(database.TenantFromContext(ctx), userID), func() ([]*models.FavouriteAsset, error) {
+				if listCache == nil {
+					return h.GetUserFavourites(ctx, userID)
+				}
+				return h.GetUserFavourites(database.ReadFromPrimary(ctx), userID)
+			})
+		}
+		if errors.Is(err, database.ErrDataFiltersUnavailable) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, handlers.ErrDataFiltersDisabled) {
+			respondWithError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
 		if err != nil {
 			logging.Log(ctx).Layer("routes").User(userID).Err(err).
 				Error("failed to get user favourites")
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithServerError(w, err)
 			return
 		}
+		handlers.LocalizeFavourites(favourites, loc)
+		if !render {
+			handlers.OmitRenderedDescriptions(favourites)
+		}
 
 		logging.Log(ctx).Layer("routes").Op("getUserFavourites").User(userID).
 			Int("count", len(favourites)).Int("status_code", http.StatusOK).
@@ -130,16 +188,47 @@ func getUserFavouritesRoute() http.HandlerFunc {
 	}
 }
 
-func addUserFavouriteRoute() http.HandlerFunc {
+// renderHTML reports whether the request asks for rendered descriptions with
+// ?render=html, responding with 400 to any other render value.
+func renderHTML(w http.ResponseWriter, r *http.Request) (render, ok bool) {
+	switch r.URL.Query().Get("render") {
+	case "":
+		return false, true
+	case "html":
+		return true, true
+	default:
+		respondWithError(w, http.StatusBadRequest, `render must be "html"`)
+		return false, false
+	}
+}
+
+// checkAssetDataSize responds with 413 and returns false when data is larger
+// than maxBytes (0 = no limit).
+func checkAssetDataSize(w http.ResponseWriter, r *http.Request, data json.RawMessage, maxBytes int) bool {
+	if maxBytes <= 0 || len(data) <= maxBytes {
+		return true
+	}
+	logging.Log(r.Context()).Layer("routes").User(auth.UserIDFromContext(r.Context())).
+		Int("asset_data_bytes", len(data)).Warn("asset data too large")
+	respondWithError(w, http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("asset_data exceeds maximum size of %d bytes", maxBytes))
+	return false
+}
+
+func addUserFavouriteRoute(h *handlers.Favourites, quotas handlers.QuotaConfig, maxAssetDataBytes int, lenientJSON bool, publisher events.Publisher, writeQueue *queue.WriteQueue) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		userID := auth.UserIDFromContext(ctx)
 
 		var req handlers.AddFavouriteRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeStrictJSONBody(w, r, &req, lenientJSON); err != nil {
 			logging.Log(ctx).Layer("routes").Op("addUserFavourite").User(userID).Err(err).
 				Error("failed to decode request body")
-			respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		if !checkAssetDataSize(w, r, req.AssetData, maxAssetDataBytes) {
 			return
 		}
 
@@ -147,6 +236,20 @@ func addUserFavouriteRoute() http.HandlerFunc {
 			AssetType(string(req.AssetType)).Str("asset_data", string(req.AssetData)).
 			Info("received add favourite request")
 
+		switch r.URL.Query().Get("validate_only") {
+		case "", "false":
+		case "true":
+			report := handlers.CheckAddFavouriteRequest(&req)
+			logging.Log(ctx).Layer("routes").Op("addUserFavourite").User(userID).
+				AssetType(string(req.AssetType)).Int("error_count", len(report.Errors)).
+				Info("add favourite request validated")
+			respondWithJSON(w, http.StatusOK, report)
+			return
+		default:
+			respondWithError(w, http.StatusBadRequest, `validate_only must be "true" or "false"`)
+			return
+		}
+
 		asset, err := handlers.ParseAddFavouriteRequest(&req)
 		if err != nil {
 			logging.Log(ctx).Layer("routes").User(userID).Err(err).
@@ -155,7 +258,7 @@ func addUserFavouriteRoute() http.HandlerFunc {
 			return
 		}
 
-		err = handlers.AddFavourite(ctx, userID, asset, req.Description)
+		err = h.AddFavourite(ctx, publisher, userID, asset, req.Description, req.Provenance, quotas)
 		if err != nil {
 			var validationErr *handlers.ValidationError
 			if errors.As(err, &validationErr) {
@@ -168,8 +271,18 @@ func addUserFavouriteRoute() http.HandlerFunc {
 				respondWithError(w, http.StatusConflict, "Favourite already exists")
 				return
 			}
+			if errors.Is(err, database.ErrQuotaExceeded) {
+				logging.Log(ctx).Layer("routes").User(userID).AssetType(string(req.AssetType)).Err(err).
+					Warn("favourites quota exceeded")
+				respondWithError(w, http.StatusConflict, err.Error())
+				return
+			}
+			if writeQueue != nil && database.IsUnavailable(err) {
+				queueFavourite(w, r, writeQueue, userID, asset, &req, err)
+				return
+			}
 			logging.Log(ctx).Layer("routes").User(userID).Err(err).Error("failed to add favourite")
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithServerError(w, err)
 			return
 		}
 
@@ -180,7 +293,130 @@ func addUserFavouriteRoute() http.HandlerFunc {
 	}
 }
 
-func updateUserFavouriteRoute() http.HandlerFunc {
+// queueFavourite stores a favourite that could not reach the database in the
+// write queue and reports it as accepted; it is replayed once the database is back.
+func queueFavourite(w http.ResponseWriter, r *http.Request, writeQueue *queue.WriteQueue, userID string, asset models.Asset, req *handlers.AddFavouriteRequest, dbErr error) {
+	ctx := r.Context()
+
+	err := writeQueue.Enqueue(queue.Entry{
+		UserID:         userID,
+		TenantID:       database.TenantFromContext(ctx),
+		AssetID:        asset.GetID(),
+		AssetType:      string(req.AssetType),
+		AssetData:      req.AssetData,
+		Description:    req.Description,
+		SourceSystem:   req.SourceSystem,
+		SourceURL:      req.SourceURL,
+		FavouritedFrom: req.FavouritedFrom,
+	})
+	switch {
+	case err == nil:
+		logging.Log(ctx).Layer("routes").Op("addUserFavourite").User(userID).Asset(asset.GetID()).
+			Int("queue_depth", writeQueue.Stats().Depth).Str("db_error", dbErr.Error()).
+			Int("status_code", http.StatusAccepted).Warn("database unavailable; favourite queued")
+		respondWithJSON(w, http.StatusAccepted, map[string]string{"message": "Favourite accepted and queued for storage"})
+	case errors.Is(err, queue.ErrAlreadyQueued):
+		respondWithError(w, http.StatusConflict, "Favourite already exists")
+	case errors.Is(err, queue.ErrFull):
+		logging.Log(ctx).Layer("routes").User(userID).Err(dbErr).Error("database unavailable and write queue full")
+		respondWithError(w, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry later")
+	default:
+		logging.Log(ctx).Layer("routes").User(userID).Err(err).Error("failed to queue favourite")
+		respondWithServerError(w, err)
+	}
+}
+
+// getUserStatsRoute returns aggregate statistics about the authenticated user's favourites.
+func getUserStatsRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		loc, ok := resolveLocation(h, w, r, userID)
+		if !ok {
+			return
+		}
+
+		stats, err := h.GetFavouriteStats(ctx, userID, loc)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").Op("getUserStats").User(userID).Err(err).
+				Error("failed to get favourite stats")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("getUserStats").User(userID).
+			Int("total", stats.Total).Int("status_code", http.StatusOK).
+			Info("favourite stats retrieved successfully")
+		respondWithJSON(w, http.StatusOK, stats)
+	}
+}
+
+// getRecentUserFavouritesRoute returns the favourites created or updated within
+// the ?window= period (default 7d), most recently changed first.
+func getRecentUserFavouritesRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+		window := r.URL.Query().Get("window")
+
+		logging.Log(ctx).Layer("routes").Op("getRecentUserFavourites").User(userID).
+			Str("window", window).Info("received recent favourites request")
+
+		loc, ok := resolveLocation(h, w, r, userID)
+		if !ok {
+			return
+		}
+		render, ok := renderHTML(w, r)
+		if !ok {
+			return
+		}
+
+		favourites, err := h.GetRecentFavourites(ctx, userID, window, loc)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").Op("getRecentUserFavourites").User(userID).Err(err).
+				Error("failed to get recent favourites")
+			respondWithServerError(w, err)
+			return
+		}
+		if !render {
+			handlers.OmitRenderedDescriptions(favourites)
+		}
+
+		logging.Log(ctx).Layer("routes").Op("getRecentUserFavourites").User(userID).
+			Int("count", len(favourites)).Int("status_code", http.StatusOK).
+			Info("recent favourites retrieved successfully")
+		respondWithJSON(w, http.StatusOK, favourites)
+	}
+}
+
+// getUserQuotaRoute reports the authenticated user's usage against the per-type quotas.
+func getUserQuotaRoute(h *handlers.Favourites, quotas handlers.QuotaConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		report, err := h.GetQuotaUsage(ctx, userID, quotas)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").Op("getUserQuota").User(userID).Err(err).
+				Error("failed to get quota usage")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("getUserQuota").User(userID).
+			Int("total", report.Total).Int("status_code", http.StatusOK).
+			Info("quota usage retrieved successfully")
+		respondWithJSON(w, http.StatusOK, report)
+	}
+}
+
+func updateUserFavouriteRoute(h *handlers.Favourites, lenientJSON bool, publisher events.Publisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		userID := auth.UserIDFromContext(ctx)
@@ -192,17 +428,17 @@ func updateUserFavouriteRoute() http.HandlerFunc {
 		}
 
 		var req handlers.UpdateDescriptionRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeStrictJSONBody(w, r, &req, lenientJSON); err != nil {
 			logging.Log(ctx).Layer("routes").User(userID).Asset(assetID).Err(err).
 				Error("failed to decode request body")
-			respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			respondInvalidBody(w, err)
 			return
 		}
 
 		logging.Log(ctx).Layer("routes").Op("updateUserFavourite").User(userID).Asset(assetID).
 			Str("description", req.Description).Info("received update favourite request")
 
-		err := handlers.UpdateDescription(userID, assetID, req.Description)
+		err := h.UpdateDescription(ctx, publisher, userID, assetID, req.Description)
 		if err != nil {
 			var validationErr *handlers.ValidationError
 			if errors.As(err, &validationErr) {
@@ -219,7 +455,7 @@ func updateUserFavouriteRoute() http.HandlerFunc {
 			}
 			logging.Log(ctx).Layer("routes").User(userID).Asset(assetID).Err(err).
 				Error("failed to update favourite")
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithServerError(w, err)
 			return
 		}
 
@@ -229,7 +465,100 @@ func updateUserFavouriteRoute() http.HandlerFunc {
 	}
 }
 
-func removeUserFavouriteRoute() http.HandlerFunc {
+// importUserFavouritesRoute adds many favourites at once and reports a
+// per-item result.
+func importUserFavouritesRoute(h *handlers.Favourites, quotas handlers.QuotaConfig, maxAssetDataBytes int, lenientJSON bool, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		var items []handlers.AddFavouriteRequest
+		if err := decodeStrictJSONBody(w, r, &items, lenientJSON); err != nil {
+			logging.Log(ctx).Layer("routes").Op("importUserFavourites").User(userID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+		for _, item := range items {
+			if !checkAssetDataSize(w, r, item.AssetData, maxAssetDataBytes) {
+				return
+			}
+		}
+
+		logging.Log(ctx).Layer("routes").Op("importUserFavourites").User(userID).
+			Int("count", len(items)).Info("received import request")
+
+		results, err := h.ImportFavourites(ctx, publisher, userID, items, quotas)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").User(userID).Err(err).Error("failed to import favourites")
+			respondWithServerError(w, err)
+			return
+		}
+
+		added := 0
+		for _, res := range results {
+			if res.Status == handlers.ImportStatusAdded {
+				added++
+			}
+		}
+
+		logging.Log(ctx).Layer("routes").Op("importUserFavourites").User(userID).
+			Int("count", len(results)).Int("added", added).Int("status_code", http.StatusOK).
+			Info("import applied")
+		respondWithJSON(w, http.StatusOK, ImportResponse{Added: added, Results: results})
+	}
+}
+
+// batchUpdateUserFavouritesRoute applies several description updates at once and
+// reports a per-item result.
+func batchUpdateUserFavouritesRoute(h *handlers.Favourites, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		var items []handlers.BatchDescriptionUpdate
+		if err := decodeJSONBody(w, r, &items); err != nil {
+			logging.Log(ctx).Layer("routes").Op("batchUpdateUserFavourites").User(userID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("batchUpdateUserFavourites").User(userID).
+			Int("count", len(items)).Info("received batch update request")
+
+		results, err := h.UpdateDescriptions(ctx, publisher, userID, items)
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logging.Log(ctx).Layer("routes").User(userID).Err(err).Error("failed to apply batch update")
+			respondWithServerError(w, err)
+			return
+		}
+
+		updated := 0
+		for _, res := range results {
+			if res.Status == handlers.BatchStatusUpdated {
+				updated++
+			}
+		}
+
+		logging.Log(ctx).Layer("routes").Op("batchUpdateUserFavourites").User(userID).
+			Int("count", len(results)).Int("updated", updated).Int("status_code", http.StatusOK).
+			Info("batch update applied")
+		respondWithJSON(w, http.StatusOK, BatchUpdateResponse{Updated: updated, Results: results})
+	}
+}
+
+func removeUserFavouriteRoute(h *handlers.Favourites, publisher events.Publisher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		userID := auth.UserIDFromContext(ctx)
@@ -243,7 +572,7 @@ func removeUserFavouriteRoute() http.HandlerFunc {
 		logging.Log(ctx).Layer("routes").Op("removeUserFavourite").User(userID).Asset(assetID).
 			Info("received remove favourite request")
 
-		err := handlers.RemoveFavourite(userID, assetID)
+		err := h.RemoveFavourite(ctx, publisher, userID, assetID)
 		if err != nil {
 			if err == database.ErrNotFound {
 				logging.Log(ctx).Layer("routes").User(userID).Asset(assetID).
@@ -253,7 +582,7 @@ func removeUserFavouriteRoute() http.HandlerFunc {
 			}
 			logging.Log(ctx).Layer("routes").User(userID).Asset(assetID).Err(err).
 				Error("failed to remove favourite")
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithServerError(w, err)
 			return
 		}
 
@@ -263,6 +592,65 @@ func removeUserFavouriteRoute() http.HandlerFunc {
 	}
 }
 
+// favouriteExistsRoute answers HEAD for a favourite of the authenticated
+// user with 200 when it exists and 404 when it does not, without reading its
+// asset data.
+func favouriteExistsRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+		assetID := chi.URLParam(r, "assetID")
+
+		if err := handlers.ValidateAssetID(assetID); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		exists, err := h.FavouriteExists(ctx, userID, assetID)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").User(userID).Asset(assetID).Err(err).
+				Error("failed to check favourite")
+			respondWithServerError(w, err)
+			return
+		}
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// removeAllUserFavouritesRoute clears every favourite of the authenticated user.
+// The caller must pass ?confirm=true to guard against accidental wipes.
+func removeAllUserFavouritesRoute(h *handlers.Favourites, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		if r.URL.Query().Get("confirm") != "true" {
+			respondWithError(w, http.StatusBadRequest, "confirm=true query parameter is required to remove all favourites")
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("removeAllUserFavourites").User(userID).
+			Info("received remove all favourites request")
+
+		deleted, err := h.RemoveAllFavourites(ctx, publisher, userID)
+		if err != nil {
+			logging.Log(ctx).Layer("routes").User(userID).Err(err).
+				Error("failed to remove all favourites")
+			respondWithServerError(w, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("removeAllUserFavourites").User(userID).
+			Int("deleted", len(deleted)).Int("status_code", http.StatusOK).
+			Info("all favourites removed successfully")
+		respondWithJSON(w, http.StatusOK, RemoveAllResponse{Message: "Favourites removed successfully", Deleted: int64(len(deleted))})
+	}
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 	response, _ := json.Marshal(payload)
 	w.Header().Set("Content-Type", "application/json")
@@ -271,5 +659,17 @@ func respondWithJSON(w http.ResponseWriter, code int, payload any) {
 }
 
 func respondWithError(w http.ResponseWriter, code int, message string) {
+	reportError(w, code, message)
 	respondWithJSON(w, code, ErrorResponse{Error: message})
 }
+
+// respondWithServerError responds to a request that failed with err: 503 when
+// the database is unavailable, such as while its circuit breaker is open, so
+// clients know to retry, and 500 otherwise.
+func respondWithServerError(w http.ResponseWriter, err error) {
+	if database.IsUnavailable(err) {
+		respondWithError(w, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry later")
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, err.Error())
+}
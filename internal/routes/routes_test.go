@@ -7,61 +7,85 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/authtest"
 	"github.com/giannis84/platform-go-challenge/internal/auth"
-	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/cache"
 	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
 	"github.com/giannis84/platform-go-challenge/internal/logging"
 	"github.com/giannis84/platform-go-challenge/internal/models"
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/lib/pq"
 )
 
-var testCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at"}
+var testCols = []string{"id", "user_id", "asset_type", "description", "data", "created_at", "updated_at", "source_system", "source_url", "favourited_from", "description_html", "tenant_id"}
 
-func testLogger() *slog.Logger {
-	return slog.New(slog.NewTextHandler(io.Discard, nil))
+// updatedAtRows is the updated_at the database returns for a written favourite.
+func updatedAtRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now())
 }
 
-func testToken(sub string) string {
-	claims := jwt.MapClaims{
-		"sub": sub,
-		"exp": time.Now().Add(time.Hour).Unix(),
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
-	s, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
-	return s
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
 
 func addAuthHeader(req *http.Request, userID string) {
-	req.Header.Set("Authorization", "Bearer "+testToken(userID))
+	req.Header.Set("Authorization", "Bearer "+authtest.TokenFor(userID))
 }
 
-func setupTestHandler(t *testing.T) (*chi.Mux, sqlmock.Sqlmock) {
+// setupTestRepository returns a Repository over a sqlmock-backed db, with the mock.
+func setupTestRepository(t *testing.T) (*database.Repository, sqlmock.Sqlmock) {
 	t.Helper()
-	logger := testLogger()
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to create sqlmock: %v", err)
 	}
 	t.Cleanup(func() { db.Close() })
+	return database.NewRepository(db, database.Options{}), mock
+}
 
-	database.DB = db
+func setupTestHandler(t *testing.T) (*chi.Mux, sqlmock.Sqlmock) {
+	t.Helper()
+	logger := testLogger()
+	repo, mock := setupTestRepository(t)
 
 	router := chi.NewRouter()
 	router.Use(logging.RequestLogger(logger))
-	router.Group(RegisterFavouritesRoutes(auth.AuthConfig{
-		Secret:              "",
-		AllowUnsignedTokens: true,
-	}, config.RateLimitConfig{}))
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Favourites: repo,
+		Store:      repo,
+		Auth: auth.AuthConfig{
+			Secret:              "",
+			AllowUnsignedTokens: true,
+			AdminUsers:          []string{"admin1"},
+		},
+		Publisher: events.NewBus(),
+		Quotas: handlers.QuotaConfig{
+			PerType: map[models.AssetType]int{models.AssetTypeChart: 1},
+		},
+		MaxAssetDataBytes: 1024,
+		MaxBodyBytes:      4096,
+	}))
 
 	return router, mock
 }
 
+// expectTimezone registers the preference lookup done before listing favourites.
+// An empty timezone means the user has no stored preference.
+func expectTimezone(mock sqlmock.Sqlmock, userID, timezone string) {
+	rows := sqlmock.NewRows([]string{"timezone"})
+	if timezone != "" {
+		rows.AddRow(timezone)
+	}
+	mock.ExpectQuery("SELECT timezone FROM user_preferences").WithArgs(userID).WillReturnRows(rows)
+}
+
 func insightRequestBody() map[string]any {
 	return map[string]any{
 		"asset_type":  "insight",
@@ -78,12 +102,28 @@ func audienceRequestBody() map[string]any {
 		"asset_type":  "audience",
 		"description": "Tech-savvy millennials",
 		"asset_data": map[string]any{
-			"id":                      "audience1",
-			"gender":                  []string{"Male", "Female"},
-			"birth_country":           []string{"US", "UK"},
-			"age_groups":              []string{"25-34"},
+			"id":                       "audience1",
+			"gender":                   []string{"Male", "Female"},
+			"birth_country":            []string{"US", "GB"},
+			"age_groups":               []string{"25-34"},
 			"social_media_hours_daily": "3-5",
-			"purchases_last_month":    5,
+			"purchases_last_month":     5,
+		},
+	}
+}
+
+func dashboardRequestBody() map[string]any {
+	return map[string]any{
+		"asset_type":  "dashboard",
+		"description": "Quarterly review",
+		"asset_data": map[string]any{
+			"id":    "dashboard1",
+			"title": "Q1 review",
+			"widgets": []map[string]any{
+				{"asset_type": "chart", "asset_id": "chart1", "position": map[string]int{"x": 0, "y": 0, "width": 8, "height": 4}},
+				{"asset_type": "insight", "asset_id": "insight1", "position": map[string]int{"x": 8, "y": 0, "width": 4, "height": 4}},
+			},
+			"layout": map[string]any{"columns": 12},
 		},
 	}
 }
@@ -103,8 +143,8 @@ func postFavourite(t *testing.T, router *chi.Mux, body map[string]any) *httptest
 func TestFavouritesRoutes_AddFavourite(t *testing.T) {
 	router, mock := setupTestHandler(t)
 
-	mock.ExpectExec("INSERT INTO favourites").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO favourites").
+		WillReturnRows(updatedAtRows())
 
 	rr := postFavourite(t, router, insightRequestBody())
 
@@ -122,13 +162,35 @@ func TestFavouritesRoutes_AddFavourite(t *testing.T) {
 	}
 }
 
+func TestFavouritesRoutes_AddDashboard(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	mock.ExpectQuery("INSERT INTO favourites").
+		WithArgs("dashboard1", "user1", "dashboard", "Quarterly review", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", "", "<p>Quarterly review</p>", "").
+		WillReturnRows(updatedAtRows())
+	if rr := postFavourite(t, router, dashboardRequestBody()); rr.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d. Body: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	// Dashboards cannot contain other dashboards.
+	body := dashboardRequestBody()
+	body["asset_data"].(map[string]any)["widgets"] = []map[string]any{{"asset_type": "dashboard", "asset_id": "dashboard2"}}
+	rr := postFavourite(t, router, body)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "widgets[0].asset_type") {
+		t.Errorf("expected a 400 naming the widget, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
 func TestFavouritesRoutes_GetUserFavourites(t *testing.T) {
 	router, mock := setupTestHandler(t)
 	now := time.Now()
 
 	// Add favourite
-	mock.ExpectExec("INSERT INTO favourites").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO favourites").
+		WillReturnRows(updatedAtRows())
 	rr := postFavourite(t, router, insightRequestBody())
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("setup failed: status %d, body: %s", rr.Code, rr.Body.String())
@@ -139,10 +201,11 @@ func TestFavouritesRoutes_GetUserFavourites(t *testing.T) {
 		ID:   "insight1",
 		Text: "40% of millennials spend more than 3 hours on social media daily",
 	})
+	expectTimezone(mock, "user1", "")
 	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 		WithArgs("user1").
 		WillReturnRows(sqlmock.NewRows(testCols).
-			AddRow("insight1", "user1", "insight", "Social media usage insight", insightData, now, now))
+			AddRow("insight1", "user1", "insight", "Social media usage insight", insightData, now, now, nil, nil, nil, nil, ""))
 
 	req := httptest.NewRequest("GET", "/api/v1/favourites", nil)
 	req.Header.Set("Accept", "application/json")
@@ -164,29 +227,225 @@ func TestFavouritesRoutes_GetUserFavourites(t *testing.T) {
 	}
 }
 
+func TestFavouritesRoutes_GetUserFavouritesFilteredByProvenance(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	now := time.Now()
+
+	insightData, _ := json.Marshal(models.Insight{ID: "insight1", Text: "t"})
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery(`SELECT .+ FROM favourites\s+WHERE user_id = \$1 AND deleted_at IS NULL AND source_system = \$2 AND favourited_from = \$3`).
+		WithArgs("user1", "crm", "search").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("insight1", "user1", "insight", "", insightData, now, now, "crm", nil, "search", nil, ""))
+
+	req := httptest.NewRequest("GET", "/api/v1/favourites?source_system=crm&favourited_from=search", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var favourites []struct {
+		ID             string `json:"id"`
+		FavouritedFrom string `json:"favourited_from"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &favourites); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(favourites) != 1 || favourites[0].ID != "insight1" || favourites[0].FavouritedFrom != "search" {
+		t.Errorf("expected only insight1 from search, got %+v", favourites)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_GetUserFavouritesFilteredByData(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	now := time.Now()
+
+	chartData, _ := json.Marshal(models.Chart{ID: "chart1", Title: "Quarterly revenue", XAxisTitle: "X", YAxisTitle: "Y"})
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery(`SELECT .+ FROM favourites\s+WHERE user_id = \$1\s+AND \(favourites.data->>\$2 ILIKE \$3`).
+		WithArgs("user1", "title", "%revenue%", "title", "%revenue%").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("chart1", "user1", "chart", "", chartData, now, now, nil, nil, nil, nil, ""))
+
+	req := httptest.NewRequest("GET", "/api/v1/favourites?data.title.contains=revenue", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	expectTimezone(mock, "user1", "")
+	req = httptest.NewRequest("GET", "/api/v1/favourites?data.Title=x", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid field, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestFavouritesRoutes_GetUserFavouritesIncludesThumbnail(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	now := time.Now()
+
+	chartData, _ := json.Marshal(models.Chart{ID: "chart1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y", ThumbnailURL: "https://cdn.example.com/chart1.png"})
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("chart1", "user1", "chart", "", chartData, now, now, nil, nil, nil, nil, ""))
+
+	req := httptest.NewRequest("GET", "/api/v1/favourites", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var favourites []struct {
+		Data struct {
+			ThumbnailURL string `json:"thumbnail_url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &favourites); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(favourites) != 1 || favourites[0].Data.ThumbnailURL != "https://cdn.example.com/chart1.png" {
+		t.Errorf("expected the chart thumbnail in the list, got %s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_UnknownFields(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	body := audienceRequestBody()
+	body["descripton"] = "typo"
+	rr := postFavourite(t, router, body)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `unknown field \"descripton\"`) {
+		t.Errorf("expected 400 naming the unknown field on add, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	req := httptest.NewRequest("PATCH", "/api/v1/favourites/audience1", strings.NewReader(`{"descripton": "typo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `unknown field \"descripton\"`) {
+		t.Errorf("expected 400 naming the unknown field on update, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	repo, mock := setupTestRepository(t)
+	lenient := chi.NewRouter()
+	lenient.Group(RegisterFavouritesRoutes(Deps{
+		Favourites:  repo,
+		Auth:        auth.AuthConfig{AllowUnsignedTokens: true},
+		Publisher:   events.NewBus(),
+		LenientJSON: true,
+	}))
+	mock.ExpectQuery("INSERT INTO favourites").WillReturnRows(updatedAtRows())
+	if rr := postFavourite(t, lenient, body); rr.Code != http.StatusCreated {
+		t.Errorf("expected lenient mode to ignore the unknown field, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_GetUserFavouritesRender(t *testing.T) {
+	chartData, _ := json.Marshal(models.Chart{ID: "chart1", Title: "T", XAxisTitle: "X", YAxisTitle: "Y"})
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantHTML string
+	}{
+		{name: "omitted by default", wantCode: http.StatusOK},
+		{name: "rendered on request", query: "?render=html", wantCode: http.StatusOK, wantHTML: "<p><strong>Q3</strong> numbers</p>"},
+		{name: "unknown format", query: "?render=pdf", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			now := time.Now()
+			expectTimezone(mock, "user1", "")
+			if tt.wantCode == http.StatusOK {
+				mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+					WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows(testCols).
+						AddRow("chart1", "user1", "chart", "**Q3** numbers", chartData, now, now, nil, nil, nil, nil, ""))
+			}
+
+			req := httptest.NewRequest("GET", "/api/v1/favourites"+tt.query, nil)
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var favourites []struct {
+					DescriptionHTML string `json:"description_html"`
+				}
+				if err := json.Unmarshal(rr.Body.Bytes(), &favourites); err != nil {
+					t.Fatalf("decoding response: %v", err)
+				}
+				if len(favourites) != 1 || favourites[0].DescriptionHTML != tt.wantHTML {
+					t.Errorf("expected description_html %q, got %s", tt.wantHTML, rr.Body.String())
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
 func TestFavouritesRoutes_UpdateDescription(t *testing.T) {
 	router, mock := setupTestHandler(t)
 	now := time.Now()
 
 	// Add favourite
-	mock.ExpectExec("INSERT INTO favourites").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO favourites").
+		WillReturnRows(updatedAtRows())
 	rr := postFavourite(t, router, audienceRequestBody())
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("setup failed: status %d, body: %s", rr.Code, rr.Body.String())
 	}
 
-	// Update description: handler calls GetFavouriteFromDB then UpdateFavouriteInDB
+	// Update description: handler reads then updates the favourite in one transaction
 	audienceData, _ := json.Marshal(models.Audience{
-		ID: "audience1", Gender: []string{"Male", "Female"}, BirthCountry: []string{"US", "UK"},
+		ID: "audience1", Gender: []string{"Male", "Female"}, BirthCountry: []string{"US", "GB"},
 		AgeGroups: []string{"25-34"}, SocialMediaHoursDaily: "3-5", PurchasesLastMonth: 5,
 	})
+	mock.ExpectBegin()
 	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 		WithArgs("user1", "audience1").
 		WillReturnRows(sqlmock.NewRows(testCols).
-			AddRow("audience1", "user1", "audience", "Tech-savvy millennials", audienceData, now, now))
-	mock.ExpectExec("UPDATE favourites").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+			AddRow("audience1", "user1", "audience", "Tech-savvy millennials", audienceData, now, now, nil, nil, nil, nil, ""))
+	mock.ExpectQuery("UPDATE favourites").
+		WillReturnRows(updatedAtRows())
+	mock.ExpectCommit()
 
 	updateBody, _ := json.Marshal(map[string]string{"description": "Updated description for audience"})
 	req := httptest.NewRequest("PATCH", "/api/v1/favourites/audience1", bytes.NewBuffer(updateBody))
@@ -210,19 +469,57 @@ func TestFavouritesRoutes_UpdateDescription(t *testing.T) {
 	}
 }
 
+func TestFavouritesRoutes_FavouriteExists(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	tests := []struct {
+		name       string
+		assetID    string
+		rows       *sqlmock.Rows
+		wantStatus int
+	}{
+		{name: "existing favourite", assetID: "insight1", rows: sqlmock.NewRows([]string{"?column?"}).AddRow(1), wantStatus: http.StatusOK},
+		{name: "missing favourite", assetID: "missing", rows: sqlmock.NewRows([]string{"?column?"}), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery("SELECT 1 FROM favourites WHERE user_id").
+				WithArgs("user1", tt.assetID).
+				WillReturnRows(tt.rows)
+
+			req := httptest.NewRequest("HEAD", "/api/v2/favourites/"+tt.assetID, nil)
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if rr.Body.Len() != 0 {
+				t.Errorf("expected no body, got %s", rr.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
 func TestFavouritesRoutes_RemoveFavourite(t *testing.T) {
 	router, mock := setupTestHandler(t)
 
 	// Add favourite
-	mock.ExpectExec("INSERT INTO favourites").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO favourites").
+		WillReturnRows(updatedAtRows())
 	rr := postFavourite(t, router, insightRequestBody())
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("setup failed: status %d, body: %s", rr.Code, rr.Body.String())
 	}
 
 	// Remove favourite
-	mock.ExpectExec("DELETE FROM favourites").
+	mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	req := httptest.NewRequest("DELETE", "/api/v1/favourites/insight1", nil)
@@ -236,6 +533,7 @@ func TestFavouritesRoutes_RemoveFavourite(t *testing.T) {
 	}
 
 	// Verify removed by getting empty list
+	expectTimezone(mock, "user1", "")
 	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 		WithArgs("user1").
 		WillReturnRows(sqlmock.NewRows(testCols))
@@ -260,15 +558,15 @@ func TestFavouritesRoutes_AddDuplicateFavourite(t *testing.T) {
 	router, mock := setupTestHandler(t)
 
 	// First add succeeds
-	mock.ExpectExec("INSERT INTO favourites").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO favourites").
+		WillReturnRows(updatedAtRows())
 	rr := postFavourite(t, router, insightRequestBody())
 	if rr.Code != http.StatusCreated {
 		t.Fatalf("first add failed: status %d, body: %s", rr.Code, rr.Body.String())
 	}
 
 	// Second add returns unique violation
-	mock.ExpectExec("INSERT INTO favourites").
+	mock.ExpectQuery("INSERT INTO favourites").
 		WillReturnError(&pq.Error{Code: "23505"})
 	rr = postFavourite(t, router, insightRequestBody())
 
@@ -325,10 +623,10 @@ func TestFavouritesRoutes_AcceptHeaderMiddleware(t *testing.T) {
 	router, mock := setupTestHandler(t)
 
 	tests := []struct {
-		name       string
-		accept     string
-		wantCode   int
-		wantError  string
+		name      string
+		accept    string
+		wantCode  int
+		wantError string
 	}{
 		{name: "missing Accept header", accept: "", wantCode: http.StatusNotAcceptable, wantError: "Accept header must include application/json"},
 		{name: "wrong Accept header", accept: "text/html", wantCode: http.StatusNotAcceptable, wantError: "Accept header must include application/json"},
@@ -340,6 +638,7 @@ func TestFavouritesRoutes_AcceptHeaderMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.wantCode == http.StatusOK {
+				expectTimezone(mock, "user1", "")
 				mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 					WithArgs("user1").
 					WillReturnRows(sqlmock.NewRows(testCols))
@@ -389,12 +688,13 @@ func TestFavouritesRoutes_ContentTypeMiddleware(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.wantCode == http.StatusOK {
+				expectTimezone(mock, "user1", "")
 				mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
 					WithArgs("user1").
 					WillReturnRows(sqlmock.NewRows(testCols))
 			}
 			if tt.wantCode == http.StatusNotFound && tt.method == "DELETE" {
-				mock.ExpectExec("DELETE FROM favourites").
+				mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").
 					WillReturnResult(sqlmock.NewResult(0, 0))
 			}
 
@@ -426,3 +726,549 @@ func TestFavouritesRoutes_ContentTypeMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestFavouritesRoutes_RemoveAllFavourites(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		setupMock   func(sqlmock.Sqlmock)
+		wantCode    int
+		wantDeleted int64
+	}{
+		{
+			name: "confirmed removal", query: "?confirm=true",
+			setupMock: func(m sqlmock.Sqlmock) {
+				m.ExpectBegin()
+				m.ExpectQuery("UPDATE favourites SET deleted_at = NOW\\(\\) WHERE user_id").
+					WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("c1").AddRow("c2").AddRow("i1").AddRow("a1"))
+				m.ExpectCommit()
+			},
+			wantCode: http.StatusOK, wantDeleted: 4,
+		},
+		{name: "missing confirm", query: "", wantCode: http.StatusBadRequest},
+		{name: "confirm not true", query: "?confirm=yes", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mock)
+			}
+
+			req := httptest.NewRequest("DELETE", "/api/v1/favourites"+tt.query, nil)
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var resp RemoveAllResponse
+				json.Unmarshal(rr.Body.Bytes(), &resp)
+				if resp.Deleted != tt.wantDeleted {
+					t.Errorf("expected deleted=%d, got %d", tt.wantDeleted, resp.Deleted)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestFavouritesRoutes_BatchUpdateDescriptions(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE favourites").WithArgs("first", sqlmock.AnyArg(), "user1", "c1", "<p>first</p>").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE favourites").WithArgs("second", sqlmock.AnyArg(), "user1", "c2", "<p>second</p>").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	body, _ := json.Marshal([]map[string]string{
+		{"asset_id": "c1", "description": "first"},
+		{"asset_id": "c2", "description": "second"},
+		{"asset_id": "c3", "description": ""},
+	})
+	req := httptest.NewRequest("PATCH", "/api/v1/favourites", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var resp BatchUpdateResponse
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Updated != 1 || len(resp.Results) != 3 {
+		t.Fatalf("unexpected response: %s", rr.Body.String())
+	}
+	wantStatuses := []string{"updated", "not_found", "invalid"}
+	for i, want := range wantStatuses {
+		if resp.Results[i].Status != want {
+			t.Errorf("item %d: expected status %q, got %q", i, want, resp.Results[i].Status)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_BatchUpdateInvalidBody(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	req := httptest.NewRequest("PATCH", "/api/v1/favourites", bytes.NewBufferString(`{"asset_id":"c1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d. Body: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+}
+
+func TestFavouritesRoutes_ImportFavourites(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO favourites .+ VALUES \(\$1, .+\),\s+\(\$13, .+\$24\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "id", "updated_at"}).AddRow("user1", "insight1", time.Now()))
+	mock.ExpectCommit()
+
+	// Insights have no quota in the test router, so they are inserted together
+	second := insightRequestBody()
+	second["asset_data"].(map[string]any)["id"] = "insight2"
+	body, _ := json.Marshal([]map[string]any{
+		insightRequestBody(),
+		second,
+		{"asset_type": "insight", "asset_data": map[string]any{"id": "insight3"}},
+	})
+	req := httptest.NewRequest("POST", "/api/v2/favourites/import", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Data ImportResponse `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp.Data.Added != 1 || len(resp.Data.Results) != 3 {
+		t.Fatalf("unexpected response: %s", rr.Body.String())
+	}
+	for i, want := range []string{"added", "exists", "invalid"} {
+		if resp.Data.Results[i].Status != want {
+			t.Errorf("item %d: expected status %q, got %q", i, want, resp.Data.Results[i].Status)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func chartRequestBody() map[string]any {
+	return map[string]any{
+		"asset_type":  "chart",
+		"description": "Revenue chart",
+		"asset_data": map[string]any{
+			"id":           "chart1",
+			"title":        "Revenue",
+			"x_axis_title": "Month",
+			"y_axis_title": "USD",
+		},
+	}
+}
+
+func TestFavouritesRoutes_AddFavouriteQuotaExceeded(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user1", "chart").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	rr := postFavourite(t, router, chartRequestBody())
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp["error"] != "favourites quota exceeded for asset type chart (limit 1)" {
+		t.Errorf("unexpected error message: %v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_AddFavouriteValidateOnly(t *testing.T) {
+	invalid := audienceRequestBody()
+	invalid["asset_data"].(map[string]any)["gender"] = []string{"Robot"}
+
+	tests := []struct {
+		name      string
+		query     string
+		body      map[string]any
+		wantCode  int
+		wantValid bool
+	}{
+		{name: "valid request", query: "?validate_only=true", body: audienceRequestBody(), wantCode: http.StatusOK, wantValid: true},
+		{name: "invalid request", query: "?validate_only=true", body: invalid, wantCode: http.StatusOK},
+		{name: "bad flag", query: "?validate_only=yes", body: audienceRequestBody(), wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+
+			data, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest("POST", "/api/v1/favourites"+tt.query, bytes.NewBuffer(data))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var report handlers.ValidationReport
+				if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+					t.Fatalf("decoding response: %v", err)
+				}
+				if report.Valid != tt.wantValid || report.Valid != (len(report.Errors) == 0) {
+					t.Errorf("unexpected report: %+v", report)
+				}
+			}
+			// Nothing may reach the database.
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestFavouritesRoutes_RequestBodyTooLarge(t *testing.T) {
+	body := chartRequestBody()
+	body["description"] = strings.Repeat("x", 8192)
+	data, _ := json.Marshal(body)
+
+	for _, tt := range []struct {
+		name          string
+		contentLength int64
+	}{
+		{name: "declared length", contentLength: int64(len(data))},
+		{name: "unknown length", contentLength: -1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+
+			req := httptest.NewRequest("POST", "/api/v1/favourites", bytes.NewReader(data))
+			req.ContentLength = tt.contentLength
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("expected status %d, got %d. Body: %s", http.StatusRequestEntityTooLarge, rr.Code, rr.Body.String())
+			}
+			var resp map[string]string
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			if resp["error"] != "request body exceeds maximum size of 4096 bytes" {
+				t.Errorf("unexpected error message: %v", resp)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestFavouritesRoutes_AddFavouriteAssetDataTooLarge(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	body := chartRequestBody()
+	body["asset_data"].(map[string]any)["data"] = map[string]any{"blob": strings.Repeat("x", 1024)}
+
+	rr := postFavourite(t, router, body)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusRequestEntityTooLarge, rr.Code, rr.Body.String())
+	}
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp["error"] != "asset_data exceeds maximum size of 1024 bytes" {
+		t.Errorf("unexpected error message: %v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_GetQuota(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	mock.ExpectQuery("SELECT asset_type, COUNT").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"asset_type", "count"}).AddRow("chart", 1))
+
+	req := httptest.NewRequest("GET", "/api/v1/favourites/quota", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var report handlers.QuotaReport
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	if report.Total != 1 || len(report.Types) != 4 {
+		t.Fatalf("unexpected report: %s", rr.Body.String())
+	}
+	for _, usage := range report.Types {
+		if usage.AssetType == models.AssetTypeChart && (usage.Limit == nil || *usage.Remaining != 0) {
+			t.Errorf("expected chart limit 1 with 0 remaining, got %s", rr.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_ListCacheInvalidatedOnWrite(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := database.NewRepository(db, database.Options{})
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(e events.Event) { published = append(published, e) })
+	listCache := cache.NewListCache(10)
+	bus.Subscribe(func(e events.Event) { listCache.Invalidate(e.UserID) })
+
+	router := chi.NewRouter()
+	router.Use(logging.RequestLogger(testLogger()))
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Favourites: repo,
+		Store:      repo,
+		Auth:       auth.AuthConfig{AllowUnsignedTokens: true},
+		Publisher:  bus,
+		ListCache:  listCache,
+	}))
+
+	now := time.Now()
+	insightData, _ := json.Marshal(models.Insight{ID: "insight1", Text: "text"})
+	list := func(wantCount int) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/favourites", nil)
+		req.Header.Set("Accept", "application/json")
+		addAuthHeader(req, "user1")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var favourites []any
+		json.Unmarshal(rr.Body.Bytes(), &favourites)
+		if rr.Code != http.StatusOK || len(favourites) != wantCount {
+			t.Fatalf("expected %d favourites, got status %d body %s", wantCount, rr.Code, rr.Body.String())
+		}
+	}
+
+	// First list hits the database, the second is served from the cache.
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("insight1", "user1", "insight", "desc", insightData, now, now, nil, nil, nil, nil, ""))
+	expectTimezone(mock, "user1", "")
+	list(1)
+	list(1)
+
+	// Removing publishes an event, which invalidates the cached list.
+	mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").WithArgs("user1", "insight1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	req := httptest.NewRequest("DELETE", "/api/v1/favourites/insight1", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("remove failed: status %d, body: %s", rr.Code, rr.Body.String())
+	}
+	if len(published) != 1 || published[0].Type != events.FavouriteRemoved || published[0].AssetID != "insight1" {
+		t.Fatalf("expected one removal event, got %+v", published)
+	}
+
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols))
+	list(0)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_GetStats(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	added := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	expectTimezone(mock, "user1", "Europe/Athens")
+	mock.ExpectQuery("SELECT asset_type, COUNT").WithArgs("user1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"asset_type", "count", "min", "max", "recent"}).
+			AddRow("chart", 2, added, added, 2))
+
+	req := httptest.NewRequest("GET", "/api/v1/favourites/stats", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var stats handlers.FavouriteStats
+	json.Unmarshal(rr.Body.Bytes(), &stats)
+	if stats.Total != 2 || stats.AddedLast30Days != 2 || len(stats.Types) != 4 {
+		t.Errorf("unexpected stats: %s", rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "+03:00") {
+		t.Errorf("expected timestamps in the stored timezone, got %s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_GetRecent(t *testing.T) {
+	changed := time.Now().UTC()
+	insightData, _ := json.Marshal(models.Insight{ID: "i1", Text: "insight"})
+
+	tests := []struct {
+		name      string
+		query     string
+		setupMock func(sqlmock.Sqlmock)
+		wantCode  int
+		wantCount int
+	}{
+		{
+			name: "default window", query: "",
+			setupMock: func(m sqlmock.Sqlmock) {
+				expectTimezone(m, "user1", "")
+				m.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id = \\$1 AND updated_at >= \\$2").
+					WithArgs("user1", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(testCols).
+						AddRow("i1", "user1", "insight", "d", insightData, changed, changed, nil, nil, nil, nil, ""))
+			},
+			wantCode: http.StatusOK, wantCount: 1,
+		},
+		{
+			name: "invalid window", query: "?window=forever",
+			setupMock: func(m sqlmock.Sqlmock) { expectTimezone(m, "user1", "") },
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := setupTestHandler(t)
+			tt.setupMock(mock)
+
+			req := httptest.NewRequest("GET", "/api/v1/favourites/recent"+tt.query, nil)
+			req.Header.Set("Accept", "application/json")
+			addAuthHeader(req, "user1")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				var favourites []map[string]any
+				json.Unmarshal(rr.Body.Bytes(), &favourites)
+				if len(favourites) != tt.wantCount {
+					t.Errorf("expected %d favourites, got %s", tt.wantCount, rr.Body.String())
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestFavouritesRoutes_AdminImpersonation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := database.NewRepository(db, database.Options{})
+
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(e events.Event) { published = append(published, e) })
+
+	router := chi.NewRouter()
+	router.Use(logging.RequestLogger(testLogger()))
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Favourites: repo,
+		Store:      repo,
+		Auth:       auth.AuthConfig{AllowUnsignedTokens: true, AdminUsers: []string{"admin1"}},
+		Publisher:  bus,
+	}))
+
+	send := func(method, path, caller, onBehalfOf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-On-Behalf-Of", onBehalfOf)
+		addAuthHeader(req, caller)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The admin sees the user's favourites.
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols))
+	if rr := send("GET", "/api/v1/favourites", "admin1", "user1"); rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	// Changes are made to the user's favourites, with the admin as actor.
+	mock.ExpectExec("UPDATE favourites SET deleted_at = NOW").
+		WithArgs("user1", "insight1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if rr := send("DELETE", "/api/v1/favourites/insight1", "admin1", "user1"); rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if len(published) != 1 || published[0].UserID != "user1" || published[0].Actor != "admin1" {
+		t.Errorf("expected a removal of user1's favourite by admin1, got %+v", published)
+	}
+
+	// Other users cannot impersonate.
+	if rr := send("GET", "/api/v1/favourites", "user2", "user1"); rr.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a non-admin, got %d", rr.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
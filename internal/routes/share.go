@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/cache"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+)
+
+// sharedFavouritesPath is where signed URLs point, relative to the version
+// prefix. Links are issued for the version the share was requested through.
+const sharedFavouritesPath = "/shared/favourites"
+
+// createShareLinkRoute issues a signed, time-boxed URL giving read-only access
+// to the authenticated user's favourites, optionally of a single asset type.
+func createShareLinkRoute(secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		if secret == "" {
+			respondWithError(w, http.StatusServiceUnavailable, "signed URLs are not configured")
+			return
+		}
+
+		var req handlers.ShareRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("createShareLink").User(userID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+
+		grant, err := handlers.NewShareGrant(userID, &req, time.Now())
+		if err != nil {
+			var validationErr *handlers.ValidationError
+			if errors.As(err, &validationErr) {
+				respondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondWithServerError(w, err)
+			return
+		}
+		grant.TenantID = database.TenantFromContext(ctx)
+
+		link := handlers.ShareLink{
+			URL:       apiPrefix(ctx) + sharedFavouritesPath + "?" + auth.SignGrant(secret, grant).Encode(),
+			AssetType: grant.AssetType,
+			ExpiresAt: grant.ExpiresAt,
+		}
+
+		logging.Log(ctx).Layer("routes").Op("createShareLink").User(userID).
+			AssetType(grant.AssetType).Str("expires_at", grant.ExpiresAt.Format(time.RFC3339)).
+			Int("status_code", http.StatusCreated).Info("share link issued")
+		respondWithJSON(w, http.StatusCreated, link)
+	}
+}
+
+// getSharedFavouritesRoute serves the favourites covered by the signed grant
+// in the query string, within the grant's tenant. Timestamps are in UTC.
+func getSharedFavouritesRoute(h *handlers.Favourites, listCache *cache.ListCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		grant, _ := auth.GrantFromContext(ctx)
+		if grant.TenantID != "" {
+			ctx = database.WithTenant(ctx, grant.TenantID)
+		}
+		render, ok := renderHTML(w, r)
+		if !ok {
+			return
+		}
+
+		favourites, err := listCache.Fetch(cache.Key(grant.TenantID, grant.UserID), func() ([]*models.FavouriteAsset, error) {
+			return h.GetUserFavourites(ctx, grant.UserID)
+		})
+		if err != nil {
+			logging.Log(ctx).Layer("routes").Op("getSharedFavourites").User(grant.UserID).Err(err).
+				Error("failed to get shared favourites")
+			respondWithServerError(w, err)
+			return
+		}
+		favourites = handlers.FilterSharedFavourites(favourites, grant)
+		if !render {
+			handlers.OmitRenderedDescriptions(favourites)
+		}
+
+		logging.Log(ctx).Layer("routes").Op("getSharedFavourites").User(grant.UserID).
+			AssetType(grant.AssetType).Int("count", len(favourites)).
+			Int("status_code", http.StatusOK).Info("shared favourites retrieved successfully")
+		respondWithJSON(w, http.StatusOK, favourites)
+	}
+}
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+func setupShareHandler(t *testing.T, secret string) (*chi.Mux, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := database.NewRepository(db, database.Options{})
+
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Favourites: repo,
+		Store:      repo,
+		Auth:       auth.AuthConfig{AllowUnsignedTokens: true, SignedURLSecret: secret},
+		Publisher:  events.NewBus(),
+	}))
+	return router, mock
+}
+
+func createShareLink(router http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/v1/favourites/share", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestShareRoutes_SignedURLGrantsReadOnlySubset(t *testing.T) {
+	router, mock := setupShareHandler(t, "s3cret")
+
+	rr := createShareLink(router, `{"asset_type": "insight", "ttl": "10m"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var link handlers.ShareLink
+	json.Unmarshal(rr.Body.Bytes(), &link)
+	if !strings.HasPrefix(link.URL, "/api/v1/shared/favourites?") || time.Until(link.ExpiresAt) > 10*time.Minute {
+		t.Fatalf("unexpected link: %+v", link)
+	}
+
+	now := time.Now()
+	insightData, _ := json.Marshal(models.Insight{ID: "i1", Text: "insight"})
+	chartData, _ := json.Marshal(models.Chart{ID: "c1", Title: "chart"})
+	mock.ExpectQuery("SELECT (.+) FROM favourites WHERE user_id").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("i1", "user1", "insight", "d", insightData, now, now, nil, nil, nil, nil, "").
+			AddRow("c1", "user1", "chart", "d", chartData, now, now, nil, nil, nil, nil, ""))
+
+	// No Authorization header: the signature alone grants access.
+	req := httptest.NewRequest("GET", link.URL, nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var favourites []map[string]any
+	json.Unmarshal(rr.Body.Bytes(), &favourites)
+	if len(favourites) != 1 || favourites[0]["id"] != "i1" {
+		t.Errorf("expected only the shared insight, got %s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestShareRoutes_RejectsTamperedURL(t *testing.T) {
+	router, _ := setupShareHandler(t, "s3cret")
+
+	rr := createShareLink(router, `{"asset_type": "insight"}`)
+	var link handlers.ShareLink
+	json.Unmarshal(rr.Body.Bytes(), &link)
+
+	req := httptest.NewRequest("GET", strings.Replace(link.URL, "type=insight", "type=chart", 1), nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rr.Code)
+	}
+}
+
+func TestShareRoutes_NotConfigured(t *testing.T) {
+	router, _ := setupShareHandler(t, "")
+
+	if rr := createShareLink(router, `{}`); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+}
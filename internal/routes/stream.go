@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/stream"
+)
+
+// subscribeEventsRoute upgrades to a WebSocket that carries the authenticated
+// user's favourite change events until either side closes it.
+func subscribeEventsRoute(hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+
+		if hub == nil {
+			respondWithError(w, http.StatusServiceUnavailable, "event streaming is not enabled")
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("subscribeEvents").User(userID).Info("event stream opening")
+		if err := hub.Serve(w, r, database.TenantFromContext(ctx), userID); err != nil {
+			logging.Log(ctx).Layer("routes").Op("subscribeEvents").User(userID).Err(err).
+				Warn("event stream ended with error")
+			return
+		}
+		logging.Log(ctx).Layer("routes").Op("subscribeEvents").User(userID).Info("event stream closed")
+	}
+}
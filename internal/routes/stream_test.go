@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/giannis84/platform-go-challenge/authtest"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/stream"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSubscribeEvents_StreamsUsersEvents(t *testing.T) {
+	// Event frames are sent as is in every version; the v2 envelope only
+	// applies to HTTP response bodies.
+	for _, v := range Versions(Deps{}) {
+		t.Run(v.Name, func(t *testing.T) {
+			bus := events.NewBus()
+			hub := stream.NewHub(bus)
+			router := chi.NewRouter()
+			router.Use(logging.RequestLogger(testLogger()))
+			router.Group(RegisterFavouritesRoutes(Deps{
+				Auth:      auth.AuthConfig{AllowUnsignedTokens: true},
+				Publisher: bus,
+				Streams:   hub,
+			}))
+			srv := httptest.NewServer(router)
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			header := http.Header{}
+			header.Set("Accept", "application/json")
+			header.Set("Authorization", "Bearer "+authtest.TokenFor("user1"))
+			conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+v.Prefix+"/ws",
+				&websocket.DialOptions{HTTPHeader: header})
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.CloseNow()
+
+			for hub.Len() == 0 {
+				time.Sleep(5 * time.Millisecond)
+			}
+			bus.Publish(ctx, events.Event{Type: events.FavouriteRemoved, UserID: "user1", AssetID: "c1"})
+
+			var got events.Event
+			if err := wsjson.Read(ctx, conn, &got); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if got.Type != events.FavouriteRemoved || got.AssetID != "c1" {
+				t.Errorf("unexpected event: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSubscribeEvents_Errors(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	t.Run("requires a JWT", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ws", nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("503 when streaming is not enabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ws", nil)
+		req.Header.Set("Accept", "application/json")
+		addAuthHeader(req, "user1")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503, got %d", rr.Code)
+		}
+	})
+}
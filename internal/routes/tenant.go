@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"expvar"
+	"net/http"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/httprate"
+)
+
+// Requests and rate-limit refusals per tenant, published by expvar.
+var (
+	tenantRequests    = expvar.NewMap("tenant_requests")
+	tenantRateLimited = expvar.NewMap("tenant_rate_limited")
+)
+
+// tenantMiddleware confines the request's favourites to the tenant of its
+// principal (see database.WithTenant) and counts it in tenant_requests.
+// Principals without a tenant, such as API keys issued outside any tenant or
+// signing keys and certificates missing from auth.AuthConfig.ServiceTenants,
+// are refused with 403. The tenant stays the caller's when acting on behalf
+// of a user, so admins and services only reach the users of their own tenant.
+func tenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		principal, _ := auth.PrincipalFromContext(ctx)
+		if principal.TenantID == "" {
+			logging.Log(ctx).Layer("routes").User(auth.UserIDFromContext(ctx)).
+				Warn("request without a tenant refused")
+			respondWithError(w, http.StatusForbidden, "a tenant is required")
+			return
+		}
+		tenantRequests.Add(principal.TenantID, 1)
+		next.ServeHTTP(w, r.WithContext(database.WithTenant(ctx, principal.TenantID)))
+	})
+}
+
+// perTenantRateLimit limits the requests of each tenant, from all its users
+// and services, to requests per window. It runs after tenantMiddleware.
+func perTenantRateLimit(requests int, window time.Duration) func(http.Handler) http.Handler {
+	return httprate.Limit(
+		requests,
+		window,
+		httprate.WithKeyFuncs(func(r *http.Request) (string, error) {
+			return "tenant:" + database.TenantFromContext(r.Context()), nil
+		}),
+		httprate.WithLimitHandler(func(w http.ResponseWriter, r *http.Request) {
+			tenant := database.TenantFromContext(r.Context())
+			tenantRateLimited.Add(tenant, 1)
+			logging.Security(r, logging.EventRateLimited).User(auth.UserIDFromContext(r.Context())).
+				Str("tenant", tenant).Info("tenant rate limit exceeded")
+			respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		}),
+	)
+}
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/authtest"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRegisterFavouritesRoutes_MultiTenant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := database.NewRepository(db, database.Options{})
+
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Favourites:  repo,
+		Store:       repo,
+		Auth:        auth.AuthConfig{AllowUnsignedTokens: true},
+		RateLimit:   config.RateLimitConfig{TenantRequests: 2, Window: time.Minute},
+		Publisher:   events.NewBus(),
+		MultiTenant: true,
+	}))
+
+	send := func(path, sub string, claims jwt.MapClaims) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+authtest.TokenFor(sub, claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := send("/api/v1/favourites", "user1", jwt.MapClaims{}); code != http.StatusForbidden {
+		t.Errorf("expected 403 for a token without a tenant, got %d", code)
+	}
+
+	// Lists are confined to the tenant of the token
+	mock.ExpectQuery(`SELECT timezone FROM user_preferences WHERE user_id = \$1 AND tenant_id = \$2`).
+		WithArgs("user1", "acme").
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}))
+	mock.ExpectQuery(`SELECT .+ FROM favourites WHERE user_id = \$1 AND deleted_at IS NULL AND tenant_id = \$2`).
+		WithArgs("user1", "acme").
+		WillReturnRows(sqlmock.NewRows(testCols))
+	if code := send("/api/v1/favourites", "user1", jwt.MapClaims{"tenant_id": "acme"}); code != http.StatusOK {
+		t.Errorf("expected the tenant's list, got %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	// The tenant budget of 2 is shared by all its users; other tenants keep
+	// theirs. Without an export runner the job lookup answers 503 once a
+	// request gets past the limits.
+	if code := send("/api/v1/export-jobs/j1", "user2", jwt.MapClaims{"tenant_id": "acme"}); code != http.StatusServiceUnavailable {
+		t.Errorf("expected the second tenant request to pass, got %d", code)
+	}
+	if code := send("/api/v1/export-jobs/j1", "user3", jwt.MapClaims{"tenant_id": "acme"}); code != http.StatusTooManyRequests {
+		t.Errorf("expected the tenant budget to be exhausted, got %d", code)
+	}
+	if code := send("/api/v1/export-jobs/j1", "user4", jwt.MapClaims{"tenant_id": "globex"}); code != http.StatusServiceUnavailable {
+		t.Errorf("expected another tenant to keep its budget, got %d", code)
+	}
+}
+
+func TestRegisterFavouritesRoutes_MultiTenantServiceClients(t *testing.T) {
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth: auth.AuthConfig{
+			APIKeys: func(ctx context.Context, hash string) (string, string, error) {
+				switch hash {
+				case auth.HashAPIKey("pgc_acme"):
+					return "user1", "acme", nil
+				case auth.HashAPIKey("pgc_legacy"):
+					return "user1", "", nil
+				}
+				return "", "", nil
+			},
+			SigningKeys:    map[string]string{"partner1": "secret1", "partner2": "secret2"},
+			ServiceTenants: map[string]string{"partner1": "acme"},
+		},
+		Publisher:   events.NewBus(),
+		MultiTenant: true,
+	}))
+
+	signed := func(key, secret string) func(*http.Request) {
+		return func(req *http.Request) {
+			now := time.Now()
+			req.Header.Set(auth.SignatureKeyHeader, key)
+			req.Header.Set(auth.SignatureTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+			req.Header.Set(auth.SignatureHeader, auth.SignRequest(secret, req.Method, req.URL.RequestURI(), nil, now))
+		}
+	}
+	withKey := func(key string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set(auth.APIKeyHeader, key) }
+	}
+
+	// Without an export runner the job lookup answers 503 once a request
+	// gets past the tenant check.
+	tests := []struct {
+		name       string
+		prepare    func(*http.Request)
+		wantStatus int
+	}{
+		{name: "API key issued in a tenant", prepare: withKey("pgc_acme"), wantStatus: http.StatusServiceUnavailable},
+		{name: "API key without a tenant", prepare: withKey("pgc_legacy"), wantStatus: http.StatusForbidden},
+		{name: "signing key mapped to a tenant", prepare: signed("partner1", "secret1"), wantStatus: http.StatusServiceUnavailable},
+		{name: "signing key without a tenant", prepare: signed("partner2", "secret2"), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/export-jobs/j1", nil)
+			req.Header.Set("Accept", "application/json")
+			tt.prepare(req)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIV2Prefix is where version 2 of the API is mounted. APIPrefix (v1) is
+// frozen: new behaviour ships in v2 only.
+const APIV2Prefix = "/api/v2"
+
+// v1Operations are the routes v1 serves, as they were when v2 was
+// introduced. Routes added to the Table since are served by v2 only.
+var v1Operations = []string{
+	"getUserFavourites", "addUserFavourite", "batchUpdateUserFavourites", "removeAllUserFavourites",
+	"getRecentUserFavourites", "createShareLink", "getSharedFavourites",
+	"createExportJob", "getExportJob", "downloadExport",
+	"getUserQuota", "getUserStats", "getUserAudit",
+	"updateUserFavourite", "removeUserFavourite", "subscribeEvents",
+	"getUserPreferences", "updateUserPreferences",
+	"assetOwnership", "searchUsers", "getAdminUserFavourites", "mergeUserFavourites", "getAdminUserAudit",
+	"getWriteQueueStats",
+}
+
+// v1DeprecatedAt is when v2 was introduced and v1 deprecated.
+var v1DeprecatedAt = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+// Version is a mounted major version of the API. Versions serve the routes of
+// the Table through the same handlers; they differ in which routes they
+// serve, how responses are shaped and their lifecycle headers.
+type Version struct {
+	Name   string
+	Prefix string
+	// Enveloped wraps successful JSON bodies as {"data": ...} and turns
+	// errors into problem details (application/problem+json).
+	Enveloped bool
+	// Deprecation, when non-nil, is announced on every response.
+	Deprecation *Deprecation
+	// Operations, when non-nil, freezes the version: it serves only the
+	// routes of the Table with these operation IDs, and no development
+	// endpoints. Nil serves the whole Table.
+	Operations []string
+}
+
+// Frozen reports whether v no longer gets new routes.
+func (v Version) Frozen() bool {
+	return v.Operations != nil
+}
+
+// Serves reports whether v serves route.
+func (v Version) Serves(route Route) bool {
+	return !v.Frozen() || slices.Contains(v.Operations, route.Name)
+}
+
+// Deprecation describes a version's retirement.
+type Deprecation struct {
+	Since time.Time
+	// Sunset is when the version stops being served; zero when not decided.
+	Sunset time.Time
+	// Successor is the prefix of the version replacing it.
+	Successor string
+}
+
+// Versions lists the mounted API versions, oldest first. d.V1Sunset, when
+// set, is announced as v1's Sunset date.
+func Versions(d Deps) []Version {
+	return []Version{
+		{
+			Name:   "v1",
+			Prefix: APIPrefix,
+			Deprecation: &Deprecation{
+				Since:     v1DeprecatedAt,
+				Sunset:    d.V1Sunset,
+				Successor: APIV2Prefix,
+			},
+			Operations: v1Operations,
+		},
+		{Name: "v2", Prefix: APIV2Prefix, Enveloped: true},
+	}
+}
+
+type versionKey struct{}
+
+// versionMiddleware records the version serving the request, so handlers can
+// build links within the same version.
+func versionMiddleware(v Version) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v)))
+		})
+	}
+}
+
+// apiPrefix returns the prefix of the API version serving ctx, defaulting to v1.
+func apiPrefix(ctx context.Context) string {
+	if v, ok := ctx.Value(versionKey{}).(Version); ok {
+		return v.Prefix
+	}
+	return APIPrefix
+}
+
+// deprecationMiddleware announces a deprecated version with the Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers and links each response to the same
+// path in the successor version.
+func deprecationMiddleware(v Version) func(http.Handler) http.Handler {
+	dep := v.Deprecation
+	deprecation := fmt.Sprintf("@%d", dep.Since.Unix())
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", deprecation)
+			if !dep.Sunset.IsZero() {
+				h.Set("Sunset", dep.Sunset.UTC().Format(http.TimeFormat))
+			}
+			successor := dep.Successor + strings.TrimPrefix(r.URL.Path, v.Prefix)
+			h.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/jobs"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestVersions_V1IsDeprecated(t *testing.T) {
+	sunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	router := chi.NewRouter()
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Auth:     auth.AuthConfig{AllowUnsignedTokens: true},
+		V1Sunset: sunset,
+	}))
+
+	// Errors keep the v1 body and still carry the lifecycle headers.
+	req := httptest.NewRequest("GET", "/api/v1/favourites/quota", nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", rr.Code)
+	}
+	if got, want := rr.Header().Get("Deprecation"), "@1792195200"; got != want {
+		t.Errorf("expected Deprecation %q, got %q", want, got)
+	}
+	if got, want := rr.Header().Get("Sunset"), "Fri, 30 Apr 2027 00:00:00 GMT"; got != want {
+		t.Errorf("expected Sunset %q, got %q", want, got)
+	}
+	if got, want := rr.Header().Get("Link"), `</api/v2/favourites/quota>; rel="successor-version"`; got != want {
+		t.Errorf("expected Link %q, got %q", want, got)
+	}
+	var body map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if body["error"] == "" {
+		t.Errorf("expected a v1 error body, got %s", rr.Body.String())
+	}
+}
+
+func TestVersions_V2EnvelopesResponses(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	now := time.Now()
+
+	insightData, _ := json.Marshal(models.Insight{ID: "insight1", Text: "insight"})
+	expectTimezone(mock, "user1", "")
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("insight1", "user1", "insight", "d", insightData, now, now, nil, nil, nil, nil, ""))
+
+	req := httptest.NewRequest("GET", "/api/v2/favourites", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Deprecation") != "" {
+		t.Error("expected v2 not to be deprecated")
+	}
+	var body struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0]["id"] != "insight1" {
+		t.Errorf("expected the favourite inside data, got %s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestVersions_V2ProblemDetails(t *testing.T) {
+	router, _ := setupTestHandler(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID string
+		status int
+		detail string
+	}{
+		{"unauthenticated", "GET", "/api/v2/favourites", "", http.StatusUnauthorized, ""},
+		{"validation", "DELETE", "/api/v2/favourites", "user1", http.StatusBadRequest, "confirm"},
+		{"forbidden", "GET", "/api/v2/admin/users", "user1", http.StatusForbidden, ""},
+		{"unknown route", "GET", "/api/v2/nope", "user1", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(""))
+			req.Header.Set("Accept", "application/json")
+			if tt.userID != "" {
+				addAuthHeader(req, tt.userID)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("expected status %d, got %d. Body: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("expected Content-Type %s, got %q", problemContentType, ct)
+			}
+			var problem Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if problem.Status != tt.status || problem.Title != http.StatusText(tt.status) ||
+				problem.Type != "about:blank" || problem.Instance != tt.path || problem.Detail == "" {
+				t.Errorf("unexpected problem: %+v", problem)
+			}
+			if !strings.Contains(problem.Detail, tt.detail) {
+				t.Errorf("expected detail to mention %q, got %q", tt.detail, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestVersions_V2LinksStayInVersion(t *testing.T) {
+	router, _ := setupShareHandler(t, "s3cret")
+
+	req := httptest.NewRequest("POST", "/api/v2/favourites/share", bytes.NewBufferString(`{"ttl": "10m"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var body struct {
+		Data handlers.ShareLink `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &body)
+	if !strings.HasPrefix(body.Data.URL, "/api/v2/shared/favourites?") {
+		t.Errorf("expected a v2 share link, got %q", body.Data.URL)
+	}
+}
+
+func TestVersions_V2ExportDownloadIsNotEnveloped(t *testing.T) {
+	router, exporter := setupExportHandler(t)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, exportRequest(http.MethodPost, "/api/v2/favourites/export-jobs", "user1"))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created struct {
+		Data exportJobResponse `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	if !strings.HasPrefix(created.Data.StatusURL, "/api/v2/export-jobs/") || rr.Header().Get("Location") != created.Data.StatusURL {
+		t.Fatalf("expected a v2 status URL, got %q (Location %q)", created.Data.StatusURL, rr.Header().Get("Location"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exporter.Run(ctx, 1, testLogger())
+
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("export did not finish")
+		}
+		if job, err := exporter.Get("user1", created.Data.ID); err == nil && job.Status == jobs.StatusSucceeded {
+			break
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, exportRequest(http.MethodGet, created.Data.StatusURL+"/download", "user1"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if rr.Body.String() != `[{"id":"c1","user_id":"user1"}]` {
+		t.Errorf("expected the export file as is, got %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// replaceFavouriteAssetDataRoute replaces a favourite's asset data, keeping the
+// previous data in its version history.
+func replaceFavouriteAssetDataRoute(h *handlers.Favourites, maxAssetDataBytes int, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+		assetID := chi.URLParam(r, "assetID")
+
+		if err := handlers.ValidateAssetID(assetID); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req handlers.ReplaceAssetDataRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			logging.Log(ctx).Layer("routes").Op("replaceFavouriteAssetData").User(userID).Asset(assetID).Err(err).
+				Error("failed to decode request body")
+			respondInvalidBody(w, err)
+			return
+		}
+		if !checkAssetDataSize(w, r, req.AssetData, maxAssetDataBytes) {
+			return
+		}
+
+		result, err := h.ReplaceAssetData(ctx, publisher, userID, assetID, req.AssetData)
+		if err != nil {
+			respondWithVersionError(w, r, "replaceFavouriteAssetData", userID, assetID, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("replaceFavouriteAssetData").User(userID).Asset(assetID).
+			Int("version", result.CurrentVersion).Info("asset data replaced")
+		respondWithJSON(w, http.StatusOK, result)
+	}
+}
+
+func getFavouriteVersionsRoute(h *handlers.Favourites) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+		assetID := chi.URLParam(r, "assetID")
+
+		if err := handlers.ValidateAssetID(assetID); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		history, err := h.GetVersionHistory(ctx, userID, assetID)
+		if err != nil {
+			respondWithVersionError(w, r, "getFavouriteVersions", userID, assetID, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, history)
+	}
+}
+
+// revertFavouriteVersionRoute makes an earlier version the favourite's asset data.
+func revertFavouriteVersionRoute(h *handlers.Favourites, publisher events.Publisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := auth.UserIDFromContext(ctx)
+		assetID := chi.URLParam(r, "assetID")
+
+		if err := handlers.ValidateAssetID(assetID); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		version, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "version must be a positive integer")
+			return
+		}
+
+		result, err := h.RevertAssetData(ctx, publisher, userID, assetID, version)
+		if err != nil {
+			respondWithVersionError(w, r, "revertFavouriteVersion", userID, assetID, err)
+			return
+		}
+
+		logging.Log(ctx).Layer("routes").Op("revertFavouriteVersion").User(userID).Asset(assetID).
+			Int("reverted_to", version).Int("version", result.CurrentVersion).Info("asset data reverted")
+		respondWithJSON(w, http.StatusOK, result)
+	}
+}
+
+// respondWithVersionError maps errors of the version history handlers to responses.
+func respondWithVersionError(w http.ResponseWriter, r *http.Request, op, userID, assetID string, err error) {
+	var validationErr *handlers.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, database.ErrNotFound):
+		respondWithError(w, http.StatusNotFound, "Favourite not found")
+	case errors.Is(err, database.ErrVersionNotFound):
+		respondWithError(w, http.StatusNotFound, "Version not found")
+	default:
+		logging.Log(r.Context()).Layer("routes").Op(op).User(userID).Asset(assetID).Err(err).
+			Error("favourite version operation failed")
+		respondWithServerError(w, err)
+	}
+}
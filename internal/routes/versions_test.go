@@ -0,0 +1,192 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/handlers"
+	"github.com/go-chi/chi/v5"
+)
+
+const storedChart = `{"id":"chart1","title":"Revenue","x_axis_title":"Month","y_axis_title":"USD"}`
+
+func expectStoredChart(mock sqlmock.Sqlmock) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").
+		WithArgs("user1", "chart1").
+		WillReturnRows(sqlmock.NewRows(testCols).
+			AddRow("chart1", "user1", "chart", "Revenue chart", []byte(storedChart), now, now, nil, nil, nil, nil, ""))
+}
+
+func sendVersionRequest(t *testing.T, router *chi.Mux, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "user1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestFavouritesRoutes_ReplaceAssetData(t *testing.T) {
+	newChart := map[string]any{"id": "chart1", "title": "Revenue 2026", "x_axis_title": "Month", "y_axis_title": "USD"}
+
+	t.Run("keeps the replaced data as a version", func(t *testing.T) {
+		router, mock := setupTestHandler(t)
+		mock.ExpectBegin()
+		expectStoredChart(mock)
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "chart1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}).AddRow([]byte(storedChart), ""))
+		mock.ExpectQuery("INSERT INTO favourite_versions").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+		mock.ExpectExec("UPDATE favourites SET data").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rr := sendVersionRequest(t, router, "PUT", "/api/v2/favourites/chart1", map[string]any{"asset_data": newChart})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var result struct {
+			Data handlers.VersionResult `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &result)
+		if result.Data.AssetID != "chart1" || result.Data.CurrentVersion != 2 {
+			t.Errorf("unexpected result: %s", rr.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("rejects a different asset ID", func(t *testing.T) {
+		router, mock := setupTestHandler(t)
+		mock.ExpectBegin()
+		expectStoredChart(mock)
+		mock.ExpectRollback()
+
+		other := map[string]any{"id": "chart2", "title": "Revenue", "x_axis_title": "Month", "y_axis_title": "USD"}
+		rr := sendVersionRequest(t, router, "PUT", "/api/v2/favourites/chart1", map[string]any{"asset_data": other})
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `asset_data.id must be the favourite's asset ID`) {
+			t.Errorf("expected 400 for a changed ID, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("rejects oversized data", func(t *testing.T) {
+		router, _ := setupTestHandler(t)
+		big := map[string]any{"id": "chart1", "title": strings.Repeat("x", 2048)}
+		rr := sendVersionRequest(t, router, "PUT", "/api/v2/favourites/chart1", map[string]any{"asset_data": big})
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+		}
+	})
+
+	t.Run("unknown favourite", func(t *testing.T) {
+		router, mock := setupTestHandler(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id").WithArgs("user1", "chart1").
+			WillReturnRows(sqlmock.NewRows(testCols))
+		mock.ExpectRollback()
+
+		rr := sendVersionRequest(t, router, "PUT", "/api/v2/favourites/chart1", map[string]any{"asset_data": newChart})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestFavouritesRoutes_GetVersions(t *testing.T) {
+	router, mock := setupTestHandler(t)
+	expectStoredChart(mock)
+	mock.ExpectQuery("SELECT version, data, replaced_at, tenant_id FROM favourite_versions").WithArgs("user1", "chart1").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "data", "replaced_at", "tenant_id"}).
+			AddRow(1, []byte(storedChart), time.Now(), ""))
+
+	rr := sendVersionRequest(t, router, "GET", "/api/v2/favourites/chart1/versions", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var history struct {
+		Data struct {
+			CurrentVersion int `json:"current_version"`
+			Versions       []struct {
+				Version int            `json:"version"`
+				Data    map[string]any `json:"data"`
+			} `json:"versions"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &history)
+	if history.Data.CurrentVersion != 2 || len(history.Data.Versions) != 1 || history.Data.Versions[0].Data["title"] != "Revenue" {
+		t.Errorf("unexpected history: %s", rr.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_RevertVersion(t *testing.T) {
+	t.Run("reverts", func(t *testing.T) {
+		router, mock := setupTestHandler(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "chart1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}).AddRow([]byte(storedChart), ""))
+		mock.ExpectQuery("SELECT data FROM favourite_versions").WithArgs("", "user1", "chart1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow([]byte(storedChart)))
+		mock.ExpectQuery("INSERT INTO favourite_versions").
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.ExpectExec("UPDATE favourites SET data").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rr := sendVersionRequest(t, router, "POST", "/api/v2/favourites/chart1/versions/1/revert", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var result struct {
+			Data handlers.VersionResult `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &result)
+		if result.Data.CurrentVersion != 3 {
+			t.Errorf("unexpected result: %s", rr.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		router, mock := setupTestHandler(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT .+ FROM favourites WHERE user_id .+ FOR UPDATE").WithArgs("user1", "chart1").
+			WillReturnRows(sqlmock.NewRows([]string{"data", "tenant_id"}).AddRow([]byte(storedChart), ""))
+		mock.ExpectQuery("SELECT data FROM favourite_versions").WithArgs("", "user1", "chart1", 5).
+			WillReturnRows(sqlmock.NewRows([]string{"data"}))
+		mock.ExpectRollback()
+
+		rr := sendVersionRequest(t, router, "POST", "/api/v2/favourites/chart1/versions/5/revert", nil)
+		if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "Version not found") {
+			t.Errorf("expected 404 Version not found, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		router, _ := setupTestHandler(t)
+		for _, v := range []string{"0", "abc"} {
+			rr := sendVersionRequest(t, router, "POST", "/api/v2/favourites/chart1/versions/"+v+"/revert", nil)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("version %q: expected status %d, got %d", v, http.StatusBadRequest, rr.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+	"github.com/giannis84/platform-go-challenge/internal/queue"
+	"github.com/go-chi/chi/v5"
+)
+
+func setupTestHandlerWithQueue(t *testing.T, capacity int) (*chi.Mux, sqlmock.Sqlmock, *queue.WriteQueue) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := database.NewRepository(db, database.Options{})
+
+	writeQueue, err := queue.Open(filepath.Join(t.TempDir(), "queue.json"), capacity)
+	if err != nil {
+		t.Fatalf("failed to open write queue: %v", err)
+	}
+
+	router := chi.NewRouter()
+	router.Use(logging.RequestLogger(testLogger()))
+	router.Group(RegisterFavouritesRoutes(Deps{
+		Favourites: repo,
+		Store:      repo,
+		Auth:       auth.AuthConfig{AllowUnsignedTokens: true, AdminUsers: []string{"admin1"}},
+		Publisher:  events.NewBus(),
+		WriteQueue: writeQueue,
+	}))
+	return router, mock, writeQueue
+}
+
+func expectInsertUnavailable(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("INSERT INTO favourites").
+		WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+}
+
+func TestFavouritesRoutes_AddFavouriteQueuedWhenDatabaseUnavailable(t *testing.T) {
+	router, mock, writeQueue := setupTestHandlerWithQueue(t, 1)
+
+	expectInsertUnavailable(mock)
+	rr := postFavourite(t, router, insightRequestBody())
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+
+	// The same favourite is already waiting in the queue.
+	expectInsertUnavailable(mock)
+	rr = postFavourite(t, router, insightRequestBody())
+	if rr.Code != http.StatusConflict {
+		t.Errorf("expected status %d for duplicate, got %d", http.StatusConflict, rr.Code)
+	}
+
+	// The queue is full.
+	expectInsertUnavailable(mock)
+	rr = postFavourite(t, router, audienceRequestBody())
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d when full, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	if depth := writeQueue.Stats().Depth; depth != 1 {
+		t.Errorf("expected queue depth 1, got %d", depth)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestFavouritesRoutes_AddFavouriteErrorWithoutQueue(t *testing.T) {
+	router, mock := setupTestHandler(t)
+
+	expectInsertUnavailable(mock)
+	rr := postFavourite(t, router, insightRequestBody())
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d without a queue, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
+
+func TestAdminRoutes_WriteQueueStats(t *testing.T) {
+	router, mock, _ := setupTestHandlerWithQueue(t, 5)
+	expectInsertUnavailable(mock)
+	postFavourite(t, router, insightRequestBody())
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/queue", nil)
+	req.Header.Set("Accept", "application/json")
+	addAuthHeader(req, "admin1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var stats queue.Stats
+	json.Unmarshal(rr.Body.Bytes(), &stats)
+	if stats.Depth != 1 || stats.Capacity != 5 || stats.OldestQueuedAt == nil {
+		t.Errorf("unexpected stats: %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials sign requests to AWS, as read from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN env vars.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager. A reference is a secret
+// name or ARN, optionally followed by "#key" to read one field of a secret
+// stored as a JSON object.
+type AWSProvider struct {
+	region   string
+	endpoint string
+	creds    AWSCredentials
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewAWSProvider returns a provider reading from Secrets Manager in region.
+func NewAWSProvider(region string, creds AWSCredentials) (*AWSProvider, error) {
+	if region == "" {
+		return nil, fmt.Errorf("aws region is required")
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws access key ID and secret access key are required")
+	}
+	return &AWSProvider{
+		region:   region,
+		endpoint: "https://secretsmanager." + region + ".amazonaws.com",
+		creds:    creds,
+		client:   &http.Client{Timeout: fetchTimeout},
+		now:      time.Now,
+	}, nil
+}
+
+// Fetch returns the current version of the secret ref names.
+func (p *AWSProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	id, key := splitRef(ref)
+	payload, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", fmt.Errorf("encoding secrets manager request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("building secrets manager request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	p.sign(req, payload, p.now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching secret %s from secrets manager: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding secrets manager response for %s: %w", id, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if strings.HasSuffix(body.Type, "ResourceNotFoundException") {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return "", fmt.Errorf("fetching secret %s from secrets manager: status %d: %s %s", id, resp.StatusCode, body.Type, body.Message)
+	}
+	if key == "" {
+		return body.SecretString, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(body.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", id, err)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no string field %q", ErrNotFound, id, key)
+	}
+	return value, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req, whose
+// body is payload.
+func (p *AWSProvider) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if p.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", p.creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+	scope := date + "/" + p.region + "/secretsmanager/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := signingKey(p.creds.SecretAccessKey, date, p.region, "secretsmanager")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		p.creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// signingKey derives the Signature Version 4 key for date, region and service.
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation.
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	if got := hex.EncodeToString(key); got != "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9" {
+		t.Errorf("signingKey = %s", got)
+	}
+}
+
+func TestAWSProvider_Fetch(t *testing.T) {
+	secrets := map[string]string{
+		"favourites/jwt": "jwt-secret",
+		"favourites/db":  `{"username":"app","password":"pg-pass"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260101/eu-west-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=") ||
+			r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || r.Header.Get("X-Amz-Security-Token") != "session" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "InvalidSignatureException", "message": auth})
+			return
+		}
+		var req struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&req)
+		value, ok := secrets[req.SecretId]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException", "message": "not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": value})
+	}))
+	defer srv.Close()
+
+	p, err := NewAWSProvider("eu-west-1", AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "session"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.endpoint = srv.URL
+	p.now = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{name: "plain secret", ref: "favourites/jwt", want: "jwt-secret"},
+		{name: "JSON field", ref: "favourites/db#password", want: "pg-pass"},
+		{name: "missing JSON field", ref: "favourites/db#host", wantErr: ErrNotFound},
+		{name: "missing secret", ref: "favourites/other", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Fetch(ctx, tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Fetch = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewAWSProvider("", AWSCredentials{AccessKeyID: "a", SecretAccessKey: "b"}); err == nil {
+		t.Error("expected an error without a region")
+	}
+	if _, err := NewAWSProvider("eu-west-1", AWSCredentials{}); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
@@ -0,0 +1,136 @@
+// Package secrets fetches credentials such as the JWT secret and the database
+// password from a secrets provider, and keeps them current as they rotate.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giannis84/platform-go-challenge/internal/logging"
+)
+
+// ErrNotFound is returned when a provider holds no secret under a reference.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider fetches the current value of the secret a reference names. The
+// form of a reference depends on the provider.
+type Provider interface {
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// FileProvider reads secrets from files in Dir, as mounted by Docker and
+// Kubernetes secrets. A reference is a file name relative to Dir; trailing
+// newlines are dropped.
+type FileProvider struct {
+	Dir string
+}
+
+// Fetch returns the contents of the file ref.
+func (p FileProvider) Fetch(_ context.Context, ref string) (string, error) {
+	if !filepath.IsLocal(ref) {
+		return "", fmt.Errorf("secret file %q must be relative to the secrets directory", ref)
+	}
+	data, err := os.ReadFile(filepath.Join(p.Dir, ref))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading secret %s: %w", ref, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Value is a secret fetched from a Provider, kept current by Refresh or
+// Watch. It is safe for concurrent use.
+type Value struct {
+	provider Provider
+	ref      string
+
+	mu       sync.RWMutex
+	current  string
+	onChange []func(string)
+}
+
+// NewValue returns the secret ref of provider, holding initial until it is
+// first refreshed.
+func NewValue(provider Provider, ref, initial string) *Value {
+	return &Value{provider: provider, ref: ref, current: initial}
+}
+
+// Get returns the current value of the secret.
+func (v *Value) Get() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.current
+}
+
+// OnChange registers fn to be called with the new value whenever a refresh
+// finds the secret rotated.
+func (v *Value) OnChange(fn func(string)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.onChange = append(v.onChange, fn)
+}
+
+// Refresh refetches the secret, reporting whether it changed. An empty or
+// failed fetch keeps the current value.
+func (v *Value) Refresh(ctx context.Context) (bool, error) {
+	value, err := v.provider.Fetch(ctx, v.ref)
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, fmt.Errorf("secret %s is empty", v.ref)
+	}
+
+	v.mu.Lock()
+	if value == v.current {
+		v.mu.Unlock()
+		return false, nil
+	}
+	v.current = value
+	callbacks := v.onChange
+	v.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(value)
+	}
+	return true, nil
+}
+
+// Watch refreshes values every interval until ctx is done. Rotations and
+// failed refreshes are logged; a failed refresh keeps the previous value.
+func Watch(ctx context.Context, logger *slog.Logger, interval time.Duration, values ...*Value) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, v := range values {
+			changed, err := v.Refresh(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				logger.Warn("failed to refresh secret", slog.String("ref", v.ref), slog.String(logging.ErrorKey, err.Error()))
+			case changed:
+				logger.Info("secret rotated", slog.String("ref", v.ref))
+			}
+		}
+	}
+}
+
+// splitRef splits a "name#key" reference into the secret's name and the key
+// of the field to read from it, which is empty when there is no '#'.
+func splitRef(ref string) (name, key string) {
+	name, key, _ = strings.Cut(ref, "#")
+	return name, key
+}
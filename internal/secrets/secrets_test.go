@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("writing secret: %v", err)
+	}
+	p := FileProvider{Dir: dir}
+	ctx := context.Background()
+
+	if got, err := p.Fetch(ctx, "jwt_secret"); err != nil || got != "s3cret" {
+		t.Errorf("Fetch = %q, %v; want %q", got, err, "s3cret")
+	}
+	if _, err := p.Fetch(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing file, got %v", err)
+	}
+	if _, err := p.Fetch(ctx, "../etc/passwd"); err == nil {
+		t.Error("expected an error for a reference outside the directory")
+	}
+}
+
+// stubProvider returns the value set for each reference.
+type stubProvider map[string]string
+
+func (p stubProvider) Fetch(_ context.Context, ref string) (string, error) {
+	v, ok := p[ref]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func TestValue_Refresh(t *testing.T) {
+	provider := stubProvider{"db": "old"}
+	v := NewValue(provider, "db", "old")
+	var rotated []string
+	v.OnChange(func(s string) { rotated = append(rotated, s) })
+	ctx := context.Background()
+
+	if changed, err := v.Refresh(ctx); err != nil || changed {
+		t.Errorf("Refresh = %v, %v; want no change", changed, err)
+	}
+
+	provider["db"] = "new"
+	if changed, err := v.Refresh(ctx); err != nil || !changed {
+		t.Errorf("Refresh = %v, %v; want a change", changed, err)
+	}
+	if v.Get() != "new" || len(rotated) != 1 || rotated[0] != "new" {
+		t.Errorf("expected the rotated value to be kept and reported once, got %q and %v", v.Get(), rotated)
+	}
+
+	// Failed or empty fetches keep the last value.
+	provider["db"] = ""
+	if _, err := v.Refresh(ctx); err == nil || v.Get() != "new" {
+		t.Errorf("expected an empty secret to be rejected, got %v and value %q", err, v.Get())
+	}
+	delete(provider, "db")
+	if _, err := v.Refresh(ctx); !errors.Is(err, ErrNotFound) || v.Get() != "new" {
+		t.Errorf("expected ErrNotFound and the previous value, got %v and value %q", err, v.Get())
+	}
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultVaultMount is the mount path of Vault's default KV engine.
+	DefaultVaultMount = "secret"
+
+	// defaultVaultKey is the field read from a secret whose reference names none.
+	defaultVaultKey = "value"
+
+	fetchTimeout = 10 * time.Second
+)
+
+// VaultProvider reads secrets from a HashiCorp Vault KV version 2 engine. A
+// reference is "path#key", naming the field key of the secret at path
+// ("value" when the key is omitted).
+type VaultProvider struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+// NewVaultProvider returns a provider reading from the Vault server at addr,
+// authenticated with token, from the KV engine mounted at mount
+// (DefaultVaultMount when empty).
+func NewVaultProvider(addr, token, mount string) (*VaultProvider, error) {
+	if u, err := url.Parse(addr); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("vault address must be an absolute http(s) URL, got %q", addr)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("vault token is required")
+	}
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	return &VaultProvider{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		client: &http.Client{Timeout: fetchTimeout},
+	}, nil
+}
+
+// Fetch returns the latest version of the field ref names.
+func (p *VaultProvider) Fetch(ctx context.Context, ref string) (string, error) {
+	path, key := splitRef(ref)
+	if key == "" {
+		key = defaultVaultKey
+	}
+	endpoint := p.addr + "/v1/" + p.mount + "/data/" + strings.Trim(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("building vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching secret %s from vault: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching secret %s from vault: unexpected status %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding vault secret %s: %w", path, err)
+	}
+	value, ok := body.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no string field %q", ErrNotFound, path, key)
+	}
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVaultProvider_Fetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/favourites/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{"data": map[string]any{"password": "pg-pass", "value": "default"}},
+		})
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	p, err := NewVaultProvider(srv.URL, "root-token", "kv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{name: "field", ref: "favourites/db#password", want: "pg-pass"},
+		{name: "default field", ref: "favourites/db", want: "default"},
+		{name: "missing field", ref: "favourites/db#user", wantErr: ErrNotFound},
+		{name: "missing secret", ref: "favourites/jwt#secret", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Fetch(ctx, tt.ref)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Fetch = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	denied, _ := NewVaultProvider(srv.URL, "wrong-token", "kv")
+	if _, err := denied.Fetch(ctx, "favourites/db#password"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+
+	if _, err := NewVaultProvider("vault:8200", "root-token", ""); err == nil {
+		t.Error("expected an error for an address without a scheme")
+	}
+	if _, err := NewVaultProvider(srv.URL, "", ""); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
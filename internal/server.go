@@ -1,12 +1,12 @@
 package internal
 
 import (
-	"database/sql"
+	"crypto/tls"
 	"log/slog"
 	"net/http"
 	"time"
 
-	"github.com/giannis84/platform-go-challenge/internal/database"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
 	"github.com/giannis84/platform-go-challenge/internal/logging"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -20,11 +20,18 @@ type Service struct {
 	// Configuration fields (set before Init)
 	Addr         string
 	Logger       *slog.Logger
-	DB           *sql.DB
 	Routes       RoutesRegistry
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	// TLS, when set, makes the service serve HTTPS. If it verifies client
+	// certificates, their principal, read from the ClientCertPrincipal
+	// field, is placed into the request context.
+	TLS                 *tls.Config
+	ClientCertPrincipal string
+	// PanicAlert, when set, is told about every panic recovered while
+	// serving a request.
+	PanicAlert logging.PanicAlert
 
 	// Runtime fields (populated by Init)
 	HTTPServer *http.Server
@@ -35,16 +42,14 @@ type Service struct {
 func (s *Service) Init() {
 	s.Router = chi.NewRouter()
 
-	// Set up database connection for the database package
-	if s.DB != nil {
-		database.DB = s.DB
-	}
-
-	// Initialize common middleware
-	s.Router.Use(middleware.RequestID)
+	// Initialize common middleware. RequestLogger sets the request ID, adopting
+	// the caller's when valid.
 	s.Router.Use(logging.RequestLogger(s.Logger))
 	s.Router.Use(middleware.Logger)
-	s.Router.Use(middleware.Recoverer)
+	s.Router.Use(logging.Recoverer(s.PanicAlert))
+	if s.TLS != nil && s.TLS.ClientAuth != tls.NoClientCert {
+		s.Router.Use(auth.ClientCertMiddleware(s.ClientCertPrincipal))
+	}
 
 	// Register routes
 	if s.Routes != nil {
@@ -71,11 +76,16 @@ func (s *Service) Init() {
 		ReadTimeout:  readTimeout,
 		WriteTimeout: writeTimeout,
 		IdleTimeout:  idleTimeout,
+		TLSConfig:    s.TLS,
 	}
 }
 
 // ListenAndServeWrapper starts the http service
 func (s *Service) ListenAndServeWrapper(service string) error {
-	s.Logger.Info("starting http service", service, slog.String("port", s.HTTPServer.Addr))
+	s.Logger.Info("starting http service", service, slog.String("port", s.HTTPServer.Addr), slog.Bool("tls", s.TLS != nil))
+	if s.TLS != nil {
+		// The certificates are already loaded into TLSConfig.
+		return s.HTTPServer.ListenAndServeTLS("", "")
+	}
 	return s.HTTPServer.ListenAndServe()
-}
\ No newline at end of file
+}
@@ -0,0 +1,158 @@
+// Package stream pushes favourite change events to connected WebSocket
+// clients.
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+const (
+	// PingInterval is how often an idle connection is probed; a client that
+	// does not answer with a pong within PongTimeout is disconnected.
+	PingInterval = 30 * time.Second
+	PongTimeout  = 10 * time.Second
+	// writeTimeout bounds sending a single event.
+	writeTimeout = 5 * time.Second
+	// sendBuffer is how many events may wait for a client before it is
+	// dropped as too slow; the bus must never block on a client.
+	sendBuffer = 32
+)
+
+// Hub tracks the open event streams so they can be closed on shutdown.
+type Hub struct {
+	sub          events.Subscriber
+	pingInterval time.Duration
+
+	mu      sync.Mutex
+	clients map[*client]struct{}
+	closed  bool
+}
+
+type client struct {
+	send chan events.Event
+	done chan struct{}
+	once sync.Once
+
+	code   websocket.StatusCode
+	reason string
+}
+
+// stop asks the client's stream to close with code; only the first call counts.
+func (c *client) stop(code websocket.StatusCode, reason string) {
+	c.once.Do(func() {
+		c.code, c.reason = code, reason
+		close(c.done)
+	})
+}
+
+// NewHub creates a Hub that streams the events delivered by sub.
+func NewHub(sub events.Subscriber) *Hub {
+	return &Hub{sub: sub, pingInterval: PingInterval, clients: make(map[*client]struct{})}
+}
+
+// Serve upgrades the request to a WebSocket and sends the favourite change
+// events of userID, within tenantID, as JSON text messages until the client disconnects, stops
+// answering pings, falls behind, or the hub shuts down. Messages sent by the
+// client are not expected; any data frame closes the stream.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, tenantID, userID string) error {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		// Accept has already written the error response.
+		return fmt.Errorf("accepting websocket: %w", err)
+	}
+
+	c := &client{send: make(chan events.Event, sendBuffer), done: make(chan struct{})}
+	if !h.add(c) {
+		conn.Close(websocket.StatusGoingAway, "server shutting down")
+		return nil
+	}
+	defer h.remove(c)
+
+	unsubscribe := h.sub.Subscribe(func(e events.Event) {
+		if e.UserID != userID || e.TenantID != tenantID {
+			return
+		}
+		select {
+		case c.send <- e:
+		default:
+			c.stop(websocket.StatusPolicyViolation, "client too slow")
+		}
+	})
+	defer unsubscribe()
+
+	// CloseRead answers pings and processes pongs and close frames; its
+	// context ends when the client goes away.
+	ctx := conn.CloseRead(r.Context())
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.CloseNow()
+			return nil
+		case <-c.done:
+			conn.Close(c.code, c.reason)
+			return nil
+		case e := <-c.send:
+			writeCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+			err := wsjson.Write(writeCtx, conn, e)
+			cancel()
+			if err != nil {
+				conn.CloseNow()
+				return fmt.Errorf("sending event: %w", err)
+			}
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, PongTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				conn.CloseNow()
+				return fmt.Errorf("keepalive: %w", err)
+			}
+		}
+	}
+}
+
+// Shutdown closes every open stream with 1001 (going away) so clients can
+// reconnect elsewhere, and refuses new ones. Hijacked connections are not
+// covered by http.Server.Shutdown, so register this with RegisterOnShutdown.
+func (h *Hub) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		c.stop(websocket.StatusGoingAway, "server shutting down")
+	}
+}
+
+// Len returns the number of open streams.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func (h *Hub) add(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	return true
+}
+
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, c)
+}
@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/giannis84/platform-go-challenge/internal/events"
+)
+
+// startHub serves hub for user1 and dials it, waiting until the stream is registered.
+func startHub(t *testing.T, hub *Hub) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.Serve(w, r, "", "user1")
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+
+	for deadline := time.Now().Add(2 * time.Second); hub.Len() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("stream was not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return conn
+}
+
+func TestHub_StreamsOnlyTheUsersEvents(t *testing.T) {
+	bus := events.NewBus()
+	conn := startHub(t, NewHub(bus))
+
+	bus.Publish(context.Background(), events.Event{Type: events.FavouriteAdded, UserID: "user2", AssetID: "other"})
+	bus.Publish(context.Background(), events.Event{Type: events.FavouriteAdded, UserID: "user1", AssetID: "c1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var got events.Event
+	if err := wsjson.Read(ctx, conn, &got); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got.UserID != "user1" || got.AssetID != "c1" || got.Type != events.FavouriteAdded {
+		t.Errorf("unexpected event: %+v", got)
+	}
+}
+
+func TestHub_ShutdownClosesStreamsAndRefusesNewOnes(t *testing.T) {
+	hub := NewHub(events.NewBus())
+	conn := startHub(t, hub)
+
+	hub.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, _, err := conn.Read(ctx)
+	if status := websocket.CloseStatus(err); status != websocket.StatusGoingAway {
+		t.Fatalf("expected close status %d, got %d (err %v)", websocket.StatusGoingAway, status, err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.Serve(w, r, "", "user1")
+	}))
+	defer srv.Close()
+	late, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer late.CloseNow()
+	_, _, err = late.Read(ctx)
+	if status := websocket.CloseStatus(err); status != websocket.StatusGoingAway {
+		t.Errorf("expected new stream to be closed with %d, got %d", websocket.StatusGoingAway, status)
+	}
+}
+
+func TestHub_EndsWhenClientDisconnects(t *testing.T) {
+	hub := NewHub(events.NewBus())
+
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done <- hub.Serve(w, r, "", "user1")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	conn.Close(websocket.StatusNormalClosure, "")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream was not closed")
+	}
+	if hub.Len() != 0 {
+		t.Errorf("expected no open streams, got %d", hub.Len())
+	}
+}
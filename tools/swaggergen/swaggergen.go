@@ -3,30 +3,55 @@
 //
 // Usage:
 //
-//	go run ./tools/swaggergen
+//	go run ./tools/swaggergen [-config config.yaml]
+//
+// With -config, the audience enumerations configured in that file are
+// documented instead of the built-in ones, e.g. to publish a market's spec.
 //
 // # For Contributors
 //
-// When you modify the API (add/change endpoints, request/response schemas, etc.),
-// update this file to keep the swagger spec in sync:
+// Paths, methods, summaries and operation IDs come from routes.Table, which also
+// drives the router, so the spec cannot list an endpoint the service does not
+// serve. One spec is generated per routes.Versions entry: v1 (deprecated) is
+// written to api/swagger.{json,yaml} and later versions to
+// api/<version>/swagger.{json,yaml}. Operations are documented once, in v1
+// shapes; buildSpec() wraps them in the envelope and problem details of
+// enveloped versions. When you modify the API (add/change endpoints, request/response schemas, etc.):
 //
-//  1. Endpoints: Edit buildPaths() to add/modify path items and operations
-//  2. Schemas: Edit buildSchemas() to add/modify request/response types
+//  1. Endpoints: Add/modify the row in routes.Table, then document its parameters,
+//     request body and handler responses in operationDocs() under the route's Name
+//     (responses produced by the shared middleware are added by buildPaths())
+//  2. Schemas: Edit buildSchemas() to add/modify request/response types. Asset
+//     payload schemas are registered with their type in internal/assets and
+//     picked up from there
 //  3. Regenerate: Run `go run ./tools/swaggergen` from the project root
-//  4. Verify: Check api/swagger.yaml and api/swagger.json for correctness
+//  4. Verify: Check api/swagger.{yaml,json} and api/v2/swagger.{yaml,json} for correctness
 //
 // Helper functions:
-//   - errContent(): Returns standard error response content (reuse for error responses)
+//   - errContent(): Returns standard error response content (reuse for error responses;
+//     enveloped versions swap it for problemContent())
 //   - assetIDParam(): Returns the {assetID} path parameter definition
+//   - userIDParam(): Returns the {userID} path parameter definition of admin routes
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/giannis84/platform-go-challenge/internal/assets"
+	"github.com/giannis84/platform-go-challenge/internal/auth"
+	"github.com/giannis84/platform-go-challenge/internal/config"
+	"github.com/giannis84/platform-go-challenge/internal/models"
+	"github.com/giannis84/platform-go-challenge/internal/routes"
 	"gopkg.in/yaml.v3"
 )
 
@@ -50,8 +75,10 @@ type Info struct {
 type PathItem struct {
 	Get    *Operation `json:"get,omitempty"    yaml:"get,omitempty"`
 	Post   *Operation `json:"post,omitempty"   yaml:"post,omitempty"`
+	Put    *Operation `json:"put,omitempty"    yaml:"put,omitempty"`
 	Patch  *Operation `json:"patch,omitempty"  yaml:"patch,omitempty"`
 	Delete *Operation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Head   *Operation `json:"head,omitempty"   yaml:"head,omitempty"`
 }
 
 type Operation struct {
@@ -59,6 +86,7 @@ type Operation struct {
 	Summary     string                `json:"summary"               yaml:"summary"`
 	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
 	OperationID string                `json:"operationId"           yaml:"operationId"`
+	Deprecated  bool                  `json:"deprecated,omitempty"  yaml:"deprecated,omitempty"`
 	Security    []map[string][]string `json:"security,omitempty"    yaml:"security,omitempty"`
 	Parameters  []Parameter           `json:"parameters,omitempty"  yaml:"parameters,omitempty"`
 	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
@@ -91,6 +119,10 @@ type Response struct {
 type Schema struct {
 	Type                 string            `json:"type,omitempty"                 yaml:"type,omitempty"`
 	Format               string            `json:"format,omitempty"               yaml:"format,omitempty"`
+	MaxLength            *int              `json:"maxLength,omitempty"            yaml:"maxLength,omitempty"`
+	MaxItems             *int              `json:"maxItems,omitempty"             yaml:"maxItems,omitempty"`
+	Minimum              *float64          `json:"minimum,omitempty"              yaml:"minimum,omitempty"`
+	Maximum              *float64          `json:"maximum,omitempty"              yaml:"maximum,omitempty"`
 	Description          string            `json:"description,omitempty"          yaml:"description,omitempty"`
 	Properties           map[string]Schema `json:"properties,omitempty"           yaml:"properties,omitempty"`
 	Items                *Schema           `json:"items,omitempty"                yaml:"items,omitempty"`
@@ -108,136 +140,874 @@ type Components struct {
 }
 
 type SecurityScheme struct {
-	Type         string `json:"type"         yaml:"type"`
-	Scheme       string `json:"scheme"       yaml:"scheme"`
-	BearerFormat string `json:"bearerFormat" yaml:"bearerFormat"`
-	Description  string `json:"description"  yaml:"description"`
+	Type         string `json:"type"                   yaml:"type"`
+	Scheme       string `json:"scheme,omitempty"       yaml:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"           yaml:"in,omitempty"`
+	Name         string `json:"name,omitempty"         yaml:"name,omitempty"`
+	Description  string `json:"description"            yaml:"description"`
 }
 
 // ---------------------------------------------------------------------------
 // Spec builder
 // ---------------------------------------------------------------------------
 
-func buildSpec() OpenAPI {
-	bearerAuth := []map[string][]string{{"BearerAuth": {}}}
+func buildSpec(v routes.Version) (OpenAPI, error) {
+	paths, err := buildPaths(v)
+	if err != nil {
+		return OpenAPI{}, err
+	}
+
+	info := Info{
+		Title:       "Platform Go Challenge - Favourites API",
+		Description: "REST API for managing user favourite assets (charts, insights, audiences, dashboards).",
+		Version:     "1.0.0",
+	}
+	schemas := buildSchemas()
+	if err := addAssetSchemas(schemas); err != nil {
+		return OpenAPI{}, err
+	}
+	if v.Deprecation != nil {
+		info.Description += fmt.Sprintf(" Deprecated since %s in favour of %s: responses carry Deprecation, Sunset (once decided) and Link rel=\"successor-version\" headers.",
+			v.Deprecation.Since.Format("2006-01-02"), v.Deprecation.Successor)
+	}
+	if v.Enveloped {
+		info.Version = "2.0.0"
+		info.Description += " Successful JSON responses are wrapped as {\"data\": ...}; errors are RFC 9457 problem details (application/problem+json)."
+		schemas["Problem"] = problemSchema()
+	}
 
 	return OpenAPI{
 		OpenAPI: "3.0.3",
-		Info: Info{
-			Title:       "Platform Go Challenge - Favourites API",
-			Description: "REST API for managing user favourite assets (charts, insights, audiences).",
-			Version:     "1.0.0",
-		},
-		Paths: buildPaths(bearerAuth),
+		Info:    info,
+		Paths:   paths,
 		Components: Components{
-			Schemas:         buildSchemas(),
+			Schemas:         schemas,
 			SecuritySchemes: buildSecuritySchemes(),
 		},
+	}, nil
+}
+
+func buildPaths(v routes.Version) (map[string]*PathItem, error) {
+	docs := operationDocs()
+	paths := make(map[string]*PathItem)
+
+	// routes.Table only needs its dependencies to serve requests, so a zero
+	// Deps is enough to read the declarations.
+	for _, route := range routes.Table(routes.Deps{}) {
+		op, ok := docs[route.Name]
+		if !ok {
+			op = &Operation{Responses: map[string]Response{}}
+		}
+		delete(docs, route.Name)
+		if !v.Serves(route) {
+			continue
+		}
+
+		op.Tags = []string{routeTag(route.Path)}
+		op.Summary = route.Summary
+		op.OperationID = route.Name
+		switch route.Scope {
+		case routes.ScopeUser:
+			op.Security = []map[string][]string{{"BearerAuth": {}}, {"ApiKeyAuth": {}}, {"RequestSignature": {}}}
+		case routes.ScopeAdmin:
+			op.Security = []map[string][]string{{"BearerAuth": {}}}
+		}
+		op.Parameters = append(op.Parameters, preferParam())
+		if route.Scope == routes.ScopeUser {
+			op.Parameters = append(op.Parameters, onBehalfOfParam(), impersonateParam())
+		}
+		if route.Scope != routes.ScopeSigned && !safeMethod(route.Method) {
+			op.Parameters = append(op.Parameters, csrfParam())
+		}
+		addMiddlewareResponses(op, route)
+		op.Description = strings.ReplaceAll(op.Description, routes.APIPrefix+"/", v.Prefix+"/")
+		op.Deprecated = v.Deprecation != nil
+		if v.Enveloped {
+			envelopeResponses(op)
+		}
+
+		path := v.Prefix + route.Path
+		item, ok := paths[path]
+		if !ok {
+			item = &PathItem{}
+			paths[path] = item
+		}
+		switch route.Method {
+		case http.MethodGet:
+			item.Get = op
+		case http.MethodPost:
+			item.Post = op
+		case http.MethodPut:
+			item.Put = op
+		case http.MethodPatch:
+			item.Patch = op
+		case http.MethodDelete:
+			item.Delete = op
+		case http.MethodHead:
+			item.Head = op
+		default:
+			return nil, fmt.Errorf("route %s: unsupported method %s", route.Name, route.Method)
+		}
+	}
+
+	for name := range docs {
+		return nil, fmt.Errorf("operationDocs: %q does not match any route in routes.Table", name)
 	}
+	return paths, nil
 }
 
-func buildPaths(bearerAuth []map[string][]string) map[string]*PathItem {
-	return map[string]*PathItem{
-		"/api/v1/favourites": {
-			Get: &Operation{
-				Tags:        []string{"Favourites"},
-				Summary:     "List user favourites",
-				Description: "Returns all favourite assets for the authenticated user.",
-				OperationID: "getUserFavourites",
-				Security:    bearerAuth,
-				Responses: map[string]Response{
-					"200": {
-						Description: "A list of favourite assets",
-						Content: map[string]MediaType{
-							"application/json": {Schema: Schema{
-								Type:  "array",
-								Items: &Schema{Ref: "#/components/schemas/FavouriteAsset"},
-							}},
-						},
+// safeMethod reports whether method only reads, so it needs no CSRF token and
+// has no minimal response.
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// routeTag groups operations by the first segment of their path.
+func routeTag(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/admin"):
+		return "Admin"
+	case strings.HasPrefix(path, "/preferences"):
+		return "Preferences"
+	default:
+		return "Favourites"
+	}
+}
+
+// addMiddlewareResponses documents the responses produced by the middleware
+// every route goes through, so operationDocs only lists handler responses.
+func addMiddlewareResponses(op *Operation, route routes.Route) {
+	op.Responses["401"] = Response{Description: "Unauthorized - missing or invalid JWT"}
+	if route.Scope == routes.ScopeSigned {
+		op.Responses["401"] = Response{Description: "Unauthorized - invalid or expired signed URL"}
+	}
+	switch route.Scope {
+	case routes.ScopeUser:
+		op.Responses["403"] = Response{Description: "Forbidden - token lacks the " + route.TokenScope() + " scope (when scopes are required), a user token names another user in user_id, or a non-admin sends X-On-Behalf-Of"}
+		if _, ok := op.Responses["400"]; !ok {
+			op.Responses["400"] = Response{Description: "Bad Request - a service token did not name the user in user_id", Content: errContent()}
+		}
+	case routes.ScopeAdmin:
+		op.Responses["403"] = Response{Description: "Forbidden - caller lacks the admin role"}
+	}
+	if route.Scope != routes.ScopeSigned && !safeMethod(route.Method) {
+		op.Responses["403"] = Response{Description: op.Responses["403"].Description + "; or, for cookie sessions, X-CSRF-Token does not match the csrf_token cookie"}
+	}
+	op.Responses["406"] = Response{Description: "Not Acceptable - Accept header must include application/json", Content: errContent()}
+	if route.Method == http.MethodPost || route.Method == http.MethodPut || route.Method == http.MethodPatch {
+		op.Responses["415"] = Response{Description: "Unsupported Media Type - Content-Type must be application/json", Content: errContent()}
+	}
+	if _, ok := op.Responses["200"]; ok && !safeMethod(route.Method) {
+		op.Responses["204"] = Response{Description: "Success without a body (Prefer: return=minimal)"}
+	}
+	limit := "Too Many Requests - per-user (or per-service) rate limit exceeded (when rate limiting is configured)"
+	if route.Rate == routes.RateBulk {
+		limit = "Too Many Requests - per-user (or per-service) rate limit exceeded; bulk operations have a stricter limit (when rate limiting is configured)"
+	}
+	if route.Scope != routes.ScopeSigned {
+		limit += ", or the client is banned after repeated authentication failures (code too_many_failures, see Retry-After)"
+	}
+	op.Responses["429"] = Response{Description: limit, Content: errContent()}
+}
+
+// passthroughOperations answer with bodies that enveloped versions serve
+// unchanged: downloads are sent as attachments.
+var passthroughOperations = map[string]bool{"downloadExport": true}
+
+// envelopeResponses rewrites the v1 response shapes of op into those of an
+// enveloped version: JSON success bodies are wrapped as {"data": ...} and
+// every error response is a problem details document.
+func envelopeResponses(op *Operation) {
+	for code, resp := range op.Responses {
+		status, err := strconv.Atoi(code)
+		switch {
+		case err != nil:
+			continue
+		case status >= http.StatusBadRequest:
+			resp.Content = problemContent()
+		case passthroughOperations[op.OperationID]:
+			continue
+		default:
+			media, ok := resp.Content["application/json"]
+			if !ok {
+				continue
+			}
+			resp.Content = map[string]MediaType{
+				"application/json": {Schema: Schema{
+					Type:       "object",
+					Properties: map[string]Schema{"data": media.Schema},
+					Required:   []string{"data"},
+				}},
+			}
+		}
+		op.Responses[code] = resp
+	}
+}
+
+// operationDocs holds the hand-written part of each operation, keyed by the
+// operation ID (Route.Name) it documents. Tags, summary, security and the
+// responses produced by the shared middleware are filled in by buildPaths.
+func operationDocs() map[string]*Operation {
+	return map[string]*Operation{
+		"getUserFavourites": {
+			Description: "Returns all favourite assets for the authenticated user, optionally filtered by provenance. Timestamps are rendered in the X-Timezone header zone, else the user's stored preference, else UTC.",
+			Parameters:  append([]Parameter{timezoneParam(), renderParam()}, provenanceParams()...),
+			Responses: map[string]Response{
+				"200": {
+					Description: "A list of favourite assets",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/FavouriteAsset"},
+						}},
 					},
-					"401": {Description: "Unauthorized - missing or invalid JWT"},
-					"406": {Description: "Not Acceptable - Accept header must include application/json", Content: errContent()},
-					"500": {Description: "Internal server error", Content: errContent()},
+				},
+				"400": {Description: "Invalid X-Timezone header", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"addUserFavourite": {
+			Description: "Adds a new asset to the authenticated user's favourites. With validate_only=true the request is only parsed and validated, and the outcome is reported without storing anything.",
+			Parameters: []Parameter{{
+				Name:        "validate_only",
+				In:          "query",
+				Description: "Validate the request and return a ValidationReport instead of adding the favourite. Duplicates and quotas are not checked.",
+				Schema:      Schema{Type: "boolean"},
+			}},
+			RequestBody: &RequestBody{
+				Required:    true,
+				Description: "Asset to favourite",
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/AddFavouriteRequest"}},
 				},
 			},
-			Post: &Operation{
-				Tags:        []string{"Favourites"},
-				Summary:     "Add a favourite",
-				Description: "Adds a new asset to the authenticated user's favourites.",
-				OperationID: "addUserFavourite",
-				Security:    bearerAuth,
-				RequestBody: &RequestBody{
-					Required:    true,
-					Description: "Asset to favourite",
+			Responses: map[string]Response{
+				"200": {
+					Description: "Validation outcome (validate_only=true)",
 					Content: map[string]MediaType{
-						"application/json": {Schema: Schema{Ref: "#/components/schemas/AddFavouriteRequest"}},
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/ValidationReport"}},
 					},
 				},
-				Responses: map[string]Response{
-					"201": {
-						Description: "Favourite added",
-						Content: map[string]MediaType{
-							"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
-						},
+				"201": {
+					Description: "Favourite added",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
+					},
+				},
+				"400": {Description: "Invalid request body or validation error", Content: errContent()},
+				"202": {
+					Description: "Database unavailable; favourite queued for storage (store-and-forward enabled)",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
+					},
+				},
+				"409": {Description: "Favourite already exists, or the per-type quota is exhausted", Content: errContent()},
+				"413": {Description: "asset_data exceeds the configured maximum size", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+				"503": {Description: "Database unavailable and the write queue is full", Content: errContent()},
+			},
+		},
+		"batchUpdateUserFavourites": {
+			Description: "Validates each item and applies the valid ones in a single transaction, returning a per-item result (updated, not_found or invalid). Max 100 items.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{
+						Type:  "array",
+						Items: &Schema{Ref: "#/components/schemas/BatchDescriptionUpdate"},
+					}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Batch applied; inspect per-item results",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/BatchUpdateResponse"}},
 					},
-					"400": {Description: "Invalid request body or validation error", Content: errContent()},
-					"401": {Description: "Unauthorized"},
-					"406": {Description: "Not Acceptable - Accept header must include application/json", Content: errContent()},
-					"409": {Description: "Favourite already exists", Content: errContent()},
-					"415": {Description: "Unsupported Media Type - Content-Type must be application/json", Content: errContent()},
-					"500": {Description: "Internal server error", Content: errContent()},
 				},
+				"400": {Description: "Invalid request body, empty or oversized batch", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
 			},
 		},
-		"/api/v1/favourites/{assetID}": {
-			Patch: &Operation{
-				Tags:        []string{"Favourites"},
-				Summary:     "Update favourite description",
-				Description: "Updates the description of an existing favourite asset.",
-				OperationID: "updateUserFavourite",
-				Security:    bearerAuth,
-                Parameters:  []Parameter{assetIDParam()},
-				RequestBody: &RequestBody{
-					Required: true,
+		"importUserFavourites": {
+			Description: "Validates each item, as addUserFavourite does, and adds the valid ones in a single transaction, returning a per-item result (added, exists, quota_exceeded or invalid). Favourites of asset types without a quota are inserted with multi-row statements. Max 10000 items, within the request body limit.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{
+						Type:  "array",
+						Items: &Schema{Ref: "#/components/schemas/AddFavouriteRequest"},
+					}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Import applied; inspect per-item results",
 					Content: map[string]MediaType{
-						"application/json": {Schema: Schema{Ref: "#/components/schemas/UpdateDescriptionRequest"}},
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/ImportResponse"}},
 					},
 				},
-				Responses: map[string]Response{
-					"200": {
-						Description: "Description updated",
-						Content: map[string]MediaType{
-							"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
-						},
+				"400": {Description: "Invalid request body, empty or oversized import", Content: errContent()},
+				"413": {Description: "An item's asset_data exceeds the configured maximum size", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"removeAllUserFavourites": {
+			Description: "Removes every favourite of the authenticated user in one statement. Requires confirm=true.",
+			Parameters: []Parameter{{
+				Name:        "confirm",
+				In:          "query",
+				Description: "Must be true to confirm the removal",
+				Required:    true,
+				Schema:      Schema{Type: "boolean"},
+			}},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Favourites removed",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/RemoveAllResponse"}},
 					},
-					"400": {Description: "Invalid request body or validation error", Content: errContent()},
-					"401": {Description: "Unauthorized"},
-					"404": {Description: "Favourite not found", Content: errContent()},
-					"406": {Description: "Not Acceptable - Accept header must include application/json", Content: errContent()},
-					"415": {Description: "Unsupported Media Type - Content-Type must be application/json", Content: errContent()},
-					"500": {Description: "Internal server error", Content: errContent()},
-				},
-			},
-			Delete: &Operation{
-				Tags:        []string{"Favourites"},
-				Summary:     "Remove a favourite",
-				Description: "Removes an asset from the authenticated user's favourites.",
-				OperationID: "removeUserFavourite",
-				Security:    bearerAuth,
-                Parameters:  []Parameter{assetIDParam()},
-				Responses: map[string]Response{
-					"200": {
-						Description: "Favourite removed",
-						Content: map[string]MediaType{
-							"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
-						},
+				},
+				"400": {Description: "Missing confirm=true", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"getRecentUserFavourites": {
+			Description: "Returns the authenticated user's favourites created or updated within the window, most recently changed first. Timestamps follow the same timezone rules as the list endpoint.",
+			Parameters: []Parameter{{
+				Name:        "window",
+				In:          "query",
+				Description: "How far back to look: whole days (e.g. 7d) or a duration (e.g. 12h); at most 90d, default 7d",
+				Schema:      Schema{Type: "string"},
+			}, timezoneParam(), renderParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Recently added or updated favourites",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/FavouriteAsset"},
+						}},
+					},
+				},
+				"400": {Description: "Invalid window or X-Timezone header", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"createShareLink": {
+			Description: "Issues a signed URL that grants read-only access to the authenticated user's favourites, optionally of one asset type, until it expires. The URL is relative to the service's public address. Requires SIGNED_URL_SECRET.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/ShareRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"201": {
+					Description: "Signed URL issued",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/ShareLink"}},
 					},
-					"400": {Description: "Missing asset ID", Content: errContent()},
-					"401": {Description: "Unauthorized"},
-					"404": {Description: "Favourite not found", Content: errContent()},
-					"406": {Description: "Not Acceptable - Accept header must include application/json", Content: errContent()},
-					"500": {Description: "Internal server error", Content: errContent()},
 				},
+				"400": {Description: "Invalid request body, asset type or ttl", Content: errContent()},
+				"503": {Description: "Signed URLs are not configured", Content: errContent()},
+			},
+		},
+		"getSharedFavourites": {
+			Description: "Returns the favourites covered by a signed URL issued by POST /api/v1/favourites/share. Authorised by the signature in the query string instead of a JWT. Timestamps are in UTC.",
+			Parameters: []Parameter{
+				{Name: "user", In: "query", Description: "User whose favourites are shared", Required: true, Schema: Schema{Type: "string"}},
+				{Name: "type", In: "query", Description: "Asset type the URL is limited to", Schema: Schema{Type: "string"}},
+				{Name: "exp", In: "query", Description: "Expiry as a Unix timestamp", Required: true, Schema: Schema{Type: "integer"}},
+				{Name: "sig", In: "query", Description: "Signature over the other parameters", Required: true, Schema: Schema{Type: "string"}},
+				renderParam(),
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Shared favourites",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/FavouriteAsset"},
+						}},
+					},
+				},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"createExportJob": {
+			Description: "Queues an export of all of the authenticated user's favourites and returns immediately. Poll the status URL (also in the Location header) until the job succeeds, then fetch download_url. While a user's export is pending or running, this returns that job instead of queueing another. Jobs are held by the instance that accepted them.",
+			Responses: map[string]Response{
+				"202": {
+					Description: "Export queued",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/ExportJob"}},
+					},
+				},
+				"500": {Description: "Internal server error", Content: errContent()},
+				"503": {Description: "Export queue is full, or exports are not enabled", Content: errContent()},
+			},
+		},
+		"getExportJob": {
+			Description: "Returns the status of one of the authenticated user's export jobs. download_url is set once the job has succeeded; finished jobs are kept until expires_at.",
+			Parameters:  []Parameter{jobIDParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Export job status",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/ExportJob"}},
+					},
+				},
+				"404": {Description: "Job not found, expired, or owned by another user", Content: errContent()},
+				"503": {Description: "Exports are not enabled", Content: errContent()},
+			},
+		},
+		"downloadExport": {
+			Description: "Downloads the file produced by a succeeded export job: a JSON array of favourites in the shape of GET /api/v1/favourites, with UTC timestamps. Supports Range requests.",
+			Parameters:  []Parameter{jobIDParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "The exported favourites",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/FavouriteAsset"},
+						}},
+					},
+				},
+				"404": {Description: "Job not found, expired, or owned by another user", Content: errContent()},
+				"409": {Description: "The job has not succeeded (still pending, running, or failed)", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+				"503": {Description: "Exports are not enabled", Content: errContent()},
+			},
+		},
+		"getUserQuota": {
+			Description: "Returns the authenticated user's favourites count per asset type alongside the configured limits. limit and remaining are null for unlimited types.",
+			Responses: map[string]Response{
+				"200": {
+					Description: "Quota breakdown",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/QuotaReport"}},
+					},
+				},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"getUserStats": {
+			Description: "Returns counts per asset type, first/last favourite timestamps and how many of the current favourites were added in the last 30 days. Timestamps follow the same timezone rules as the list endpoint.",
+			Parameters:  []Parameter{timezoneParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Favourites statistics",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/FavouriteStats"}},
+					},
+				},
+				"400": {Description: "Invalid X-Timezone header", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"getUserAudit": {
+			Description: "Returns the recorded adds, updates and deletes of the authenticated user's favourites, newest first. actor is whoever made the change (e.g. an admin).",
+			Parameters:  []Parameter{auditLimitParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Audit entries",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/AuditEntry"},
+						}},
+					},
+				},
+				"400": {Description: "Invalid limit", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"updateUserFavourite": {
+			Description: "Updates the description of an existing favourite asset.",
+			Parameters:  []Parameter{assetIDParam()},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/UpdateDescriptionRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Description updated",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
+					},
+				},
+				"400": {Description: "Invalid request body or validation error", Content: errContent()},
+				"404": {Description: "Favourite not found", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"replaceFavouriteAssetData": {
+			Description: "Replaces the asset data of an existing favourite. The payload must be of the favourite's asset type and keep its ID; the replaced data is kept as a version (see getFavouriteVersions).",
+			Parameters:  []Parameter{assetIDParam()},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/ReplaceAssetDataRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Asset data replaced",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/VersionResult"}},
+					},
+				},
+				"400": {Description: "Invalid request body or validation error", Content: errContent()},
+				"404": {Description: "Favourite not found", Content: errContent()},
+				"413": {Description: "asset_data exceeds the configured maximum size", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"getFavouriteVersions": {
+			Description: "Lists the asset data a favourite held before it was replaced or reverted, newest first.",
+			Parameters:  []Parameter{assetIDParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Version history",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/VersionHistory"}},
+					},
+				},
+				"400": {Description: "Invalid asset ID", Content: errContent()},
+				"404": {Description: "Favourite not found", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"revertFavouriteVersion": {
+			Description: "Makes the asset data of an earlier version the favourite's data. The data it replaces is kept as a new version, so a revert can be reverted too.",
+			Parameters: []Parameter{assetIDParam(), {
+				Name:        "version",
+				In:          "path",
+				Description: "Version number from getFavouriteVersions",
+				Required:    true,
+				Schema:      Schema{Type: "integer"},
+			}},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Asset data reverted",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/VersionResult"}},
+					},
+				},
+				"400": {Description: "Invalid asset ID or version", Content: errContent()},
+				"404": {Description: "Favourite or version not found", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"favouriteExists": {
+			Description: "Checks whether the authenticated user has a favourite with the asset ID, without reading its asset data. The response has no body.",
+			Parameters:  []Parameter{assetIDParam()},
+			Responses: map[string]Response{
+				"200": {Description: "The favourite exists"},
+				"400": {Description: "Invalid asset ID"},
+				"404": {Description: "The favourite does not exist"},
+				"500": {Description: "Internal server error"},
+			},
+		},
+		"removeUserFavourite": {
+			Description: "Removes an asset from the authenticated user's favourites.",
+			Parameters:  []Parameter{assetIDParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Favourite removed",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
+					},
+				},
+				"400": {Description: "Missing asset ID", Content: errContent()},
+				"404": {Description: "Favourite not found", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"subscribeEvents": {
+			Description: "Upgrades to a WebSocket (send the usual Authorization and Accept headers with the handshake) that carries the authenticated user's favourite change events as JSON text messages: {type, user_id, actor, asset_id, asset_type, reason, changes, occurred_at}, with type one of favourite.added, favourite.updated or favourite.removed. The server pings every 30s and closes connections that do not answer within 10s, closes clients that fall behind with 1008, and closes every stream with 1001 when shutting down.",
+			Responses: map[string]Response{
+				"101": {Description: "Switching Protocols - the WebSocket is open"},
+				"426": {Description: "Upgrade Required - the request is not a WebSocket handshake"},
+				"503": {Description: "Event streaming is not enabled", Content: errContent()},
+			},
+		},
+		"getUserPreferences": {
+			Description: "Returns the authenticated user's preferences. timezone defaults to UTC.",
+			Responses: map[string]Response{
+				"200": {
+					Description: "User preferences",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/UserPreferences"}},
+					},
+				},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"updateUserPreferences": {
+			Description: "Stores the authenticated user's preferences. timezone must be an IANA timezone name.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/UserPreferences"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Preferences updated",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/UserPreferences"}},
+					},
+				},
+				"400": {Description: "Invalid request body or unknown timezone", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"searchUsers": {
+			Description: "Lists users that have favourites and whose ID starts with q, ordered by user ID, with their favourites count. Admin only.",
+			Parameters: []Parameter{{
+				Name:        "q",
+				In:          "query",
+				Description: "User ID prefix (empty matches every user; at most 64 characters)",
+				Schema:      Schema{Type: "string"},
+			}, {
+				Name:        "limit",
+				In:          "query",
+				Description: "Maximum number of users to return (1-500, default 50)",
+				Schema:      Schema{Type: "integer"},
+			}},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Matching users",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/UserSummary"},
+						}},
+					},
+				},
+				"400": {Description: "Invalid limit", Content: errContent()},
+				"422": {Description: "Query rejected: q longer than 64 characters, or the search exceeded its 2s time budget", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"getAdminUserFavourites": {
+			Description: "Returns all favourite assets of the given user, with UTC timestamps. Admin only.",
+			Parameters:  []Parameter{userIDParam(), renderParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "A list of favourite assets",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/FavouriteAsset"},
+						}},
+					},
+				},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"mergeUserFavourites": {
+			Description: "Copies every favourite of source_user_id that the given user does not already have. The source user's favourites are kept and quotas are not applied. An add event is published for each copied favourite. Admin only.",
+			Parameters:  []Parameter{userIDParam()},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/MergeFavouritesRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Favourites merged",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/MergeFavouritesResponse"}},
+					},
+				},
+				"400": {Description: "Invalid request body, missing source or source equals target", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"listAPIKeys": {
+			Description: "Returns the API keys issued to the given user, oldest first, revoked ones included. The keys themselves are never returned. Admin only.",
+			Parameters:  []Parameter{userIDParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "API keys",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/APIKey"},
+						}},
+					},
+				},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"createAPIKey": {
+			Description: "Issues an API key bound to the given user. Service clients send it in the X-API-Key header instead of a bearer token and act as that user, with the favourites:read and favourites:write scopes but no access to admin endpoints. The key is only returned in this response. Admin only.",
+			Parameters:  []Parameter{userIDParam()},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/CreateAPIKeyRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"201": {
+					Description: "API key issued",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/CreatedAPIKey"}},
+					},
+				},
+				"400": {Description: "Invalid request body, missing or overlong name", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"revokeAPIKey": {
+			Description: "Revokes an API key of the given user; requests using it are rejected from then on. Admin only.",
+			Parameters: []Parameter{userIDParam(), {
+				Name:        "keyID",
+				In:          "path",
+				Description: "ID of the API key",
+				Required:    true,
+				Schema:      Schema{Type: "string"},
+			}},
+			Responses: map[string]Response{
+				"200": {
+					Description: "API key revoked",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/SuccessMessage"}},
+					},
+				},
+				"404": {Description: "The user has no active API key with this ID", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"revokeToken": {
+			Description: "Adds a token ID (the jti claim) to the denylist, so requests bearing that token get 401 with code token_revoked. The entry lasts until expires_at, the token's own expiry, or for the configured revocation_ttl when it is not given. Admin only.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/RevokeTokenRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"201": {
+					Description: "Token revoked",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/TokenRevocation"}},
+					},
+				},
+				"400": {Description: "Invalid request body, missing jti or past expires_at", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+				"503": {Description: "Token revocation is not enabled", Content: errContent()},
+			},
+		},
+		"getAdminUserAudit": {
+			Description: "Returns the recorded changes to the given user's favourites, newest first. Admin only.",
+			Parameters:  []Parameter{userIDParam(), auditLimitParam()},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Audit entries",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{
+							Type:  "array",
+							Items: &Schema{Ref: "#/components/schemas/AuditEntry"},
+						}},
+					},
+				},
+				"400": {Description: "Invalid limit", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"getWriteQueueStats": {
+			Description: "Reports how many favourites are waiting in the store-and-forward queue. A disabled queue reports zero depth and capacity. Admin only.",
+			Responses: map[string]Response{
+				"200": {
+					Description: "Queue statistics",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/WriteQueueStats"}},
+					},
+				},
+			},
+		},
+		"getLogLevel": {
+			Description: "Reports the minimum level of the entries this instance logs. Admin only.",
+			Responses: map[string]Response{
+				"200": {
+					Description: "Log level",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/LogLevel"}},
+					},
+				},
+			},
+		},
+		"setLogLevel": {
+			Description: "Changes the minimum level of the entries this instance logs, without a restart, e.g. to debug while investigating it. The change lasts until changed again or the instance restarts, which applies the configured log_level; other instances are unaffected. Admin only.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/LogLevelRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Log level changed",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/LogLevel"}},
+					},
+				},
+				"400": {Description: "Invalid request body or unknown level", Content: errContent()},
+			},
+		},
+		"assetOwnership": {
+			Description: "Reports which users have the given assets favourited. When remove is true the favourites are deleted and a removal event is published for each affected user. Admin only.",
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/AssetOwnershipRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Ownership report",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/AssetOwnershipReport"}},
+					},
+				},
+				"400": {Description: "Invalid request body or validation error", Content: errContent()},
+				"422": {Description: "Query rejected: the report exceeded its 5s time budget; request fewer asset_ids", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
+			},
+		},
+		"updateCatalogAsset": {
+			Description: "Replaces the catalog entry of an asset. Every favourite referencing the entry (stored with asset_storage \"normalized\") shows the new data, and an update event is published for each of them. Favourites holding their own copy are unaffected. Admin only.",
+			Parameters: []Parameter{
+				{Name: "assetType", In: "path", Description: "Asset type of the catalog entry", Required: true, Schema: Schema{Type: "string", Enum: assetTypeEnum()}},
+				{Name: "assetID", In: "path", Description: "Asset ID of the catalog entry", Required: true, Schema: Schema{Type: "string"}},
+			},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]MediaType{
+					"application/json": {Schema: Schema{Ref: "#/components/schemas/ReplaceAssetDataRequest"}},
+				},
+			},
+			Responses: map[string]Response{
+				"200": {
+					Description: "Catalog entry updated",
+					Content: map[string]MediaType{
+						"application/json": {Schema: Schema{Ref: "#/components/schemas/CatalogUpdateResult"}},
+					},
+				},
+				"400": {Description: "Unknown asset type, invalid request body or validation error", Content: errContent()},
+				"404": {Description: "Asset not found in catalog", Content: errContent()},
+				"413": {Description: "asset_data exceeds the configured maximum size", Content: errContent()},
+				"500": {Description: "Internal server error", Content: errContent()},
 			},
 		},
 	}
@@ -257,7 +1027,132 @@ func assetIDParam() Parameter {
 	}
 }
 
+func jobIDParam() Parameter {
+	return Parameter{
+		Name:        "jobID",
+		In:          "path",
+		Description: "Export job ID returned by POST /api/v1/favourites/export-jobs",
+		Required:    true,
+		Schema:      Schema{Type: "string"},
+	}
+}
+
+func preferParam() Parameter {
+	return Parameter{
+		Name:        "Prefer",
+		In:          "header",
+		Description: "RFC 7240 preferences: return=minimal (writes answer without a body), wait=<seconds> (shorter request deadline), handling=strict|lenient (reject or ignore unknown body fields). Applied ones are echoed in Preference-Applied.",
+		Schema:      Schema{Type: "string"},
+	}
+}
+
+func onBehalfOfParam() Parameter {
+	return Parameter{
+		Name:        auth.OnBehalfOfParam,
+		In:          "query",
+		Description: "User a service token acts on behalf of; required for service tokens. User tokens may only name themselves.",
+		Schema:      Schema{Type: "string"},
+	}
+}
+
+func impersonateParam() Parameter {
+	return Parameter{
+		Name:        auth.OnBehalfOfHeader,
+		In:          "header",
+		Description: "User an admin acts on behalf of; the audit log records both identities. Only tokens with the admin role may send it.",
+		Schema:      Schema{Type: "string"},
+	}
+}
+
+func csrfParam() Parameter {
+	return Parameter{
+		Name:        auth.CSRFHeader,
+		In:          "header",
+		Description: "Value of the " + auth.CSRFCookie + " cookie; required when authenticating with the session cookie.",
+		Schema:      Schema{Type: "string"},
+	}
+}
+
+func userIDParam() Parameter {
+	return Parameter{
+		Name:        "userID",
+		In:          "path",
+		Description: "User the admin operation applies to",
+		Required:    true,
+		Schema:      Schema{Type: "string"},
+	}
+}
+
+func timezoneParam() Parameter {
+	return Parameter{
+		Name:        "X-Timezone",
+		In:          "header",
+		Description: "IANA timezone (e.g. Europe/Athens) overriding the stored preference",
+		Schema:      Schema{Type: "string"},
+	}
+}
 
+// provenanceParams are the exact-match provenance filters of the favourites list.
+func provenanceParams() []Parameter {
+	var params []Parameter
+	for _, name := range []string{"source_system", "source_url", "favourited_from"} {
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: "Only return favourites whose " + name + " equals this value",
+			Schema:      Schema{Type: "string"},
+		})
+	}
+	return params
+}
+
+// descriptionSchema documents a favourite description with the configured
+// maximum length.
+func descriptionSchema(description string) Schema {
+	limit := assets.CurrentTextLimits().Description
+	return Schema{
+		Type:        "string",
+		Description: description + ". Accepts a Markdown subset (paragraphs, - lists, **bold**, *italic*, `code`, [links](https://...)); HTML tags are stripped",
+		MaxLength:   &limit,
+	}
+}
+
+// renderParam selects whether list responses include rendered descriptions.
+func renderParam() Parameter {
+	return Parameter{
+		Name:        "render",
+		In:          "query",
+		Description: "Set to html to include each description rendered as sanitized HTML in description_html",
+		Schema:      Schema{Type: "string", Enum: []string{"html"}},
+	}
+}
+
+// provenanceProperties are the optional provenance fields of a favourite.
+func provenanceProperties() map[string]Schema {
+	return map[string]Schema{
+		"source_system": {Type: "string", Description: "System the asset came from (max 255 chars)"},
+		"source_url":    {Type: "string", Format: "uri", Description: "Absolute http(s) URL of the asset in its source system (max 2048 chars)"},
+		"favourited_from": {
+			Type:        "string",
+			Description: "UI surface the favourite was added from, e.g. dashboard or search (max 64 chars of a-z, 0-9, '-' and '_')",
+		},
+	}
+}
+
+// withProperties returns props with extra merged in.
+func withProperties(props, extra map[string]Schema) map[string]Schema {
+	maps.Copy(props, extra)
+	return props
+}
+
+func auditLimitParam() Parameter {
+	return Parameter{
+		Name:        "limit",
+		In:          "query",
+		Description: "Maximum number of entries to return (1-1000, default 100)",
+		Schema:      Schema{Type: "integer"},
+	}
+}
 
 func errContent() map[string]MediaType {
 	return map[string]MediaType{
@@ -265,6 +1160,68 @@ func errContent() map[string]MediaType {
 	}
 }
 
+// assetTypeEnum lists the registered asset types.
+func assetTypeEnum() []string {
+	var names []string
+	for _, name := range assets.Names() {
+		names = append(names, string(name))
+	}
+	return names
+}
+
+// assetSchemaName is the component name of an asset type's payload schema,
+// e.g. "Chart" for "chart".
+func assetSchemaName(name models.AssetType) string {
+	return strings.ToUpper(string(name[:1])) + string(name[1:])
+}
+
+// assetPayloadRefs references the payload schema of every registered asset type.
+func assetPayloadRefs() []Schema {
+	var refs []Schema
+	for _, name := range assets.Names() {
+		refs = append(refs, Schema{Ref: "#/components/schemas/" + assetSchemaName(name)})
+	}
+	return refs
+}
+
+// addAssetSchemas adds the payload schema each asset type registered. Keywords
+// Schema cannot represent are rejected rather than dropped from the spec.
+func addAssetSchemas(schemas map[string]Schema) error {
+	for _, t := range assets.Types() {
+		var schema Schema
+		dec := json.NewDecoder(bytes.NewReader(t.Schema()))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&schema); err != nil {
+			return fmt.Errorf("asset type %s: invalid schema: %w", t.Name, err)
+		}
+		schemas[assetSchemaName(t.Name)] = schema
+	}
+	return nil
+}
+
+// problemContent returns the error response content of enveloped versions.
+func problemContent() map[string]MediaType {
+	return map[string]MediaType{
+		"application/problem+json": {Schema: Schema{Ref: "#/components/schemas/Problem"}},
+	}
+}
+
+// problemSchema describes routes.Problem.
+func problemSchema() Schema {
+	return Schema{
+		Type:        "object",
+		Description: "RFC 9457 problem details",
+		Properties: map[string]Schema{
+			"type":     {Type: "string", Description: "Problem type URI; about:blank for plain HTTP errors", Example: "about:blank"},
+			"title":    {Type: "string", Description: "HTTP status text", Example: "Bad Request"},
+			"status":   {Type: "integer", Description: "HTTP status code", Example: 400},
+			"detail":   {Type: "string", Description: "Human-readable error message"},
+			"instance": {Type: "string", Description: "Request path", Example: "/api/v2/favourites"},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+}
+
 func buildSecuritySchemes() map[string]SecurityScheme {
 	return map[string]SecurityScheme{
 		"BearerAuth": {
@@ -273,6 +1230,18 @@ func buildSecuritySchemes() map[string]SecurityScheme {
 			BearerFormat: "JWT",
 			Description:  "JWT token with a 'sub' claim identifying the user.",
 		},
+		"ApiKeyAuth": {
+			Type:        "apiKey",
+			In:          "header",
+			Name:        "X-API-Key",
+			Description: "API key issued by an admin to a service client; not accepted by admin endpoints.",
+		},
+		"RequestSignature": {
+			Type:        "apiKey",
+			In:          "header",
+			Name:        "X-Signature",
+			Description: "Hex HMAC-SHA256 of the X-Signature-Timestamp value, method, path with query and body (newline-separated), keyed with the partner secret named in X-Signature-Key; not accepted by admin endpoints.",
+		},
 	}
 }
 
@@ -292,110 +1261,373 @@ func buildSchemas() map[string]Schema {
 			},
 			Required: []string{"message"},
 		},
+		"BatchDescriptionUpdate": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"asset_id":    {Type: "string"},
+				"description": descriptionSchema("New description"),
+			},
+			Required: []string{"asset_id", "description"},
+		},
+		"BatchUpdateResponse": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"updated": {Type: "integer", Description: "Number of favourites updated"},
+				"results": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]Schema{
+							"asset_id": {Type: "string"},
+							"status":   {Type: "string", Enum: []string{"updated", "not_found", "invalid"}},
+							"error":    {Type: "string"},
+						},
+						Required: []string{"asset_id", "status"},
+					},
+				},
+			},
+			Required: []string{"updated", "results"},
+		},
+		"ImportResponse": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"added": {Type: "integer", Description: "Number of favourites added"},
+				"results": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]Schema{
+							"asset_id": {Type: "string", Description: "Omitted when the asset data could not be parsed"},
+							"status":   {Type: "string", Enum: []string{"added", "exists", "quota_exceeded", "invalid"}},
+							"error":    {Type: "string"},
+						},
+						Required: []string{"status"},
+					},
+				},
+			},
+			Required: []string{"added", "results"},
+		},
+		"QuotaReport": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"total": {Type: "integer", Description: "Total favourites of the user"},
+				"types": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]Schema{
+							"asset_type": {Type: "string"},
+							"used":       {Type: "integer"},
+							"limit":      {Type: "integer", Description: "null when unlimited"},
+							"remaining":  {Type: "integer", Description: "null when unlimited"},
+						},
+						Required: []string{"asset_type", "used", "limit", "remaining"},
+					},
+				},
+			},
+			Required: []string{"total", "types"},
+		},
+		"FavouriteStats": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"total":              {Type: "integer"},
+				"first_added_at":     {Type: "string", Format: "date-time", Description: "null when the user has no favourites"},
+				"last_added_at":      {Type: "string", Format: "date-time", Description: "null when the user has no favourites"},
+				"added_last_30_days": {Type: "integer"},
+				"types": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]Schema{
+							"asset_type":         {Type: "string"},
+							"count":              {Type: "integer"},
+							"first_added_at":     {Type: "string", Format: "date-time"},
+							"last_added_at":      {Type: "string", Format: "date-time"},
+							"added_last_30_days": {Type: "integer"},
+						},
+						Required: []string{"asset_type", "count", "first_added_at", "last_added_at", "added_last_30_days"},
+					},
+				},
+			},
+			Required: []string{"total", "first_added_at", "last_added_at", "added_last_30_days", "types"},
+		},
+		"AuditEntry": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"id":          {Type: "integer"},
+				"user_id":     {Type: "string", Description: "Owner of the favourite"},
+				"actor":       {Type: "string", Description: "User who made the change"},
+				"action":      {Type: "string", Enum: []string{"add", "update", "delete"}},
+				"asset_id":    {Type: "string"},
+				"diff":        {Type: "object", Description: "New values of the fields the change set"},
+				"occurred_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"id", "user_id", "actor", "action", "asset_id", "occurred_at"},
+		},
+		"WriteQueueStats": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"depth":            {Type: "integer", Description: "Favourites waiting to be stored"},
+				"capacity":         {Type: "integer"},
+				"oldest_queued_at": {Type: "string", Format: "date-time", Description: "null when the queue is empty"},
+			},
+			Required: []string{"depth", "capacity", "oldest_queued_at"},
+		},
+		"ShareRequest": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"asset_type": {Type: "string", Enum: assetTypeEnum(), Description: "Limit the URL to one asset type (default: all)"},
+				"ttl":        {Type: "string", Description: "Validity as a duration, e.g. 30m (default 15m, max 24h)"},
+			},
+		},
+		"ShareLink": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"url":        {Type: "string", Description: "Signed URL relative to the service's public address"},
+				"asset_type": {Type: "string"},
+				"expires_at": {Type: "string", Format: "date-time"},
+			},
+			Required: []string{"url", "expires_at"},
+		},
+		"ExportJob": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"id":           {Type: "string"},
+				"status":       {Type: "string", Enum: []string{"pending", "running", "succeeded", "failed"}},
+				"count":        {Type: "integer", Description: "Number of favourites exported (once succeeded)"},
+				"error":        {Type: "string", Description: "Why the job failed"},
+				"created_at":   {Type: "string", Format: "date-time"},
+				"completed_at": {Type: "string", Format: "date-time"},
+				"expires_at":   {Type: "string", Format: "date-time", Description: "When a finished job and its file are removed"},
+				"status_url":   {Type: "string"},
+				"download_url": {Type: "string", Description: "Set once the job has succeeded"},
+			},
+			Required: []string{"id", "status", "count", "created_at", "status_url"},
+		},
+		"UserSummary": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"user_id":    {Type: "string"},
+				"favourites": {Type: "integer", Description: "Number of favourites the user has"},
+			},
+			Required: []string{"user_id", "favourites"},
+		},
+		"APIKey": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"id":         {Type: "string"},
+				"user_id":    {Type: "string"},
+				"name":       {Type: "string"},
+				"created_at": {Type: "string", Format: "date-time"},
+				"revoked_at": {Type: "string", Format: "date-time", Description: "Absent while the key is active"},
+			},
+		},
+		"CreateAPIKeyRequest": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"name": {Type: "string", Description: "Label identifying the client, up to 100 characters"},
+			},
+			Required: []string{"name"},
+		},
+		"CreatedAPIKey": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"id":         {Type: "string"},
+				"user_id":    {Type: "string"},
+				"name":       {Type: "string"},
+				"created_at": {Type: "string", Format: "date-time"},
+				"key":        {Type: "string", Description: "The API key; store it now, it cannot be retrieved again"},
+			},
+		},
+		"RevokeTokenRequest": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"jti":        {Type: "string", Description: "ID of the token to revoke, up to 256 characters"},
+				"expires_at": {Type: "string", Format: "date-time", Description: "The token's expiry; the revocation lasts until then"},
+			},
+			Required: []string{"jti"},
+		},
+		"LogLevelRequest": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"level": {Type: "string", Description: "debug, info, warn or error, in any case"},
+			},
+			Required: []string{"level"},
+		},
+		"LogLevel": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"level": {Type: "string", Description: "DEBUG, INFO, WARN or ERROR"},
+			},
+			Required: []string{"level"},
+		},
+		"TokenRevocation": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"jti":           {Type: "string"},
+				"revoked_until": {Type: "string", Format: "date-time"},
+			},
+		},
+		"MergeFavouritesRequest": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"source_user_id": {Type: "string", Description: "User whose favourites are copied"},
+			},
+			Required: []string{"source_user_id"},
+		},
+		"MergeFavouritesResponse": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"merged":    {Type: "integer", Description: "Number of favourites copied"},
+				"asset_ids": {Type: "array", Items: &Schema{Type: "string"}},
+			},
+			Required: []string{"merged", "asset_ids"},
+		},
+		"UserPreferences": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"timezone": {Type: "string", Description: "IANA timezone name used to render timestamps", Example: "Europe/Athens"},
+			},
+			Required: []string{"timezone"},
+		},
+		"RemoveAllResponse": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"message": {Type: "string", Description: "Success message"},
+				"deleted": {Type: "integer", Description: "Number of favourites removed"},
+			},
+			Required: []string{"message", "deleted"},
+		},
 		"AddFavouriteRequest": {
 			Type:        "object",
 			Description: "Payload for adding a favourite asset. The asset_data shape depends on asset_type.",
-			Properties: map[string]Schema{
+			Properties: withProperties(map[string]Schema{
 				"asset_type": {
-					Type: "string",
-					Enum: []string{"chart", "insight", "audience"},
-					Description: "Type of asset being favourited",
-				},
-				"description": {
 					Type:        "string",
-					Description: "Optional description for the favourite (max 255 chars)",
+					Enum:        assetTypeEnum(),
+					Description: "Type of asset being favourited",
 				},
+				"description": descriptionSchema("Optional description for the favourite"),
 				"asset_data": {
-					Description: "Asset payload - one of Chart, Insight or Audience",
-					OneOf: []Schema{
-						{Ref: "#/components/schemas/Chart"},
-						{Ref: "#/components/schemas/Insight"},
-						{Ref: "#/components/schemas/Audience"},
-					},
+					Description: "Asset payload, in the schema of its asset_type",
+					OneOf:       assetPayloadRefs(),
 				},
-			},
+			}, provenanceProperties()),
 			Required: []string{"asset_type", "asset_data"},
 		},
 		"UpdateDescriptionRequest": {
 			Type: "object",
 			Properties: map[string]Schema{
-				"description": {Type: "string", Description: "New description (max 255 chars)"},
+				"description": descriptionSchema("New description"),
 			},
 			Required: []string{"description"},
 		},
-		"FavouriteAsset": {
-			Type:        "object",
-			Description: "A user's favourited asset with metadata.",
+		"ReplaceAssetDataRequest": {
+			Type: "object",
 			Properties: map[string]Schema{
-				"id":          {Type: "string"},
-				"user_id":     {Type: "string"},
-				"asset_type":  {Type: "string", Enum: []string{"chart", "insight", "audience"}},
-				"description": {Type: "string"},
-				"created_at":  {Type: "string", Format: "date-time"},
-				"updated_at":  {Type: "string", Format: "date-time"},
-				"data": {
-					Description: "The full asset object",
-					OneOf: []Schema{
-						{Ref: "#/components/schemas/Chart"},
-						{Ref: "#/components/schemas/Insight"},
-						{Ref: "#/components/schemas/Audience"},
-					},
+				"asset_data": {
+					Description: "New asset payload, in the schema of the favourite's asset_type and with its ID",
+					OneOf:       assetPayloadRefs(),
 				},
 			},
-			Required: []string{"id", "user_id", "asset_type", "created_at", "updated_at", "data"},
+			Required: []string{"asset_data"},
 		},
-		"Chart": {
-			Type:        "object",
-			Description: "A chart asset.",
+		"VersionResult": {
+			Type: "object",
 			Properties: map[string]Schema{
-				"id":           {Type: "string"},
-				"title":        {Type: "string"},
-				"x_axis_title": {Type: "string"},
-				"y_axis_title": {Type: "string"},
-				"data": {
-					Type:                 "object",
-					AdditionalProperties: &Schema{},
-					Description:          "Arbitrary chart data points",
-				},
+				"asset_id":        {Type: "string"},
+				"current_version": {Type: "integer", Description: "Version number of the favourite's asset data now"},
 			},
-			Required: []string{"id", "title", "x_axis_title", "y_axis_title"},
 		},
-		"Insight": {
-			Type:        "object",
-			Description: "An insight asset.",
+		"VersionHistory": {
+			Type: "object",
 			Properties: map[string]Schema{
-				"id":   {Type: "string"},
-				"text": {Type: "string"},
+				"asset_id":        {Type: "string"},
+				"current_version": {Type: "integer", Description: "Version number of the current asset data (1 if it was never replaced)"},
+				"versions": {
+					Type:        "array",
+					Description: "Earlier asset data, newest first",
+					Items:       &Schema{Ref: "#/components/schemas/FavouriteVersion"},
+				},
 			},
-			Required: []string{"id", "text"},
 		},
-		"Audience": {
-			Type:        "object",
-			Description: "An audience segment asset.",
+		"FavouriteVersion": {
+			Type: "object",
 			Properties: map[string]Schema{
-				"id": {Type: "string"},
-				"gender": {
-					Type:  "array",
-					Items: &Schema{Type: "string", Enum: []string{"Male", "Female"}},
+				"version": {Type: "integer"},
+				"data": {
+					Description: "The asset data of this version",
+					OneOf:       assetPayloadRefs(),
 				},
-				"birth_country": {
-					Type:  "array",
-					Items: &Schema{Type: "string"},
+				"replaced_at": {Type: "string", Format: "date-time", Description: "When this data stopped being current"},
+			},
+		},
+		"AssetOwnershipRequest": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"asset_ids": {
+					Type:        "array",
+					Items:       &Schema{Type: "string"},
+					Description: "Asset IDs to report on (max 1000)",
 				},
-				"age_groups": {
-					Type:  "array",
-					Items: &Schema{Type: "string", Enum: []string{"18-24", "25-34", "35-44", "45-54", "55+"}},
+				"remove": {Type: "boolean", Description: "Also remove the assets from every user's favourites"},
+			},
+			Required: []string{"asset_ids"},
+		},
+		"AssetOwnershipReport": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"assets": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]Schema{
+							"asset_id": {Type: "string"},
+							"users":    {Type: "array", Items: &Schema{Type: "string"}},
+						},
+					},
 				},
-				"social_media_hours_daily": {
-					Type: "string",
-					Enum: []string{"0-1", "1-3", "3-5", "5+"},
+				"removed": {Type: "integer", Description: "Number of favourites removed"},
+			},
+			Required: []string{"assets", "removed"},
+		},
+		"ValidationReport": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"valid":  {Type: "boolean"},
+				"errors": {Type: "array", Items: &Schema{Type: "string"}, Description: "Every validation failure; absent when valid"},
+			},
+			Required: []string{"valid"},
+		},
+		"CatalogUpdateResult": {
+			Type: "object",
+			Properties: map[string]Schema{
+				"asset_type": {Type: "string", Enum: assetTypeEnum()},
+				"asset_id":   {Type: "string"},
+				"favourites": {Type: "integer", Description: "Number of favourites referencing the entry"},
+			},
+			Required: []string{"asset_type", "asset_id", "favourites"},
+		},
+		"FavouriteAsset": {
+			Type:        "object",
+			Description: "A user's favourited asset with metadata.",
+			Properties: withProperties(map[string]Schema{
+				"id":          {Type: "string"},
+				"user_id":     {Type: "string"},
+				"asset_type":  {Type: "string", Enum: assetTypeEnum()},
+				"description": {Type: "string"},
+				"description_html": {
+					Type:        "string",
+					Description: "The description rendered as sanitized HTML; only present when requested with ?render=html",
 				},
-				"purchases_last_month": {
-					Type:        "integer",
-					Description: "Must be non-negative",
+				"created_at": {Type: "string", Format: "date-time"},
+				"updated_at": {Type: "string", Format: "date-time"},
+				"data": {
+					Description: "The full asset object",
+					OneOf:       assetPayloadRefs(),
 				},
-			},
-			Required: []string{"id"},
+			}, provenanceProperties()),
+			Required: []string{"id", "user_id", "asset_type", "created_at", "updated_at", "data"},
 		},
 	}
 }
@@ -421,7 +1653,39 @@ func writeYAML(spec OpenAPI, path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// applyConfig makes the spec document the audience enumerations, text field
+// lengths and opt-in asset types of the config file at path.
+func applyConfig(path string) error {
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	enums, err := cfg.AudienceEnumConfig()
+	if err != nil {
+		return err
+	}
+	assets.SetAudienceEnums(enums)
+	limits, err := cfg.TextLimitConfig()
+	if err != nil {
+		return err
+	}
+	assets.SetTextLimits(limits)
+	if cfg.EnableGenericAssets {
+		return assets.Enable(models.AssetTypeGeneric)
+	}
+	return nil
+}
+
 func main() {
+	configPath := flag.String("config", "", "config file whose audience_enums, max_text_lengths and opt-in asset types to document (default: built-in values)")
+	flag.Parse()
+	if *configPath != "" {
+		if err := applyConfig(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error applying %s: %v\n", *configPath, err)
+			os.Exit(1)
+		}
+	}
+
 	_, src, _, _ := runtime.Caller(0)
 	outDir := filepath.Join(filepath.Join(filepath.Dir(src), "..", ".."), "api")
 
@@ -430,19 +1694,35 @@ func main() {
 		os.Exit(1)
 	}
 
-	spec := buildSpec()
+	for _, v := range routes.Versions(routes.Deps{}) {
+		// v1 keeps its original location so existing links stay valid.
+		dir := outDir
+		if v.Prefix != routes.APIPrefix {
+			dir = filepath.Join(outDir, v.Name)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create %s directory: %v\n", dir, err)
+			os.Exit(1)
+		}
 
-	jsonPath := filepath.Join(outDir, "swagger.json")
-	if err := writeJSON(spec, jsonPath); err != nil {
-		fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
-		os.Exit(1)
-	}
+		spec, err := buildSpec(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error building %s spec: %v\n", v.Name, err)
+			os.Exit(1)
+		}
 
-	yamlPath := filepath.Join(outDir, "swagger.yaml")
-	if err := writeYAML(spec, yamlPath); err != nil {
-		fmt.Fprintf(os.Stderr, "error writing YAML: %v\n", err)
-		os.Exit(1)
-	}
+		jsonPath := filepath.Join(dir, "swagger.json")
+		if err := writeJSON(spec, jsonPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+
+		yamlPath := filepath.Join(dir, "swagger.yaml")
+		if err := writeYAML(spec, yamlPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing YAML: %v\n", err)
+			os.Exit(1)
+		}
 
-	fmt.Printf("Swagger specs generated:\n  %s\n  %s\n", jsonPath, yamlPath)
+		fmt.Printf("Swagger %s specs generated:\n  %s\n  %s\n", v.Name, jsonPath, yamlPath)
+	}
 }